@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// a11yTreeTimeout bounds capturing the accessibility tree of a single page.
+const a11yTreeTimeout = 10 * time.Second
+
+// captureA11yTree returns Playwright's ARIA snapshot of the page body: a YAML outline of roles,
+// accessible names and text, e.g. `- heading "Install" [level=2]`.
+func captureA11yTree(page playwright.Page) (string, error) {
+	snapshot, err := page.Locator("body").AriaSnapshot(playwright.LocatorAriaSnapshotOptions{
+		Timeout: playwright.Float(float64(a11yTreeTimeout.Milliseconds())),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to capture accessibility tree: %w", err)
+	}
+	return strings.TrimSpace(snapshot), nil
+}
+
+// formatA11yTreeAsMarkdown renders an ARIA snapshot as a fenced YAML block.
+func formatA11yTreeAsMarkdown(tree string) string {
+	return "```yaml\n" + tree + "\n```"
+}
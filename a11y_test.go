@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestA11yTreeInOutputs(t *testing.T) {
+	tree := "- heading \"Install\" [level=1]\n- paragraph: Run the installer."
+	pd := PageData{Title: "Install", URL: "https://example.com/install", Markdown: "# Install", A11yTree: tree}
+
+	if got := formatPageDataAsMarkdownFile(&pd); !strings.HasSuffix(got, "## Accessibility Tree\n\n```yaml\n"+tree+"\n```\n") {
+		t.Errorf("formatPageDataAsMarkdownFile() = %q, want an Accessibility Tree section", got)
+	}
+	if got := formatPageDataAsXML(&pd); !strings.Contains(got, "  <a11y_tree>\n"+tree+"\n  </a11y_tree>\n</page>") {
+		t.Errorf("formatPageDataAsXML() = %q, want an <a11y_tree> element", got)
+	}
+	data, err := formatResultsAsJSONL([]PageData{pd})
+	if err != nil {
+		t.Fatalf("formatResultsAsJSONL() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"a11y_tree":"- heading \"Install\" [level=1]\n- paragraph: Run the installer."`) {
+		t.Errorf("formatResultsAsJSONL() = %s, want an a11y_tree field", data)
+	}
+
+	pd.A11yTree = ""
+	if got := formatPageDataAsXML(&pd); strings.Contains(got, "a11y_tree") {
+		t.Errorf("formatPageDataAsXML() without a tree = %q", got)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// adaptiveWaitSettle is how long --adaptive-wait lets a page keep rendering after the network
+// went idle before reading its HTML again.
+const adaptiveWaitSettle = 2 * time.Second
+
+// extractPage runs the content pipeline on a fetched page: DOM rules, comment and table
+// protection, then readability.
+func (c *Crawler) extractPage(pageURL *url.URL, htmlContent string, contentSelector string) (*PageData, []Comment, []Table, error) {
+	articleHTML := applyDOMRules(htmlContent, c.opts.DOMRules)
+	var comments []Comment
+	if c.opts.IncludeComments {
+		comments, articleHTML = extractComments(pageURL, articleHTML)
+	}
+	var tables []Table
+	if c.opts.Tables != "" {
+		articleHTML, tables = protectTables(articleHTML)
+	}
+	pageData, err := processHTMLWithTimeout(c.opts.ProcessTimeout, pageURL.String(), articleHTML, contentSelector, c.opts.SelectorMode)
+	return pageData, comments, tables, err
+}
+
+// extractedNothing reports whether extraction failed or produced no content, which for SPAs
+// often means the page had not finished rendering. Timeouts are not retried.
+func extractedNothing(pageData *PageData, processErr error) bool {
+	if processErr != nil {
+		return !errors.Is(processErr, errProcessingTimeout)
+	}
+	return pageData == nil || strings.TrimSpace(pageData.Markdown) == ""
+}
+
+// fetchWithAdaptiveWait refetches pageURL on page waiting for network idle, lets it settle for
+// adaptiveWaitSettle and returns the HTML it has rendered by then.
+func (c *Crawler) fetchWithAdaptiveWait(page playwright.Page, pageURL string, referer string) (string, pageResponse, error) {
+	logger.Printf("Adaptive wait: refetching %s with network idle and a %s settle delay...", pageURL, adaptiveWaitSettle)
+	htmlContent, response, err := fetchPageHTML(page, c.rootCtx, pageURL, true, referer)
+	if err != nil && !errors.Is(err, errEmptyHTML) {
+		logger.Printf("Adaptive wait: refetch of %s failed: %v", pageURL, err)
+		return "", response, err
+	}
+	select {
+	case <-time.After(adaptiveWaitSettle):
+	case <-c.rootCtx.Done():
+		return "", response, c.rootCtx.Err()
+	}
+	if content, contentErr := page.Content(); contentErr == nil {
+		htmlContent = content
+	}
+	if strings.TrimSpace(htmlContent) == "" {
+		logger.Printf("Adaptive wait: %s is still empty.", pageURL)
+		return "", response, fmt.Errorf("fetched HTML content from %s is %w", pageURL, errEmptyHTML)
+	}
+	logger.Printf("Adaptive wait: refetched %s (length: %d)", pageURL, len(htmlContent))
+	return htmlContent, response, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExtractedNothing(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageData *PageData
+		err      error
+		want     bool
+	}{
+		{name: "content", pageData: &PageData{Markdown: "# Title\n\nBody"}, want: false},
+		{name: "empty markdown", pageData: &PageData{Markdown: "  \n"}, want: true},
+		{name: "nil page", pageData: nil, want: true},
+		{name: "readability error", err: errors.New("could not parse"), want: true},
+		{name: "processing timeout", err: fmt.Errorf("page: %w", errProcessingTimeout), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractedNothing(tt.pageData, tt.err); got != tt.want {
+				t.Errorf("extractedNothing() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// minAPIKeyLength rejects keys short enough to guess.
+const minAPIKeyLength = 16
+
+// apiKeyNamePattern restricts key names to what is safe as a directory name.
+var apiKeyNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// uiAPIKeys is an --api-keys file: the keys allowed to use a shared 'sitepanda ui' server.
+// A nil *uiAPIKeys leaves the server open to anyone who can reach it.
+type uiAPIKeys struct {
+	Keys []*uiAPIKey `yaml:"keys"`
+}
+
+// uiAPIKey is one entry of an --api-keys file and the usage recorded for it. A nil *uiAPIKey
+// has no limits.
+type uiAPIKey struct {
+	// Name identifies the key in logs and names its results directory.
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+	// RequestsPerMinute caps the key's API requests (0 for no cap).
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	// MaxPages caps the pages the key's scrapes save while the server runs (0 for no cap).
+	MaxPages int `yaml:"max_pages"`
+
+	mu sync.Mutex
+	// windowStart and requests count the requests of the current minute.
+	windowStart time.Time
+	requests    int
+	// pagesUsed counts saved pages plus the limits of scrapes still running.
+	pagesUsed int
+}
+
+// parseAPIKeys parses and validates the YAML of an --api-keys file.
+func parseAPIKeys(data []byte) (*uiAPIKeys, error) {
+	var keys uiAPIKeys
+	if err := yaml.UnmarshalStrict(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid API keys file: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("API keys file contains no keys")
+	}
+	names := make(map[string]bool)
+	values := make(map[string]bool)
+	for i, key := range keys.Keys {
+		switch {
+		case key == nil || !apiKeyNamePattern.MatchString(key.Name):
+			return nil, fmt.Errorf("key %d: name is required and may only contain letters, digits, '-' and '_'", i+1)
+		case names[key.Name]:
+			return nil, fmt.Errorf("key %d: duplicate name %q", i+1, key.Name)
+		case len(key.Key) < minAPIKeyLength:
+			return nil, fmt.Errorf("key %q: key must be at least %d characters", key.Name, minAPIKeyLength)
+		case values[key.Key]:
+			return nil, fmt.Errorf("key %q: key is already used by another entry", key.Name)
+		case key.RequestsPerMinute < 0:
+			return nil, fmt.Errorf("key %q: requests_per_minute must not be negative, got %d", key.Name, key.RequestsPerMinute)
+		case key.MaxPages < 0:
+			return nil, fmt.Errorf("key %q: max_pages must not be negative, got %d", key.Name, key.MaxPages)
+		}
+		names[key.Name], values[key.Key] = true, true
+	}
+	return &keys, nil
+}
+
+// loadAPIKeys reads and parses an --api-keys file.
+func loadAPIKeys(path string) (*uiAPIKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys file: %w", err)
+	}
+	return parseAPIKeys(data)
+}
+
+// lookup returns the entry whose key is value, or nil. Every key is compared in constant time.
+func (k *uiAPIKeys) lookup(value string) *uiAPIKey {
+	var found *uiAPIKey
+	for _, key := range k.Keys {
+		if subtle.ConstantTimeCompare([]byte(key.Key), []byte(value)) == 1 {
+			found = key
+		}
+	}
+	return found
+}
+
+// byName returns the entry named name, or nil.
+func (k *uiAPIKeys) byName(name string) *uiAPIKey {
+	for _, key := range k.Keys {
+		if key.Name == name {
+			return key
+		}
+	}
+	return nil
+}
+
+// apiKeyFromRequest returns the key sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+func apiKeyFromRequest(r *http.Request) string {
+	if value, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(value)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// allow records a request at now and reports whether it is within RequestsPerMinute. If not,
+// it returns how long until the next request is allowed.
+func (k *uiAPIKey) allow(now time.Time) (time.Duration, bool) {
+	if k == nil || k.RequestsPerMinute == 0 {
+		return 0, true
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if now.Sub(k.windowStart) >= time.Minute {
+		k.windowStart, k.requests = now, 0
+	}
+	if k.requests >= k.RequestsPerMinute {
+		return k.windowStart.Add(time.Minute).Sub(now), false
+	}
+	k.requests++
+	return 0, true
+}
+
+// reservePages reserves pages for a scrape with the given --limit (0 for none) and returns the
+// limit to run it with, lowered to what is left of MaxPages.
+func (k *uiAPIKey) reservePages(limit int) (int, error) {
+	if k == nil || k.MaxPages == 0 {
+		return limit, nil
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	remaining := k.MaxPages - k.pagesUsed
+	if remaining <= 0 {
+		return 0, fmt.Errorf("the page quota of %d pages is used up", k.MaxPages)
+	}
+	if limit == 0 || limit > remaining {
+		limit = remaining
+	}
+	k.pagesUsed += limit
+	return limit, nil
+}
+
+// releasePages returns the part of a reservation a finished scrape did not save.
+func (k *uiAPIKey) releasePages(reserved int, saved int) {
+	if k == nil || k.MaxPages == 0 {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.pagesUsed -= reserved - min(saved, reserved)
+}
+
+// countPages counts pages saved or reserved by jobs of an earlier run against MaxPages.
+func (k *uiAPIKey) countPages(n int) {
+	if k == nil || k.MaxPages == 0 {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.pagesUsed += n
+}
+
+type uiAPIKeyContextKey struct{}
+
+// requestAPIKey returns the key that authorized r, or nil if the server has no --api-keys.
+func requestAPIKey(r *http.Request) *uiAPIKey {
+	key, _ := r.Context().Value(uiAPIKeyContextKey{}).(*uiAPIKey)
+	return key
+}
+
+// authorize wraps an API handler so that, with --api-keys, requests need a known key within
+// its rate limit. The key is passed on in the request's context.
+func (s *uiServer) authorize(next http.HandlerFunc) http.HandlerFunc {
+	if s.keys == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := s.keys.lookup(apiKeyFromRequest(r))
+		if key == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="sitepanda"`)
+			http.Error(w, "a valid API key is required", http.StatusUnauthorized)
+			return
+		}
+		if wait, ok := key.allow(time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, fmt.Sprintf("rate limit of %d requests per minute exceeded", key.RequestsPerMinute), http.StatusTooManyRequests)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), uiAPIKeyContextKey{}, key)))
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Kinds of non-HTML files that --download-assets can save.
+const (
+	assetKindPDF   = "pdf"
+	assetKindText  = "txt"
+	assetKindImage = "image"
+	// assetKindOther is any other non-HTML file, such as an archive or a video. It is never
+	// downloaded.
+	assetKindOther = "other"
+)
+
+// assetFileExtensions maps the URL path extensions of non-HTML files to their kind. Links
+// ending in one are not opened in the browser, which cannot render them as a page.
+var assetFileExtensions = map[string]string{
+	".pdf": assetKindPDF,
+	".txt": assetKindText,
+	".png": assetKindImage, ".jpg": assetKindImage, ".jpeg": assetKindImage, ".gif": assetKindImage,
+	".webp": assetKindImage, ".svg": assetKindImage, ".avif": assetKindImage, ".ico": assetKindImage,
+	".zip": assetKindOther, ".gz": assetKindOther, ".tgz": assetKindOther, ".tar": assetKindOther,
+	".7z": assetKindOther, ".rar": assetKindOther, ".dmg": assetKindOther, ".exe": assetKindOther,
+	".msi": assetKindOther, ".deb": assetKindOther, ".rpm": assetKindOther, ".apk": assetKindOther,
+	".mp3": assetKindOther, ".wav": assetKindOther, ".mp4": assetKindOther, ".mov": assetKindOther,
+	".webm": assetKindOther, ".woff": assetKindOther, ".woff2": assetKindOther, ".ttf": assetKindOther,
+	".doc": assetKindOther, ".docx": assetKindOther, ".xls": assetKindOther, ".xlsx": assetKindOther,
+	".ppt": assetKindOther, ".pptx": assetKindOther,
+}
+
+// linkedAssetKind returns the kind of non-HTML file u links to, judged by its path extension,
+// or "" if it may be a page.
+func linkedAssetKind(u *url.URL) string {
+	return assetFileExtensions[strings.ToLower(path.Ext(u.Path))]
+}
+
+// contentAssetKind returns the kind of non-HTML file a response with this Content-Type
+// holds, or "" for HTML and for a response without a Content-Type.
+func contentAssetKind(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return ""
+	case mediaType == "application/pdf":
+		return assetKindPDF
+	case mediaType == "text/plain" || mediaType == "text/markdown":
+		return assetKindText
+	case strings.HasPrefix(mediaType, "image/"):
+		return assetKindImage
+	default:
+		return assetKindOther
+	}
+}
+
+// assetKinds is the set of --download-assets kinds. A nil set downloads nothing.
+type assetKinds map[string]bool
+
+// parseAssetKinds parses the --download-assets values, e.g. pdf,txt.
+func parseAssetKinds(values []string) (assetKinds, error) {
+	var kinds assetKinds
+	for _, value := range values {
+		kind := strings.ToLower(strings.TrimSpace(value))
+		switch kind {
+		case "":
+			continue
+		case assetKindPDF, assetKindText, assetKindImage:
+		default:
+			return nil, fmt.Errorf("invalid --download-assets kind %q: want pdf, txt or image", value)
+		}
+		if kinds == nil {
+			kinds = make(assetKinds)
+		}
+		kinds[kind] = true
+	}
+	return kinds, nil
+}
+
+// DownloadedAsset is a non-HTML file saved by --download-assets.
+type DownloadedAsset struct {
+	URL string `json:"url"`
+	// Path is where the file was written, inside --asset-dir.
+	Path string `json:"path"`
+}
+
+// handleAsset deals with assetURL, which holds a non-HTML file of kind: it is downloaded into
+// --asset-dir if --download-assets includes kind, and skipped otherwise.
+func (c *Crawler) handleAsset(assetURL *url.URL, assetURLStr string, kind string, status int, result *CrawlResult) {
+	if !c.opts.DownloadAssets[kind] {
+		reason := fmt.Sprintf("not an HTML page (%s file)", kind)
+		logger.Printf("Skipping %s: %s", assetURLStr, reason)
+		result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: assetURLStr, Reason: reason})
+		c.recordDecision(assetURLStr, status, 0, auditDecisionSkipped, reason)
+		return
+	}
+	file, size, err := c.downloadFile(assetURLStr, kind)
+	if err != nil {
+		logger.Printf("Error downloading %s: %v", assetURLStr, err)
+		c.recordDecision(assetURLStr, status, 0, auditDecisionFailed, err.Error())
+		result.countFailure(assetURL.Hostname(), assetURLStr, err.Error())
+		return
+	}
+	c.opts.Bytes.add(size)
+	result.DownloadedAssets = append(result.DownloadedAssets, DownloadedAsset{URL: assetURLStr, Path: file})
+	c.recordDecision(assetURLStr, status, int(size), auditDecisionSaved, "downloaded to "+file)
+	logger.Printf("Downloaded %s to %s", assetURLStr, file)
+}
+
+// downloadFile saves the raw body of fileURL under --asset-dir, at a path derived from the URL
+// like --output-dir page files, and returns the path and its size.
+func (c *Crawler) downloadFile(fileURL string, kind string) (string, int64, error) {
+	var body []byte
+	contentType := ""
+	if c.opts.Fixtures != nil {
+		content, response, err := c.opts.Fixtures.fetch(fileURL)
+		if err != nil {
+			return "", 0, err
+		}
+		body, contentType = []byte(content), response.Headers["content-type"]
+	} else {
+		var err error
+		if body, contentType, err = fetchRawFile(c.rootCtx, fileURL); err != nil {
+			return "", 0, err
+		}
+	}
+	if contentAssetKind(contentType) == "" && contentType != "" {
+		return "", 0, fmt.Errorf("expected a %s file, got %s", kind, contentType)
+	}
+
+	if c.assetFiles == nil {
+		c.assetFiles = newPagePathAllocator()
+	}
+	var ext string
+	if u, err := url.Parse(fileURL); err == nil {
+		ext = strings.ToLower(path.Ext(u.Path))
+	}
+	if ext == "" {
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	file := filepath.Join(c.opts.AssetDir, filepath.FromSlash(strings.TrimSuffix(c.assetFiles.Allocate(fileURL), ".md")+ext))
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return "", 0, fmt.Errorf("failed to create %s: %w", filepath.Dir(file), err)
+	}
+	if err := os.WriteFile(file, body, 0644); err != nil {
+		return "", 0, fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return file, int64(len(body)), nil
+}
+
+// fetchRawFile downloads fileURL with a plain HTTP GET, up to maxHTTPBodyBytes, and returns
+// its body and Content-Type.
+func fetchRawFile(ctx context.Context, fileURL string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build request for %s: %w", fileURL, err)
+	}
+	req.Header.Set("User-Agent", "sitepanda/"+Version)
+	resp, err := httpFetchClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", fileURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return nil, "", fmt.Errorf("failed to download %s: status %s", fileURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBodyBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", fileURL, err)
+	}
+	if len(body) > maxHTTPBodyBytes {
+		return nil, "", fmt.Errorf("skipping %s: larger than %s", fileURL, formatByteSize(maxHTTPBodyBytes))
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAssetKinds(t *testing.T) {
+	contentTypes := map[string]string{
+		"":                          "",
+		"text/html; charset=utf-8":  "",
+		"application/xhtml+xml":     "",
+		"application/pdf":           assetKindPDF,
+		"text/plain; charset=UTF-8": assetKindText,
+		"image/png":                 assetKindImage,
+		"application/zip":           assetKindOther,
+	}
+	for contentType, want := range contentTypes {
+		if got := contentAssetKind(contentType); got != want {
+			t.Errorf("contentAssetKind(%q) = %q, want %q", contentType, got, want)
+		}
+	}
+	links := map[string]string{
+		"https://example.com/docs/guide":        "",
+		"https://example.com/docs/guide.html":   "",
+		"https://example.com/files/Manual.PDF":  assetKindPDF,
+		"https://example.com/robots-notes.txt":  assetKindText,
+		"https://example.com/logo.svg?v=2":      assetKindImage,
+		"https://example.com/release/v1.tar.gz": assetKindOther,
+	}
+	for link, want := range links {
+		u, _ := url.Parse(link)
+		if got := linkedAssetKind(u); got != want {
+			t.Errorf("linkedAssetKind(%s) = %q, want %q", link, got, want)
+		}
+	}
+}
+
+func TestParseAssetKinds(t *testing.T) {
+	kinds, err := parseAssetKinds([]string{"pdf", " TXT "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kinds[assetKindPDF] || !kinds[assetKindText] || kinds[assetKindImage] {
+		t.Errorf("parseAssetKinds() = %v, want pdf and txt", kinds)
+	}
+	if kinds, err := parseAssetKinds(nil); err != nil || kinds != nil {
+		t.Errorf("parseAssetKinds(nil) = %v, %v; want nil", kinds, err)
+	}
+	if _, err := parseAssetKinds([]string{"zip"}); err == nil {
+		t.Error("parseAssetKinds accepted zip")
+	}
+}
+
+func TestCrawlDownloadAssets(t *testing.T) {
+	pages := map[string]string{
+		"index.html":       `<html><head><title>Docs</title></head><body><h1>Docs</h1><p>Read the manual.</p><a href="/files/manual.pdf">Manual</a> <a href="/notes.txt">Notes</a> <a href="/guide">Guide</a></body></html>`,
+		"guide.html":       `<html><head><title>Guide</title></head><body><h1>Guide</h1><p>Start with the basics.</p></body></html>`,
+		"files/manual.pdf": "%PDF-1.4 manual",
+		"notes.txt":        "release notes",
+	}
+	assetDir := t.TempDir()
+	_, result, _ := crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{DownloadAssets: assetKinds{assetKindPDF: true}, AssetDir: assetDir}})
+	if result.PagesSaved != 2 {
+		t.Errorf("saved %d pages, want the 2 HTML pages", result.PagesSaved)
+	}
+	if len(result.DownloadedAssets) != 1 {
+		t.Fatalf("downloaded %v, want the PDF", result.DownloadedAssets)
+	}
+	want := filepath.Join(assetDir, "example.com", "files", "manual.pdf")
+	if got := result.DownloadedAssets[0].Path; got != want {
+		t.Errorf("PDF saved to %s, want %s", got, want)
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != "%PDF-1.4 manual" {
+		t.Errorf("downloaded PDF = %q, %v", data, err)
+	}
+	skipped := false
+	for _, page := range result.SkippedPages {
+		skipped = skipped || page.URL == "https://example.com/notes.txt"
+	}
+	if !skipped {
+		t.Errorf("the text file was not skipped: %v", result.SkippedPages)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit log decisions recorded for every attempted URL.
+const (
+	auditDecisionSaved     = "saved"
+	auditDecisionSkipped   = "skipped"
+	auditDecisionMatchMiss = "match-miss"
+	auditDecisionFailed    = "failed"
+)
+
+// auditEntry is one line of the --audit-log JSONL file.
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	URL      string    `json:"url"`
+	Status   int       `json:"status,omitempty"`
+	Bytes    int       `json:"bytes"`
+	Decision string    `json:"decision"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+// auditLog appends auditEntry records to a file. A nil *auditLog discards all records.
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// newAuditLog opens path for appending, creating it if needed.
+func newAuditLog(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	return &auditLog{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+// Record writes one entry. Write errors are logged, never fatal to the crawl.
+func (a *auditLog) Record(pageURL string, status int, bytes int, decision string, reason string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry := auditEntry{Time: time.Now().UTC(), URL: pageURL, Status: status, Bytes: bytes, Decision: decision, Reason: reason}
+	if err := a.enc.Encode(&entry); err != nil {
+		logger.Printf("Warning: failed to write audit log entry for %s: %v", pageURL, err)
+	}
+}
+
+func (a *auditLog) Close() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.file.Close()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	a, err := newAuditLog(path)
+	if err != nil {
+		t.Fatalf("newAuditLog() error: %v", err)
+	}
+	a.Record("https://example.com/", 200, 1024, auditDecisionSaved, "")
+	a.Record("https://example.com/about", 200, 512, auditDecisionMatchMiss, "")
+	a.Record("https://example.com/missing", 404, 0, auditDecisionFailed, "not found")
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	// Reopening appends rather than truncating.
+	a, err = newAuditLog(path)
+	if err != nil {
+		t.Fatalf("newAuditLog() reopen error: %v", err)
+	}
+	a.Record("https://example.com/huge", 200, 99999, auditDecisionSkipped, "too big")
+	a.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	want := []auditEntry{
+		{URL: "https://example.com/", Status: 200, Bytes: 1024, Decision: auditDecisionSaved},
+		{URL: "https://example.com/about", Status: 200, Bytes: 512, Decision: auditDecisionMatchMiss},
+		{URL: "https://example.com/missing", Status: 404, Decision: auditDecisionFailed, Reason: "not found"},
+		{URL: "https://example.com/huge", Status: 200, Bytes: 99999, Decision: auditDecisionSkipped, Reason: "too big"},
+	}
+	scanner := bufio.NewScanner(f)
+	var i int
+	for ; scanner.Scan(); i++ {
+		var got auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &got); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v", i+1, err)
+		}
+		if got.Time.IsZero() {
+			t.Errorf("line %d has no timestamp", i+1)
+		}
+		got.Time = want[i].Time
+		if got != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i+1, got, want[i])
+		}
+	}
+	if i != len(want) {
+		t.Errorf("got %d lines, want %d", i, len(want))
+	}
+}
+
+func TestAuditLogNilIsNoop(t *testing.T) {
+	var a *auditLog
+	a.Record("https://example.com/", 200, 10, auditDecisionSaved, "")
+	if err := a.Close(); err != nil {
+		t.Errorf("Close() on nil audit log = %v, want nil", err)
+	}
+}
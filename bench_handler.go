@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hokupod/sitepanda/cmd"
+)
+
+// benchEngineResult holds the measurements for one engine in a bench run.
+type benchEngineResult struct {
+	Engine       string
+	Startup      time.Duration
+	Crawl        time.Duration
+	Attempted    int
+	Saved        int
+	ContentChars int
+	Err          error
+}
+
+// HandleBench crawls the same sample of pages with each engine and prints a comparison.
+func HandleBench(startURL string) {
+	if cmd.GetSilent() {
+		SetLoggerOutput(io.Discard)
+	}
+
+	pages := cmd.GetBenchPages()
+	if pages < 1 {
+		pages = 1
+	}
+	engines := cmd.GetBenchEngines()
+
+	logger.Printf("Sitepanda v%s benchmarking %s with engines: %s", Version, startURL, strings.Join(engines, ", "))
+
+	sample, err := discoverBenchSample(context.Background(), startURL, pages)
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	logger.Printf("Benchmark sample: %d pages", len(sample))
+
+	var results []benchEngineResult
+	for _, engine := range engines {
+		logger.Printf("--- Benchmarking engine: %s ---", engine)
+		var res benchEngineResult
+		if engine == "http" {
+			res = runBenchHTTPEngine(sample)
+		} else {
+			res = runBenchBrowserEngine(engine, sample)
+		}
+		if res.Err != nil {
+			logger.Printf("Engine %s failed: %v", engine, res.Err)
+		}
+		results = append(results, res)
+	}
+
+	fmt.Print(formatBenchReport(results, len(sample)))
+}
+
+// discoverBenchSample collects up to pages same-host URLs, starting at startURL, using plain
+// HTTP link discovery so that every engine is measured on the same set of pages.
+func discoverBenchSample(ctx context.Context, startURL string, pages int) ([]string, error) {
+	normStartURL, err := normalizeURLtoString(startURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start URL '%s': %w", startURL, err)
+	}
+	parsedStartURL, err := url.Parse(normStartURL)
+	if err != nil || (parsedStartURL.Scheme != "http" && parsedStartURL.Scheme != "https") {
+		return nil, fmt.Errorf("start URL must be an http or https URL, got: %s", startURL)
+	}
+
+	linkFinder := &Crawler{startURL: parsedStartURL}
+	sample := []string{normStartURL}
+	seen := map[string]bool{normStartURL: true}
+	for i := 0; i < len(sample) && len(sample) < pages; i++ {
+		htmlContent, err := fetchPageHTMLOverHTTP(ctx, sample[i])
+		if err != nil {
+			logger.Printf("Sample discovery: skipping links from %s: %v", sample[i], err)
+			continue
+		}
+		pageURL, _ := url.Parse(sample[i])
+		for _, link := range linkFinder.extractAndFilterLinks(pageURL, htmlContent) {
+			if len(sample) >= pages {
+				break
+			}
+			if !seen[link] {
+				seen[link] = true
+				sample = append(sample, link)
+			}
+		}
+	}
+	return sample, nil
+}
+
+// runBenchHTTPEngine fetches and extracts the sample with plain HTTP requests.
+func runBenchHTTPEngine(sample []string) benchEngineResult {
+	res := benchEngineResult{Engine: "http"}
+	start := time.Now()
+	for _, pageURL := range sample {
+		res.Attempted++
+		htmlContent, err := fetchPageHTMLOverHTTP(context.Background(), pageURL)
+		if err != nil {
+			logger.Printf("http engine: %v", err)
+			continue
+		}
+		pageData, err := processHTML(pageURL, htmlContent, "", "")
+		if err != nil {
+			logger.Printf("http engine: %v", err)
+			continue
+		}
+		res.Saved++
+		res.ContentChars += len(pageData.Markdown)
+	}
+	res.Crawl = time.Since(start)
+	return res
+}
+
+// runBenchBrowserEngine launches the given browser and crawls the sample in URL list mode.
+func runBenchBrowserEngine(engine string, sample []string) benchEngineResult {
+	res := benchEngineResult{Engine: engine}
+
+	playwrightDriverDir, err := GetAppSubdirectory("playwright_driver")
+	if err != nil {
+		res.Err = fmt.Errorf("failed to determine Playwright driver directory: %w", err)
+		return res
+	}
+
+	startupBegin := time.Now()
+	browserExecutablePath, browserPrepareCleanup, err := prepareBrowser(engine, playwrightDriverDir)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer browserPrepareCleanup()
+
+	lightpandaCmd, wsURL, pwInstance, pwBrowser, _, _, err := launchBrowserAndGetConnection(engine, browserExecutablePath, playwrightDriverDir, false, nil)
+	defer shutdownBrowser(engine, pwBrowser, pwInstance, lightpandaCmd)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	var crawler *Crawler
+	if engine == "lightpanda" {
+		crawler, err = NewCrawlerForLightpanda(sample[0], sample, true, wsURL, pwInstance, 0, nil, nil, "", os.DevNull, true, false, "jsonl", CrawlOptions{})
+	} else {
+		crawler, err = NewCrawlerForPlaywrightBrowser(sample[0], sample, true, pwBrowser, 0, nil, nil, "", os.DevNull, true, false, "jsonl", CrawlOptions{})
+	}
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Startup = time.Since(startupBegin)
+
+	crawlBegin := time.Now()
+	crawlResult, err := crawler.Crawl()
+	res.Crawl = time.Since(crawlBegin)
+	if err != nil {
+		res.Err = err
+	}
+	res.Attempted = len(sample)
+	res.Saved = crawlResult.PagesSaved
+	for _, pd := range crawler.results {
+		res.ContentChars += len(pd.Markdown)
+	}
+	return res
+}
+
+// formatBenchReport renders the per-engine comparison table and a --browser suggestion.
+func formatBenchReport(results []benchEngineResult, sampleSize int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nBenchmark results (%d sample pages)\n\n", sampleSize)
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENGINE\tSTARTUP\tCRAWL\tPAGES/S\tSUCCESS\tAVG CONTENT")
+	for _, r := range results {
+		if r.Err != nil && r.Saved == 0 {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\terror: %v\n", r.Engine, r.Err)
+			continue
+		}
+		pagesPerSecond := 0.0
+		if r.Crawl > 0 {
+			pagesPerSecond = float64(r.Saved) / r.Crawl.Seconds()
+		}
+		successRate := 0.0
+		avgContent := 0
+		if r.Attempted > 0 {
+			successRate = 100 * float64(r.Saved) / float64(r.Attempted)
+		}
+		if r.Saved > 0 {
+			avgContent = r.ContentChars / r.Saved
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\t%d/%d (%.0f%%)\t%d chars\n",
+			r.Engine, r.Startup.Round(time.Millisecond), r.Crawl.Round(time.Millisecond), pagesPerSecond,
+			r.Saved, r.Attempted, successRate, avgContent)
+	}
+	tw.Flush()
+
+	if best := suggestBenchBrowser(results); best != "" {
+		fmt.Fprintf(&b, "\nSuggested --browser for this site: %s\n", best)
+	}
+	return b.String()
+}
+
+// suggestBenchBrowser picks the browser engine with the most saved pages, breaking ties by total time.
+// The plain http engine is excluded because it is not a --browser option.
+func suggestBenchBrowser(results []benchEngineResult) string {
+	var best *benchEngineResult
+	for i := range results {
+		r := &results[i]
+		if r.Engine == "http" || r.Saved == 0 {
+			continue
+		}
+		if best == nil || r.Saved > best.Saved ||
+			(r.Saved == best.Saved && r.Startup+r.Crawl < best.Startup+best.Crawl) {
+			best = r
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.Engine
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiscoverBenchSample(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/a">A</a><a href="/b">B</a><a href="https://other.example/">X</a></body></html>`)
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><a href="/c">C</a></body></html>`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	sample, err := discoverBenchSample(context.Background(), server.URL, 3)
+	if err != nil {
+		t.Fatalf("discoverBenchSample() error = %v", err)
+	}
+	want := []string{server.URL + "/", server.URL + "/a", server.URL + "/b"}
+	if len(sample) != len(want) {
+		t.Fatalf("discoverBenchSample() = %v, want %v", sample, want)
+	}
+	for i := range want {
+		if sample[i] != want[i] {
+			t.Errorf("sample[%d] = %q, want %q", i, sample[i], want[i])
+		}
+	}
+
+	if _, err := discoverBenchSample(context.Background(), "ftp://example.com", 3); err == nil {
+		t.Error("Expected error for non-http start URL")
+	}
+}
+
+func TestFormatBenchReport(t *testing.T) {
+	results := []benchEngineResult{
+		{Engine: "chromium", Startup: time.Second, Crawl: 10 * time.Second, Attempted: 20, Saved: 20, ContentChars: 20000},
+		{Engine: "lightpanda", Startup: 100 * time.Millisecond, Crawl: 4 * time.Second, Attempted: 20, Saved: 18, ContentChars: 16200},
+		{Engine: "http", Crawl: time.Second, Attempted: 20, Saved: 20, ContentChars: 10000},
+		{Engine: "broken", Err: fmt.Errorf("launch failed")},
+	}
+
+	report := formatBenchReport(results, 20)
+	for _, want := range []string{
+		"20 sample pages",
+		"20/20 (100%)",
+		"18/20 (90%)",
+		"1000 chars",
+		"error: launch failed",
+		"Suggested --browser for this site: chromium",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestSuggestBenchBrowser(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []benchEngineResult
+		want    string
+	}{
+		{
+			name: "more saved pages wins",
+			results: []benchEngineResult{
+				{Engine: "chromium", Saved: 10, Crawl: 10 * time.Second},
+				{Engine: "lightpanda", Saved: 8, Crawl: time.Second},
+			},
+			want: "chromium",
+		},
+		{
+			name: "tie broken by total time",
+			results: []benchEngineResult{
+				{Engine: "chromium", Saved: 10, Crawl: 10 * time.Second},
+				{Engine: "lightpanda", Saved: 10, Crawl: time.Second},
+			},
+			want: "lightpanda",
+		},
+		{
+			name: "http engine is never suggested",
+			results: []benchEngineResult{
+				{Engine: "http", Saved: 10, Crawl: time.Millisecond},
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := suggestBenchBrowser(tt.results); got != tt.want {
+				t.Errorf("suggestBenchBrowser() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
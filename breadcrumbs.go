@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// sectionSeparator joins breadcrumb names into PageData.Section.
+const sectionSeparator = " > "
+
+// Breadcrumb is one step of a page's breadcrumb trail.
+type Breadcrumb struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+// extractBreadcrumbs returns the breadcrumb trail of a page, trying schema.org BreadcrumbList
+// JSON-LD, then microdata, then nav[aria-label=breadcrumb] and common breadcrumb classes.
+// Relative links are resolved against pageURL. It returns nil if no breadcrumbs are found.
+func extractBreadcrumbs(pageURL *url.URL, rawHTML string) []Breadcrumb {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		return nil
+	}
+	return extractBreadcrumbsFromDocument(pageURL, doc)
+}
+
+func extractBreadcrumbsFromDocument(pageURL *url.URL, doc *goquery.Document) []Breadcrumb {
+	if crumbs := breadcrumbsFromJSONLD(pageURL, doc); len(crumbs) > 0 {
+		return crumbs
+	}
+	if crumbs := breadcrumbsFromMicrodata(pageURL, doc); len(crumbs) > 0 {
+		return crumbs
+	}
+	return breadcrumbsFromNav(pageURL, doc)
+}
+
+func breadcrumbsFromJSONLD(pageURL *url.URL, doc *goquery.Document) []Breadcrumb {
+	var crumbs []Breadcrumb
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+		if list := findJSONLDType(data, "BreadcrumbList"); list != nil {
+			crumbs = breadcrumbsFromJSONLDList(pageURL, list)
+		}
+		return len(crumbs) == 0
+	})
+	return crumbs
+}
+
+// findJSONLDType searches a decoded JSON-LD value (object, array or @graph) for an object of the given @type.
+func findJSONLDType(data any, typeName string) map[string]any {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if found := findJSONLDType(item, typeName); found != nil {
+				return found
+			}
+		}
+	case map[string]any:
+		if jsonLDHasType(v["@type"], typeName) {
+			return v
+		}
+		if graph, ok := v["@graph"]; ok {
+			return findJSONLDType(graph, typeName)
+		}
+	}
+	return nil
+}
+
+func jsonLDHasType(t any, typeName string) bool {
+	switch v := t.(type) {
+	case string:
+		return v == typeName
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && s == typeName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func breadcrumbsFromJSONLDList(pageURL *url.URL, list map[string]any) []Breadcrumb {
+	elements, _ := list["itemListElement"].([]any)
+	type positioned struct {
+		pos   float64
+		crumb Breadcrumb
+	}
+	var items []positioned
+	for i, el := range elements {
+		obj, ok := el.(map[string]any)
+		if !ok {
+			continue
+		}
+		pos, ok := obj["position"].(float64)
+		if !ok {
+			pos = float64(i + 1)
+		}
+		name, _ := obj["name"].(string)
+		var link string
+		switch item := obj["item"].(type) {
+		case string:
+			link = item
+		case map[string]any:
+			if id, ok := item["@id"].(string); ok {
+				link = id
+			} else if u, ok := item["url"].(string); ok {
+				link = u
+			}
+			if name == "" {
+				name, _ = item["name"].(string)
+			}
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		items = append(items, positioned{pos: pos, crumb: Breadcrumb{Name: name, URL: resolveBreadcrumbURL(pageURL, link)}})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].pos < items[j].pos })
+	crumbs := make([]Breadcrumb, 0, len(items))
+	for _, it := range items {
+		crumbs = append(crumbs, it.crumb)
+	}
+	return crumbs
+}
+
+func breadcrumbsFromMicrodata(pageURL *url.URL, doc *goquery.Document) []Breadcrumb {
+	var crumbs []Breadcrumb
+	doc.Find(`[itemtype$="schema.org/BreadcrumbList"]`).First().Find(`[itemprop="itemListElement"]`).Each(func(_ int, s *goquery.Selection) {
+		name := strings.TrimSpace(s.Find(`[itemprop="name"]`).First().Text())
+		if name == "" {
+			name = strings.TrimSpace(s.Text())
+		}
+		if name == "" {
+			return
+		}
+		link, _ := s.Find(`[itemprop="item"]`).First().Attr("href")
+		if link == "" {
+			link, _ = s.Find("a[href]").First().Attr("href")
+		}
+		crumbs = append(crumbs, Breadcrumb{Name: collapseWhitespace(name), URL: resolveBreadcrumbURL(pageURL, link)})
+	})
+	return crumbs
+}
+
+func breadcrumbsFromNav(pageURL *url.URL, doc *goquery.Document) []Breadcrumb {
+	container := doc.Find("nav[aria-label]").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		label, _ := s.Attr("aria-label")
+		return strings.Contains(strings.ToLower(label), "breadcrumb")
+	}).First()
+	if container.Length() == 0 {
+		container = doc.Find("ol.breadcrumb, ul.breadcrumb, .breadcrumbs, .breadcrumb").First()
+	}
+	if container.Length() == 0 {
+		return nil
+	}
+
+	var crumbs []Breadcrumb
+	items := container.Find("li")
+	if items.Length() == 0 {
+		items = container.Find("a")
+	}
+	items.Each(func(_ int, s *goquery.Selection) {
+		name := collapseWhitespace(s.Text())
+		if name == "" {
+			return
+		}
+		link, _ := s.Attr("href")
+		if link == "" {
+			link, _ = s.Find("a[href]").First().Attr("href")
+		}
+		crumbs = append(crumbs, Breadcrumb{Name: name, URL: resolveBreadcrumbURL(pageURL, link)})
+	})
+	return crumbs
+}
+
+// sectionTrailFromBreadcrumbs returns the section labels above the page, dropping a leading
+// link to the site root ("Home") and a trailing crumb for the page itself.
+func sectionTrailFromBreadcrumbs(pageURL *url.URL, crumbs []Breadcrumb) []string {
+	if len(crumbs) == 0 {
+		return nil
+	}
+	if first, err := url.Parse(crumbs[0].URL); err == nil && crumbs[0].URL != "" && first.Host == pageURL.Host && (first.Path == "" || first.Path == "/") {
+		crumbs = crumbs[1:]
+	}
+	if n := len(crumbs); n > 0 {
+		last := crumbs[n-1]
+		self, _ := normalizeURLtoString(pageURL.String())
+		if last.URL == "" || last.URL == self {
+			crumbs = crumbs[:n-1]
+		}
+	}
+	trail := make([]string, 0, len(crumbs))
+	for _, c := range crumbs {
+		trail = append(trail, c.Name)
+	}
+	return trail
+}
+
+func resolveBreadcrumbURL(pageURL *url.URL, link string) string {
+	link = strings.TrimSpace(link)
+	if link == "" || pageURL == nil {
+		return link
+	}
+	resolved, err := pageURL.Parse(link)
+	if err != nil {
+		return ""
+	}
+	normalized, err := normalizeURLtoString(resolved.String())
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExtractBreadcrumbs(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/docs/guide/install")
+
+	tests := []struct {
+		name string
+		html string
+		want []Breadcrumb
+	}{
+		{
+			name: "JSON-LD BreadcrumbList in @graph, out of order",
+			html: `<html><head><script type="application/ld+json">
+{"@context":"https://schema.org","@graph":[{"@type":"WebPage"},{"@type":"BreadcrumbList","itemListElement":[
+ {"@type":"ListItem","position":2,"name":"Guide","item":"https://example.com/docs/guide/"},
+ {"@type":"ListItem","position":1,"name":"Docs","item":{"@id":"/docs"}},
+ {"@type":"ListItem","position":3,"name":"Install"}]}]}
+</script></head><body></body></html>`,
+			want: []Breadcrumb{
+				{Name: "Docs", URL: "https://example.com/docs"},
+				{Name: "Guide", URL: "https://example.com/docs/guide"},
+				{Name: "Install"},
+			},
+		},
+		{
+			name: "microdata",
+			html: `<ol itemscope itemtype="https://schema.org/BreadcrumbList">
+<li itemprop="itemListElement" itemscope itemtype="https://schema.org/ListItem"><a itemprop="item" href="/docs"><span itemprop="name">Docs</span></a></li>
+<li itemprop="itemListElement" itemscope itemtype="https://schema.org/ListItem"><span itemprop="name">Install</span></li>
+</ol>`,
+			want: []Breadcrumb{{Name: "Docs", URL: "https://example.com/docs"}, {Name: "Install"}},
+		},
+		{
+			name: "nav aria-label",
+			html: `<nav aria-label="Breadcrumb"><ol><li><a href="../">Docs</a></li><li><a href="./">  Getting
+ Started </a></li><li aria-current="page">Install</li></ol></nav>`,
+			want: []Breadcrumb{
+				{Name: "Docs", URL: "https://example.com/docs"},
+				{Name: "Getting Started", URL: "https://example.com/docs/guide"},
+				{Name: "Install"},
+			},
+		},
+		{
+			name: "breadcrumb class with bare links",
+			html: `<div class="breadcrumbs"><a href="/">Home</a> &gt; <a href="/docs">Docs</a></div>`,
+			want: []Breadcrumb{{Name: "Home", URL: "https://example.com/"}, {Name: "Docs", URL: "https://example.com/docs"}},
+		},
+		{
+			name: "no breadcrumbs",
+			html: `<nav aria-label="Main"><a href="/">Home</a></nav>`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractBreadcrumbs(pageURL, tt.html)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractBreadcrumbs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSectionTrailFromBreadcrumbs(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/docs/guide/install")
+
+	tests := []struct {
+		name   string
+		crumbs []Breadcrumb
+		want   []string
+	}{
+		{"none", nil, nil},
+		{
+			"drops home and current page",
+			[]Breadcrumb{{Name: "Home", URL: "https://example.com/"}, {Name: "Docs", URL: "https://example.com/docs"}, {Name: "Install"}},
+			[]string{"Docs"},
+		},
+		{
+			"drops trailing self link",
+			[]Breadcrumb{{Name: "Docs", URL: "https://example.com/docs"}, {Name: "Guide", URL: "https://example.com/docs/guide"}, {Name: "Install", URL: "https://example.com/docs/guide/install"}},
+			[]string{"Docs", "Guide"},
+		},
+		{
+			"keeps trailing parent link",
+			[]Breadcrumb{{Name: "Docs", URL: "https://example.com/docs"}, {Name: "Guide", URL: "https://example.com/docs/guide"}},
+			[]string{"Docs", "Guide"},
+		},
+		{
+			"home link on another host is kept",
+			[]Breadcrumb{{Name: "Portal", URL: "https://portal.example.com/"}, {Name: "Install"}},
+			[]string{"Portal"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sectionTrailFromBreadcrumbs(pageURL, tt.crumbs)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sectionTrailFromBreadcrumbs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
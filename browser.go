@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"os/exec"
@@ -66,7 +67,10 @@ func getFreePort() (int, error) {
 	return l.Addr().(*net.TCPAddr).Port, nil
 }
 
-func launchBrowserAndGetConnection(browserName string, lightpandaExecutablePath string, baseInstallDirForChromium string, verboseBrowser bool) (
+// launchBrowserAndGetConnection starts the requested browser. Lightpanda's stdout/stderr are always
+// captured in the returned buffers; with verboseBrowser they are also streamed live to the logger,
+// and if lightpandaLog is non-nil they are copied there as well (e.g. a rotating log file).
+func launchBrowserAndGetConnection(browserName string, lightpandaExecutablePath string, baseInstallDirForChromium string, verboseBrowser bool, lightpandaLog io.Writer) (
 	cmd *exec.Cmd, wsURL string, pwInstance *playwright.Playwright, pwBrowser playwright.Browser, lpStdout *bytes.Buffer, lpStderr *bytes.Buffer, err error) {
 
 	switch browserName {
@@ -81,8 +85,18 @@ func launchBrowserAndGetConnection(browserName string, lightpandaExecutablePath
 		actualWsURL := fmt.Sprintf("ws://%s:%d", host, port)
 
 		lightpandaCmd := exec.Command(lightpandaExecutablePath, "serve", "--host", host, "--port", fmt.Sprintf("%d", port))
-		lightpandaCmd.Stdout = stdoutBuf
-		lightpandaCmd.Stderr = stderrBuf
+		stdoutWriters := []io.Writer{stdoutBuf}
+		stderrWriters := []io.Writer{stderrBuf}
+		if verboseBrowser {
+			stdoutWriters = append(stdoutWriters, newLinePrefixWriter(logger.Writer(), "[lightpanda stdout] "))
+			stderrWriters = append(stderrWriters, newLinePrefixWriter(logger.Writer(), "[lightpanda stderr] "))
+		}
+		if lightpandaLog != nil {
+			stdoutWriters = append(stdoutWriters, newLinePrefixWriter(lightpandaLog, "[stdout] "))
+			stderrWriters = append(stderrWriters, newLinePrefixWriter(lightpandaLog, "[stderr] "))
+		}
+		lightpandaCmd.Stdout = io.MultiWriter(stdoutWriters...)
+		lightpandaCmd.Stderr = io.MultiWriter(stderrWriters...)
 
 		if errStart := lightpandaCmd.Start(); errStart != nil {
 			return nil, "", nil, nil, stdoutBuf, stderrBuf, fmt.Errorf("failed to start Lightpanda server (command: %s serve --host %s --port %d): %w", lightpandaExecutablePath, host, port, errStart)
@@ -111,6 +125,9 @@ func launchBrowserAndGetConnection(browserName string, lightpandaExecutablePath
 	case "chromium":
 		logger.Println("Launching Chromium via playwright-go...")
 
+		if errDriver := ensureDriverVersion(baseInstallDirForChromium); errDriver != nil {
+			return nil, "", nil, nil, nil, nil, errDriver
+		}
 		runOpts := playwright.RunOptions{DriverDirectory: baseInstallDirForChromium, Verbose: verboseBrowser}
 		pwRunInstance, errRun := playwright.Run(&runOpts)
 		if errRun != nil {
@@ -132,6 +149,32 @@ func launchBrowserAndGetConnection(browserName string, lightpandaExecutablePath
 	}
 }
 
+// shutdownBrowser closes the Playwright browser and instance and terminates the Lightpanda
+// process, whichever of them were started by launchBrowserAndGetConnection.
+func shutdownBrowser(browserName string, pwBrowser playwright.Browser, pwInstance *playwright.Playwright, lightpandaCmd *exec.Cmd) {
+	if pwBrowser != nil && pwBrowser.IsConnected() {
+		logger.Printf("Closing Playwright browser connection for %s...", browserName)
+		if err := pwBrowser.Close(); err != nil {
+			logger.Printf("Warning: failed to close Playwright browser for %s: %v", browserName, err)
+		}
+	}
+	if pwInstance != nil {
+		logger.Printf("Stopping Playwright instance for %s...", browserName)
+		if err := pwInstance.Stop(); err != nil {
+			logger.Printf("Warning: failed to stop Playwright instance for %s: %v", browserName, err)
+		}
+	}
+	if lightpandaCmd != nil && lightpandaCmd.Process != nil {
+		logger.Printf("Attempting to terminate Lightpanda process (PID: %d)...", lightpandaCmd.Process.Pid)
+		if killErr := lightpandaCmd.Process.Kill(); killErr != nil {
+			logger.Printf("Warning: failed to kill Lightpanda process (PID: %d): %v", lightpandaCmd.Process.Pid, killErr)
+		} else {
+			logger.Printf("Lightpanda process (PID: %d) terminated.", lightpandaCmd.Process.Pid)
+		}
+		_ = lightpandaCmd.Wait()
+	}
+}
+
 // waitForPort waits for a TCP port on a given host to become available for connection.
 func waitForPort(host string, port int, timeout time.Duration) error {
 	addr := fmt.Sprintf("%s:%d", host, port)
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// linePrefixWriter prefixes every line written through it, e.g. "[lightpanda stderr] ".
+// It does not buffer, so partial lines are forwarded immediately.
+type linePrefixWriter struct {
+	dst         io.Writer
+	prefix      []byte
+	atLineStart bool
+}
+
+func newLinePrefixWriter(dst io.Writer, prefix string) *linePrefixWriter {
+	return &linePrefixWriter{dst: dst, prefix: []byte(prefix), atLineStart: true}
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	var out bytes.Buffer
+	for _, b := range p {
+		if w.atLineStart {
+			out.Write(w.prefix)
+			w.atLineStart = false
+		}
+		out.WriteByte(b)
+		if b == '\n' {
+			w.atLineStart = true
+		}
+	}
+	if _, err := w.dst.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rotatingFileWriter appends to a log file and rotates it once it grows past maxBytes,
+// keeping up to maxBackups older files as path.1, path.2, ... It is safe for concurrent use.
+type rotatingFileWriter struct {
+	mu         sync.Mutex
+	path       string
+	maxBytes   int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func newRotatingFileWriter(path string, maxBytes int64, maxBackups int) (*rotatingFileWriter, error) {
+	w := &rotatingFileWriter{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingFileWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %w", w.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to stat log file %s: %w", w.path, err)
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+func (w *rotatingFileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file %s for rotation: %w", w.path, err)
+	}
+	if w.maxBackups > 0 {
+		for i := w.maxBackups - 1; i >= 1; i-- {
+			_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+		}
+		if err := os.Rename(w.path, w.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate log file %s: %w", w.path, err)
+		}
+	} else if err := os.Remove(w.path); err != nil {
+		return fmt.Errorf("failed to truncate log file %s: %w", w.path, err)
+	}
+	return w.open()
+}
+
+func (w *rotatingFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return 0, fmt.Errorf("log file %s is closed", w.path)
+	}
+	if w.maxBytes > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLinePrefixWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"single line", []string{"hello\n"}, "[lp] hello\n"},
+		{"multiple lines in one write", []string{"a\nb\n"}, "[lp] a\n[lp] b\n"},
+		{"line split across writes", []string{"hel", "lo\nwor", "ld\n"}, "[lp] hello\n[lp] world\n"},
+		{"trailing partial line", []string{"a\nb"}, "[lp] a\n[lp] b"},
+		{"empty write", []string{""}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := newLinePrefixWriter(&buf, "[lp] ")
+			for _, s := range tt.writes {
+				n, err := w.Write([]byte(s))
+				if err != nil {
+					t.Fatalf("Write(%q) error: %v", s, err)
+				}
+				if n != len(s) {
+					t.Errorf("Write(%q) = %d, want %d", s, n, len(s))
+				}
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotatingFileWriter(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lightpanda.log")
+
+	w, err := newRotatingFileWriter(path, 10, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFileWriter() error: %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write(%q) error: %v", line, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	wantFiles := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for p, want := range wantFiles {
+		got, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error: %v", p, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(p), got, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected no third backup, stat err = %v", err)
+	}
+
+	if _, err := w.Write([]byte("late\n")); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("Write after Close error = %v, want closed error", err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strconv"
+	"sync/atomic"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// byteBudget counts the bytes a crawl downloads against --max-bytes. A nil *byteBudget counts
+// nothing and is never exceeded.
+type byteBudget struct {
+	limit int64
+	used  atomic.Int64
+}
+
+// newByteBudget returns a budget of limit bytes, or nil if limit is 0.
+func newByteBudget(limit int64) *byteBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &byteBudget{limit: limit}
+}
+
+func (b *byteBudget) add(n int64) {
+	if b != nil && n > 0 {
+		b.used.Add(n)
+	}
+}
+
+// Used returns the bytes counted so far.
+func (b *byteBudget) Used() int64 {
+	if b == nil {
+		return 0
+	}
+	return b.used.Load()
+}
+
+// exceeded reports whether the crawl has downloaded at least the budget.
+func (b *byteBudget) exceeded() bool {
+	return b != nil && b.used.Load() >= b.limit
+}
+
+// watch counts the Content-Length of every response page receives, including images, scripts
+// and other subresources. Responses without one, such as chunked documents, are not counted
+// here; the crawl loop adds the size of such a page's HTML itself.
+func (b *byteBudget) watch(page playwright.Page) {
+	if b == nil || page == nil {
+		return
+	}
+	page.OnResponse(func(response playwright.Response) {
+		b.add(contentLength(response.Headers()))
+	})
+}
+
+// contentLength returns the Content-Length in headers (with lower-cased names), or 0 if it is
+// missing or invalid.
+func contentLength(headers map[string]string) int64 {
+	n, err := strconv.ParseInt(headers["content-length"], 10, 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestByteBudget(t *testing.T) {
+	var none *byteBudget
+	none.add(100)
+	if none.exceeded() || none.Used() != 0 {
+		t.Error("a nil budget counted bytes")
+	}
+	if newByteBudget(0) != nil {
+		t.Error("newByteBudget(0) is not nil")
+	}
+
+	b := newByteBudget(1000)
+	b.add(600)
+	b.add(-5)
+	if b.exceeded() {
+		t.Error("exceeded after 600 of 1000 bytes")
+	}
+	b.add(400)
+	if !b.exceeded() || b.Used() != 1000 {
+		t.Errorf("used %d, exceeded %t; want 1000 and exceeded", b.Used(), b.exceeded())
+	}
+}
+
+func TestContentLength(t *testing.T) {
+	tests := map[string]int64{"1234": 1234, "": 0, "abc": 0, "-1": 0}
+	for value, want := range tests {
+		if got := contentLength(map[string]string{"content-length": value}); got != want {
+			t.Errorf("contentLength(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
+
+func TestCrawlByteBudget(t *testing.T) {
+	page := func(title, link string) string {
+		return `<html><head><title>` + title + `</title></head><body><h1>` + title + `</h1><p>` + strings.Repeat("Filler text. ", 40) + `</p><a href="` + link + `">Next</a></body></html>`
+	}
+	pages := map[string]string{
+		"index.html": page("Start", "/one"),
+		"one.html":   page("One", "/two"),
+		"two.html":   page("Two", "/three"),
+	}
+	// Two pages exceed the budget, so the third is never fetched.
+	budget := newByteBudget(int64(len(page("Start", "/one"))) + 1)
+	_, result, _ := crawlFixtures(t, pages, fixtureCrawl{format: "json", opts: CrawlOptions{Bytes: budget}})
+	if result.PagesSaved != 2 || !strings.HasPrefix(result.StopReason, "Byte budget reached") {
+		t.Errorf("saved %d pages, stop reason %q; want 2 and the byte budget", result.PagesSaved, result.StopReason)
+	}
+	if result.BytesDownloaded != budget.Used() || result.BytesDownloaded <= budget.limit {
+		t.Errorf("BytesDownloaded = %d, want the %d bytes counted", result.BytesDownloaded, budget.Used())
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// canonicalLink returns the URL of doc's <link rel="canonical">, resolved against pageURL, or
+// nil if it has none.
+func canonicalLink(doc *goquery.Document, pageURL *url.URL) *url.URL {
+	href, ok := doc.Find(`link[rel~="canonical" i]`).First().Attr("href")
+	if !ok || strings.TrimSpace(href) == "" {
+		return nil
+	}
+	canonical, err := pageURL.Parse(strings.TrimSpace(href))
+	if err != nil || (canonical.Scheme != "http" && canonical.Scheme != "https") {
+		return nil
+	}
+	return canonical
+}
+
+// pageCanonical returns the normalized canonical URL declared by htmlContent for
+// --respect-canonical, or "" if the page declares none.
+func pageCanonical(pageURL *url.URL, htmlContent string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
+	if err != nil {
+		return ""
+	}
+	canonical := canonicalLink(doc, pageURL)
+	if canonical == nil {
+		return ""
+	}
+	normalized, err := normalizeURLtoString(canonical.String())
+	if err != nil {
+		return ""
+	}
+	return normalized
+}
+
+// canonicalDuplicate reports the page already saved for canonical, the URL a page declares as
+// canonical or the page's own URL if it declares none. It is "" without --respect-canonical.
+func (c *Crawler) canonicalDuplicate(canonical string) string {
+	if !c.opts.RespectCanonical {
+		return ""
+	}
+	return c.savedCanonicals[canonical]
+}
+
+// recordCanonical remembers that pageURL was saved as the page for canonical.
+func (c *Crawler) recordCanonical(canonical string, pageURL string) {
+	if !c.opts.RespectCanonical {
+		return
+	}
+	if c.savedCanonicals == nil {
+		c.savedCanonicals = make(map[string]string)
+	}
+	c.savedCanonicals[canonical] = pageURL
+}
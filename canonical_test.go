@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPageCanonical(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/blog/?page=2")
+	tests := []struct {
+		name string
+		head string
+		want string
+	}{
+		{"relative", `<link rel="canonical" href="/blog/">`, "https://example.com/blog"},
+		{"absolute with fragment", `<link rel="canonical" href="https://example.com/post#top">`, "https://example.com/post"},
+		{"mixed case and several rels", `<link rel="Canonical alternate" href="/blog/">`, "https://example.com/blog"},
+		{"none", `<link rel="alternate" href="/feed.xml">`, ""},
+		{"empty href", `<link rel="canonical" href=" ">`, ""},
+		{"not http", `<link rel="canonical" href="javascript:void(0)">`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := "<html><head>" + tt.head + "</head><body></body></html>"
+			if got := pageCanonical(pageURL, html); got != tt.want {
+				t.Errorf("pageCanonical() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrawlRespectCanonical(t *testing.T) {
+	page := func(title, head, links string) string {
+		return `<html><head><title>` + title + `</title>` + head + `</head><body><h1>` + title + `</h1><p>Content of the ` + title + ` page.</p>` + links + `</body></html>`
+	}
+	// Fixture files ignore query strings, so every /list variant serves list.html.
+	pages := map[string]string{
+		"index.html": page("Home", "", `<a href="/list?sort=asc">Sorted</a> <a href="/list">List</a> <a href="/list?sort=desc">Reversed</a> <a href="/about">About</a>`),
+		"list.html":  page("List", `<link rel="canonical" href="/list">`, ""),
+		"about.html": page("About", "", ""),
+	}
+	_, result, outfile := crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{RespectCanonical: true}})
+	if result.PagesSaved != 3 {
+		t.Errorf("saved %d pages, want 3 (home, one list view, about)", result.PagesSaved)
+	}
+	if len(result.SkippedPages) != 2 || !strings.Contains(result.SkippedPages[0].Reason, "canonical URL https://example.com/list was already saved from https://example.com/list?sort=asc") {
+		t.Errorf("skipped pages = %+v", result.SkippedPages)
+	}
+	out, err := os.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(out), `"canonical":"https://example.com/list"`); got != 1 {
+		t.Errorf("output records the canonical URL %d times, want once:\n%s", got, out)
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hokupod/sitepanda/cmd"
+)
+
+// cassetteEntry is one recorded exchange, stored as <key>.json in the cassette directory.
+type cassetteEntry struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
+
+// hopByHopHeaders are connection-level headers that a proxy must not forward or record.
+var hopByHopHeaders = []string{"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// cassetteKey identifies a request in the cassette: its method, URL and a hash of its body.
+// Headers are ignored so that cookies and user agents do not break replay.
+func cassetteKey(method, rawURL string, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", method, rawURL)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// cassetteProxy is an HTTP proxy that records responses into a cassette directory or replays
+// them from it. HTTPS requests are intercepted with certificates signed by an ephemeral CA.
+type cassetteProxy struct {
+	dir      string
+	record   bool
+	upstream http.RoundTripper
+
+	ca      *x509.Certificate
+	caKey   *ecdsa.PrivateKey
+	mu      sync.Mutex
+	certs   map[string]*tls.Certificate
+	leafKey *ecdsa.PrivateKey
+}
+
+func newCassetteProxy(dir string, record bool) (*cassetteProxy, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "Sitepanda proxy CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &cassetteProxy{
+		dir:      dir,
+		record:   record,
+		upstream: &http.Transport{Proxy: nil, DisableCompression: true, TLSHandshakeTimeout: 30 * time.Second, ResponseHeaderTimeout: 60 * time.Second},
+		ca:       ca,
+		caKey:    caKey,
+		certs:    make(map[string]*tls.Certificate),
+		leafKey:  leafKey,
+	}, nil
+}
+
+// certificate returns a certificate for the TLS client's server name, signed by the proxy CA.
+func (p *cassetteProxy) certificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := hello.ServerName
+	if host == "" {
+		if addr, ok := hello.Conn.LocalAddr().(*net.TCPAddr); ok {
+			host = addr.IP.String()
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if cert, ok := p.certs[host]; ok {
+		return cert, nil
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	} else {
+		template.DNSNames = []string{host}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, p.ca, &p.leafKey.PublicKey, p.caKey)
+	if err != nil {
+		return nil, err
+	}
+	cert := &tls.Certificate{Certificate: [][]byte{der, p.ca.Raw}, PrivateKey: p.leafKey}
+	p.certs[host] = cert
+	return cert, nil
+}
+
+// ServeHTTP handles plain HTTP proxy requests and CONNECT tunnels.
+func (p *cassetteProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveTunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "sitepanda proxy: only proxy requests are supported", http.StatusBadRequest)
+		return
+	}
+	entry := p.exchange(r)
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Content-Length", fmt.Sprint(len(entry.Body)))
+	w.WriteHeader(entry.Status)
+	w.Write(entry.Body)
+}
+
+// serveTunnel terminates TLS for a CONNECT tunnel and serves the HTTP requests sent through it.
+func (p *cassetteProxy) serveTunnel(w http.ResponseWriter, r *http.Request) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "sitepanda proxy: tunnels are not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		return
+	}
+	tlsConn := tls.Server(conn, &tls.Config{GetCertificate: p.certificate, NextProtos: []string{"http/1.1"}})
+	if err := tlsConn.Handshake(); err != nil {
+		logger.Printf("Proxy: TLS handshake for %s failed: %v", r.Host, err)
+		return
+	}
+	host := strings.TrimSuffix(r.Host, ":443")
+	reader := bufio.NewReader(tlsConn)
+	for {
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+		req.URL.Scheme = "https"
+		req.URL.Host = host
+		entry := p.exchange(req)
+		resp := &http.Response{
+			StatusCode:    entry.Status,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        entry.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Close:         req.Close,
+			Request:       req,
+		}
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		if err := resp.Write(tlsConn); err != nil || req.Close {
+			return
+		}
+	}
+}
+
+// exchange returns the response to req: fetched from the network and saved when recording,
+// read from the cassette when replaying. Failures become 502 responses.
+func (p *cassetteProxy) exchange(req *http.Request) *cassetteEntry {
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return proxyErrorEntry(req, fmt.Errorf("failed to read request body: %w", err))
+	}
+	key := cassetteKey(req.Method, req.URL.String(), body)
+	path := filepath.Join(p.dir, key+".json")
+
+	if !p.record {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Printf("Proxy: %s %s is not in the cassette", req.Method, req.URL)
+			if req.URL.Path == "/robots.txt" {
+				// Cassettes recorded with --ignore-robots or before robots.txt support have none;
+				// a 502 would make the crawler treat the whole host as disallowed.
+				return &cassetteEntry{Method: req.Method, URL: req.URL.String(), Status: http.StatusNotFound}
+			}
+			return proxyErrorEntry(req, errors.New("not recorded in the cassette"))
+		}
+		var entry cassetteEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return proxyErrorEntry(req, fmt.Errorf("invalid cassette entry %s: %w", path, err))
+		}
+		logger.Printf("Proxy: replayed %s %s (%d)", req.Method, req.URL, entry.Status)
+		return &entry
+	}
+
+	out, err := http.NewRequestWithContext(req.Context(), req.Method, req.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return proxyErrorEntry(req, err)
+	}
+	out.Header = req.Header.Clone()
+	for _, name := range hopByHopHeaders {
+		out.Header.Del(name)
+	}
+	resp, err := p.upstream.RoundTrip(out)
+	if err != nil {
+		logger.Printf("Proxy: %s %s failed: %v", req.Method, req.URL, err)
+		return proxyErrorEntry(req, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return proxyErrorEntry(req, fmt.Errorf("failed to read response: %w", err))
+	}
+	header := resp.Header.Clone()
+	for _, name := range hopByHopHeaders {
+		header.Del(name)
+	}
+	header.Del("Content-Length")
+	entry := &cassetteEntry{Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Header: header, Body: respBody, RecordedAt: time.Now().UTC()}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		logger.Printf("Proxy: failed to record %s %s: %v", req.Method, req.URL, err)
+	} else {
+		logger.Printf("Proxy: recorded %s %s (%d)", req.Method, req.URL, resp.StatusCode)
+	}
+	return entry
+}
+
+func proxyErrorEntry(req *http.Request, err error) *cassetteEntry {
+	return &cassetteEntry{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Status: http.StatusBadGateway,
+		Header: http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+		Body:   []byte("sitepanda proxy: " + err.Error() + "\n"),
+	}
+}
+
+// HandleProxy runs 'sitepanda proxy' until it is interrupted.
+func HandleProxy() {
+	dir, record := cmd.GetProxyReplay(), false
+	if recordDir := cmd.GetProxyRecord(); recordDir != "" {
+		dir, record = recordDir, true
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			logger.Fatalf("Error: failed to create cassette directory %s: %v", dir, err)
+		}
+	} else if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		logger.Fatalf("Error: cassette directory %s does not exist.", dir)
+	}
+
+	proxy, err := newCassetteProxy(dir, record)
+	if err != nil {
+		logger.Fatalf("Error: failed to create proxy certificate authority: %v", err)
+	}
+	listener, err := net.Listen("tcp", cmd.GetProxyListen())
+	if err != nil {
+		logger.Fatalf("Error: failed to listen on %s: %v", cmd.GetProxyListen(), err)
+	}
+	server := &http.Server{Handler: proxy}
+
+	mode := "Replaying from"
+	if record {
+		mode = "Recording into"
+	}
+	logger.Printf("%s cassette %s. Proxy listening on http://%s", mode, dir, listener.Addr())
+	logger.Printf("Crawl through it with: sitepanda scrape --proxy http://%s ...", listener.Addr())
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Fatalf("Error: proxy stopped: %v", err)
+	}
+	logger.Println("Proxy stopped.")
+}
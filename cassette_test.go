@@ -0,0 +1,109 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// proxyClient returns a client that sends requests through proxy and trusts its CA.
+func proxyClient(t *testing.T, proxy *cassetteProxy) *http.Client {
+	t.Helper()
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+	proxyURL, _ := url.Parse(server.URL)
+	roots := x509.NewCertPool()
+	roots.AddCert(proxy.ca)
+	return &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots},
+	}}
+}
+
+func get(t *testing.T, client *http.Client, rawURL string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", rawURL, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestCassetteProxyRecordAndReplay(t *testing.T) {
+	var hits atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<h1>%s</h1>", r.URL.Path)
+	})
+	plain := httptest.NewServer(handler)
+	secure := httptest.NewTLSServer(handler)
+	dir := t.TempDir()
+
+	recorder, err := newCassetteProxy(dir, true)
+	if err != nil {
+		t.Fatalf("newCassetteProxy() error = %v", err)
+	}
+	recorder.upstream = secure.Client().Transport
+	client := proxyClient(t, recorder)
+	for _, u := range []string{plain.URL + "/a", secure.URL + "/b"} {
+		if status, body := get(t, client, u); status != http.StatusOK || !strings.Contains(body, "<h1>") {
+			t.Fatalf("recording %s: got %d %q", u, status, body)
+		}
+	}
+	entries, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(entries) != 2 {
+		t.Fatalf("cassette has %d entries, want 2", len(entries))
+	}
+
+	plain.Close()
+	secure.Close()
+	recordedHits := hits.Load()
+
+	replayer, err := newCassetteProxy(dir, false)
+	if err != nil {
+		t.Fatalf("newCassetteProxy() error = %v", err)
+	}
+	client = proxyClient(t, replayer)
+	if status, body := get(t, client, plain.URL+"/a"); status != http.StatusOK || body != "<h1>/a</h1>" {
+		t.Errorf("replaying http: got %d %q", status, body)
+	}
+	if status, body := get(t, client, secure.URL+"/b"); status != http.StatusOK || body != "<h1>/b</h1>" {
+		t.Errorf("replaying https: got %d %q", status, body)
+	}
+	if status, _ := get(t, client, secure.URL+"/not-recorded"); status != http.StatusBadGateway {
+		t.Errorf("unrecorded request: got %d, want 502", status)
+	}
+	if status, _ := get(t, client, secure.URL+"/robots.txt"); status != http.StatusNotFound {
+		t.Errorf("unrecorded robots.txt: got %d, want 404", status)
+	}
+	if hits.Load() != recordedHits {
+		t.Error("replay reached the network")
+	}
+}
+
+func TestCassetteKey(t *testing.T) {
+	a := cassetteKey("GET", "https://example.com/", nil)
+	if a != cassetteKey("GET", "https://example.com/", []byte{}) {
+		t.Error("nil and empty bodies should have the same key")
+	}
+	for _, other := range []string{
+		cassetteKey("HEAD", "https://example.com/", nil),
+		cassetteKey("GET", "https://example.com/?q=1", nil),
+		cassetteKey("GET", "https://example.com/", []byte("x")),
+	} {
+		if other == a {
+			t.Errorf("distinct requests share key %s", a)
+		}
+	}
+}
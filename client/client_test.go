@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/fetch", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "a valid API key is required", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"url":"https://example.com/","title":"Example","content":"# Example","tags":["docs"]}`)
+	})
+	mux.HandleFunc("GET /api/jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id":"`+r.PathValue("id")+`","url":"https://example.com/","state":"running","processed":1,"saved":1,"queued":3}`)
+	})
+	mux.HandleFunc("GET /api/jobs/{id}/result", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, `{"error":"the scrape is still running"}`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c, err := NewClientWithResponses(server.URL, WithAPIKey("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	fetched, err := c.FetchWithResponse(ctx, ScrapeRequest{URL: "https://example.com/"})
+	if err != nil || fetched.JSON200 == nil || fetched.JSON200.Title != "Example" || len(*fetched.JSON200.Tags) != 1 {
+		t.Errorf("Fetch() = %+v, %v; want the page", fetched, err)
+	}
+	result, err := c.GetJobResultWithResponse(ctx, "1")
+	if err != nil || result.StatusCode() != http.StatusConflict || result.JSON409 == nil || *result.JSON409.Error != "the scrape is still running" {
+		t.Errorf("GetJobResult() = %+v, %v; want 409 with the JSON message", result, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	job, err := c.WaitForJob(ctx, "7", 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) || job == nil || job.ID != "7" || job.Queued != 3 || job.Finished() {
+		t.Errorf("WaitForJob() = %+v, %v; want the running job and a deadline error", job, err)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Bench flags
+	benchPages   int
+	benchEngines []string
+)
+
+// BenchHandler is a function that handles the bench command
+// It will be set by the main package
+var BenchHandler func(string)
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench <url>",
+	Short: "Compare scraping engines on a sample of pages",
+	Long: `Crawl the same sample of pages with each engine (Chromium, Lightpanda, and a plain
+HTTP fetcher without JavaScript) and report per-engine startup time, crawl speed,
+success rate and extracted content length. Use it to pick --browser for a target site.
+
+The sample is discovered by following same-host links from <url> over plain HTTP.
+
+Examples:
+  sitepanda bench https://example.com
+  sitepanda bench --pages 50 https://example.com
+  sitepanda bench --engines chromium,http https://example.com`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, engine := range benchEngines {
+			if engine != "chromium" && engine != "lightpanda" && engine != "http" {
+				return fmt.Errorf("unsupported engine %q for --engines (supported: chromium, lightpanda, http)", engine)
+			}
+		}
+		if BenchHandler != nil {
+			BenchHandler(args[0])
+		} else {
+			fmt.Printf("Error: Bench handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().IntVar(&benchPages, "pages", 20, "Number of pages to sample")
+	benchCmd.Flags().StringSliceVar(&benchEngines, "engines", []string{"chromium", "lightpanda", "http"}, "Engines to compare (chromium, lightpanda, http)")
+}
+
+// Getter functions for main package to access flag values
+func GetBenchPages() int        { return benchPages }
+func GetBenchEngines() []string { return benchEngines }
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// Browser daemon flags
+	browserDaemonForeground bool
+)
+
+// BrowserDaemonHandler is a function that handles the browser start/stop/status commands
+// It will be set by the main package
+var BrowserDaemonHandler func(action string)
+
+// browserDaemonCmd represents the browser command
+var browserDaemonCmd = &cobra.Command{
+	Use:   "browser",
+	Short: "Manage a long-lived background browser",
+	Long: `Run a managed browser in the background so that subsequent scrape invocations
+connect to it instantly instead of paying the browser startup cost every time.
+
+While a daemon for the selected --browser is running, 'sitepanda scrape' uses it
+automatically (disable with --no-daemon).
+
+Examples:
+  sitepanda browser start
+  sitepanda browser start --browser lightpanda
+  sitepanda browser status
+  sitepanda browser stop`,
+}
+
+func newBrowserDaemonActionCmd(action, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action,
+		Short: short,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if BrowserDaemonHandler != nil {
+				BrowserDaemonHandler(action)
+			} else {
+				fmt.Printf("Error: Browser daemon handler not set. Please report this issue.\n")
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(browserDaemonCmd)
+
+	startCmd := newBrowserDaemonActionCmd("start", "Start the background browser for --browser")
+	startCmd.Flags().BoolVar(&browserDaemonForeground, "foreground", false, "Run the daemon in the foreground (used internally)")
+	_ = startCmd.Flags().MarkHidden("foreground")
+
+	browserDaemonCmd.AddCommand(startCmd)
+	browserDaemonCmd.AddCommand(newBrowserDaemonActionCmd("stop", "Stop the background browser for --browser"))
+	browserDaemonCmd.AddCommand(newBrowserDaemonActionCmd("status", "Show whether a background browser is running for --browser"))
+}
+
+// Getter functions for main package to access flag values
+func GetBrowserDaemonForeground() bool { return browserDaemonForeground }
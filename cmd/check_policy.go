@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// CheckPolicyHandler handles the check-policy command. It will be set by the main package.
+var CheckPolicyHandler func(string, []string)
+
+// checkPolicyCmd represents the check-policy command
+var checkPolicyCmd = &cobra.Command{
+	Use:   "check-policy <policy.yaml> [url...]",
+	Short: "Validate a crawl policy file and show which rule applies to URLs",
+	Long: `Validates a --policy file for 'sitepanda scrape' and lists its rules. For each
+URL given, prints the rule that applies to it (the first rule whose path glob
+matches the URL path) and what the crawler will do with the page.
+
+Exits with status 1 if the policy is invalid, so it can run in CI before a
+scheduled crawl.
+
+Examples:
+  sitepanda check-policy policy.yaml
+  sitepanda check-policy policy.yaml https://example.com/blog/2024/post https://example.com/tag/go`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if CheckPolicyHandler != nil {
+			CheckPolicyHandler(args[0], args[1:])
+		} else {
+			fmt.Printf("Error: Check-policy handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkPolicyCmd)
+}
@@ -254,7 +254,7 @@ func TestScrapeCommand(t *testing.T) {
 			rootCmd := &cobra.Command{Use: "sitepanda"}
 
 			// Reset flags to default values
-			outfile = ""
+			outfiles = []string{}
 			urlFile = ""
 			matchPatterns = []string{}
 			followMatchPatterns = []string{}
@@ -290,7 +290,7 @@ func TestScrapeCommand(t *testing.T) {
 			}
 
 			// Add the same flags as the real scrape command
-			testScrapeCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "Write the fetched site to a text file")
+			testScrapeCmd.Flags().StringArrayVarP(&outfiles, "outfile", "o", []string{}, "Write the fetched site to a text file")
 			testScrapeCmd.Flags().StringVar(&urlFile, "url-file", "", "Path to a file containing URLs to process")
 			testScrapeCmd.Flags().StringSliceVarP(&matchPatterns, "match", "m", []string{}, "Only extract content from matched pages")
 
@@ -340,7 +340,7 @@ func TestFlagGetters(t *testing.T) {
 	}
 
 	// Test scrape flag getters
-	outfile = "test.txt"
+	outfiles = []string{"test.txt", "s3://bucket/test.txt"}
 	urlFile = "urls.txt"
 	matchPatterns = []string{"/test", "/example"}
 	followMatchPatterns = []string{"/follow"}
@@ -351,6 +351,9 @@ func TestFlagGetters(t *testing.T) {
 	if GetOutfile() != "test.txt" {
 		t.Errorf("Expected outfile to be 'test.txt', got %q", GetOutfile())
 	}
+	if got := GetOutfiles(); len(got) != 2 || got[1] != "s3://bucket/test.txt" {
+		t.Errorf("Expected two outfiles, got %v", got)
+	}
 
 	if GetURLFile() != "urls.txt" {
 		t.Errorf("Expected urlFile to be 'urls.txt', got %q", GetURLFile())
@@ -401,3 +404,109 @@ func TestEnvironmentVariableBrowserDefault(t *testing.T) {
 		t.Errorf("Expected default browser to be 'lightpanda' when env var is set, got %q", defaultBrowser)
 	}
 }
+
+func TestBindEnvToFlags(t *testing.T) {
+	newCmd := func() (*cobra.Command, *string, *[]string, *int) {
+		var out string
+		var match []string
+		var limit int
+		c := &cobra.Command{Use: "scrape"}
+		c.Flags().StringVarP(&out, "outfile", "o", "", "")
+		c.Flags().StringSliceVar(&match, "match", []string{}, "")
+		c.Flags().IntVar(&limit, "limit", 0, "")
+		c.Flags().Bool("version", false, "")
+		return c, &out, &match, &limit
+	}
+
+	t.Run("Environment fills unset flags", func(t *testing.T) {
+		t.Setenv("SITEPANDA_OUTFILE", "env.json")
+		t.Setenv("SITEPANDA_MATCH", "/blog/**,/docs/**")
+		t.Setenv("SITEPANDA_LIMIT", "5")
+		c, out, match, limit := newCmd()
+		if err := c.ParseFlags([]string{}); err != nil {
+			t.Fatalf("ParseFlags failed: %v", err)
+		}
+		if err := bindEnvToFlags(c); err != nil {
+			t.Fatalf("bindEnvToFlags returned error: %v", err)
+		}
+		if *out != "env.json" {
+			t.Errorf("Expected outfile from env to be 'env.json', got %q", *out)
+		}
+		if len(*match) != 2 || (*match)[0] != "/blog/**" || (*match)[1] != "/docs/**" {
+			t.Errorf("Expected match patterns from env, got %v", *match)
+		}
+		if *limit != 5 {
+			t.Errorf("Expected limit from env to be 5, got %d", *limit)
+		}
+	})
+
+	t.Run("Command line takes precedence", func(t *testing.T) {
+		t.Setenv("SITEPANDA_OUTFILE", "env.json")
+		c, out, _, _ := newCmd()
+		if err := c.ParseFlags([]string{"--outfile", "cli.json"}); err != nil {
+			t.Fatalf("ParseFlags failed: %v", err)
+		}
+		if err := bindEnvToFlags(c); err != nil {
+			t.Fatalf("bindEnvToFlags returned error: %v", err)
+		}
+		if *out != "cli.json" {
+			t.Errorf("Expected outfile from command line to be 'cli.json', got %q", *out)
+		}
+	})
+
+	t.Run("Invalid value reports variable name", func(t *testing.T) {
+		t.Setenv("SITEPANDA_LIMIT", "many")
+		c, _, _, _ := newCmd()
+		if err := c.ParseFlags([]string{}); err != nil {
+			t.Fatalf("ParseFlags failed: %v", err)
+		}
+		err := bindEnvToFlags(c)
+		if err == nil || !strings.Contains(err.Error(), "SITEPANDA_LIMIT") {
+			t.Errorf("Expected error mentioning SITEPANDA_LIMIT, got %v", err)
+		}
+	})
+
+	t.Run("Excluded flags are ignored", func(t *testing.T) {
+		t.Setenv("SITEPANDA_VERSION", "true")
+		c, _, _, _ := newCmd()
+		if err := c.ParseFlags([]string{}); err != nil {
+			t.Fatalf("ParseFlags failed: %v", err)
+		}
+		if err := bindEnvToFlags(c); err != nil {
+			t.Fatalf("bindEnvToFlags returned error: %v", err)
+		}
+		if v, _ := c.Flags().GetBool("version"); v {
+			t.Errorf("Expected --version to ignore SITEPANDA_VERSION")
+		}
+	})
+}
+
+func TestEnvVarForFlag(t *testing.T) {
+	if got := envVarForFlag("follow-match"); got != "SITEPANDA_FOLLOW_MATCH" {
+		t.Errorf("Expected SITEPANDA_FOLLOW_MATCH, got %q", got)
+	}
+	if got := envVarForFlag("outfile"); got != "SITEPANDA_OUTFILE" {
+		t.Errorf("Expected SITEPANDA_OUTFILE, got %q", got)
+	}
+}
+
+func TestGetEffectiveConfig(t *testing.T) {
+	oldKey, oldLimit := searchAPIKey, pageLimit
+	defer func() { searchAPIKey, pageLimit = oldKey, oldLimit }()
+	searchAPIKey = "secret-key"
+	pageLimit = 42
+
+	config := GetEffectiveConfig()
+	if got := config["search-api-key"]; got != "<redacted>" {
+		t.Errorf("search-api-key = %q, want <redacted>", got)
+	}
+	if got := config["limit"]; got != "42" {
+		t.Errorf("limit = %q, want 42", got)
+	}
+	if got, ok := config["smtp-password"]; !ok || got != "" {
+		t.Errorf("unset smtp-password = %q (present %v), want empty and present", got, ok)
+	}
+	if _, ok := config["help"]; ok {
+		t.Error("help flag should not be part of the configuration")
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// envPrefix is prepended to the upper-cased flag name to form its environment variable.
+const envPrefix = "SITEPANDA_"
+
+// envBindingExclusions lists flags that are never read from the environment.
+// --browser has its own validated default in root.go (SITEPANDA_BROWSER).
+var envBindingExclusions = map[string]bool{
+	"help":    true,
+	"version": true,
+	"browser": true,
+}
+
+// envVarForFlag returns the environment variable name bound to a flag,
+// e.g. "follow-match" -> "SITEPANDA_FOLLOW_MATCH".
+func envVarForFlag(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// bindEnvToFlags applies SITEPANDA_* environment variables to every flag of cmd
+// that was not set explicitly on the command line. Command-line flags always win.
+// Slice flags accept comma-separated values, mirroring repeated flags.
+func bindEnvToFlags(cmd *cobra.Command) error {
+	var bindErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if bindErr != nil || f.Changed || envBindingExclusions[f.Name] {
+			return
+		}
+		envName := envVarForFlag(f.Name)
+		value, ok := os.LookupEnv(envName)
+		if !ok || value == "" {
+			return
+		}
+		if err := cmd.Flags().Set(f.Name, value); err != nil {
+			bindErr = fmt.Errorf("invalid value %q in environment variable %s for --%s: %w", value, envName, f.Name, err)
+		}
+	})
+	return bindErr
+}
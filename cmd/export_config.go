@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// export-config / import-config flags
+	exportConfigFormat string
+	importConfigFormat string
+)
+
+// ExportConfigHandler and ImportConfigHandler handle the export-config and import-config commands.
+// They will be set by the main package.
+var (
+	ExportConfigHandler func(string)
+	ImportConfigHandler func(string)
+)
+
+// exportConfigCmd represents the export-config command
+var exportConfigCmd = &cobra.Command{
+	Use:   "export-config <url>",
+	Short: "Translate scrape options into a Firecrawl or Scrapy configuration",
+	Long: `Print the configuration another crawler needs to crawl <url> the way
+'sitepanda scrape' would with the same --match, --follow-match, --limit,
+--content-selector, --wait-for-network-idle and --dom-rule options.
+
+--format firecrawl prints a Firecrawl /v1/crawl request body (JSON);
+--format scrapy prints a Scrapy CrawlSpider module. Options without an exact
+equivalent are reported on stderr.
+
+Examples:
+  sitepanda export-config --format firecrawl --follow-match "/docs/**" --limit 100 https://example.com/docs/
+  sitepanda export-config --format scrapy --content-selector "main article" https://example.com > spider.py`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportConfigFormat != "firecrawl" && exportConfigFormat != "scrapy" {
+			return fmt.Errorf("unsupported --format %q (supported: firecrawl, scrapy)", exportConfigFormat)
+		}
+		if ExportConfigHandler != nil {
+			ExportConfigHandler(args[0])
+		} else {
+			fmt.Printf("Error: Export config handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// importConfigCmd represents the import-config command
+var importConfigCmd = &cobra.Command{
+	Use:   "import-config <file>",
+	Short: "Translate Firecrawl crawl options into a sitepanda scrape command",
+	Long: `Read a Firecrawl /v1/crawl request body (JSON) and print the equivalent
+'sitepanda scrape' command line. Options that cannot be translated are
+reported on stderr.
+
+Example:
+  sitepanda import-config --format firecrawl crawl.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if importConfigFormat != "firecrawl" {
+			return fmt.Errorf("unsupported --format %q (supported: firecrawl)", importConfigFormat)
+		}
+		if ImportConfigHandler != nil {
+			ImportConfigHandler(args[0])
+		} else {
+			fmt.Printf("Error: Import config handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportConfigCmd)
+	rootCmd.AddCommand(importConfigCmd)
+
+	exportConfigCmd.Flags().StringVar(&exportConfigFormat, "format", "", "Target crawler (firecrawl, scrapy)")
+	_ = exportConfigCmd.MarkFlagRequired("format")
+	// The scrape options that have an equivalent in other crawlers share their variables with scrape.
+	exportConfigCmd.Flags().StringSliceVarP(&matchPatterns, "match", "m", []string{}, "Only extract content from matched pages (glob pattern, can be specified multiple times)")
+	exportConfigCmd.Flags().StringSliceVar(&followMatchPatterns, "follow-match", []string{}, "Only add links matching this glob pattern to the crawl queue (can be specified multiple times)")
+	exportConfigCmd.Flags().StringSliceVar(&excludeMatch, "exclude-match", []string{}, "Do not extract content from pages matching this glob pattern (can be specified multiple times)")
+	exportConfigCmd.Flags().StringSliceVar(&excludeFollow, "exclude-follow", []string{}, "Do not add links matching this glob pattern to the crawl queue (can be specified multiple times)")
+	exportConfigCmd.Flags().IntVar(&pageLimit, "limit", 0, "Stop crawling once this many pages have had their content saved (0 for no limit)")
+	exportConfigCmd.Flags().IntVar(&maxDepth, "depth", 0, "Do not follow links on pages this many links away from the start URL (0 for no limit)")
+	exportConfigCmd.Flags().StringVar(&contentSelector, "content-selector", "", "CSS selector of the main content area")
+	exportConfigCmd.Flags().BoolVarP(&waitForNetworkIdle, "wait-for-network-idle", "w", false, "Wait for network to be idle instead of just load when fetching pages")
+	exportConfigCmd.Flags().StringArrayVar(&domRules, "dom-rule", []string{}, "DOM rule as for scrape; remove:<selector> rules are exported")
+
+	importConfigCmd.Flags().StringVar(&importConfigFormat, "format", "firecrawl", "Source crawler (firecrawl)")
+}
+
+// Getter functions for main package to access flag values
+func GetExportConfigFormat() string { return exportConfigFormat }
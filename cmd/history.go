@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// history flags
+	historyLast      int
+	historyCompare   bool
+	historyBaseline  int
+	historyThreshold float64
+	// historyFile is shared with scrape, which appends to it.
+	historyFile string
+
+	// history show flags
+	snapshotDB      string
+	snapshotVersion int
+)
+
+// HistoryHandler handles the history command. It will be set by the main package.
+var HistoryHandler func(string)
+
+// HistoryShowHandler handles the history show command. It will be set by the main package.
+var HistoryShowHandler func(string)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history [domain]",
+	Short: "List and compare past scrape runs",
+	Long: `'sitepanda scrape --history' appends the run's statistics (start URL, pages
+saved, skipped and failed per domain, duration, status) to a history file in
+Sitepanda's data directory. 'sitepanda history' lists recent runs, optionally
+only those of one domain.
+
+With --compare, the latest run of each domain is compared with the median of its
+previous runs. A run that saved noticeably fewer pages or failed on noticeably
+more pages than usual (see --threshold) is reported as an anomaly and the
+command exits with status 1, so it can gate scheduled jobs.
+
+Examples:
+  sitepanda history
+  sitepanda history docs.example.com --last 5
+  sitepanda history --compare --baseline 7 --threshold 0.3
+  sitepanda history show https://example.com/docs/ --db pages.db`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		domain := ""
+		if len(args) > 0 {
+			domain = args[0]
+		}
+		if HistoryHandler != nil {
+			HistoryHandler(domain)
+		} else {
+			fmt.Printf("Error: History handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+	},
+}
+
+// historyShowCmd represents the history show command
+var historyShowCmd = &cobra.Command{
+	Use:   "show <url>",
+	Short: "List the stored versions of a page",
+	Long: `'sitepanda scrape --outfile sqlite://pages.db' keeps every distinct version of
+each crawled page in a SQLite database instead of overwriting an output file. A
+page gets a new version whenever its content changes; an unchanged page only
+updates the time it was last fetched.
+
+'sitepanda history show' lists the versions of a page with the time each was
+first and last fetched and its content hash. --version prints the content of
+one version, counted from 1 (oldest) or from -1 (latest).
+
+Examples:
+  sitepanda history show https://example.com/docs/ --db pages.db
+  sitepanda history show https://example.com/docs/ --db pages.db --version -1`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if HistoryShowHandler != nil {
+			HistoryShowHandler(args[0])
+		} else {
+			fmt.Printf("Error: History show handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyShowCmd)
+
+	historyCmd.Flags().IntVar(&historyLast, "last", 20, "Number of most recent entries to list (0 for all)")
+	historyCmd.Flags().BoolVar(&historyCompare, "compare", false, "Compare each domain's latest run with its previous runs and exit with status 1 on an anomaly")
+	historyCmd.Flags().IntVar(&historyBaseline, "baseline", 10, "Number of previous runs the comparison median is taken over (0 for all)")
+	historyCmd.Flags().Float64Var(&historyThreshold, "threshold", 0.5, "Relative deviation from the median that counts as an anomaly, between 0 and 1")
+	historyShowCmd.Flags().StringVar(&snapshotDB, "db", "", "Snapshot database written by 'scrape --outfile sqlite://<path>'")
+	historyShowCmd.Flags().IntVar(&snapshotVersion, "version", 0, "Print the content of this version (1 is the oldest, -1 the latest) instead of listing versions")
+	_ = historyShowCmd.MarkFlagRequired("db")
+	historyCmd.Flags().StringVar(&historyFile, "history-file", "", "Run history file (default: history/runs.jsonl in Sitepanda's data directory)")
+}
+
+// Getter functions for main package to access flag values
+func GetHistoryLast() int          { return historyLast }
+func GetHistoryCompare() bool      { return historyCompare }
+func GetHistoryBaseline() int      { return historyBaseline }
+func GetHistoryThreshold() float64 { return historyThreshold }
+func GetHistoryFile() string       { return historyFile }
+func GetSnapshotDB() string        { return snapshotDB }
+func GetSnapshotVersion() int      { return snapshotVersion }
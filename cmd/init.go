@@ -7,6 +7,12 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	// init flags
+	initMinimal bool
+	initVerify  bool
+)
+
 // initCmd represents the init command
 var initCmd = &cobra.Command{
 	Use:   "init [browser]",
@@ -17,7 +23,24 @@ Supported browsers:
 - chromium (default): Downloads Chromium via Playwright
 - lightpanda: Downloads Lightpanda binary
 
-The browser will be installed to a user-specific data directory and can be used for scraping.`,
+The browser will be installed to a user-specific data directory and can be used for scraping.
+
+With --minimal, only Chromium's headless shell is downloaded instead of the full
+browser, which is considerably smaller and is all Sitepanda needs for headless
+crawling. 'sitepanda init prune' removes browser versions the installed Playwright
+driver no longer uses.
+
+With --verify, nothing is installed: the installed browser is launched and a bundled
+local test page is crawled through the full fetch and extraction pipeline, catching
+a broken installation before a long crawl fails at startup.
+
+Examples:
+  sitepanda init
+  sitepanda init chromium --minimal
+  sitepanda init lightpanda
+  sitepanda init --verify
+  sitepanda init lightpanda --verify
+  sitepanda init prune`,
 	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		browserToInit := "chromium"
@@ -29,15 +52,63 @@ The browser will be installed to a user-specific data directory and can be used
 				os.Exit(1)
 			}
 		}
+		if initMinimal && browserToInit != "chromium" {
+			fmt.Fprintf(os.Stderr, "Error: --minimal is only supported for chromium.\n")
+			os.Exit(1)
+		}
+		if initVerify {
+			if initMinimal {
+				fmt.Fprintf(os.Stderr, "Error: --verify does not install anything and cannot be combined with --minimal.\n")
+				os.Exit(1)
+			}
+			if VerifyHandler != nil {
+				VerifyHandler(browserToInit)
+			} else {
+				fmt.Printf("Error: Verify handler not set. Please report this issue.\n")
+				os.Exit(1)
+			}
+			return
+		}
 
 		handleInitCommand(browserToInit)
 	},
 }
 
+// initPruneCmd represents the init prune command
+var initPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove browser versions the Playwright driver no longer uses",
+	Long: `Remove browser versions from Playwright's browser directory that the installed
+Playwright driver does not use, typically left behind by earlier Sitepanda
+versions, and report the reclaimed disk space.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if PruneHandler != nil {
+			PruneHandler()
+		} else {
+			fmt.Printf("Error: Prune handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(initCmd)
+	initCmd.AddCommand(initPruneCmd)
+
+	initCmd.Flags().BoolVar(&initVerify, "verify", false, "Check the installed browser by crawling a bundled test page instead of installing")
+	initCmd.Flags().BoolVar(&initMinimal, "minimal", false, "Install only Chromium's headless shell instead of the full browser (chromium only)")
 }
 
+// Getter functions for main package to access flag values
+func GetInitMinimal() bool { return initMinimal }
+
+// VerifyHandler handles init --verify for a browser. It will be set by the main package.
+var VerifyHandler func(string)
+
+// PruneHandler handles the init prune command. It will be set by the main package.
+var PruneHandler func()
+
 // InitHandler is a function that handles browser initialization
 // It will be set by the main package
 var InitHandler func(string)
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// jobStartURLKey is the job file key that gives the start URL instead of a flag.
+const jobStartURLKey = "start_url"
+
+// parseJobVars parses --var key=value pairs.
+func parseJobVars(pairs []string) (map[string]string, error) {
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", pair)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// renderJobTemplate expands a job file as a Go template with {{.env.NAME}} for environment
+// variables and {{.vars.NAME}} for --var values. Referencing an unset name is an error.
+func renderJobTemplate(text string, environ []string, vars map[string]string) (string, error) {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	tmpl, err := template.New("job").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, map[string]map[string]string{"env": env, "vars": vars}); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// applyJobFile renders the --job file at path and sets every flag it names that was not given
+// on the command line or through the environment. It returns the file's start_url.
+func applyJobFile(c *cobra.Command, path string, varPairs []string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read job file: %w", err)
+	}
+	vars, err := parseJobVars(varPairs)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := renderJobTemplate(string(data), os.Environ(), vars)
+	if err != nil {
+		return "", fmt.Errorf("job file %s: %w", path, err)
+	}
+	var settings yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(rendered), &settings); err != nil {
+		return "", fmt.Errorf("job file %s: invalid YAML after templating: %w", path, err)
+	}
+
+	var startURL string
+	for _, item := range settings {
+		key := fmt.Sprint(item.Key)
+		if key == jobStartURLKey {
+			startURL = fmt.Sprint(item.Value)
+			continue
+		}
+		name := strings.ReplaceAll(key, "_", "-")
+		f := c.Flags().Lookup(name)
+		if f == nil || name == "job" || name == "var" {
+			return "", fmt.Errorf("job file %s: unknown setting %q", path, key)
+		}
+		if f.Changed {
+			continue
+		}
+		if item.Value == nil {
+			return "", fmt.Errorf("job file %s: %s has no value", path, key)
+		}
+		values := []any{item.Value}
+		if list, ok := item.Value.([]any); ok {
+			values = list
+		}
+		for _, value := range values {
+			if err := c.Flags().Set(name, fmt.Sprint(value)); err != nil {
+				return "", fmt.Errorf("job file %s: invalid value %v for %s: %w", path, value, key, err)
+			}
+		}
+	}
+	return startURL, nil
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestParseJobVars(t *testing.T) {
+	vars, err := parseJobVars([]string{"tenant=acme", "query=a=b", "empty="})
+	if err != nil {
+		t.Fatalf("parseJobVars: %v", err)
+	}
+	if vars["tenant"] != "acme" || vars["query"] != "a=b" || vars["empty"] != "" {
+		t.Errorf("parseJobVars = %v", vars)
+	}
+	for _, bad := range []string{"tenant", "=acme"} {
+		if _, err := parseJobVars([]string{bad}); err == nil {
+			t.Errorf("parseJobVars(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestRenderJobTemplate(t *testing.T) {
+	env := []string{"TARGET=docs.example.com", "EMPTY="}
+	vars := map[string]string{"section": "guide"}
+
+	got, err := renderJobTemplate("start_url: https://{{.env.TARGET}}/{{.vars.section}}/", env, vars)
+	if err != nil {
+		t.Fatalf("renderJobTemplate: %v", err)
+	}
+	if got != "start_url: https://docs.example.com/guide/" {
+		t.Errorf("renderJobTemplate = %q", got)
+	}
+
+	for _, text := range []string{"{{.env.MISSING}}", "{{.vars.missing}}", "{{.env.TARGET"} {
+		if _, err := renderJobTemplate(text, env, vars); err == nil {
+			t.Errorf("renderJobTemplate(%q) succeeded, want error", text)
+		}
+	}
+}
+
+func newTestJobCommand() (*cobra.Command, *string, *int, *[]string) {
+	var outfile string
+	var limit int
+	var match []string
+	c := &cobra.Command{Use: "test"}
+	c.Flags().StringVarP(&outfile, "outfile", "o", "", "")
+	c.Flags().IntVar(&limit, "page-limit", 0, "")
+	c.Flags().StringSliceVar(&match, "match", []string{}, "")
+	c.Flags().StringVar(new(string), "job", "", "")
+	return c, &outfile, &limit, &match
+}
+
+func writeJobFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "job.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyJobFile(t *testing.T) {
+	t.Setenv("SITEPANDA_TEST_TARGET", "acme.example.com")
+	path := writeJobFile(t, `
+start_url: https://{{.env.SITEPANDA_TEST_TARGET}}/docs/
+outfile: "{{.vars.tenant}}.json"
+page_limit: 50
+match:
+  - /docs/**
+  - /guide/**
+`)
+
+	c, outfile, limit, match := newTestJobCommand()
+	if err := c.Flags().Set("page-limit", "5"); err != nil {
+		t.Fatal(err)
+	}
+	startURL, err := applyJobFile(c, path, []string{"tenant=acme"})
+	if err != nil {
+		t.Fatalf("applyJobFile: %v", err)
+	}
+	if startURL != "https://acme.example.com/docs/" {
+		t.Errorf("start_url = %q", startURL)
+	}
+	if *outfile != "acme.json" {
+		t.Errorf("outfile = %q, want acme.json", *outfile)
+	}
+	if *limit != 5 {
+		t.Errorf("page-limit = %d, want the command-line value 5", *limit)
+	}
+	if strings.Join(*match, " ") != "/docs/** /guide/**" {
+		t.Errorf("match = %v", *match)
+	}
+}
+
+func TestApplyJobFileErrors(t *testing.T) {
+	tests := []struct {
+		name, content, wantErr string
+	}{
+		{"unknown setting", "pagelimit: 5", `unknown setting "pagelimit"`},
+		{"job itself", "job: other.yaml", `unknown setting "job"`},
+		{"invalid value", "page_limit: many", "invalid value many for page_limit"},
+		{"missing variable", "outfile: '{{.vars.tenant}}.json'", "map has no entry for key"},
+		{"no value", "outfile:", "outfile has no value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _, _ := newTestJobCommand()
+			_, err := applyJobFile(c, writeJobFile(t, tt.content), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("applyJobFile error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
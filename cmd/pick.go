@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// pick flags
+	pickTop                int
+	pickInteractive        bool
+	pickWaitForNetworkIdle bool
+)
+
+// PickHandler handles the pick command. It will be set by the main package.
+var PickHandler func(string)
+
+// pickCmd represents the pick command
+var pickCmd = &cobra.Command{
+	Use:   "pick <url>",
+	Short: "Suggest --content-selector values for a page",
+	Long: `Loads the page and prints the elements most likely to hold its main content,
+ranked by text density (text outside links per element), with a CSS selector for
+each that can be passed to 'sitepanda scrape --content-selector'.
+
+With --interactive a visible Chromium window opens on the page instead; every
+element you click is printed with its selector, how many elements it matches and
+a preview of its text. Close the window or press Ctrl+C to stop.
+
+Examples:
+  sitepanda pick https://example.com/docs/intro
+  sitepanda pick --top 5 --wni https://example.com/app
+  sitepanda pick --interactive https://example.com/docs/intro`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if pickTop < 1 {
+			return fmt.Errorf("--top must be at least 1, got %d", pickTop)
+		}
+		if PickHandler != nil {
+			PickHandler(args[0])
+		} else {
+			fmt.Printf("Error: Pick handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pickCmd)
+
+	pickCmd.Flags().IntVar(&pickTop, "top", 10, "Number of candidate containers to print")
+	pickCmd.Flags().BoolVar(&pickInteractive, "interactive", false, "Open a visible Chromium window and print the selector of each element clicked")
+	pickCmd.Flags().BoolVar(&pickWaitForNetworkIdle, "wait-for-network-idle", false, "Wait for network activity to settle before reading the page")
+	pickCmd.Flags().BoolVar(&pickWaitForNetworkIdle, "wni", false, "Shorthand for --wait-for-network-idle")
+}
+
+// Getter functions for main package to access flag values
+func GetPickTop() int                 { return pickTop }
+func GetPickInteractive() bool        { return pickInteractive }
+func GetPickWaitForNetworkIdle() bool { return pickWaitForNetworkIdle }
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// preview flags
+	previewContentSelector    string
+	previewSelectorMode       string
+	previewWaitForNetworkIdle bool
+	previewLines              int
+)
+
+// PreviewHandler handles the preview command. It will be set by the main package.
+var PreviewHandler func(string)
+
+// previewCmd represents the preview command
+var previewCmd = &cobra.Command{
+	Use:   "preview <url>",
+	Short: "Compare extraction with and without a content selector on one page",
+	Long: `Loads one page and extracts it the way 'sitepanda scrape' would, printing the
+raw HTML size, extracted size, title and the first lines of Markdown. With
+--content-selector the readability defaults and the selector are shown side by
+side, so settings can be checked before a full crawl.
+
+Examples:
+  sitepanda preview https://example.com/docs/intro
+  sitepanda preview --content-selector "article.post" https://example.com/blog/hello
+  sitepanda preview --content-selector "main" --lines 40 --wni https://example.com/app`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if previewLines < 0 {
+			return fmt.Errorf("--lines must not be negative, got %d", previewLines)
+		}
+		if PreviewHandler != nil {
+			PreviewHandler(args[0])
+		} else {
+			fmt.Printf("Error: Preview handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+
+	previewCmd.Flags().StringVar(&previewContentSelector, "content-selector", "", "CSS selector to compare with the readability defaults, as for 'scrape --content-selector'")
+	previewCmd.Flags().StringVar(&previewSelectorMode, "selector-mode", "first", "How to use several elements matched by --content-selector: first, all (concatenated) or largest (most text)")
+	previewCmd.Flags().BoolVar(&previewWaitForNetworkIdle, "wait-for-network-idle", false, "Wait for network activity to settle before reading the page")
+	previewCmd.Flags().BoolVar(&previewWaitForNetworkIdle, "wni", false, "Shorthand for --wait-for-network-idle")
+	previewCmd.Flags().IntVar(&previewLines, "lines", 20, "Number of Markdown lines to show for each extraction")
+}
+
+// Getter functions for main package to access flag values
+func GetPreviewContentSelector() string  { return previewContentSelector }
+func GetPreviewSelectorMode() string     { return previewSelectorMode }
+func GetPreviewWaitForNetworkIdle() bool { return previewWaitForNetworkIdle }
+func GetPreviewLines() int               { return previewLines }
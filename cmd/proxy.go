@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// proxy flags
+	proxyRecord string
+	proxyReplay string
+	proxyListen string
+)
+
+// ProxyHandler handles the proxy command. It will be set by the main package.
+var ProxyHandler func()
+
+// proxyCmd represents the proxy command
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Record a crawl's HTTP traffic into a cassette directory, or replay it",
+	Long: `Runs a local HTTP(S) proxy for developing extraction and output formats
+without hitting live sites. Point a crawl at it with 'sitepanda scrape --proxy'.
+
+With --record, every request is sent to the network and the response is saved
+into the cassette directory. With --replay, responses are served from the
+cassette only; requests that were not recorded fail with status 502, so a
+replayed crawl is hermetic and repeatable.
+
+HTTPS is intercepted with a certificate authority generated for each proxy run;
+'sitepanda scrape --proxy' tells the browser to accept its certificates.
+
+Examples:
+  sitepanda proxy --record testdata/docs-cassette
+  sitepanda scrape --proxy http://127.0.0.1:8899 -o out.md https://docs.example.com/
+  sitepanda proxy --replay testdata/docs-cassette`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if (proxyRecord == "") == (proxyReplay == "") {
+			fmt.Fprintf(os.Stderr, "Error: exactly one of --record or --replay is required.\n")
+			os.Exit(1)
+		}
+		if ProxyHandler != nil {
+			ProxyHandler()
+		} else {
+			fmt.Printf("Error: Proxy handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+
+	proxyCmd.Flags().StringVar(&proxyRecord, "record", "", "Forward requests to the network and save the responses into this cassette directory")
+	proxyCmd.Flags().StringVar(&proxyReplay, "replay", "", "Serve responses from this cassette directory only, without network access")
+	proxyCmd.Flags().StringVar(&proxyListen, "listen", "127.0.0.1:8899", "Address the proxy listens on")
+}
+
+// Getter functions for main package to access flag values
+func GetProxyRecord() string { return proxyRecord }
+func GetProxyReplay() string { return proxyReplay }
+func GetProxyListen() string { return proxyListen }
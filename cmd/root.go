@@ -28,7 +28,15 @@ primary goal is to extract the main readable content from web pages and save it
 
 Commands:
   init    Download and install browser dependencies
-  scrape  Scrape websites and save content as Markdown`,
+  scrape  Scrape websites and save content as Markdown
+  bench   Compare scraping engines on a sample of pages
+
+Every flag can also be set through an environment variable named SITEPANDA_<FLAG>,
+e.g. SITEPANDA_OUTFILE=out.json or SITEPANDA_MATCH="/blog/**,/docs/**".
+Flags given on the command line take precedence.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return bindEnvToFlags(cmd)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		if showVersion {
 			if VersionFunc != nil {
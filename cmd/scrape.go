@@ -3,21 +3,136 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	// Scraping flags
-	outfile             string
+	outfiles            []string
 	urlFile             string
 	matchPatterns       []string
 	followMatchPatterns []string
+	excludeMatch        []string
+	excludeFollow       []string
 	pageLimit           int
+	maxDepth            int
+	strategy            string
+	prioritizeMatch     bool
+	maxBytes            string
 	contentSelector     string
 	waitForNetworkIdle  bool
 	outputFormat        string
 	verboseBrowser      bool
+	maxPageBytes        string
+	processTimeout      time.Duration
+	retries             int
+	skipStatus          string
+	downloadAssets      []string
+	splitByHeading      string
+	assetDir            string
+	retryBackoff        time.Duration
+	maxMemory           string
+	browserLogFile      string
+	browserLogMaxSize   string
+	browserLogBackups   int
+	noDaemon            bool
+	auditLog            string
+	progressFormat      string
+	progressFile        string
+	offset              int
+	outputDir           string
+	jobName             string
+	tocFile             string
+	publishedAfter      string
+	includeComments     bool
+	tablesMode          string
+	followRelNext       bool
+	referer             string
+	oauthTokenURL       string
+	oauthClientID       string
+	oauthClientSecret   string
+	oauthScopes         []string
+	oauthHosts          []string
+	responseHeaders     []string
+	selectorMode        string
+	domRules            []string
+	seoReport           string
+	a11yTree            bool
+	captureOGImages     bool
+	redactPII           []string
+	dropFields          []string
+	maxContentLength    int
+	domainsFile         string
+	recordHistory       bool
+	fallbackBrowser     string
+	prefetch            bool
+	concurrency         int
+	ignoreRobots        bool
+	fixtureDir          string
+	requestDelay        time.Duration
+	delayJitter         time.Duration
+	maxRPS              float64
+	adaptiveWait        bool
+	treatQueryAsPage    bool
+	includeGated        bool
+	interactFile        string
+	evalExtract         []string
+	disableSW           bool
+	bypassCache         bool
+	freezeTime          string
+	queryParams         []string
+	stripQueryParams    []string
+	stripQuery          bool
+	collapseLocales     bool
+	preferredLang       string
+	latestVersionOnly   bool
+	versions            []string
+	lock                bool
+	lockFile            string
+	lockWait            time.Duration
+	manifestFile        string
+	reportFile          string
+	splitSpec           string
+	splitSeed           int64
+	wrapTemplate        string
+	maxTotalTokens      int64
+	relevantTo          string
+	top                 int
+	dedupeSimilarity    float64
+	respectCanonical    bool
+	dedupeContent       bool
+	proxyServer         string
+	pluginPaths         []string
+	wasmTransformPath   string
+	policyFile          string
+	filterExpr          string
+	labelRules          []string
+	routeRules          []string
+	jobFile             string
+	jobVars             []string
+
+	// Search seeding flags
+	search         string
+	searchEngine   string
+	searchLimit    int
+	searchAPIKey   string
+	searchCX       string
+	searchEndpoint string
+
+	// Notification flags
+	configFile           string
+	notifySlackWebhook   string
+	notifyDiscordWebhook string
+	notifyEmail          []string
+	notifyTemplate       string
+	smtpHost             string
+	smtpPort             int
+	smtpUsername         string
+	smtpPassword         string
+	smtpFrom             string
 )
 
 // ScrapingHandler is a function that handles the scraping functionality
@@ -36,9 +151,23 @@ Examples:
   sitepanda scrape https://example.com
   sitepanda scrape --outfile output.txt --match "/blog/**" https://example.com
   sitepanda scrape --url-file urls.txt --outfile output.json
-  sitepanda scrape --browser chromium --outfile output.json https://example.com`,
+  sitepanda scrape --browser chromium --outfile output.json https://example.com
+  sitepanda scrape --job docs-job.yaml --var tenant=acme`,
 	Args: cobra.MaximumNArgs(1), // Allow 0 or 1 positional argument (the URL)
 	Run: func(cmd *cobra.Command, args []string) {
+		if jobFile != "" {
+			startURL, err := applyJobFile(cmd, jobFile, jobVars)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if len(args) == 0 && startURL != "" {
+				args = []string{startURL}
+			}
+		} else if len(jobVars) > 0 {
+			fmt.Fprintf(os.Stderr, "Error: --var requires --job.\n")
+			os.Exit(1)
+		}
 		// Handle scraping logic
 		if ScrapingHandler != nil {
 			ScrapingHandler(args)
@@ -53,25 +182,283 @@ func init() {
 	rootCmd.AddCommand(scrapeCmd)
 
 	// Scraping flags
-	scrapeCmd.Flags().StringVarP(&outfile, "outfile", "o", "", "Write the fetched site to a text file.")
-	scrapeCmd.Flags().StringVarP(&outputFormat, "output-format", "f", "xml-like", "Output format (xml-like, json, jsonl)")
+	scrapeCmd.Flags().StringArrayVarP(&outfiles, "outfile", "o", []string{}, "Write the fetched site to a text file, '-' for stdout, or a store URL (s3://bucket/key, http(s):// webhook, sqlite://path for versioned page snapshots); can be given multiple times. Supports {host}, {date}, {time}, {datetime} and {job} placeholders")
+	scrapeCmd.Flags().StringVar(&outputDir, "output-dir", "", "Write each saved page as a Markdown file into this directory (with a URL to path mapping file); supports the same placeholders as --outfile")
+	scrapeCmd.Flags().BoolVar(&captureOGImages, "capture-og-images", false, "Download each saved page's og:image and the site favicon into <output-dir>/_assets and reference them as image/favicon metadata (requires --output-dir)")
+	scrapeCmd.Flags().StringVar(&tocFile, "toc", "", "Write a hierarchical Markdown table of contents (from URL structure and breadcrumbs) to this file, linking to --output-dir files")
+	scrapeCmd.Flags().StringVar(&jobName, "job-name", "", "Name of this run, available as {job} in --outfile and --output-dir")
+	scrapeCmd.Flags().StringVarP(&outputFormat, "output-format", "f", "xml-like", "Output format (xml-like, json, jsonl, org, asciidoc, parquet, hf-dataset, site); parquet, hf-dataset and site require --outfile, the latter two write a directory there")
+	scrapeCmd.Flags().Float64Var(&dedupeSimilarity, "dedupe-similarity", 0, "Skip pages whose content is at least this similar (0-1, e.g. 0.92) to an already saved page, by SimHash over the Markdown; clusters are listed in the summary (0 disables)")
+	scrapeCmd.Flags().BoolVar(&dedupeContent, "dedupe-content", false, "Skip pages whose extracted content is identical to an already saved page, such as mirrored paths (a SHA-256 hash of the Markdown)")
+	scrapeCmd.Flags().BoolVar(&respectCanonical, "respect-canonical", false, "Skip pages whose <link rel=\"canonical\"> URL was already saved, such as paginated or filtered views of one page, and record the canonical URL in the output")
+	scrapeCmd.Flags().StringVar(&relevantTo, "relevant-to", "", "Only output pages relevant to this query, e.g. \"kubernetes networking\", most relevant first (BM25 over title and content; the score is saved as relevance)")
+	scrapeCmd.Flags().IntVar(&top, "top", 0, "With --relevant-to, output at most this many pages (0 for all pages that match the query)")
+	scrapeCmd.Flags().StringVar(&wrapTemplate, "wrap-template", "", "Write each page with this template instead of an output format, e.g. \"### {title}\\n{content}\\n\" (placeholders {title}, {url}, {content}, {section}, {published}; \\n and \\t are expanded)")
+	scrapeCmd.Flags().Int64Var(&maxTotalTokens, "max-total-tokens", 0, "With --wrap-template, stop adding pages once the output reaches this many estimated tokens, cutting the last page to fit (0 for no limit)")
+	scrapeCmd.Flags().StringVar(&splitSpec, "split", "", "Write pages into one --outfile per split, e.g. train=0.9,val=0.1 gives <name>.train.<ext> and <name>.val.<ext> (data/<split>.jsonl for hf-dataset)")
+	scrapeCmd.Flags().StringVar(&splitByHeading, "split-by-heading", "", "Split each saved page into one output record per section at headings of this level, e.g. h2, with the heading's #anchor in its URL")
+	scrapeCmd.Flags().Int64Var(&splitSeed, "split-seed", 0, "Seed for --split; a page's split is a hash of the seed and its URL, so it is the same in every run")
 	scrapeCmd.Flags().StringVar(&urlFile, "url-file", "", "Path to a file containing URLs to process (one per line). Overrides <url> argument")
+	scrapeCmd.Flags().StringVar(&domainsFile, "domains-file", "", "Crawl several sites in one run: a file with one root URL per line (optionally \"limit=N\"); --limit applies per site and pages are tagged with their site")
 	scrapeCmd.Flags().StringSliceVarP(&matchPatterns, "match", "m", []string{}, "Only extract content from matched pages (glob pattern, can be specified multiple times)")
 	scrapeCmd.Flags().StringSliceVar(&followMatchPatterns, "follow-match", []string{}, "Only add links matching this glob pattern to the crawl queue (can be specified multiple times)")
-	scrapeCmd.Flags().IntVar(&pageLimit, "limit", 0, "Stop crawling once this many pages have had their content saved (0 for no limit)")
-	scrapeCmd.Flags().StringVar(&contentSelector, "content-selector", "", "Specify a CSS selector to target the main content area")
+	scrapeCmd.Flags().StringSliceVar(&excludeMatch, "exclude-match", []string{}, "Do not extract content from pages matching this glob pattern, even if they match --match (can be specified multiple times)")
+	scrapeCmd.Flags().StringSliceVar(&excludeFollow, "exclude-follow", []string{}, "Do not add links matching this glob pattern to the crawl queue, even if they match --follow-match (can be specified multiple times)")
+	scrapeCmd.Flags().IntVar(&pageLimit, "limit", 0, "Stop crawling once this many pages have had their content saved (0 for no limit); with --url-file, also process at most this many URLs from the list")
+	scrapeCmd.Flags().BoolVar(&prioritizeMatch, "prioritize-match", false, "Fetch queued pages that --match or --policy will save before pages only crawled for their links, so --limit is not spent on index pages (default: plain --strategy order)")
+	scrapeCmd.Flags().StringVar(&strategy, "strategy", "bfs", "Order in which queued pages are fetched: bfs (level by level), dfs (a page's links before its siblings, for deep doc trees under a small --limit) or best-first (paths matching --relevant-to terms, then shallow paths, first)")
+	scrapeCmd.Flags().IntVar(&maxDepth, "depth", 0, "Do not follow links on pages this many links away from the start URL, e.g. 2 for the start page, the pages it links to and theirs (0 for no limit)")
+	scrapeCmd.Flags().StringVar(&maxBytes, "max-bytes", "0", "Stop the crawl once its responses add up to this size, e.g. 2GB, counting subresources such as images and scripts (0 for no limit)")
+	scrapeCmd.Flags().IntVar(&offset, "offset", 0, "With --url-file, skip this many URLs from the start of the list (use with --limit to process the file in shards)")
+	scrapeCmd.Flags().StringVar(&publishedAfter, "published-after", "", "Skip saving pages whose detected publication date is before this date, e.g. 2023-01-01 (pages without a date are kept)")
+	scrapeCmd.Flags().BoolVar(&includeComments, "include-comments", false, "Extract comment threads (WordPress, Hacker News style, inline Disqus, schema.org Comment) into a separate comments field")
+	scrapeCmd.Flags().BoolVar(&a11yTree, "a11y-tree", false, "Capture each saved page's accessibility (ARIA) tree as YAML into an a11y_tree field (JSON), <a11y_tree> (xml-like) or an Accessibility Tree section (--output-dir)")
+	scrapeCmd.Flags().StringVar(&tablesMode, "tables", "", "Preserve data tables that readability would drop: keep (Markdown pipe tables), csv (pipe tables plus sidecar CSV files, requires --output-dir) or html (raw HTML blocks)")
+	scrapeCmd.Flags().BoolVar(&followRelNext, "follow-rel-next", false, "Follow rel=\"next\" pagination links (<link>, <a> and the HTTP Link header) on the same host even when they don't match --follow-match")
+	scrapeCmd.Flags().StringVar(&referer, "referer", "", "Referer for page navigations: auto (the page the link was found on), none (strip Referer from all requests) or a fixed URL")
+	scrapeCmd.Flags().StringVar(&oauthTokenURL, "oauth-token-url", "", "OAuth2 token endpoint; obtain a bearer token with the client-credentials grant and send it as the Authorization header")
+	scrapeCmd.Flags().StringVar(&oauthClientID, "oauth-client-id", "", "OAuth2 client ID for --oauth-token-url")
+	scrapeCmd.Flags().StringVar(&oauthClientSecret, "oauth-client-secret", "", "OAuth2 client secret for --oauth-token-url (prefer SITEPANDA_OAUTH_CLIENT_SECRET)")
+	scrapeCmd.Flags().StringSliceVar(&oauthScopes, "oauth-scope", []string{}, "OAuth2 scope to request (can be specified multiple times)")
+	scrapeCmd.Flags().StringSliceVar(&oauthHosts, "oauth-host", []string{}, "Host that receives the bearer token, e.g. docs.example.com or *.example.com (can be specified multiple times; default: the start URL's host)")
+	scrapeCmd.Flags().StringSliceVar(&responseHeaders, "response-headers", nil, "HTTP response headers to save with each page in JSON/JSONL output and --output-dir front matter, e.g. content-type,last-modified,etag")
+	scrapeCmd.Flags().StringSliceVar(&redactPII, "redact-pii", []string{}, "Mask personal data in extracted content before output: emails, phones, ips (comma-separated); counts are shown in the summary")
+	scrapeCmd.Flags().StringSliceVar(&dropFields, "drop-fields", []string{}, "Clear these page fields before they are stored or written, e.g. raw_html,article_html,headers (names as in JSON output; url cannot be dropped)")
+	scrapeCmd.Flags().IntVar(&maxContentLength, "max-content-length", 0, "Cut each page's Markdown content to at most this many characters (0 for no limit)")
+	scrapeCmd.Flags().StringVar(&contentSelector, "content-selector", "", "Specify a CSS selector to target the main content area; a comma-separated list is tried in order and the first non-trivial match wins")
+	scrapeCmd.Flags().StringVar(&selectorMode, "selector-mode", "first", "How to use several elements matched by --content-selector: first, all (concatenated) or largest (most text)")
+	scrapeCmd.Flags().StringArrayVar(&domRules, "dom-rule", []string{}, "Transform the page before extraction, applied in order: remove:<sel>, unwrap:<sel>, keep:<sel>, rename:<sel>=<tag>, remove-attr:<sel>=<attr> (can be specified multiple times)")
 	scrapeCmd.Flags().BoolVarP(&waitForNetworkIdle, "wait-for-network-idle", "w", false, "Wait for network to be idle instead of just load when fetching pages")
 	scrapeCmd.Flags().BoolVar(&waitForNetworkIdle, "wni", false, "Shorthand for --wait-for-network-idle")
-	scrapeCmd.Flags().BoolVar(&verboseBrowser, "verbose-browser", false, "Display verbose browser logs (e.g., from Chromium) in the console")
+	scrapeCmd.Flags().BoolVar(&verboseBrowser, "verbose-browser", false, "Display verbose browser logs (e.g., from Chromium) in the console; Lightpanda output is streamed live")
+	scrapeCmd.Flags().StringVar(&browserLogFile, "browser-log-file", "", "Write Lightpanda stdout/stderr to this file (rotated by size)")
+	scrapeCmd.Flags().StringVar(&browserLogMaxSize, "browser-log-max-size", "10MB", "Rotate --browser-log-file once it exceeds this size")
+	scrapeCmd.Flags().IntVar(&browserLogBackups, "browser-log-max-backups", 3, "Number of rotated --browser-log-file backups to keep")
+	scrapeCmd.Flags().BoolVar(&noDaemon, "no-daemon", false, "Launch a fresh browser even if one was started with 'sitepanda browser start'")
+	scrapeCmd.Flags().StringArrayVar(&evalExtract, "eval-extract", []string{}, "Evaluate this JavaScript expression in each saved page and store its JSON value under \"extracted\", e.g. window.__NUXT__.data (can be specified multiple times)")
+	scrapeCmd.Flags().StringVar(&interactFile, "interact", "", "YAML file of scripted interactions (fill, click, press, wait_for, wait) such as submitting a search form; the crawl starts from the pages they end on")
+	scrapeCmd.Flags().BoolVar(&includeGated, "include-gated", false, "Save pages detected as login walls or paywalls instead of excluding them")
+	scrapeCmd.Flags().BoolVar(&treatQueryAsPage, "treat-query-as-page", false, "Crawl links that differ only in their query string as separate pages (the default); with --query-param-whitelist only the listed parameters do")
+	scrapeCmd.Flags().StringSliceVar(&queryParams, "query-param-whitelist", nil, "With --treat-query-as-page, the query parameters that make a link a distinct page, e.g. page,tab; other parameters are dropped from discovered links")
+	scrapeCmd.Flags().StringSliceVar(&stripQueryParams, "strip-query-params", nil, "Query parameters to drop from discovered links so tracking variants are crawled once, e.g. utm_*,fbclid,ref (a trailing * matches a prefix)")
+	scrapeCmd.Flags().BoolVar(&stripQuery, "strip-query", false, "Drop the query string from discovered links, crawling ?page=2 style variants as one page")
+	scrapeCmd.Flags().BoolVar(&collapseLocales, "collapse-locales", false, "Recognize /en/, /ja/, /fr/ style locale path prefixes and crawl one translation, mapping links to other locales onto --preferred-lang")
+	scrapeCmd.Flags().StringVar(&preferredLang, "preferred-lang", "", "Locale kept by --collapse-locales, e.g. en, ja or pt-br (default: the start URL's locale, else en)")
+	scrapeCmd.Flags().BoolVar(&latestVersionOnly, "latest-version-only", false, "Recognize v2, 3.1 and latest style version path segments and version switchers, and crawl only the newest version of versioned docs")
+	scrapeCmd.Flags().StringSliceVar(&versions, "versions", nil, "Crawl only these versions of versioned docs, e.g. v2,v3 (URLs without a version segment are always crawled)")
+	scrapeCmd.Flags().BoolVar(&adaptiveWait, "adaptive-wait", false, "Refetch a page once with network idle and a settle delay if it comes back empty or yields no content")
+	scrapeCmd.Flags().BoolVar(&prefetch, "prefetch", false, "Process two pages at once (Chromium); same as --concurrency 2, and not with --delay or --max-rps")
+	scrapeCmd.Flags().IntVar(&concurrency, "concurrency", 1, "Number of pages processed in parallel, each in its own browser page; pages are saved in the order they finish (Chromium; not with --delay or --max-rps)")
+	scrapeCmd.Flags().DurationVar(&requestDelay, "delay", 0, "Wait this long between page fetches, e.g. 2s (cannot be combined with --concurrency or --prefetch)")
+	scrapeCmd.Flags().DurationVar(&delayJitter, "delay-jitter", 0, "Add a random wait of up to this long to each --delay, e.g. 1s, so requests do not arrive at a fixed rate")
+	scrapeCmd.Flags().Float64Var(&maxRPS, "max-rps", 0, "Start at most this many page fetches per second, e.g. 0.5 for one every two seconds (0 for no limit; cannot be combined with --concurrency or --prefetch)")
+	scrapeCmd.Flags().BoolVar(&ignoreRobots, "ignore-robots", false, "Do not fetch robots.txt; by default its Disallow rules for sitepanda (or *) are obeyed and its Crawl-delay is waited between fetches")
+	scrapeCmd.Flags().StringVar(&fixtureDir, "fixture-dir", "", "Serve every URL from local files in this directory instead of a browser, e.g. testdata/site/ (<dir>/<host>/<path> or <dir>/<path>; directory URLs map to index.html)")
+	scrapeCmd.Flags().BoolVar(&disableSW, "disable-service-workers", false, "Block service workers, which can serve stale offline content instead of the live site")
+	scrapeCmd.Flags().BoolVar(&bypassCache, "bypass-cache", false, "Disable the browser's HTTP cache so every page and resource is fetched from the network")
+	scrapeCmd.Flags().StringVar(&freezeTime, "freeze-time", "", "Make Date in every page report this fixed time (RFC 3339, e.g. 2024-01-01T00:00:00Z) so clocks and relative timestamps render deterministically")
+	scrapeCmd.Flags().BoolVar(&lock, "lock", false, "Run as a singleton: if another run of the same job (same --job-name, or same outputs and URLs) holds the lock, wait --lock-wait and then exit with status 75")
+	scrapeCmd.Flags().StringVar(&lockFile, "lock-file", "", "Lock file for --lock, shared by the runs that must not overlap (implies --lock; default: one per job in Sitepanda's data directory)")
+	scrapeCmd.Flags().DurationVar(&lockWait, "lock-wait", 0, "How long to wait for a held --lock before giving up, e.g. 10m (0 exits immediately)")
+	scrapeCmd.Flags().StringVar(&jobFile, "job", "", "YAML job file of scrape settings (flag names as keys, plus start_url), templated with {{.env.NAME}} and {{.vars.NAME}}; command-line flags and SITEPANDA_* variables take precedence")
+	scrapeCmd.Flags().StringArrayVar(&jobVars, "var", []string{}, "Variable for the --job template as key=value, available as {{.vars.key}} (can be specified multiple times)")
+	scrapeCmd.Flags().StringArrayVar(&labelRules, "label", []string{}, "Tag saved pages whose URL path matches a glob with a label, as name=glob (e.g. docs=/docs/**); labels are included in the output metadata (can be specified multiple times)")
+	scrapeCmd.Flags().StringArrayVar(&routeRules, "route", []string{}, "Write pages with a --label to their own file or directory instead of the main output, as label=file (format from the extension) or label=dir/ (one Markdown file per page) (can be specified multiple times)")
+	scrapeCmd.Flags().StringVar(&filterExpr, "filter", "", "Only save pages matching an expression over page fields, e.g. 'word_count > 100 && !title.contains(\"404\")'")
+	scrapeCmd.Flags().StringVar(&policyFile, "policy", "", "YAML file of per-path rules (path glob, action save|save-only|follow-only|skip, delay, selector, wait, max_pages); the first matching rule replaces --match/--follow-match for a URL. Check it with 'sitepanda check-policy'")
+	scrapeCmd.Flags().StringArrayVar(&pluginPaths, "plugin", []string{}, "Run this plugin executable, which implements OnRequest/OnHTML/OnPage/OnFinish hooks as JSON-RPC over stdio (can be specified multiple times; called in order)")
+	scrapeCmd.Flags().StringVar(&wasmTransformPath, "wasm-transform", "", "Run this WASM module (a WASI command) on every page in a sandbox: it reads the page and its HTML as JSON on stdin and writes edits in the plugins' OnPage format to stdout")
+	scrapeCmd.Flags().StringVar(&proxyServer, "proxy", "", "Send the browser's requests through this HTTP proxy, e.g. http://127.0.0.1:8899 for 'sitepanda proxy'; its HTTPS certificates are accepted (chromium only)")
+	scrapeCmd.Flags().StringVar(&fallbackBrowser, "fallback-browser", "", "Retry pages the primary browser fails to fetch with this browser ('lightpanda' or 'chromium', must differ from --browser)")
+	scrapeCmd.Flags().StringVar(&maxPageBytes, "max-page-bytes", "0", "Skip pages whose HTML is larger than this size, e.g. 10MB (0 for no limit)")
+	scrapeCmd.Flags().StringVar(&skipStatus, "skip-status", "4xx,5xx", "Skip pages whose HTTP status is in this list of codes and classes, e.g. 404,410,5xx, without saving them or following their links ('none' saves every page)")
+	scrapeCmd.Flags().StringSliceVar(&downloadAssets, "download-assets", nil, "Save linked non-HTML files of these kinds (pdf, txt, image) into --asset-dir instead of skipping them")
+	scrapeCmd.Flags().StringVar(&assetDir, "asset-dir", "", "Directory that receives the files saved by --download-assets, laid out by host and path like --output-dir")
+	scrapeCmd.Flags().IntVar(&retries, "retries", 1, "Retry a page this many times when its fetch times out, loses its connection or gets a 5xx or 429 response (other errors, such as a 404, are not retried)")
+	scrapeCmd.Flags().DurationVar(&retryBackoff, "retry-backoff", time.Second, "Wait before the first retry, doubled for each further one and raised to a longer Retry-After (at most 1m)")
+	scrapeCmd.Flags().DurationVar(&processTimeout, "process-timeout", 60*time.Second, "Skip a page if content extraction takes longer than this (0 for no limit)")
+	scrapeCmd.Flags().StringVar(&manifestFile, "manifest", "", "Write a JSON manifest with the Sitepanda and browser versions, the effective configuration and the SHA-256 of every output file to this file")
+	scrapeCmd.Flags().StringVar(&reportFile, "report", "", "Write a JSON report of the crawl to this file: pages fetched, saved, skipped and failed with their reasons, duration and bytes downloaded")
+	scrapeCmd.Flags().StringVar(&seoReport, "seo-report", "", "Write a JSON SEO report to this file: per-page title and meta description length, canonical, robots directives, h1 count and broken internal links")
+	scrapeCmd.Flags().BoolVar(&recordHistory, "history", false, "Record this run's statistics in the run history (see 'sitepanda history')")
+	scrapeCmd.Flags().StringVar(&historyFile, "history-file", "", "Run history file to append this run's statistics to; implies --history (default: history/runs.jsonl in Sitepanda's data directory)")
+	scrapeCmd.Flags().StringVar(&progressFormat, "progress-format", "text", "Progress reporting: text (log lines only) or json (one JSON event per page on stderr, or --progress-file)")
+	scrapeCmd.Flags().StringVar(&progressFile, "progress-file", "", "Write --progress-format json events to this file or named pipe instead of stderr")
+	scrapeCmd.Flags().StringVar(&auditLog, "audit-log", "", "Append a JSONL record (time, url, status, bytes, decision) for every attempted URL to this file")
+	scrapeCmd.Flags().StringVar(&search, "search", "", "Seed the URL list from web search results for this query, e.g. \"site:example.com kubernetes\" (processed like --url-file; requires --search-api-key)")
+	scrapeCmd.Flags().StringVar(&searchEngine, "search-engine", "bing", "Search API for --search: bing (Bing Web Search) or google (Custom Search JSON API, requires --search-cx)")
+	scrapeCmd.Flags().IntVar(&searchLimit, "search-limit", 50, "Maximum number of search results to seed with --search (Google returns at most 100)")
+	scrapeCmd.Flags().StringVar(&searchAPIKey, "search-api-key", "", "API key for --search-engine (prefer SITEPANDA_SEARCH_API_KEY)")
+	scrapeCmd.Flags().StringVar(&searchCX, "search-cx", "", "Google Programmable Search Engine ID for --search-engine google")
+	scrapeCmd.Flags().StringVar(&searchEndpoint, "search-endpoint", "", "Override the search API URL, e.g. for a compatible proxy")
+	scrapeCmd.Flags().StringVar(&configFile, "config", "", "YAML config file; its notify section sets up failure notifications with a message template per destination")
+	scrapeCmd.Flags().StringVar(&notifySlackWebhook, "notify-slack-webhook", "", "Post a message to this Slack incoming webhook when the crawl fails")
+	scrapeCmd.Flags().StringVar(&notifyDiscordWebhook, "notify-discord-webhook", "", "Post a message to this Discord webhook when the crawl fails")
+	scrapeCmd.Flags().StringSliceVar(&notifyEmail, "notify-email", []string{}, "Email these addresses via SMTP when the crawl fails (can be specified multiple times; requires --smtp-host and --smtp-from)")
+	scrapeCmd.Flags().StringVar(&notifyTemplate, "notify-template", "", "Go text/template for notification messages without their own template in --config, with {{.StartURL}}, {{.JobName}}, {{.Reason}}, {{.StopReason}}, {{.PagesSaved}}, {{.PagesSkipped}} and {{.Time}}")
+	scrapeCmd.Flags().StringVar(&smtpHost, "smtp-host", "", "SMTP server for --notify-email")
+	scrapeCmd.Flags().IntVar(&smtpPort, "smtp-port", 587, "SMTP server port for --notify-email")
+	scrapeCmd.Flags().StringVar(&smtpUsername, "smtp-username", "", "SMTP username for --notify-email (PLAIN auth; omit for unauthenticated relays)")
+	scrapeCmd.Flags().StringVar(&smtpPassword, "smtp-password", "", "SMTP password for --notify-email (prefer SITEPANDA_SMTP_PASSWORD)")
+	scrapeCmd.Flags().StringVar(&smtpFrom, "smtp-from", "", "Sender address for --notify-email")
+	scrapeCmd.Flags().StringVar(&maxMemory, "max-memory", "0", "Memory budget, e.g. 2GB; when exceeded results are flushed to disk and, if still over, the crawl stops with partial output (0 for no limit)")
+}
+
+// GetOutfile returns the first --outfile value, or "" if none was given.
+func GetOutfile() string {
+	if len(outfiles) == 0 {
+		return ""
+	}
+	return outfiles[0]
 }
 
 // Getter functions for main package to access flag values
-func GetOutfile() string               { return outfile }
+func GetOutfiles() []string            { return outfiles }
 func GetURLFile() string               { return urlFile }
 func GetMatchPatterns() []string       { return matchPatterns }
 func GetFollowMatchPatterns() []string { return followMatchPatterns }
+func GetExcludeMatch() []string        { return excludeMatch }
+func GetExcludeFollow() []string       { return excludeFollow }
 func GetPageLimit() int                { return pageLimit }
+func GetMaxDepth() int                 { return maxDepth }
+func GetStrategy() string              { return strategy }
+func GetPrioritizeMatch() bool         { return prioritizeMatch }
+func GetMaxBytes() string              { return maxBytes }
 func GetContentSelector() string       { return contentSelector }
 func GetWaitForNetworkIdle() bool      { return waitForNetworkIdle }
 func GetOutputFormat() string          { return outputFormat }
 func GetVerboseBrowser() bool          { return verboseBrowser }
+func GetMaxPageBytes() string          { return maxPageBytes }
+func GetProcessTimeout() time.Duration { return processTimeout }
+func GetRetries() int                  { return retries }
+func GetSkipStatus() string            { return skipStatus }
+func GetSkipStatusSet() bool           { return scrapeCmd.Flags().Changed("skip-status") }
+func GetDownloadAssets() []string      { return downloadAssets }
+func GetAssetDir() string              { return assetDir }
+func GetRetryBackoff() time.Duration   { return retryBackoff }
+func GetMaxMemory() string             { return maxMemory }
+func GetBrowserLogFile() string        { return browserLogFile }
+func GetBrowserLogMaxSize() string     { return browserLogMaxSize }
+func GetBrowserLogMaxBackups() int     { return browserLogBackups }
+func GetNoDaemon() bool                { return noDaemon }
+func GetFallbackBrowser() string       { return fallbackBrowser }
+func GetPrefetch() bool                { return prefetch }
+func GetConcurrency() int              { return concurrency }
+func GetIgnoreRobots() bool            { return ignoreRobots }
+func GetFixtureDir() string            { return fixtureDir }
+func GetDelay() time.Duration          { return requestDelay }
+func GetDelayJitter() time.Duration    { return delayJitter }
+func GetMaxRPS() float64               { return maxRPS }
+func GetAdaptiveWait() bool            { return adaptiveWait }
+func GetTreatQueryAsPage() bool        { return treatQueryAsPage }
+func GetIncludeGated() bool            { return includeGated }
+func GetInteractFile() string          { return interactFile }
+func GetEvalExtract() []string         { return evalExtract }
+func GetDisableServiceWorkers() bool   { return disableSW }
+func GetBypassCache() bool             { return bypassCache }
+func GetFreezeTime() string            { return freezeTime }
+func GetQueryParamWhitelist() []string { return queryParams }
+func GetStripQueryParams() []string    { return stripQueryParams }
+func GetStripQuery() bool              { return stripQuery }
+func GetCollapseLocales() bool         { return collapseLocales }
+func GetPreferredLang() string         { return preferredLang }
+func GetLatestVersionOnly() bool       { return latestVersionOnly }
+func GetVersions() []string            { return versions }
+func GetLock() bool                    { return lock }
+func GetLockFile() string              { return lockFile }
+func GetLockWait() time.Duration       { return lockWait }
+func GetManifestFile() string          { return manifestFile }
+func GetReportFile() string            { return reportFile }
+func GetSplit() string                 { return splitSpec }
+func GetSplitByHeading() string        { return splitByHeading }
+func GetSplitSeed() int64              { return splitSeed }
+func GetWrapTemplate() string          { return wrapTemplate }
+func GetMaxTotalTokens() int64         { return maxTotalTokens }
+func GetRelevantTo() string            { return relevantTo }
+func GetTop() int                      { return top }
+func GetDedupeSimilarity() float64     { return dedupeSimilarity }
+func GetRespectCanonical() bool        { return respectCanonical }
+func GetDedupeContent() bool           { return dedupeContent }
+func GetProxy() string                 { return proxyServer }
+func GetPlugins() []string             { return pluginPaths }
+func GetWASMTransform() string         { return wasmTransformPath }
+func GetPolicyFile() string            { return policyFile }
+func GetFilter() string                { return filterExpr }
+func GetLabels() []string              { return labelRules }
+func GetRoutes() []string              { return routeRules }
+func GetAuditLog() string              { return auditLog }
+func GetProgressFormat() string        { return progressFormat }
+func GetProgressFile() string          { return progressFile }
+func GetOffset() int                   { return offset }
+func GetOutputDir() string             { return outputDir }
+func GetJobName() string               { return jobName }
+func GetTOCFile() string               { return tocFile }
+func GetPublishedAfter() string        { return publishedAfter }
+func GetIncludeComments() bool         { return includeComments }
+func GetTables() string                { return tablesMode }
+func GetFollowRelNext() bool           { return followRelNext }
+func GetReferer() string               { return referer }
+func GetOAuthTokenURL() string         { return oauthTokenURL }
+func GetOAuthClientID() string         { return oauthClientID }
+func GetOAuthClientSecret() string     { return oauthClientSecret }
+func GetOAuthScopes() []string         { return oauthScopes }
+func GetOAuthHosts() []string          { return oauthHosts }
+func GetResponseHeaders() []string     { return responseHeaders }
+func GetSelectorMode() string          { return selectorMode }
+func GetDOMRules() []string            { return domRules }
+func GetSEOReport() string             { return seoReport }
+func GetA11yTree() bool                { return a11yTree }
+func GetCaptureOGImages() bool         { return captureOGImages }
+func GetRedactPII() []string           { return redactPII }
+func GetDropFields() []string          { return dropFields }
+func GetMaxContentLength() int         { return maxContentLength }
+func GetDomainsFile() string           { return domainsFile }
+func GetHistory() bool                 { return recordHistory }
+
+// Search seeding getters
+func GetSearch() string         { return search }
+func GetSearchEngine() string   { return searchEngine }
+func GetSearchLimit() int       { return searchLimit }
+func GetSearchAPIKey() string   { return searchAPIKey }
+func GetSearchCX() string       { return searchCX }
+func GetSearchEndpoint() string { return searchEndpoint }
+
+// Notification getters
+func GetConfigFile() string           { return configFile }
+func GetNotifySlackWebhook() string   { return notifySlackWebhook }
+func GetNotifyDiscordWebhook() string { return notifyDiscordWebhook }
+func GetNotifyEmail() []string        { return notifyEmail }
+func GetNotifyTemplate() string       { return notifyTemplate }
+func GetSMTPHost() string             { return smtpHost }
+func GetSMTPPort() int                { return smtpPort }
+func GetSMTPPortSet() bool            { return scrapeCmd.Flags().Changed("smtp-port") }
+func GetSMTPUsername() string         { return smtpUsername }
+func GetSMTPPassword() string         { return smtpPassword }
+func GetSMTPFrom() string             { return smtpFrom }
+
+// secretScrapeFlags hold credentials and are redacted from GetEffectiveConfig.
+var secretScrapeFlags = map[string]bool{
+	"oauth-client-secret":    true,
+	"search-api-key":         true,
+	"smtp-password":          true,
+	"notify-slack-webhook":   true,
+	"notify-discord-webhook": true,
+	"var":                    true,
+}
+
+// GetEffectiveConfig returns the value of every scrape option, including defaults and the global
+// flags, keyed by flag name. Credentials that are set are replaced with "<redacted>".
+func GetEffectiveConfig() map[string]string {
+	config := make(map[string]string)
+	scrapeCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if f.Name == "help" || f.Name == "version" {
+			return
+		}
+		value := f.Value.String()
+		if secretScrapeFlags[f.Name] && value != "" {
+			value = "<redacted>"
+		}
+		config[f.Name] = value
+	})
+	return config
+}
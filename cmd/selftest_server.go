@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// selftest-server flags
+	selftestHost    string
+	selftestPort    int
+	selftestLatency time.Duration
+)
+
+// SelftestServerHandler handles the selftest-server command. It will be set by the main package.
+var SelftestServerHandler func()
+
+// selftestServerCmd represents the selftest-server command
+var selftestServerCmd = &cobra.Command{
+	Use:   "selftest-server",
+	Short: "Serve a synthetic multi-page site for trying out flags locally",
+	Long: `Starts a local web server with the synthetic site used by Sitepanda's
+integration tests: a home page, a docs section with tables and code, a
+paginated blog with rel=next links, a page rendered by JavaScript, a slow page,
+a robots.txt that disallows /private/ and a missing page.
+
+Crawl it to verify an installation end-to-end or to compare flags without
+touching external sites. The site's URL is printed on stdout; with --port 0 a
+free port is chosen. Stop the server with Ctrl+C.
+
+Examples:
+  sitepanda selftest-server --port 0
+  sitepanda selftest-server --port 8080 --latency 200ms
+  sitepanda scrape --wni http://127.0.0.1:8080/`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if selftestPort < 0 || selftestPort > 65535 {
+			fmt.Fprintf(os.Stderr, "Error: --port must be between 0 and 65535, got %d.\n", selftestPort)
+			os.Exit(1)
+		}
+		if selftestLatency < 0 {
+			fmt.Fprintf(os.Stderr, "Error: --latency must not be negative, got %s.\n", selftestLatency)
+			os.Exit(1)
+		}
+		if SelftestServerHandler != nil {
+			SelftestServerHandler()
+		} else {
+			fmt.Printf("Error: Selftest server handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(selftestServerCmd)
+
+	selftestServerCmd.Flags().StringVar(&selftestHost, "host", "127.0.0.1", "Address the site listens on")
+	selftestServerCmd.Flags().IntVar(&selftestPort, "port", 8080, "Port the site listens on (0 picks a free port)")
+	selftestServerCmd.Flags().DurationVar(&selftestLatency, "latency", 0, "Delay every response by this long, e.g. 200ms, to mimic a remote server")
+}
+
+func GetSelftestHost() string           { return selftestHost }
+func GetSelftestPort() int              { return selftestPort }
+func GetSelftestLatency() time.Duration { return selftestLatency }
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ui flags
+	uiListen  string
+	uiNoOpen  bool
+	uiAPIKeys string
+	uiDataDir string
+	uiMaxJobs int
+)
+
+// UIHandler handles the ui command. It will be set by the main package.
+var UIHandler func()
+
+// uiCmd represents the ui command
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Configure and run scrapes from a local web page",
+	Long: `Starts a local web server and opens a page in your browser to configure a
+scrape: the start URL, --match and --follow-match patterns, a content selector
+with a live preview of an example page, the page limit and the output format.
+Scrapes run as 'sitepanda scrape' processes and their results can be downloaded
+from the page when they finish.
+
+The server only listens on the loopback interface by default. To share one
+server with a team, pass --api-keys with a YAML file of keys: API requests must
+then send a key ("Authorization: Bearer <key>" or "X-API-Key: <key>"), each key
+can have its own requests-per-minute limit and page quota, and each key only
+sees its own scrapes and results.
+
+Scrapes are jobs: POST /api/jobs returns a job ID at once, GET /api/jobs/{id}
+reports its state and progress and GET /api/jobs/{id}/result streams its output
+once it has finished. --max-jobs queues jobs beyond that many running scrapes.
+With --data-dir, jobs and results are kept on disk; jobs that were queued or
+running when the server stopped run again when it starts on the same directory.
+Stop the server with Ctrl+C.
+
+Examples:
+  sitepanda ui
+  sitepanda ui --listen 127.0.0.1:9000 --no-open
+  sitepanda ui --listen 0.0.0.0:8765 --no-open --api-keys keys.yaml --data-dir /var/lib/sitepanda --max-jobs 4`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if uiMaxJobs < 0 {
+			fmt.Fprintf(os.Stderr, "Error: --max-jobs must not be negative, got %d.\n", uiMaxJobs)
+			os.Exit(1)
+		}
+		if UIHandler != nil {
+			UIHandler()
+		} else {
+			fmt.Printf("Error: UI handler not set. Please report this issue.\n")
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+
+	uiCmd.Flags().StringVar(&uiListen, "listen", "127.0.0.1:8765", "Address the web UI listens on")
+	uiCmd.Flags().BoolVar(&uiNoOpen, "no-open", false, "Print the UI address without opening a browser")
+	uiCmd.Flags().StringVar(&uiDataDir, "data-dir", "", "Keep jobs and results in this directory so they survive restarts (default: a temporary directory removed on exit)")
+	uiCmd.Flags().IntVar(&uiMaxJobs, "max-jobs", 0, "Run at most this many scrapes at once; further jobs wait queued (0 for no limit)")
+	uiCmd.Flags().StringVar(&uiAPIKeys, "api-keys", "", "YAML file of API keys with per-key rate limits and page quotas; requests without a listed key are rejected")
+}
+
+func GetUIListen() string  { return uiListen }
+func GetUINoOpen() bool    { return uiNoOpen }
+func GetUIAPIKeys() string { return uiAPIKeys }
+func GetUIDataDir() string { return uiDataDir }
+func GetUIMaxJobs() int    { return uiMaxJobs }
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Comment is one entry of a page's comment thread. Depth is 0 for top-level comments.
+type Comment struct {
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+	Text   string `json:"text"`
+	Depth  int    `json:"depth,omitempty"`
+}
+
+// commentContainerSelector matches the elements that hold comment threads. They are removed from
+// the page before article extraction so comments don't end up merged into the content.
+const commentContainerSelector = `#comments, .comments-area, ol.comment-list, ol.commentlist, #disqus_thread, #posts, table.comment-tree, [itemtype$="schema.org/Comment"]`
+
+// commentExtractor extracts comments for one family of comment markup.
+type commentExtractor func(doc *goquery.Document) []Comment
+
+// commentExtractors are tried in order; the first that finds comments wins.
+var commentExtractors = []commentExtractor{
+	wordPressComments,
+	hackerNewsComments,
+	disqusComments,
+	microdataComments,
+}
+
+// extractComments returns the comment thread of a page and the HTML with comment containers removed.
+// If no comments are found, rawHTML is returned unchanged.
+func extractComments(pageURL *url.URL, rawHTML string) ([]Comment, string) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		return nil, rawHTML
+	}
+	var comments []Comment
+	for _, extract := range commentExtractors {
+		if comments = extract(doc); len(comments) > 0 {
+			break
+		}
+	}
+	if len(comments) == 0 {
+		return nil, rawHTML
+	}
+
+	doc.Find(commentContainerSelector).Remove()
+	stripped, err := doc.Html()
+	if err != nil {
+		logger.Printf("Warning: failed to serialize %s after removing comments: %v", pageURL, err)
+		return comments, rawHTML
+	}
+	return comments, stripped
+}
+
+// wordPressComments handles the markup of wp_list_comments() and most themes derived from it.
+func wordPressComments(doc *goquery.Document) []Comment {
+	var comments []Comment
+	doc.Find("ol.comment-list li.comment, ol.commentlist li.comment, .comments-area li.comment").Each(func(_ int, s *goquery.Selection) {
+		body := s.ChildrenFiltered("article, div.comment-body").First()
+		if body.Length() == 0 {
+			body = s
+		}
+		textSel := body.Find(".comment-content").First()
+		if textSel.Length() == 0 {
+			textSel = body.Find("p")
+		}
+		text := commentText(textSel)
+		if text == "" {
+			return
+		}
+		date, ok := body.Find(".comment-metadata time, .comment-meta time").First().Attr("datetime")
+		if !ok {
+			date = collapseWhitespace(body.Find(".comment-metadata, .comment-meta").First().Text())
+		}
+		comments = append(comments, Comment{
+			Author: collapseWhitespace(body.Find(".comment-author .fn").First().Text()),
+			Date:   date,
+			Text:   text,
+			Depth:  s.ParentsFiltered("li.comment").Length(),
+		})
+	})
+	return comments
+}
+
+// hackerNewsComments handles Hacker News style threads, where nesting is given by an indent attribute.
+func hackerNewsComments(doc *goquery.Document) []Comment {
+	var comments []Comment
+	doc.Find("tr.athing.comtr").Each(func(_ int, s *goquery.Selection) {
+		text := commentText(s.Find(".commtext").First())
+		if text == "" {
+			return
+		}
+		depth := 0
+		if indent, ok := s.Find("td.ind").First().Attr("indent"); ok {
+			depth, _ = strconv.Atoi(indent)
+		} else if width, ok := s.Find("td.ind img").First().Attr("width"); ok {
+			if w, err := strconv.Atoi(width); err == nil {
+				depth = w / 40
+			}
+		}
+		date, ok := s.Find(".age").First().Attr("title")
+		if ok {
+			// HN appends a Unix timestamp after the ISO date: "2024-05-01T10:00:00 1714557600".
+			date, _, _ = strings.Cut(date, " ")
+		}
+		comments = append(comments, Comment{
+			Author: collapseWhitespace(s.Find(".hnuser").First().Text()),
+			Date:   date,
+			Text:   text,
+			Depth:  depth,
+		})
+	})
+	return comments
+}
+
+// disqusComments handles Disqus threads rendered into the page itself. Threads loaded in the
+// Disqus iframe are not part of the page HTML and cannot be captured this way.
+func disqusComments(doc *goquery.Document) []Comment {
+	var comments []Comment
+	doc.Find("#posts li.post, #post-list li.post").Each(func(_ int, s *goquery.Selection) {
+		text := commentText(s.Find(".post-message").First())
+		if text == "" {
+			return
+		}
+		date, _ := s.Find(".post-meta a[title], .time-ago").First().Attr("title")
+		comments = append(comments, Comment{
+			Author: collapseWhitespace(s.Find(".post-byline .author").First().Text()),
+			Date:   date,
+			Text:   text,
+			Depth:  s.ParentsFiltered("li.post").Length(),
+		})
+	})
+	return comments
+}
+
+// microdataComments handles schema.org Comment microdata.
+func microdataComments(doc *goquery.Document) []Comment {
+	var comments []Comment
+	doc.Find(`[itemtype$="schema.org/Comment"]`).Each(func(_ int, s *goquery.Selection) {
+		text := commentText(s.Find(`[itemprop="text"]`).First())
+		if text == "" {
+			return
+		}
+		author := s.Find(`[itemprop="author"] [itemprop="name"]`).First()
+		if author.Length() == 0 {
+			author = s.Find(`[itemprop="author"]`).First()
+		}
+		dateSel := s.Find(`[itemprop="dateCreated"], [itemprop="datePublished"]`).First()
+		date, ok := dateSel.Attr("datetime")
+		if !ok {
+			date, _ = dateSel.Attr("content")
+		}
+		comments = append(comments, Comment{
+			Author: collapseWhitespace(author.Text()),
+			Date:   date,
+			Text:   text,
+			Depth:  s.ParentsFiltered(`[itemtype$="schema.org/Comment"]`).Length(),
+		})
+	})
+	return comments
+}
+
+// commentText returns the text of a comment body, keeping paragraph breaks.
+func commentText(s *goquery.Selection) string {
+	var paragraphs []string
+	s.Each(func(_ int, sel *goquery.Selection) {
+		if p := sel.Find("p"); p.Length() > 0 && sel.Children().Length() == p.Length() {
+			p.Each(func(_ int, para *goquery.Selection) {
+				if text := collapseWhitespace(para.Text()); text != "" {
+					paragraphs = append(paragraphs, text)
+				}
+			})
+			return
+		}
+		if text := collapseWhitespace(sel.Text()); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	})
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// formatCommentsAsMarkdown renders comments as a nested Markdown list.
+func formatCommentsAsMarkdown(comments []Comment) string {
+	var b strings.Builder
+	for _, c := range comments {
+		indent := strings.Repeat("  ", c.Depth)
+		author := c.Author
+		if author == "" {
+			author = "Anonymous"
+		}
+		header := "**" + author + "**"
+		if c.Date != "" {
+			header += " (" + c.Date + ")"
+		}
+		lines := strings.Split(c.Text, "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = indent + "  " + lines[i]
+			}
+		}
+		fmt.Fprintf(&b, "%s- %s: %s\n", indent, header, strings.Join(lines, "\n"))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
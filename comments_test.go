@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractComments(t *testing.T) {
+	pageURL, _ := url.Parse("https://blog.example.com/post")
+
+	tests := []struct {
+		name string
+		html string
+		want []Comment
+	}{
+		{
+			name: "WordPress threaded comments",
+			html: `<article><p>Post body</p></article>
+<div id="comments" class="comments-area"><ol class="comment-list">
+<li class="comment depth-1"><article class="comment-body">
+<footer class="comment-meta"><div class="comment-author"><b class="fn">Alice</b></div>
+<div class="comment-metadata"><time datetime="2024-05-01T10:00:00+00:00">May 1</time></div></footer>
+<div class="comment-content"><p>Great post.</p><p>Thanks!</p></div></article>
+<ol class="children"><li class="comment depth-2"><article class="comment-body">
+<div class="comment-author"><b class="fn">Bob</b></div>
+<div class="comment-content"><p>Agreed.</p></div></article></li></ol>
+</li></ol></div>`,
+			want: []Comment{
+				{Author: "Alice", Date: "2024-05-01T10:00:00+00:00", Text: "Great post.\n\nThanks!"},
+				{Author: "Bob", Text: "Agreed.", Depth: 1},
+			},
+		},
+		{
+			name: "Hacker News style",
+			html: `<table class="comment-tree">
+<tr class="athing comtr"><td><table><tr><td class="ind" indent="0"></td><td>
+<a class="hnuser">pg</a> <span class="age" title="2024-05-01T10:00:00 1714557600">1 hour ago</span>
+<div class="commtext">First comment</div></td></tr></table></td></tr>
+<tr class="athing comtr"><td><table><tr><td class="ind"><img width="40"></td><td>
+<a class="hnuser">dang</a><div class="commtext">Reply</div></td></tr></table></td></tr>
+</table>`,
+			want: []Comment{
+				{Author: "pg", Date: "2024-05-01T10:00:00", Text: "First comment"},
+				{Author: "dang", Text: "Reply", Depth: 1},
+			},
+		},
+		{
+			name: "schema.org microdata",
+			html: `<div itemscope itemtype="https://schema.org/Comment">
+<span itemprop="author" itemscope itemtype="https://schema.org/Person"><span itemprop="name">Carol</span></span>
+<meta itemprop="dateCreated" content="2023-01-02"><div itemprop="text">Nice</div></div>`,
+			want: []Comment{{Author: "Carol", Date: "2023-01-02", Text: "Nice"}},
+		},
+		{
+			name: "no comments",
+			html: `<article><p>Just a post</p></article>`,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stripped := extractComments(pageURL, tt.html)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractComments() comments = %#v, want %#v", got, tt.want)
+			}
+			if tt.want == nil {
+				if stripped != tt.html {
+					t.Errorf("extractComments() changed HTML without comments")
+				}
+				return
+			}
+			for _, c := range tt.want {
+				if strings.Contains(stripped, c.Author) {
+					t.Errorf("extractComments() left comment by %q in the page HTML", c.Author)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatCommentsAsMarkdown(t *testing.T) {
+	comments := []Comment{
+		{Author: "Alice", Date: "2024-05-01", Text: "Para one\n\nPara two"},
+		{Text: "Reply", Depth: 1},
+	}
+	want := "- **Alice** (2024-05-01): Para one\n\n  Para two\n  - **Anonymous**: Reply"
+	if got := formatCommentsAsMarkdown(comments); got != want {
+		t.Errorf("formatCommentsAsMarkdown() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Selector modes for --selector-mode.
+const (
+	selectorModeFirst   = "first"
+	selectorModeAll     = "all"
+	selectorModeLargest = "largest"
+)
+
+// validateSelectorMode checks a --selector-mode value. The empty string means first.
+func validateSelectorMode(mode string) error {
+	switch mode {
+	case "", selectorModeFirst, selectorModeAll, selectorModeLargest:
+		return nil
+	}
+	return fmt.Errorf("invalid selector mode %q (expected %s, %s or %s)", mode, selectorModeFirst, selectorModeAll, selectorModeLargest)
+}
+
+// minSelectorMatchText is the amount of text (in characters) a selector match needs to count as
+// non-trivial in a --content-selector fallback chain.
+const minSelectorMatchText = 25
+
+// splitSelectorList splits a selector list such as "main article, .post-content, #content" into its
+// alternatives, ignoring commas inside parentheses, brackets and quotes.
+func splitSelectorList(selector string) []string {
+	var parts []string
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range selector {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			depth--
+		case r == ',' && depth == 0:
+			if part := strings.TrimSpace(selector[start:i]); part != "" {
+				parts = append(parts, part)
+			}
+			start = i + 1
+		}
+	}
+	if part := strings.TrimSpace(selector[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// selectContent tries each alternative of a selector list in order and returns the first match
+// with non-trivial text, together with the alternative that won. mode decides what an alternative
+// matches when it finds several elements: the first (default), all of them (outermost only), or the
+// one with the most text. If every match is trivial, the first match is returned; if nothing
+// matches, the selection is empty.
+func selectContent(doc *goquery.Document, contentSelector string, mode string) (*goquery.Selection, string) {
+	var fallback *goquery.Selection
+	var fallbackSelector string
+	for _, selector := range splitSelectorList(contentSelector) {
+		matched := doc.Find(selector)
+		if matched.Length() == 0 {
+			continue
+		}
+		selection := pickSelectorMatches(matched, mode)
+		if utf8.RuneCountInString(collapseWhitespace(selection.Text())) >= minSelectorMatchText {
+			return selection, selector
+		}
+		if fallback == nil {
+			fallback, fallbackSelector = selection, selector
+		}
+	}
+	if fallback != nil {
+		return fallback, fallbackSelector
+	}
+	return doc.Selection.Slice(0, 0), ""
+}
+
+// pickSelectorMatches reduces the elements matched by one selector according to mode.
+func pickSelectorMatches(matched *goquery.Selection, mode string) *goquery.Selection {
+	switch mode {
+	case selectorModeAll:
+		// Drop matches nested in other matches so their content isn't included twice.
+		return matched.FilterFunction(func(_ int, s *goquery.Selection) bool {
+			return s.ParentsFiltered("*").FilterSelection(matched).Length() == 0
+		})
+	case selectorModeLargest:
+		largest, largestLen := matched.First(), -1
+		matched.Each(func(_ int, s *goquery.Selection) {
+			if n := utf8.RuneCountInString(collapseWhitespace(s.Text())); n > largestLen {
+				largest, largestLen = s, n
+			}
+		})
+		return largest
+	default:
+		return matched.First()
+	}
+}
+
+// selectionOuterHTML returns the outer HTML of a selection. Several elements are wrapped in one <div>
+// so readability sees them as a single article.
+func selectionOuterHTML(selection *goquery.Selection) (string, error) {
+	if selection.Length() == 1 {
+		return goquery.OuterHtml(selection)
+	}
+	var b strings.Builder
+	b.WriteString("<div>")
+	for i := range selection.Nodes {
+		html, err := goquery.OuterHtml(selection.Eq(i))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(html)
+	}
+	b.WriteString("</div>")
+	return b.String(), nil
+}
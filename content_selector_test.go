@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestSplitSelectorList(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{selector: "main article", want: []string{"main article"}},
+		{selector: "main article, .post-content ,#content", want: []string{"main article", ".post-content", "#content"}},
+		{selector: `div:not(.a, .b), a[title="x,y"], `, want: []string{"div:not(.a, .b)", `a[title="x,y"]`}},
+		{selector: "", want: nil},
+	}
+	for _, tt := range tests {
+		if got := splitSelectorList(tt.selector); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitSelectorList(%q) = %q, want %q", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestSelectContent(t *testing.T) {
+	html := `<body><div id="content"><p>Site-wide content wrapper with plenty of text in it.</p></div>
+<main><article>Short</article></main>
+<div class="post-content"><p>The actual post body, which is long enough to count.</p></div></body>`
+
+	tests := []struct {
+		name         string
+		selector     string
+		wantSelector string
+		wantText     string
+	}{
+		{name: "priority order, not document order", selector: ".post-content, #content", wantSelector: ".post-content", wantText: "The actual post body"},
+		{name: "trivial match skipped", selector: "main article, .post-content, #content", wantSelector: ".post-content", wantText: "The actual post body"},
+		{name: "missing selector skipped", selector: ".missing, #content", wantSelector: "#content", wantText: "Site-wide content"},
+		{name: "only trivial matches", selector: ".missing, main article", wantSelector: "main article", wantText: "Short"},
+		{name: "no match", selector: ".missing, .absent", wantSelector: "", wantText: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+			if err != nil {
+				t.Fatalf("failed to parse HTML: %v", err)
+			}
+			selection, selector := selectContent(doc, tt.selector, "")
+			if selector != tt.wantSelector {
+				t.Errorf("selectContent() selector = %q, want %q", selector, tt.wantSelector)
+			}
+			if text := strings.TrimSpace(selection.Text()); !strings.HasPrefix(text, tt.wantText) || (tt.wantText == "" && text != "") {
+				t.Errorf("selectContent() text = %q, want prefix %q", text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestSelectContentModes(t *testing.T) {
+	html := `<body><div class="chapter"><p>Chapter one is about the beginning of everything.</p></div>
+<div class="chapter"><p>Chapter two is much longer and goes into considerably more detail than the first.</p>
+<div class="chapter"><p>A nested chapter-like box.</p></div></div></body>`
+
+	tests := []struct {
+		mode      string
+		wantCount int
+		wantText  string
+	}{
+		{mode: "", wantCount: 1, wantText: "Chapter one"},
+		{mode: selectorModeFirst, wantCount: 1, wantText: "Chapter one"},
+		{mode: selectorModeAll, wantCount: 2, wantText: "Chapter one"},
+		{mode: selectorModeLargest, wantCount: 1, wantText: "Chapter two"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+			if err != nil {
+				t.Fatalf("failed to parse HTML: %v", err)
+			}
+			selection, _ := selectContent(doc, ".chapter", tt.mode)
+			if selection.Length() != tt.wantCount {
+				t.Errorf("selectContent() matched %d elements, want %d", selection.Length(), tt.wantCount)
+			}
+			if text := strings.TrimSpace(selection.First().Text()); !strings.HasPrefix(text, tt.wantText) {
+				t.Errorf("selectContent() text = %q, want prefix %q", text, tt.wantText)
+			}
+			html, err := selectionOuterHTML(selection)
+			if err != nil {
+				t.Fatalf("selectionOuterHTML() error = %v", err)
+			}
+			if tt.wantCount > 1 && (!strings.HasPrefix(html, "<div><div class=\"chapter\">") || strings.Count(html, "Chapter two") != 1) {
+				t.Errorf("selectionOuterHTML() = %q, want matches wrapped in one <div>", html)
+			}
+		})
+	}
+}
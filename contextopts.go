@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// hideServiceWorkerScript removes the Service Worker API from pages, for browser contexts that
+// were not created by sitepanda and so cannot be given ServiceWorkers: block.
+const hideServiceWorkerScript = `Object.defineProperty(Navigator.prototype, 'serviceWorker', { get: () => undefined, configurable: true });`
+
+// browserContextOptions returns the options for browser contexts created by the crawler.
+func browserContextOptions(opts CrawlOptions) playwright.BrowserNewContextOptions {
+	var contextOpts playwright.BrowserNewContextOptions
+	if opts.DisableServiceWorkers {
+		contextOpts.ServiceWorkers = playwright.ServiceWorkerPolicyBlock
+	}
+	if opts.Proxy != "" {
+		// 'sitepanda proxy' intercepts HTTPS with certificates of its own CA.
+		contextOpts.Proxy = &playwright.Proxy{Server: opts.Proxy}
+		contextOpts.IgnoreHttpsErrors = playwright.Bool(true)
+	}
+	return contextOpts
+}
+
+// freezeTimeScript replaces Date so that the current time is always t: new Date(), Date() and
+// Date.now() return it while dates built from explicit values are unaffected.
+func freezeTimeScript(t time.Time) string {
+	return fmt.Sprintf(`(() => {
+  const fixed = %d;
+  const RealDate = Date;
+  function FrozenDate(...args) {
+    if (!new.target) return new RealDate(fixed).toString();
+    return args.length === 0 ? new RealDate(fixed) : new RealDate(...args);
+  }
+  FrozenDate.prototype = RealDate.prototype;
+  FrozenDate.now = () => fixed;
+  FrozenDate.parse = RealDate.parse;
+  FrozenDate.UTC = RealDate.UTC;
+  globalThis.Date = FrozenDate;
+})();`, t.UnixMilli())
+}
+
+// addContextInitScripts installs the init scripts the options call for. reused reports whether
+// the context was not created by sitepanda (Lightpanda's default context or a browser daemon's);
+// --disable-service-workers then hides the Service Worker API, since ServiceWorkers: block can
+// only be set at creation.
+func addContextInitScripts(browserCtx playwright.BrowserContext, opts CrawlOptions, reused bool) error {
+	var scripts []string
+	if reused && opts.DisableServiceWorkers {
+		scripts = append(scripts, hideServiceWorkerScript)
+	}
+	if !opts.FreezeTime.IsZero() {
+		scripts = append(scripts, freezeTimeScript(opts.FreezeTime))
+	}
+	for i := range scripts {
+		if err := browserCtx.AddInitScript(playwright.Script{Content: &scripts[i]}); err != nil {
+			return fmt.Errorf("failed to add init script to browser context: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestBrowserContextOptions(t *testing.T) {
+	if got := browserContextOptions(CrawlOptions{}); got.ServiceWorkers != nil {
+		t.Errorf("ServiceWorkers = %v by default, want unset", *got.ServiceWorkers)
+	}
+	got := browserContextOptions(CrawlOptions{DisableServiceWorkers: true})
+	if got.ServiceWorkers == nil || *got.ServiceWorkers != *playwright.ServiceWorkerPolicyBlock {
+		t.Errorf("ServiceWorkers = %v with --disable-service-workers, want block", got.ServiceWorkers)
+	}
+}
+
+func TestRequestHeaderRouteBypassCache(t *testing.T) {
+	if requestHeaderRoute(context.Background(), CrawlOptions{}) != nil {
+		t.Error("expected no route without header rewriting or --bypass-cache")
+	}
+	if requestHeaderRoute(context.Background(), CrawlOptions{BypassCache: true}) == nil {
+		t.Error("expected a route with --bypass-cache, which disables the HTTP cache")
+	}
+}
+
+func TestFreezeTimeScript(t *testing.T) {
+	script := freezeTimeScript(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !strings.Contains(script, "const fixed = 1704067200000;") {
+		t.Errorf("freezeTimeScript() does not pin 2024-01-01T00:00:00Z:\n%s", script)
+	}
+	if !strings.Contains(script, "FrozenDate.now = () => fixed;") {
+		t.Errorf("freezeTimeScript() does not override Date.now:\n%s", script)
+	}
+}
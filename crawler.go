@@ -2,13 +2,18 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gobwas/glob"
@@ -16,9 +21,25 @@ import (
 )
 
 type JSONOutputPage struct {
-	Title   string `json:"title"`
-	URL     string `json:"url"`
-	Content string `json:"content"`
+	Title       string                     `json:"title"`
+	URL         string                     `json:"url"`
+	Section     string                     `json:"section,omitempty"`
+	Breadcrumbs []Breadcrumb               `json:"breadcrumbs,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Published   string                     `json:"published,omitempty"`
+	Content     string                     `json:"content"`
+	Comments    []Comment                  `json:"comments,omitempty"`
+	Headers     map[string]string          `json:"headers,omitempty"`
+	A11yTree    string                     `json:"a11y_tree,omitempty"`
+	Image       string                     `json:"image,omitempty"`
+	Favicon     string                     `json:"favicon,omitempty"`
+	Site        string                     `json:"site,omitempty"`
+	FinalURL    string                     `json:"final_url,omitempty"`
+	Canonical   string                     `json:"canonical,omitempty"`
+	Status      int                        `json:"status,omitempty"`
+	Labels      []string                   `json:"labels,omitempty"`
+	Extracted   map[string]json.RawMessage `json:"extracted,omitempty"`
+	Relevance   float64                    `json:"relevance,omitempty"`
 }
 
 // CrawlResult holds the summary of a crawl operation.
@@ -27,6 +48,243 @@ type CrawlResult struct {
 	OutputFile      string
 	StopReason      string
 	OutputFileError error
+	SkippedPages    []SkippedPage
+	OutputDir       string
+	OutputDirError  error
+	TOCFile         string
+	TOCFileError    error
+	SEOReport       string
+	SEOReportError  error
+	// PIIRedactions counts --redact-pii matches per kind; nil when redaction is disabled.
+	PIIRedactions map[string]int
+	// PagesFetched counts pages whose HTML was fetched, whether or not they were saved.
+	PagesFetched int
+	// PagesFailed counts pages that could not be fetched or processed.
+	PagesFailed int
+	// FailedPages lists those pages with their errors.
+	FailedPages []SkippedPage
+	// MatchSkippedPages counts fetched pages not saved because no --match pattern or policy
+	// rule saves them; their links are still followed.
+	MatchSkippedPages int
+	// Duration is how long the crawl took, not counting writing the output.
+	Duration time.Duration
+	// HostStats counts saved and failed pages per host.
+	HostStats map[string]HostStats
+	// SectionRecords counts the output records of the saved pages after --split-by-heading.
+	SectionRecords int
+	// DownloadedAssets lists the files saved by --download-assets.
+	DownloadedAssets []DownloadedAsset
+	// GatedPages lists pages excluded as login walls or paywalls.
+	GatedPages []SkippedPage
+	// ShortcutSkipped lists URLs skipped without a fetch because their host was found unreachable.
+	ShortcutSkipped []SkippedPage
+	// AdaptiveWaitRetries counts pages refetched by --adaptive-wait.
+	AdaptiveWaitRetries int
+	// FallbackFetches counts pages fetched by --fallback-browser after the primary browser failed.
+	FallbackFetches int
+	// DepthLimitedPages counts pages at --depth whose links were not followed.
+	DepthLimitedPages int
+	// BytesDownloaded is the size of the responses counted against --max-bytes, or of the
+	// fetched HTML without it.
+	BytesDownloaded int64
+	// NearDuplicates lists the saved pages that near-duplicates were dropped for (--dedupe-similarity).
+	NearDuplicates []NearDuplicateCluster
+	// IrrelevantPages lists saved pages left out of the output by --relevant-to and --top.
+	IrrelevantPages []SkippedPage
+	// SplitFiles lists the files written instead of OutputFile when --split is used.
+	SplitFiles []SplitFile
+	// RouteFiles lists the --route destinations, named by their labels, and RouteFileError the
+	// last error writing one.
+	RouteFiles     []SplitFile
+	RouteFileError error
+	// StoreResults lists the outcome of each store the output was written to.
+	StoreResults []StoreResult
+}
+
+// SplitFile is the output file of one --split share or --route.
+type SplitFile struct {
+	Name  string
+	Path  string
+	Pages int
+}
+
+// HostStats holds per-host page counts of a crawl.
+type HostStats struct {
+	Saved  int `json:"saved"`
+	Failed int `json:"failed"`
+}
+
+// SkippedPage records a fetched page that was deliberately not saved, and why.
+type SkippedPage struct {
+	URL    string
+	Reason string
+}
+
+// CrawlOptions holds optional crawler settings. The zero value disables every option.
+type CrawlOptions struct {
+	// ProcessTimeout bounds content extraction for a single page (0 disables the limit).
+	ProcessTimeout time.Duration
+	// MaxPageBytes skips pages whose fetched HTML is larger than this (0 disables the cap).
+	MaxPageBytes int64
+	// MaxMemory is the heap budget; exceeding it triggers a flush to disk and, failing that, a graceful stop (0 disables the guard).
+	MaxMemory int64
+	// AuditLog, if set, receives one record per attempted URL.
+	AuditLog *auditLog
+	// Progress, if set, receives a JSON event per attempted URL (--progress-format json).
+	Progress *progressReporter
+	// URLOverrides holds per-URL settings from --url-file, keyed by normalized URL.
+	URLOverrides map[string]URLOverrides
+	// OutputDir, if set, receives one Markdown file per saved page plus a URL to path mapping.
+	OutputDir string
+	// TOCFile, if set, receives a hierarchical Markdown table of contents of the saved pages.
+	TOCFile string
+	// PublishedAfter skips saving pages whose detected publication date is before it (zero disables the filter).
+	PublishedAfter time.Time
+	// IncludeComments extracts comment threads into PageData.Comments and keeps them out of the content.
+	IncludeComments bool
+	// Tables protects data tables from readability: "keep", "csv" or "html" (empty leaves them to readability).
+	Tables string
+	// FollowRelNext follows rel="next" pagination links even when they don't match --follow-match.
+	FollowRelNext bool
+	// Referer is "auto" (send the linking page), "none" (strip Referer from all requests), a fixed URL, or empty.
+	Referer string
+	// OAuth, if set, adds a client-credentials bearer token to requests for its hosts.
+	OAuth *oauthClientCredentials
+	// ResponseHeaders lists the response headers saved into PageData.Headers.
+	ResponseHeaders []string
+	// SelectorMode picks among several content selector matches: "first" (default), "all" or "largest".
+	SelectorMode string
+	// DOMRules are applied to each page's HTML before comment/table handling and readability.
+	DOMRules []domRule
+	// DisableServiceWorkers blocks service worker registration, which could serve stale offline content.
+	DisableServiceWorkers bool
+	// BypassCache disables the browser's HTTP cache so every request goes to the network.
+	BypassCache bool
+	// FreezeTime, if set, makes Date in every page report this fixed time.
+	FreezeTime time.Time
+	// EvalExtract lists JavaScript expressions evaluated in each saved page; their JSON values go
+	// into PageData.Extracted.
+	EvalExtract []string
+	// A11yTree captures Playwright's ARIA snapshot of each saved page into PageData.A11yTree.
+	A11yTree bool
+	// RedactPII masks personal data of these kinds in saved pages.
+	RedactPII []piiDetector
+	// Domains, if set, crawls each listed site from its root URL instead of only the start URL;
+	// pageLimit then applies per site.
+	Domains []domainRoot
+	// DropFields lists fields (by output name, see droppableFields) cleared before a page is stored.
+	DropFields []string
+	// MaxContentLength cuts each page's Markdown to this many characters (0 disables the cap).
+	MaxContentLength int
+	// CaptureOGImages downloads each saved page's og:image and favicon into OutputDir.
+	CaptureOGImages bool
+	// Interactions, if set, are run before the crawl; the pages they end on replace the start URL
+	// as the crawl's seeds.
+	Interactions []interaction
+	// IncludeGated saves pages detected as login walls or paywalls instead of excluding them.
+	IncludeGated bool
+	// QueryParamWhitelist, if set, lists the only query parameters that make discovered links
+	// distinct pages; other parameters are dropped. Empty keeps every query string.
+	QueryParamWhitelist []string
+	// StripQueryParams lists query parameters dropped from discovered links; a trailing '*'
+	// matches a prefix, e.g. utm_*.
+	StripQueryParams []string
+	// StripQuery drops the whole query string from discovered links.
+	StripQuery bool
+	// AdaptiveWait refetches a page once with network idle and a settle delay when it comes back
+	// empty or yields no content.
+	AdaptiveWait bool
+	// Prefetch processes two pages at once, as Concurrency 2 does.
+	Prefetch bool
+	// Concurrency is the number of pages processed at once, each by a worker with its own
+	// browser page (1 or 0 for one at a time).
+	Concurrency int
+	// SEOReport, if set, receives per-page SEO data (title, description, canonical, robots, h1s, broken internal links) as JSON.
+	SEOReport string
+	// Splits, if set, writes the pages to one output file per split (one data file per split
+	// for hf-dataset), assigned by a hash of SplitSeed and the page URL.
+	Splits    []outputSplit
+	SplitSeed int64
+	// WrapTemplate, if set, replaces the output format: each page is rendered with it and the
+	// results concatenated, up to MaxTotalTokens estimated tokens (0 for no limit).
+	WrapTemplate   string
+	MaxTotalTokens int64
+	// RelevantTo, if set, keeps only the pages that mention it, ranked by BM25 relevance, and at
+	// most RelevantTop of them (0 for no cap).
+	RelevantTo  string
+	RelevantTop int
+	// DedupeSimilarity, if set, skips pages whose Markdown SimHash is at least this similar (0 to
+	// 1) to an already saved page.
+	DedupeSimilarity float64
+	// RespectCanonical skips pages whose <link rel="canonical"> URL, or own URL if they declare
+	// none, was already saved, and records the canonical URL in the output.
+	RespectCanonical bool
+	// DedupeContent skips pages whose extracted Markdown is identical to a saved page's.
+	DedupeContent bool
+	// Proxy, if set, sends the browser's requests through this HTTP proxy, normally a
+	// 'sitepanda proxy', and accepts the certificates it presents.
+	Proxy string
+	// Stores receive the single-file output; without any the output is printed unless OutputDir
+	// is set. newCrawlerCommon adds a file store for the outfile argument if none are given.
+	Stores []Store
+	// Plugins are the --plugin processes whose hooks run for every URL and saved page.
+	Plugins pluginSet
+	// WASMTransform is the --wasm-transform module run on every page after the plugins.
+	WASMTransform *wasmTransform
+	// Policy, if set, decides per path whether pages are fetched, saved and followed, and
+	// overrides delay, selector and wait (--policy).
+	Policy *crawlPolicy
+	// Labels are the --label rules that tag saved pages by URL path.
+	Labels urlLabels
+	// Routes, if set, write pages with routed labels to their own destinations instead of the
+	// main output.
+	Routes []outputRoute
+	// Filter, if set, leaves out saved pages that do not satisfy the --filter expression.
+	Filter *pageFilter
+	// Throttle, if set, paces fetches for --delay, --delay-jitter and --max-rps.
+	Throttle *requestThrottle
+	// IgnoreRobots disables robots.txt: by default each host's Disallow rules are obeyed and its
+	// Crawl-delay is waited before every fetch.
+	IgnoreRobots bool
+	// Fixtures, if set, serves every page from --fixture-dir instead of the browser.
+	Fixtures *fixtureSite
+	// MaxDepth, if positive, stops following links on pages this many links from the start URLs.
+	MaxDepth int
+	// Strategy is the --strategy that orders the crawl queue: bfs (or empty), dfs or best-first.
+	Strategy string
+	// PrioritizeMatch fetches pages that --match or --policy will save before pages only
+	// crawled for their links (--prioritize-match).
+	PrioritizeMatch bool
+	// Locales maps links to other translations onto the preferred locale (--collapse-locales).
+	Locales *localeCollapser
+	// Versions keeps the crawl to the newest or the chosen versions of versioned docs
+	// (--latest-version-only, --versions).
+	Versions *versionFilter
+	// SkipStatus skips pages whose main response has one of these statuses (--skip-status),
+	// without saving them or following their links.
+	SkipStatus *statusSet
+	// RecordStatus writes the status of every saved page; otherwise only statuses other than
+	// 200 reach the output (--skip-status given explicitly).
+	RecordStatus bool
+	// SplitHeading, if positive, splits each saved page into one record per section at headings
+	// of this level (--split-by-heading).
+	SplitHeading int
+	// DownloadAssets are the kinds of non-HTML files saved into AssetDir (--download-assets);
+	// other non-HTML files are skipped.
+	DownloadAssets assetKinds
+	// AssetDir is the --asset-dir that receives downloaded files.
+	AssetDir string
+	// Retry repeats fetches that fail with a timeout, a dropped connection or a 5xx or 429
+	// response (--retries, --retry-backoff).
+	Retry retryPolicy
+	// Bytes, if set, counts downloaded bytes and stops the crawl once --max-bytes is reached.
+	Bytes *byteBudget
+	// ExcludeMatch are the --exclude-match globs: pages whose path matches one are not saved,
+	// even if they match --match.
+	ExcludeMatch []glob.Glob
+	// ExcludeFollow are the --exclude-follow globs: links whose path matches one are not queued,
+	// even if they match --follow-match.
+	ExcludeFollow []glob.Glob
 }
 
 type Crawler struct {
@@ -39,6 +297,7 @@ type Crawler struct {
 	silent              bool
 	waitForNetworkIdle  bool
 	outputFormat        string
+	opts                CrawlOptions
 
 	isURLListMode bool
 	initialURLs   []string
@@ -48,6 +307,60 @@ type Crawler struct {
 	rootCtx context.Context
 	cancel  context.CancelFunc
 
+	// seeds are the URLs the crawl started from, which --exclude-follow does not apply to.
+	seeds map[string]bool
+	// linkedFrom maps a queued URL to the page it was first found on (--referer auto).
+	linkedFrom map[string]string
+	// depths maps a queued URL to its number of links from the start URLs (--depth); start URLs
+	// are not recorded.
+	depths map[string]int
+
+	// seo collects --seo-report data; nil when the report is disabled.
+	seo *seoReport
+	// nearDuplicates finds pages similar to saved ones (--dedupe-similarity); nil when disabled.
+	nearDuplicates *nearDuplicateIndex
+	// savedCanonicals maps the canonical URLs of saved pages to the page saved for each
+	// (--respect-canonical).
+	savedCanonicals map[string]string
+	// savedContent maps the content hashes of saved pages to the first page saved with each
+	// (--dedupe-content).
+	savedContent map[[sha256.Size]byte]string
+	// domainSaved counts saved pages per host for --domains-file limits.
+	domainSaved map[string]int
+	// policySaved counts saved pages per --policy rule for max_pages.
+	policySaved map[*policyRule]int
+	// assetFiles assigns --asset-dir paths to files downloaded by --download-assets.
+	assetFiles *pagePathAllocator
+	// assetPaths maps downloaded image URLs to their path in the output directory ("" if the download failed).
+	assetPaths map[string]string
+
+	// fallback retries failed fetches with a second engine (--fallback-browser); nil when disabled.
+	fallback *fallbackBrowser
+	// fetched maps every URL fetched so far, requested or reached by redirect, to the queued URL it was fetched through.
+	fetched map[string]string
+	// queued is the number of URLs waiting in the queue, for progress events.
+	queued int
+	// deadHosts maps hosts that failed with DNS or connection errors to the reason.
+	deadHosts map[string]string
+	// robots caches the robots.txt rules of each scheme and host crawled.
+	robots map[string]*robotsRules
+
+	// mu guards the crawl state above, the queue and the CrawlResult while workers process pages
+	// in parallel (--concurrency); a worker holds it except while it waits, fetches or extracts.
+	mu sync.Mutex
+	// idle is signalled when a worker finishes a page, for workers waiting on an empty queue or
+	// on the --limit.
+	idle *sync.Cond
+	// workers process the queued URLs, each in its own browser page.
+	workers []*crawlWorker
+	// inFlight is the number of URLs taken from the queue whose page is still being processed.
+	inFlight int
+	// stopped is set once the crawl stops early; workers finish their page and exit.
+	stopped bool
+
+	spillFile    *os.File
+	spilledCount int
+
 	pwBrowser playwright.Browser
 	pwContext playwright.BrowserContext
 	page      playwright.Page
@@ -103,6 +416,7 @@ func newCrawlerCommon(
 	silent bool,
 	waitForNetworkIdle bool,
 	outputFormat string,
+	opts CrawlOptions,
 	rootContext context.Context,
 	rootCancelFunc context.CancelFunc,
 ) (*Crawler, error) {
@@ -111,18 +425,30 @@ func newCrawlerCommon(
 	var p playwright.Page
 	var err error
 
+	if len(opts.Stores) == 0 && outfile != "" {
+		opts.Stores = []Store{fileStore{path: outfile}}
+	}
+
 	contexts := pwB.Contexts()
+	if len(contexts) > 0 && opts.Proxy != "" {
+		rootCancelFunc()
+		return nil, fmt.Errorf("--proxy needs a browser context created by this run; the browser already has one (use --no-daemon)")
+	}
 	if len(contexts) > 0 {
 		browserCtx = contexts[0]
 		logger.Printf("Using existing browser context from browser (Number of contexts: %d)", len(contexts))
 	} else {
-		browserCtx, err = pwB.NewContext()
+		browserCtx, err = pwB.NewContext(browserContextOptions(opts))
 		if err != nil {
 			rootCancelFunc()
 			return nil, fmt.Errorf("failed to create new browser context: %w", err)
 		}
 		logger.Println("Created new browser context.")
 	}
+	if err := addContextInitScripts(browserCtx, opts, len(contexts) > 0); err != nil {
+		rootCancelFunc()
+		return nil, err
+	}
 
 	logger.Println("Creating a new page in the browser context...")
 	p, err = browserCtx.NewPage()
@@ -132,6 +458,16 @@ func newCrawlerCommon(
 		return nil, fmt.Errorf("failed to create new page in browser context: %w", err)
 	}
 	logger.Printf("Successfully created a new page.")
+	opts.Bytes.watch(p)
+
+	if handler := requestHeaderRoute(rootContext, opts); handler != nil {
+		if err := browserCtx.Route("**/*", handler); err != nil {
+			_ = p.Close()
+			_ = browserCtx.Close()
+			rootCancelFunc()
+			return nil, fmt.Errorf("failed to install request header route: %w", err)
+		}
+	}
 
 	if p == nil {
 		_ = browserCtx.Close()
@@ -179,6 +515,7 @@ func newCrawlerCommon(
 		silent:              silent,
 		waitForNetworkIdle:  waitForNetworkIdle,
 		outputFormat:        outputFormat,
+		opts:                opts,
 		visited:             visitedMap,
 		results:             make([]PageData, 0),
 		rootCtx:             rootContext,
@@ -205,6 +542,7 @@ func NewCrawlerForLightpanda(
 	silent bool,
 	waitForNetworkIdle bool,
 	outputFormat string,
+	opts CrawlOptions,
 ) (*Crawler, error) {
 	parsedStartURL, compiledMatchPatterns, compiledFollowPatterns, err := parseCrawlerArgs(startURLStr, matchPatternsRaw, followMatchPatternsRaw)
 	if err != nil {
@@ -223,7 +561,7 @@ func NewCrawlerForLightpanda(
 	}
 	logger.Printf("Playwright successfully connected to Lightpanda at %s", wsURL)
 
-	return newCrawlerCommon(parsedStartURL, urlList, isListMode, browser, pageLimit, compiledMatchPatterns, compiledFollowPatterns, contentSelector, outfile, silent, waitForNetworkIdle, outputFormat, rootCtxForCrawler, rootCrawlerCancel)
+	return newCrawlerCommon(parsedStartURL, urlList, isListMode, browser, pageLimit, compiledMatchPatterns, compiledFollowPatterns, contentSelector, outfile, silent, waitForNetworkIdle, outputFormat, opts, rootCtxForCrawler, rootCrawlerCancel)
 }
 
 func NewCrawlerForPlaywrightBrowser(
@@ -239,13 +577,14 @@ func NewCrawlerForPlaywrightBrowser(
 	silent bool,
 	waitForNetworkIdle bool,
 	outputFormat string,
+	opts CrawlOptions,
 ) (*Crawler, error) {
 	parsedStartURL, compiledMatchPatterns, compiledFollowPatterns, err := parseCrawlerArgs(startURLStr, matchPatternsRaw, followMatchPatternsRaw)
 	if err != nil {
 		return nil, err
 	}
 	rootCtxForCrawler, rootCrawlerCancel := context.WithCancel(context.Background())
-	return newCrawlerCommon(parsedStartURL, urlList, isListMode, pwB, pageLimit, compiledMatchPatterns, compiledFollowPatterns, contentSelector, outfile, silent, waitForNetworkIdle, outputFormat, rootCtxForCrawler, rootCrawlerCancel)
+	return newCrawlerCommon(parsedStartURL, urlList, isListMode, pwB, pageLimit, compiledMatchPatterns, compiledFollowPatterns, contentSelector, outfile, silent, waitForNetworkIdle, outputFormat, opts, rootCtxForCrawler, rootCrawlerCancel)
 }
 
 func (c *Crawler) Cancel() {
@@ -255,12 +594,33 @@ func (c *Crawler) Cancel() {
 }
 
 func (c *Crawler) Crawl() (CrawlResult, error) {
+	started := time.Now()
 	result := CrawlResult{
 		OutputFile: c.outfile,
+		OutputDir:  c.opts.OutputDir,
+		TOCFile:    c.opts.TOCFile,
+		SEOReport:  c.opts.SEOReport,
 		StopReason: "Completed", // Default stop reason
 	}
+	if c.opts.SEOReport != "" {
+		c.seo = newSEOReport()
+	}
+	if c.opts.Policy != nil {
+		c.policySaved = make(map[*policyRule]int)
+	}
+	if c.opts.DedupeSimilarity > 0 {
+		c.nearDuplicates = newNearDuplicateIndex(c.opts.DedupeSimilarity)
+	}
+	if len(c.opts.RedactPII) > 0 {
+		result.PIIRedactions = make(map[string]int)
+		for _, detector := range c.opts.RedactPII {
+			result.PIIRedactions[detector.Kind] = 0
+		}
+	}
 
 	defer func() {
+		c.closeWorkers()
+		c.closeFallback()
 		if c.page != nil && !c.page.IsClosed() {
 			logger.Println("Crawler: closing Playwright page...")
 			if err := c.page.Close(); err != nil {
@@ -275,7 +635,7 @@ func (c *Crawler) Crawl() (CrawlResult, error) {
 		}
 	}()
 
-	queue := []string{}
+	seeds := []string{}
 
 	if c.isURLListMode {
 		logger.Printf("URL List Mode: Initializing queue with %d URLs from the provided list.", len(c.initialURLs))
@@ -287,208 +647,627 @@ func (c *Crawler) Crawl() (CrawlResult, error) {
 				continue
 			}
 			if _, exists := uniqueURLsForQueue[normalizedURL]; !exists {
-				queue = append(queue, normalizedURL)
+				seeds = append(seeds, normalizedURL)
 				uniqueURLsForQueue[normalizedURL] = struct{}{}
 				c.visited[normalizedURL] = true
 			}
 		}
-		logger.Printf("URL List Mode: Effective initial queue size after normalization and deduplication: %d", len(queue))
+		logger.Printf("URL List Mode: Effective initial queue size after normalization and deduplication: %d", len(seeds))
+	} else if len(c.opts.Domains) > 0 {
+		c.domainSaved = make(map[string]int)
+		for _, root := range c.opts.Domains {
+			if !c.visited[root.URL] {
+				seeds = append(seeds, root.URL)
+				c.visited[root.URL] = true
+			}
+		}
+		logger.Printf("Domains Mode: Initializing queue with %d root URLs.", len(seeds))
+	} else if len(c.opts.Interactions) > 0 {
+		for _, seed := range c.runInteractions() {
+			if !c.visited[seed] {
+				seeds = append(seeds, seed)
+				c.visited[seed] = true
+			}
+		}
+		logger.Printf("Interaction Mode: Initializing queue with %d result pages.", len(seeds))
 	} else {
 		normStartURLForQueue, err := normalizeURLtoString(c.startURL.String())
 		if err != nil {
 			result.StopReason = "Failed to start"
 			return result, fmt.Errorf("failed to normalize the initial start URL %s: %w", c.startURL.String(), err)
 		}
-		queue = append(queue, normStartURLForQueue)
+		seeds = append(seeds, normStartURLForQueue)
 		c.visited[normStartURLForQueue] = true
 		logger.Printf("Single URL Mode: Initializing queue with start URL: %s", normStartURLForQueue)
 	}
 
-	if len(queue) == 0 {
+	queue, err := c.newQueue()
+	if err != nil {
+		result.StopReason = "Failed to start"
+		return result, err
+	}
+	queue.Push(seeds...)
+	c.seeds = make(map[string]bool, len(seeds))
+	for _, seed := range seeds {
+		c.seeds[seed] = true
+	}
+
+	if queue.Len() == 0 {
 		logger.Println("Initial crawl queue is empty. Nothing to process.")
 		result.StopReason = "No URLs to process"
 		return result, nil
 	}
 
-	logger.Printf("Starting crawl. Initial queue size: %d. Start URL for context: %s", len(queue), c.startURL.String())
+	if c.opts.MaxMemory > 0 {
+		// Let the GC work harder as the heap approaches the budget before the guard has to step in.
+		defer setGCMemoryLimit(c.opts.MaxMemory)()
+	}
 
-OuterCrawlLoop:
-	for len(queue) > 0 {
-		if c.rootCtx.Err() != nil {
-			logger.Printf("Root context canceled. Stopping crawl. Error: %v", c.rootCtx.Err())
-			result.StopReason = "Cancelled by user"
-			break
-		}
+	c.startWorkers()
+	c.opts.Progress.Start(c.startURL.String(), queue.Len())
 
-		currentURLStr := queue[0]
-		queue = queue[1:]
+	logger.Printf("Starting crawl with %d worker(s). Initial queue size: %d. Start URL for context: %s", len(c.workers), queue.Len(), c.startURL.String())
+	c.runWorkers(queue, &result)
 
-		if c.pageLimit > 0 && len(c.results) >= c.pageLimit {
-			logger.Printf("Page limit (%d) for saved content reached. Stopping crawl.", c.pageLimit)
-			result.StopReason = fmt.Sprintf("Page limit reached (%d)", c.pageLimit)
-			break
+	if err := c.restoreSpilledResults(); err != nil {
+		logger.Printf("Error restoring results flushed to disk: %v", err)
+	}
+	result.NearDuplicates = c.nearDuplicates.Clusters()
+	if c.opts.RelevantTo != "" {
+		c.results, result.IrrelevantPages = selectRelevant(c.results, c.opts.RelevantTo, c.opts.RelevantTop)
+	}
+	result.PagesSaved = len(c.results)
+	if c.opts.SplitHeading > 0 {
+		c.results = splitResultsByHeading(c.results, c.opts.SplitHeading)
+		result.SectionRecords = len(c.results)
+	}
+	if c.opts.Bytes != nil {
+		result.BytesDownloaded = c.opts.Bytes.Used()
+	}
+	result.Duration = time.Since(started)
+
+	var pagePaths map[string]string
+	if len(c.results) > 0 && c.opts.OutputDir != "" {
+		var err error
+		if pagePaths, err = writePageFiles(c.opts.OutputDir, c.results); err != nil {
+			logger.Printf("Error writing page files to %s: %v", c.opts.OutputDir, err)
+			result.OutputDirError = err
+		} else if err := writeOutputIndex(c.opts.OutputDir, c.results, pagePaths); err != nil {
+			logger.Printf("Error writing index of %s: %v", c.opts.OutputDir, err)
+			result.OutputDirError = err
+		}
+	}
+	if len(c.results) > 0 && c.opts.TOCFile != "" {
+		if err := writeTOC(c.opts.TOCFile, c.results, c.opts.OutputDir, pagePaths); err != nil {
+			logger.Printf("Error writing table of contents: %v", err)
+			result.TOCFileError = err
+		}
+	}
+	if c.seo != nil {
+		if err := c.seo.Write(c.opts.SEOReport); err != nil {
+			logger.Printf("Error writing SEO report: %v", err)
+			result.SEOReportError = err
 		}
+	}
 
-		logger.Printf("Processing URL: %s (Queue size: %d, Results: %d)", currentURLStr, len(queue), len(c.results))
+	results := c.results
+	if len(c.results) > 0 && len(c.opts.Routes) > 0 {
+		results = c.writeRoutes(&result)
+	}
 
-		currentURL, err := url.Parse(currentURLStr)
+	if len(c.results) > 0 && c.outputFormat == siteBundleFormat {
+		if err := writeSiteBundle(c.rootCtx, c.outfile, c.results); err != nil {
+			logger.Printf("Error writing site bundle to %s: %v", c.outfile, err)
+			result.OutputFileError = err
+		}
+	} else if len(c.results) > 0 && c.outputFormat == hfDatasetFormat {
+		if err := writeHFDataset(c.outfile, c.results, c.opts.Splits, c.opts.SplitSeed); err != nil {
+			logger.Printf("Error writing dataset to %s: %v", c.outfile, err)
+			result.OutputFileError = err
+		}
+	} else if len(c.results) > 0 && len(c.opts.Splits) > 0 {
+		for i, pages := range assignSplits(c.results, c.opts.Splits, c.opts.SplitSeed) {
+			path := splitOutfilePath(c.outfile, c.opts.Splits[i].Name)
+			result.SplitFiles = append(result.SplitFiles, SplitFile{Name: c.opts.Splits[i].Name, Path: path, Pages: len(pages)})
+			outputData, err := c.formatOutput(pages)
+			if err == nil {
+				err = os.WriteFile(path, outputData, 0644)
+			}
+			if err != nil {
+				logger.Printf("Error writing split %s to %s: %v", c.opts.Splits[i].Name, path, err)
+				result.OutputFileError = err
+			}
+		}
+	} else if len(results) > 0 && (len(c.opts.Stores) > 0 || c.opts.OutputDir == "") {
+		outputData, err := c.formatOutput(results)
 		if err != nil {
-			logger.Printf("Warning: failed to re-parse normalized URL from queue %s: %v. Skipping.", currentURLStr, err)
-			continue
+			logger.Printf("Error encoding results as %s: %v", c.outputFormat, err)
+		} else if len(c.opts.Stores) > 0 {
+			// Not rootCtx: output collected before an interruption is still written.
+			result.StoreResults, result.OutputFileError = writeStores(context.Background(), c.opts.Stores, c.results, outputData, outputContentType(c.outputFormat, c.opts.WrapTemplate))
+		} else {
+			fmt.Println(string(outputData))
 		}
+	}
 
-		var htmlContent string
-		var fetchErr error
-		const maxRetries = 1
+	return result, nil
+}
 
-		for attempt := 0; attempt <= maxRetries; attempt++ {
-			if c.rootCtx.Err() != nil {
-				logger.Printf("Root context canceled before fetching %s, attempt %d. Stopping crawl.", currentURLStr, attempt+1)
-				fetchErr = c.rootCtx.Err()
-				result.StopReason = "Cancelled by user"
-				break OuterCrawlLoop
-			}
-			htmlContent, fetchErr = fetchPageHTML(c.page, c.rootCtx, currentURLStr, c.waitForNetworkIdle)
-			if fetchErr == nil {
-				break
-			}
-			logger.Printf("Error fetching page %s (attempt %d/%d): %v", currentURLStr, attempt+1, maxRetries+1, fetchErr)
+// crawlURL fetches and processes one URL taken from queue on worker w, saving its page and
+// queueing its links. It returns true if the crawl must stop. c.mu must be held.
+func (c *Crawler) crawlURL(w *crawlWorker, queue Frontier, currentURLStr string, result *CrawlResult) bool {
+	logger.Printf("Processing URL: %s (Queue size: %d, Results: %d)", currentURLStr, queue.Len(), c.savedCount())
 
-			isTimeout := errors.Is(fetchErr, context.DeadlineExceeded)
-			isPlaywrightError := strings.Contains(fetchErr.Error(), "Playwright")
+	currentURL, err := url.Parse(currentURLStr)
+	if err != nil {
+		logger.Printf("Warning: failed to re-parse normalized URL from queue %s: %v. Skipping.", currentURLStr, err)
+		c.recordDecision(currentURLStr, 0, 0, auditDecisionFailed, err.Error())
+		return false
+	}
+	rule := c.opts.Policy.Match(currentURL)
+	switch reason, kind := c.fetchSkipReason(currentURL, currentURLStr, rule); kind {
+	case fetchSkipQuiet:
+		logger.Printf("Skipping %s: %s.", currentURLStr, reason)
+		return false
+	case fetchSkipShortcut:
+		logger.Printf("Skipping %s: %s", currentURLStr, reason)
+		result.ShortcutSkipped = append(result.ShortcutSkipped, SkippedPage{URL: currentURLStr, Reason: reason})
+		c.recordDecision(currentURLStr, 0, 0, auditDecisionSkipped, reason)
+		return false
+	case fetchSkipPage:
+		logger.Printf("Skipping %s: %s", currentURLStr, reason)
+		result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+		c.recordDecision(currentURLStr, 0, 0, auditDecisionSkipped, reason)
+		return false
+	}
 
-			if (isTimeout || isPlaywrightError) && attempt < maxRetries {
-				logger.Printf("Retrying fetch for %s...", currentURLStr)
-				continue
-			}
-			break
+	contentSelector := c.contentSelector
+	waitForNetworkIdle := c.waitForNetworkIdle
+	if rule != nil && rule.Selector != "" {
+		contentSelector = rule.Selector
+	}
+	if rule != nil && rule.waitForNetworkIdle != nil {
+		waitForNetworkIdle = *rule.waitForNetworkIdle
+	}
+	if o, ok := c.opts.URLOverrides[currentURLStr]; ok {
+		if o.ContentSelector != "" {
+			contentSelector = o.ContentSelector
+		}
+		if o.WaitForNetworkIdle != nil {
+			waitForNetworkIdle = *o.WaitForNetworkIdle
 		}
+	}
 
+	var htmlContent string
+	var response pageResponse
+	var fetchErr error
+
+	delay := c.robotsFor(currentURL).CrawlDelay()
+	if rule != nil {
+		delay = max(delay, rule.delay)
+	}
+	delay = c.opts.Throttle.reserve(time.Now(), delay)
+	if delay > 0 && !c.sleep(delay) {
+		logger.Printf("Root context canceled during the crawl delay for %s. Stopping crawl.", currentURLStr)
+		result.StopReason = "Cancelled by user"
+		return true
+	}
+	if kind := linkedAssetKind(currentURL); kind != "" {
+		// A browser cannot render these as a page, so they are never opened in it.
+		c.handleAsset(currentURL, currentURLStr, kind, 0, result)
+		return false
+	}
+	referer := c.refererFor(currentURLStr)
+	for attempt := 0; ; attempt++ {
+		if c.rootCtx.Err() != nil {
+			logger.Printf("Root context canceled before fetching %s, attempt %d. Stopping crawl.", currentURLStr, attempt+1)
+			result.StopReason = "Cancelled by user"
+			return true
+		}
+		c.unlocked(func() {
+			htmlContent, response, fetchErr = c.fetch(w.page, currentURLStr, waitForNetworkIdle, referer)
+		})
+		class := classifyFetch(fetchErr, response.Status)
+		if class == fetchOK {
+			break
+		}
 		if fetchErr != nil {
-			errMsgFromFetch := fetchErr.Error()
-			isCriticalError := c.rootCtx.Err() != nil ||
-				(c.pwBrowser != nil && !c.pwBrowser.IsConnected()) ||
-				strings.Contains(errMsgFromFetch, "browser has been closed") ||
-				strings.Contains(errMsgFromFetch, "Target page, context or browser has been closed") ||
-				strings.Contains(errMsgFromFetch, "Target closed") ||
-				strings.Contains(errMsgFromFetch, "net::ERR_CONNECTION_REFUSED")
-
-			if isCriticalError {
-				if c.rootCtx.Err() != nil {
-					logger.Printf("Root context done (%v), stopping crawl. Original fetch error for %s: %v", c.rootCtx.Err(), currentURLStr, fetchErr)
-					result.StopReason = "Cancelled by user"
-				} else if c.pwBrowser != nil && !c.pwBrowser.IsConnected() {
-					logger.Printf("Playwright browser disconnected. Stopping crawl. Original fetch error for %s: %v", currentURLStr, fetchErr)
-					result.StopReason = "Browser connection lost"
-				} else {
-					logger.Printf("Critical error encountered while fetching %s: %v. Stopping crawl.", currentURLStr, fetchErr)
-					result.StopReason = "Critical fetch error"
-				}
-				break
-			}
-			logger.Printf("Skipping page %s due to non-critical fetch error after retries: %v", currentURLStr, fetchErr)
-			continue
+			logger.Printf("Error fetching page %s (attempt %d/%d, %s): %v", currentURLStr, attempt+1, c.opts.Retry.Retries+1, class, fetchErr)
+		} else {
+			logger.Printf("Page %s returned HTTP %d (attempt %d/%d)", currentURLStr, response.Status, attempt+1, c.opts.Retry.Retries+1)
+		}
+		if !class.retryable() || attempt >= c.opts.Retry.Retries {
+			break
+		}
+		wait := c.opts.Throttle.reserve(time.Now(), c.opts.Retry.wait(attempt+1, response.Headers["retry-after"], time.Now()))
+		logger.Printf("Retrying fetch for %s in %s...", currentURLStr, wait)
+		if wait > 0 && !c.sleep(wait) {
+			logger.Printf("Root context canceled while waiting to retry %s. Stopping crawl.", currentURLStr)
+			result.StopReason = "Cancelled by user"
+			return true
 		}
+	}
 
-		if c.shouldProcessContent(currentURL) {
-			pageData, processErr := processHTML(currentURLStr, htmlContent, c.contentSelector)
-			if processErr != nil {
-				logger.Printf("Error processing HTML for %s: %v", currentURLStr, processErr)
-			} else {
-				c.results = append(c.results, *pageData)
-				logger.Printf("Content saved for %s. Total saved pages: %d", currentURLStr, len(c.results))
+	fetchedPage := w.page
+	adaptiveRetried := false
+	if errors.Is(fetchErr, errEmptyHTML) && c.opts.AdaptiveWait {
+		adaptiveRetried = true
+		result.AdaptiveWaitRetries++
+		c.unlocked(func() {
+			if html, resp, err := c.fetchWithAdaptiveWait(w.page, currentURLStr, referer); err == nil {
+				htmlContent, response, fetchErr = html, resp, nil
 			}
+		})
+	}
+	if fetchErr != nil {
+		if page, fallbackHTML, fallbackResponse := c.fetchWithFallback(currentURLStr, waitForNetworkIdle, fetchErr); page != nil {
+			fetchedPage, htmlContent, response, fetchErr = page, fallbackHTML, fallbackResponse, nil
+			result.FallbackFetches++
 		}
+	}
 
-		if !c.isURLListMode {
-			if currentURL.Hostname() == c.startURL.Hostname() {
-				links := c.extractAndFilterLinks(currentURL, htmlContent)
-				for _, normalizedLinkStr := range links {
-					if _, visited := c.visited[normalizedLinkStr]; !visited {
-						if c.rootCtx.Err() != nil {
-							logger.Printf("Root context canceled. Not adding more links to queue.")
-							result.StopReason = "Cancelled by user"
-							break
-						}
-						c.visited[normalizedLinkStr] = true
-						queue = append(queue, normalizedLinkStr)
-						logger.Printf("Added to queue: %s", normalizedLinkStr)
-					}
-				}
+	if response.Headers["content-length"] == "" {
+		// The page's OnResponse listener could not count a document without a Content-Length.
+		c.opts.Bytes.add(int64(len(htmlContent)))
+	}
+	statusCode := response.Status
+	if fetchErr != nil {
+		c.recordDecision(currentURLStr, statusCode, 0, auditDecisionFailed, fetchErr.Error())
+		result.countFailure(currentURL.Hostname(), currentURLStr, fetchErr.Error())
+		c.seo.RecordFailure(currentURLStr, statusCode, fetchErr)
+		c.markDeadHost(currentURL.Hostname(), fetchErr)
+		class := classifyFetch(fetchErr, statusCode)
+		// A refused connection ends a single-site crawl, but with several hosts only that host is given up.
+		singleHost := !c.isURLListMode && len(c.opts.Domains) == 0
+		isCriticalError := c.rootCtx.Err() != nil ||
+			(c.pwBrowser != nil && !c.pwBrowser.IsConnected()) ||
+			class == fetchErrorBrowserClosed ||
+			(singleHost && class == fetchErrorRefused)
+
+		if isCriticalError {
+			if c.rootCtx.Err() != nil {
+				logger.Printf("Root context done (%v), stopping crawl. Original fetch error for %s: %v", c.rootCtx.Err(), currentURLStr, fetchErr)
+				result.StopReason = "Cancelled by user"
+			} else if c.pwBrowser != nil && !c.pwBrowser.IsConnected() {
+				logger.Printf("Playwright browser disconnected. Stopping crawl. Original fetch error for %s: %v", currentURLStr, fetchErr)
+				result.StopReason = "Browser connection lost"
+			} else {
+				logger.Printf("Critical error encountered while fetching %s: %v. Stopping crawl.", currentURLStr, fetchErr)
+				result.StopReason = "Critical fetch error"
 			}
+			return true
 		}
+		logger.Printf("Skipping page %s due to non-critical fetch error after retries: %v", currentURLStr, fetchErr)
+		return false
 	}
 
-	result.PagesSaved = len(c.results)
+	result.PagesFetched++
+	result.BytesDownloaded += int64(len(htmlContent))
 
-	if len(c.results) > 0 {
-		var outputData []byte
-		var err error
+	if reason, duplicate := c.recordFetch(currentURLStr, response.FinalURL); duplicate {
+		logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+		result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+		c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		return false
+	}
+	pageURL := c.finalPageURL(currentURL, response.FinalURL)
+	if pageURL != currentURL {
+		logger.Printf("%s redirected to %s", currentURLStr, pageURL)
+		c.followStartRedirect(currentURLStr, pageURL)
+	}
+	// Relative links and images resolve against where the page ended up, as the browser
+	// reported it: normalizing drops the trailing slash of directory URLs.
+	baseURL := linkBaseURL(currentURL, response.FinalURL)
 
-		switch c.outputFormat {
-		case "json":
-			outputData, err = formatResultsAsJSON(c.results)
-			if err != nil {
-				logger.Printf("Error marshalling results to JSON: %v", err)
+	if kind := contentAssetKind(response.Headers["content-type"]); kind != "" && !c.opts.SkipStatus.contains(statusCode) {
+		c.handleAsset(currentURL, currentURLStr, kind, statusCode, result)
+		return false
+	}
+	if c.opts.MaxPageBytes > 0 && int64(len(htmlContent)) > c.opts.MaxPageBytes {
+		reason := fmt.Sprintf("page size %s exceeds limit of %s", formatByteSize(int64(len(htmlContent))), formatByteSize(c.opts.MaxPageBytes))
+		logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+		result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+		c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		return false
+	}
+
+	c.seo.AddPage(currentURL, statusCode, response.Headers, htmlContent)
+	if c.opts.SkipStatus.contains(statusCode) {
+		reason := fmt.Sprintf("HTTP status %d", statusCode)
+		logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+		result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+		c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		return false
+	}
+	htmlContent = c.opts.Plugins.OnHTML(currentURLStr, htmlContent)
+
+	var canonical string
+	if c.opts.RespectCanonical {
+		canonical = pageCanonical(baseURL, htmlContent)
+	}
+	canonicalKey := cmp.Or(canonical, currentURLStr)
+
+	if rule != nil && !rule.saves() {
+		c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionMatchMiss, "policy rule "+rule.Path+": "+rule.Action)
+		result.MatchSkippedPages++
+	} else if rule == nil && !c.shouldProcessContent(currentURL) {
+		c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionMatchMiss, "")
+		result.MatchSkippedPages++
+	} else if original := c.canonicalDuplicate(canonicalKey); original != "" {
+		reason := fmt.Sprintf("canonical URL %s was already saved from %s", canonicalKey, original)
+		logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+		result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+		c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+	} else {
+		var pageData *PageData
+		var comments []Comment
+		var tables []Table
+		var processErr error
+		c.unlocked(func() {
+			pageData, comments, tables, processErr = c.extractPage(baseURL, htmlContent, contentSelector)
+		})
+		if c.opts.AdaptiveWait && !adaptiveRetried && extractedNothing(pageData, processErr) {
+			result.AdaptiveWaitRetries++
+			c.unlocked(func() {
+				if html, resp, err := c.fetchWithAdaptiveWait(fetchedPage, currentURLStr, referer); err == nil {
+					htmlContent, response = html, resp
+					pageData, comments, tables, processErr = c.extractPage(baseURL, htmlContent, contentSelector)
+				}
+			})
+			statusCode = response.Status
+		}
+		// Other workers may have saved pages while this one was extracting.
+		if original := c.canonicalDuplicate(canonicalKey); original != "" {
+			reason := fmt.Sprintf("canonical URL %s was already saved from %s", canonicalKey, original)
+			logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+			result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		} else if reason := c.saveLimitReason(currentURL, rule); reason != "" {
+			logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+			result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		} else if errors.Is(processErr, errProcessingTimeout) {
+			reason := fmt.Sprintf("content processing exceeded %s", c.opts.ProcessTimeout)
+			logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+			result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		} else if processErr != nil {
+			logger.Printf("Error processing HTML for %s: %v", currentURLStr, processErr)
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionFailed, processErr.Error())
+			result.countFailure(currentURL.Hostname(), currentURLStr, processErr.Error())
+		} else if !c.opts.PublishedAfter.IsZero() && !pageData.Published.IsZero() && pageData.Published.Before(c.opts.PublishedAfter) {
+			reason := fmt.Sprintf("published %s, before %s", formatPublishedDate(pageData.Published), formatPublishedDate(c.opts.PublishedAfter))
+			logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+			result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		} else if reason := c.gatedReason(htmlContent, pageData.Markdown); reason != "" {
+			logger.Printf("Skipping gated page %s: %s", currentURLStr, reason)
+			result.GatedPages = append(result.GatedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, "gated: "+reason)
+		} else if original := c.contentDuplicate(pageData.Markdown); original != "" {
+			reason := "same content as " + original
+			logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+			result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		} else if original, similarity := c.nearDuplicates.Check(currentURLStr, pageData.Markdown); original != "" {
+			reason := fmt.Sprintf("near-duplicate of %s (similarity %.2f)", original, similarity)
+			logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+			c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+		} else {
+			// --dedupe-content hashes the content as extracted, before redaction or truncation.
+			extractedMarkdown := pageData.Markdown
+			pageData.RawHTML = htmlContent
+			pageData.Comments = comments
+			pageData.Headers = selectResponseHeaders(response.Headers, c.opts.ResponseHeaders)
+			restoreTables(pageData, tables, c.opts.Tables)
+			if c.opts.A11yTree {
+				tree, err := captureA11yTree(fetchedPage)
+				if err != nil {
+					logger.Printf("Warning: %v for %s. Saving the page without it.", err, currentURLStr)
+				}
+				pageData.A11yTree = tree
 			}
-		case "jsonl":
-			outputData, err = formatResultsAsJSONL(c.results)
-			if err != nil {
-				logger.Printf("Error marshalling results to JSONL: %v", err)
+			if len(c.opts.EvalExtract) > 0 {
+				extracted, err := evalExtract(fetchedPage, c.opts.EvalExtract)
+				if err != nil {
+					logger.Printf("Warning: %v for %s", err, currentURLStr)
+				}
+				pageData.Extracted = extracted
+			}
+			if c.opts.CaptureOGImages {
+				c.captureOGImages(baseURL, htmlContent, pageData)
+			}
+			if len(c.opts.RedactPII) > 0 {
+				redactPageData(pageData, c.opts.RedactPII, result.PIIRedactions)
+			}
+			if len(c.opts.Domains) > 0 {
+				pageData.Site = currentURL.Hostname()
 			}
-		case "xml-like":
-			fallthrough
-		default:
-			var outputStrings []string
-			for _, pd := range c.results {
-				outputStrings = append(outputStrings, formatPageDataAsXML(&pd))
+			pageData.Labels = c.opts.Labels.Match(currentURL)
+			pageData.URL = currentURLStr
+			if pageURL != currentURL {
+				pageData.FinalURL = pageURL.String()
+			}
+			pageData.Canonical = canonical
+			pageData.Status = statusCode
+			reason := c.opts.Filter.rejectReason(pageData)
+			if reason == "" {
+				if statusCode == http.StatusOK && !c.opts.RecordStatus {
+					pageData.Status = 0
+				}
+				applyOutputFieldPolicy(pageData, c.opts.DropFields, c.opts.MaxContentLength)
+				reason = c.opts.Plugins.OnPage(pageData)
+			}
+			if reason == "" {
+				reason = c.opts.WASMTransform.Apply(pageData, htmlContent)
+			}
+			if reason != "" {
+				logger.Printf("Skipping page %s: %s", currentURLStr, reason)
+				result.SkippedPages = append(result.SkippedPages, SkippedPage{URL: currentURLStr, Reason: reason})
+				c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSkipped, reason)
+			} else {
+				if pageData.Site != "" {
+					c.domainSaved[pageData.Site]++
+				}
+				if rule != nil {
+					c.policySaved[rule]++
+				}
+				c.results = append(c.results, *pageData)
+				c.recordCanonical(canonicalKey, currentURLStr)
+				c.recordContent(extractedMarkdown, currentURLStr)
+				c.recordDecision(currentURLStr, statusCode, len(htmlContent), auditDecisionSaved, "")
+				result.countPage(currentURL.Hostname(), true)
+				logger.Printf("Content saved for %s. Total saved pages: %d", currentURLStr, c.savedCount())
 			}
-			finalOutput := strings.Join(outputStrings, "\n\n")
-			outputData = []byte(finalOutput)
 		}
+	}
 
-		if err == nil {
-			if c.outfile != "" {
-				err := os.WriteFile(c.outfile, outputData, 0644)
-				if err != nil {
-					logger.Printf("Error writing to outfile %s: %v", c.outfile, err)
-					result.OutputFileError = err
+	if !c.enforceMemoryLimit(w) {
+		result.StopReason = fmt.Sprintf("Memory limit exceeded (%s)", formatByteSize(c.opts.MaxMemory))
+		return true
+	}
+
+	if !c.isURLListMode && (rule == nil || rule.follows()) {
+		if !c.followsLinksFrom(currentURLStr) {
+			logger.Printf("Not following links on %s: it is at the maximum depth of %d.", currentURLStr, c.opts.MaxDepth)
+			result.DepthLimitedPages++
+		} else if c.isCrawlHost(pageURL.Hostname()) {
+			links := c.extractAndFilterLinks(baseURL, htmlContent)
+			if c.opts.FollowRelNext {
+				links = append(links, c.extractRelNextLinks(baseURL, htmlContent, response.Headers["link"])...)
+			}
+			var found []string
+			for _, normalizedLinkStr := range links {
+				if _, visited := c.visited[normalizedLinkStr]; !visited {
+					if c.rootCtx.Err() != nil {
+						logger.Printf("Root context canceled. Not adding more links to queue.")
+						result.StopReason = "Cancelled by user"
+						break
+					}
+					c.visited[normalizedLinkStr] = true
+					c.recordLinkSource(normalizedLinkStr, currentURLStr)
+					c.recordLinkDepth(normalizedLinkStr, currentURLStr)
+					found = append(found, normalizedLinkStr)
+					logger.Printf("Added to queue: %s", normalizedLinkStr)
 				}
-			} else {
-				fmt.Println(string(outputData))
 			}
+			queue.Push(found...)
 		}
 	}
+	return false
+}
+
+// countFailure records a page of host that could not be fetched or processed.
+func (r *CrawlResult) countFailure(host string, pageURL string, reason string) {
+	r.countPage(host, false)
+	r.FailedPages = append(r.FailedPages, SkippedPage{URL: pageURL, Reason: reason})
+}
+
+// countPage adds a saved or failed page of host to the result's counters.
+func (r *CrawlResult) countPage(host string, saved bool) {
+	if r.HostStats == nil {
+		r.HostStats = make(map[string]HostStats)
+	}
+	stats := r.HostStats[host]
+	if saved {
+		stats.Saved++
+	} else {
+		stats.Failed++
+		r.PagesFailed++
+	}
+	r.HostStats[host] = stats
+}
 
-	return result, nil
+// fetchSkipKind says whether and how a URL taken from the queue is skipped without a fetch.
+type fetchSkipKind int
+
+const (
+	fetchSkipNone fetchSkipKind = iota
+	// fetchSkipQuiet is only logged: the host's page limit is reached, or the URL was already
+	// fetched as a redirect target.
+	fetchSkipQuiet
+	// fetchSkipShortcut is listed as a shortcut skip: the host was found unreachable.
+	fetchSkipShortcut
+	// fetchSkipPage is listed as a skipped page: a --policy rule, --exclude-follow, --versions,
+	// robots.txt or a plugin skips it.
+	fetchSkipPage
+)
+
+// fetchSkipReason runs the checks a URL taken from the queue must pass before it is fetched and
+// returns why it is skipped.
+func (c *Crawler) fetchSkipReason(u *url.URL, pageURL string, rule *policyRule) (string, fetchSkipKind) {
+	if c.domainLimitReached(u.Hostname()) {
+		return fmt.Sprintf("page limit for %s reached", u.Hostname()), fetchSkipQuiet
+	}
+	if via, ok := c.alreadyFetchedAs(pageURL); ok {
+		return "already fetched as the redirect target of " + via, fetchSkipQuiet
+	}
+	if reason, dead := c.deadHostSkipReason(u.Hostname()); dead {
+		return reason, fetchSkipShortcut
+	}
+	if reason := c.policySkipReason(rule); reason != "" {
+		return reason, fetchSkipPage
+	}
+	if rule == nil && !c.seeds[pageURL] && c.excludesFollow(u) {
+		return "matches an --exclude-follow pattern", fetchSkipPage
+	}
+	if reason := c.versionSkipReason(u, pageURL); reason != "" {
+		return reason, fetchSkipPage
+	}
+	if reason := c.robotsFor(u).skipReason(u); reason != "" {
+		return reason, fetchSkipPage
+	}
+	if reason := c.opts.Plugins.OnRequest(pageURL); reason != "" {
+		return reason, fetchSkipPage
+	}
+	return "", fetchSkipNone
 }
 
 func (c *Crawler) shouldProcessContent(pageURL *url.URL) bool {
-	if len(c.matchPatterns) == 0 {
-		return true
+	if c.excludesContent(pageURL) {
+		logger.Printf("Path '%s' (from URL %s) matches an --exclude-match pattern. Skipping content processing.", pageURL.Path, pageURL.String())
+		return false
 	}
-	pathToMatch := pageURL.Path
-	if pathToMatch == "" {
-		pathToMatch = "/"
-	} else if !strings.HasPrefix(pathToMatch, "/") {
-		pathToMatch = "/" + pathToMatch
+	if c.matchesContent(pageURL) {
+		return true
 	}
+	logger.Printf("Path '%s' (from URL %s) did not match any --match patterns. Skipping content processing.", matchPath(pageURL), pageURL.String())
+	return false
+}
 
+// matchesContent reports whether pageURL's path matches a --match pattern, or there are none.
+func (c *Crawler) matchesContent(pageURL *url.URL) bool {
+	if len(c.matchPatterns) == 0 {
+		return true
+	}
+	pathToMatch := matchPath(pageURL)
 	for _, g := range c.matchPatterns {
 		if g.Match(pathToMatch) {
 			return true
 		}
 	}
-	logger.Printf("Path '%s' (from URL %s) did not match any --match patterns. Skipping content processing.", pathToMatch, pageURL.String())
 	return false
 }
 
+// matchPath returns the path of pageURL as --match patterns see it, with a leading slash.
+func matchPath(pageURL *url.URL) string {
+	switch {
+	case pageURL.Path == "":
+		return "/"
+	case !strings.HasPrefix(pageURL.Path, "/"):
+		return "/" + pageURL.Path
+	default:
+		return pageURL.Path
+	}
+}
+
 func (c *Crawler) extractAndFilterLinks(pageURL *url.URL, htmlBody string) []string {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlBody))
 	if err != nil {
 		logger.Printf("Warning: failed to parse HTML for link extraction from %s: %v", pageURL.String(), err)
 		return nil
 	}
+	c.opts.Versions.observe(pageURL, doc)
 
 	uniqueLinks := make(map[string]struct{})
 	var validLinks []string
@@ -508,16 +1287,23 @@ func (c *Crawler) extractAndFilterLinks(pageURL *url.URL, htmlBody string) []str
 		if err != nil {
 			return
 		}
+		normLinkStr = c.collapseLocale(c.applyQueryPolicy(normLinkStr))
 
 		resolvedParsedURL, _ := url.Parse(normLinkStr)
 		if resolvedParsedURL.Scheme != "http" && resolvedParsedURL.Scheme != "https" {
 			return
 		}
-		if resolvedParsedURL.Hostname() != c.startURL.Hostname() {
+		if !c.isCrawlHost(resolvedParsedURL.Hostname()) || c.opts.Versions.skipReason(resolvedParsedURL) != "" {
 			return
 		}
 
-		if len(c.followMatchPatterns) > 0 {
+		if rule := c.opts.Policy.Match(resolvedParsedURL); rule != nil {
+			if rule.Action == policyActionSkip {
+				return
+			}
+		} else if c.excludesFollow(resolvedParsedURL) {
+			return
+		} else if len(c.followMatchPatterns) > 0 {
 			shouldFollow := false
 			pathToMatch := resolvedParsedURL.Path
 			if pathToMatch == "" {
@@ -589,17 +1375,87 @@ func normalizeURLtoString(urlString string) (string, error) {
 	return parsed.String(), nil
 }
 
+// formatOutput encodes results for --outfile or stdout: with the --wrap-template, or else in
+// the output format.
+func (c *Crawler) formatOutput(results []PageData) ([]byte, error) {
+	if c.opts.WrapTemplate == "" {
+		return formatResults(c.outputFormat, results)
+	}
+	data, included := formatResultsWithTemplate(c.opts.WrapTemplate, results, c.opts.MaxTotalTokens)
+	if included < len(results) {
+		logger.Printf("--max-total-tokens %d: output holds %d of %d pages.", c.opts.MaxTotalTokens, included, len(results))
+	}
+	return data, nil
+}
+
+// formatResults encodes results in one of the single-file output formats; unknown formats
+// fall back to xml-like.
+func formatResults(format string, results []PageData) ([]byte, error) {
+	switch format {
+	case "json":
+		return formatResultsAsJSON(results)
+	case "jsonl":
+		return formatResultsAsJSONL(results)
+	case parquetFormat:
+		return formatResultsAsParquet(results)
+	case "org":
+		var outputStrings []string
+		for i := range results {
+			outputStrings = append(outputStrings, formatPageDataAsOrg(&results[i]))
+		}
+		return []byte(strings.Join(outputStrings, "\n")), nil
+	case "asciidoc":
+		var outputStrings []string
+		for i := range results {
+			outputStrings = append(outputStrings, formatPageDataAsAsciiDoc(&results[i]))
+		}
+		return []byte(strings.Join(outputStrings, "\n")), nil
+	default:
+		var outputStrings []string
+		for _, pd := range results {
+			outputStrings = append(outputStrings, formatPageDataAsXML(&pd))
+		}
+		return []byte(strings.Join(outputStrings, "\n\n")), nil
+	}
+}
+
+// isDirectoryOutputFormat reports whether format writes a directory at --outfile rather than a file.
+func isDirectoryOutputFormat(format string) bool {
+	return format == siteBundleFormat || format == hfDatasetFormat
+}
+
+// newJSONOutputPage returns the JSON and JSONL representation of pd.
+func newJSONOutputPage(pd *PageData) JSONOutputPage {
+	return JSONOutputPage{
+		Title:       pd.Title,
+		URL:         pd.URL,
+		Section:     pd.Section,
+		Breadcrumbs: pd.Breadcrumbs,
+		Tags:        pd.Tags,
+		Published:   formatPublishedDate(pd.Published),
+		Content:     pd.Markdown,
+		Comments:    pd.Comments,
+		Headers:     pd.Headers,
+		A11yTree:    pd.A11yTree,
+		Image:       pd.OGImage,
+		Favicon:     pd.Favicon,
+		Site:        pd.Site,
+		FinalURL:    pd.FinalURL,
+		Canonical:   pd.Canonical,
+		Status:      pd.Status,
+		Labels:      pd.Labels,
+		Extracted:   pd.Extracted,
+		Relevance:   pd.Relevance,
+	}
+}
+
 func formatResultsAsJSON(results []PageData) ([]byte, error) {
 	if len(results) == 0 {
 		return []byte("[]"), nil
 	}
 	var jsonOutputPages []JSONOutputPage
 	for _, pd := range results {
-		jsonOutputPages = append(jsonOutputPages, JSONOutputPage{
-			Title:   pd.Title,
-			URL:     pd.URL,
-			Content: pd.Markdown,
-		})
+		jsonOutputPages = append(jsonOutputPages, newJSONOutputPage(&pd))
 	}
 	return json.MarshalIndent(jsonOutputPages, "", "  ")
 }
@@ -607,11 +1463,7 @@ func formatResultsAsJSON(results []PageData) ([]byte, error) {
 func formatResultsAsJSONL(results []PageData) ([]byte, error) {
 	var buffer bytes.Buffer
 	for _, pd := range results {
-		jsonOutputPage := JSONOutputPage{
-			Title:   pd.Title,
-			URL:     pd.URL,
-			Content: pd.Markdown,
-		}
+		jsonOutputPage := newJSONOutputPage(&pd)
 		jsonData, err := json.Marshal(jsonOutputPage)
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode page to JSONL (URL: %s): %w", pd.URL, err)
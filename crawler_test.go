@@ -184,6 +184,14 @@ func TestFormatResultsAsJSONL(t *testing.T) {
 			},
 			wantJSONL: `{"title":"Special \"Chars\" Page","url":"http://example.com/special","content":"Content with \u003c\u003e\u0026'\""}` + "\n",
 		},
+		{
+			name: "page with section and breadcrumbs",
+			input: []PageData{
+				{Title: "Install", URL: "http://example.com/docs/install", Markdown: "Steps", Section: "Docs",
+					Breadcrumbs: []Breadcrumb{{Name: "Docs", URL: "http://example.com/docs"}, {Name: "Install"}}},
+			},
+			wantJSONL: `{"title":"Install","url":"http://example.com/docs/install","section":"Docs","breadcrumbs":[{"name":"Docs","url":"http://example.com/docs"},{"name":"Install"}],"content":"Steps"}` + "\n",
+		},
 	}
 
 	for _, tt := range tests {
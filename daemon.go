@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/hokupod/sitepanda/cmd"
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	browserDaemonStartTimeout = 60 * time.Second
+	browserDaemonStopTimeout  = 15 * time.Second
+)
+
+// browserDaemonState is written by a running browser daemon so that other invocations can find it.
+type browserDaemonState struct {
+	Browser   string    `json:"browser"`
+	PID       int       `json:"pid"`
+	Endpoint  string    `json:"endpoint"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// browserDaemonPaths returns the state and log file locations for the daemon of browserName.
+func browserDaemonPaths(browserName string) (statePath string, logPath string, err error) {
+	dir, err := GetAppSubdirectory("daemon")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to determine daemon directory: %w", err)
+	}
+	return filepath.Join(dir, browserName+".json"), filepath.Join(dir, browserName+".log"), nil
+}
+
+// readBrowserDaemonState loads the state file. It returns nil without error if no daemon state exists.
+func readBrowserDaemonState(statePath string) (*browserDaemonState, error) {
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daemon state %s: %w", statePath, err)
+	}
+	var state browserDaemonState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon state %s: %w", statePath, err)
+	}
+	return &state, nil
+}
+
+func writeBrowserDaemonState(statePath string, state *browserDaemonState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write daemon state %s: %w", tmpPath, err)
+	}
+	return os.Rename(tmpPath, statePath)
+}
+
+// reachable reports whether the daemon's debugging endpoint accepts TCP connections.
+func (s *browserDaemonState) reachable() bool {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", u.Host, time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// findRunningBrowserDaemon returns the state of a reachable daemon for browserName, or nil.
+func findRunningBrowserDaemon(browserName string) *browserDaemonState {
+	statePath, _, err := browserDaemonPaths(browserName)
+	if err != nil {
+		return nil
+	}
+	state, err := readBrowserDaemonState(statePath)
+	if err != nil || state == nil || state.Browser != browserName || !state.reachable() {
+		return nil
+	}
+	return state
+}
+
+// connectToBrowserDaemon attaches to a running daemon. For Lightpanda the crawler connects to
+// the returned WebSocket URL itself; for Chromium the connected browser is returned.
+func connectToBrowserDaemon(state *browserDaemonState, baseInstallDirForChromium string) (wsURL string, pwInstance *playwright.Playwright, pwBrowser playwright.Browser, err error) {
+	switch state.Browser {
+	case "lightpanda":
+		pwInstance, err = playwright.Run()
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("could not start playwright for Lightpanda connection: %w", err)
+		}
+		return state.Endpoint, pwInstance, nil, nil
+	case "chromium":
+		pwInstance, err = playwright.Run(&playwright.RunOptions{DriverDirectory: baseInstallDirForChromium})
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("could not start playwright for Chromium (DriverDirectory: %s): %w", baseInstallDirForChromium, err)
+		}
+		pwBrowser, err = pwInstance.Chromium.ConnectOverCDP(state.Endpoint, playwright.BrowserTypeConnectOverCDPOptions{
+			Timeout: playwright.Float(30000),
+		})
+		if err != nil {
+			_ = pwInstance.Stop()
+			return "", nil, nil, fmt.Errorf("could not connect to Chromium daemon at %s: %w", state.Endpoint, err)
+		}
+		return "", pwInstance, pwBrowser, nil
+	default:
+		return "", nil, nil, fmt.Errorf("unsupported browser in daemon state: %s", state.Browser)
+	}
+}
+
+// HandleBrowserDaemon implements the browser start/stop/status commands.
+func HandleBrowserDaemon(action string) {
+	if cmd.GetSilent() {
+		SetLoggerOutput(io.Discard)
+	}
+
+	browserName := cmd.GetBrowserName()
+	if browserName != "lightpanda" && browserName != "chromium" {
+		logger.Fatalf("Error: Invalid browser specified: %s. Supported: 'lightpanda', 'chromium'.", browserName)
+	}
+	statePath, logPath, err := browserDaemonPaths(browserName)
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+
+	switch action {
+	case "start":
+		if cmd.GetBrowserDaemonForeground() {
+			runBrowserDaemon(browserName, statePath)
+		} else {
+			startBrowserDaemon(browserName, statePath, logPath)
+		}
+	case "stop":
+		stopBrowserDaemon(browserName, statePath)
+	case "status":
+		state, err := readBrowserDaemonState(statePath)
+		if err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+		if state == nil {
+			fmt.Printf("%s daemon: not running\n", browserName)
+			return
+		}
+		if !state.reachable() {
+			fmt.Printf("%s daemon: not responding (stale state for PID %d, run 'sitepanda browser stop --browser %s' to clean up)\n", browserName, state.PID, browserName)
+			return
+		}
+		fmt.Printf("%s daemon: running\n", browserName)
+		fmt.Printf("  PID:      %d\n", state.PID)
+		fmt.Printf("  Endpoint: %s\n", state.Endpoint)
+		fmt.Printf("  Uptime:   %s\n", time.Since(state.StartedAt).Round(time.Second))
+		fmt.Printf("  Log:      %s\n", logPath)
+	default:
+		logger.Fatalf("Error: unknown browser daemon action: %s", action)
+	}
+}
+
+// startBrowserDaemon re-launches sitepanda as a detached background process and waits until it is ready.
+func startBrowserDaemon(browserName, statePath, logPath string) {
+	if state := findRunningBrowserDaemon(browserName); state != nil {
+		fmt.Printf("%s daemon is already running (PID %d, endpoint %s)\n", browserName, state.PID, state.Endpoint)
+		return
+	}
+	_ = os.Remove(statePath)
+
+	exe, err := os.Executable()
+	if err != nil {
+		logger.Fatalf("Error: could not determine sitepanda executable: %v", err)
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Fatalf("Error: could not open daemon log %s: %v", logPath, err)
+	}
+	defer logFile.Close()
+
+	daemonCmd := exec.Command(exe, "browser", "start", "--foreground", "--browser", browserName)
+	daemonCmd.Stdout = logFile
+	daemonCmd.Stderr = logFile
+	detachProcess(daemonCmd)
+	if err := daemonCmd.Start(); err != nil {
+		logger.Fatalf("Error: failed to start %s daemon: %v", browserName, err)
+	}
+	pid := daemonCmd.Process.Pid
+	_ = daemonCmd.Process.Release()
+
+	logger.Printf("Waiting for %s daemon (PID %d) to become ready...", browserName, pid)
+	deadline := time.Now().Add(browserDaemonStartTimeout)
+	for time.Now().Before(deadline) {
+		state, _ := readBrowserDaemonState(statePath)
+		if state != nil && state.PID == pid && state.reachable() {
+			fmt.Printf("%s daemon started (PID %d, endpoint %s)\n", browserName, pid, state.Endpoint)
+			return
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	logger.Fatalf("Error: %s daemon did not become ready within %s. See %s for details.", browserName, browserDaemonStartTimeout, logPath)
+}
+
+// runBrowserDaemon launches the browser, publishes its endpoint and keeps it alive until signaled.
+func runBrowserDaemon(browserName, statePath string) {
+	playwrightDriverDir, err := GetAppSubdirectory("playwright_driver")
+	if err != nil {
+		logger.Fatalf("Failed to determine Sitepanda's Playwright driver directory: %v", err)
+	}
+	browserExecutablePath, browserPrepareCleanup, err := prepareBrowser(browserName, playwrightDriverDir)
+	if err != nil {
+		logger.Fatalf("Failed to prepare %s: %v. If not installed, please run 'sitepanda init %s'.", browserName, err, browserName)
+	}
+	defer browserPrepareCleanup()
+
+	port, err := getFreePort()
+	if err != nil {
+		logger.Fatalf("Failed to get free port for %s daemon: %v", browserName, err)
+	}
+	host := "127.0.0.1"
+
+	var endpoint string
+	var lightpandaCmd *exec.Cmd
+	var pwInstance *playwright.Playwright
+	var pwBrowser playwright.Browser
+	exited := make(chan struct{})
+
+	switch browserName {
+	case "lightpanda":
+		lightpandaCmd = exec.Command(browserExecutablePath, "serve", "--host", host, "--port", fmt.Sprintf("%d", port))
+		lightpandaCmd.Stdout = newLinePrefixWriter(logger.Writer(), "[lightpanda stdout] ")
+		lightpandaCmd.Stderr = newLinePrefixWriter(logger.Writer(), "[lightpanda stderr] ")
+		if err := lightpandaCmd.Start(); err != nil {
+			logger.Fatalf("Failed to start Lightpanda server: %v", err)
+		}
+		go func() {
+			_ = lightpandaCmd.Wait()
+			close(exited)
+		}()
+		endpoint = fmt.Sprintf("ws://%s:%d", host, port)
+	case "chromium":
+		pwInstance, err = playwright.Run(&playwright.RunOptions{DriverDirectory: playwrightDriverDir})
+		if err != nil {
+			logger.Fatalf("Could not start playwright for Chromium (DriverDirectory: %s): %v", playwrightDriverDir, err)
+		}
+		pwBrowser, err = pwInstance.Chromium.Launch(playwright.BrowserTypeLaunchOptions{
+			Headless: playwright.Bool(true),
+			Args:     []string{"--disable-gpu", fmt.Sprintf("--remote-debugging-address=%s", host), fmt.Sprintf("--remote-debugging-port=%d", port)},
+		})
+		if err != nil {
+			_ = pwInstance.Stop()
+			logger.Fatalf("Could not launch Chromium: %v", err)
+		}
+		endpoint = fmt.Sprintf("http://%s:%d", host, port)
+	}
+	defer shutdownBrowser(browserName, pwBrowser, pwInstance, nil)
+
+	if err := waitForPort(host, port, 10*time.Second); err != nil {
+		if lightpandaCmd != nil {
+			_ = lightpandaCmd.Process.Kill()
+		}
+		logger.Printf("Error: %s did not become ready in time: %v", browserName, err)
+		return
+	}
+
+	state := &browserDaemonState{Browser: browserName, PID: os.Getpid(), Endpoint: endpoint, StartedAt: time.Now()}
+	if err := writeBrowserDaemonState(statePath, state); err != nil {
+		logger.Printf("Error: %v", err)
+		return
+	}
+	defer os.Remove(statePath)
+	logger.Printf("%s daemon ready at %s (PID %d)", browserName, endpoint, state.PID)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	select {
+	case sig := <-sigChan:
+		logger.Printf("Received signal: %v. Stopping %s daemon...", sig, browserName)
+		if lightpandaCmd != nil {
+			_ = lightpandaCmd.Process.Kill()
+			<-exited
+		}
+	case <-exited:
+		logger.Printf("Lightpanda process exited. Stopping daemon.")
+	}
+}
+
+// stopBrowserDaemon signals the daemon to shut down and waits for it to remove its state file.
+func stopBrowserDaemon(browserName, statePath string) {
+	state, err := readBrowserDaemonState(statePath)
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if state == nil {
+		fmt.Printf("%s daemon is not running\n", browserName)
+		return
+	}
+
+	proc, err := os.FindProcess(state.PID)
+	if err == nil {
+		if sigErr := proc.Signal(syscall.SIGTERM); sigErr != nil {
+			_ = proc.Kill()
+		}
+	}
+
+	deadline := time.Now().Add(browserDaemonStopTimeout)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(statePath); errors.Is(err, os.ErrNotExist) {
+			fmt.Printf("%s daemon stopped (PID %d)\n", browserName, state.PID)
+			return
+		}
+		if !state.reachable() {
+			break
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	if proc != nil {
+		_ = proc.Kill()
+	}
+	_ = os.Remove(statePath)
+	fmt.Printf("%s daemon stopped (PID %d)\n", browserName, state.PID)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBrowserDaemonStateRoundTrip(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "chromium.json")
+
+	state, err := readBrowserDaemonState(statePath)
+	if err != nil || state != nil {
+		t.Fatalf("readBrowserDaemonState(missing) = %v, %v; want nil, nil", state, err)
+	}
+
+	want := &browserDaemonState{Browser: "chromium", PID: 4242, Endpoint: "http://127.0.0.1:9222", StartedAt: time.Now().UTC().Truncate(time.Second)}
+	if err := writeBrowserDaemonState(statePath, want); err != nil {
+		t.Fatalf("writeBrowserDaemonState() error: %v", err)
+	}
+	got, err := readBrowserDaemonState(statePath)
+	if err != nil {
+		t.Fatalf("readBrowserDaemonState() error: %v", err)
+	}
+	if *got != *want {
+		t.Errorf("readBrowserDaemonState() = %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(statePath, []byte("not json"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readBrowserDaemonState(statePath); err == nil {
+		t.Error("readBrowserDaemonState(corrupt) expected error, got nil")
+	}
+}
+
+func TestBrowserDaemonStateReachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+
+	tests := []struct {
+		name     string
+		endpoint string
+		want     bool
+	}{
+		{"listening ws endpoint", fmt.Sprintf("ws://127.0.0.1:%d", port), true},
+		{"listening http endpoint", fmt.Sprintf("http://127.0.0.1:%d", port), true},
+		{"invalid endpoint", "::not a url", false},
+		{"endpoint without host", "ws://", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &browserDaemonState{Endpoint: tt.endpoint}
+			if got := s.reachable(); got != tt.want {
+				t.Errorf("reachable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	l.Close()
+	s := &browserDaemonState{Endpoint: fmt.Sprintf("ws://127.0.0.1:%d", port)}
+	if s.reachable() {
+		t.Error("reachable() = true after listener closed, want false")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// deadHostErrors maps browser network errors that mean a whole host is unreachable to the reason
+// shown for URLs skipped because of them.
+var deadHostErrors = []struct {
+	code   string
+	reason string
+}{
+	{"net::ERR_NAME_NOT_RESOLVED", "DNS lookup failed"},
+	{"net::ERR_NAME_RESOLUTION_FAILED", "DNS lookup failed"},
+	{"net::ERR_CONNECTION_REFUSED", "connection refused"},
+	{"net::ERR_ADDRESS_UNREACHABLE", "address unreachable"},
+}
+
+// deadHostReason returns why fetchErr shows its host to be unreachable, or "" if the error is
+// specific to the page.
+func deadHostReason(fetchErr error) string {
+	if fetchErr == nil {
+		return ""
+	}
+	msg := fetchErr.Error()
+	for _, e := range deadHostErrors {
+		if strings.Contains(msg, e.code) {
+			return e.reason
+		}
+	}
+	return ""
+}
+
+// markDeadHost remembers that host failed with a host-wide error, so later URLs on it are
+// skipped without a navigation timeout.
+func (c *Crawler) markDeadHost(host string, fetchErr error) {
+	reason := deadHostReason(fetchErr)
+	if reason == "" || host == "" {
+		return
+	}
+	if c.deadHosts == nil {
+		c.deadHosts = make(map[string]string)
+	}
+	if _, ok := c.deadHosts[host]; !ok {
+		logger.Printf("Host %s is unreachable (%s); skipping its remaining URLs for this run.", host, reason)
+		c.deadHosts[host] = reason
+	}
+}
+
+// deadHostSkipReason returns the skip reason for a URL on host if host is known to be dead.
+func (c *Crawler) deadHostSkipReason(host string) (string, bool) {
+	reason, ok := c.deadHosts[host]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("host %s unreachable earlier in this run: %s", host, reason), true
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeadHostReason(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "dns", err: errors.New("playwright page.Goto failed for https://gone.example.com/: net::ERR_NAME_NOT_RESOLVED at https://gone.example.com/"), want: "DNS lookup failed"},
+		{name: "refused", err: errors.New("net::ERR_CONNECTION_REFUSED at http://localhost:1/"), want: "connection refused"},
+		{name: "timeout", err: errors.New("playwright operation for https://example.com/ context deadline exceeded"), want: ""},
+		{name: "empty page", err: errors.New("fetched HTML content from https://example.com/ is empty or whitespace"), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deadHostReason(tt.err); got != tt.want {
+				t.Errorf("deadHostReason() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkDeadHost(t *testing.T) {
+	c := &Crawler{}
+	if _, dead := c.deadHostSkipReason("gone.example.com"); dead {
+		t.Fatal("host reported dead before any failure")
+	}
+	c.markDeadHost("slow.example.com", errors.New("timeout"))
+	if _, dead := c.deadHostSkipReason("slow.example.com"); dead {
+		t.Error("page-specific error marked the host dead")
+	}
+	c.markDeadHost("gone.example.com", errors.New("net::ERR_NAME_NOT_RESOLVED"))
+	reason, dead := c.deadHostSkipReason("gone.example.com")
+	if !dead || !strings.Contains(reason, "DNS lookup failed") {
+		t.Errorf("deadHostSkipReason() = (%q, %v), want a DNS reason", reason, dead)
+	}
+	if _, dead := c.deadHostSkipReason("example.com"); dead {
+		t.Error("unrelated host reported dead")
+	}
+}
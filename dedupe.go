@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/sha256"
+	"strings"
+)
+
+// dedupeHash returns the hash of a page's extracted Markdown for --dedupe-content, and false
+// if the page has no text. Surrounding whitespace does not change the hash.
+func dedupeHash(markdown string) ([sha256.Size]byte, bool) {
+	markdown = strings.TrimSpace(markdown)
+	if markdown == "" {
+		return [sha256.Size]byte{}, false
+	}
+	return sha256.Sum256([]byte(markdown)), true
+}
+
+// contentDuplicate returns the saved page whose content is identical to markdown, or "".
+// It is always "" without --dedupe-content.
+func (c *Crawler) contentDuplicate(markdown string) string {
+	if !c.opts.DedupeContent {
+		return ""
+	}
+	hash, ok := dedupeHash(markdown)
+	if !ok {
+		return ""
+	}
+	return c.savedContent[hash]
+}
+
+// recordContent remembers that pageURL was saved with markdown as its content.
+func (c *Crawler) recordContent(markdown string, pageURL string) {
+	if !c.opts.DedupeContent {
+		return
+	}
+	hash, ok := dedupeHash(markdown)
+	if !ok {
+		return
+	}
+	if c.savedContent == nil {
+		c.savedContent = make(map[[sha256.Size]byte]string)
+	}
+	if _, found := c.savedContent[hash]; !found {
+		c.savedContent[hash] = pageURL
+	}
+}
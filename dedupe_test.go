@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDedupeHash(t *testing.T) {
+	a, ok := dedupeHash("# Title\n\nBody text.\n")
+	if !ok {
+		t.Fatal("dedupeHash() reported no text")
+	}
+	if b, _ := dedupeHash("  # Title\n\nBody text."); a != b {
+		t.Error("surrounding whitespace changed the hash")
+	}
+	if c, _ := dedupeHash("# Title\n\nOther text."); a == c {
+		t.Error("different content has the same hash")
+	}
+	if _, ok := dedupeHash(" \n"); ok {
+		t.Error("blank content was hashed")
+	}
+}
+
+func TestCrawlDedupeContent(t *testing.T) {
+	article := `<html><head><title>Guide</title></head><body><h1>Guide</h1><p>The same guide is mirrored under two paths.</p></body></html>`
+	pages := map[string]string{
+		"index.html":    `<html><head><title>Home</title></head><body><h1>Home</h1><p>Welcome to the mirrored site.</p><a href="/guide">Guide</a> <a href="/v1/guide">Old guide</a> <a href="/other">Other</a></body></html>`,
+		"guide.html":    article,
+		"v1/guide.html": article,
+		"other.html":    `<html><head><title>Other</title></head><body><h1>Other</h1><p>A page with its own text.</p></body></html>`,
+	}
+	_, result, _ := crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{DedupeContent: true}})
+	if result.PagesSaved != 3 {
+		t.Errorf("saved %d pages, want 3", result.PagesSaved)
+	}
+	if len(result.SkippedPages) != 1 || result.SkippedPages[0].URL != "https://example.com/v1/guide" || !strings.Contains(result.SkippedPages[0].Reason, "same content as https://example.com/guide") {
+		t.Errorf("skipped pages = %+v", result.SkippedPages)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+// recordLinkDepth notes that pageURL was first found on fromURL, one link further from the
+// crawl's start URLs, for --depth.
+func (c *Crawler) recordLinkDepth(pageURL, fromURL string) {
+	if c.opts.MaxDepth <= 0 {
+		return
+	}
+	if c.depths == nil {
+		c.depths = make(map[string]int)
+	}
+	c.depths[pageURL] = c.depths[fromURL] + 1
+}
+
+// followsLinksFrom reports whether links on pageURL are within --depth. Start URLs are at
+// depth 0. With --strategy bfs the queue is first in, first out, so a URL's recorded depth is
+// its shortest distance from them; with dfs or best-first, or when --prioritize-match moves
+// matching pages ahead, it is that of the page it was first found on.
+func (c *Crawler) followsLinksFrom(pageURL string) bool {
+	return c.opts.MaxDepth <= 0 || c.depths[pageURL] < c.opts.MaxDepth
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCrawlMaxDepth(t *testing.T) {
+	page := func(title, link string) string {
+		return `<html><head><title>` + title + `</title></head><body><h1>` + title + `</h1><p>The ` + title + ` page of a chain of links.</p><a href="` + link + `">Next</a> <a href="/">Home</a></body></html>`
+	}
+	pages := map[string]string{
+		"index.html": page("Start", "/one"),
+		"one.html":   page("One", "/two"),
+		"two.html":   page("Two", "/three"),
+		"three.html": page("Three", "/four"),
+	}
+	tests := []struct {
+		maxDepth     int
+		saved        int
+		depthLimited int
+	}{
+		{0, 4, 0},
+		{1, 2, 1},
+		{2, 3, 1},
+		{5, 4, 0},
+	}
+	for _, tt := range tests {
+		_, result, _ := crawlFixtures(t, pages, fixtureCrawl{format: "json", opts: CrawlOptions{MaxDepth: tt.maxDepth}})
+		if result.PagesSaved != tt.saved || result.DepthLimitedPages != tt.depthLimited {
+			t.Errorf("--depth %d saved %d pages and stopped at %d, want %d and %d", tt.maxDepth, result.PagesSaved, result.DepthLimitedPages, tt.saved, tt.depthLimited)
+		}
+	}
+}
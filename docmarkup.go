@@ -0,0 +1,454 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// markupDialect describes how a lightweight markup language writes the HTML constructs the
+// converter understands. Org-mode and AsciiDoc are converted from ArticleHTML directly, since
+// going through the Markdown loses tables, code languages and nesting.
+type markupDialect struct {
+	heading   func(level int, text string) string
+	bold      func(string) string
+	italic    func(string) string
+	code      func(string) string
+	link      func(href, text string) string
+	image     func(src, alt string) string
+	codeBlock func(lang, code string) string
+	quote     func(body string) string
+	listItem  func(ordered bool, depth int, text string) string
+	table     func(rows [][]string, header bool) string
+	lineBreak string
+	rule      string
+}
+
+var orgDialect = &markupDialect{
+	heading: func(level int, text string) string { return strings.Repeat("*", level) + " " + text },
+	bold:    func(s string) string { return "*" + s + "*" },
+	italic:  func(s string) string { return "/" + s + "/" },
+	code:    func(s string) string { return "~" + s + "~" },
+	link: func(href, text string) string {
+		if text == "" || text == href {
+			return "[[" + href + "]]"
+		}
+		return "[[" + href + "][" + text + "]]"
+	},
+	image: func(src, alt string) string { return "[[" + src + "]]" },
+	codeBlock: func(lang, code string) string {
+		if lang == "" {
+			return "#+BEGIN_EXAMPLE\n" + code + "\n#+END_EXAMPLE"
+		}
+		return "#+BEGIN_SRC " + lang + "\n" + code + "\n#+END_SRC"
+	},
+	quote: func(body string) string { return "#+BEGIN_QUOTE\n" + body + "\n#+END_QUOTE" },
+	listItem: func(ordered bool, depth int, text string) string {
+		marker := "- "
+		if ordered {
+			marker = "1. "
+		}
+		return strings.Repeat("  ", depth) + marker + text
+	},
+	table: func(rows [][]string, header bool) string {
+		var b strings.Builder
+		for i, row := range rows {
+			b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+			if i == 0 && header && len(rows) > 1 {
+				b.WriteString("|" + strings.Repeat("---+", len(row)-1) + "---|\n")
+			}
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	},
+	lineBreak: "\\\\\n",
+	rule:      "-----",
+}
+
+var asciiDocDialect = &markupDialect{
+	// AsciiDoc has section levels 0 (=) to 5 (======); deeper headings stay at level 5.
+	heading: func(level int, text string) string { return strings.Repeat("=", min(level+1, 6)) + " " + text },
+	bold:    func(s string) string { return "*" + s + "*" },
+	italic:  func(s string) string { return "_" + s + "_" },
+	code:    func(s string) string { return "`" + s + "`" },
+	link: func(href, text string) string {
+		return "link:" + href + "[" + strings.ReplaceAll(text, "]", "\\]") + "]"
+	},
+	image: func(src, alt string) string { return "image:" + src + "[" + strings.ReplaceAll(alt, "]", "\\]") + "]" },
+	codeBlock: func(lang, code string) string {
+		if lang == "" {
+			return "----\n" + code + "\n----"
+		}
+		return "[source," + lang + "]\n----\n" + code + "\n----"
+	},
+	quote: func(body string) string { return "____\n" + body + "\n____" },
+	listItem: func(ordered bool, depth int, text string) string {
+		marker := "*"
+		if ordered {
+			marker = "."
+		}
+		return strings.Repeat(marker, depth+1) + " " + text
+	},
+	table: func(rows [][]string, header bool) string {
+		var b strings.Builder
+		// One cell per line, so the column count must be declared.
+		if header {
+			fmt.Fprintf(&b, "[%%header,cols=\"%d*\"]\n", len(rows[0]))
+		} else {
+			fmt.Fprintf(&b, "[cols=\"%d*\"]\n", len(rows[0]))
+		}
+		b.WriteString("|===\n")
+		for _, row := range rows {
+			for _, cell := range row {
+				b.WriteString("| " + strings.ReplaceAll(cell, "|", "\\|") + "\n")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("|===")
+		return b.String()
+	},
+	lineBreak: " +\n",
+	rule:      "'''",
+}
+
+// markupConverter converts article HTML into a markupDialect. Heading levels are shifted by
+// headingOffset so the page title can be the top-level heading.
+type markupConverter struct {
+	dialect       *markupDialect
+	base          *url.URL
+	headingOffset int
+}
+
+// convertArticleHTML converts articleHTML of the page at pageURL to dialect.
+func convertArticleHTML(dialect *markupDialect, pageURL string, articleHTML string, headingOffset int) (string, error) {
+	root, err := html.Parse(strings.NewReader(articleHTML))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse article HTML: %w", err)
+	}
+	base, _ := url.Parse(pageURL)
+	c := &markupConverter{dialect: dialect, base: base, headingOffset: headingOffset}
+	return strings.TrimSpace(c.blocks(root, 0)), nil
+}
+
+func isBlockElement(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Footer, atom.Aside, atom.Nav,
+		atom.Figure, atom.Figcaption, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Ul, atom.Ol,
+		atom.Pre, atom.Blockquote, atom.Table, atom.Hr, atom.Dl, atom.Dt, atom.Dd, atom.Html, atom.Body, atom.Details, atom.Summary:
+		return true
+	}
+	return false
+}
+
+// blocks renders the children of n as blocks separated by blank lines. Runs of inline content
+// between block elements become paragraphs.
+func (c *markupConverter) blocks(n *html.Node, listDepth int) string {
+	var out []string
+	var para strings.Builder
+	flush := func() {
+		if text := strings.TrimSpace(para.String()); text != "" {
+			out = append(out, text)
+		}
+		para.Reset()
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && isBlockElement(child.DataAtom) {
+			flush()
+			if block := c.block(child, listDepth); block != "" {
+				out = append(out, block)
+			}
+			continue
+		}
+		para.WriteString(c.inline(child))
+	}
+	flush()
+	return strings.Join(out, "\n\n")
+}
+
+func (c *markupConverter) block(n *html.Node, listDepth int) string {
+	d := c.dialect
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1]-'0') + c.headingOffset
+		return d.heading(level, c.inlineText(n))
+	case atom.Ul, atom.Ol:
+		return c.list(n, listDepth)
+	case atom.Pre:
+		return d.codeBlock(codeLanguage(n), strings.TrimRight(nodeText(n), "\n"))
+	case atom.Blockquote:
+		return d.quote(c.blocks(n, listDepth))
+	case atom.Table:
+		rows, header := c.tableRows(n)
+		if len(rows) == 0 {
+			return ""
+		}
+		return d.table(rows, header)
+	case atom.Hr:
+		return d.rule
+	default:
+		return c.blocks(n, listDepth)
+	}
+}
+
+func (c *markupConverter) list(n *html.Node, depth int) string {
+	ordered := n.DataAtom == atom.Ol
+	var items []string
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode || li.DataAtom != atom.Li {
+			continue
+		}
+		var text strings.Builder
+		var nested []string
+		for child := li.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && (child.DataAtom == atom.Ul || child.DataAtom == atom.Ol) {
+				nested = append(nested, c.list(child, depth+1))
+				continue
+			}
+			if child.Type == html.ElementNode && isBlockElement(child.DataAtom) {
+				text.WriteString(" " + collapseSpace(c.blocks(child, depth)) + " ")
+				continue
+			}
+			text.WriteString(c.inline(child))
+		}
+		items = append(items, c.dialect.listItem(ordered, depth, collapseSpace(text.String())))
+		items = append(items, nested...)
+	}
+	return strings.Join(items, "\n")
+}
+
+// tableRows returns the cell texts of a table and whether its first row is a header row.
+func (c *markupConverter) tableRows(table *html.Node) ([][]string, bool) {
+	var rows [][]string
+	header := false
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.Tr:
+				var row []string
+				allHeaders := true
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						row = append(row, c.inlineText(cell))
+						if cell.DataAtom != atom.Th {
+							allHeaders = false
+						}
+					}
+				}
+				if len(row) > 0 {
+					if len(rows) == 0 && allHeaders {
+						header = true
+					}
+					rows = append(rows, row)
+				}
+			case atom.Table:
+				// Nested tables are flattened into their cell by inlineText.
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(table)
+	return rows, header
+}
+
+// inline renders an inline node and its children.
+func (c *markupConverter) inline(n *html.Node) string {
+	d := c.dialect
+	switch n.Type {
+	case html.TextNode:
+		return collapseSpaceKeepEdges(n.Data)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Noscript, atom.Template:
+		return ""
+	case atom.Br:
+		return d.lineBreak
+	case atom.Strong, atom.B:
+		return wrapTrimmed(c.children(n), d.bold)
+	case atom.Em, atom.I:
+		return wrapTrimmed(c.children(n), d.italic)
+	case atom.Code, atom.Kbd, atom.Samp:
+		return wrapTrimmed(collapseSpaceKeepEdges(nodeText(n)), d.code)
+	case atom.A:
+		text := strings.TrimSpace(c.children(n))
+		href := c.resolve(attr(n, "href"))
+		if href == "" {
+			return text
+		}
+		return d.link(href, text)
+	case atom.Img:
+		src := c.resolve(attr(n, "src"))
+		if src == "" {
+			return ""
+		}
+		return d.image(src, attr(n, "alt"))
+	default:
+		if isBlockElement(n.DataAtom) {
+			return " " + collapseSpace(c.blocks(n, 0)) + " "
+		}
+		return c.children(n)
+	}
+}
+
+func (c *markupConverter) children(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		b.WriteString(c.inline(child))
+	}
+	return b.String()
+}
+
+// inlineText renders the inline content of n on one line.
+func (c *markupConverter) inlineText(n *html.Node) string {
+	return collapseSpace(c.children(n))
+}
+
+// resolve makes ref absolute against the page URL; fragments and non-HTTP schemes are kept as is.
+func (c *markupConverter) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || c.base == nil {
+		return ref
+	}
+	u, err := c.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// codeLanguage returns the language of a pre block from a language-* or lang-* class on it or
+// its code element.
+func codeLanguage(pre *html.Node) string {
+	for _, n := range []*html.Node{pre, pre.FirstChild} {
+		if n == nil || n.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(attr(n, "class")) {
+			for _, prefix := range []string{"language-", "lang-"} {
+				if lang, ok := strings.CutPrefix(class, prefix); ok && lang != "" {
+					return lang
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// nodeText returns the text of n and its descendants verbatim.
+func nodeText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// collapseSpaceKeepEdges collapses runs of whitespace to one space, keeping a leading or
+// trailing space so adjacent inline elements stay separated.
+func collapseSpaceKeepEdges(s string) string {
+	if strings.TrimSpace(s) == "" {
+		if s == "" {
+			return ""
+		}
+		return " "
+	}
+	out := collapseSpace(s)
+	if first := s[0]; first == ' ' || first == '\n' || first == '\t' || first == '\r' {
+		out = " " + out
+	}
+	if last := s[len(s)-1]; last == ' ' || last == '\n' || last == '\t' || last == '\r' {
+		out += " "
+	}
+	return out
+}
+
+// wrapTrimmed applies wrap to the trimmed s and keeps its surrounding spaces outside the markup,
+// which both dialects require for emphasis to be recognized.
+func wrapTrimmed(s string, wrap func(string) string) string {
+	trimmed := strings.TrimSpace(s)
+	if trimmed == "" {
+		return s
+	}
+	lead := s[:len(s)-len(strings.TrimLeft(s, " "))]
+	trail := s[len(strings.TrimRight(s, " ")):]
+	return lead + wrap(trimmed) + trail
+}
+
+// formatPageDataAsOrg renders a page as an Org-mode top-level heading with its URL as a property.
+func formatPageDataAsOrg(pd *PageData) string {
+	var b strings.Builder
+	b.WriteString(orgDialect.heading(1, pageTitleOrURL(pd)) + "\n")
+	b.WriteString(":PROPERTIES:\n:URL: " + pd.URL + "\n")
+	if pd.Section != "" {
+		b.WriteString(":SECTION: " + pd.Section + "\n")
+	}
+	if published := formatPublishedDate(pd.Published); published != "" {
+		b.WriteString(":PUBLISHED: " + published + "\n")
+	}
+	b.WriteString(":END:\n\n")
+	b.WriteString(pageBodyAs(orgDialect, pd))
+	return b.String()
+}
+
+// formatPageDataAsAsciiDoc renders a page as an AsciiDoc level-1 section with a source link.
+func formatPageDataAsAsciiDoc(pd *PageData) string {
+	var b strings.Builder
+	b.WriteString(asciiDocDialect.heading(1, pageTitleOrURL(pd)) + "\n\n")
+	b.WriteString("Source: " + asciiDocDialect.link(pd.URL, pd.URL) + "\n")
+	if published := formatPublishedDate(pd.Published); published != "" {
+		b.WriteString("Published: " + published + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(pageBodyAs(asciiDocDialect, pd))
+	return b.String()
+}
+
+func pageTitleOrURL(pd *PageData) string {
+	if strings.TrimSpace(pd.Title) != "" {
+		return collapseSpace(pd.Title)
+	}
+	return pd.URL
+}
+
+// pageBodyAs converts the page's ArticleHTML, below the page heading, falling back to its
+// Markdown as a literal block when there is no article HTML (e.g. dropped with --drop-fields).
+func pageBodyAs(dialect *markupDialect, pd *PageData) string {
+	if strings.TrimSpace(pd.ArticleHTML) != "" {
+		if body, err := convertArticleHTML(dialect, pd.URL, pd.ArticleHTML, 1); err == nil {
+			return body + "\n"
+		}
+	}
+	if strings.TrimSpace(pd.Markdown) == "" {
+		return ""
+	}
+	return dialect.codeBlock("", strings.TrimSpace(pd.Markdown)) + "\n"
+}
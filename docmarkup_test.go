@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const docMarkupTestHTML = `<div>
+<h2>Install</h2>
+<p>Run <code>make</code> with <strong>care</strong>, see <a href="/docs/faq">the FAQ</a>.<br>Next line.</p>
+<ul><li>one<ul><li>nested</li></ul></li><li>two</li></ul>
+<ol><li>first</li></ol>
+<pre><code class="language-go">fmt.Println("hi")
+</code></pre>
+<blockquote><p>Quoted <em>text</em></p></blockquote>
+<table><tr><th>Name</th><th>Value</th></tr><tr><td>a</td><td>1</td></tr></table>
+<img src="img/logo.png" alt="Logo">
+<script>ignored()</script>
+</div>`
+
+func TestConvertArticleHTMLToOrg(t *testing.T) {
+	got, err := convertArticleHTML(orgDialect, "https://example.com/guide/", docMarkupTestHTML, 1)
+	if err != nil {
+		t.Fatalf("convertArticleHTML() error = %v", err)
+	}
+	for _, want := range []string{
+		"*** Install",
+		"Run ~make~ with *care*, see [[https://example.com/docs/faq][the FAQ]].\\\\\nNext line.",
+		"- one\n  - nested\n- two",
+		"1. first",
+		"#+BEGIN_SRC go\nfmt.Println(\"hi\")\n#+END_SRC",
+		"#+BEGIN_QUOTE\nQuoted /text/\n#+END_QUOTE",
+		"| Name | Value |\n|---+---|\n| a | 1 |",
+		"[[https://example.com/guide/img/logo.png]]",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Org output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ignored") {
+		t.Errorf("Org output contains script content:\n%s", got)
+	}
+}
+
+func TestConvertArticleHTMLToAsciiDoc(t *testing.T) {
+	got, err := convertArticleHTML(asciiDocDialect, "https://example.com/guide/", docMarkupTestHTML, 1)
+	if err != nil {
+		t.Fatalf("convertArticleHTML() error = %v", err)
+	}
+	for _, want := range []string{
+		"==== Install",
+		"Run `make` with *care*, see link:https://example.com/docs/faq[the FAQ]. +\nNext line.",
+		"* one\n** nested\n* two",
+		". first",
+		"[source,go]\n----\nfmt.Println(\"hi\")\n----",
+		"____\nQuoted _text_\n____",
+		"[%header,cols=\"2*\"]\n|===\n| Name\n| Value\n\n| a\n| 1\n\n|===",
+		"image:https://example.com/guide/img/logo.png[Logo]",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("AsciiDoc output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestFormatPageDataAsOrgAndAsciiDoc(t *testing.T) {
+	pd := &PageData{Title: "Guide", URL: "https://example.com/guide", Section: "Docs", ArticleHTML: "<h1>Intro</h1><p>Hello</p>"}
+	org := formatPageDataAsOrg(pd)
+	if want := "* Guide\n:PROPERTIES:\n:URL: https://example.com/guide\n:SECTION: Docs\n:END:\n\n** Intro\n\nHello\n"; org != want {
+		t.Errorf("formatPageDataAsOrg() =\n%q\nwant\n%q", org, want)
+	}
+	adoc := formatPageDataAsAsciiDoc(pd)
+	if want := "== Guide\n\nSource: link:https://example.com/guide[https://example.com/guide]\n\n=== Intro\n\nHello\n"; adoc != want {
+		t.Errorf("formatPageDataAsAsciiDoc() =\n%q\nwant\n%q", adoc, want)
+	}
+
+	noHTML := &PageData{Title: "Plain", URL: "https://example.com/plain", Markdown: "# Plain\n\ntext"}
+	if got := formatPageDataAsOrg(noHTML); !strings.Contains(got, "#+BEGIN_EXAMPLE\n# Plain\n\ntext\n#+END_EXAMPLE") {
+		t.Errorf("page without article HTML should fall back to its Markdown:\n%s", got)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// domainRoot is one site of a --domains-file crawl.
+type domainRoot struct {
+	// URL is the normalized root URL the site's crawl starts from.
+	URL string
+	// Host is the site's host name; only links to listed hosts are followed.
+	Host string
+	// Limit caps the pages saved for this site; 0 uses --limit.
+	Limit int
+}
+
+// parseDomainsFile parses --domains-file content: one root URL per line, optionally followed by
+// "limit=N", e.g. "https://docs.example.com/ limit=200". Comments work as in --url-file.
+func parseDomainsFile(content string) ([]domainRoot, error) {
+	var roots []domainRoot
+	seenHosts := make(map[string]int)
+	for i, line := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		fields := strings.Fields(stripURLFileComment(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		normalized, err := normalizeURLtoString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid URL %q: %w", lineNum, fields[0], err)
+		}
+		parsed, err := url.Parse(normalized)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+			return nil, fmt.Errorf("line %d: %q is not an http(s) URL", lineNum, fields[0])
+		}
+		if prev, ok := seenHosts[parsed.Hostname()]; ok {
+			return nil, fmt.Errorf("line %d: host %s is already listed on line %d", lineNum, parsed.Hostname(), prev)
+		}
+		seenHosts[parsed.Hostname()] = lineNum
+
+		root := domainRoot{URL: normalized, Host: parsed.Hostname()}
+		for _, field := range fields[1:] {
+			key, value, found := strings.Cut(field, "=")
+			if !found || value == "" {
+				return nil, fmt.Errorf("line %d: invalid option %q (expected key=value)", lineNum, field)
+			}
+			switch key {
+			case "limit":
+				limit, err := strconv.Atoi(value)
+				if err != nil || limit < 0 {
+					return nil, fmt.Errorf("line %d: invalid limit %q", lineNum, value)
+				}
+				root.Limit = limit
+			default:
+				return nil, fmt.Errorf("line %d: unknown option %q (supported: limit)", lineNum, key)
+			}
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// isCrawlHost reports whether links to host may be followed: the start URL's host, or with
+// --domains-file any listed host.
+func (c *Crawler) isCrawlHost(host string) bool {
+	if len(c.opts.Domains) == 0 {
+		return host == c.startURL.Hostname()
+	}
+	_, ok := c.domainFor(host)
+	return ok
+}
+
+// domainFor returns the --domains-file entry for host.
+func (c *Crawler) domainFor(host string) (domainRoot, bool) {
+	for _, root := range c.opts.Domains {
+		if root.Host == host {
+			return root, true
+		}
+	}
+	return domainRoot{}, false
+}
+
+// domainLimitReached reports whether the site of host has saved as many pages as it may.
+func (c *Crawler) domainLimitReached(host string) bool {
+	root, ok := c.domainFor(host)
+	if !ok {
+		return false
+	}
+	limit := root.Limit
+	if limit == 0 {
+		limit = c.pageLimit
+	}
+	return limit > 0 && c.domainSaved[host] >= limit
+}
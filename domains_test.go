@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDomainsFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []domainRoot
+		wantErr bool
+	}{
+		{
+			name:    "roots with comments and limits",
+			content: "# sites\nhttps://docs.example.com/guide limit=20\n\nhttps://blog.example.org  # default limit\n",
+			want: []domainRoot{
+				{URL: "https://docs.example.com/guide", Host: "docs.example.com", Limit: 20},
+				{URL: "https://blog.example.org/", Host: "blog.example.org"},
+			},
+		},
+		{name: "duplicate host", content: "https://example.com/a\nhttps://example.com/b\n", wantErr: true},
+		{name: "not http", content: "ftp://example.com/\n", wantErr: true},
+		{name: "bad limit", content: "https://example.com/ limit=-1\n", wantErr: true},
+		{name: "unknown option", content: "https://example.com/ depth=2\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseDomainsFile(tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDomainsFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseDomainsFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainsModeHostsAndLimits(t *testing.T) {
+	startURL, _ := url.Parse("https://a.example.com/")
+	c := &Crawler{
+		startURL:  startURL,
+		pageLimit: 2,
+		opts: CrawlOptions{Domains: []domainRoot{
+			{URL: "https://a.example.com/", Host: "a.example.com"},
+			{URL: "https://b.example.com/", Host: "b.example.com", Limit: 1},
+		}},
+		domainSaved: map[string]int{"a.example.com": 1, "b.example.com": 1},
+	}
+
+	links := c.extractAndFilterLinks(startURL, `<a href="/x">x</a><a href="https://b.example.com/y">y</a><a href="https://c.example.com/z">z</a>`)
+	if want := []string{"https://a.example.com/x", "https://b.example.com/y"}; !reflect.DeepEqual(links, want) {
+		t.Errorf("extractAndFilterLinks() = %v, want %v", links, want)
+	}
+	if c.domainLimitReached("a.example.com") {
+		t.Errorf("a.example.com has saved 1 of --limit 2, want limit not reached")
+	}
+	if !c.domainLimitReached("b.example.com") {
+		t.Errorf("b.example.com has saved 1 of limit=1, want limit reached")
+	}
+	if c.domainLimitReached("c.example.com") {
+		t.Errorf("unlisted host reported as limited")
+	}
+}
+
+func TestSiteInOutputs(t *testing.T) {
+	pd := PageData{Title: "T", URL: "https://a.example.com/", Markdown: "x", Site: "a.example.com"}
+	if got := formatPageDataAsXML(&pd); !strings.Contains(got, "  <site>a.example.com</site>\n") {
+		t.Errorf("formatPageDataAsXML() = %q, want a <site> element", got)
+	}
+	if got := formatPageDataAsMarkdownFile(&pd); !strings.Contains(got, "\nsite: a.example.com\n") {
+		t.Errorf("formatPageDataAsMarkdownFile() = %q, want site front matter", got)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// domRule is one --dom-rule transformation, e.g. "unwrap:.wrapper".
+type domRule struct {
+	Action   string
+	Selector string
+	// Arg is the tag name for "rename" and the attribute name for "remove-attr".
+	Arg string
+}
+
+// domRuleActions lists the supported actions and whether they take an argument after the selector.
+var domRuleActions = map[string]bool{
+	"remove":      false, // delete matching elements
+	"unwrap":      false, // replace matching elements with their children
+	"keep":        false, // delete everything in <body> except matching elements
+	"rename":      true,  // change the tag name: rename:<selector>=<tag>
+	"remove-attr": true,  // delete an attribute: remove-attr:<selector>=<attribute>
+}
+
+// parseDOMRules parses --dom-rule values of the form action:selector or action:selector=arg.
+func parseDOMRules(values []string) ([]domRule, error) {
+	var rules []domRule
+	for _, value := range values {
+		action, rest, ok := strings.Cut(value, ":")
+		action = strings.ToLower(strings.TrimSpace(action))
+		takesArg, known := domRuleActions[action]
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid DOM rule %q (expected remove, unwrap, keep, rename or remove-attr followed by :<selector>)", value)
+		}
+		rule := domRule{Action: action, Selector: strings.TrimSpace(rest)}
+		if takesArg {
+			eq := strings.LastIndex(rest, "=")
+			if eq < 0 || strings.TrimSpace(rest[eq+1:]) == "" || strings.ContainsAny(rest[eq+1:], "]\"'") {
+				return nil, fmt.Errorf("invalid DOM rule %q (%s needs :<selector>=<name>)", value, action)
+			}
+			rule.Selector = strings.TrimSpace(rest[:eq])
+			rule.Arg = strings.TrimSpace(rest[eq+1:])
+		}
+		if rule.Selector == "" {
+			return nil, fmt.Errorf("invalid DOM rule %q (empty selector)", value)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// applyDOMRules applies rules in order to rawHTML and returns the transformed HTML.
+// If rules is empty or the HTML cannot be parsed, rawHTML is returned unchanged.
+func applyDOMRules(rawHTML string, rules []domRule) string {
+	if len(rules) == 0 {
+		return rawHTML
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		return rawHTML
+	}
+	for _, rule := range rules {
+		matched := doc.Find(rule.Selector)
+		switch rule.Action {
+		case "remove":
+			matched.Remove()
+		case "unwrap":
+			matched.Each(func(_ int, s *goquery.Selection) {
+				if s.Contents().Length() == 0 {
+					s.Remove()
+					return
+				}
+				s.Contents().Unwrap()
+			})
+		case "keep":
+			if matched.Length() == 0 {
+				continue
+			}
+			body := doc.Find("body")
+			kept := matched.FilterFunction(func(_ int, s *goquery.Selection) bool {
+				return s.ParentsFiltered("*").FilterSelection(matched).Length() == 0
+			}).Remove()
+			body.Empty()
+			body.AppendSelection(kept)
+		case "rename":
+			matched.Each(func(_ int, s *goquery.Selection) {
+				s.Nodes[0].Data = rule.Arg
+				s.Nodes[0].DataAtom = 0
+			})
+		case "remove-attr":
+			matched.RemoveAttr(rule.Arg)
+		}
+	}
+	html, err := goquery.OuterHtml(doc.Selection)
+	if err != nil {
+		return rawHTML
+	}
+	return html
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDOMRules(t *testing.T) {
+	rules, err := parseDOMRules([]string{"unwrap:.wrapper", "remove:[aria-hidden=true]", "rename:div.title=h2", "remove-attr:a[target=_blank]=target"})
+	if err != nil {
+		t.Fatalf("parseDOMRules() error = %v", err)
+	}
+	want := []domRule{
+		{Action: "unwrap", Selector: ".wrapper"},
+		{Action: "remove", Selector: "[aria-hidden=true]"},
+		{Action: "rename", Selector: "div.title", Arg: "h2"},
+		{Action: "remove-attr", Selector: "a[target=_blank]", Arg: "target"},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("parseDOMRules() = %+v, want %+v", rules, want)
+	}
+
+	for _, bad := range []string{"drop:.x", "remove", "remove:", "rename:div.title", "remove-attr:[data-x=1]"} {
+		if _, err := parseDOMRules([]string{bad}); err == nil {
+			t.Errorf("parseDOMRules(%q) expected error", bad)
+		}
+	}
+}
+
+func TestApplyDOMRules(t *testing.T) {
+	html := `<html><head></head><body><nav>Menu</nav><div class="wrapper"><div class="wrapper"><p>Text</p></div></div>
+<span aria-hidden="true">icon</span><div class="title">Heading</div><div class="empty wrapper"></div>
+<article class="main"><p>Body</p><article class="main">Inner</article></article><a href="/x" target="_blank">x</a></body></html>`
+
+	tests := []struct {
+		name    string
+		rules   []string
+		want    []string
+		notWant []string
+	}{
+		{name: "no rules", rules: nil, want: []string{`<nav>Menu</nav>`}},
+		{name: "unwrap", rules: []string{"unwrap:.wrapper"}, want: []string{"<p>Text</p>"}, notWant: []string{"wrapper"}},
+		{name: "remove", rules: []string{"remove:[aria-hidden=true]"}, notWant: []string{"icon"}},
+		{name: "rename", rules: []string{"rename:div.title=h2"}, want: []string{`<h2 class="title">Heading</h2>`}},
+		{name: "remove-attr", rules: []string{"remove-attr:a=target"}, want: []string{`<a href="/x">x</a>`}},
+		{name: "keep", rules: []string{"keep:article.main"}, want: []string{"<body><article", "Inner"}, notWant: []string{"Menu", "Heading"}},
+		{name: "keep without match", rules: []string{"keep:.missing"}, want: []string{"Menu"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseDOMRules(tt.rules)
+			if err != nil {
+				t.Fatalf("parseDOMRules() error = %v", err)
+			}
+			got := applyDOMRules(html, rules)
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("applyDOMRules() = %s, want it to contain %q", got, s)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(got, s) {
+					t.Errorf("applyDOMRules() = %s, want it not to contain %q", got, s)
+				}
+			}
+		})
+	}
+}
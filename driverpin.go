@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// driverRecordFile is written into the Playwright driver directory by 'sitepanda init chromium'.
+const driverRecordFile = "sitepanda-driver.json"
+
+// driverRecord describes the Playwright driver installed into the driver directory.
+type driverRecord struct {
+	PlaywrightVersion string    `json:"playwright_version"`
+	Minimal           bool      `json:"minimal,omitempty"`
+	InstalledAt       time.Time `json:"installed_at"`
+}
+
+// installPlaywright is a variable to allow mocking playwright.Install in tests.
+var installPlaywright = playwright.Install
+
+// embeddedDriverVersion returns the Playwright driver version this build of playwright-go expects.
+func embeddedDriverVersion(driverDir string) (string, error) {
+	driver, err := playwright.NewDriver(&playwright.RunOptions{DriverDirectory: driverDir})
+	if err != nil {
+		return "", fmt.Errorf("could not determine the embedded Playwright driver version: %w", err)
+	}
+	return driver.Version, nil
+}
+
+// readDriverRecord returns the record of the installed driver, or nil if there is none.
+func readDriverRecord(driverDir string) (*driverRecord, error) {
+	data, err := os.ReadFile(filepath.Join(driverDir, driverRecordFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record driverRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", driverRecordFile, err)
+	}
+	return &record, nil
+}
+
+// writeDriverRecord records the installed driver version in driverDir.
+func writeDriverRecord(driverDir string, record driverRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(driverDir, driverRecordFile), append(data, '\n'), 0644)
+}
+
+// driverNeedsReinstall reports whether the driver in driverDir must be reinstalled for the
+// embedded version. A driver without a record predates version pinning and is reinstalled too,
+// which is cheap when it is already current; an empty directory is left to 'sitepanda init'.
+func driverNeedsReinstall(driverDir string, record *driverRecord, embedded string) bool {
+	if record != nil {
+		return record.PlaywrightVersion != embedded
+	}
+	_, err := os.Stat(filepath.Join(driverDir, "package"))
+	return err == nil
+}
+
+// ensureDriverVersion reinstalls the Playwright driver and Chromium into driverDir when the
+// driver recorded there does not match the version embedded in this build, typically after a
+// Sitepanda upgrade, instead of letting Playwright fail to start.
+func ensureDriverVersion(driverDir string) error {
+	embedded, err := embeddedDriverVersion(driverDir)
+	if err != nil {
+		return err
+	}
+	record, err := readDriverRecord(driverDir)
+	if err != nil {
+		logger.Printf("Warning: ignoring unreadable Playwright driver record: %v", err)
+	}
+	if !driverNeedsReinstall(driverDir, record, embedded) {
+		return nil
+	}
+
+	minimal := false
+	if record != nil {
+		minimal = record.Minimal
+		logger.Printf("Playwright driver in %s is v%s but this Sitepanda needs v%s; reinstalling...", driverDir, record.PlaywrightVersion, embedded)
+	} else {
+		logger.Printf("Playwright driver in %s has no version record; reinstalling v%s to make sure it is compatible...", driverDir, embedded)
+	}
+	if err := installPlaywright(&playwright.RunOptions{
+		Browsers:         []string{"chromium"},
+		DriverDirectory:  driverDir,
+		OnlyInstallShell: minimal,
+		Verbose:          true,
+		Stdout:           logger.Writer(),
+		Stderr:           logger.Writer(),
+	}); err != nil {
+		return fmt.Errorf("failed to reinstall Playwright driver v%s (run 'sitepanda init chromium' to retry): %w", embedded, err)
+	}
+	if err := writeDriverRecord(driverDir, driverRecord{PlaywrightVersion: embedded, Minimal: minimal, InstalledAt: time.Now().UTC()}); err != nil {
+		logger.Printf("Warning: could not record Playwright driver version: %v", err)
+	}
+	logger.Printf("Playwright driver v%s installed.", embedded)
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestDriverNeedsReinstall(t *testing.T) {
+	emptyDir := t.TempDir()
+	legacyDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(legacyDir, "package"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		record *driverRecord
+		want   bool
+	}{
+		{"matching record", legacyDir, &driverRecord{PlaywrightVersion: "1.52.0"}, false},
+		{"outdated record", legacyDir, &driverRecord{PlaywrightVersion: "1.49.1"}, true},
+		{"legacy install without record", legacyDir, nil, true},
+		{"nothing installed", emptyDir, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := driverNeedsReinstall(tt.dir, tt.record, "1.52.0"); got != tt.want {
+				t.Errorf("driverNeedsReinstall() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureDriverVersion(t *testing.T) {
+	originalInstall := installPlaywright
+	defer func() { installPlaywright = originalInstall }()
+
+	driverDir := t.TempDir()
+	embedded, err := embeddedDriverVersion(driverDir)
+	if err != nil {
+		t.Fatalf("embeddedDriverVersion() error = %v", err)
+	}
+	if err := writeDriverRecord(driverDir, driverRecord{PlaywrightVersion: "0.0.1", Minimal: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var installs []*playwright.RunOptions
+	installPlaywright = func(options ...*playwright.RunOptions) error {
+		installs = append(installs, options...)
+		return nil
+	}
+	if err := ensureDriverVersion(driverDir); err != nil {
+		t.Fatalf("ensureDriverVersion() error = %v", err)
+	}
+	if len(installs) != 1 || installs[0].DriverDirectory != driverDir || !installs[0].OnlyInstallShell {
+		t.Fatalf("expected one minimal reinstall into %s, got %+v", driverDir, installs)
+	}
+	record, err := readDriverRecord(driverDir)
+	if err != nil || record == nil || record.PlaywrightVersion != embedded || !record.Minimal {
+		t.Fatalf("record after reinstall = %+v, %v; want version %s, minimal", record, err, embedded)
+	}
+
+	// Now current: no further install.
+	if err := ensureDriverVersion(driverDir); err != nil {
+		t.Fatalf("second ensureDriverVersion() error = %v", err)
+	}
+	if len(installs) != 1 {
+		t.Errorf("expected no reinstall of a current driver, got %d installs", len(installs))
+	}
+
+	// A failed reinstall is reported.
+	if err := writeDriverRecord(driverDir, driverRecord{PlaywrightVersion: "0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	installPlaywright = func(options ...*playwright.RunOptions) error { return errors.New("offline") }
+	if err := ensureDriverVersion(driverDir); err == nil {
+		t.Error("ensureDriverVersion() with failing install: expected error")
+	}
+}
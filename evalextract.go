@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// maxEvalExtractBytes caps the JSON stored for one --eval-extract expression.
+const maxEvalExtractBytes = 1 << 20
+
+// evalExtractScript wraps an --eval-extract expression so the page serializes its value with
+// JSON.stringify; undefined becomes null.
+func evalExtractScript(expression string) string {
+	return fmt.Sprintf("(() => { const value = (%s); return value === undefined ? 'null' : JSON.stringify(value); })()", expression)
+}
+
+// evalExtract evaluates each expression in page and returns the JSON results keyed by expression.
+// Expressions that throw, e.g. because a global is missing on this page, are left out and
+// reported in the returned error.
+func evalExtract(page playwright.Page, expressions []string) (map[string]json.RawMessage, error) {
+	results := make(map[string]json.RawMessage, len(expressions))
+	var failures []error
+	for _, expression := range expressions {
+		value, err := page.Evaluate(evalExtractScript(expression))
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", expression, err))
+			continue
+		}
+		raw, ok := value.(string)
+		if !ok || !json.Valid([]byte(raw)) {
+			failures = append(failures, fmt.Errorf("%s: value is not JSON-serializable", expression))
+			continue
+		}
+		if len(raw) > maxEvalExtractBytes {
+			failures = append(failures, fmt.Errorf("%s: value is %s, larger than %s", expression, formatByteSize(int64(len(raw))), formatByteSize(maxEvalExtractBytes)))
+			continue
+		}
+		results[expression] = json.RawMessage(raw)
+	}
+	if len(results) == 0 {
+		results = nil
+	}
+	if len(failures) > 0 {
+		return results, fmt.Errorf("--eval-extract failed for %d of %d expressions: %v", len(failures), len(expressions), failures)
+	}
+	return results, nil
+}
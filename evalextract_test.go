@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEvalExtractScript(t *testing.T) {
+	got := evalExtractScript("window.__NUXT__.data")
+	if !strings.Contains(got, "const value = (window.__NUXT__.data);") || !strings.Contains(got, "JSON.stringify(value)") {
+		t.Errorf("evalExtractScript() = %q", got)
+	}
+}
+
+func TestExtractedInOutputs(t *testing.T) {
+	pd := PageData{
+		Title:     "Product",
+		URL:       "https://example.com/p/1",
+		Markdown:  "Body",
+		Extracted: map[string]json.RawMessage{"window.__DATA__": json.RawMessage(`{"sku":"A1","price":9.5}`)},
+	}
+
+	jsonOut, err := formatResultsAsJSON([]PageData{pd})
+	if err != nil {
+		t.Fatalf("formatResultsAsJSON() error: %v", err)
+	}
+	var pages []map[string]any
+	if err := json.Unmarshal(jsonOut, &pages); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	extracted, ok := pages[0]["extracted"].(map[string]any)
+	if !ok || extracted["window.__DATA__"].(map[string]any)["sku"] != "A1" {
+		t.Errorf("JSON extracted = %v", pages[0]["extracted"])
+	}
+
+	if got := formatPageDataAsXML(&pd); !strings.Contains(got, `<extracted>{"window.__DATA__":{"sku":"A1","price":9.5}}</extracted>`) {
+		t.Errorf("XML output missing extracted data:\n%s", got)
+	}
+	if got := formatPageDataAsMarkdownFile(&pd); !strings.Contains(got, `extracted: {"window.__DATA__":{"sku":"A1","price":9.5}}`) {
+		t.Errorf("front matter missing extracted data:\n%s", got)
+	}
+
+	applyOutputFieldPolicy(&pd, []string{"extracted"}, 0)
+	if pd.Extracted != nil {
+		t.Error("--drop-fields extracted did not clear the field")
+	}
+}
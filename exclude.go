@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// compileExcludePatterns compiles the globs of an --exclude-match or --exclude-follow flag.
+func compileExcludePatterns(flag string, patterns []string) ([]glob.Glob, error) {
+	var compiled []glob.Glob
+	for _, p := range patterns {
+		g, err := glob.Compile(p, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern '%s': %w", flag, p, err)
+		}
+		compiled = append(compiled, g)
+	}
+	return compiled, nil
+}
+
+// pathMatchesAny reports whether one of patterns matches u's path. Normalization drops
+// trailing slashes, so the path is also tried with one to let "/login/**" cover /login.
+func pathMatchesAny(patterns []glob.Glob, u *url.URL) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	pathToMatch := u.Path
+	if pathToMatch == "" {
+		pathToMatch = "/"
+	} else if !strings.HasPrefix(pathToMatch, "/") {
+		pathToMatch = "/" + pathToMatch
+	}
+	for _, g := range patterns {
+		if g.Match(pathToMatch) || g.Match(pathToMatch+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// excludesContent reports whether --exclude-match keeps pageURL's content from being saved.
+func (c *Crawler) excludesContent(pageURL *url.URL) bool {
+	return pathMatchesAny(c.opts.ExcludeMatch, pageURL)
+}
+
+// excludesFollow reports whether --exclude-follow keeps a link to linkURL out of the queue.
+func (c *Crawler) excludesFollow(linkURL *url.URL) bool {
+	return pathMatchesAny(c.opts.ExcludeFollow, linkURL)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCompileExcludePatterns(t *testing.T) {
+	if _, err := compileExcludePatterns("--exclude-match", []string{"/tag/**", "/print/*"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := compileExcludePatterns("--exclude-follow", []string{"/a/[b"})
+	if err == nil || !strings.Contains(err.Error(), "--exclude-follow") {
+		t.Errorf("error = %v, want an invalid --exclude-follow pattern", err)
+	}
+}
+
+func TestCrawlExcludePatterns(t *testing.T) {
+	page := func(title, links string) string {
+		return `<html><head><title>` + title + `</title></head><body><h1>` + title + `</h1><p>Content of the ` + title + ` page.</p>` + links + `</body></html>`
+	}
+	pages := map[string]string{
+		"index.html":            page("Home", `<a href="/docs/intro">Intro</a> <a href="/tag/go">Tag</a> <a href="/login/">Login</a>`),
+		"docs/intro.html":       page("Intro", `<a href="/docs/print/intro">Print</a>`),
+		"docs/print/intro.html": page("Printable", ""),
+		"tag/go.html":           page("Tagged", `<a href="/docs/hidden">Hidden</a>`),
+		"docs/hidden.html":      page("Hidden", ""),
+		"login/index.html":      page("Login", ""),
+	}
+	excludeMatch, err := compileExcludePatterns("--exclude-match", []string{"/tag/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excludeFollow, err := compileExcludePatterns("--exclude-follow", []string{"/login/**", "/docs/print/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler, _, outfile := crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{ExcludeMatch: excludeMatch, ExcludeFollow: excludeFollow}})
+	out, err := os.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// /tag/go is crawled for links but not saved; /login/ and /docs/print/ are never queued.
+	for title, want := range map[string]bool{"Home": true, "Intro": true, "Hidden": true, "Tagged": false, "Login": false, "Printable": false} {
+		if got := strings.Contains(string(out), "Content of the "+title+" page"); got != want {
+			t.Errorf("output contains %s page = %t, want %t", title, got, want)
+		}
+	}
+	if crawler.visited["https://example.com/login/"] || crawler.visited["https://example.com/docs/print/intro"] {
+		t.Error("an --exclude-follow link was queued")
+	}
+}
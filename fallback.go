@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// fallbackBrowser is the second engine of --fallback-browser. Chromium and Lightpanda fail on
+// different kinds of sites, so pages the primary browser cannot fetch are retried with it.
+type fallbackBrowser struct {
+	name    string
+	context playwright.BrowserContext
+	page    playwright.Page
+}
+
+// validateFallbackBrowser checks a --fallback-browser value against the primary browser.
+func validateFallbackBrowser(primary, fallback string) error {
+	if fallback != "lightpanda" && fallback != "chromium" {
+		return fmt.Errorf("invalid --fallback-browser %q: must be 'lightpanda' or 'chromium'", fallback)
+	}
+	if fallback == primary {
+		return fmt.Errorf("--fallback-browser must differ from --browser (both are %s)", primary)
+	}
+	return nil
+}
+
+// launchFallbackBrowser starts a fresh instance of browserName for --fallback-browser and returns
+// a connected browser plus a function that shuts it down again.
+func launchFallbackBrowser(browserName string, playwrightDriverDir string, verboseBrowser bool) (playwright.Browser, func(), error) {
+	executablePath, cleanup, err := prepareBrowser(browserName, playwrightDriverDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	lightpandaCmd, wsURL, pwInstance, pwBrowser, _, _, err := launchBrowserAndGetConnection(browserName, executablePath, playwrightDriverDir, verboseBrowser, nil)
+	shutdown := func() {
+		shutdownBrowser(browserName, pwBrowser, pwInstance, lightpandaCmd)
+		cleanup()
+	}
+	if err != nil {
+		shutdown()
+		return nil, nil, err
+	}
+	if pwBrowser == nil {
+		// Lightpanda is started as a CDP server; connect to it like NewCrawlerForLightpanda does.
+		pwBrowser, err = pwInstance.Chromium.ConnectOverCDP(wsURL, playwright.BrowserTypeConnectOverCDPOptions{
+			Timeout: playwright.Float(30000),
+		})
+		if err != nil {
+			shutdown()
+			return nil, nil, fmt.Errorf("playwright could not connect to fallback browser over CDP at %s: %w", wsURL, err)
+		}
+	}
+	return pwBrowser, shutdown, nil
+}
+
+// attachFallbackBrowser opens the page fetches are retried on in pwB, with the same request
+// rewriting as the primary page.
+func (c *Crawler) attachFallbackBrowser(name string, pwB playwright.Browser) error {
+	browserCtx, err := pwB.NewContext(browserContextOptions(c.opts))
+	if err != nil {
+		return fmt.Errorf("failed to create %s fallback browser context: %w", name, err)
+	}
+	if err := addContextInitScripts(browserCtx, c.opts, false); err != nil {
+		_ = browserCtx.Close()
+		return fmt.Errorf("%s fallback browser: %w", name, err)
+	}
+	if handler := requestHeaderRoute(c.rootCtx, c.opts); handler != nil {
+		if err := browserCtx.Route("**/*", handler); err != nil {
+			_ = browserCtx.Close()
+			return fmt.Errorf("failed to install request header route in %s fallback browser: %w", name, err)
+		}
+	}
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		_ = browserCtx.Close()
+		return fmt.Errorf("failed to create page in %s fallback browser: %w", name, err)
+	}
+	c.opts.Bytes.watch(page)
+	c.fallback = &fallbackBrowser{name: name, context: browserCtx, page: page}
+	logger.Printf("Fallback browser %s ready.", name)
+	return nil
+}
+
+// fetchWithFallback retries a page the primary browser failed on with the fallback browser. It
+// returns the page that holds the fetched document, or nil if there is no fallback or it failed too.
+func (c *Crawler) fetchWithFallback(pageURL string, waitForNetworkIdle bool, primaryErr error) (playwright.Page, string, pageResponse) {
+	if c.fallback == nil || c.rootCtx.Err() != nil {
+		return nil, "", pageResponse{}
+	}
+	logger.Printf("Retrying %s with fallback browser %s after: %v", pageURL, c.fallback.name, primaryErr)
+	htmlContent, response, err := fetchPageHTML(c.fallback.page, c.rootCtx, pageURL, waitForNetworkIdle, c.refererFor(pageURL))
+	if err != nil {
+		logger.Printf("Fallback browser %s also failed for %s: %v", c.fallback.name, pageURL, err)
+		return nil, "", pageResponse{}
+	}
+	logger.Printf("Fetched %s with fallback browser %s.", pageURL, c.fallback.name)
+	return c.fallback.page, htmlContent, response
+}
+
+// closeFallback closes the fallback page and context; the browser itself is shut down by the caller.
+func (c *Crawler) closeFallback() {
+	if c.fallback == nil {
+		return
+	}
+	if !c.fallback.page.IsClosed() {
+		if err := c.fallback.page.Close(); err != nil {
+			logger.Printf("Error closing fallback browser page: %v", err)
+		}
+	}
+	if err := c.fallback.context.Close(); err != nil {
+		logger.Printf("Error closing fallback browser context: %v", err)
+	}
+}
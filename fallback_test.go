@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateFallbackBrowser(t *testing.T) {
+	tests := []struct {
+		name     string
+		primary  string
+		fallback string
+		wantErr  string
+	}{
+		{name: "lightpanda fallback for chromium", primary: "chromium", fallback: "lightpanda"},
+		{name: "chromium fallback for lightpanda", primary: "lightpanda", fallback: "chromium"},
+		{name: "same as primary", primary: "chromium", fallback: "chromium", wantErr: "must differ"},
+		{name: "unknown browser", primary: "chromium", fallback: "firefox", wantErr: "invalid --fallback-browser"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFallbackBrowser(tt.primary, tt.fallback)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFetchWithFallbackDisabled(t *testing.T) {
+	c := &Crawler{rootCtx: context.Background()}
+	page, html, _ := c.fetchWithFallback("https://example.com/", false, errors.New("boom"))
+	if page != nil || html != "" {
+		t.Errorf("fetchWithFallback without a fallback browser = (%v, %q), want nothing", page, html)
+	}
+	c.closeFallback()
+}
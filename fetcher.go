@@ -4,13 +4,27 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
 )
 
-func fetchPageHTML(page playwright.Page, parentCtx context.Context, pageURL string, waitForNetworkIdle bool) (string, error) {
+// pageResponse describes the main response of a navigation. Status is 0 and Headers is nil if the
+// browser did not report a response.
+type pageResponse struct {
+	Status int
+	// Headers has lower-cased header names.
+	Headers map[string]string
+	// FinalURL is the URL of the page after redirects, including client-side ones.
+	FinalURL string
+}
+
+// fetchPageHTML navigates page to pageURL and returns the rendered HTML together with the main response.
+// A non-empty referer is sent as the Referer of the navigation.
+func fetchPageHTML(page playwright.Page, parentCtx context.Context, pageURL string, waitForNetworkIdle bool, referer string) (string, pageResponse, error) {
 	opTimeout := 120 * time.Second
 	ctx, cancel := context.WithTimeout(parentCtx, opTimeout)
 	defer cancel()
@@ -19,8 +33,9 @@ func fetchPageHTML(page playwright.Page, parentCtx context.Context, pageURL stri
 	logger.Printf("Fetching HTML for %s (using Playwright page: %p, closed: %t, waitForNetworkIdle: %t)", pageURL, page, page.IsClosed(), waitForNetworkIdle)
 
 	type result struct {
-		content string
-		err     error
+		content  string
+		response pageResponse
+		err      error
 	}
 	resultChan := make(chan result, 1)
 
@@ -41,10 +56,14 @@ func fetchPageHTML(page playwright.Page, parentCtx context.Context, pageURL stri
 			waitUntilState = playwright.WaitUntilStateNetworkidle
 		}
 
-		_, err := page.Goto(pageURL, playwright.PageGotoOptions{
+		gotoOptions := playwright.PageGotoOptions{
 			Timeout:   playwright.Float(pwTimeoutMs),
 			WaitUntil: waitUntilState,
-		})
+		}
+		if referer != "" {
+			gotoOptions.Referer = playwright.String(referer)
+		}
+		resp, err := page.Goto(pageURL, gotoOptions)
 
 		if err != nil {
 			if strings.Contains(err.Error(), "Target page, context or browser has been closed") || strings.Contains(err.Error(), "Target closed") {
@@ -73,27 +92,74 @@ func fetchPageHTML(page playwright.Page, parentCtx context.Context, pageURL stri
 			}
 			return
 		}
-		resultChan <- result{content: content, err: nil}
+		var response pageResponse
+		if resp != nil {
+			response = pageResponse{Status: resp.Status(), Headers: resp.Headers(), FinalURL: resp.URL()}
+		}
+		if finalURL := page.URL(); finalURL != "" && finalURL != "about:blank" {
+			response.FinalURL = finalURL
+		}
+		resultChan <- result{content: content, response: response, err: nil}
 	}()
 
+	var response pageResponse
 	select {
 	case <-ctx.Done():
 		errReason := ctx.Err()
 		if parentCtx.Err() == context.Canceled && errors.Is(errReason, context.Canceled) {
-			return "", fmt.Errorf("parent context canceled during fetch of %s: %w", pageURL, parentCtx.Err())
+			return "", pageResponse{}, fmt.Errorf("parent context canceled during fetch of %s: %w", pageURL, parentCtx.Err())
 		}
-		return "", fmt.Errorf("playwright operation for %s %v (overall %s): %w", pageURL, errReason, opTimeout, errReason)
+		return "", pageResponse{}, fmt.Errorf("playwright operation for %s %v (overall %s): %w", pageURL, errReason, opTimeout, errReason)
 	case res := <-resultChan:
 		if res.err != nil {
-			return "", res.err
+			return "", res.response, res.err
 		}
 		htmlContent = res.content
+		response = res.response
 	}
 
 	if strings.TrimSpace(htmlContent) == "" {
-		return "", fmt.Errorf("fetched HTML content from %s is empty or whitespace", pageURL)
+		return "", response, fmt.Errorf("fetched HTML content from %s is %w", pageURL, errEmptyHTML)
 	}
 
 	logger.Printf("Successfully fetched HTML from %s (length: %d)", pageURL, len(htmlContent))
-	return htmlContent, nil
+	return htmlContent, response, nil
+}
+
+// errEmptyHTML is wrapped by fetchPageHTML when a page has no content, e.g. an SPA that has not rendered yet.
+var errEmptyHTML = errors.New("empty or whitespace")
+
+// maxHTTPBodyBytes caps how much of a response body the plain HTTP engine reads.
+const maxHTTPBodyBytes = 50 << 20
+
+// httpFetchClient is the client used by the plain HTTP engine.
+var httpFetchClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchPageHTMLOverHTTP fetches a page with a plain HTTP GET. Unlike fetchPageHTML it does not
+// execute JavaScript, so it is only suitable for server-rendered pages.
+func fetchPageHTMLOverHTTP(ctx context.Context, pageURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build HTTP request for %s: %w", pageURL, err)
+	}
+	req.Header.Set("User-Agent", "sitepanda/"+Version)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+
+	resp, err := httpFetchClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("HTTP GET failed for %s: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("HTTP GET for %s returned status %s", pageURL, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read HTTP response body from %s: %w", pageURL, err)
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return "", fmt.Errorf("fetched HTML content from %s is empty or whitespace", pageURL)
+	}
+	return string(body), nil
 }
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// filterType is the static type of a --filter expression.
+type filterType int
+
+const (
+	filterBool filterType = iota
+	filterNumber
+	filterString
+	filterList
+	filterMap
+)
+
+func (t filterType) String() string {
+	switch t {
+	case filterBool:
+		return "bool"
+	case filterNumber:
+		return "number"
+	case filterString:
+		return "string"
+	case filterList:
+		return "list"
+	}
+	return "map"
+}
+
+// filterNode is a type-checked --filter expression. eval returns a bool, float64, string,
+// []string or map[string]string according to typ.
+type filterNode struct {
+	typ  filterType
+	eval func(pd *PageData) any
+	// literal is set for string literals, which matches() requires so its pattern is
+	// compiled once.
+	literal bool
+}
+
+// filterFields are the page fields a --filter expression can refer to.
+var filterFields = map[string]filterNode{
+	"title":     {typ: filterString, eval: func(pd *PageData) any { return pd.Title }},
+	"url":       {typ: filterString, eval: func(pd *PageData) any { return pd.URL }},
+	"path":      {typ: filterString, eval: func(pd *PageData) any { return urlPath(pd.URL) }},
+	"content":   {typ: filterString, eval: func(pd *PageData) any { return pd.Markdown }},
+	"section":   {typ: filterString, eval: func(pd *PageData) any { return pd.Section }},
+	"site":      {typ: filterString, eval: func(pd *PageData) any { return pd.Site }},
+	"published": {typ: filterString, eval: func(pd *PageData) any { return formatPublishedDate(pd.Published) }},
+	"word_count": {typ: filterNumber, eval: func(pd *PageData) any {
+		return float64(len(strings.Fields(pd.Markdown)))
+	}},
+	"relevance": {typ: filterNumber, eval: func(pd *PageData) any { return pd.Relevance }},
+	"status":    {typ: filterNumber, eval: func(pd *PageData) any { return float64(pd.Status) }},
+	"tags":      {typ: filterList, eval: func(pd *PageData) any { return pd.Tags }},
+	"labels":    {typ: filterList, eval: func(pd *PageData) any { return pd.Labels }},
+	"headers":   {typ: filterMap, eval: func(pd *PageData) any { return pd.Headers }},
+}
+
+// urlPath returns the path of rawURL, or "" if it does not parse.
+func urlPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
+
+// pageFilter is a compiled --filter expression. A nil filter keeps every page.
+type pageFilter struct {
+	source string
+	root   filterNode
+}
+
+// parseFilter compiles a --filter expression, which must evaluate to a bool.
+func parseFilter(expr string) (*pageFilter, error) {
+	p := &filterParser{src: expr}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != filterTokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	if root.typ != filterBool {
+		return nil, fmt.Errorf("expression is a %s, not a bool", root.typ)
+	}
+	return &pageFilter{source: expr, root: root}, nil
+}
+
+// Keep reports whether pd satisfies the filter.
+func (f *pageFilter) Keep(pd *PageData) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.eval(pd).(bool)
+}
+
+// rejectReason returns why pd is left out of the output by the filter, or "".
+func (f *pageFilter) rejectReason(pd *PageData) string {
+	if f.Keep(pd) {
+		return ""
+	}
+	return "excluded by --filter"
+}
+
+type filterTokenKind int
+
+const (
+	filterTokenEOF filterTokenKind = iota
+	filterTokenIdent
+	filterTokenNumber
+	filterTokenString
+	filterTokenOp
+)
+
+type filterToken struct {
+	kind filterTokenKind
+	text string // the operator, identifier or literal source; the unquoted value for strings
+	pos  int
+}
+
+type filterParser struct {
+	src    string
+	tokens []filterToken
+	next   int
+}
+
+// filterOps are the operators and punctuation, longest first.
+var filterOps = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "(", ")", "[", "]", ".", ","}
+
+func (p *filterParser) tokenize() error {
+	for i := 0; i < len(p.src); {
+		r, size := utf8.DecodeRuneInString(p.src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(p.src) && p.src[end] != byte(r) {
+				if p.src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(p.src) {
+				return fmt.Errorf("at column %d: unterminated string", i+1)
+			}
+			raw := p.src[i : end+1]
+			if r == '\'' {
+				raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+			}
+			value, err := strconv.Unquote(raw)
+			if err != nil {
+				return fmt.Errorf("at column %d: invalid string %s", i+1, p.src[i:end+1])
+			}
+			p.tokens = append(p.tokens, filterToken{filterTokenString, value, i})
+			i = end + 1
+		case r >= '0' && r <= '9':
+			end := i
+			for end < len(p.src) && (p.src[end] >= '0' && p.src[end] <= '9' || p.src[end] == '.') {
+				end++
+			}
+			p.tokens = append(p.tokens, filterToken{filterTokenNumber, p.src[i:end], i})
+			i = end
+		case r == '_' || unicode.IsLetter(r):
+			end := i
+			for end < len(p.src) {
+				r, size := utf8.DecodeRuneInString(p.src[end:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += size
+			}
+			p.tokens = append(p.tokens, filterToken{filterTokenIdent, p.src[i:end], i})
+			i = end
+		default:
+			op := ""
+			for _, candidate := range filterOps {
+				if strings.HasPrefix(p.src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("at column %d: unexpected character %q", i+1, r)
+			}
+			p.tokens = append(p.tokens, filterToken{filterTokenOp, op, i})
+			i += len(op)
+		}
+	}
+	p.tokens = append(p.tokens, filterToken{filterTokenEOF, "end of expression", len(p.src)})
+	return nil
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.next] }
+
+func (p *filterParser) advance() filterToken {
+	tok := p.tokens[p.next]
+	if tok.kind != filterTokenEOF {
+		p.next++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the operator op.
+func (p *filterParser) accept(op string) bool {
+	if tok := p.peek(); tok.kind == filterTokenOp && tok.text == op {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) expect(op string) error {
+	if !p.accept(op) {
+		tok := p.peek()
+		return p.errorf(tok, "expected %q, found %q", op, tok.text)
+	}
+	return nil
+}
+
+func (p *filterParser) errorf(tok filterToken, format string, args ...any) error {
+	return fmt.Errorf("at column %d: %s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return left, err
+	}
+	for {
+		tok := p.peek()
+		if !p.accept("||") {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return right, err
+		}
+		if left.typ != filterBool || right.typ != filterBool {
+			return left, p.errorf(tok, "|| needs bool operands, got %s and %s", left.typ, right.typ)
+		}
+		l, r := left.eval, right.eval
+		left = filterNode{typ: filterBool, eval: func(pd *PageData) any { return l(pd).(bool) || r(pd).(bool) }}
+	}
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return left, err
+	}
+	for {
+		tok := p.peek()
+		if !p.accept("&&") {
+			return left, nil
+		}
+		right, err := p.parseComparison()
+		if err != nil {
+			return right, err
+		}
+		if left.typ != filterBool || right.typ != filterBool {
+			return left, p.errorf(tok, "&& needs bool operands, got %s and %s", left.typ, right.typ)
+		}
+		l, r := left.eval, right.eval
+		left = filterNode{typ: filterBool, eval: func(pd *PageData) any { return l(pd).(bool) && r(pd).(bool) }}
+	}
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return left, err
+	}
+	tok := p.peek()
+	if tok.kind != filterTokenOp {
+		return left, nil
+	}
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.advance()
+	right, err := p.parseUnary()
+	if err != nil {
+		return right, err
+	}
+	if left.typ != right.typ {
+		return left, p.errorf(tok, "cannot compare %s with %s", left.typ, right.typ)
+	}
+	ordered := tok.text != "==" && tok.text != "!="
+	if (left.typ != filterNumber && left.typ != filterString) && (ordered || left.typ != filterBool) {
+		return left, p.errorf(tok, "%s cannot be compared with %s", left.typ, tok.text)
+	}
+	l, r, op := left.eval, right.eval, tok.text
+	return filterNode{typ: filterBool, eval: func(pd *PageData) any {
+		return compareFilterValues(l(pd), r(pd), op)
+	}}, nil
+}
+
+// compareFilterValues applies a comparison operator to two values of the same type.
+func compareFilterValues(a, b any, op string) bool {
+	var cmp int
+	switch a := a.(type) {
+	case float64:
+		cmp = compareOrdered(a, b.(float64))
+	case string:
+		cmp = strings.Compare(a, b.(string))
+	case bool:
+		if a != b.(bool) {
+			cmp = 1
+		}
+	}
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	}
+	return cmp >= 0
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	tok := p.peek()
+	if !p.accept("!") {
+		return p.parsePostfix()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return operand, err
+	}
+	if operand.typ != filterBool {
+		return operand, p.errorf(tok, "! needs a bool operand, got %s", operand.typ)
+	}
+	inner := operand.eval
+	return filterNode{typ: filterBool, eval: func(pd *PageData) any { return !inner(pd).(bool) }}, nil
+}
+
+func (p *filterParser) parsePostfix() (filterNode, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return node, err
+	}
+	for {
+		tok := p.peek()
+		switch {
+		case p.accept("."):
+			name := p.advance()
+			if name.kind != filterTokenIdent {
+				return node, p.errorf(name, "expected a method name after '.'")
+			}
+			if err := p.expect("("); err != nil {
+				return node, err
+			}
+			var args []filterNode
+			for !p.accept(")") {
+				if len(args) > 0 {
+					if err := p.expect(","); err != nil {
+						return node, err
+					}
+				}
+				arg, err := p.parseOr()
+				if err != nil {
+					return arg, err
+				}
+				args = append(args, arg)
+			}
+			if node, err = p.method(name, node, args); err != nil {
+				return node, err
+			}
+		case p.accept("["):
+			key, err := p.parseOr()
+			if err != nil {
+				return key, err
+			}
+			if err := p.expect("]"); err != nil {
+				return node, err
+			}
+			if node.typ != filterMap || key.typ != filterString {
+				return node, p.errorf(tok, "only maps can be indexed, by a string")
+			}
+			m, k := node.eval, key.eval
+			node = filterNode{typ: filterString, eval: func(pd *PageData) any {
+				return m(pd).(map[string]string)[strings.ToLower(k(pd).(string))]
+			}}
+		default:
+			return node, nil
+		}
+	}
+}
+
+// method type-checks a method call on recv and returns its node.
+func (p *filterParser) method(name filterToken, recv filterNode, args []filterNode) (filterNode, error) {
+	argTypes := func(want ...filterType) error {
+		if len(args) != len(want) {
+			return p.errorf(name, "%s() takes %d arguments, got %d", name.text, len(want), len(args))
+		}
+		for i, arg := range args {
+			if arg.typ != want[i] {
+				return p.errorf(name, "%s() argument %d must be a %s, got %s", name.text, i+1, want[i], arg.typ)
+			}
+		}
+		return nil
+	}
+	r := recv.eval
+	switch {
+	case name.text == "size" && (recv.typ == filterString || recv.typ == filterList || recv.typ == filterMap):
+		if err := argTypes(); err != nil {
+			return recv, err
+		}
+		return filterNode{typ: filterNumber, eval: func(pd *PageData) any {
+			switch v := r(pd).(type) {
+			case string:
+				return float64(utf8.RuneCountInString(v))
+			case []string:
+				return float64(len(v))
+			default:
+				return float64(len(v.(map[string]string)))
+			}
+		}}, nil
+	case name.text == "contains" && recv.typ == filterList:
+		if err := argTypes(filterString); err != nil {
+			return recv, err
+		}
+		a := args[0].eval
+		return filterNode{typ: filterBool, eval: func(pd *PageData) any {
+			return slices.Contains(r(pd).([]string), a(pd).(string))
+		}}, nil
+	case name.text == "lower" && recv.typ == filterString:
+		if err := argTypes(); err != nil {
+			return recv, err
+		}
+		return filterNode{typ: filterString, eval: func(pd *PageData) any { return strings.ToLower(r(pd).(string)) }}, nil
+	case name.text == "matches" && recv.typ == filterString:
+		if err := argTypes(filterString); err != nil {
+			return recv, err
+		}
+		if !args[0].literal {
+			return recv, p.errorf(name, "matches() takes a string literal")
+		}
+		re, err := regexp.Compile(args[0].eval(nil).(string))
+		if err != nil {
+			return recv, p.errorf(name, "invalid regular expression: %v", err)
+		}
+		return filterNode{typ: filterBool, eval: func(pd *PageData) any { return re.MatchString(r(pd).(string)) }}, nil
+	case recv.typ == filterString:
+		var fn func(s, arg string) bool
+		switch name.text {
+		case "contains":
+			fn = strings.Contains
+		case "startsWith":
+			fn = strings.HasPrefix
+		case "endsWith":
+			fn = strings.HasSuffix
+		default:
+			return recv, p.errorf(name, "unknown string method %s()", name.text)
+		}
+		if err := argTypes(filterString); err != nil {
+			return recv, err
+		}
+		a := args[0].eval
+		return filterNode{typ: filterBool, eval: func(pd *PageData) any { return fn(r(pd).(string), a(pd).(string)) }}, nil
+	}
+	return recv, p.errorf(name, "unknown %s method %s()", recv.typ, name.text)
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case filterTokenNumber:
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return filterNode{}, p.errorf(tok, "invalid number %s", tok.text)
+		}
+		return filterNode{typ: filterNumber, eval: func(*PageData) any { return n }}, nil
+	case filterTokenString:
+		s := tok.text
+		return filterNode{typ: filterString, eval: func(*PageData) any { return s }, literal: true}, nil
+	case filterTokenIdent:
+		switch tok.text {
+		case "true", "false":
+			b := tok.text == "true"
+			return filterNode{typ: filterBool, eval: func(*PageData) any { return b }}, nil
+		}
+		if field, ok := filterFields[tok.text]; ok {
+			return field, nil
+		}
+		return filterNode{}, p.errorf(tok, "unknown field %q", tok.text)
+	case filterTokenOp:
+		if tok.text == "(" {
+			node, err := p.parseOr()
+			if err != nil {
+				return node, err
+			}
+			return node, p.expect(")")
+		}
+	}
+	return filterNode{}, p.errorf(tok, "unexpected %q", tok.text)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPageFilterKeep(t *testing.T) {
+	pd := &PageData{
+		Title:     "Getting Started",
+		URL:       "https://example.com/docs/start?x=1",
+		Markdown:  "one two three four five",
+		Section:   "Docs > Guide",
+		Tags:      []string{"go", "intro"},
+		Published: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Headers:   map[string]string{"content-language": "en"},
+		Relevance: 1.5,
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`word_count > 4`, true},
+		{`word_count > 100 && !title.contains("404")`, false},
+		{`word_count >= 5 && !title.contains('404')`, true},
+		{`title == "Getting Started"`, true},
+		{`title.lower().startsWith("getting") && path.endsWith("/start")`, true},
+		{`path == "/docs/start"`, true},
+		{`url.matches("^https://[^/]+/docs/")`, true},
+		{`tags.contains("go") && tags.size() == 2`, true},
+		{`tags.contains("rust") || section.contains("Guide")`, true},
+		{`published >= "2024-01-01" && published < "2025-01-01"`, true},
+		{`headers["Content-Language"] == "en"`, true},
+		{`headers["x-missing"] == ""`, true},
+		{`relevance > 1.2 && content.size() == 23`, true},
+		{`!(site != "" || false)`, true},
+	}
+	for _, tt := range tests {
+		f, err := parseFilter(tt.expr)
+		if err != nil {
+			t.Errorf("parseFilter(%s): %v", tt.expr, err)
+			continue
+		}
+		if got := f.Keep(pd); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	var none *pageFilter
+	if !none.Keep(pd) || none.rejectReason(pd) != "" {
+		t.Error("nil filter rejected a page")
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr, wantErr string
+	}{
+		{`word_count`, "expression is a number, not a bool"},
+		{`word_count > "100"`, "cannot compare number with string"},
+		{`wordcount > 1`, `at column 1: unknown field "wordcount"`},
+		{`title.contains(1)`, "contains() argument 1 must be a string, got number"},
+		{`title.size(1)`, "size() takes 0 arguments, got 1"},
+		{`title.trim() == ""`, "unknown string method trim()"},
+		{`tags > tags`, "list cannot be compared with >"},
+		{`word_count > 1 &&`, `unexpected "end of expression"`},
+		{`(word_count > 1`, `expected ")"`},
+		{`title == "open`, "unterminated string"},
+		{`title.matches(url)`, "matches() takes a string literal"},
+		{`title.matches("[")`, "invalid regular expression"},
+		{`title["a"] == ""`, "only maps can be indexed"},
+		{`word_count > 1 # x`, "unexpected character '#'"},
+		{`true true`, `at column 6: unexpected "true"`},
+	}
+	for _, tt := range tests {
+		if _, err := parseFilter(tt.expr); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseFilter(%s) error = %v, want %q", tt.expr, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// alreadyFetchedAs reports the URL through which pageURL was fetched earlier in the run, e.g.
+// when it was the redirect target of another queued URL.
+func (c *Crawler) alreadyFetchedAs(pageURL string) (string, bool) {
+	via, ok := c.fetched[pageURL]
+	return via, ok
+}
+
+// recordFetch marks the requested URL and the post-redirect final URL of a fetch as fetched,
+// so redirect chains (http to https, trailing slashes) are not fetched again through another
+// entry. It returns a skip reason if the final URL had already been fetched.
+func (c *Crawler) recordFetch(requestedURL string, finalURL string) (string, bool) {
+	if c.fetched == nil {
+		c.fetched = make(map[string]string)
+	}
+	c.fetched[requestedURL] = requestedURL
+	if finalURL == "" {
+		return "", false
+	}
+	normalized, err := normalizeURLtoString(finalURL)
+	if err != nil {
+		return "", false
+	}
+	normalized = c.applyQueryPolicy(normalized)
+	if normalized == requestedURL {
+		return "", false
+	}
+	if via, ok := c.fetched[normalized]; ok {
+		return fmt.Sprintf("redirects to %s, already fetched via %s", normalized, via), true
+	}
+	c.fetched[normalized] = requestedURL
+	if c.visited != nil {
+		c.visited[normalized] = true
+	}
+	return "", false
+}
+
+// finalPageURL returns the URL a page fetched from requested is at: the normalized finalURL
+// if the fetch was redirected, requested itself otherwise.
+func (c *Crawler) finalPageURL(requested *url.URL, finalURL string) *url.URL {
+	if finalURL == "" {
+		return requested
+	}
+	normalized, err := normalizeURLtoString(finalURL)
+	if err != nil {
+		return requested
+	}
+	if normalized == requested.String() {
+		return requested
+	}
+	if normalized = c.applyQueryPolicy(normalized); normalized == requested.String() {
+		return requested
+	}
+	final, err := url.Parse(normalized)
+	if err != nil {
+		return requested
+	}
+	return final
+}
+
+// linkBaseURL returns the URL that relative links on a page fetched from requested resolve
+// against: finalURL as fetched, keeping the trailing slash that normalizing drops, so that
+// "intro.html" on /docs/ is /docs/intro.html rather than /intro.html.
+func linkBaseURL(requested *url.URL, finalURL string) *url.URL {
+	if finalURL == "" {
+		return requested
+	}
+	base, err := url.Parse(finalURL)
+	if err != nil || !base.IsAbs() {
+		return requested
+	}
+	return base
+}
+
+// followStartRedirect moves a single-site crawl to the host its start URL redirects to, e.g.
+// from example.com to www.example.com, so the links of the site are not taken as off-site.
+func (c *Crawler) followStartRedirect(requestedURL string, final *url.URL) {
+	if c.isURLListMode || len(c.opts.Domains) > 0 || final.Hostname() == c.startURL.Hostname() {
+		return
+	}
+	if start, err := normalizeURLtoString(c.startURL.String()); err != nil || start != requestedURL {
+		return
+	}
+	logger.Printf("Start URL %s redirected to host %s; crawling that host instead.", requestedURL, final.Hostname())
+	c.startURL = final
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestRecordFetch(t *testing.T) {
+	c := &Crawler{visited: make(map[string]bool)}
+
+	if _, dup := c.recordFetch("http://example.com/docs", "https://example.com/docs/"); dup {
+		t.Fatal("first fetch reported as duplicate")
+	}
+	if !c.visited["https://example.com/docs"] {
+		t.Error("final URL not marked visited")
+	}
+	via, ok := c.alreadyFetchedAs("https://example.com/docs")
+	if !ok || via != "http://example.com/docs" {
+		t.Errorf("alreadyFetchedAs() = (%q, %v), want the requested URL", via, ok)
+	}
+
+	reason, dup := c.recordFetch("http://www.example.com/docs", "https://example.com/docs")
+	if !dup || !strings.Contains(reason, "already fetched via http://example.com/docs") {
+		t.Errorf("second redirect to the same target = (%q, %v), want a duplicate", reason, dup)
+	}
+
+	if _, dup := c.recordFetch("https://example.com/about", "https://example.com/about"); dup {
+		t.Error("fetch without redirect reported as duplicate")
+	}
+	if _, dup := c.recordFetch("https://example.com/blog", ""); dup {
+		t.Error("fetch without a response URL reported as duplicate")
+	}
+}
+
+func TestFinalPageURL(t *testing.T) {
+	c := &Crawler{}
+	requested, _ := url.Parse("https://example.com/docs")
+	tests := []struct {
+		finalURL string
+		want     string
+	}{
+		{"", "https://example.com/docs"},
+		{"https://example.com/docs/", "https://example.com/docs"},
+		{"https://example.com/docs#intro", "https://example.com/docs"},
+		{"https://example.com/docs/v2/", "https://example.com/docs/v2"},
+		{"https://www.example.com/docs", "https://www.example.com/docs"},
+	}
+	for _, tt := range tests {
+		got := c.finalPageURL(requested, tt.finalURL)
+		if got.String() != tt.want {
+			t.Errorf("finalPageURL(%q) = %s, want %s", tt.finalURL, got, tt.want)
+		}
+		if (got == requested) != (tt.want == requested.String()) {
+			t.Errorf("finalPageURL(%q) returned a new URL for the same page", tt.finalURL)
+		}
+	}
+}
+
+func TestFollowStartRedirect(t *testing.T) {
+	start, _ := url.Parse("https://example.com/")
+	c := &Crawler{startURL: start}
+	final, _ := url.Parse("https://www.example.com/")
+
+	c.followStartRedirect("https://example.com/about", final)
+	if c.startURL.Hostname() != "example.com" {
+		t.Errorf("a redirect of another page moved the crawl to %s", c.startURL.Hostname())
+	}
+	normalizedStart, err := normalizeURLtoString(start.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.followStartRedirect(normalizedStart, final)
+	if !c.isCrawlHost("www.example.com") || c.isCrawlHost("example.com") {
+		t.Errorf("after the start URL redirected, crawl host is %s, want www.example.com", c.startURL.Hostname())
+	}
+
+	c = &Crawler{startURL: start, isURLListMode: true}
+	c.followStartRedirect(normalizedStart, final)
+	if c.startURL.Hostname() != "example.com" {
+		t.Error("a URL list crawl moved to the redirect target")
+	}
+}
+
+func TestCrawlResolvesLinksAgainstDirectoryURL(t *testing.T) {
+	pages := map[string]string{
+		"docs/index.html":       `<html><head><title>Docs</title></head><body><h1>Docs</h1><p>Start here.</p><a href="intro.html">Intro</a> <a href="guide/">Guide</a></body></html>`,
+		"docs/intro.html":       `<html><head><title>Intro</title></head><body><h1>Intro</h1><p>Read me.</p></body></html>`,
+		"docs/guide/index.html": `<html><head><title>Guide</title></head><body><h1>Guide</h1><p>Steps.</p><a href="setup.html">Setup</a></body></html>`,
+		"docs/guide/setup.html": `<html><head><title>Setup</title></head><body><h1>Setup</h1><p>Install it.</p></body></html>`,
+	}
+	crawler, result, _ := crawlFixtures(t, pages, fixtureCrawl{start: "https://example.com/docs/"})
+	if result.PagesSaved != 4 || len(result.FailedPages) != 0 {
+		t.Errorf("saved %d pages with failures %v, want all 4 pages", result.PagesSaved, result.FailedPages)
+	}
+	for _, page := range crawler.results {
+		if page.FinalURL != "" {
+			t.Errorf("%s has final_url %s; a trailing slash is not a redirect to record", page.URL, page.FinalURL)
+		}
+	}
+}
+
+func TestFinalURLOutput(t *testing.T) {
+	page := PageData{Title: "Docs", URL: "http://example.com/docs", FinalURL: "https://example.com/docs/v2", Markdown: "Body"}
+	if out := formatPageDataAsXML(&page); !strings.Contains(out, "<final_url>https://example.com/docs/v2</final_url>") {
+		t.Errorf("xml-like output lacks the final URL:\n%s", out)
+	}
+	if out := formatPageDataAsMarkdownFile(&page); !strings.Contains(out, "final_url: \"https://example.com/docs/v2\"\n") {
+		t.Errorf("front matter lacks the final URL:\n%s", out)
+	}
+	data, err := formatResultsAsJSON([]PageData{page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"final_url": "https://example.com/docs/v2"`) {
+		t.Errorf("JSON output lacks the final URL:\n%s", data)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// fixtureSite serves pages from local files for --fixture-dir, so a crawl runs without a
+// browser or network.
+type fixtureSite struct {
+	dir string
+}
+
+// newFixtureSite returns the fixture site rooted at dir.
+func newFixtureSite(dir string) (*fixtureSite, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("--fixture-dir: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("--fixture-dir %s is not a directory", dir)
+	}
+	return &fixtureSite{dir: dir}, nil
+}
+
+// candidates returns the files that may hold u, in order of preference. Files are looked up
+// under a directory named after u's host if there is one, and under the fixture directory
+// itself otherwise. A path ending in / is served from its index.html, and a path without an
+// extension also from <path>.html and <path>/index.html. The query string is ignored.
+func (f *fixtureSite) candidates(u *url.URL) []string {
+	root := f.dir
+	if host := u.Hostname(); host != "" {
+		if info, err := os.Stat(filepath.Join(f.dir, host)); err == nil && info.IsDir() {
+			root = filepath.Join(f.dir, host)
+		}
+	}
+	// Cleaning a rooted path drops any ".." that would leave the fixture directory.
+	clean := path.Clean("/" + u.Path)
+	file := filepath.Join(root, filepath.FromSlash(clean))
+	if strings.HasSuffix(u.Path, "/") || clean == "/" {
+		return []string{filepath.Join(file, "index.html")}
+	}
+	if path.Ext(clean) != "" {
+		return []string{file}
+	}
+	return []string{file + ".html", filepath.Join(file, "index.html"), file}
+}
+
+// fetch returns the HTML of pageURL from the fixture files as fetchPageHTML would from the
+// browser. A page without a file fails with a 404 response, and a directory served from its
+// index.html ends up at its URL with a trailing slash.
+func (f *fixtureSite) fetch(pageURL string) (string, pageResponse, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", pageResponse{}, fmt.Errorf("invalid URL %s: %w", pageURL, err)
+	}
+	tried := f.candidates(u)
+	for _, file := range tried {
+		info, err := os.Stat(file)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return "", pageResponse{}, fmt.Errorf("failed to read fixture %s: %w", file, err)
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(file))
+		if contentType == "" || filepath.Ext(file) == "" {
+			contentType = "text/html; charset=utf-8"
+		}
+		finalURL := pageURL
+		if filepath.Base(file) == "index.html" && !strings.HasSuffix(u.Path, "/") && path.Ext(u.Path) == "" {
+			// A web server redirects a directory to its URL with a trailing slash.
+			dir := *u
+			dir.Path += "/"
+			dir.RawPath = ""
+			finalURL = dir.String()
+		}
+		response := pageResponse{Status: 200, Headers: map[string]string{"content-type": contentType}, FinalURL: finalURL}
+		if strings.TrimSpace(string(body)) == "" {
+			return "", response, fmt.Errorf("fixture %s: %w", file, errEmptyHTML)
+		}
+		return string(body), response, nil
+	}
+	return "", pageResponse{Status: 404, FinalURL: pageURL}, fmt.Errorf("no fixture for %s (tried %s): %w", pageURL, strings.Join(tried, ", "), fs.ErrNotExist)
+}
+
+// robots returns the rules of the fixture robots.txt for u's host, allowing everything if
+// there is none.
+func (f *fixtureSite) robots(u *url.URL) *robotsRules {
+	body, _, err := f.fetch(robotsOrigin(u) + "/robots.txt")
+	if err != nil {
+		return robotsRulesForResponse(404, nil)
+	}
+	return robotsRulesForResponse(200, []byte(body))
+}
+
+// validateFixtureOptions rejects options that need a browser page, which a crawl from
+// --fixture-dir does not have.
+func validateFixtureOptions(opts CrawlOptions, fallbackBrowser string) error {
+	var flags []string
+	if fallbackBrowser != "" {
+		flags = append(flags, "--fallback-browser")
+	}
+	if len(opts.Interactions) > 0 {
+		flags = append(flags, "--interact")
+	}
+	if opts.A11yTree {
+		flags = append(flags, "--a11y-tree")
+	}
+	if len(opts.EvalExtract) > 0 {
+		flags = append(flags, "--eval-extract")
+	}
+	if opts.AdaptiveWait {
+		flags = append(flags, "--adaptive-wait")
+	}
+	if opts.CaptureOGImages {
+		flags = append(flags, "--capture-og-images")
+	}
+	if opts.Proxy != "" {
+		flags = append(flags, "--proxy")
+	}
+	if len(flags) > 0 {
+		return fmt.Errorf("--fixture-dir serves pages without a browser and cannot be combined with %s", strings.Join(flags, ", "))
+	}
+	return nil
+}
+
+// fetch loads pageURL from --fixture-dir if it is set and with the crawler's page otherwise.
+func (c *Crawler) fetch(page playwright.Page, pageURL string, waitForNetworkIdle bool, referer string) (string, pageResponse, error) {
+	if c.opts.Fixtures != nil {
+		return c.opts.Fixtures.fetch(pageURL)
+	}
+	return fetchPageHTML(page, c.rootCtx, pageURL, waitForNetworkIdle, referer)
+}
+
+// NewCrawlerForFixtures returns a crawler that reads every page from opts.Fixtures instead of
+// a browser.
+func NewCrawlerForFixtures(
+	startURLStr string,
+	urlList []string,
+	isListMode bool,
+	pageLimit int,
+	matchPatternsRaw []string,
+	followMatchPatternsRaw []string,
+	contentSelector string,
+	outfile string,
+	silent bool,
+	waitForNetworkIdle bool,
+	outputFormat string,
+	opts CrawlOptions,
+) (*Crawler, error) {
+	if opts.Fixtures == nil {
+		return nil, errors.New("no fixture directory given")
+	}
+	parsedStartURL, compiledMatchPatterns, compiledFollowPatterns, err := parseCrawlerArgs(startURLStr, matchPatternsRaw, followMatchPatternsRaw)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.Stores) == 0 && outfile != "" {
+		opts.Stores = []Store{fileStore{path: outfile}}
+	}
+	rootCtxForCrawler, rootCrawlerCancel := context.WithCancel(context.Background())
+	return &Crawler{
+		startURL:            parsedStartURL,
+		pageLimit:           pageLimit,
+		matchPatterns:       compiledMatchPatterns,
+		followMatchPatterns: compiledFollowPatterns,
+		contentSelector:     contentSelector,
+		isURLListMode:       isListMode,
+		initialURLs:         urlList,
+		outfile:             outfile,
+		silent:              silent,
+		waitForNetworkIdle:  waitForNetworkIdle,
+		outputFormat:        outputFormat,
+		opts:                opts,
+		visited:             make(map[string]bool),
+		results:             make([]PageData, 0),
+		rootCtx:             rootCtxForCrawler,
+		cancel:              rootCrawlerCancel,
+	}, nil
+}
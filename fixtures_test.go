@@ -0,0 +1,157 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFixtures creates files under dir from a map of slash-separated paths to contents.
+func writeFixtures(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// fixtureCrawl describes a crawl for crawlFixtures. The zero value crawls from
+// https://example.com/ without a limit or --match patterns into a JSONL file.
+type fixtureCrawl struct {
+	start  string
+	limit  int
+	match  []string
+	format string
+	// opts are the crawl options; Fixtures is set by crawlFixtures.
+	opts CrawlOptions
+}
+
+// crawlFixtures writes pages into a fixture directory and crawls it as described by crawl. It
+// returns the crawler, whose results hold the saved pages, the crawl result and the output file.
+func crawlFixtures(t *testing.T, pages map[string]string, crawl fixtureCrawl) (*Crawler, CrawlResult, string) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFixtures(t, dir, pages)
+	site, err := newFixtureSite(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, format := cmp.Or(crawl.start, "https://example.com/"), cmp.Or(crawl.format, "jsonl")
+	outfile := filepath.Join(t.TempDir(), "out."+format)
+	crawl.opts.Fixtures = site
+	crawler, err := NewCrawlerForFixtures(start, []string{start}, false, crawl.limit, crawl.match, nil, "", outfile, true, false, format, crawl.opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := crawler.Crawl()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crawler, result, outfile
+}
+
+func TestFixtureSiteFetch(t *testing.T) {
+	dir := t.TempDir()
+	writeFixtures(t, dir, map[string]string{
+		"index.html":                     "root index",
+		"docs/index.html":                "docs index",
+		"docs/intro.html":                "intro",
+		"feed.xml":                       "<rss/>",
+		"secret.html":                    "outside",
+		"other.example/index.html":       "other index",
+		"other.example/about/index.html": "other about",
+	})
+	site, err := newFixtureSite(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url         string
+		want        string
+		contentType string
+		// finalURL is where a directory URL without a trailing slash ends up; "" is url.
+		finalURL string
+	}{
+		{"https://example.com/", "root index", "text/html; charset=utf-8", ""},
+		{"https://example.com", "root index", "text/html; charset=utf-8", "https://example.com/"},
+		{"https://example.com/docs/", "docs index", "text/html; charset=utf-8", ""},
+		{"https://example.com/docs", "docs index", "text/html; charset=utf-8", "https://example.com/docs/"},
+		{"https://example.com/docs/intro", "intro", "text/html; charset=utf-8", ""},
+		{"https://example.com/docs/intro.html?ref=nav", "intro", "text/html; charset=utf-8", ""},
+		{"https://example.com/feed.xml", "<rss/>", "text/xml; charset=utf-8", ""},
+		{"https://example.com/../../secret", "outside", "text/html; charset=utf-8", ""},
+		{"https://other.example/", "other index", "text/html; charset=utf-8", ""},
+		{"https://other.example:8443/about", "other about", "text/html; charset=utf-8", "https://other.example:8443/about/"},
+	}
+	for _, tt := range tests {
+		html, resp, err := site.fetch(tt.url)
+		if err != nil {
+			t.Errorf("fetch(%s) error: %v", tt.url, err)
+			continue
+		}
+		if html != tt.want || resp.Status != 200 || resp.FinalURL != cmp.Or(tt.finalURL, tt.url) {
+			t.Errorf("fetch(%s) = %q, %+v; want %q with status 200 at %s", tt.url, html, resp, tt.want, cmp.Or(tt.finalURL, tt.url))
+		}
+		if got := resp.Headers["content-type"]; got != tt.contentType {
+			t.Errorf("fetch(%s) content-type = %q, want %q", tt.url, got, tt.contentType)
+		}
+	}
+
+	_, resp, err := site.fetch("https://example.com/missing")
+	if !errors.Is(err, fs.ErrNotExist) || resp.Status != 404 {
+		t.Errorf("missing page: status %d, error %v; want 404 and fs.ErrNotExist", resp.Status, err)
+	}
+	// A host with its own directory does not fall back to the shared files.
+	if _, _, err := site.fetch("https://other.example/docs/intro"); err == nil {
+		t.Error("other.example/docs/intro was served from the shared files")
+	}
+
+	if _, err := newFixtureSite(filepath.Join(dir, "index.html")); err == nil {
+		t.Error("newFixtureSite accepted a file")
+	}
+}
+
+func TestCrawlFixtures(t *testing.T) {
+	pages := map[string]string{
+		"robots.txt": "User-agent: *\nDisallow: /private\n",
+		"index.html": `<html><head><title>Home</title></head><body><h1>Home</h1><p>Welcome to the fixture site.</p>
+<a href="/docs/intro">Intro</a> <a href="/private/notes">Notes</a> <a href="/missing">Missing</a></body></html>`,
+		"docs/intro.html":    `<html><head><title>Intro</title></head><body><h1>Intro</h1><p>Read the introduction here.</p></body></html>`,
+		"private/notes.html": `<html><head><title>Notes</title></head><body><p>Should not be crawled.</p></body></html>`,
+	}
+	_, result, outfile := crawlFixtures(t, pages, fixtureCrawl{})
+	if result.PagesSaved != 2 {
+		t.Errorf("saved %d pages, want 2 (result %+v)", result.PagesSaved, result)
+	}
+	out, err := os.ReadFile(outfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Welcome to the fixture site", "Read the introduction here"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(string(out), "Should not be crawled") {
+		t.Errorf("output contains a page disallowed by the fixture robots.txt:\n%s", out)
+	}
+}
+
+func TestValidateFixtureOptions(t *testing.T) {
+	if err := validateFixtureOptions(CrawlOptions{Concurrency: 4}, ""); err != nil {
+		t.Errorf("plain options rejected: %v", err)
+	}
+	err := validateFixtureOptions(CrawlOptions{A11yTree: true, Proxy: "http://127.0.0.1:8080"}, "chromium")
+	if err == nil || !strings.Contains(err.Error(), "--fallback-browser, --a11y-tree, --proxy") {
+		t.Errorf("error = %v, want the browser-only flags listed", err)
+	}
+}
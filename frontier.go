@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Crawl strategies accepted by --strategy.
+const (
+	strategyBFS       = "bfs"
+	strategyDFS       = "dfs"
+	strategyBestFirst = "best-first"
+)
+
+// Frontier holds the URLs a crawl has queued but not fetched yet, and decides which comes next.
+type Frontier interface {
+	// Push queues the links found on one page, in document order.
+	Push(urls ...string)
+	// Pop removes and returns the next URL to fetch.
+	Pop() (string, bool)
+	Len() int
+}
+
+// newFrontier returns the frontier of a --strategy. Best-first ranks URLs by the terms of
+// relevantTo found in their path, if set.
+func newFrontier(strategy string, relevantTo string) (Frontier, error) {
+	switch strategy {
+	case "", strategyBFS:
+		return &bfsFrontier{}, nil
+	case strategyDFS:
+		return &dfsFrontier{}, nil
+	case strategyBestFirst:
+		return &bestFirstFrontier{terms: relevanceTerms(relevantTo)}, nil
+	default:
+		return nil, fmt.Errorf("invalid --strategy %q (supported: %s, %s, %s)", strategy, strategyBFS, strategyDFS, strategyBestFirst)
+	}
+}
+
+// bfsFrontier is first in, first out: pages are fetched level by level from the start URLs.
+type bfsFrontier struct {
+	urls []string
+}
+
+func (f *bfsFrontier) Push(urls ...string) { f.urls = append(f.urls, urls...) }
+
+func (f *bfsFrontier) Pop() (string, bool) {
+	if len(f.urls) == 0 {
+		return "", false
+	}
+	next := f.urls[0]
+	f.urls = f.urls[1:]
+	return next, true
+}
+
+func (f *bfsFrontier) Len() int { return len(f.urls) }
+
+// dfsFrontier is last in, first out: a page's links are fetched, in document order, before its
+// siblings, so a crawl reaches the bottom of a deep tree within a small --limit.
+type dfsFrontier struct {
+	// urls is a stack; its top is the last element.
+	urls []string
+}
+
+func (f *dfsFrontier) Push(urls ...string) {
+	for _, u := range slices.Backward(urls) {
+		f.urls = append(f.urls, u)
+	}
+}
+
+func (f *dfsFrontier) Pop() (string, bool) {
+	if len(f.urls) == 0 {
+		return "", false
+	}
+	next := f.urls[len(f.urls)-1]
+	f.urls = f.urls[:len(f.urls)-1]
+	return next, true
+}
+
+func (f *dfsFrontier) Len() int { return len(f.urls) }
+
+// bestFirstFrontier fetches the URL with the highest score first: the most --relevant-to terms
+// in its path, then the fewest path segments, then the earliest found.
+type bestFirstFrontier struct {
+	terms []string
+	// urls are kept sorted, best first.
+	urls []scoredURL
+	seq  int
+}
+
+type scoredURL struct {
+	url      string
+	matches  int
+	segments int
+	seq      int
+}
+
+// before reports whether a is fetched before b.
+func (a scoredURL) before(b scoredURL) bool {
+	if a.matches != b.matches {
+		return a.matches > b.matches
+	}
+	if a.segments != b.segments {
+		return a.segments < b.segments
+	}
+	return a.seq < b.seq
+}
+
+func (f *bestFirstFrontier) Push(urls ...string) {
+	for _, u := range urls {
+		scored := f.score(u)
+		i, _ := slices.BinarySearchFunc(f.urls, scored, func(e, target scoredURL) int {
+			if e.before(target) {
+				return -1
+			}
+			return 1
+		})
+		f.urls = slices.Insert(f.urls, i, scored)
+	}
+}
+
+func (f *bestFirstFrontier) score(rawURL string) scoredURL {
+	f.seq++
+	scored := scoredURL{url: rawURL, seq: f.seq}
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" {
+			scored.segments++
+		}
+	}
+	pathTerms := relevanceTerms(path)
+	for _, term := range f.terms {
+		if slices.Contains(pathTerms, term) {
+			scored.matches++
+		}
+	}
+	return scored
+}
+
+func (f *bestFirstFrontier) Pop() (string, bool) {
+	if len(f.urls) == 0 {
+		return "", false
+	}
+	next := f.urls[0]
+	f.urls = f.urls[1:]
+	return next.url, true
+}
+
+func (f *bestFirstFrontier) Len() int { return len(f.urls) }
+
+// priorityFrontier fetches the URLs of pages that will be saved before those only crawled for
+// their links (--prioritize-match), so a --limit is not used up on index and landing pages.
+// Each group keeps the order of its own --strategy frontier.
+type priorityFrontier struct {
+	saved     Frontier
+	linksOnly Frontier
+	saves     func(string) bool
+}
+
+func (f *priorityFrontier) Push(urls ...string) {
+	var saved, linksOnly []string
+	for _, u := range urls {
+		if f.saves(u) {
+			saved = append(saved, u)
+		} else {
+			linksOnly = append(linksOnly, u)
+		}
+	}
+	f.saved.Push(saved...)
+	f.linksOnly.Push(linksOnly...)
+}
+
+func (f *priorityFrontier) Pop() (string, bool) {
+	if next, ok := f.saved.Pop(); ok {
+		return next, true
+	}
+	return f.linksOnly.Pop()
+}
+
+func (f *priorityFrontier) Len() int { return f.saved.Len() + f.linksOnly.Len() }
+
+// newQueue returns the crawl's frontier for its --strategy, wrapped in a priorityFrontier
+// with --prioritize-match when --match, --exclude-match or --policy leave some pages unsaved.
+// A URL list is fetched in the order given.
+func (c *Crawler) newQueue() (Frontier, error) {
+	queue, err := newFrontier(c.opts.Strategy, c.opts.RelevantTo)
+	if err != nil || !c.opts.PrioritizeMatch || c.isURLListMode {
+		return queue, err
+	}
+	if len(c.matchPatterns) == 0 && len(c.opts.ExcludeMatch) == 0 && c.opts.Policy == nil {
+		return queue, nil
+	}
+	linksOnly, err := newFrontier(c.opts.Strategy, c.opts.RelevantTo)
+	if err != nil {
+		return nil, err
+	}
+	return &priorityFrontier{saved: queue, linksOnly: linksOnly, saves: c.savesURL}, nil
+}
+
+// savesURL reports whether the page at pageURL would be saved by the --policy rule or the
+// --match and --exclude-match patterns, without logging like shouldProcessContent.
+func (c *Crawler) savesURL(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	if rule := c.opts.Policy.Match(u); rule != nil {
+		return rule.saves()
+	}
+	return !c.excludesContent(u) && c.matchesContent(u)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFrontierOrder(t *testing.T) {
+	tests := []struct {
+		strategy   string
+		relevantTo string
+		want       []string
+	}{
+		{strategyBFS, "", []string{"/", "/a", "/b", "/a/1", "/a/2", "/b/1"}},
+		{strategyDFS, "", []string{"/", "/a", "/a/1", "/a/2", "/b", "/b/1"}},
+		{strategyBestFirst, "", []string{"/", "/a", "/b", "/a/1", "/a/2", "/b/1"}},
+		{strategyBestFirst, "b", []string{"/", "/b", "/b/1", "/a", "/a/1", "/a/2"}},
+	}
+	links := map[string][]string{
+		"/":  {"/a", "/b"},
+		"/a": {"/a/1", "/a/2"},
+		"/b": {"/b/1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy+" "+tt.relevantTo, func(t *testing.T) {
+			f, err := newFrontier(tt.strategy, tt.relevantTo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f.Push("https://example.com/")
+			var got []string
+			for f.Len() > 0 {
+				next, _ := f.Pop()
+				path := strings.TrimPrefix(next, "https://example.com")
+				got = append(got, path)
+				var found []string
+				for _, link := range links[path] {
+					found = append(found, "https://example.com"+link)
+				}
+				f.Push(found...)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrontierPopEmpty(t *testing.T) {
+	for _, strategy := range []string{strategyBFS, strategyDFS, strategyBestFirst} {
+		f, _ := newFrontier(strategy, "")
+		if _, ok := f.Pop(); ok {
+			t.Errorf("%s: Pop() of an empty frontier succeeded", strategy)
+		}
+	}
+}
+
+func TestNewFrontierInvalid(t *testing.T) {
+	if _, err := newFrontier("random", ""); err == nil || !strings.Contains(err.Error(), "best-first") {
+		t.Errorf("newFrontier(random) error = %v", err)
+	}
+}
+
+func TestCrawlStrategyDFS(t *testing.T) {
+	pages := map[string]string{
+		"index.html":               `<html><head><title>Docs</title></head><body><h1>Docs</h1><p>All guides.</p><a href="/guide">Guide</a> <a href="/api">API</a></body></html>`,
+		"guide.html":               `<html><head><title>Guide</title></head><body><h1>Guide</h1><p>Start with the basics.</p><a href="/guide/install">Install</a></body></html>`,
+		"guide/install.html":       `<html><head><title>Install</title></head><body><h1>Install</h1><p>Download the binary.</p><a href="/guide/install/linux">Linux</a></body></html>`,
+		"guide/install/linux.html": `<html><head><title>Linux</title></head><body><h1>Linux</h1><p>Use the package manager.</p></body></html>`,
+		"api.html":                 `<html><head><title>API</title></head><body><h1>API</h1><p>Reference.</p></body></html>`,
+	}
+	crawler, _, _ := crawlFixtures(t, pages, fixtureCrawl{limit: 4, opts: CrawlOptions{Strategy: strategyDFS}})
+	var got []string
+	for _, page := range crawler.results {
+		got = append(got, page.URL)
+	}
+	want := []string{"https://example.com/", "https://example.com/guide", "https://example.com/guide/install", "https://example.com/guide/install/linux"}
+	if !slices.Equal(got, want) {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+}
+
+func TestPriorityFrontier(t *testing.T) {
+	saves := func(u string) bool { return strings.Contains(u, "/docs/") }
+	f := &priorityFrontier{saved: &bfsFrontier{}, linksOnly: &bfsFrontier{}, saves: saves}
+	f.Push("https://example.com/", "https://example.com/docs/a", "https://example.com/blog", "https://example.com/docs/b")
+	f.Push("https://example.com/docs/c", "https://example.com/about")
+	var got []string
+	for f.Len() > 0 {
+		next, _ := f.Pop()
+		got = append(got, next)
+	}
+	want := []string{"https://example.com/docs/a", "https://example.com/docs/b", "https://example.com/docs/c", "https://example.com/", "https://example.com/blog", "https://example.com/about"}
+	if !slices.Equal(got, want) {
+		t.Errorf("popped %v, want %v", got, want)
+	}
+}
+
+func TestCrawlPrioritizeMatch(t *testing.T) {
+	pages := map[string]string{
+		"index.html":        `<html><head><title>Home</title></head><body><h1>Home</h1><p>Welcome.</p><a href="/blog">Blog</a> <a href="/about">About</a> <a href="/docs/intro">Intro</a></body></html>`,
+		"blog.html":         `<html><head><title>Blog</title></head><body><h1>Blog</h1><p>News.</p><a href="/docs/news">Docs news</a></body></html>`,
+		"about.html":        `<html><head><title>About</title></head><body><h1>About</h1><p>Us.</p></body></html>`,
+		"docs/intro.html":   `<html><head><title>Intro</title></head><body><h1>Intro</h1><p>Start here.</p><a href="/docs/install">Install</a></body></html>`,
+		"docs/install.html": `<html><head><title>Install</title></head><body><h1>Install</h1><p>Download it.</p></body></html>`,
+		"docs/news.html":    `<html><head><title>Docs news</title></head><body><h1>Docs news</h1><p>What changed.</p></body></html>`,
+	}
+	for _, prioritize := range []bool{true, false} {
+		_, result, _ := crawlFixtures(t, pages, fixtureCrawl{limit: 2, match: []string{"/docs/**"}, opts: CrawlOptions{PrioritizeMatch: prioritize}})
+		// Without priority, the blog and about pages are fetched before the docs.
+		wantFetched := 3
+		if !prioritize {
+			wantFetched = 5
+		}
+		if result.PagesSaved != 2 || result.PagesFetched != wantFetched {
+			t.Errorf("prioritize %t: saved %d after fetching %d pages, want 2 after %d", prioritize, result.PagesSaved, result.PagesFetched, wantFetched)
+		}
+	}
+}
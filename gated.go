@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// gatedMaxWords is the most words a page with a login form may have to count as a login wall;
+// longer pages merely contain a login box next to their content.
+const gatedMaxWords = 150
+
+// paywallSelectors match common paywall and registration wall containers.
+var paywallSelectors = []string{
+	`[class*="paywall" i]`,
+	`[id*="paywall" i]`,
+	`[data-paywall]`,
+	`[class*="regwall" i]`,
+	`.tp-modal`,
+	`.piano-offer`,
+	`[class*="subscriber-only" i]`,
+	`[class*="premium-content-gate" i]`,
+}
+
+// detectGatedPage classifies a fetched page as behind a login wall or paywall and returns why,
+// or "" for a freely accessible page. markdown is the extracted content of the page.
+func detectGatedPage(rawHTML string, markdown string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		return ""
+	}
+
+	gated := ""
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+		if jsonLDNotAccessibleForFree(data) {
+			gated = "paywall (JSON-LD isAccessibleForFree=false)"
+			return false
+		}
+		return true
+	})
+	if gated != "" {
+		return gated
+	}
+
+	for _, selector := range paywallSelectors {
+		if doc.Find(selector).Length() > 0 {
+			return "paywall marker " + selector
+		}
+	}
+
+	if doc.Find(`input[type="password" i]`).Length() > 0 && len(strings.Fields(markdown)) <= gatedMaxWords {
+		return "login wall (password form with little content)"
+	}
+	return ""
+}
+
+// gatedReason returns why a page is excluded as gated, or "" if it is saved. With --include-gated
+// pages are not classified at all.
+func (c *Crawler) gatedReason(rawHTML string, markdown string) string {
+	if c.opts.IncludeGated {
+		return ""
+	}
+	return detectGatedPage(rawHTML, markdown)
+}
+
+// jsonLDNotAccessibleForFree reports whether a decoded JSON-LD value, its @graph or hasPart
+// declares isAccessibleForFree false (as a boolean or string).
+func jsonLDNotAccessibleForFree(data any) bool {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if jsonLDNotAccessibleForFree(item) {
+				return true
+			}
+		}
+	case map[string]any:
+		switch free := v["isAccessibleForFree"].(type) {
+		case bool:
+			if !free {
+				return true
+			}
+		case string:
+			if strings.EqualFold(free, "false") {
+				return true
+			}
+		}
+		for _, key := range []string{"@graph", "hasPart"} {
+			if nested, ok := v[key]; ok && jsonLDNotAccessibleForFree(nested) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectGatedPage(t *testing.T) {
+	longArticle := strings.Repeat("word ", 400)
+	tests := []struct {
+		name       string
+		html       string
+		markdown   string
+		wantPrefix string
+	}{
+		{
+			name:     "open article",
+			html:     `<html><body><article><p>Hello</p></article></body></html>`,
+			markdown: "Hello",
+		},
+		{
+			name:       "json-ld not free",
+			html:       `<html><head><script type="application/ld+json">{"@type":"NewsArticle","isAccessibleForFree":false}</script></head><body><p>Teaser</p></body></html>`,
+			markdown:   longArticle,
+			wantPrefix: "paywall (JSON-LD",
+		},
+		{
+			name:       "json-ld string in hasPart",
+			html:       `<script type="application/ld+json">{"@graph":[{"@type":"Article","hasPart":{"@type":"WebPageElement","isAccessibleForFree":"False"}}]}</script>`,
+			markdown:   longArticle,
+			wantPrefix: "paywall (JSON-LD",
+		},
+		{
+			name:     "json-ld free",
+			html:     `<script type="application/ld+json">{"@type":"Article","isAccessibleForFree":true}</script>`,
+			markdown: longArticle,
+		},
+		{
+			name:       "paywall container",
+			html:       `<html><body><p>Teaser</p><div class="article-Paywall-overlay">Subscribe</div></body></html>`,
+			markdown:   "Teaser",
+			wantPrefix: "paywall marker",
+		},
+		{
+			name:       "login wall",
+			html:       `<html><body><form><input type="email"><input type="password"></form></body></html>`,
+			markdown:   "Sign in to continue",
+			wantPrefix: "login wall",
+		},
+		{
+			name:     "login box beside long content",
+			html:     `<html><body><article>...</article><aside><input type="password"></aside></body></html>`,
+			markdown: longArticle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectGatedPage(tt.html, tt.markdown)
+			if tt.wantPrefix == "" {
+				if got != "" {
+					t.Errorf("detectGatedPage() = %q, want not gated", got)
+				}
+				return
+			}
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("detectGatedPage() = %q, want prefix %q", got, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestGatedReasonIncludeGated(t *testing.T) {
+	c := &Crawler{opts: CrawlOptions{IncludeGated: true}}
+	if got := c.gatedReason(`<div class="paywall"></div>`, ""); got != "" {
+		t.Errorf("gatedReason() with --include-gated = %q, want \"\"", got)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -97,6 +98,32 @@ func TestHandleScraping(t *testing.T) {
 	}
 }
 
+func TestSelectURLListShard(t *testing.T) {
+	urls := []string{"u1", "u2", "u3", "u4", "u5"}
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   []string
+	}{
+		{"no offset or limit", 0, 0, urls},
+		{"limit only", 0, 2, []string{"u1", "u2"}},
+		{"offset only", 3, 0, []string{"u4", "u5"}},
+		{"offset and limit", 1, 2, []string{"u2", "u3"}},
+		{"limit past end", 3, 10, []string{"u4", "u5"}},
+		{"offset at end", 5, 0, nil},
+		{"offset past end", 9, 1, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectURLListShard(urls, tt.offset, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectURLListShard(offset=%d, limit=%d) = %v, want %v", tt.offset, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestUtilityFunctions(t *testing.T) {
 	t.Run("SetLoggerOutput", func(t *testing.T) {
 		var buf bytes.Buffer
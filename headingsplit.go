@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// markdownHeadingPattern matches an ATX heading line such as "## Install".
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})[ \t]+(.+?)[ \t#]*$`)
+
+// markdownLinkPattern matches an inline Markdown link, capturing its text and target.
+var markdownLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]*)(?:\s+"[^"]*")?\)`)
+
+// parseSplitHeading parses --split-by-heading, "h1" to "h6", into a heading level. "" is 0,
+// which leaves pages whole.
+func parseSplitHeading(value string) (int, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return 0, nil
+	}
+	if len(value) == 2 && value[0] == 'h' && value[1] >= '1' && value[1] <= '6' {
+		return int(value[1] - '0'), nil
+	}
+	return 0, fmt.Errorf("invalid --split-by-heading %q: want a heading level from h1 to h6", value)
+}
+
+// headingSection is the part of a page's Markdown from one heading to the next.
+type headingSection struct {
+	// title is the heading's text without Markdown links, emphasis or permalink signs.
+	title string
+	// anchor is the fragment of a permalink in the heading, if it has one.
+	anchor   string
+	markdown string
+}
+
+// splitMarkdownByHeading cuts markdown before every heading of the given level, ignoring
+// headings in fenced code blocks. Higher-level headings, such as the page's h1 when splitting
+// at h2, stay in the text. intro is the text before the first cut.
+func splitMarkdownByHeading(markdown string, level int) (intro string, sections []headingSection) {
+	var current strings.Builder
+	var section *headingSection
+	flush := func() {
+		if section == nil {
+			intro = current.String()
+		} else {
+			section.markdown = current.String()
+			sections = append(sections, *section)
+		}
+		current.Reset()
+	}
+	fence := ""
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+		default:
+			if m := markdownHeadingPattern.FindStringSubmatch(strings.TrimRight(line, "\r\n")); m != nil && len(m[1]) == level {
+				flush()
+				title, anchor := headingText(m[2])
+				section = &headingSection{title: title, anchor: anchor}
+			}
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return intro, sections
+}
+
+// headingText returns the plain text of a Markdown heading and the fragment of a same-page
+// link in it, such as the "¶" permalinks of documentation generators.
+func headingText(heading string) (string, string) {
+	anchor := ""
+	text := markdownLinkPattern.ReplaceAllStringFunc(heading, func(link string) string {
+		m := markdownLinkPattern.FindStringSubmatch(link)
+		if _, fragment, ok := strings.Cut(m[2], "#"); ok && anchor == "" {
+			anchor = fragment
+		}
+		return m[1]
+	})
+	text = strings.NewReplacer("**", "", "__", "", "`", "", "¶", "", "\\", "").Replace(text)
+	return strings.Join(strings.Fields(text), " "), anchor
+}
+
+// headingAnchors maps the text of the h<level> headings of rawHTML to their ids, taken from
+// the heading or an anchor inside it.
+func headingAnchors(rawHTML string, level int) map[string]string {
+	anchors := make(map[string]string)
+	if strings.TrimSpace(rawHTML) == "" {
+		return anchors
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		return anchors
+	}
+	doc.Find(fmt.Sprintf("h%d", level)).Each(func(_ int, s *goquery.Selection) {
+		id, ok := s.Attr("id")
+		if !ok {
+			id, ok = s.Find("[id]").First().Attr("id")
+		}
+		if !ok {
+			id, ok = s.Find("a[name]").First().Attr("name")
+		}
+		text, _ := headingText(s.Text())
+		if ok && id != "" && text != "" {
+			if _, seen := anchors[text]; !seen {
+				anchors[text] = id
+			}
+		}
+	})
+	return anchors
+}
+
+// headingSlug returns a GitHub style anchor for heading text: lower-cased, spaces as hyphens
+// and punctuation dropped.
+func headingSlug(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// splitPageByHeading returns pd as one record per section for --split-by-heading. Each
+// section's URL is the page URL with an anchor for its heading, and its title is the page
+// title followed by the heading. Text before the first heading keeps the page's URL and
+// title. Data that belongs to the whole page, such as comments and tables, stays with the
+// first record, and the article HTML is dropped, as it would repeat the whole page.
+func splitPageByHeading(pd PageData, level int) []PageData {
+	intro, sections := splitMarkdownByHeading(pd.Markdown, level)
+	if len(sections) == 0 {
+		return []PageData{pd}
+	}
+	pageURL, _, _ := strings.Cut(pd.URL, "#")
+	anchors := headingAnchors(pd.RawHTML, level)
+	used := make(map[string]int)
+
+	var records []PageData
+	if strings.TrimSpace(intro) != "" {
+		record := pd
+		record.Markdown = strings.TrimSpace(intro)
+		records = append(records, record)
+	}
+	for _, section := range sections {
+		anchor := section.anchor
+		if anchor == "" {
+			anchor = anchors[section.title]
+		}
+		if anchor == "" {
+			anchor = headingSlug(section.title)
+		}
+		// Repeated headings get -1, -2 suffixes, as on GitHub.
+		if n := used[anchor]; n > 0 {
+			used[anchor]++
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			used[anchor] = 1
+		}
+		record := pd
+		record.URL = pageURL + "#" + anchor
+		record.Title = section.title
+		if pd.Title != "" && pd.Title != section.title {
+			record.Title = pd.Title + sectionSeparator + section.title
+		}
+		record.Markdown = strings.TrimSpace(section.markdown)
+		records = append(records, record)
+	}
+	for i := range records {
+		records[i].ArticleHTML = ""
+		if i > 0 {
+			records[i].Comments = nil
+			records[i].Tables = nil
+			records[i].A11yTree = ""
+			records[i].Extracted = nil
+		}
+	}
+	return records
+}
+
+// splitResultsByHeading applies splitPageByHeading to every page.
+func splitResultsByHeading(results []PageData, level int) []PageData {
+	var split []PageData
+	for _, pd := range results {
+		split = append(split, splitPageByHeading(pd, level)...)
+	}
+	return split
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSplitHeading(t *testing.T) {
+	for value, want := range map[string]int{"": 0, "h2": 2, "H1": 1, " h6 ": 6} {
+		if got, err := parseSplitHeading(value); err != nil || got != want {
+			t.Errorf("parseSplitHeading(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"2", "h7", "h0", "heading"} {
+		if _, err := parseSplitHeading(value); err == nil {
+			t.Errorf("parseSplitHeading(%q) accepted an invalid level", value)
+		}
+	}
+}
+
+func TestSplitPageByHeading(t *testing.T) {
+	page := PageData{
+		Title: "Guide",
+		URL:   "https://example.com/guide",
+		Markdown: "Read this first.\n\n" +
+			"## Install\n\nDownload it.\n\n### From source\n\nBuild it.\n\n" +
+			"```sh\n## not a heading\n```\n\n" +
+			"## Configure [¶](https://example.com/guide#config \"Permalink\")\n\nSet options.\n\n" +
+			"## Usage\n\nRun it.\n\n## Usage\n\nAgain.\n",
+		RawHTML:     `<h2 id="installation">Install</h2><h3 id="source">From source</h3><h2>Usage</h2>`,
+		ArticleHTML: "<p>Whole page</p>",
+		Comments:    []Comment{{Author: "ann", Text: "Nice"}},
+	}
+	records := splitPageByHeading(page, 2)
+	var urls, titles []string
+	for _, r := range records {
+		urls = append(urls, r.URL)
+		titles = append(titles, r.Title)
+		if r.ArticleHTML != "" {
+			t.Errorf("record %s kept the article HTML of the whole page", r.URL)
+		}
+	}
+	wantURLs := []string{
+		"https://example.com/guide",
+		"https://example.com/guide#installation",
+		"https://example.com/guide#config",
+		"https://example.com/guide#usage",
+		"https://example.com/guide#usage-1",
+	}
+	if !slices.Equal(urls, wantURLs) {
+		t.Errorf("URLs = %v, want %v", urls, wantURLs)
+	}
+	wantTitles := []string{"Guide", "Guide > Install", "Guide > Configure", "Guide > Usage", "Guide > Usage"}
+	if !slices.Equal(titles, wantTitles) {
+		t.Errorf("titles = %v, want %v", titles, wantTitles)
+	}
+	if got, want := records[1].Markdown, "## Install\n\nDownload it.\n\n### From source\n\nBuild it.\n\n```sh\n## not a heading\n```"; got != want {
+		t.Errorf("Install section = %q, want %q", got, want)
+	}
+	if records[0].Markdown != "Read this first." || len(records[0].Comments) != 1 || records[1].Comments != nil {
+		t.Errorf("intro = %q with %d comments; comments must stay with the first record only", records[0].Markdown, len(records[0].Comments))
+	}
+
+	whole := PageData{Title: "Short", URL: "https://example.com/short", Markdown: "# Short\n\nNo sections."}
+	if got := splitPageByHeading(whole, 2); len(got) != 1 || got[0].URL != whole.URL {
+		t.Errorf("a page without h2 headings was split into %d records", len(got))
+	}
+}
+
+func TestPagePathForSection(t *testing.T) {
+	if got, want := pagePathForURL("https://example.com/guide#install"), "example.com/guide-install"; got != want {
+		t.Errorf("pagePathForURL() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// hfDatasetFormat is the --output-format that writes a Hugging Face dataset directory into
+	// --outfile.
+	hfDatasetFormat = "hf-dataset"
+	// hfDatasetCard is the dataset card datasets.load_dataset reads the data file layout from.
+	hfDatasetCard = "README.md"
+)
+
+// hfDatasetRow is one page in the dataset. All keys are always present so every row has the
+// same schema.
+type hfDatasetRow struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	// Metadata is the page's JSON output object without title, url and content, as a string so
+	// that pages with different fields do not conflict in the inferred schema.
+	Metadata string `json:"metadata"`
+	Tokens   int64  `json:"tokens"`
+}
+
+// hfDatasetSplit is the rows written to data/<name>.jsonl.
+type hfDatasetSplit struct {
+	name string
+	rows []hfDatasetRow
+}
+
+// writeHFDataset writes results into dir as a dataset that datasets.load_dataset(dir) can load:
+// JSONL data files under data/ and a README.md dataset card declaring them. Without --split
+// every page is in the train split.
+func writeHFDataset(dir string, results []PageData, outputSplits []outputSplit, seed int64) error {
+	if len(outputSplits) == 0 {
+		outputSplits = []outputSplit{{Name: "train", Ratio: 1}}
+	}
+	var splits []hfDatasetSplit
+	for i, pages := range assignSplits(results, outputSplits, seed) {
+		split := hfDatasetSplit{name: outputSplits[i].Name, rows: make([]hfDatasetRow, len(pages))}
+		for j := range pages {
+			pd := &pages[j]
+			metadata, err := pageMetadataJSON(pd)
+			if err != nil {
+				return fmt.Errorf("failed to encode metadata of %s: %w", pd.URL, err)
+			}
+			split.rows[j] = hfDatasetRow{URL: pd.URL, Title: pd.Title, Text: pd.Markdown, Metadata: metadata, Tokens: estimateTokens(pd.Markdown)}
+		}
+		splits = append(splits, split)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "data"), 0755); err != nil {
+		return fmt.Errorf("failed to create dataset directory: %w", err)
+	}
+	for _, split := range splits {
+		var buf bytes.Buffer
+		for _, row := range split.rows {
+			line, err := json.Marshal(row)
+			if err != nil {
+				return fmt.Errorf("failed to encode dataset row for %s: %w", row.URL, err)
+			}
+			buf.Write(line)
+			buf.WriteByte('\n')
+		}
+		name := filepath.Join(dir, "data", split.name+".jsonl")
+		if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	card := formatHFDatasetCard(results, splits, time.Now())
+	if err := os.WriteFile(filepath.Join(dir, hfDatasetCard), []byte(card), 0644); err != nil {
+		return fmt.Errorf("failed to write dataset card: %w", err)
+	}
+	return nil
+}
+
+// formatHFDatasetCard renders the dataset card: YAML metadata with the data files and features,
+// followed by a stub description for the publisher to complete.
+func formatHFDatasetCard(results []PageData, splits []hfDatasetSplit, now time.Time) string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, pd := range results {
+		if u, err := url.Parse(pd.URL); err == nil && u.Host != "" && !seen[u.Host] {
+			seen[u.Host] = true
+			hosts = append(hosts, u.Host)
+		}
+	}
+	name := "Sitepanda crawl"
+	if len(hosts) > 0 {
+		name = hosts[0]
+		if len(hosts) > 1 {
+			name += fmt.Sprintf(" and %d more sites", len(hosts)-1)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "pretty_name: %s\n", yamlQuote(name))
+	b.WriteString("configs:\n- config_name: default\n  data_files:\n")
+	for _, split := range splits {
+		fmt.Fprintf(&b, "  - split: %s\n    path: data/%s.jsonl\n", split.name, split.name)
+	}
+	b.WriteString("dataset_info:\n  features:\n")
+	for _, f := range [][2]string{{"url", "string"}, {"title", "string"}, {"text", "string"}, {"metadata", "string"}, {"tokens", "int64"}} {
+		fmt.Fprintf(&b, "  - name: %s\n    dtype: %s\n", f[0], f[1])
+	}
+	b.WriteString("  splits:\n")
+	for _, split := range splits {
+		fmt.Fprintf(&b, "  - name: %s\n    num_examples: %d\n", split.name, len(split.rows))
+	}
+	b.WriteString("---\n\n")
+
+	fmt.Fprintf(&b, "# %s\n\n", name)
+	fmt.Fprintf(&b, "Web pages crawled with Sitepanda %s on %s from %s.\n\n", Version, now.UTC().Format("2006-01-02"), strings.Join(hosts, ", "))
+	b.WriteString("## Dataset Structure\n\nOne row per page:\n\n")
+	b.WriteString("- `url`: the page URL\n- `title`: the page title\n- `text`: the main content as Markdown\n")
+	b.WriteString("- `metadata`: JSON object with further page fields such as `section`, `tags` and `published`, when known\n")
+	b.WriteString("- `tokens`: estimated LLM token count of `text` (four characters per token)\n\n")
+	b.WriteString("| Split | Pages |\n|---|---|\n")
+	for _, split := range splits {
+		fmt.Fprintf(&b, "| %s | %d |\n", split.name, len(split.rows))
+	}
+	b.WriteString("\n## Licensing\n\nThe content belongs to its publishers. Check the terms of the crawled sites and add a `license` to the metadata above before publishing this dataset.\n")
+	return b.String()
+}
+
+// yamlQuote returns s as a double-quoted YAML scalar.
+func yamlQuote(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestWriteHFDataset(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "dataset")
+	results := []PageData{
+		{Title: "Home", URL: "https://example.com/", Markdown: "Hello", Tags: []string{"intro"}},
+		{Title: "API", URL: "https://docs.example.org/api", Markdown: "Reference"},
+	}
+	if err := writeHFDataset(dir, results, nil, 0); err != nil {
+		t.Fatalf("writeHFDataset() error = %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "data", "train.jsonl"))
+	if err != nil {
+		t.Fatalf("data file missing: %v", err)
+	}
+	defer f.Close()
+	var rows []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var row map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			t.Fatalf("invalid JSONL line %q: %v", scanner.Text(), err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	for _, row := range rows {
+		if len(row) != 5 {
+			t.Errorf("row %v does not have the 5 dataset columns", row)
+		}
+	}
+	if rows[0]["text"] != "Hello" || rows[0]["metadata"] != `{"tags":["intro"]}` || rows[1]["metadata"] != `{}` {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	card, err := os.ReadFile(filepath.Join(dir, hfDatasetCard))
+	if err != nil {
+		t.Fatalf("dataset card missing: %v", err)
+	}
+	parts := strings.SplitN(string(card), "---\n", 3)
+	if len(parts) != 3 || parts[0] != "" {
+		t.Fatalf("dataset card has no YAML header:\n%s", card)
+	}
+	var header struct {
+		PrettyName string `yaml:"pretty_name"`
+		Configs    []struct {
+			ConfigName string `yaml:"config_name"`
+			DataFiles  []struct {
+				Split string `yaml:"split"`
+				Path  string `yaml:"path"`
+			} `yaml:"data_files"`
+		} `yaml:"configs"`
+	}
+	if err := yaml.Unmarshal([]byte(parts[1]), &header); err != nil {
+		t.Fatalf("invalid dataset card YAML: %v", err)
+	}
+	if header.PrettyName != "example.com and 1 more sites" {
+		t.Errorf("pretty_name = %q", header.PrettyName)
+	}
+	if len(header.Configs) != 1 || len(header.Configs[0].DataFiles) != 1 || header.Configs[0].DataFiles[0].Split != "train" {
+		t.Fatalf("unexpected configs: %+v", header.Configs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, header.Configs[0].DataFiles[0].Path)); err != nil {
+		t.Errorf("card points to a missing data file: %v", err)
+	}
+}
+
+func TestFormatHFDatasetCardCountsSplits(t *testing.T) {
+	card := formatHFDatasetCard(nil, []hfDatasetSplit{{name: "train", rows: make([]hfDatasetRow, 3)}}, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	for _, want := range []string{"pretty_name: \"Sitepanda crawl\"", "  - name: train\n    num_examples: 3", "| train | 3 |", "on 2024-01-02"} {
+		if !strings.Contains(card, want) {
+			t.Errorf("card missing %q:\n%s", want, card)
+		}
+	}
+}
+
+func TestWriteHFDatasetSplits(t *testing.T) {
+	dir := t.TempDir()
+	var results []PageData
+	for _, p := range []string{"a", "b", "c", "d", "e", "f"} {
+		results = append(results, PageData{URL: "https://example.com/" + p})
+	}
+	if err := writeHFDataset(dir, results, []outputSplit{{"train", 0.5}, {"test", 0.5}}, 1); err != nil {
+		t.Fatalf("writeHFDataset() error = %v", err)
+	}
+	card, _ := os.ReadFile(filepath.Join(dir, hfDatasetCard))
+	for _, split := range []string{"train", "test"} {
+		if _, err := os.Stat(filepath.Join(dir, "data", split+".jsonl")); err != nil {
+			t.Errorf("missing data file for %s: %v", split, err)
+		}
+		if !strings.Contains(string(card), "  - split: "+split+"\n    path: data/"+split+".jsonl") {
+			t.Errorf("card does not declare split %s:\n%s", split, card)
+		}
+	}
+}
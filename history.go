@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hokupod/sitepanda/cmd"
+)
+
+// historyFileName is the run history file in Sitepanda's "history" data subdirectory.
+const historyFileName = "runs.jsonl"
+
+// historyRun is one line of the run history: the statistics of a single scrape.
+type historyRun struct {
+	Time            time.Time            `json:"time"`
+	StartURL        string               `json:"start_url"`
+	Job             string               `json:"job,omitempty"`
+	StopReason      string               `json:"stop_reason"`
+	PagesSaved      int                  `json:"pages_saved"`
+	PagesSkipped    int                  `json:"pages_skipped"`
+	PagesFailed     int                  `json:"pages_failed"`
+	DurationSeconds float64              `json:"duration_seconds"`
+	Domains         map[string]HostStats `json:"domains,omitempty"`
+}
+
+// historyEntry is a run's numbers for one domain, the unit that `sitepanda history` lists and compares.
+type historyEntry struct {
+	Time       time.Time
+	Domain     string
+	Job        string
+	Saved      int
+	Failed     int
+	Duration   time.Duration
+	StopReason string
+}
+
+// historyComparison compares a domain's latest run with the median of its previous runs.
+type historyComparison struct {
+	Latest         historyEntry
+	Baseline       int
+	MedianSaved    float64
+	MedianFailed   float64
+	MedianDuration time.Duration
+	// Anomalies describes deviations beyond the threshold; empty means the run looks normal.
+	Anomalies []string
+}
+
+// historyFilePath returns --history-file or the default file in Sitepanda's data directory.
+func historyFilePath() (string, error) {
+	if path := cmd.GetHistoryFile(); path != "" {
+		return path, nil
+	}
+	dir, err := GetAppSubdirectory("history")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, historyFileName), nil
+}
+
+// newHistoryRun builds the history record of a finished crawl. hosts are always included, with
+// zero counts if nothing was fetched from them, so empty runs show up in comparisons.
+func newHistoryRun(started time.Time, startURL string, job string, hosts []string, result CrawlResult) historyRun {
+	run := historyRun{
+		Time:            started.UTC(),
+		StartURL:        startURL,
+		Job:             job,
+		StopReason:      result.StopReason,
+		PagesSaved:      result.PagesSaved,
+		PagesSkipped:    len(result.SkippedPages),
+		PagesFailed:     result.PagesFailed,
+		DurationSeconds: math.Round(time.Since(started).Seconds()*10) / 10,
+		Domains:         make(map[string]HostStats),
+	}
+	for host, stats := range result.HostStats {
+		run.Domains[host] = stats
+	}
+	for _, host := range hosts {
+		if _, ok := run.Domains[host]; !ok && host != "" {
+			run.Domains[host] = HostStats{}
+		}
+	}
+	return run
+}
+
+// appendHistoryRun adds run to the history file at path, creating it if needed.
+func appendHistoryRun(path string, run historyRun) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open run history %s: %w", path, err)
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(&run); err != nil {
+		return fmt.Errorf("failed to write run history %s: %w", path, err)
+	}
+	return nil
+}
+
+// readHistoryRuns reads the history file. A missing file is an empty history; malformed lines are skipped.
+func readHistoryRuns(path string) ([]historyRun, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run history %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var runs []historyRun
+	reader := bufio.NewReader(f)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var run historyRun
+			if jsonErr := json.Unmarshal(line, &run); jsonErr != nil {
+				logger.Printf("Warning: skipping malformed line %d of %s: %v", lineNum, path, jsonErr)
+			} else {
+				runs = append(runs, run)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read run history %s: %w", path, err)
+		}
+	}
+	return runs, nil
+}
+
+// historyEntries splits runs into per-domain entries in chronological order, keeping only
+// domain's entries when it is non-empty.
+func historyEntries(runs []historyRun, domain string) []historyEntry {
+	var entries []historyEntry
+	for _, run := range runs {
+		domains := run.Domains
+		if len(domains) == 0 {
+			host := run.StartURL
+			if u, err := url.Parse(run.StartURL); err == nil && u.Hostname() != "" {
+				host = u.Hostname()
+			}
+			domains = map[string]HostStats{host: {Saved: run.PagesSaved, Failed: run.PagesFailed}}
+		}
+		hosts := make([]string, 0, len(domains))
+		for host := range domains {
+			hosts = append(hosts, host)
+		}
+		sort.Strings(hosts)
+		for _, host := range hosts {
+			if domain != "" && host != domain {
+				continue
+			}
+			entries = append(entries, historyEntry{
+				Time:       run.Time,
+				Domain:     host,
+				Job:        run.Job,
+				Saved:      domains[host].Saved,
+				Failed:     domains[host].Failed,
+				Duration:   time.Duration(run.DurationSeconds * float64(time.Second)),
+				StopReason: run.StopReason,
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries
+}
+
+// compareHistory compares each domain's latest entry with the median of up to baseline earlier
+// entries. A run is anomalous when it saved fewer than (1-threshold) times the median pages or
+// failed on more than (1+threshold) times the median pages. Domains with a single run are skipped.
+func compareHistory(entries []historyEntry, baseline int, threshold float64) []historyComparison {
+	byDomain := make(map[string][]historyEntry)
+	var domains []string
+	for _, e := range entries {
+		if _, ok := byDomain[e.Domain]; !ok {
+			domains = append(domains, e.Domain)
+		}
+		byDomain[e.Domain] = append(byDomain[e.Domain], e)
+	}
+	sort.Strings(domains)
+
+	var comparisons []historyComparison
+	for _, domain := range domains {
+		list := byDomain[domain]
+		if len(list) < 2 {
+			continue
+		}
+		latest := list[len(list)-1]
+		previous := list[:len(list)-1]
+		if baseline > 0 && len(previous) > baseline {
+			previous = previous[len(previous)-baseline:]
+		}
+		var saved, failed, durations []float64
+		for _, e := range previous {
+			saved = append(saved, float64(e.Saved))
+			failed = append(failed, float64(e.Failed))
+			durations = append(durations, float64(e.Duration))
+		}
+		c := historyComparison{
+			Latest:         latest,
+			Baseline:       len(previous),
+			MedianSaved:    median(saved),
+			MedianFailed:   median(failed),
+			MedianDuration: time.Duration(median(durations)),
+		}
+		if float64(latest.Saved) < (1-threshold)*c.MedianSaved {
+			c.Anomalies = append(c.Anomalies, fmt.Sprintf("saved %d pages, median %.0f", latest.Saved, c.MedianSaved))
+		}
+		if float64(latest.Failed) > (1+threshold)*c.MedianFailed {
+			c.Anomalies = append(c.Anomalies, fmt.Sprintf("%d failures, median %.0f", latest.Failed, c.MedianFailed))
+		}
+		comparisons = append(comparisons, c)
+	}
+	return comparisons
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
+
+// formatPercentChange renders the change from base to value, e.g. "-42%".
+func formatPercentChange(value, base float64) string {
+	if base == 0 {
+		if value == 0 {
+			return "0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.0f%%", (value-base)/base*100)
+}
+
+// writeHistoryTable lists entries, newest last.
+func writeHistoryTable(w io.Writer, entries []historyEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tDOMAIN\tSAVED\tFAILED\tDURATION\tSTATUS")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\n", e.Time.Local().Format("2006-01-02 15:04"), e.Domain, e.Saved, e.Failed, e.Duration.Round(time.Second), e.StopReason)
+	}
+	return tw.Flush()
+}
+
+// writeHistoryComparison prints one row per domain comparing its latest run with the baseline.
+func writeHistoryComparison(w io.Writer, comparisons []historyComparison) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOMAIN\tLATEST\tSAVED\tMEDIAN\tCHANGE\tFAILED\tMEDIAN\tDURATION\tMEDIAN\tRUNS\tRESULT")
+	for _, c := range comparisons {
+		verdict := "ok"
+		if len(c.Anomalies) > 0 {
+			verdict = "ANOMALY: " + strings.Join(c.Anomalies, "; ")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.0f\t%s\t%d\t%.0f\t%s\t%s\t%d\t%s\n",
+			c.Latest.Domain, c.Latest.Time.Local().Format("2006-01-02 15:04"),
+			c.Latest.Saved, c.MedianSaved, formatPercentChange(float64(c.Latest.Saved), c.MedianSaved),
+			c.Latest.Failed, c.MedianFailed,
+			c.Latest.Duration.Round(time.Second), c.MedianDuration.Round(time.Second), c.Baseline, verdict)
+	}
+	return tw.Flush()
+}
+
+// HandleHistory implements `sitepanda history [domain]`: it lists recent runs or, with --compare,
+// compares each domain's latest run with its history and exits with status 1 on an anomaly.
+func HandleHistory(domain string) {
+	path, err := historyFilePath()
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	runs, err := readHistoryRuns(path)
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	entries := historyEntries(runs, domain)
+	if len(entries) == 0 {
+		if domain != "" {
+			fmt.Printf("No runs recorded for %s in %s.\n", domain, path)
+		} else {
+			fmt.Printf("No runs recorded in %s.\n", path)
+		}
+		return
+	}
+
+	if !cmd.GetHistoryCompare() {
+		if last := cmd.GetHistoryLast(); last > 0 && len(entries) > last {
+			entries = entries[len(entries)-last:]
+		}
+		if err := writeHistoryTable(os.Stdout, entries); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	threshold := cmd.GetHistoryThreshold()
+	if threshold <= 0 || threshold >= 1 {
+		logger.Fatalf("Error: --threshold must be between 0 and 1, got %v.", threshold)
+	}
+	comparisons := compareHistory(entries, cmd.GetHistoryBaseline(), threshold)
+	if len(comparisons) == 0 {
+		fmt.Println("Not enough runs to compare; at least two runs per domain are needed.")
+		return
+	}
+	if err := writeHistoryComparison(os.Stdout, comparisons); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	for _, c := range comparisons {
+		if len(c.Anomalies) > 0 {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRunRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.jsonl")
+	started := time.Now().Add(-90 * time.Second)
+	result := CrawlResult{
+		StopReason:   "Completed",
+		PagesSaved:   3,
+		PagesFailed:  1,
+		SkippedPages: []SkippedPage{{URL: "https://a.example.com/big", Reason: "too big"}},
+		HostStats:    map[string]HostStats{"a.example.com": {Saved: 3, Failed: 1}},
+	}
+	run := newHistoryRun(started, "https://a.example.com/", "nightly", []string{"a.example.com", "b.example.com"}, result)
+	if want := map[string]HostStats{"a.example.com": {Saved: 3, Failed: 1}, "b.example.com": {}}; !reflect.DeepEqual(run.Domains, want) {
+		t.Errorf("newHistoryRun() domains = %v, want %v", run.Domains, want)
+	}
+	if run.DurationSeconds < 89 || run.DurationSeconds > 100 {
+		t.Errorf("newHistoryRun() duration = %v, want about 90s", run.DurationSeconds)
+	}
+
+	if err := appendHistoryRun(path, run); err != nil {
+		t.Fatalf("appendHistoryRun() error = %v", err)
+	}
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString("not json\n")
+	f.Close()
+	if err := appendHistoryRun(path, run); err != nil {
+		t.Fatalf("appendHistoryRun() error = %v", err)
+	}
+
+	runs, err := readHistoryRuns(path)
+	if err != nil {
+		t.Fatalf("readHistoryRuns() error = %v", err)
+	}
+	if len(runs) != 2 || runs[0].Job != "nightly" || runs[1].PagesSkipped != 1 {
+		t.Errorf("readHistoryRuns() = %+v", runs)
+	}
+
+	if runs, err := readHistoryRuns(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil || runs != nil {
+		t.Errorf("readHistoryRuns(missing) = %v, %v; want empty history", runs, err)
+	}
+}
+
+func TestHistoryEntries(t *testing.T) {
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := []historyRun{
+		{Time: t1.Add(time.Hour), StartURL: "https://a.example.com/", Domains: map[string]HostStats{"b.example.com": {Saved: 2}, "a.example.com": {Saved: 5, Failed: 1}}, DurationSeconds: 30},
+		{Time: t1, StartURL: "https://old.example.com/docs", PagesSaved: 7},
+	}
+	entries := historyEntries(runs, "")
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Domain)
+	}
+	if want := []string{"old.example.com", "a.example.com", "b.example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("historyEntries() domains = %v, want %v", got, want)
+	}
+	if entries[0].Saved != 7 || entries[1].Failed != 1 || entries[1].Duration != 30*time.Second {
+		t.Errorf("historyEntries() = %+v", entries)
+	}
+	if filtered := historyEntries(runs, "b.example.com"); len(filtered) != 1 || filtered[0].Saved != 2 {
+		t.Errorf("historyEntries(b.example.com) = %+v", filtered)
+	}
+}
+
+func TestCompareHistory(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var entries []historyEntry
+	for i, saved := range []int{100, 120, 110, 40} {
+		entries = append(entries, historyEntry{Time: base.AddDate(0, 0, i), Domain: "a.example.com", Saved: saved})
+	}
+	for i, failed := range []int{0, 2, 2} {
+		entries = append(entries, historyEntry{Time: base.AddDate(0, 0, i), Domain: "b.example.com", Saved: 10, Failed: failed})
+	}
+	entries = append(entries, historyEntry{Time: base, Domain: "single.example.com", Saved: 1})
+
+	comparisons := compareHistory(entries, 10, 0.5)
+	if len(comparisons) != 2 {
+		t.Fatalf("compareHistory() returned %d comparisons, want 2 (single-run domains skipped)", len(comparisons))
+	}
+	a, b := comparisons[0], comparisons[1]
+	if a.Latest.Saved != 40 || a.MedianSaved != 110 || a.Baseline != 3 || len(a.Anomalies) != 1 {
+		t.Errorf("a.example.com comparison = %+v, want a pages anomaly against median 110", a)
+	}
+	if b.MedianFailed != 1 || len(b.Anomalies) != 1 || !strings.Contains(b.Anomalies[0], "2 failures") {
+		t.Errorf("b.example.com comparison = %+v, want a failures anomaly", b)
+	}
+
+	if got := compareHistory(entries, 1, 0.5); got[0].MedianSaved != 110 || got[0].Baseline != 1 {
+		t.Errorf("compareHistory() with baseline 1 = %+v, want only the previous run", got[0])
+	}
+
+	var buf bytes.Buffer
+	if err := writeHistoryComparison(&buf, comparisons); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "-64%") || !strings.Contains(out, "ANOMALY: saved 40 pages, median 110") {
+		t.Errorf("writeHistoryComparison() = %q", out)
+	}
+}
+
+func TestFormatPercentChange(t *testing.T) {
+	tests := []struct {
+		value, base float64
+		want        string
+	}{
+		{value: 50, base: 100, want: "-50%"},
+		{value: 150, base: 100, want: "+50%"},
+		{value: 0, base: 0, want: "0%"},
+		{value: 3, base: 0, want: "new"},
+	}
+	for _, tt := range tests {
+		if got := formatPercentChange(tt.value, tt.base); got != tt.want {
+			t.Errorf("formatPercentChange(%v, %v) = %q, want %q", tt.value, tt.base, got, tt.want)
+		}
+	}
+}
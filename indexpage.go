@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// outputIndexMarkdown and outputIndexHTML are written to the root of --output-dir. Page files
+	// always live below a host directory, so the names cannot clash with a page.
+	outputIndexMarkdown = "index.md"
+	outputIndexHTML     = "index.html"
+)
+
+// indexSection is a group of pages in the output directory index.
+type indexSection struct {
+	Title string
+	Pages []indexEntry
+}
+
+// indexEntry is one page in the output directory index.
+type indexEntry struct {
+	Title     string
+	Link      string
+	URL       string
+	Published string
+	Words     int
+}
+
+// buildIndexSections groups pages by their section: the breadcrumb section when the page has
+// one, otherwise its host and parent path as in the table of contents. Sections and pages keep
+// crawl order. pagePaths maps page URLs to their file relative to the output directory.
+func buildIndexSections(results []PageData, pagePaths map[string]string) []indexSection {
+	titleByURL := make(map[string]string, len(results))
+	for _, pd := range results {
+		if pd.Title != "" {
+			titleByURL[pd.URL] = pd.Title
+		}
+	}
+
+	var sections []indexSection
+	sectionIndex := make(map[string]int)
+	for _, pd := range results {
+		sectionTitle := pd.Section
+		if sectionTitle == "" {
+			if pageURL, err := url.Parse(pd.URL); err == nil {
+				sectionTitle = strings.Join(append([]string{pageURL.Host}, tocTrailFromURL(pageURL, titleByURL)...), " > ")
+			}
+		}
+		i, ok := sectionIndex[sectionTitle]
+		if !ok {
+			i = len(sections)
+			sectionIndex[sectionTitle] = i
+			sections = append(sections, indexSection{Title: sectionTitle})
+		}
+
+		title := pd.Title
+		if title == "" {
+			title = pd.URL
+		}
+		link := pd.URL
+		if rel, ok := pagePaths[pd.URL]; ok {
+			link = (&url.URL{Path: rel}).String()
+		}
+		sections[i].Pages = append(sections[i].Pages, indexEntry{
+			Title:     title,
+			Link:      link,
+			URL:       pd.URL,
+			Published: formatPublishedDate(pd.Published),
+			Words:     len(strings.Fields(pd.Markdown)),
+		})
+	}
+	return sections
+}
+
+// formatIndexMarkdown renders the index as Markdown, one table per section.
+func formatIndexMarkdown(sections []indexSection, pageCount int) string {
+	var b strings.Builder
+	b.WriteString("# Index\n\n")
+	fmt.Fprintf(&b, "%d pages in %d sections.\n", pageCount, len(sections))
+	for _, s := range sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", s.Title)
+		b.WriteString("| Page | Published | Words |\n|---|---|---|\n")
+		for _, p := range s.Pages {
+			fmt.Fprintf(&b, "| [%s](%s) | %s | %d |\n", escapeMarkdownTableCell(escapeMarkdownLinkText(p.Title)), p.Link, p.Published, p.Words)
+		}
+	}
+	return b.String()
+}
+
+func escapeMarkdownTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+var indexHTMLTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Index</title>
+<style>
+body { font-family: sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2rem; }
+th, td { text-align: left; padding: 0.3rem 0.6rem; border-bottom: 1px solid #ddd; }
+td.num { text-align: right; }
+</style>
+</head>
+<body>
+<h1>Index</h1>
+<p>{{.PageCount}} pages in {{len .Sections}} sections.</p>
+{{range .Sections}}<h2>{{.Title}}</h2>
+<table>
+<tr><th>Page</th><th>Published</th><th>Words</th></tr>
+{{range .Pages}}<tr><td><a href="{{.Link}}" title="{{.URL}}">{{.Title}}</a></td><td>{{.Published}}</td><td class="num">{{.Words}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// writeOutputIndex writes index.md and index.html listing the saved pages to the root of dir.
+func writeOutputIndex(dir string, results []PageData, pagePaths map[string]string) error {
+	sections := buildIndexSections(results, pagePaths)
+	if err := os.WriteFile(filepath.Join(dir, outputIndexMarkdown), []byte(formatIndexMarkdown(sections, len(results))), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputIndexMarkdown, err)
+	}
+	var b strings.Builder
+	if err := indexHTMLTemplate.Execute(&b, struct {
+		PageCount int
+		Sections  []indexSection
+	}{len(results), sections}); err != nil {
+		return fmt.Errorf("failed to render %s: %w", outputIndexHTML, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, outputIndexHTML), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputIndexHTML, err)
+	}
+	return nil
+}
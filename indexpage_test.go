@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildIndexSections(t *testing.T) {
+	results := []PageData{
+		{Title: "Home", URL: "https://example.com/", Markdown: "welcome home"},
+		{Title: "Install", URL: "https://example.com/docs/install", Section: "Docs > Guide", Markdown: "one two three", Published: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "", URL: "https://example.com/blog/post", Markdown: ""},
+		{Title: "Upgrade", URL: "https://example.com/docs/upgrade", Section: "Docs > Guide", Markdown: "a b"},
+	}
+	pagePaths := map[string]string{
+		"https://example.com/":             "example.com/index.md",
+		"https://example.com/docs/install": "example.com/docs/install.md",
+		"https://example.com/docs/upgrade": "example.com/docs/upgrade.md",
+		"https://example.com/blog/post":    "example.com/blog/post.md",
+	}
+
+	got := buildIndexSections(results, pagePaths)
+	want := []indexSection{
+		{Title: "example.com", Pages: []indexEntry{{Title: "Home", Link: "example.com/index.md", URL: "https://example.com/", Words: 2}}},
+		{Title: "Docs > Guide", Pages: []indexEntry{
+			{Title: "Install", Link: "example.com/docs/install.md", URL: "https://example.com/docs/install", Published: "2024-03-01", Words: 3},
+			{Title: "Upgrade", Link: "example.com/docs/upgrade.md", URL: "https://example.com/docs/upgrade", Words: 2},
+		}},
+		{Title: "example.com > blog", Pages: []indexEntry{{Title: "https://example.com/blog/post", Link: "example.com/blog/post.md", URL: "https://example.com/blog/post"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildIndexSections() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestWriteOutputIndex(t *testing.T) {
+	dir := t.TempDir()
+	results := []PageData{{Title: "A | B <c>", URL: "https://example.com/a", Markdown: "x y"}}
+	if err := writeOutputIndex(dir, results, map[string]string{"https://example.com/a": "example.com/a.md"}); err != nil {
+		t.Fatalf("writeOutputIndex() error = %v", err)
+	}
+
+	md, err := os.ReadFile(filepath.Join(dir, outputIndexMarkdown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Index", "## example.com", `| [A \| B <c>](example.com/a.md) |  | 2 |`} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("index.md missing %q:\n%s", want, md)
+		}
+	}
+
+	html, err := os.ReadFile(filepath.Join(dir, outputIndexHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`<a href="example.com/a.md" title="https://example.com/a">A | B &lt;c&gt;</a>`, `<td class="num">2</td>`} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("index.html missing %q:\n%s", want, html)
+		}
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
+	"github.com/hokupod/sitepanda/cmd"
 	"github.com/playwright-community/playwright-go"
 )
 
@@ -91,6 +93,11 @@ func HandleInitCommand(browserToInstall string) {
 			Stdout:          os.Stdout,
 			Stderr:          os.Stderr,
 		}
+		if cmd.GetInitMinimal() {
+			// Sitepanda always launches Chromium headless, which only needs the headless shell.
+			installOptions.OnlyInstallShell = true
+			logger.Println("--minimal: installing only Chromium's headless shell.")
+		}
 
 		logger.Println("Running playwright.Install to download and set up Chromium...")
 		if err := playwright.Install(&installOptions); err != nil {
@@ -98,6 +105,14 @@ func HandleInitCommand(browserToInstall string) {
 		}
 		logger.Println("Chromium has been successfully set up via playwright-go within Sitepanda's designated directory.")
 
+		if version, err := embeddedDriverVersion(playwrightInstallDir); err != nil {
+			logger.Printf("Warning: %v", err)
+		} else if err := writeDriverRecord(playwrightInstallDir, driverRecord{PlaywrightVersion: version, Minimal: installOptions.OnlyInstallShell, InstalledAt: time.Now().UTC()}); err != nil {
+			logger.Printf("Warning: could not record Playwright driver version: %v", err)
+		} else {
+			logger.Printf("Recorded Playwright driver version v%s.", version)
+		}
+
 		chromiumPathHint, pathErr := GetBrowserExecutablePath("chromium", playwrightInstallDir)
 		if pathErr != nil {
 			logger.Printf("Note: Could not determine a specific path hint for the Chromium executable after installation: %v. This is generally okay as Playwright manages this internally within %s.", pathErr, playwrightInstallDir)
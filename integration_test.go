@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"net/http/httptest"
 	"os"
 	"os/exec"
@@ -63,20 +62,10 @@ func TestMain(m *testing.M) {
 	os.Exit(exitCode)
 }
 
-// setupTestServer creates a simple HTTP test server.
+// setupTestServer serves the synthetic site of 'sitepanda selftest-server'. Its /page1 links to
+// /page2, which takes 2 seconds to respond.
 func setupTestServer() *httptest.Server {
-	mux := http.NewServeMux()
-	mux.HandleFunc("/page1", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		fmt.Fprintln(w, `<html><head><title>Page 1</title></head><body><h1>Hello</h1><p>This is page 1.</p><a href="/page2">Page 2</a></body></html>`)
-	})
-	mux.HandleFunc("/page2", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/html")
-		// A page that takes a bit of time to respond for cancellation tests
-		time.Sleep(2 * time.Second)
-		fmt.Fprintln(w, `<html><head><title>Page 2</title></head><body><p>This is page 2.</p></body></html>`)
-	})
-	return httptest.NewServer(mux)
+	return httptest.NewServer(newSelftestSite(0))
 }
 
 func TestCLIIntegration(t *testing.T) {
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"gopkg.in/yaml.v2"
+)
+
+// interactionTimeout bounds each step of an --interact script.
+const interactionTimeout = 30 * time.Second
+
+// interaction is one entry of an --interact file: steps run on a page, typically filling and
+// submitting a search form. The page the steps end on is crawled.
+type interaction struct {
+	// URL is the page the steps start on; empty uses the start URL.
+	URL   string            `yaml:"url"`
+	Steps []interactionStep `yaml:"steps"`
+}
+
+// interactionStep is a single action. Exactly one of Fill, Click, Press, WaitFor and Wait is set.
+type interactionStep struct {
+	Fill    string `yaml:"fill"`
+	Value   string `yaml:"value"`
+	Click   string `yaml:"click"`
+	Press   string `yaml:"press"`
+	Key     string `yaml:"key"`
+	WaitFor string `yaml:"wait_for"`
+	Wait    string `yaml:"wait"`
+
+	waitDuration time.Duration
+}
+
+// parseInteractions parses and validates the YAML of an --interact file: a list of interactions.
+func parseInteractions(data []byte) ([]interaction, error) {
+	var interactions []interaction
+	if err := yaml.UnmarshalStrict(data, &interactions); err != nil {
+		return nil, fmt.Errorf("invalid interactions file: %w", err)
+	}
+	if len(interactions) == 0 {
+		return nil, fmt.Errorf("interactions file contains no interactions")
+	}
+	for i := range interactions {
+		if interactions[i].URL != "" {
+			normalized, err := normalizeURLtoString(interactions[i].URL)
+			if err != nil {
+				return nil, fmt.Errorf("interaction %d: invalid url %q: %w", i+1, interactions[i].URL, err)
+			}
+			interactions[i].URL = normalized
+		}
+		if len(interactions[i].Steps) == 0 {
+			return nil, fmt.Errorf("interaction %d has no steps", i+1)
+		}
+		for j := range interactions[i].Steps {
+			if err := interactions[i].Steps[j].validate(); err != nil {
+				return nil, fmt.Errorf("interaction %d, step %d: %w", i+1, j+1, err)
+			}
+		}
+	}
+	return interactions, nil
+}
+
+func (s *interactionStep) validate() error {
+	actions := 0
+	for _, set := range []bool{s.Fill != "", s.Click != "", s.Press != "", s.WaitFor != "", s.Wait != ""} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return fmt.Errorf("a step needs exactly one of fill, click, press, wait_for or wait")
+	}
+	switch {
+	case s.Fill != "" && s.Value == "":
+		return fmt.Errorf("fill %q needs a value", s.Fill)
+	case s.Press != "" && s.Key == "":
+		return fmt.Errorf("press %q needs a key, e.g. Enter", s.Press)
+	case s.Wait != "":
+		d, err := time.ParseDuration(s.Wait)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid wait %q (expected a duration such as 2s)", s.Wait)
+		}
+		s.waitDuration = d
+	}
+	return nil
+}
+
+// run performs the step on page.
+func (s interactionStep) run(page playwright.Page) error {
+	timeout := playwright.Float(float64(interactionTimeout.Milliseconds()))
+	switch {
+	case s.Fill != "":
+		return page.Locator(s.Fill).First().Fill(s.Value, playwright.LocatorFillOptions{Timeout: timeout})
+	case s.Click != "":
+		return page.Locator(s.Click).First().Click(playwright.LocatorClickOptions{Timeout: timeout})
+	case s.Press != "":
+		return page.Locator(s.Press).First().Press(s.Key, playwright.LocatorPressOptions{Timeout: timeout})
+	case s.WaitFor != "":
+		return page.Locator(s.WaitFor).First().WaitFor(playwright.LocatorWaitForOptions{State: playwright.WaitForSelectorStateVisible, Timeout: timeout})
+	default:
+		page.WaitForTimeout(float64(s.waitDuration.Milliseconds()))
+		return nil
+	}
+}
+
+func (s interactionStep) String() string {
+	switch {
+	case s.Fill != "":
+		return "fill " + s.Fill
+	case s.Click != "":
+		return "click " + s.Click
+	case s.Press != "":
+		return "press " + s.Key + " on " + s.Press
+	case s.WaitFor != "":
+		return "wait for " + s.WaitFor
+	default:
+		return "wait " + s.Wait
+	}
+}
+
+// runInteractions performs the --interact scripts on the crawler's page and returns the URLs of
+// the pages they end on, which seed the crawl. Failed interactions are logged and skipped.
+func (c *Crawler) runInteractions() []string {
+	var seeds []string
+	for i, in := range c.opts.Interactions {
+		startURL := in.URL
+		if startURL == "" {
+			startURL = c.startURL.String()
+		}
+		resultURL, err := c.runInteraction(startURL, in.Steps)
+		if err != nil {
+			logger.Printf("Warning: interaction %d on %s failed: %v", i+1, startURL, err)
+			continue
+		}
+		logger.Printf("Interaction %d on %s ended on %s", i+1, startURL, resultURL)
+		seeds = append(seeds, resultURL)
+	}
+	return seeds
+}
+
+func (c *Crawler) runInteraction(startURL string, steps []interactionStep) (string, error) {
+	if _, _, err := fetchPageHTML(c.page, c.rootCtx, startURL, c.waitForNetworkIdle, ""); err != nil {
+		return "", err
+	}
+	for _, step := range steps {
+		if c.rootCtx.Err() != nil {
+			return "", c.rootCtx.Err()
+		}
+		if err := step.run(c.page); err != nil {
+			return "", fmt.Errorf("%s: %w", step, err)
+		}
+	}
+	// A submitted form navigates; let the result page load before reading its URL.
+	if err := c.page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{State: playwright.LoadStateLoad}); err != nil {
+		return "", fmt.Errorf("result page did not load: %w", err)
+	}
+	return normalizeURLtoString(c.page.URL())
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInteractions(t *testing.T) {
+	valid := `
+- url: https://example.com/search/
+  steps:
+    - fill: input[name=q]
+      value: kubernetes networking
+    - press: input[name=q]
+      key: Enter
+    - wait_for: .results
+    - wait: 500ms
+- steps:
+    - click: "#show-all"
+`
+	interactions, err := parseInteractions([]byte(valid))
+	if err != nil {
+		t.Fatalf("parseInteractions() error: %v", err)
+	}
+	if len(interactions) != 2 {
+		t.Fatalf("got %d interactions, want 2", len(interactions))
+	}
+	if interactions[0].URL != "https://example.com/search" {
+		t.Errorf("URL = %q, want it normalized", interactions[0].URL)
+	}
+	if got := interactions[0].Steps[3].waitDuration; got != 500*time.Millisecond {
+		t.Errorf("wait duration = %v, want 500ms", got)
+	}
+	if interactions[1].URL != "" || interactions[1].Steps[0].Click != "#show-all" {
+		t.Errorf("second interaction = %+v", interactions[1])
+	}
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{name: "empty", yaml: "[]", wantErr: "no interactions"},
+		{name: "no steps", yaml: "- url: https://example.com/\n", wantErr: "has no steps"},
+		{name: "two actions", yaml: "- steps:\n    - click: a\n      wait_for: b\n", wantErr: "exactly one"},
+		{name: "fill without value", yaml: "- steps:\n    - fill: input\n", wantErr: "needs a value"},
+		{name: "press without key", yaml: "- steps:\n    - press: input\n", wantErr: "needs a key"},
+		{name: "bad wait", yaml: "- steps:\n    - wait: soon\n", wantErr: "invalid wait"},
+		{name: "unknown field", yaml: "- steps:\n    - tap: button\n", wantErr: "invalid interactions file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseInteractions([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseInteractions() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
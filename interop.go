@@ -0,0 +1,464 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hokupod/sitepanda/cmd"
+)
+
+// crawlProfile is the crawler-independent part of a sitepanda scrape configuration that
+// export-config and import-config translate to and from other crawlers.
+type crawlProfile struct {
+	StartURL            string
+	MatchPatterns       []string
+	FollowMatchPatterns []string
+	// ExcludeMatchPatterns and ExcludeFollowPatterns are the --exclude-match and
+	// --exclude-follow globs.
+	ExcludeMatchPatterns  []string
+	ExcludeFollowPatterns []string
+	PageLimit             int
+	// MaxDepth is the --depth link limit, 0 for none.
+	MaxDepth           int
+	ContentSelector    string
+	WaitForNetworkIdle bool
+	// RemoveSelectors are the selectors of remove: DOM rules.
+	RemoveSelectors []string
+}
+
+// firecrawlCrawlOptions is the subset of Firecrawl's /v1/crawl request body sitepanda understands.
+type firecrawlCrawlOptions struct {
+	URL                string                  `json:"url"`
+	IncludePaths       []string                `json:"includePaths,omitempty"`
+	ExcludePaths       []string                `json:"excludePaths,omitempty"`
+	MaxDepth           int                     `json:"maxDepth,omitempty"`
+	Limit              int                     `json:"limit,omitempty"`
+	AllowExternalLinks bool                    `json:"allowExternalLinks,omitempty"`
+	ScrapeOptions      *firecrawlScrapeOptions `json:"scrapeOptions,omitempty"`
+}
+
+type firecrawlScrapeOptions struct {
+	Formats         []string `json:"formats,omitempty"`
+	OnlyMainContent bool     `json:"onlyMainContent,omitempty"`
+	IncludeTags     []string `json:"includeTags,omitempty"`
+	ExcludeTags     []string `json:"excludeTags,omitempty"`
+	WaitFor         int      `json:"waitFor,omitempty"`
+}
+
+// globToRegexp translates a sitepanda --match/--follow-match glob (with / as separator) into an
+// anchored regular expression over the URL path.
+func globToRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	runes := []rune(pattern)
+	inAlternatives := false
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch r {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '{':
+			if inAlternatives {
+				return "", fmt.Errorf("nested {} in glob %q is not supported", pattern)
+			}
+			inAlternatives = true
+			b.WriteString("(?:")
+		case '}':
+			if !inAlternatives {
+				return "", fmt.Errorf("unbalanced } in glob %q", pattern)
+			}
+			inAlternatives = false
+			b.WriteString(")")
+		case ',':
+			if inAlternatives {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '[':
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) {
+				return "", fmt.Errorf("unbalanced [ in glob %q", pattern)
+			}
+			class := string(runes[i+1 : end])
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i = end
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if inAlternatives {
+		return "", fmt.Errorf("unbalanced { in glob %q", pattern)
+	}
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// simpleRegexpToGlob translates the common shapes of Firecrawl path regexes (literal text, .*,
+// [^/]* and [^/]+) back into a glob. It reports false for anything else.
+func simpleRegexpToGlob(re string) (string, bool) {
+	re = strings.TrimPrefix(re, "^")
+	anchoredEnd := strings.HasSuffix(re, "$") && !strings.HasSuffix(re, `\$`)
+	re = strings.TrimSuffix(re, "$")
+	var b strings.Builder
+	for i := 0; i < len(re); i++ {
+		switch {
+		case strings.HasPrefix(re[i:], ".*"), strings.HasPrefix(re[i:], ".+"):
+			b.WriteString("**")
+			i++
+		case strings.HasPrefix(re[i:], "[^/]*"), strings.HasPrefix(re[i:], "[^/]+"):
+			b.WriteString("*")
+			i += 4
+		case re[i] == '\\' && i+1 < len(re) && strings.ContainsRune(`.-/_?+*()[]{}|^$\`, rune(re[i+1])):
+			if strings.ContainsRune("*?[]{}\\", rune(re[i+1])) {
+				return "", false
+			}
+			b.WriteByte(re[i+1])
+			i++
+		case strings.ContainsRune(`.+?()[]{}|^$\*`, rune(re[i])):
+			return "", false
+		default:
+			b.WriteByte(re[i])
+		}
+	}
+	glob := b.String()
+	if !strings.HasPrefix(glob, "/") && !strings.HasPrefix(glob, "**") {
+		glob = "/" + glob
+	}
+	if !anchoredEnd && !strings.HasSuffix(glob, "**") {
+		// An unanchored regex also matches longer paths.
+		glob += "**"
+	}
+	return glob, true
+}
+
+// exportFirecrawl translates a profile into Firecrawl crawl options. Notes describe settings that
+// have no exact equivalent.
+func exportFirecrawl(p crawlProfile) (firecrawlCrawlOptions, []string, error) {
+	opts := firecrawlCrawlOptions{URL: p.StartURL, Limit: p.PageLimit}
+	var notes []string
+	if p.MaxDepth > 0 {
+		notes = append(notes, "--depth counts links, while Firecrawl's maxDepth counts path segments; it was not exported")
+	}
+
+	includeGlobs := p.FollowMatchPatterns
+	if len(p.MatchPatterns) > 0 {
+		if len(includeGlobs) == 0 {
+			includeGlobs = p.MatchPatterns
+			notes = append(notes, "--match is exported as includePaths, so Firecrawl will not crawl through pages that don't match it")
+		} else {
+			notes = append(notes, "--match has no Firecrawl equivalent (Firecrawl returns every crawled page); only --follow-match is exported as includePaths")
+		}
+	}
+	for _, g := range includeGlobs {
+		re, err := globToRegexp(g)
+		if err != nil {
+			return opts, nil, err
+		}
+		opts.IncludePaths = append(opts.IncludePaths, re)
+	}
+
+	excludeGlobs := p.ExcludeFollowPatterns
+	if len(p.ExcludeMatchPatterns) > 0 {
+		if len(excludeGlobs) == 0 {
+			excludeGlobs = p.ExcludeMatchPatterns
+			notes = append(notes, "--exclude-match is exported as excludePaths, so Firecrawl will not crawl through pages that match it")
+		} else {
+			notes = append(notes, "--exclude-match has no Firecrawl equivalent; only --exclude-follow is exported as excludePaths")
+		}
+	}
+	for _, g := range excludeGlobs {
+		re, err := globToRegexp(g)
+		if err != nil {
+			return opts, nil, err
+		}
+		opts.ExcludePaths = append(opts.ExcludePaths, re)
+	}
+
+	scrape := &firecrawlScrapeOptions{Formats: []string{"markdown"}, OnlyMainContent: true}
+	if p.ContentSelector != "" {
+		scrape.IncludeTags = splitSelectorList(p.ContentSelector)
+		if len(scrape.IncludeTags) > 1 {
+			notes = append(notes, "Firecrawl includeTags keeps every listed selector, while --content-selector uses the first one that matches")
+		}
+	}
+	scrape.ExcludeTags = p.RemoveSelectors
+	if p.WaitForNetworkIdle {
+		notes = append(notes, "--wait-for-network-idle has no Firecrawl equivalent; consider scrapeOptions.waitFor")
+	}
+	opts.ScrapeOptions = scrape
+	return opts, notes, nil
+}
+
+// importFirecrawl translates Firecrawl crawl options into a profile. Notes describe options that
+// were dropped or approximated.
+func importFirecrawl(opts firecrawlCrawlOptions) (crawlProfile, []string, error) {
+	if opts.URL == "" {
+		return crawlProfile{}, nil, fmt.Errorf("firecrawl options have no url")
+	}
+	p := crawlProfile{StartURL: opts.URL, PageLimit: opts.Limit}
+	var notes []string
+	for _, re := range opts.IncludePaths {
+		g, ok := simpleRegexpToGlob(re)
+		if !ok {
+			notes = append(notes, fmt.Sprintf("includePaths regex %q cannot be expressed as a glob and was dropped", re))
+			continue
+		}
+		// Firecrawl only crawls and returns pages under includePaths.
+		p.FollowMatchPatterns = append(p.FollowMatchPatterns, g)
+		p.MatchPatterns = append(p.MatchPatterns, g)
+	}
+	for _, re := range opts.ExcludePaths {
+		g, ok := simpleRegexpToGlob(re)
+		if !ok {
+			notes = append(notes, fmt.Sprintf("excludePaths regex %q cannot be expressed as a glob and was dropped", re))
+			continue
+		}
+		// Firecrawl neither crawls nor returns pages under excludePaths.
+		p.ExcludeFollowPatterns = append(p.ExcludeFollowPatterns, g)
+		p.ExcludeMatchPatterns = append(p.ExcludeMatchPatterns, g)
+	}
+	if opts.MaxDepth > 0 {
+		notes = append(notes, "maxDepth has no sitepanda equivalent and was dropped")
+	}
+	if opts.AllowExternalLinks {
+		notes = append(notes, "allowExternalLinks is not supported; sitepanda stays on the start URL's host")
+	}
+	if s := opts.ScrapeOptions; s != nil {
+		p.ContentSelector = strings.Join(s.IncludeTags, ", ")
+		p.RemoveSelectors = s.ExcludeTags
+		if s.WaitFor > 0 {
+			p.WaitForNetworkIdle = true
+			notes = append(notes, fmt.Sprintf("waitFor %dms is approximated with --wait-for-network-idle", s.WaitFor))
+		}
+	}
+	return p, notes, nil
+}
+
+// scrapeCommandLine renders a profile as a sitepanda scrape command line.
+func scrapeCommandLine(p crawlProfile) string {
+	args := []string{"sitepanda", "scrape"}
+	for _, m := range p.MatchPatterns {
+		args = append(args, "--match", shellQuote(m))
+	}
+	for _, m := range p.FollowMatchPatterns {
+		args = append(args, "--follow-match", shellQuote(m))
+	}
+	for _, m := range p.ExcludeMatchPatterns {
+		args = append(args, "--exclude-match", shellQuote(m))
+	}
+	for _, m := range p.ExcludeFollowPatterns {
+		args = append(args, "--exclude-follow", shellQuote(m))
+	}
+	if p.PageLimit > 0 {
+		args = append(args, "--limit", strconv.Itoa(p.PageLimit))
+	}
+	if p.MaxDepth > 0 {
+		args = append(args, "--depth", strconv.Itoa(p.MaxDepth))
+	}
+	if p.ContentSelector != "" {
+		args = append(args, "--content-selector", shellQuote(p.ContentSelector))
+	}
+	for _, sel := range p.RemoveSelectors {
+		args = append(args, "--dom-rule", shellQuote("remove:"+sel))
+	}
+	if p.WaitForNetworkIdle {
+		args = append(args, "--wait-for-network-idle")
+	}
+	args = append(args, shellQuote(p.StartURL))
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes s for a POSIX shell if it contains anything but safe characters.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// exportScrapy renders a profile as a Scrapy CrawlSpider module.
+func exportScrapy(p crawlProfile) (string, []string, error) {
+	start, err := url.Parse(p.StartURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid start URL %q: %w", p.StartURL, err)
+	}
+	var notes []string
+	pyStr := func(s string) string { q, _ := json.Marshal(s); return string(q) }
+	pyList := func(items []string) string {
+		quoted := make([]string, len(items))
+		for i, item := range items {
+			quoted[i] = pyStr(item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	}
+	regexps := func(globs []string) ([]string, error) {
+		var res []string
+		for _, g := range globs {
+			re, err := globToRegexp(g)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, re)
+		}
+		return res, nil
+	}
+	followRes, err := regexps(p.FollowMatchPatterns)
+	if err != nil {
+		return "", nil, err
+	}
+	matchRes, err := regexps(p.MatchPatterns)
+	if err != nil {
+		return "", nil, err
+	}
+	excludeFollowRes, err := regexps(p.ExcludeFollowPatterns)
+	if err != nil {
+		return "", nil, err
+	}
+	excludeMatchRes, err := regexps(p.ExcludeMatchPatterns)
+	if err != nil {
+		return "", nil, err
+	}
+	// LinkExtractor matches the whole URL, so path regexes are prefixed with the scheme and host.
+	allow := make([]string, len(followRes))
+	for i, re := range followRes {
+		allow[i] = `^https?://[^/]+` + strings.TrimPrefix(re, "^")
+	}
+	deny := make([]string, len(excludeFollowRes))
+	for i, re := range excludeFollowRes {
+		deny[i] = `^https?://[^/]+` + strings.TrimPrefix(re, "^")
+	}
+	settings := []string{`"ROBOTSTXT_OBEY": False`}
+	if p.PageLimit > 0 {
+		settings = append(settings, fmt.Sprintf(`"CLOSESPIDER_ITEMCOUNT": %d`, p.PageLimit))
+	}
+	if p.MaxDepth > 0 {
+		settings = append(settings, fmt.Sprintf(`"DEPTH_LIMIT": %d`, p.MaxDepth))
+	}
+	if p.WaitForNetworkIdle || len(p.RemoveSelectors) > 0 {
+		notes = append(notes, "Scrapy does not render JavaScript; --wait-for-network-idle and --dom-rule are not exported")
+	}
+	sort.Strings(settings)
+
+	var b strings.Builder
+	b.WriteString("# Generated by `sitepanda export-config --format scrapy`.\n")
+	b.WriteString("# Run with: scrapy runspider sitepanda_spider.py -O pages.jsonl\n")
+	b.WriteString("import re\nfrom urllib.parse import urlparse\n\n")
+	b.WriteString("from scrapy.linkextractors import LinkExtractor\nfrom scrapy.spiders import CrawlSpider, Rule\n\n\n")
+	b.WriteString("class SitepandaSpider(CrawlSpider):\n")
+	b.WriteString("    name = \"sitepanda\"\n")
+	fmt.Fprintf(&b, "    allowed_domains = %s\n", pyList([]string{start.Hostname()}))
+	fmt.Fprintf(&b, "    start_urls = %s\n", pyList([]string{p.StartURL}))
+	fmt.Fprintf(&b, "    custom_settings = {%s}\n", strings.Join(settings, ", "))
+	fmt.Fprintf(&b, "    match_patterns = [re.compile(p) for p in %s]\n", pyList(matchRes))
+	fmt.Fprintf(&b, "    exclude_patterns = [re.compile(p) for p in %s]\n", pyList(excludeMatchRes))
+	fmt.Fprintf(&b, "    content_selector = %s\n", pyStr(p.ContentSelector))
+	fmt.Fprintf(&b, "    rules = (Rule(LinkExtractor(allow=%s, deny=%s), callback=\"parse_item\", follow=True),)\n\n", pyList(allow), pyList(deny))
+	b.WriteString("    def parse_start_url(self, response):\n")
+	b.WriteString("        return self.parse_item(response)\n\n")
+	b.WriteString("    def parse_item(self, response):\n")
+	b.WriteString("        path = urlparse(response.url).path or \"/\"\n")
+	b.WriteString("        if self.match_patterns and not any(p.search(path) for p in self.match_patterns):\n")
+	b.WriteString("            return\n")
+	b.WriteString("        if any(p.search(path) for p in self.exclude_patterns):\n")
+	b.WriteString("            return\n")
+	b.WriteString("        content = response.css(self.content_selector) if self.content_selector else response.css(\"body\")\n")
+	b.WriteString("        yield {\n")
+	b.WriteString("            \"title\": response.css(\"title::text\").get(default=\"\").strip(),\n")
+	b.WriteString("            \"url\": response.url,\n")
+	b.WriteString("            \"content\": \"\".join(content[:1].getall()),\n")
+	b.WriteString("        }\n")
+	return b.String(), notes, nil
+}
+
+// HandleExportConfig prints the scrape configuration given on the command line as a config for another crawler.
+func HandleExportConfig(startURL string) {
+	profile := crawlProfile{
+		StartURL:              startURL,
+		MatchPatterns:         cmd.GetMatchPatterns(),
+		FollowMatchPatterns:   cmd.GetFollowMatchPatterns(),
+		ExcludeMatchPatterns:  cmd.GetExcludeMatch(),
+		ExcludeFollowPatterns: cmd.GetExcludeFollow(),
+		PageLimit:             cmd.GetPageLimit(),
+		MaxDepth:              cmd.GetMaxDepth(),
+		ContentSelector:       cmd.GetContentSelector(),
+		WaitForNetworkIdle:    cmd.GetWaitForNetworkIdle(),
+	}
+	rules, err := parseDOMRules(cmd.GetDOMRules())
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	for _, rule := range rules {
+		if rule.Action == "remove" {
+			profile.RemoveSelectors = append(profile.RemoveSelectors, rule.Selector)
+		}
+	}
+
+	var output string
+	var notes []string
+	switch cmd.GetExportConfigFormat() {
+	case "firecrawl":
+		var opts firecrawlCrawlOptions
+		opts, notes, err = exportFirecrawl(profile)
+		if err == nil {
+			var data []byte
+			data, err = json.MarshalIndent(opts, "", "  ")
+			output = string(data) + "\n"
+		}
+	case "scrapy":
+		output, notes, err = exportScrapy(profile)
+	}
+	if err != nil {
+		logger.Fatalf("Error: Failed to export config: %v", err)
+	}
+	for _, note := range notes {
+		logger.Printf("Note: %s", note)
+	}
+	fmt.Print(output)
+}
+
+// HandleImportConfig reads another crawler's config and prints the equivalent sitepanda command line.
+func HandleImportConfig(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Fatalf("Error: Failed to read %s: %v", path, err)
+	}
+	var opts firecrawlCrawlOptions
+	if err := json.Unmarshal(data, &opts); err != nil {
+		logger.Fatalf("Error: Invalid Firecrawl crawl options in %s: %v", path, err)
+	}
+	profile, notes, err := importFirecrawl(opts)
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	for _, note := range notes {
+		logger.Printf("Note: %s", note)
+	}
+	fmt.Println(scrapeCommandLine(profile))
+}
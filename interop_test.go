@@ -0,0 +1,179 @@
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gobwas/glob"
+)
+
+func TestGlobToRegexpMatchesGlob(t *testing.T) {
+	patterns := []string{"/blog/**", "/docs/*", "/docs/*/intro", "/p?ge", "/{en,ja}/guide/**", "/v[0-9]/*", "/v[!0-9]/*", "/file.html"}
+	paths := []string{"/", "/blog", "/blog/", "/blog/a/b", "/docs/a", "/docs/a/b", "/docs/x/intro", "/page", "/pages", "/en/guide/x", "/fr/guide/x", "/v1/x", "/vx/x", "/file.html", "/fileXhtml"}
+
+	for _, pattern := range patterns {
+		g := glob.MustCompile(pattern, '/')
+		re, err := globToRegexp(pattern)
+		if err != nil {
+			t.Fatalf("globToRegexp(%q) error = %v", pattern, err)
+		}
+		compiled := regexp.MustCompile(re)
+		for _, path := range paths {
+			if got, want := compiled.MatchString(path), g.Match(path); got != want {
+				t.Errorf("globToRegexp(%q) = %q matches %q: %v, glob: %v", pattern, re, path, got, want)
+			}
+		}
+	}
+
+	for _, bad := range []string{"/{a,{b}}", "/a}", "/[abc", "/{a"} {
+		if _, err := globToRegexp(bad); err == nil {
+			t.Errorf("globToRegexp(%q) expected error", bad)
+		}
+	}
+}
+
+func TestSimpleRegexpToGlob(t *testing.T) {
+	tests := []struct {
+		re     string
+		want   string
+		wantOK bool
+	}{
+		{re: "blog/.*", want: "/blog/**", wantOK: true},
+		{re: "^/docs/[^/]+$", want: "/docs/*", wantOK: true},
+		{re: `^/api/v1\.0/.*$`, want: "/api/v1.0/**", wantOK: true},
+		{re: "^/about$", want: "/about", wantOK: true},
+		{re: "^/(en|ja)/.*", wantOK: false},
+		{re: "^/page[0-9]+$", wantOK: false},
+	}
+	for _, tt := range tests {
+		got, ok := simpleRegexpToGlob(tt.re)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("simpleRegexpToGlob(%q) = %q, %v; want %q, %v", tt.re, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestExportFirecrawl(t *testing.T) {
+	profile := crawlProfile{
+		StartURL:              "https://example.com/docs/",
+		MatchPatterns:         []string{"/docs/guide/**"},
+		FollowMatchPatterns:   []string{"/docs/**"},
+		ExcludeFollowPatterns: []string{"/docs/print/**"},
+		PageLimit:             50,
+		ContentSelector:       "main article",
+		RemoveSelectors:       []string{"nav", ".ads"},
+	}
+	opts, notes, err := exportFirecrawl(profile)
+	if err != nil {
+		t.Fatalf("exportFirecrawl() error = %v", err)
+	}
+	want := firecrawlCrawlOptions{
+		URL:          "https://example.com/docs/",
+		IncludePaths: []string{`^/docs/.*$`},
+		ExcludePaths: []string{`^/docs/print/.*$`},
+		Limit:        50,
+		ScrapeOptions: &firecrawlScrapeOptions{
+			Formats:         []string{"markdown"},
+			OnlyMainContent: true,
+			IncludeTags:     []string{"main article"},
+			ExcludeTags:     []string{"nav", ".ads"},
+		},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("exportFirecrawl() = %+v, want %+v", opts, want)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "--match") {
+		t.Errorf("exportFirecrawl() notes = %v, want one note about --match", notes)
+	}
+}
+
+func TestImportFirecrawl(t *testing.T) {
+	opts := firecrawlCrawlOptions{
+		URL:          "https://example.com/",
+		IncludePaths: []string{"blog/.*", "^/(a|b)$"},
+		ExcludePaths: []string{"blog/private/.*"},
+		Limit:        10,
+		ScrapeOptions: &firecrawlScrapeOptions{
+			IncludeTags: []string{"article", ".post"},
+			ExcludeTags: []string{"footer"},
+			WaitFor:     1000,
+		},
+	}
+	profile, notes, err := importFirecrawl(opts)
+	if err != nil {
+		t.Fatalf("importFirecrawl() error = %v", err)
+	}
+	want := crawlProfile{
+		StartURL:              "https://example.com/",
+		MatchPatterns:         []string{"/blog/**"},
+		FollowMatchPatterns:   []string{"/blog/**"},
+		ExcludeMatchPatterns:  []string{"/blog/private/**"},
+		ExcludeFollowPatterns: []string{"/blog/private/**"},
+		PageLimit:             10,
+		ContentSelector:       "article, .post",
+		WaitForNetworkIdle:    true,
+		RemoveSelectors:       []string{"footer"},
+	}
+	if !reflect.DeepEqual(profile, want) {
+		t.Errorf("importFirecrawl() = %+v, want %+v", profile, want)
+	}
+	if len(notes) != 2 {
+		t.Errorf("importFirecrawl() notes = %v, want 2 (regex, waitFor)", notes)
+	}
+
+	wantCmd := `sitepanda scrape --match '/blog/**' --follow-match '/blog/**' --exclude-match '/blog/private/**' --exclude-follow '/blog/private/**' --limit 10 --content-selector 'article, .post' --dom-rule remove:footer --wait-for-network-idle https://example.com/`
+	if got := scrapeCommandLine(profile); got != wantCmd {
+		t.Errorf("scrapeCommandLine() = %q, want %q", got, wantCmd)
+	}
+
+	if _, _, err := importFirecrawl(firecrawlCrawlOptions{}); err == nil {
+		t.Errorf("importFirecrawl() without url expected error")
+	}
+}
+
+func TestExportScrapy(t *testing.T) {
+	profile := crawlProfile{
+		StartURL:              "https://example.com/blog/",
+		MatchPatterns:         []string{"/blog/*"},
+		FollowMatchPatterns:   []string{"/blog/**"},
+		ExcludeMatchPatterns:  []string{"/blog/tag/**"},
+		ExcludeFollowPatterns: []string{"/blog/print/**"},
+		PageLimit:             20,
+		MaxDepth:              3,
+		ContentSelector:       "article",
+	}
+	spider, _, err := exportScrapy(profile)
+	if err != nil {
+		t.Fatalf("exportScrapy() error = %v", err)
+	}
+	for _, want := range []string{
+		`allowed_domains = ["example.com"]`,
+		`start_urls = ["https://example.com/blog/"]`,
+		`"CLOSESPIDER_ITEMCOUNT": 20`,
+		`"DEPTH_LIMIT": 3`,
+		`match_patterns = [re.compile(p) for p in ["^/blog/[^/]*$"]]`,
+		`LinkExtractor(allow=["^https?://[^/]+/blog/.*$"], deny=["^https?://[^/]+/blog/print/.*$"])`,
+		`exclude_patterns = [re.compile(p) for p in ["^/blog/tag/.*$"]]`,
+		`content_selector = "article"`,
+	} {
+		if !strings.Contains(spider, want) {
+			t.Errorf("exportScrapy() output missing %q:\n%s", want, spider)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/": "https://example.com/",
+		"/blog/**":             "'/blog/**'",
+		"it's":                 `'it'\''s'`,
+		"":                     "''",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// labelNamePattern restricts --label names to characters that are safe in every output format.
+var labelNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// urlLabel is one --label rule: pages whose path matches the glob get the label.
+type urlLabel struct {
+	name string
+	glob glob.Glob
+}
+
+// urlLabels are the --label rules in the order given. A nil list labels nothing.
+type urlLabels []urlLabel
+
+// parseLabels parses --label name=glob rules. The glob is matched against the URL path, as in
+// --match; a name may be given several times to cover several globs.
+func parseLabels(specs []string) (urlLabels, error) {
+	var labels urlLabels
+	for _, spec := range specs {
+		name, pattern, ok := strings.Cut(spec, "=")
+		name, pattern = strings.TrimSpace(name), strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("invalid --label %q (expected name=glob)", spec)
+		}
+		if !labelNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid --label name %q (use letters, digits, '_', '.' and '-')", name)
+		}
+		g, err := glob.Compile(pattern, '/')
+		if err != nil {
+			return nil, fmt.Errorf("invalid --label pattern %q: %w", pattern, err)
+		}
+		labels = append(labels, urlLabel{name: name, glob: g})
+	}
+	return labels, nil
+}
+
+// Match returns the names of the labels whose glob matches the path of u, each once, in the
+// order the labels were given.
+func (l urlLabels) Match(u *url.URL) []string {
+	path := u.Path
+	if path == "" {
+		path = "/"
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	var names []string
+	for _, label := range l {
+		if label.glob.Match(path) && !slices.Contains(names, label.name) {
+			names = append(names, label.name)
+		}
+	}
+	return names
+}
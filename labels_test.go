@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := parseLabels([]string{"docs=/docs/**", "blog=/blog/**", "docs=/guide/**", "api = /docs/api/**"})
+	if err != nil {
+		t.Fatalf("parseLabels: %v", err)
+	}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/docs/api/users", "docs api"},
+		{"https://example.com/guide/start", "docs"},
+		{"https://example.com/blog/2024/post", "blog"},
+		{"https://example.com/about", ""},
+		{"https://example.com", ""},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := strings.Join(labels.Match(u), " "); got != tt.want {
+			t.Errorf("Match(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"docs", "docs=", "=/docs/**", "my docs=/docs/**", "docs=/docs/["} {
+		if _, err := parseLabels([]string{bad}); err == nil {
+			t.Errorf("parseLabels(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestLabelsInOutputs(t *testing.T) {
+	pd := PageData{Title: "T", URL: "https://example.com/docs/", Markdown: "x", Labels: []string{"docs", "api"}}
+	data, _ := json.Marshal(newJSONOutputPage(&pd))
+	if !strings.Contains(string(data), `"labels":["docs","api"]`) {
+		t.Errorf("JSON output = %s, want labels", data)
+	}
+	if got := formatPageDataAsXML(&pd); !strings.Contains(got, "  <labels>docs, api</labels>\n") {
+		t.Errorf("formatPageDataAsXML() = %q, want a <labels> element", got)
+	}
+	if got := formatPageDataAsMarkdownFile(&pd); !strings.Contains(got, "\nlabels: [\"docs\",\"api\"]\n") {
+		t.Errorf("formatPageDataAsMarkdownFile() = %q, want labels front matter", got)
+	}
+}
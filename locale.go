@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// localeSegmentPattern matches a path segment such as "ja", "pt-br", "zh_Hant" or "es-419".
+var localeSegmentPattern = regexp.MustCompile(`^(?i)([a-z]{2})(?:[-_]([a-z]{2}|[0-9]{3}|hans|hant))?$`)
+
+// localeLanguages are the languages whose codes are taken as locale prefixes. Two-letter codes
+// that are also common path words ("is", "to", "my", "am", "so", ...) are left out.
+var localeLanguages = map[string]bool{
+	"ar": true, "bg": true, "bn": true, "ca": true, "cs": true, "da": true, "de": true, "el": true,
+	"en": true, "es": true, "et": true, "fa": true, "fi": true, "fr": true, "he": true, "hi": true,
+	"hr": true, "hu": true, "id": true, "it": true, "ja": true, "ko": true, "lt": true, "lv": true,
+	"ms": true, "nb": true, "nl": true, "no": true, "pl": true, "pt": true, "ro": true, "ru": true,
+	"sk": true, "sl": true, "sr": true, "sv": true, "ta": true, "th": true, "tr": true, "uk": true,
+	"ur": true, "vi": true, "zh": true,
+}
+
+// localeTag returns the language and lower-cased tag of a locale path segment, e.g. "pt" and
+// "pt-br" for "pt_BR", or ok false if segment is not one.
+func localeTag(segment string) (lang string, tag string, ok bool) {
+	m := localeSegmentPattern.FindStringSubmatch(segment)
+	if m == nil || !localeLanguages[strings.ToLower(m[1])] {
+		return "", "", false
+	}
+	lang = strings.ToLower(m[1])
+	tag = lang
+	if m[2] != "" {
+		tag += "-" + strings.ToLower(m[2])
+	}
+	return lang, tag, true
+}
+
+// firstPathSegment splits path into its first segment and the rest, which keeps its leading slash.
+func firstPathSegment(path string) (string, string) {
+	trimmed := strings.TrimPrefix(path, "/")
+	segment, rest, found := strings.Cut(trimmed, "/")
+	if found {
+		rest = "/" + rest
+	}
+	return segment, rest
+}
+
+// localeCollapser maps links to other translations of a site onto the preferred one
+// (--collapse-locales), so each page is crawled in one language. A nil *localeCollapser
+// leaves links alone.
+type localeCollapser struct {
+	// lang and tag are the preferred locale; a tag without a region also accepts regional variants.
+	lang string
+	tag  string
+	// prefix replaces another locale's path segment; "" removes it, for sites that serve
+	// their default language without a prefix.
+	prefix string
+}
+
+// newLocaleCollapser returns the collapser for a crawl from startURL. preferredLang defaults
+// to the start URL's locale, then "en". Links are mapped onto the start URL's form: a
+// preferred-locale prefix if it has a locale prefix, none if it does not.
+func newLocaleCollapser(startURL *url.URL, preferredLang string) (*localeCollapser, error) {
+	startSegment, _ := firstPathSegment(startURL.Path)
+	startLang, startTag, startPrefixed := localeTag(startSegment)
+	l := &localeCollapser{lang: startLang, tag: startTag}
+	if preferredLang = strings.TrimSpace(preferredLang); preferredLang != "" {
+		var ok bool
+		if l.lang, l.tag, ok = localeTag(preferredLang); !ok {
+			return nil, fmt.Errorf("invalid --preferred-lang %q: want a language code such as en, ja or pt-br", preferredLang)
+		}
+	} else if !startPrefixed {
+		l.lang, l.tag = "en", "en"
+	}
+	if startPrefixed {
+		l.prefix = preferredLang
+		if l.matches(startLang, startTag) {
+			// Keep the site's own spelling, e.g. en-US.
+			l.prefix = startSegment
+		}
+	}
+	return l, nil
+}
+
+// matches reports whether a locale is the preferred one.
+func (l *localeCollapser) matches(lang string, tag string) bool {
+	return tag == l.tag || (l.tag == l.lang && lang == l.lang)
+}
+
+// collapse returns link with another locale's path prefix replaced by the preferred one.
+func (l *localeCollapser) collapse(link string) string {
+	if l == nil {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	segment, rest := firstPathSegment(u.Path)
+	lang, tag, ok := localeTag(segment)
+	if !ok || l.matches(lang, tag) {
+		return link
+	}
+	if l.prefix == "" {
+		u.Path = rest
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	} else {
+		u.Path = "/" + l.prefix + rest
+	}
+	u.RawPath = ""
+	return u.String()
+}
+
+// collapseLocale applies --collapse-locales to a normalized link URL.
+func (c *Crawler) collapseLocale(normalized string) string {
+	collapsed := c.opts.Locales.collapse(normalized)
+	if collapsed == normalized {
+		return normalized
+	}
+	if renormalized, err := normalizeURLtoString(collapsed); err == nil {
+		return renormalized
+	}
+	return collapsed
+}
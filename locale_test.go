@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestLocaleTag(t *testing.T) {
+	tests := []struct {
+		segment  string
+		lang     string
+		tag      string
+		isLocale bool
+	}{
+		{"en", "en", "en", true},
+		{"ja", "ja", "ja", true},
+		{"pt_BR", "pt", "pt-br", true},
+		{"en-US", "en", "en-us", true},
+		{"es-419", "es", "es-419", true},
+		{"zh-Hant", "zh", "zh-hant", true},
+		{"docs", "", "", false},
+		{"is", "", "", false},
+		{"en-", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		lang, tag, ok := localeTag(tt.segment)
+		if lang != tt.lang || tag != tt.tag || ok != tt.isLocale {
+			t.Errorf("localeTag(%q) = %q, %q, %t; want %q, %q, %t", tt.segment, lang, tag, ok, tt.lang, tt.tag, tt.isLocale)
+		}
+	}
+}
+
+func TestLocaleCollapse(t *testing.T) {
+	tests := []struct {
+		name          string
+		start         string
+		preferredLang string
+		link          string
+		want          string
+	}{
+		{"other locale", "https://example.com/en/docs", "", "https://example.com/ja/docs/intro", "https://example.com/en/docs/intro"},
+		{"preferred locale", "https://example.com/en/", "", "https://example.com/en/guide", "https://example.com/en/guide"},
+		{"no locale", "https://example.com/en/", "", "https://example.com/blog/post", "https://example.com/blog/post"},
+		{"locale root", "https://example.com/en/", "", "https://example.com/fr", "https://example.com/en"},
+		{"regional variant kept", "https://example.com/en/", "", "https://example.com/en-gb/guide", "https://example.com/en-gb/guide"},
+		{"regional start", "https://example.com/en-US/", "", "https://example.com/de-DE/guide", "https://example.com/en-US/guide"},
+		{"unprefixed start", "https://example.com/", "", "https://example.com/ja/guide?x=1", "https://example.com/guide?x=1"},
+		{"unprefixed start root", "https://example.com/docs", "", "https://example.com/fr", "https://example.com/"},
+		{"explicit preference", "https://example.com/en/", "ja", "https://example.com/en/guide", "https://example.com/ja/guide"},
+		{"explicit preference kept", "https://example.com/en/", "ja", "https://example.com/ja/guide", "https://example.com/ja/guide"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, err := url.Parse(tt.start)
+			if err != nil {
+				t.Fatal(err)
+			}
+			l, err := newLocaleCollapser(start, tt.preferredLang)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.collapse(tt.link); got != tt.want {
+				t.Errorf("collapse(%q) = %q, want %q", tt.link, got, tt.want)
+			}
+		})
+	}
+	if _, err := newLocaleCollapser(&url.URL{Path: "/"}, "english"); err == nil {
+		t.Error("newLocaleCollapser accepted --preferred-lang english")
+	}
+	var none *localeCollapser
+	if got := none.collapse("https://example.com/ja/"); got != "https://example.com/ja/" {
+		t.Errorf("nil collapser changed the link to %q", got)
+	}
+}
+
+func TestCrawlCollapseLocales(t *testing.T) {
+	pages := map[string]string{
+		"en/index.html": `<html><head><title>Docs</title></head><body><h1>Docs</h1><p>All guides.</p><a href="/en/guide">Guide</a> <a href="/ja/">日本語</a> <a href="/fr/guide">Français</a></body></html>`,
+		"en/guide.html": `<html><head><title>Guide</title></head><body><h1>Guide</h1><p>Start with the basics.</p><a href="/ja/guide">日本語</a></body></html>`,
+		"ja/index.html": `<html><head><title>ドキュメント</title></head><body><h1>ドキュメント</h1><p>ガイド一覧。</p></body></html>`,
+		"ja/guide.html": `<html><head><title>ガイド</title></head><body><h1>ガイド</h1><p>基本から始めましょう。</p></body></html>`,
+	}
+	start, _ := url.Parse("https://example.com/en/")
+	locales, err := newLocaleCollapser(start, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler, _, _ := crawlFixtures(t, pages, fixtureCrawl{start: "https://example.com/en/", opts: CrawlOptions{Locales: locales}})
+	var got []string
+	for _, page := range crawler.results {
+		got = append(got, page.URL)
+	}
+	slices.Sort(got)
+	want := []string{"https://example.com/en", "https://example.com/en/guide"}
+	if !slices.Equal(got, want) {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+}
@@ -5,7 +5,21 @@ import "github.com/hokupod/sitepanda/cmd"
 func main() {
 	// Set the handlers for the cmd package
 	cmd.InitHandler = HandleInitCommand
+	cmd.VerifyHandler = HandleInitVerify
+	cmd.PruneHandler = HandlePrune
 	cmd.ScrapingHandler = HandleScraping
+	cmd.BenchHandler = HandleBench
+	cmd.BrowserDaemonHandler = HandleBrowserDaemon
+	cmd.ExportConfigHandler = HandleExportConfig
+	cmd.ImportConfigHandler = HandleImportConfig
+	cmd.HistoryHandler = HandleHistory
+	cmd.HistoryShowHandler = HandleHistoryShow
+	cmd.ProxyHandler = HandleProxy
+	cmd.CheckPolicyHandler = HandleCheckPolicy
+	cmd.UIHandler = HandleUI
+	cmd.PickHandler = HandlePick
+	cmd.PreviewHandler = HandlePreview
+	cmd.SelftestServerHandler = HandleSelftestServer
 	cmd.VersionFunc = func() string { return Version }
 
 	cmd.Execute()
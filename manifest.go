@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// manifestFormatVersion is the version of the --manifest file layout.
+const manifestFormatVersion = 1
+
+// runManifest is the --manifest file: what produced a run's outputs and their checksums, so a
+// dataset can be audited and reproduced.
+type runManifest struct {
+	ManifestVersion  int               `json:"manifest_version"`
+	SitepandaVersion string            `json:"sitepanda_version"`
+	Browser          string            `json:"browser"`
+	BrowserVersion   string            `json:"browser_version,omitempty"`
+	GeneratedAt      time.Time         `json:"generated_at"`
+	StartURL         string            `json:"start_url"`
+	Status           string            `json:"status"`
+	PagesSaved       int               `json:"pages_saved"`
+	Config           map[string]string `json:"config"`
+	Files            []manifestFile    `json:"files"`
+}
+
+// manifestFile is one output file. Path is relative to the manifest's directory.
+type manifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// hashFile returns the size and hex SHA-256 of the file at path.
+func hashFile(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// collectManifestFiles hashes files and every file under dirs, skipping the manifest itself and
+// files that do not exist. Paths are made relative to the manifest's directory and sorted.
+func collectManifestFiles(manifestPath string, files []string, dirs []string) ([]manifestFile, error) {
+	manifestAbs, _ := filepath.Abs(manifestPath)
+	baseDir := filepath.Dir(manifestAbs)
+	seen := make(map[string]bool)
+	var entries []manifestFile
+	add := func(path string) error {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if abs == manifestAbs || seen[abs] {
+			return nil
+		}
+		seen[abs] = true
+		size, sum, err := hashFile(abs)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to hash %s: %w", path, err)
+		}
+		rel, err := filepath.Rel(baseDir, abs)
+		if err != nil {
+			rel = abs
+		}
+		entries = append(entries, manifestFile{Path: filepath.ToSlash(rel), Size: size, SHA256: sum})
+		return nil
+	}
+
+	for _, path := range files {
+		if path == "" {
+			continue
+		}
+		if err := add(path); err != nil {
+			return nil, err
+		}
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			return add(path)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash files in %s: %w", dir, err)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// writeManifest writes m as indented JSON to path.
+func writeManifest(path string, m runManifest) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
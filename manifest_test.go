@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCollectManifestFiles(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("out.jsonl", "hello")
+	write("pages/index.md", "")
+	write("pages/docs/a.md", "a")
+	manifestPath := filepath.Join(root, "pages", "manifest.json")
+	write("pages/manifest.json", "{}") // left over from an earlier run
+
+	files, err := collectManifestFiles(manifestPath,
+		[]string{filepath.Join(root, "out.jsonl"), "", filepath.Join(root, "missing.json"), filepath.Join(root, "pages", "index.md")},
+		[]string{filepath.Join(root, "pages"), ""})
+	if err != nil {
+		t.Fatalf("collectManifestFiles() error = %v", err)
+	}
+	want := []manifestFile{
+		{Path: "../out.jsonl", Size: 5, SHA256: "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{Path: "docs/a.md", Size: 1, SHA256: "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb"},
+		{Path: "index.md", Size: 0, SHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("collectManifestFiles() = %+v, want %+v", files, want)
+	}
+}
+
+func TestWriteManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "manifest.json")
+	m := runManifest{
+		ManifestVersion:  manifestFormatVersion,
+		SitepandaVersion: "1.2.3",
+		Browser:          "chromium",
+		StartURL:         "https://example.com/",
+		Config:           map[string]string{"limit": "10"},
+		Files:            []manifestFile{{Path: "out.jsonl", Size: 1, SHA256: "ab"}},
+	}
+	if err := writeManifest(path, m); err != nil {
+		t.Fatalf("writeManifest() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got runManifest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("round-tripped manifest = %+v, want %+v", got, m)
+	}
+}
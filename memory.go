@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// readHeapBytes reports the live heap size. It is a variable to allow mocking in tests.
+var readHeapBytes = func() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// savedCount returns the number of saved pages, including those spilled to disk.
+func (c *Crawler) savedCount() int {
+	return c.spilledCount + len(c.results)
+}
+
+// setGCMemoryLimit sets the runtime's soft memory limit to limit and returns a function that
+// restores the previous one. The limit is process-wide, so a crawl must not leave it behind.
+func setGCMemoryLimit(limit int64) (restore func()) {
+	previous := debug.SetMemoryLimit(limit)
+	return func() { debug.SetMemoryLimit(previous) }
+}
+
+// enforceMemoryLimit checks the heap against --max-memory. When the limit is exceeded it
+// spills saved results to disk, releases memory and recycles w's browser page. It returns
+// false if usage is still above the limit afterwards and the crawl should stop.
+func (c *Crawler) enforceMemoryLimit(w *crawlWorker) bool {
+	limit := c.opts.MaxMemory
+	if limit <= 0 || readHeapBytes() <= uint64(limit) {
+		return true
+	}
+
+	logger.Printf("Memory usage (%s) exceeds --max-memory %s. Flushing %d results to disk and recycling the browser page...",
+		formatByteSize(int64(readHeapBytes())), formatByteSize(limit), len(c.results))
+	if err := c.spillResults(); err != nil {
+		logger.Printf("Warning: failed to flush results to disk: %v", err)
+	}
+	if err := c.recyclePage(w); err != nil {
+		logger.Printf("Warning: failed to recycle browser page: %v", err)
+	}
+	runtime.GC()
+	debug.FreeOSMemory()
+
+	usage := readHeapBytes()
+	if usage > uint64(limit) {
+		logger.Printf("Memory usage (%s) is still above --max-memory %s after cleanup.", formatByteSize(int64(usage)), formatByteSize(limit))
+		return false
+	}
+	logger.Printf("Memory usage back to %s after cleanup.", formatByteSize(int64(usage)))
+	return true
+}
+
+// spillResults appends the in-memory results to a temporary JSONL spill file and clears them.
+func (c *Crawler) spillResults() error {
+	if len(c.results) == 0 {
+		return nil
+	}
+	if c.spillFile == nil {
+		f, err := os.CreateTemp("", "sitepanda-spill-*.jsonl")
+		if err != nil {
+			return fmt.Errorf("failed to create spill file: %w", err)
+		}
+		c.spillFile = f
+		logger.Printf("Spilling results to %s", f.Name())
+	}
+
+	w := bufio.NewWriter(c.spillFile)
+	enc := json.NewEncoder(w)
+	for i := range c.results {
+		if err := enc.Encode(&c.results[i]); err != nil {
+			return fmt.Errorf("failed to write result for %s to spill file: %w", c.results[i].URL, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush spill file: %w", err)
+	}
+	c.spilledCount += len(c.results)
+	c.results = make([]PageData, 0)
+	return nil
+}
+
+// restoreSpilledResults reads spilled results back in front of the in-memory ones
+// and removes the spill file.
+func (c *Crawler) restoreSpilledResults() error {
+	if c.spillFile == nil {
+		return nil
+	}
+	defer func() {
+		name := c.spillFile.Name()
+		_ = c.spillFile.Close()
+		_ = os.Remove(name)
+		c.spillFile = nil
+		c.spilledCount = 0
+	}()
+
+	if _, err := c.spillFile.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind spill file: %w", err)
+	}
+	restored := make([]PageData, 0, c.spilledCount+len(c.results))
+	dec := json.NewDecoder(bufio.NewReader(c.spillFile))
+	for {
+		var pd PageData
+		if err := dec.Decode(&pd); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("failed to read spill file: %w", err)
+		}
+		restored = append(restored, pd)
+	}
+	c.results = append(restored, c.results...)
+	return nil
+}
+
+// recyclePage closes w's browser page and opens a fresh one in the same context, releasing
+// memory the browser accumulated for it.
+func (c *Crawler) recyclePage(w *crawlWorker) error {
+	if c.pwContext == nil || w.page == nil {
+		return nil
+	}
+	if !w.page.IsClosed() {
+		if err := w.page.Close(); err != nil {
+			logger.Printf("Warning: failed to close page during recycle: %v", err)
+		}
+	}
+	newPage, err := c.pwContext.NewPage()
+	if err != nil {
+		return fmt.Errorf("failed to open replacement page: %w", err)
+	}
+	if _, err := newPage.Goto("about:blank", playwright.PageGotoOptions{Timeout: playwright.Float(15000)}); err != nil {
+		return fmt.Errorf("replacement page failed to load about:blank: %w", err)
+	}
+	w.page = newPage
+	logger.Println("Recycled browser page.")
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"runtime/debug"
+	"testing"
+)
+
+func TestSpillAndRestoreResults(t *testing.T) {
+	c := &Crawler{
+		results: []PageData{
+			{Title: "A", URL: "http://example.com/a", Markdown: "Content A", RawHTML: "<p>A</p>"},
+			{Title: "B", URL: "http://example.com/b", Markdown: "Content B"},
+		},
+	}
+
+	if err := c.spillResults(); err != nil {
+		t.Fatalf("spillResults() error = %v", err)
+	}
+	if len(c.results) != 0 {
+		t.Errorf("Expected in-memory results to be cleared, got %d", len(c.results))
+	}
+	if c.savedCount() != 2 {
+		t.Errorf("Expected savedCount() to include spilled pages, got %d", c.savedCount())
+	}
+	spillPath := c.spillFile.Name()
+
+	c.results = append(c.results, PageData{Title: "C", URL: "http://example.com/c", Markdown: "Content C"})
+	if c.savedCount() != 3 {
+		t.Errorf("Expected savedCount() = 3, got %d", c.savedCount())
+	}
+
+	if err := c.restoreSpilledResults(); err != nil {
+		t.Fatalf("restoreSpilledResults() error = %v", err)
+	}
+	if len(c.results) != 3 {
+		t.Fatalf("Expected 3 restored results, got %d", len(c.results))
+	}
+	wantOrder := []string{"A", "B", "C"}
+	for i, title := range wantOrder {
+		if c.results[i].Title != title {
+			t.Errorf("results[%d].Title = %q, want %q", i, c.results[i].Title, title)
+		}
+	}
+	if c.results[0].RawHTML != "<p>A</p>" {
+		t.Errorf("Expected RawHTML to survive the round trip, got %q", c.results[0].RawHTML)
+	}
+	if _, err := os.Stat(spillPath); !os.IsNotExist(err) {
+		t.Errorf("Expected spill file %s to be removed, stat error = %v", spillPath, err)
+	}
+}
+
+func TestEnforceMemoryLimit(t *testing.T) {
+	originalReadHeapBytes := readHeapBytes
+	defer func() { readHeapBytes = originalReadHeapBytes }()
+
+	t.Run("Disabled guard never stops", func(t *testing.T) {
+		readHeapBytes = func() uint64 { return 1 << 40 }
+		c := &Crawler{}
+		if !c.enforceMemoryLimit(&crawlWorker{}) {
+			t.Error("Expected enforceMemoryLimit() to return true when MaxMemory is 0")
+		}
+	})
+
+	t.Run("Under budget keeps results in memory", func(t *testing.T) {
+		readHeapBytes = func() uint64 { return 1 << 20 }
+		c := &Crawler{opts: CrawlOptions{MaxMemory: 2 << 20}, results: []PageData{{URL: "http://example.com/"}}}
+		if !c.enforceMemoryLimit(&crawlWorker{}) {
+			t.Error("Expected enforceMemoryLimit() to return true under budget")
+		}
+		if len(c.results) != 1 || c.spillFile != nil {
+			t.Error("Expected results to stay in memory under budget")
+		}
+	})
+
+	t.Run("Over budget flushes and recovers", func(t *testing.T) {
+		calls := 0
+		readHeapBytes = func() uint64 {
+			calls++
+			if calls <= 2 {
+				return 3 << 20
+			}
+			return 1 << 20
+		}
+		c := &Crawler{opts: CrawlOptions{MaxMemory: 2 << 20}, results: []PageData{{URL: "http://example.com/"}}}
+		defer c.restoreSpilledResults()
+		if !c.enforceMemoryLimit(&crawlWorker{}) {
+			t.Error("Expected enforceMemoryLimit() to recover after flushing")
+		}
+		if len(c.results) != 0 || c.spilledCount != 1 {
+			t.Errorf("Expected results to be flushed to disk, in-memory=%d spilled=%d", len(c.results), c.spilledCount)
+		}
+	})
+
+	t.Run("Still over budget requests stop", func(t *testing.T) {
+		readHeapBytes = func() uint64 { return 3 << 20 }
+		c := &Crawler{opts: CrawlOptions{MaxMemory: 2 << 20}}
+		if c.enforceMemoryLimit(&crawlWorker{}) {
+			t.Error("Expected enforceMemoryLimit() to return false when cleanup does not help")
+		}
+	})
+}
+
+func TestSetGCMemoryLimitRestores(t *testing.T) {
+	previous := debug.SetMemoryLimit(-1)
+	restore := setGCMemoryLimit(1 << 30)
+	if got := debug.SetMemoryLimit(-1); got != 1<<30 {
+		t.Errorf("memory limit = %d, want %d", got, 1<<30)
+	}
+	restore()
+	if got := debug.SetMemoryLimit(-1); got != previous {
+		t.Errorf("memory limit after restore = %d, want %d", got, previous)
+	}
+}
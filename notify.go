@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// defaultNotifyTemplate is the message sent when neither --notify-template nor the config file
+// sets one.
+const defaultNotifyTemplate = `sitepanda crawl of {{.StartURL}}{{if .JobName}} ({{.JobName}}){{end}} failed: {{.Reason}}. Pages saved: {{.PagesSaved}}, skipped: {{.PagesSkipped}}.`
+
+// defaultNotifySubject is the subject of notification emails when the config file sets none.
+const defaultNotifySubject = `sitepanda: crawl of {{.StartURL}} failed`
+
+// discordMaxMessageLength is the longest message content Discord webhooks accept.
+const discordMaxMessageLength = 2000
+
+// notificationData is the data available to --notify-template.
+type notificationData struct {
+	StartURL     string
+	JobName      string
+	Reason       string
+	StopReason   string
+	PagesSaved   int
+	PagesSkipped int
+	Time         time.Time
+}
+
+// configFile is a --config file. Its sections configure features that need more than a
+// flag can hold; unknown keys are rejected.
+type configFile struct {
+	Notify notifyConfig `yaml:"notify"`
+}
+
+// notifyConfig is the notify section of a --config file. Each destination may set its own
+// message template; Template is the message of those that do not.
+type notifyConfig struct {
+	Template string              `yaml:"template"`
+	Slack    webhookNotifyConfig `yaml:"slack"`
+	Discord  webhookNotifyConfig `yaml:"discord"`
+	Email    emailNotifyConfig   `yaml:"email"`
+}
+
+// webhookNotifyConfig configures a Slack or Discord webhook; an empty Webhook disables it.
+type webhookNotifyConfig struct {
+	Webhook  string `yaml:"webhook"`
+	Template string `yaml:"template"`
+}
+
+// emailNotifyConfig configures notification emails; an empty To disables them.
+type emailNotifyConfig struct {
+	To       []string   `yaml:"to"`
+	Subject  string     `yaml:"subject"`
+	Template string     `yaml:"template"`
+	SMTP     smtpConfig `yaml:"smtp"`
+}
+
+// smtpConfig is the server notification emails are sent through.
+type smtpConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+// loadConfigFile reads and parses a --config file.
+func loadConfigFile(path string) (configFile, error) {
+	var cfg configFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// notification is a notifier together with the templates of the messages it is sent.
+type notification struct {
+	notifier notifier
+	message  *template.Template
+	subject  *template.Template
+}
+
+// notifications returns the notifications cfg enables, with their templates parsed.
+func (cfg notifyConfig) notifications() ([]notification, error) {
+	var notifications []notification
+	add := func(n notifier, message, subject string) error {
+		messageTmpl, err := parseNotifyTemplate(cmp.Or(message, cfg.Template))
+		if err != nil {
+			return fmt.Errorf("%s notification: %w", n.Name(), err)
+		}
+		subjectTmpl, err := parseNotifySubject(subject)
+		if err != nil {
+			return fmt.Errorf("%s notification: %w", n.Name(), err)
+		}
+		notifications = append(notifications, notification{notifier: n, message: messageTmpl, subject: subjectTmpl})
+		return nil
+	}
+	if cfg.Slack.Webhook != "" {
+		if err := add(slackNotifier{WebhookURL: cfg.Slack.Webhook}, cfg.Slack.Template, ""); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Discord.Webhook != "" {
+		if err := add(discordNotifier{WebhookURL: cfg.Discord.Webhook}, cfg.Discord.Template, ""); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.Email.To) > 0 {
+		server := cfg.Email.SMTP
+		if server.Host == "" || server.From == "" {
+			return nil, errors.New("email notifications require an SMTP host and sender (--smtp-host and --smtp-from, or smtp host and from in the config file)")
+		}
+		n := emailNotifier{Host: server.Host, Port: cmp.Or(server.Port, 587), Username: server.Username, Password: server.Password, From: server.From, To: cfg.Email.To}
+		if err := add(n, cfg.Email.Template, cfg.Email.Subject); err != nil {
+			return nil, err
+		}
+	}
+	return notifications, nil
+}
+
+// notifier delivers a crawl notification to one destination.
+type notifier interface {
+	Name() string
+	Notify(ctx context.Context, subject, message string) error
+}
+
+// slackNotifier posts to a Slack incoming webhook.
+type slackNotifier struct {
+	WebhookURL string
+}
+
+func (n slackNotifier) Name() string { return "Slack" }
+
+func (n slackNotifier) Notify(ctx context.Context, _ string, message string) error {
+	return postWebhookJSON(ctx, n.WebhookURL, map[string]string{"text": message})
+}
+
+// discordNotifier posts to a Discord webhook.
+type discordNotifier struct {
+	WebhookURL string
+}
+
+func (n discordNotifier) Name() string { return "Discord" }
+
+func (n discordNotifier) Notify(ctx context.Context, _ string, message string) error {
+	if len([]rune(message)) > discordMaxMessageLength {
+		message = string([]rune(message)[:discordMaxMessageLength-1]) + "…"
+	}
+	return postWebhookJSON(ctx, n.WebhookURL, map[string]string{"content": message})
+}
+
+// emailNotifier sends a plain-text email over SMTP, authenticating with PLAIN auth if a username is set.
+type emailNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (n emailNotifier) Name() string { return "email" }
+
+func (n emailNotifier) Notify(_ context.Context, subject, message string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	if err := smtp.SendMail(addr, auth, n.From, n.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", addr, err)
+	}
+	return nil
+}
+
+func postWebhookJSON(ctx context.Context, webhookURL string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := httpFetchClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// parseNotifyTemplate parses a message template, falling back to defaultNotifyTemplate.
+func parseNotifyTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("notification").Option("missingkey=error").Parse(cmp.Or(text, defaultNotifyTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// parseNotifySubject parses an email subject template, falling back to defaultNotifySubject.
+func parseNotifySubject(text string) (*template.Template, error) {
+	tmpl, err := template.New("subject").Option("missingkey=error").Parse(cmp.Or(text, defaultNotifySubject))
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification subject: %w", err)
+	}
+	return tmpl, nil
+}
+
+// crawlFailureReason returns why a crawl counts as failed for notifications, or "" if it succeeded.
+// Cancellation by the user and page or memory limits are not failures.
+func crawlFailureReason(result CrawlResult, crawlErr error) string {
+	switch {
+	case crawlErr != nil:
+		return crawlErr.Error()
+	case result.StopReason == "Browser connection lost" || result.StopReason == "Critical fetch error" || result.StopReason == "Failed to start":
+		return result.StopReason
+	case result.OutputFileError != nil:
+		return fmt.Sprintf("failed to write %s: %v", result.OutputFile, result.OutputFileError)
+	case result.OutputDirError != nil:
+		return fmt.Sprintf("failed to write %s: %v", result.OutputDir, result.OutputDirError)
+	case result.TOCFileError != nil:
+		return fmt.Sprintf("failed to write %s: %v", result.TOCFile, result.TOCFileError)
+	}
+	return ""
+}
+
+// sendNotifications renders each notification's messages and delivers them, logging failures.
+func sendNotifications(notifications []notification, data notificationData) {
+	for _, n := range notifications {
+		var message, subject strings.Builder
+		if err := n.message.Execute(&message, data); err != nil {
+			logger.Printf("Warning: failed to render %s notification template: %v", n.notifier.Name(), err)
+			continue
+		}
+		if err := n.subject.Execute(&subject, data); err != nil {
+			logger.Printf("Warning: failed to render %s notification subject: %v", n.notifier.Name(), err)
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		if err := n.notifier.Notify(ctx, subject.String(), message.String()); err != nil {
+			logger.Printf("Warning: failed to send %s notification: %v", n.notifier.Name(), err)
+		} else {
+			logger.Printf("Sent %s notification.", n.notifier.Name())
+		}
+		cancel()
+	}
+}
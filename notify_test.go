@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifiers(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		got = nil
+		json.NewDecoder(r.Body).Decode(&got)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := (slackNotifier{WebhookURL: server.URL}).Notify(context.Background(), "s", "hello"); err != nil {
+		t.Fatalf("slackNotifier.Notify() error = %v", err)
+	}
+	if got["text"] != "hello" {
+		t.Errorf("Slack payload = %v, want text=hello", got)
+	}
+
+	long := strings.Repeat("x", discordMaxMessageLength+10)
+	if err := (discordNotifier{WebhookURL: server.URL}).Notify(context.Background(), "s", long); err != nil {
+		t.Fatalf("discordNotifier.Notify() error = %v", err)
+	}
+	if n := len([]rune(got["content"])); n != discordMaxMessageLength {
+		t.Errorf("Discord content length = %d, want %d", n, discordMaxMessageLength)
+	}
+
+	if err := (slackNotifier{WebhookURL: server.URL + "/fail"}).Notify(context.Background(), "s", "hello"); err == nil {
+		t.Errorf("slackNotifier.Notify() expected error for a 400 response")
+	}
+}
+
+func TestNotifyTemplate(t *testing.T) {
+	data := notificationData{StartURL: "https://example.com/", JobName: "nightly", Reason: "Browser connection lost", PagesSaved: 3, PagesSkipped: 1, Time: time.Now()}
+
+	tmpl, err := parseNotifyTemplate("")
+	if err != nil {
+		t.Fatalf("parseNotifyTemplate(\"\") error = %v", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "sitepanda crawl of https://example.com/ (nightly) failed: Browser connection lost. Pages saved: 3, skipped: 1."
+	if b.String() != want {
+		t.Errorf("default template = %q, want %q", b.String(), want)
+	}
+
+	if _, err := parseNotifyTemplate("{{.Reason"); err == nil {
+		t.Errorf("parseNotifyTemplate() expected error for an unterminated action")
+	}
+}
+
+func TestCrawlFailureReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   CrawlResult
+		crawlErr error
+		want     string
+	}{
+		{name: "completed", result: CrawlResult{StopReason: "Completed"}, want: ""},
+		{name: "page limit", result: CrawlResult{StopReason: "Page limit reached (5)"}, want: ""},
+		{name: "cancelled", result: CrawlResult{StopReason: "Cancelled by user"}, want: ""},
+		{name: "crawl error", result: CrawlResult{StopReason: "Failed to start"}, crawlErr: errors.New("boom"), want: "boom"},
+		{name: "browser lost", result: CrawlResult{StopReason: "Browser connection lost"}, want: "Browser connection lost"},
+		{name: "output file", result: CrawlResult{StopReason: "Completed", OutputFile: "out.txt", OutputFileError: errors.New("disk full")}, want: "failed to write out.txt: disk full"},
+	}
+	for _, tt := range tests {
+		if got := crawlFailureReason(tt.result, tt.crawlErr); got != tt.want {
+			t.Errorf("%s: crawlFailureReason() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNotifyConfig(t *testing.T) {
+	var messages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		messages = append(messages, r.URL.Path+": "+payload["text"]+payload["content"])
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "sitepanda.yaml")
+	config := `notify:
+  template: "{{.StartURL}} failed: {{.Reason}}"
+  slack:
+    webhook: ` + server.URL + `/slack
+    template: ":x: {{.JobName}}: {{.Reason}}"
+  discord:
+    webhook: ` + server.URL + `/discord
+`
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfigFile(path)
+	if err != nil {
+		t.Fatalf("loadConfigFile() error = %v", err)
+	}
+	notifications, err := cfg.Notify.notifications()
+	if err != nil {
+		t.Fatalf("notifications() error = %v", err)
+	}
+	sendNotifications(notifications, notificationData{StartURL: "https://example.com/", JobName: "nightly", Reason: "Critical fetch error"})
+	want := []string{"/slack: :x: nightly: Critical fetch error", "/discord: https://example.com/ failed: Critical fetch error"}
+	if !slices.Equal(messages, want) {
+		t.Errorf("messages = %q, want %q", messages, want)
+	}
+
+	if err := os.WriteFile(path, []byte("notify:\n  slak:\n    webhook: x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfigFile(path); err == nil {
+		t.Error("loadConfigFile() expected error for an unknown key")
+	}
+	if _, err := (notifyConfig{Email: emailNotifyConfig{To: []string{"ops@example.com"}}}).notifications(); err == nil {
+		t.Error("notifications() expected error for email without an SMTP host")
+	}
+	if _, err := (notifyConfig{Slack: webhookNotifyConfig{Webhook: server.URL, Template: "{{.Reason"}}).notifications(); err == nil {
+		t.Error("notifications() expected error for an invalid template")
+	}
+}
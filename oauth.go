@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauthTokenRefreshMargin renews a token this long before it expires.
+const oauthTokenRefreshMargin = 30 * time.Second
+
+// oauthClientCredentials obtains bearer tokens with the OAuth2 client-credentials grant and
+// caches them until shortly before they expire. It is safe for concurrent use.
+type oauthClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Hosts receive the Authorization header; "*.example.com" also matches subdomains.
+	Hosts []string
+
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauthTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Token returns a valid access token, requesting a new one if there is none or it is about to expire.
+func (o *oauthClientCredentials) Token(ctx context.Context) (string, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.token != "" && (o.expiry.IsZero() || time.Now().Add(oauthTokenRefreshMargin).Before(o.expiry)) {
+		return o.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to build OAuth token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(o.ClientID), url.QueryEscape(o.ClientSecret))
+
+	client := o.client
+	if client == nil {
+		client = httpFetchClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("OAuth token request to %s failed: %w", o.TokenURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read OAuth token response: %w", err)
+	}
+
+	var tok oauthTokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return "", fmt.Errorf("OAuth token endpoint returned status %s and an invalid JSON body: %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || tok.Error != "" {
+		if tok.ErrorDescription != "" {
+			return "", fmt.Errorf("OAuth token endpoint returned status %s: %s (%s)", resp.Status, tok.Error, tok.ErrorDescription)
+		}
+		return "", fmt.Errorf("OAuth token endpoint returned status %s: %s", resp.Status, tok.Error)
+	}
+	if tok.AccessToken == "" {
+		return "", fmt.Errorf("OAuth token response from %s has no access_token", o.TokenURL)
+	}
+	if tok.TokenType != "" && !strings.EqualFold(tok.TokenType, "bearer") {
+		return "", fmt.Errorf("OAuth token endpoint returned unsupported token type %q", tok.TokenType)
+	}
+
+	o.token = tok.AccessToken
+	o.expiry = time.Time{}
+	if tok.ExpiresIn > 0 {
+		o.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return o.token, nil
+}
+
+// MatchesHost reports whether requests to host should carry the token.
+func (o *oauthClientCredentials) MatchesHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range o.Hosts {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
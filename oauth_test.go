@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOAuthClientCredentialsToken(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"bad credentials"}`))
+			return
+		}
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "docs:read api" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_request"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"tok-1","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	o := &oauthClientCredentials{TokenURL: server.URL, ClientID: "client", ClientSecret: "s3cret", Scopes: []string{"docs:read", "api"}, client: server.Client()}
+	for i := 0; i < 2; i++ {
+		token, err := o.Token(context.Background())
+		if err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+		if token != "tok-1" {
+			t.Errorf("Token() = %q, want tok-1", token)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("token endpoint called %d times, want 1 (token should be cached)", requests)
+	}
+
+	bad := &oauthClientCredentials{TokenURL: server.URL, ClientID: "client", ClientSecret: "wrong", client: server.Client()}
+	_, err := bad.Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "invalid_client (bad credentials)") {
+		t.Errorf("Token() with bad credentials error = %v, want invalid_client", err)
+	}
+}
+
+func TestOAuthClientCredentialsTokenRefresh(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"access_token":"short","token_type":"bearer","expires_in":10}`))
+	}))
+	defer server.Close()
+
+	o := &oauthClientCredentials{TokenURL: server.URL, ClientID: "c", ClientSecret: "s", client: server.Client()}
+	for i := 0; i < 2; i++ {
+		if _, err := o.Token(context.Background()); err != nil {
+			t.Fatalf("Token() error = %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("token endpoint called %d times, want 2 (tokens expiring within the refresh margin are renewed)", requests)
+	}
+}
+
+func TestOAuthClientCredentialsMatchesHost(t *testing.T) {
+	o := &oauthClientCredentials{Hosts: []string{"docs.example.com", "*.api.example.org"}}
+	tests := map[string]bool{
+		"docs.example.com":    true,
+		"DOCS.example.com":    true,
+		"www.example.com":     false,
+		"api.example.org":     true,
+		"v2.api.example.org":  true,
+		"evilapi.example.org": false,
+	}
+	for host, want := range tests {
+		if got := o.MatchesHost(host); got != want {
+			t.Errorf("MatchesHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// assetsDirName is the --output-dir subdirectory that receives downloaded images.
+	assetsDirName = "_assets"
+	// maxAssetBytes caps the size of a downloaded og:image or favicon.
+	maxAssetBytes = 10 << 20
+)
+
+// ogImageSelectors are tried in order for a page's preview image.
+var ogImageSelectors = []string{
+	`meta[property="og:image:secure_url"]`,
+	`meta[property="og:image"]`,
+	`meta[property="og:image:url"]`,
+	`meta[name="twitter:image"]`,
+	`meta[name="twitter:image:src"]`,
+}
+
+// faviconSelectors are tried in order for the site icon; /favicon.ico is the fallback.
+var faviconSelectors = []string{
+	`link[rel="icon" i]`,
+	`link[rel="shortcut icon" i]`,
+	`link[rel="apple-touch-icon" i]`,
+}
+
+// extractOGImageURL returns the absolute URL of the page's og:image (or Twitter card image), or "".
+func extractOGImageURL(pageURL *url.URL, doc *goquery.Document) string {
+	for _, selector := range ogImageSelectors {
+		if content, ok := doc.Find(selector).First().Attr("content"); ok && strings.TrimSpace(content) != "" {
+			if u, err := pageURL.Parse(strings.TrimSpace(content)); err == nil {
+				return u.String()
+			}
+		}
+	}
+	return ""
+}
+
+// extractFaviconURL returns the absolute URL of the site icon declared by the page, falling back to /favicon.ico.
+func extractFaviconURL(pageURL *url.URL, doc *goquery.Document) string {
+	for _, selector := range faviconSelectors {
+		if href, ok := doc.Find(selector).First().Attr("href"); ok && strings.TrimSpace(href) != "" {
+			if u, err := pageURL.Parse(strings.TrimSpace(href)); err == nil {
+				return u.String()
+			}
+		}
+	}
+	return (&url.URL{Scheme: pageURL.Scheme, Host: pageURL.Host, Path: "/favicon.ico"}).String()
+}
+
+// captureOGImages downloads the og:image and favicon of a saved page into the output directory and
+// sets PageData.OGImage and PageData.Favicon. Each image URL is downloaded once per crawl; failures
+// are logged and leave the field empty.
+func (c *Crawler) captureOGImages(pageURL *url.URL, rawHTML string, pd *PageData) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		logger.Printf("Warning: failed to parse HTML for og:image on %s: %v", pageURL, err)
+		return
+	}
+	if imageURL := extractOGImageURL(pageURL, doc); imageURL != "" {
+		pd.OGImage = c.downloadAssetOnce(imageURL)
+	}
+	pd.Favicon = c.downloadAssetOnce(extractFaviconURL(pageURL, doc))
+}
+
+// downloadAssetOnce returns the output-dir relative path of assetURL, downloading it on first use.
+func (c *Crawler) downloadAssetOnce(assetURL string) string {
+	if c.assetPaths == nil {
+		c.assetPaths = make(map[string]string)
+	}
+	if rel, ok := c.assetPaths[assetURL]; ok {
+		return rel
+	}
+	rel, err := downloadAsset(c.rootCtx, assetURL, c.opts.OutputDir)
+	if err != nil {
+		logger.Printf("Warning: %v", err)
+	}
+	c.assetPaths[assetURL] = rel
+	return rel
+}
+
+// downloadAsset fetches an image over HTTP into dir/_assets and returns its slash-separated path
+// relative to dir. The file name is a hash of the URL plus an extension from the URL or Content-Type.
+func downloadAsset(ctx context.Context, assetURL string, dir string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request for image %s: %w", assetURL, err)
+	}
+	req.Header.Set("User-Agent", "sitepanda/"+Version)
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := httpFetchClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download image %s: %w", assetURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return "", fmt.Errorf("failed to download image %s: status %s", assetURL, resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType != "" && !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("skipping image %s: unexpected content type %s", assetURL, contentType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAssetBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read image %s: %w", assetURL, err)
+	}
+	if len(data) > maxAssetBytes {
+		return "", fmt.Errorf("skipping image %s: larger than %s", assetURL, formatByteSize(maxAssetBytes))
+	}
+
+	rel := path.Join(assetsDirName, shortHash(assetURL)+assetExtension(assetURL, contentType))
+	full := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", filepath.Dir(full), err)
+	}
+	if err := os.WriteFile(full, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write image %s: %w", full, err)
+	}
+	return rel, nil
+}
+
+// assetExtension picks a file extension for an image from its URL path, then its Content-Type.
+func assetExtension(assetURL string, contentType string) string {
+	if u, err := url.Parse(assetURL); err == nil {
+		switch ext := strings.ToLower(path.Ext(u.Path)); ext {
+		case ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".ico", ".avif":
+			return ext
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/x-icon", "image/vnd.microsoft.icon":
+		return ".ico"
+	case "image/svg+xml":
+		return ".svg"
+	}
+	if strings.HasPrefix(mediaType, "image/") {
+		return "." + strings.TrimPrefix(mediaType, "image/")
+	}
+	return ""
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractOGImageAndFaviconURL(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/blog/post")
+	tests := []struct {
+		name        string
+		html        string
+		wantImage   string
+		wantFavicon string
+	}{
+		{
+			name:        "og:image and icon link",
+			html:        `<head><meta property="og:image" content="/img/cover.png"><link rel="icon" href="icons/fav.svg"></head>`,
+			wantImage:   "https://example.com/img/cover.png",
+			wantFavicon: "https://example.com/blog/icons/fav.svg",
+		},
+		{
+			name:        "secure_url preferred, shortcut icon",
+			html:        `<head><meta property="og:image" content="http://cdn.example.com/a.jpg"><meta property="og:image:secure_url" content="https://cdn.example.com/a.jpg"><link rel="Shortcut Icon" href="/favicon.png"></head>`,
+			wantImage:   "https://cdn.example.com/a.jpg",
+			wantFavicon: "https://example.com/favicon.png",
+		},
+		{
+			name:        "twitter card fallback and default favicon",
+			html:        `<head><meta name="twitter:image" content="https://example.com/card.webp"></head>`,
+			wantImage:   "https://example.com/card.webp",
+			wantFavicon: "https://example.com/favicon.ico",
+		},
+		{
+			name:        "no image",
+			html:        `<head><meta property="og:image" content="  "></head>`,
+			wantImage:   "",
+			wantFavicon: "https://example.com/favicon.ico",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := extractOGImageURL(pageURL, doc); got != tt.wantImage {
+				t.Errorf("extractOGImageURL() = %q, want %q", got, tt.wantImage)
+			}
+			if got := extractFaviconURL(pageURL, doc); got != tt.wantFavicon {
+				t.Errorf("extractFaviconURL() = %q, want %q", got, tt.wantFavicon)
+			}
+		})
+	}
+}
+
+func TestCaptureOGImages(t *testing.T) {
+	requests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests[r.URL.Path]++
+		switch r.URL.Path {
+		case "/cover":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Write([]byte("jpeg-bytes"))
+		case "/favicon.ico":
+			w.Header().Set("Content-Type", "image/x-icon")
+			w.Write([]byte("ico-bytes"))
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	c := &Crawler{rootCtx: context.Background(), opts: CrawlOptions{OutputDir: dir, CaptureOGImages: true}}
+	pageURL, _ := url.Parse(server.URL + "/a")
+	html := `<head><meta property="og:image" content="/cover"></head>`
+
+	var first, second PageData
+	c.captureOGImages(pageURL, html, &first)
+	c.captureOGImages(pageURL, html, &second)
+
+	if first.OGImage != "_assets/"+shortHash(server.URL+"/cover")+".jpg" {
+		t.Errorf("OGImage = %q", first.OGImage)
+	}
+	if first.Favicon != "_assets/"+shortHash(server.URL+"/favicon.ico")+".ico" {
+		t.Errorf("Favicon = %q", first.Favicon)
+	}
+	if second.OGImage != first.OGImage || second.Favicon != first.Favicon {
+		t.Errorf("second page = %+v, want the same paths as %+v", second, first)
+	}
+	if requests["/cover"] != 1 || requests["/favicon.ico"] != 1 {
+		t.Errorf("requests = %v, want each image downloaded once", requests)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(first.OGImage)))
+	if err != nil || string(data) != "jpeg-bytes" {
+		t.Errorf("saved og:image = %q, %v", data, err)
+	}
+
+	var failed PageData
+	c.captureOGImages(pageURL, `<head><meta property="og:image" content="/missing.png"><link rel="icon" href="/page.html"></head>`, &failed)
+	if failed.OGImage != "" || failed.Favicon != "" {
+		t.Errorf("failed downloads = %+v, want empty paths", failed)
+	}
+}
+
+func TestOGImageFrontMatter(t *testing.T) {
+	pd := PageData{Title: "T", URL: "https://example.com/", Markdown: "x", OGImage: "_assets/1.png", Favicon: "_assets/2.ico"}
+	got := formatPageDataAsMarkdownFile(&pd)
+	if !strings.Contains(got, "image: _assets/1.png\nfavicon: _assets/2.ico\n") {
+		t.Errorf("formatPageDataAsMarkdownFile() = %q, want image and favicon front matter", got)
+	}
+}
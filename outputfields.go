@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// droppableFields maps --drop-fields names to a function that clears the field. Names match the
+// JSON output keys; raw_html and article_html are never written to the outputs but are kept in
+// memory and in --max-memory spill files unless dropped.
+var droppableFields = map[string]func(pd *PageData){
+	"raw_html":     func(pd *PageData) { pd.RawHTML = "" },
+	"article_html": func(pd *PageData) { pd.ArticleHTML = "" },
+	"title":        func(pd *PageData) { pd.Title = "" },
+	"section":      func(pd *PageData) { pd.Section = "" },
+	"breadcrumbs":  func(pd *PageData) { pd.Breadcrumbs = nil },
+	"tags":         func(pd *PageData) { pd.Tags = nil },
+	"published":    func(pd *PageData) { pd.Published = time.Time{} },
+	"content":      func(pd *PageData) { pd.Markdown = "" },
+	"comments":     func(pd *PageData) { pd.Comments = nil },
+	"headers":      func(pd *PageData) { pd.Headers = nil },
+	"a11y_tree":    func(pd *PageData) { pd.A11yTree = "" },
+	"image":        func(pd *PageData) { pd.OGImage = "" },
+	"favicon":      func(pd *PageData) { pd.Favicon = "" },
+	"site":         func(pd *PageData) { pd.Site = "" },
+	"final_url":    func(pd *PageData) { pd.FinalURL = "" },
+	"canonical":    func(pd *PageData) { pd.Canonical = "" },
+	"status":       func(pd *PageData) { pd.Status = 0 },
+	"labels":       func(pd *PageData) { pd.Labels = nil },
+	"extracted":    func(pd *PageData) { pd.Extracted = nil },
+	"tables":       func(pd *PageData) { pd.Tables = nil },
+}
+
+// parseDropFields validates a --drop-fields list. The url field identifies pages and cannot be dropped.
+func parseDropFields(fields []string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" || seen[field] {
+			continue
+		}
+		if _, ok := droppableFields[field]; !ok {
+			return nil, fmt.Errorf("invalid --drop-fields field %q", field)
+		}
+		seen[field] = true
+		names = append(names, field)
+	}
+	return names, nil
+}
+
+// applyOutputFieldPolicy clears the dropped fields of pd and cuts its Markdown to at most
+// maxContentLength characters (0 disables the cap). It runs before a page is stored, so dropped
+// fields never reach spill files or outputs.
+func applyOutputFieldPolicy(pd *PageData, dropFields []string, maxContentLength int) {
+	for _, field := range dropFields {
+		droppableFields[field](pd)
+	}
+	if maxContentLength > 0 {
+		pd.Markdown = truncateRunes(pd.Markdown, maxContentLength)
+	}
+}
+
+// truncateRunes returns the first n characters of s.
+func truncateRunes(s string, n int) string {
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDropFields(t *testing.T) {
+	got, err := parseDropFields([]string{"raw_html", " Article_HTML", "raw_html", ""})
+	if err != nil {
+		t.Fatalf("parseDropFields() error = %v", err)
+	}
+	if want := []string{"raw_html", "article_html"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDropFields() = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"url", "body"} {
+		if _, err := parseDropFields([]string{bad}); err == nil {
+			t.Errorf("parseDropFields(%q) expected error", bad)
+		}
+	}
+}
+
+func TestApplyOutputFieldPolicy(t *testing.T) {
+	pd := PageData{
+		Title:       "Title",
+		URL:         "https://example.com/",
+		Markdown:    "héllo wörld",
+		RawHTML:     "<html>raw</html>",
+		ArticleHTML: "<p>article</p>",
+		Published:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Headers:     map[string]string{"etag": "x"},
+	}
+	applyOutputFieldPolicy(&pd, []string{"raw_html", "article_html", "headers", "published"}, 5)
+
+	want := PageData{Title: "Title", URL: "https://example.com/", Markdown: "héllo"}
+	if !reflect.DeepEqual(pd, want) {
+		t.Errorf("applyOutputFieldPolicy() = %+v, want %+v", pd, want)
+	}
+
+	data, err := formatResultsAsJSONL([]PageData{pd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "headers") || strings.Contains(string(data), "published") {
+		t.Errorf("JSONL output still contains dropped fields: %s", data)
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{in: "abc", n: 5, want: "abc"},
+		{in: "abc", n: 3, want: "abc"},
+		{in: "日本語テキスト", n: 3, want: "日本語"},
+		{in: "abc", n: 0, want: ""},
+	}
+	for _, tt := range tests {
+		if got := truncateRunes(tt.in, tt.n); got != tt.want {
+			t.Errorf("truncateRunes(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// outputTemplatePlaceholder matches "{name}" placeholders in --outfile and --output-dir.
+var outputTemplatePlaceholder = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// outputTemplateVars holds the values available to --outfile and --output-dir templates.
+type outputTemplateVars struct {
+	Host    string
+	Now     time.Time
+	JobName string
+}
+
+// newOutputTemplateVars derives template values for a run starting at startURL.
+func newOutputTemplateVars(startURL string, now time.Time, jobName string) outputTemplateVars {
+	host := "unknown-host"
+	if u, err := url.Parse(startURL); err == nil && u.Host != "" {
+		host = u.Host
+	} else if u, err := url.Parse("http://" + startURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return outputTemplateVars{Host: slugifyPathSegment(host), Now: now, JobName: jobName}
+}
+
+// expandOutputTemplate replaces {host}, {date}, {time}, {datetime} and {job} in tmpl.
+// Unknown placeholders are an error so typos do not silently produce literal file names.
+func expandOutputTemplate(tmpl string, vars outputTemplateVars) (string, error) {
+	var expandErr error
+	expanded := outputTemplatePlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		switch name := strings.Trim(m, "{}"); name {
+		case "host":
+			return vars.Host
+		case "date":
+			return vars.Now.Format("2006-01-02")
+		case "time":
+			return vars.Now.Format("150405")
+		case "datetime":
+			return vars.Now.Format("20060102-150405")
+		case "job":
+			if vars.JobName == "" {
+				expandErr = fmt.Errorf("placeholder {job} requires --job-name")
+				return m
+			}
+			return slugifyPathSegment(vars.JobName)
+		default:
+			if expandErr == nil {
+				expandErr = fmt.Errorf("unknown placeholder %s (supported: {host}, {date}, {time}, {datetime}, {job})", m)
+			}
+			return m
+		}
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandOutputTemplate(t *testing.T) {
+	now := time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)
+	vars := newOutputTemplateVars("https://docs.example.com:8443/guide", now, "nightly docs")
+
+	tests := []struct {
+		name      string
+		tmpl      string
+		vars      outputTemplateVars
+		want      string
+		wantError string
+	}{
+		{"no placeholders", "out.json", vars, "out.json", ""},
+		{"empty", "", vars, "", ""},
+		{"host and date", "out/{host}-{date}.json", vars, "out/docs.example.com-8443-2024-03-09.json", ""},
+		{"time and datetime", "{time}_{datetime}.txt", vars, "140507_20240309-140507.txt", ""},
+		{"job", "runs/{job}/{date}", vars, "runs/nightly docs/2024-03-09", ""},
+		{"job without name", "{job}.json", outputTemplateVars{Now: now}, "", "requires --job-name"},
+		{"unknown placeholder", "{hots}.json", vars, "", "unknown placeholder {hots}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandOutputTemplate(tt.tmpl, tt.vars)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("expandOutputTemplate(%q) error = %v, want containing %q", tt.tmpl, err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandOutputTemplate(%q) unexpected error: %v", tt.tmpl, err)
+			}
+			if got != tt.want {
+				t.Errorf("expandOutputTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewOutputTemplateVarsHost(t *testing.T) {
+	tests := []struct {
+		startURL string
+		want     string
+	}{
+		{"https://example.com/a", "example.com"},
+		{"example.com", "example.com"},
+		{"", "unknown-host"},
+	}
+	for _, tt := range tests {
+		if got := newOutputTemplateVars(tt.startURL, time.Now(), "").Host; got != tt.want {
+			t.Errorf("newOutputTemplateVars(%q).Host = %q, want %q", tt.startURL, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// pagePathMappingFile is written to --output-dir and maps each page URL to its file.
+	pagePathMappingFile = "sitepanda-paths.json"
+	// maxPathSegmentBytes keeps file and directory names well below common filesystem limits.
+	maxPathSegmentBytes = 100
+)
+
+// windowsReservedNames cannot be used as file names on Windows, with or without an extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// pagePathAllocator assigns each page URL a unique relative file path (slash-separated).
+// Paths are compared case-insensitively so the output is also safe on macOS and Windows.
+// Allocation is deterministic for a given sequence of URLs.
+type pagePathAllocator struct {
+	used  map[string]bool
+	paths map[string]string
+}
+
+func newPagePathAllocator() *pagePathAllocator {
+	return &pagePathAllocator{used: make(map[string]bool), paths: make(map[string]string)}
+}
+
+// Allocate returns the relative path for pageURL, reusing the earlier result for a repeated URL.
+func (a *pagePathAllocator) Allocate(pageURL string) string {
+	if p, ok := a.paths[pageURL]; ok {
+		return p
+	}
+	base := pagePathForURL(pageURL)
+	candidate := base + ".md"
+	if a.used[strings.ToLower(candidate)] {
+		candidate = fmt.Sprintf("%s-%s.md", base, shortHash(pageURL))
+		for n := 2; a.used[strings.ToLower(candidate)]; n++ {
+			candidate = fmt.Sprintf("%s-%s-%d.md", base, shortHash(pageURL), n)
+		}
+	}
+	a.used[strings.ToLower(candidate)] = true
+	a.paths[pageURL] = candidate
+	return candidate
+}
+
+// Mapping returns a copy of the URL to relative path assignments.
+func (a *pagePathAllocator) Mapping() map[string]string {
+	m := make(map[string]string, len(a.paths))
+	for k, v := range a.paths {
+		m[k] = v
+	}
+	return m
+}
+
+// pagePathForURL derives a relative path without extension from a URL: host, then path segments,
+// with "index" for directory-like paths and a hash suffix for query strings.
+func pagePathForURL(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return "pages/" + shortHash(pageURL)
+	}
+	segments := []string{slugifyPathSegment(u.Host)}
+	for _, seg := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		segments = append(segments, slugifyPathSegment(seg))
+	}
+	if len(segments) == 1 {
+		segments = append(segments, "index")
+	}
+	// Directories never end in ".md", so they cannot clash with a page file.
+	for i := 0; i < len(segments)-1; i++ {
+		if strings.HasSuffix(strings.ToLower(segments[i]), ".md") {
+			segments[i] += "_"
+		}
+	}
+	last := strings.TrimSuffix(segments[len(segments)-1], path.Ext(segments[len(segments)-1]))
+	if last == "" {
+		last = "index"
+	}
+	if u.RawQuery != "" {
+		last += "-" + shortHash(u.RawQuery)
+	}
+	if u.Fragment != "" {
+		// A --split-by-heading section.
+		last += "-" + slugifyPathSegment(u.Fragment)
+	}
+	segments[len(segments)-1] = last
+	return strings.Join(segments, "/")
+}
+
+// slugifyPathSegment makes s safe as a single file or directory name on Linux, macOS and Windows.
+func slugifyPathSegment(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x20 || r == 0x7f:
+			b.WriteByte('-')
+		case strings.ContainsRune(`<>:"/\|?*`, r):
+			b.WriteByte('-')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	slug := strings.TrimRight(b.String(), ". ")
+	if slug == "" || slug == "." || slug == ".." {
+		slug = "_"
+	}
+	if name, _, _ := strings.Cut(slug, "."); windowsReservedNames[strings.ToUpper(name)] {
+		slug = "_" + slug
+	}
+	if len(slug) > maxPathSegmentBytes {
+		cut := maxPathSegmentBytes - 9
+		for cut > 0 && !isRuneStart(slug[cut]) {
+			cut--
+		}
+		slug = slug[:cut] + "-" + shortHash(s)
+	}
+	return slug
+}
+
+func isRuneStart(b byte) bool { return b&0xC0 != 0x80 }
+
+// shortHash returns the first 8 hex characters of the SHA-1 of s.
+func shortHash(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:4])
+}
+
+// formatPageDataAsMarkdownFile renders a page for --output-dir: YAML front matter followed by the Markdown.
+func formatPageDataAsMarkdownFile(pd *PageData) string {
+	title, _ := json.Marshal(pd.Title)
+	pageURL, _ := json.Marshal(pd.URL)
+	var metadata string
+	if pd.Site != "" {
+		metadata += fmt.Sprintf("site: %s\n", pd.Site)
+	}
+	if pd.FinalURL != "" {
+		finalURL, _ := json.Marshal(pd.FinalURL)
+		metadata += fmt.Sprintf("final_url: %s\n", finalURL)
+	}
+	if pd.Canonical != "" {
+		canonical, _ := json.Marshal(pd.Canonical)
+		metadata += fmt.Sprintf("canonical: %s\n", canonical)
+	}
+	if pd.Status != 0 {
+		metadata += fmt.Sprintf("status: %d\n", pd.Status)
+	}
+	if pd.Section != "" {
+		quoted, _ := json.Marshal(pd.Section)
+		metadata += fmt.Sprintf("section: %s\n", quoted)
+	}
+	if len(pd.Tags) > 0 {
+		quoted, _ := json.Marshal(pd.Tags)
+		metadata += fmt.Sprintf("tags: %s\n", quoted)
+	}
+	if len(pd.Labels) > 0 {
+		quoted, _ := json.Marshal(pd.Labels)
+		metadata += fmt.Sprintf("labels: %s\n", quoted)
+	}
+	if published := formatPublishedDate(pd.Published); published != "" {
+		metadata += fmt.Sprintf("published: %s\n", published)
+	}
+	if pd.Relevance > 0 {
+		metadata += fmt.Sprintf("relevance: %g\n", pd.Relevance)
+	}
+	if pd.OGImage != "" {
+		metadata += fmt.Sprintf("image: %s\n", pd.OGImage)
+	}
+	if pd.Favicon != "" {
+		metadata += fmt.Sprintf("favicon: %s\n", pd.Favicon)
+	}
+	if len(pd.Headers) > 0 {
+		quoted, _ := json.Marshal(pd.Headers)
+		metadata += fmt.Sprintf("headers: %s\n", quoted)
+	}
+	if len(pd.Extracted) > 0 {
+		extracted, _ := json.Marshal(pd.Extracted)
+		metadata += fmt.Sprintf("extracted: %s\n", extracted)
+	}
+	body := strings.TrimSpace(pd.Markdown)
+	if len(pd.Comments) > 0 {
+		body += "\n\n## Comments\n\n" + formatCommentsAsMarkdown(pd.Comments)
+	}
+	if pd.A11yTree != "" {
+		body += "\n\n## Accessibility Tree\n\n" + formatA11yTreeAsMarkdown(pd.A11yTree)
+	}
+	return fmt.Sprintf("---\ntitle: %s\nurl: %s\n%s---\n\n%s\n", title, pageURL, metadata, body)
+}
+
+// writePageFiles writes one Markdown file per page below dir plus the URL to path mapping file.
+// Tables kept for --tables csv are written next to their page as <page>.table-N.csv.
+// It returns the mapping of URL to relative path.
+func writePageFiles(dir string, results []PageData) (map[string]string, error) {
+	allocator := newPagePathAllocator()
+	for i := range results {
+		rel := allocator.Allocate(results[i].URL)
+		full := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", results[i].URL, err)
+		}
+		if err := os.WriteFile(full, []byte(formatPageDataAsMarkdownFile(&results[i])), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write page file for %s: %w", results[i].URL, err)
+		}
+		if err := writeTableCSVFiles(full, results[i].Tables); err != nil {
+			return nil, err
+		}
+	}
+
+	mapping := allocator.Mapping()
+	data, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode path mapping: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, pagePathMappingFile), data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write path mapping: %w", err)
+	}
+	return mapping, nil
+}
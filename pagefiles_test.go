@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPagePathForURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/", "example.com/index"},
+		{"https://example.com/about", "example.com/about"},
+		{"https://example.com/docs/guide/intro.html", "example.com/docs/guide/intro"},
+		{"https://example.com:8080/a", "example.com-8080/a"},
+		{"https://example.com/a%3Ab/c", "example.com/a-b/c"},
+		{"https://example.com/con", "example.com/_con"},
+		{"https://example.com/notes.md/x", "example.com/notes.md_/x"},
+		{"https://example.com/search?q=go", "example.com/search-" + shortHash("q=go")},
+		{"https://example.com/?page=2", "example.com/index-" + shortHash("page=2")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := pagePathForURL(tt.url); got != tt.want {
+				t.Errorf("pagePathForURL(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlugifyPathSegment(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"plain", "plain"},
+		{`a<b>c:d"e\f|g?h*i`, "a-b-c-d-e-f-g-h-i"},
+		{"trailing. ", "trailing"},
+		{"..", "_"},
+		{"NUL.txt", "_NUL.txt"},
+		{"tab\there", "tab-here"},
+	}
+	for _, tt := range tests {
+		if got := slugifyPathSegment(tt.in); got != tt.want {
+			t.Errorf("slugifyPathSegment(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	long := strings.Repeat("é", 200)
+	got := slugifyPathSegment(long)
+	if len(got) > maxPathSegmentBytes {
+		t.Errorf("slugifyPathSegment(long) has %d bytes, want <= %d", len(got), maxPathSegmentBytes)
+	}
+	if !strings.HasSuffix(got, "-"+shortHash(long)) {
+		t.Errorf("slugifyPathSegment(long) = %q, want hash suffix", got)
+	}
+	if strings.ToValidUTF8(got, "?") != got {
+		t.Errorf("slugifyPathSegment(long) cut a multi-byte character: %q", got)
+	}
+}
+
+func TestPagePathAllocatorCollisions(t *testing.T) {
+	a := newPagePathAllocator()
+	first := a.Allocate("https://example.com/About")
+	second := a.Allocate("https://example.com/about")
+	third := a.Allocate("https://example.com/about.html")
+
+	if first != "example.com/About.md" {
+		t.Errorf("first allocation = %q, want example.com/About.md", first)
+	}
+	if second == first || strings.EqualFold(second, first) {
+		t.Errorf("case-insensitive collision not resolved: %q vs %q", first, second)
+	}
+	if third == first || third == second || strings.EqualFold(third, first) {
+		t.Errorf("extension collision not resolved: %q", third)
+	}
+	if again := a.Allocate("https://example.com/about"); again != second {
+		t.Errorf("repeated URL got %q, want %q", again, second)
+	}
+
+	b := newPagePathAllocator()
+	b.Allocate("https://example.com/About")
+	if got := b.Allocate("https://example.com/about"); got != second {
+		t.Errorf("allocation is not deterministic: %q vs %q", got, second)
+	}
+}
+
+func TestWritePageFiles(t *testing.T) {
+	dir := t.TempDir()
+	results := []PageData{
+		{Title: "Home", URL: "https://example.com/", Markdown: "Welcome"},
+		{Title: `Say "hi"`, URL: "https://example.com/docs/intro", Markdown: "Intro text"},
+	}
+	mapping, err := writePageFiles(dir, results)
+	if err != nil {
+		t.Fatalf("writePageFiles() error: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "example.com", "docs", "intro.md"))
+	if err != nil {
+		t.Fatalf("page file not written: %v", err)
+	}
+	want := "---\ntitle: \"Say \\\"hi\\\"\"\nurl: \"https://example.com/docs/intro\"\n---\n\nIntro text\n"
+	if string(content) != want {
+		t.Errorf("page file content = %q, want %q", content, want)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, pagePathMappingFile))
+	if err != nil {
+		t.Fatalf("mapping file not written: %v", err)
+	}
+	var onDisk map[string]string
+	if err := json.Unmarshal(data, &onDisk); err != nil {
+		t.Fatalf("mapping file is not valid JSON: %v", err)
+	}
+	if onDisk["https://example.com/"] != "example.com/index.md" || len(onDisk) != 2 || onDisk["https://example.com/docs/intro"] != mapping["https://example.com/docs/intro"] {
+		t.Errorf("mapping = %v", onDisk)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	// parquetFormat is the --output-format that writes a Parquet file to --outfile.
+	parquetFormat = "parquet"
+	// parquetPageBytes and parquetRowGroupBytes are the target sizes of the uncompressed values
+	// in one data page and one row group.
+	parquetPageBytes     = 1 << 20
+	parquetRowGroupBytes = 64 << 20
+)
+
+// Parquet format constants (parquet.thrift).
+const (
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+	parquetRequired      = 0
+	parquetConvertedUTF8 = 0
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+	parquetCodecGzip     = 2
+	parquetDataPage      = 0
+)
+
+// Thrift compact protocol type ids.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// parquetRow is one page in the Parquet export.
+type parquetRow struct {
+	URL      string
+	Title    string
+	Markdown string
+	// Metadata is the page's JSON output object without title, url and content.
+	Metadata string
+	Tokens   int64
+}
+
+// parquetColumn is one column of the Parquet export.
+type parquetColumn struct {
+	name     string
+	physical int32
+	// encode appends the row's value in PLAIN encoding.
+	encode func(buf *bytes.Buffer, row *parquetRow)
+}
+
+var parquetColumns = []parquetColumn{
+	{"url", parquetTypeByteArray, func(buf *bytes.Buffer, row *parquetRow) { appendParquetString(buf, row.URL) }},
+	{"title", parquetTypeByteArray, func(buf *bytes.Buffer, row *parquetRow) { appendParquetString(buf, row.Title) }},
+	{"markdown", parquetTypeByteArray, func(buf *bytes.Buffer, row *parquetRow) { appendParquetString(buf, row.Markdown) }},
+	{"metadata", parquetTypeByteArray, func(buf *bytes.Buffer, row *parquetRow) { appendParquetString(buf, row.Metadata) }},
+	{"tokens", parquetTypeInt64, func(buf *bytes.Buffer, row *parquetRow) {
+		binary.Write(buf, binary.LittleEndian, row.Tokens)
+	}},
+}
+
+func appendParquetString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.LittleEndian, uint32(len(s)))
+	buf.WriteString(s)
+}
+
+// estimateTokens approximates the number of LLM tokens in s at four characters per token.
+func estimateTokens(s string) int64 {
+	return int64((utf8.RuneCountInString(s) + 3) / 4)
+}
+
+// pageMetadataJSON returns the page's JSON output object without the fields that have their own
+// column, so metadata added to the JSON formats also reaches the Parquet and dataset exports.
+func pageMetadataJSON(pd *PageData) (string, error) {
+	data, err := json.Marshal(newJSONOutputPage(pd))
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, "title")
+	delete(fields, "url")
+	delete(fields, "content")
+	data, err = json.Marshal(fields)
+	return string(data), err
+}
+
+// parquetColumnChunk is the metadata of one column in one row group.
+type parquetColumnChunk struct {
+	offset       int64
+	numValues    int64
+	uncompressed int64
+	compressed   int64
+}
+
+// parquetRowGroup is the metadata of one row group.
+type parquetRowGroup struct {
+	numRows int64
+	columns []parquetColumnChunk
+}
+
+// formatResultsAsParquet encodes results as a Parquet file with the columns url, title,
+// markdown, metadata (JSON) and tokens. Values are PLAIN encoded in gzip-compressed pages.
+func formatResultsAsParquet(results []PageData) ([]byte, error) {
+	rows := make([]parquetRow, len(results))
+	for i := range results {
+		pd := &results[i]
+		metadata, err := pageMetadataJSON(pd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metadata of %s: %w", pd.URL, err)
+		}
+		rows[i] = parquetRow{URL: pd.URL, Title: pd.Title, Markdown: pd.Markdown, Metadata: metadata, Tokens: estimateTokens(pd.Markdown)}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("PAR1")
+	var groups []parquetRowGroup
+	for start := 0; start < len(rows); {
+		end, size := start, 0
+		for end < len(rows) && (end == start || size < parquetRowGroupBytes) {
+			size += len(rows[end].URL) + len(rows[end].Title) + len(rows[end].Markdown) + len(rows[end].Metadata)
+			end++
+		}
+		group := parquetRowGroup{numRows: int64(end - start)}
+		for _, col := range parquetColumns {
+			chunk, err := writeParquetColumnChunk(&out, col, rows[start:end])
+			if err != nil {
+				return nil, err
+			}
+			group.columns = append(group.columns, chunk)
+		}
+		groups = append(groups, group)
+		start = end
+	}
+
+	footer := parquetFileMetaData(int64(len(rows)), groups)
+	out.Write(footer)
+	binary.Write(&out, binary.LittleEndian, uint32(len(footer)))
+	out.WriteString("PAR1")
+	return out.Bytes(), nil
+}
+
+// writeParquetColumnChunk appends the values of col for rows as data pages of about
+// parquetPageBytes each.
+func writeParquetColumnChunk(out *bytes.Buffer, col parquetColumn, rows []parquetRow) (parquetColumnChunk, error) {
+	chunk := parquetColumnChunk{offset: int64(out.Len()), numValues: int64(len(rows))}
+	var values bytes.Buffer
+	pageValues := 0
+	flush := func() error {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(values.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		header := parquetPageHeader(values.Len(), compressed.Len(), pageValues)
+		out.Write(header)
+		out.Write(compressed.Bytes())
+		chunk.uncompressed += int64(len(header) + values.Len())
+		chunk.compressed += int64(len(header) + compressed.Len())
+		values.Reset()
+		pageValues = 0
+		return nil
+	}
+	for i := range rows {
+		col.encode(&values, &rows[i])
+		pageValues++
+		if values.Len() >= parquetPageBytes {
+			if err := flush(); err != nil {
+				return chunk, fmt.Errorf("failed to compress parquet column %s: %w", col.name, err)
+			}
+		}
+	}
+	if pageValues > 0 {
+		if err := flush(); err != nil {
+			return chunk, fmt.Errorf("failed to compress parquet column %s: %w", col.name, err)
+		}
+	}
+	return chunk, nil
+}
+
+// parquetPageHeader encodes the PageHeader of a PLAIN data page. Columns are required and
+// flat, so the page holds no repetition or definition levels.
+func parquetPageHeader(uncompressed, compressed, numValues int) []byte {
+	var t thriftWriter
+	t.i32(1, parquetDataPage)
+	t.i32(2, int32(uncompressed))
+	t.i32(3, int32(compressed))
+	t.structField(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, parquetEncodingPlain)
+	t.i32(3, parquetEncodingRLE)
+	t.i32(4, parquetEncodingRLE)
+	t.structEnd()
+	t.structEnd()
+	return t.buf.Bytes()
+}
+
+// parquetFileMetaData encodes the file footer.
+func parquetFileMetaData(numRows int64, groups []parquetRowGroup) []byte {
+	var t thriftWriter
+	t.i32(1, 1)
+	t.listBegin(2, thriftStruct, len(parquetColumns)+1)
+	t.structBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(parquetColumns)))
+	t.structEnd()
+	for _, col := range parquetColumns {
+		t.structBegin()
+		t.i32(1, col.physical)
+		t.i32(3, parquetRequired)
+		t.binary(4, col.name)
+		if col.physical == parquetTypeByteArray {
+			t.i32(6, parquetConvertedUTF8)
+			t.structField(10) // LogicalType
+			t.structField(1)  // STRING
+			t.structEnd()
+			t.structEnd()
+		}
+		t.structEnd()
+	}
+	t.i64(3, numRows)
+	t.listBegin(4, thriftStruct, len(groups))
+	for _, g := range groups {
+		t.structBegin()
+		t.listBegin(1, thriftStruct, len(g.columns))
+		var totalBytes int64
+		for i, c := range g.columns {
+			totalBytes += c.uncompressed
+			t.structBegin()
+			t.i64(2, c.offset)
+			t.structField(3)
+			t.i32(1, parquetColumns[i].physical)
+			t.listBegin(2, thriftI32, 2)
+			t.varint(zigzag(parquetEncodingPlain))
+			t.varint(zigzag(parquetEncodingRLE))
+			t.listBegin(3, thriftBinary, 1)
+			t.rawBinary(parquetColumns[i].name)
+			t.i32(4, parquetCodecGzip)
+			t.i64(5, c.numValues)
+			t.i64(6, c.uncompressed)
+			t.i64(7, c.compressed)
+			t.i64(9, c.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, totalBytes)
+		t.i64(3, g.numRows)
+		t.structEnd()
+	}
+	t.binary(6, "sitepanda version "+Version)
+	t.structEnd()
+	return t.buf.Bytes()
+}
+
+// thriftWriter writes the Thrift compact protocol, the encoding of Parquet's metadata.
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastID  int16
+	parents []int16
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.rawBinary(s)
+}
+
+func (t *thriftWriter) rawBinary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+// listBegin starts a list field of n elements; the caller writes the elements.
+func (t *thriftWriter) listBegin(id int16, elemType byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.varint(uint64(n))
+	}
+}
+
+// structField starts a struct-valued field; structBegin starts a struct list element.
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.structBegin()
+}
+
+func (t *thriftWriter) structBegin() {
+	t.parents = append(t.parents, t.lastID)
+	t.lastID = 0
+}
+
+// structEnd writes the stop byte of the current struct. The top-level struct is ended without
+// a matching structBegin.
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	if n := len(t.parents); n > 0 {
+		t.lastID = t.parents[n-1]
+		t.parents = t.parents[:n-1]
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes the Thrift compact protocol into maps of field id to value, enough to
+// check the Parquet metadata written by thriftWriter.
+type thriftReader struct {
+	r *bytes.Reader
+}
+
+func (t *thriftReader) varint() uint64 {
+	v, err := binary.ReadUvarint(t.r)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (t *thriftReader) zigzag() int64 {
+	v := t.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (t *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return t.zigzag()
+	case thriftBinary:
+		b := make([]byte, t.varint())
+		if _, err := io.ReadFull(t.r, b); err != nil {
+			panic(err)
+		}
+		return string(b)
+	case thriftList:
+		head, _ := t.r.ReadByte()
+		n := int(head >> 4)
+		if n == 15 {
+			n = int(t.varint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = t.value(head & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return t.structValue()
+	}
+	panic("unsupported thrift type")
+}
+
+func (t *thriftReader) structValue() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		head, err := t.r.ReadByte()
+		if err != nil {
+			panic(err)
+		}
+		if head == 0 {
+			return fields
+		}
+		id := last + int16(head>>4)
+		if head>>4 == 0 {
+			id = int16(t.zigzag())
+		}
+		fields[id] = t.value(head & 0x0f)
+		last = id
+	}
+}
+
+func TestFormatResultsAsParquet(t *testing.T) {
+	results := []PageData{
+		{Title: "Home", URL: "https://example.com/", Markdown: "Hello world, this is home.", Section: "Docs", Tags: []string{"intro"}},
+		{Title: "Über", URL: "https://example.com/about", Markdown: strings.Repeat("a", parquetPageBytes), Published: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "", URL: "https://example.com/empty"},
+	}
+	data, err := formatResultsAsParquet(results)
+	if err != nil {
+		t.Fatalf("formatResultsAsParquet() error = %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("missing PAR1 magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := (&thriftReader{bytes.NewReader(data[len(data)-8-footerLen : len(data)-8])}).structValue()
+
+	if footer[3].(int64) != int64(len(results)) {
+		t.Errorf("num_rows = %v, want %d", footer[3], len(results))
+	}
+	schema := footer[2].([]interface{})
+	var names []string
+	for _, el := range schema[1:] {
+		names = append(names, el.(map[int16]interface{})[4].(string))
+	}
+	if got := strings.Join(names, ","); got != "url,title,markdown,metadata,tokens" {
+		t.Errorf("columns = %s", got)
+	}
+
+	columns := make(map[string][]interface{})
+	for _, g := range footer[4].([]interface{}) {
+		for i, c := range g.(map[int16]interface{})[1].([]interface{}) {
+			meta := c.(map[int16]interface{})[3].(map[int16]interface{})
+			r := &thriftReader{bytes.NewReader(data[meta[9].(int64):])}
+			for read := int64(0); read < meta[5].(int64); {
+				header := r.structValue()
+				page := make([]byte, header[3].(int64))
+				io.ReadFull(r.r, page)
+				zr, err := gzip.NewReader(bytes.NewReader(page))
+				if err != nil {
+					t.Fatalf("page of %s: %v", names[i], err)
+				}
+				values, _ := io.ReadAll(zr)
+				if int64(len(values)) != header[2].(int64) {
+					t.Errorf("page of %s: uncompressed size %d, header says %d", names[i], len(values), header[2])
+				}
+				n := header[5].(map[int16]interface{})[1].(int64)
+				for j := int64(0); j < n; j++ {
+					if meta[1].(int64) == parquetTypeInt64 {
+						columns[names[i]] = append(columns[names[i]], int64(binary.LittleEndian.Uint64(values)))
+						values = values[8:]
+					} else {
+						size := binary.LittleEndian.Uint32(values)
+						columns[names[i]] = append(columns[names[i]], string(values[4:4+size]))
+						values = values[4+size:]
+					}
+				}
+				read += n
+			}
+		}
+	}
+
+	for i, pd := range results {
+		if columns["url"][i] != pd.URL || columns["title"][i] != pd.Title || columns["markdown"][i] != pd.Markdown {
+			t.Errorf("row %d does not round-trip", i)
+		}
+	}
+	if got := columns["tokens"][0]; got != int64(7) {
+		t.Errorf("tokens[0] = %v, want 7", got)
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(columns["metadata"][0].(string)), &metadata); err != nil {
+		t.Fatalf("metadata is not JSON: %v", err)
+	}
+	if metadata["section"] != "Docs" || metadata["title"] != nil || metadata["content"] != nil {
+		t.Errorf("metadata[0] = %v", metadata)
+	}
+	if got := columns["metadata"][1]; got != `{"published":"2024-05-01"}` {
+		t.Errorf("metadata[1] = %v", got)
+	}
+	if got := columns["metadata"][2]; got != `{}` {
+		t.Errorf("metadata[2] = %v", got)
+	}
+}
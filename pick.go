@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hokupod/sitepanda/cmd"
+	"github.com/playwright-community/playwright-go"
+)
+
+// pickContainerSelector matches the elements 'sitepanda pick' considers as content containers.
+const pickContainerSelector = "body, main, article, section, div, td, [role=main]"
+
+// pickBoilerplateSelector matches elements whose text does not count as content.
+const pickBoilerplateSelector = "script, style, noscript, template, nav, header, footer, aside, form, [role=navigation]"
+
+// pickMinShare is the share of the page's text a container must hold to be a candidate, which
+// keeps small dense boxes such as a single paragraph from outranking the article around them.
+const pickMinShare = 0.2
+
+// pickPreviewChars is how much of a candidate's text is shown.
+const pickPreviewChars = 60
+
+// cssIdentPattern matches ids and class names usable in a selector without escaping.
+var cssIdentPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// pickCandidate is a possible --content-selector for a page.
+type pickCandidate struct {
+	Selector string
+	// TextChars counts the characters of text outside links and boilerplate.
+	TextChars int
+	// LinkChars counts the characters of link text.
+	LinkChars int
+	// Density is TextChars per element, including the container itself.
+	Density float64
+	Preview string
+}
+
+// rankContentCandidates returns up to top containers of doc that hold at least pickMinShare of
+// its text, by descending text density.
+func rankContentCandidates(doc *goquery.Document, top int) []pickCandidate {
+	_, _, total, _ := pickTextStats(doc.Find("body"))
+	if total == 0 {
+		return nil
+	}
+	var candidates []pickCandidate
+	doc.Find(pickContainerSelector).Each(func(_ int, s *goquery.Selection) {
+		if s.ParentsFiltered(pickBoilerplateSelector).Length() > 0 {
+			return
+		}
+		text, linkChars, textChars, elements := pickTextStats(s)
+		if float64(textChars) < pickMinShare*float64(total) {
+			return
+		}
+		candidates = append(candidates, pickCandidate{
+			Selector:  cssSelectorFor(doc, s),
+			TextChars: textChars,
+			LinkChars: linkChars,
+			Density:   float64(textChars) / float64(elements+1),
+			Preview:   truncateRunes(text, pickPreviewChars),
+		})
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Density != candidates[j].Density {
+			return candidates[i].Density > candidates[j].Density
+		}
+		return candidates[i].TextChars > candidates[j].TextChars
+	})
+	if len(candidates) > top {
+		candidates = candidates[:top]
+	}
+	return candidates
+}
+
+// pickTextStats returns the whitespace-collapsed text of s without boilerplate, the length of
+// its link text, the length of the rest and the number of elements it is spread over.
+func pickTextStats(s *goquery.Selection) (text string, linkChars int, textChars int, elements int) {
+	content := s.Clone()
+	content.Find(pickBoilerplateSelector).Remove()
+	text = strings.Join(strings.Fields(content.Text()), " ")
+	linkText := strings.Join(strings.Fields(content.Find("a").Text()), " ")
+	linkChars = len([]rune(linkText))
+	return text, linkChars, max(len([]rune(text))-linkChars, 0), content.Find("*").Length()
+}
+
+// cssSelectorFor returns a selector matching only s in doc: a unique id if s or an ancestor has
+// one, otherwise a child path of tag names and up to two classes, numbered with :nth-of-type
+// where siblings would be ambiguous. pickScript builds selectors the same way in the browser.
+func cssSelectorFor(doc *goquery.Document, s *goquery.Selection) string {
+	selector := ""
+	join := func(part string) string {
+		if selector == "" {
+			return part
+		}
+		return part + " > " + selector
+	}
+	unique := func(sel string) bool { return doc.Find(sel).Length() == 1 }
+	for node := s; node.Length() > 0 && goquery.NodeName(node) != "html"; node = node.Parent() {
+		if id, ok := node.Attr("id"); ok && cssIdentPattern.MatchString(id) && unique("#"+id) {
+			return join("#" + id)
+		}
+		tag := goquery.NodeName(node)
+		part := tag
+		classes := 0
+		for _, class := range strings.Fields(node.AttrOr("class", "")) {
+			if classes < 2 && cssIdentPattern.MatchString(class) {
+				part += "." + class
+				classes++
+			}
+		}
+		if unique(join(part)) {
+			return join(part)
+		}
+		if node.Siblings().FilterFunction(func(_ int, sib *goquery.Selection) bool { return goquery.NodeName(sib) == tag }).Length() > 0 {
+			index := node.PrevAllFiltered(tag).Length() + 1
+			part += fmt.Sprintf(":nth-of-type(%d)", index)
+		}
+		selector = join(part)
+		if unique(selector) {
+			return selector
+		}
+	}
+	return selector
+}
+
+// writePickCandidates prints the ranked candidates as a table.
+func writePickCandidates(w io.Writer, candidates []pickCandidate) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tSELECTOR\tDENSITY\tTEXT\tLINK TEXT\tPREVIEW")
+	for i, c := range candidates {
+		fmt.Fprintf(tw, "%d\t%s\t%.1f\t%d\t%d\t%s\n", i+1, c.Selector, c.Density, c.TextChars, c.LinkChars, c.Preview)
+	}
+	return tw.Flush()
+}
+
+// pickScript is added to every page in --interactive mode. It outlines the element under the
+// pointer and, instead of following clicks, queues the clicked element's selector, built like
+// cssSelectorFor, in window.__sitepandaPicks.
+const pickScript = `(() => {
+  if (window.__sitepandaPicks) return;
+  window.__sitepandaPicks = [];
+  const ident = /^[A-Za-z][A-Za-z0-9_-]*$/;
+  const unique = (sel) => document.querySelectorAll(sel).length === 1;
+  const selectorFor = (el) => {
+    let selector = "";
+    const join = (part) => selector ? part + " > " + selector : part;
+    for (let node = el; node && node.nodeType === 1 && node.tagName !== "HTML"; node = node.parentElement) {
+      if (node.id && ident.test(node.id) && unique("#" + node.id)) return join("#" + node.id);
+      const tag = node.tagName.toLowerCase();
+      let part = tag + Array.from(node.classList).filter((c) => ident.test(c)).slice(0, 2).map((c) => "." + c).join("");
+      if (unique(join(part))) return join(part);
+      const same = node.parentElement ? Array.from(node.parentElement.children).filter((c) => c.tagName === node.tagName) : [];
+      if (same.length > 1) part += ":nth-of-type(" + (same.indexOf(node) + 1) + ")";
+      selector = join(part);
+      if (unique(selector)) return selector;
+    }
+    return selector;
+  };
+  let outlined = null;
+  document.addEventListener("mouseover", (e) => {
+    if (outlined) outlined.style.outline = outlined.dataset.sitepandaOutline || "";
+    outlined = e.target;
+    outlined.dataset.sitepandaOutline = outlined.style.outline;
+    outlined.style.outline = "2px solid #e8590c";
+  }, true);
+  document.addEventListener("click", (e) => {
+    e.preventDefault();
+    e.stopPropagation();
+    const selector = selectorFor(e.target);
+    const text = (e.target.innerText || "").replace(/\s+/g, " ").trim();
+    window.__sitepandaPicks.push({selector: selector, matches: document.querySelectorAll(selector).length, text: text});
+  }, true);
+})();`
+
+// HandlePick runs 'sitepanda pick'.
+func HandlePick(pageURL string) {
+	if cmd.GetSilent() {
+		SetLoggerOutput(io.Discard)
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		logger.Fatalf("Error: pick requires an http or https URL, got: %s", pageURL)
+	}
+	if cmd.GetPickInteractive() {
+		err = runInteractivePick(u.String())
+	} else {
+		err = runPick(u.String())
+	}
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+}
+
+// loadPageHTML renders pageURL with the --browser engine, in a browser launched for this call,
+// and returns its HTML.
+func loadPageHTML(pageURL string, waitForNetworkIdle bool) (string, error) {
+	playwrightDriverDir, err := GetAppSubdirectory("playwright_driver")
+	if err != nil {
+		return "", fmt.Errorf("failed to determine Playwright driver directory: %w", err)
+	}
+	browserName := cmd.GetBrowserName()
+	pwBrowser, shutdown, err := launchFallbackBrowser(browserName, playwrightDriverDir, false)
+	if err != nil {
+		return "", err
+	}
+	defer shutdown()
+	browserCtx, err := pwBrowser.NewContext()
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s browser context: %w", browserName, err)
+	}
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		return "", fmt.Errorf("failed to open a %s page: %w", browserName, err)
+	}
+	html, _, err := fetchPageHTML(page, context.Background(), pageURL, waitForNetworkIdle, "")
+	return html, err
+}
+
+// runPick loads pageURL and prints its ranked content candidates.
+func runPick(pageURL string) error {
+	html, err := loadPageHTML(pageURL, cmd.GetPickWaitForNetworkIdle())
+	if err != nil {
+		return err
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", pageURL, err)
+	}
+	candidates := rankContentCandidates(doc, cmd.GetPickTop())
+	if len(candidates) == 0 {
+		fmt.Printf("No text content found on %s.\n", pageURL)
+		return nil
+	}
+	return writePickCandidates(os.Stdout, candidates)
+}
+
+// runInteractivePick opens pageURL in a visible Chromium window and prints the selector of each
+// element clicked until the window is closed or the command is interrupted.
+func runInteractivePick(pageURL string) error {
+	if browserName := cmd.GetBrowserName(); browserName != "chromium" {
+		return fmt.Errorf("--interactive requires --browser chromium, got %s", browserName)
+	}
+	playwrightDriverDir, err := GetAppSubdirectory("playwright_driver")
+	if err != nil {
+		return fmt.Errorf("failed to determine Playwright driver directory: %w", err)
+	}
+	if err := ensureDriverVersion(playwrightDriverDir); err != nil {
+		return err
+	}
+	pwInstance, err := playwright.Run(&playwright.RunOptions{DriverDirectory: playwrightDriverDir})
+	if err != nil {
+		return fmt.Errorf("could not start playwright for Chromium: %w", err)
+	}
+	defer pwInstance.Stop()
+	pwBrowser, err := pwInstance.Chromium.Launch(playwright.BrowserTypeLaunchOptions{Headless: playwright.Bool(false)})
+	if err != nil {
+		return fmt.Errorf("could not launch a visible Chromium window: %w", err)
+	}
+	defer pwBrowser.Close()
+	browserCtx, err := pwBrowser.NewContext()
+	if err != nil {
+		return fmt.Errorf("failed to create browser context: %w", err)
+	}
+	script := pickScript
+	if err := browserCtx.AddInitScript(playwright.Script{Content: &script}); err != nil {
+		return fmt.Errorf("failed to install the pick script: %w", err)
+	}
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		return fmt.Errorf("failed to open a page: %w", err)
+	}
+	if _, err := page.Goto(pageURL); err != nil {
+		return fmt.Errorf("failed to load %s: %w", pageURL, err)
+	}
+	fmt.Println("Click elements in the browser window to print their selectors. Close the window or press Ctrl+C to stop.")
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	for !page.IsClosed() && pwBrowser.IsConnected() {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(300 * time.Millisecond):
+		}
+		picks, err := page.Evaluate(`() => window.__sitepandaPicks ? window.__sitepandaPicks.splice(0) : []`)
+		if err != nil {
+			// The page is navigating or was closed; the loop condition tells which.
+			continue
+		}
+		list, _ := picks.([]interface{})
+		for _, item := range list {
+			pick, _ := item.(map[string]interface{})
+			fmt.Printf("%v  (%v matches)  %s\n", pick["selector"], pick["matches"], truncateRunes(fmt.Sprint(pick["text"]), pickPreviewChars))
+		}
+	}
+	return nil
+}
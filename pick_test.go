@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const pickTestPage = `<html><body>
+<header><nav><a href="/">Home</a> <a href="/docs">Docs</a> <a href="/blog">Blog</a></nav></header>
+<div class="layout">
+  <aside><a href="/a">Sidebar link one</a> <a href="/b">Sidebar link two</a></aside>
+  <div class="content">
+    <article class="post body">
+      <h1>Getting started</h1>
+      <p>Sitepanda crawls a website and extracts the main content of every page as Markdown.</p>
+      <p>This guide walks through installing the browser and running a first scrape.</p>
+      <p>See <a href="/docs/flags">the flags reference</a> for every option.</p>
+    </article>
+  </div>
+</div>
+<footer>Copyright</footer>
+</body></html>`
+
+func TestRankContentCandidates(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(pickTestPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates := rankContentCandidates(doc, 10)
+	if len(candidates) == 0 {
+		t.Fatal("no candidates")
+	}
+	if got := candidates[0].Selector; got != "article.post.body" {
+		t.Errorf("top candidate = %q, want article.post.body (all: %+v)", got, candidates)
+	}
+	for _, c := range candidates {
+		if strings.Contains(c.Preview, "Sidebar") || strings.Contains(c.Preview, "Home") {
+			t.Errorf("candidate %s counts boilerplate text: %q", c.Selector, c.Preview)
+		}
+		if c.LinkChars != len("the flags reference") {
+			t.Errorf("candidate %s has %d link characters, want %d", c.Selector, c.LinkChars, len("the flags reference"))
+		}
+	}
+	if got := rankContentCandidates(doc, 1); len(got) != 1 {
+		t.Errorf("top 1 returned %d candidates", len(got))
+	}
+
+	var out bytes.Buffer
+	if err := writePickCandidates(&out, candidates[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "SELECTOR") || !strings.Contains(out.String(), "article.post.body") {
+		t.Errorf("table = %q", out.String())
+	}
+}
+
+func TestCSSSelectorFor(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><body>
+<div id="main"><section><p>a</p><p>b</p></section></div>
+<div class="card"><span>x</span></div>
+<div class="card"><span>y</span></div>
+<div id="bad id"><em>z</em></div>
+</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		find string
+		want string
+	}{
+		{"#main", "#main"},
+		{"#main p:last-child", "p:nth-of-type(2)"},
+		{`span:contains("y")`, "div.card:nth-of-type(3) > span"},
+		{"em", "em"},
+	}
+	for _, tt := range tests {
+		s := doc.Find(tt.find).First()
+		got := cssSelectorFor(doc, s)
+		if got != tt.want {
+			t.Errorf("cssSelectorFor(%s) = %q, want %q", tt.find, got, tt.want)
+		}
+		if matched := doc.Find(got); matched.Length() != 1 || matched.Get(0) != s.Get(0) {
+			t.Errorf("selector %q does not match only the element", got)
+		}
+	}
+}
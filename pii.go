@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// piiDetector finds one kind of personal data for --redact-pii. Valid, if set, rejects candidate
+// matches that the pattern alone cannot rule out.
+type piiDetector struct {
+	Kind    string
+	Mask    string
+	Pattern *regexp.Regexp
+	Valid   func(match string) bool
+}
+
+// piiDetectors lists the supported --redact-pii kinds.
+var piiDetectors = map[string]piiDetector{
+	"emails": {
+		Kind:    "emails",
+		Mask:    "[REDACTED EMAIL]",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	},
+	"phones": {
+		Kind:    "phones",
+		Mask:    "[REDACTED PHONE]",
+		Pattern: regexp.MustCompile(`\+\d{8,15}|(?:\+\d{1,3}[ .-]?)?(?:\(\d{1,4}\)[ .-]?|\d{2,4}[ .-])(?:\d{2,4}[ .-]){0,3}\d{2,4}`),
+		Valid:   validPhoneNumber,
+	},
+	"ips": {
+		Kind:    "ips",
+		Mask:    "[REDACTED IP]",
+		Pattern: regexp.MustCompile(`\d{1,3}(?:\.\d{1,3}){3}|[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`),
+		Valid:   validIPAddress,
+	},
+}
+
+// piiDetectorOrder is the order detectors run in: IP addresses before phone numbers, so a
+// dotted address is not mistaken for a phone number.
+var piiDetectorOrder = []string{"emails", "ips", "phones"}
+
+// parsePIIKinds resolves a --redact-pii list such as ["emails", "phones"] to detectors.
+func parsePIIKinds(kinds []string) ([]piiDetector, error) {
+	selected := make(map[string]bool)
+	for _, kind := range kinds {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		if kind == "" {
+			continue
+		}
+		if _, ok := piiDetectors[kind]; !ok {
+			return nil, fmt.Errorf("invalid --redact-pii kind %q: must be emails, phones or ips", kind)
+		}
+		selected[kind] = true
+	}
+	var detectors []piiDetector
+	for _, kind := range piiDetectorOrder {
+		if selected[kind] {
+			detectors = append(detectors, piiDetectors[kind])
+		}
+	}
+	return detectors, nil
+}
+
+// validPhoneNumber accepts matches with 9 to 15 digits, so dates and short number runs are left alone.
+func validPhoneNumber(match string) bool {
+	digits := 0
+	for _, r := range match {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits >= 9 && digits <= 15
+}
+
+// validIPAddress accepts IPv4 and IPv6 addresses with at least two non-zero-length groups, which
+// keeps things like "d::" in code and "10:30:00" times from being redacted.
+func validIPAddress(match string) bool {
+	if net.ParseIP(match) == nil {
+		return false
+	}
+	if strings.Contains(match, ".") {
+		return true
+	}
+	groups := 0
+	for _, g := range strings.Split(match, ":") {
+		if g != "" {
+			groups++
+		}
+	}
+	return groups >= 2
+}
+
+// redactPII masks every match of detector in s and returns the result and the number of matches.
+// Matches that continue a longer word or number are skipped.
+func redactPII(s string, detector piiDetector) (string, int) {
+	var b strings.Builder
+	count, last := 0, 0
+	for _, loc := range detector.Pattern.FindAllStringIndex(s, -1) {
+		match := s[loc[0]:loc[1]]
+		if !piiBoundary(s, loc[0], loc[1]) || (detector.Valid != nil && !detector.Valid(match)) {
+			continue
+		}
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(detector.Mask)
+		last = loc[1]
+		count++
+	}
+	if count == 0 {
+		return s, 0
+	}
+	b.WriteString(s[last:])
+	return b.String(), count
+}
+
+// piiBoundary reports whether s[start:end] is not glued to surrounding letters or digits.
+func piiBoundary(s string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return false
+		}
+	}
+	if end < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// redactPageData masks personal data in everything of pd that is written out: the Markdown,
+// comment texts, the accessibility tree and table cells for CSV files. It adds the number of
+// redactions per kind to counts.
+func redactPageData(pd *PageData, detectors []piiDetector, counts map[string]int) {
+	redact := func(s string) string {
+		for _, detector := range detectors {
+			var n int
+			s, n = redactPII(s, detector)
+			counts[detector.Kind] += n
+		}
+		return s
+	}
+	pd.Markdown = redact(pd.Markdown)
+	pd.A11yTree = redact(pd.A11yTree)
+	for i := range pd.Comments {
+		pd.Comments[i].Text = redact(pd.Comments[i].Text)
+	}
+	for _, table := range pd.Tables {
+		for _, row := range table.Rows {
+			for j := range row {
+				row[j] = redact(row[j])
+			}
+		}
+	}
+}
+
+// formatPIIRedactionCounts renders redaction counts for the summary, e.g. "emails 3, phones 0".
+func formatPIIRedactionCounts(counts map[string]int) string {
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%s %d", kind, counts[kind])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedactPII(t *testing.T) {
+	detectors, err := parsePIIKinds([]string{"phones", "emails", "ips"})
+	if err != nil {
+		t.Fatalf("parsePIIKinds() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "email", in: "Contact jane.doe+news@mail.example.co.uk.", want: "Contact [REDACTED EMAIL]."},
+		{name: "mailto link", in: "[mail](mailto:info@example.com)", want: "[mail](mailto:[REDACTED EMAIL])"},
+		{name: "us phone", in: "Call 555-123-4567 today", want: "Call [REDACTED PHONE] today"},
+		{name: "international phone", in: "Tel: +44 20 7946 0958", want: "Tel: [REDACTED PHONE]"},
+		{name: "parenthesized area code", in: "(03) 1234 5678", want: "[REDACTED PHONE]"},
+		{name: "e164", in: "Phone +819012345678.", want: "Phone [REDACTED PHONE]."},
+		{name: "date kept", in: "Released 2023-05-12.", want: "Released 2023-05-12."},
+		{name: "short numbers kept", in: "Pages 10 20 30", want: "Pages 10 20 30"},
+		{name: "ipv4", in: "Server 192.168.10.254 responded", want: "Server [REDACTED IP] responded"},
+		{name: "invalid ipv4 kept", in: "Version 999.1.1.1", want: "Version 999.1.1.1"},
+		{name: "ipv6", in: "Host 2001:db8::8a2e:370:7334 up", want: "Host [REDACTED IP] up"},
+		{name: "time kept", in: "At 10:30:00 UTC", want: "At 10:30:00 UTC"},
+		{name: "cpp scope kept", in: "Use std::vector here", want: "Use std::vector here"},
+		{name: "digits inside word kept", in: "id abc5551234567", want: "id abc5551234567"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in
+			for _, d := range detectors {
+				got, _ = redactPII(got, d)
+			}
+			if got != tt.want {
+				t.Errorf("redacted = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePIIKinds(t *testing.T) {
+	detectors, err := parsePIIKinds([]string{" Phones", "ips", "phones", ""})
+	if err != nil {
+		t.Fatalf("parsePIIKinds() error = %v", err)
+	}
+	var kinds []string
+	for _, d := range detectors {
+		kinds = append(kinds, d.Kind)
+	}
+	if want := []string{"ips", "phones"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("parsePIIKinds() kinds = %v, want %v", kinds, want)
+	}
+	if _, err := parsePIIKinds([]string{"names"}); err == nil {
+		t.Errorf("parsePIIKinds(names) expected error")
+	}
+}
+
+func TestRedactPageData(t *testing.T) {
+	detectors, _ := parsePIIKinds([]string{"emails", "phones"})
+	pd := PageData{
+		Markdown: "Mail a@example.com or b@example.com",
+		Comments: []Comment{{Author: "x", Text: "call me at 090-1234-5678"}},
+		Tables:   []Table{{Rows: [][]string{{"Name", "Email"}, {"A", "a@example.com"}}}},
+	}
+	counts := map[string]int{"emails": 0, "phones": 0}
+	redactPageData(&pd, detectors, counts)
+
+	if pd.Markdown != "Mail [REDACTED EMAIL] or [REDACTED EMAIL]" {
+		t.Errorf("Markdown = %q", pd.Markdown)
+	}
+	if pd.Comments[0].Text != "call me at [REDACTED PHONE]" {
+		t.Errorf("comment = %q", pd.Comments[0].Text)
+	}
+	if pd.Tables[0].Rows[1][1] != "[REDACTED EMAIL]" {
+		t.Errorf("table cell = %q", pd.Tables[0].Rows[1][1])
+	}
+	if want := map[string]int{"emails": 3, "phones": 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+	if got := formatPIIRedactionCounts(counts); got != "emails 3, phones 1" {
+		t.Errorf("formatPIIRedactionCounts() = %q", got)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// Plugin hooks. A plugin lists the ones it implements in its initialize result.
+const (
+	pluginHookRequest = "OnRequest"
+	pluginHookHTML    = "OnHTML"
+	pluginHookPage    = "OnPage"
+	pluginHookFinish  = "OnFinish"
+)
+
+// pluginCallTimeout bounds a single hook call; a plugin that does not answer in time is stopped.
+const pluginCallTimeout = 30 * time.Second
+
+// pluginRequest is a JSON-RPC 2.0 request, sent to a plugin as one line on its stdin.
+type pluginRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int64  `json:"id"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// pluginResponse is a JSON-RPC 2.0 response, read from one line of a plugin's stdout.
+type pluginResponse struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// pluginPageResult is the result of OnPage. Title and Content replace the page's, Extracted
+// entries are added to its extracted fields, and Drop leaves the page out.
+type pluginPageResult struct {
+	Drop      bool                       `json:"drop"`
+	Reason    string                     `json:"reason"`
+	Title     *string                    `json:"title"`
+	Content   *string                    `json:"content"`
+	Extracted map[string]json.RawMessage `json:"extracted"`
+}
+
+// pluginProcess is an external executable that extends the crawl through hooks, called as JSON-RPC
+// over its stdin and stdout. Its stderr is passed through.
+type pluginProcess struct {
+	name      string
+	stdin     io.WriteCloser
+	responses chan pluginResponse
+	done      chan struct{}
+	hooks     map[string]bool
+	nextID    int64
+	stopped   bool
+	cmd       *exec.Cmd
+}
+
+// newPlugin talks to a plugin over stdin and stdout and performs the initialize handshake.
+func newPlugin(name string, stdin io.WriteCloser, stdout io.Reader) (*pluginProcess, error) {
+	p := &pluginProcess{name: name, stdin: stdin, responses: make(chan pluginResponse), done: make(chan struct{}), hooks: make(map[string]bool)}
+	go func() {
+		defer close(p.responses)
+		reader := bufio.NewReader(stdout)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(line) > 0 {
+				var resp pluginResponse
+				if jsonErr := json.Unmarshal(line, &resp); jsonErr != nil {
+					logger.Printf("Plugin %s: ignoring invalid response line: %v", name, jsonErr)
+				} else {
+					select {
+					case p.responses <- resp:
+					case <-p.done:
+						return
+					}
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var init struct {
+		Hooks []string `json:"hooks"`
+	}
+	if err := p.call("initialize", map[string]string{"sitepanda_version": Version}, &init); err != nil {
+		return nil, fmt.Errorf("plugin %s: initialize failed: %w", name, err)
+	}
+	for _, hook := range init.Hooks {
+		switch hook {
+		case pluginHookRequest, pluginHookHTML, pluginHookPage, pluginHookFinish:
+			p.hooks[hook] = true
+		default:
+			return nil, fmt.Errorf("plugin %s: unknown hook %q", name, hook)
+		}
+	}
+	return p, nil
+}
+
+// startPlugin runs the plugin executable at path.
+func startPlugin(path string) (*pluginProcess, error) {
+	cmd := exec.Command(path)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+	p, err := newPlugin(filepath.Base(path), stdin, stdout)
+	if err != nil {
+		stdin.Close()
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	p.cmd = cmd
+	return p, nil
+}
+
+// call sends a request and decodes the matching response's result into result.
+func (p *pluginProcess) call(method string, params any, result any) error {
+	if p.stopped {
+		return errors.New("plugin stopped")
+	}
+	p.nextID++
+	data, err := json.Marshal(pluginRequest{JSONRPC: "2.0", ID: p.nextID, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		p.stop()
+		return fmt.Errorf("plugin exited: %w", err)
+	}
+	timeout := time.After(pluginCallTimeout)
+	for {
+		select {
+		case resp, ok := <-p.responses:
+			if !ok {
+				p.stop()
+				return errors.New("plugin exited")
+			}
+			if resp.ID != p.nextID {
+				continue
+			}
+			if resp.Error != nil {
+				return fmt.Errorf("%s (code %d)", resp.Error.Message, resp.Error.Code)
+			}
+			if result == nil || len(resp.Result) == 0 || string(resp.Result) == "null" {
+				return nil
+			}
+			return json.Unmarshal(resp.Result, result)
+		case <-timeout:
+			p.stop()
+			return fmt.Errorf("no response within %s", pluginCallTimeout)
+		}
+	}
+}
+
+// stop closes the plugin's stdin, which asks it to exit, and waits a moment before killing it.
+func (p *pluginProcess) stop() {
+	if p.stopped {
+		return
+	}
+	p.stopped = true
+	close(p.done)
+	p.stdin.Close()
+	if p.cmd == nil {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		p.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		p.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// pluginSet holds the --plugin processes in the order they were given. Each hook is called on
+// the plugins that implement it, in order; a nil set calls nothing.
+type pluginSet []*pluginProcess
+
+// startPlugins starts the plugins at paths, stopping those already started if one fails.
+func startPlugins(paths []string) (pluginSet, error) {
+	var plugins pluginSet
+	for _, path := range paths {
+		p, err := startPlugin(path)
+		if err != nil {
+			plugins.Close()
+			return nil, err
+		}
+		logger.Printf("Plugin %s started (hooks: %d).", p.name, len(p.hooks))
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// callHook calls hook if the plugin implements it and returns the result, or false if it was
+// not called or failed. Failures are logged and do not stop the crawl.
+func (p *pluginProcess) callHook(hook string, params any) (json.RawMessage, bool) {
+	if !p.hooks[hook] || p.stopped {
+		return nil, false
+	}
+	var raw json.RawMessage
+	if err := p.call(hook, params, &raw); err != nil {
+		logger.Printf("Warning: plugin %s %s failed: %v", p.name, hook, err)
+		return nil, false
+	}
+	return raw, len(raw) > 0
+}
+
+// OnRequest asks the plugins whether pageURL should be fetched. It returns why a plugin
+// skipped it, or "".
+func (ps pluginSet) OnRequest(pageURL string) string {
+	for _, p := range ps {
+		raw, ok := p.callHook(pluginHookRequest, map[string]string{"url": pageURL})
+		if !ok {
+			continue
+		}
+		var res struct {
+			Skip   bool   `json:"skip"`
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			logger.Printf("Warning: plugin %s %s returned an invalid result: %v", p.name, pluginHookRequest, err)
+		} else if res.Skip {
+			return pluginReason("skipped", p, res.Reason)
+		}
+	}
+	return ""
+}
+
+// OnHTML passes the fetched HTML through the plugins, each of which may replace it.
+func (ps pluginSet) OnHTML(pageURL string, html string) string {
+	for _, p := range ps {
+		raw, ok := p.callHook(pluginHookHTML, map[string]string{"url": pageURL, "html": html})
+		if !ok {
+			continue
+		}
+		var res struct {
+			HTML *string `json:"html"`
+		}
+		if err := json.Unmarshal(raw, &res); err != nil {
+			logger.Printf("Warning: plugin %s %s returned an invalid result: %v", p.name, pluginHookHTML, err)
+		} else if res.HTML != nil {
+			html = *res.HTML
+		}
+	}
+	return html
+}
+
+// OnPage lets the plugins edit a page before it is saved. It returns why a plugin dropped the
+// page, or "".
+func (ps pluginSet) OnPage(pd *PageData) string {
+	for _, p := range ps {
+		raw, ok := p.callHook(pluginHookPage, map[string]any{"page": newJSONOutputPage(pd)})
+		if !ok {
+			continue
+		}
+		var res pluginPageResult
+		if err := json.Unmarshal(raw, &res); err != nil {
+			logger.Printf("Warning: plugin %s %s returned an invalid result: %v", p.name, pluginHookPage, err)
+			continue
+		}
+		if res.Drop {
+			return pluginReason("dropped", p, res.Reason)
+		}
+		res.apply(pd)
+	}
+	return ""
+}
+
+// apply makes the edits of an OnPage result to pd.
+func (res pluginPageResult) apply(pd *PageData) {
+	if res.Title != nil {
+		pd.Title = *res.Title
+	}
+	if res.Content != nil {
+		pd.Markdown = *res.Content
+	}
+	for key, value := range res.Extracted {
+		if pd.Extracted == nil {
+			pd.Extracted = make(map[string]json.RawMessage)
+		}
+		pd.Extracted[key] = value
+	}
+}
+
+// OnFinish tells the plugins how the crawl ended.
+func (ps pluginSet) OnFinish(result CrawlResult) {
+	params := map[string]any{
+		"stop_reason":   result.StopReason,
+		"pages_saved":   result.PagesSaved,
+		"pages_failed":  result.PagesFailed,
+		"pages_skipped": len(result.SkippedPages),
+		"output_file":   result.OutputFile,
+	}
+	for _, p := range ps {
+		p.callHook(pluginHookFinish, params)
+	}
+}
+
+func pluginReason(action string, p *pluginProcess, reason string) string {
+	if reason == "" {
+		return fmt.Sprintf("%s by plugin %s", action, p.name)
+	}
+	return fmt.Sprintf("%s by plugin %s: %s", action, p.name, reason)
+}
+
+// Close stops all plugins.
+func (ps pluginSet) Close() {
+	for _, p := range ps {
+		p.stop()
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+// fakePlugin serves JSON-RPC requests on pipes with handle, which returns a result or an error
+// message, and returns the plugin end connected to it.
+func fakePlugin(t *testing.T, hooks []string, handle func(method string, params json.RawMessage) (any, string)) *pluginProcess {
+	t.Helper()
+	stdinReader, stdinWriter := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	go func() {
+		defer stdoutWriter.Close()
+		scanner := bufio.NewScanner(stdinReader)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			var req struct {
+				ID     int64           `json:"id"`
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			json.Unmarshal(scanner.Bytes(), &req)
+			resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+			if req.Method == "initialize" {
+				resp["result"] = map[string]any{"hooks": hooks}
+			} else if result, errMsg := handle(req.Method, req.Params); errMsg != "" {
+				resp["error"] = map[string]any{"code": -32000, "message": errMsg}
+			} else {
+				resp["result"] = result
+			}
+			data, _ := json.Marshal(resp)
+			stdoutWriter.Write(append(data, '\n'))
+		}
+	}()
+	p, err := newPlugin("fake", stdinWriter, stdoutReader)
+	if err != nil {
+		t.Fatalf("newPlugin: %v", err)
+	}
+	t.Cleanup(p.stop)
+	return p
+}
+
+func TestPluginHooks(t *testing.T) {
+	var finished map[string]any
+	p := fakePlugin(t, []string{pluginHookRequest, pluginHookHTML, pluginHookPage, pluginHookFinish}, func(method string, params json.RawMessage) (any, string) {
+		var args map[string]any
+		json.Unmarshal(params, &args)
+		switch method {
+		case pluginHookRequest:
+			if strings.Contains(args["url"].(string), "/private/") {
+				return map[string]any{"skip": true, "reason": "private area"}, ""
+			}
+			return map[string]any{"skip": false}, ""
+		case pluginHookHTML:
+			return map[string]any{"html": strings.ReplaceAll(args["html"].(string), "Ad", "")}, ""
+		case pluginHookPage:
+			page := args["page"].(map[string]any)
+			if page["title"] == "Drop me" {
+				return map[string]any{"drop": true}, ""
+			}
+			return map[string]any{"content": strings.ToUpper(page["content"].(string)), "extracted": map[string]any{"words": 2}}, ""
+		case pluginHookFinish:
+			finished = args
+			return nil, ""
+		}
+		return nil, "unexpected method " + method
+	})
+	plugins := pluginSet{p}
+
+	if got := plugins.OnRequest("https://example.com/private/a"); got != "skipped by plugin fake: private area" {
+		t.Errorf("OnRequest(private) = %q", got)
+	}
+	if got := plugins.OnRequest("https://example.com/docs/"); got != "" {
+		t.Errorf("OnRequest(docs) = %q, want \"\"", got)
+	}
+	if got := plugins.OnHTML("https://example.com/", "<p>Ad</p><p>Text</p>"); got != "<p></p><p>Text</p>" {
+		t.Errorf("OnHTML = %q", got)
+	}
+
+	pd := &PageData{Title: "Docs", URL: "https://example.com/docs/", Markdown: "hello world"}
+	if got := plugins.OnPage(pd); got != "" {
+		t.Errorf("OnPage = %q, want \"\"", got)
+	}
+	if pd.Markdown != "HELLO WORLD" || string(pd.Extracted["words"]) != "2" || pd.Title != "Docs" {
+		t.Errorf("OnPage edited page to %+v", pd)
+	}
+	if got := plugins.OnPage(&PageData{Title: "Drop me"}); got != "dropped by plugin fake" {
+		t.Errorf("OnPage(drop) = %q", got)
+	}
+
+	plugins.OnFinish(CrawlResult{StopReason: "Completed", PagesSaved: 1})
+	if finished["stop_reason"] != "Completed" || finished["pages_saved"] != float64(1) {
+		t.Errorf("OnFinish params = %v", finished)
+	}
+}
+
+func TestPluginOnlyCalledForItsHooks(t *testing.T) {
+	var calls []string
+	p := fakePlugin(t, []string{pluginHookPage}, func(method string, _ json.RawMessage) (any, string) {
+		calls = append(calls, method)
+		return map[string]any{}, ""
+	})
+	plugins := pluginSet{p}
+	plugins.OnRequest("https://example.com/")
+	plugins.OnHTML("https://example.com/", "<p>x</p>")
+	plugins.OnPage(&PageData{})
+	if len(calls) != 1 || calls[0] != pluginHookPage {
+		t.Errorf("calls = %v, want only %s", calls, pluginHookPage)
+	}
+}
+
+func TestPluginErrorsDoNotStopCrawl(t *testing.T) {
+	p := fakePlugin(t, []string{pluginHookHTML}, func(string, json.RawMessage) (any, string) {
+		return nil, "boom"
+	})
+	if got := (pluginSet{p}).OnHTML("https://example.com/", "<p>x</p>"); got != "<p>x</p>" {
+		t.Errorf("OnHTML after error = %q, want the original HTML", got)
+	}
+
+	var none pluginSet
+	if got := none.OnPage(&PageData{}); got != "" {
+		t.Errorf("nil set OnPage = %q", got)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gobwas/glob"
+	"gopkg.in/yaml.v2"
+)
+
+// Policy actions: what the crawler does with a page whose path matches a rule.
+const (
+	policyActionSave       = "save"        // save the page and follow its links
+	policyActionSaveOnly   = "save-only"   // save the page without following its links
+	policyActionFollowOnly = "follow-only" // follow the page's links without saving it
+	policyActionSkip       = "skip"        // neither fetch nor enqueue the page
+)
+
+// crawlPolicy is a --policy file: rules mapping path globs to crawl behavior. The first rule
+// whose path matches a URL applies; URLs no rule matches are governed by --match and
+// --follow-match. A nil policy matches nothing.
+type crawlPolicy struct {
+	Rules []*policyRule `yaml:"rules"`
+}
+
+// policyRule is one entry of a --policy file.
+type policyRule struct {
+	// Path is a glob matched against the URL path, as in --match.
+	Path   string `yaml:"path"`
+	Action string `yaml:"action"`
+	// Delay is waited before each fetch of a matching page, e.g. "2s".
+	Delay string `yaml:"delay"`
+	// Selector overrides --content-selector.
+	Selector string `yaml:"selector"`
+	// Wait is "load" or "networkidle" and overrides --wait-for-network-idle.
+	Wait string `yaml:"wait"`
+	// MaxPages caps the pages saved under this rule (0 for no cap).
+	MaxPages int `yaml:"max_pages"`
+
+	glob               glob.Glob
+	delay              time.Duration
+	waitForNetworkIdle *bool
+}
+
+// parsePolicy parses and validates the YAML of a --policy file.
+func parsePolicy(data []byte) (*crawlPolicy, error) {
+	var policy crawlPolicy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file: %w", err)
+	}
+	if len(policy.Rules) == 0 {
+		return nil, fmt.Errorf("policy file contains no rules")
+	}
+	for i, rule := range policy.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return &policy, nil
+}
+
+// loadPolicy reads and parses a --policy file.
+func loadPolicy(path string) (*crawlPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	return parsePolicy(data)
+}
+
+func (r *policyRule) validate() error {
+	if r == nil || r.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+	g, err := glob.Compile(r.Path, '/')
+	if err != nil {
+		return fmt.Errorf("invalid path glob %q: %w", r.Path, err)
+	}
+	r.glob = g
+
+	switch r.Action {
+	case "":
+		r.Action = policyActionSave
+	case policyActionSave, policyActionSaveOnly, policyActionFollowOnly, policyActionSkip:
+	default:
+		return fmt.Errorf("unknown action %q (supported: %s, %s, %s, %s)", r.Action, policyActionSave, policyActionSaveOnly, policyActionFollowOnly, policyActionSkip)
+	}
+	if r.Delay != "" {
+		if r.delay, err = time.ParseDuration(r.Delay); err != nil || r.delay < 0 {
+			return fmt.Errorf("invalid delay %q (expected a duration such as 500ms or 2s)", r.Delay)
+		}
+	}
+	switch r.Wait {
+	case "":
+	case "load", "networkidle":
+		networkIdle := r.Wait == "networkidle"
+		r.waitForNetworkIdle = &networkIdle
+	default:
+		return fmt.Errorf("invalid wait value %q (supported: load, networkidle)", r.Wait)
+	}
+	if r.MaxPages < 0 {
+		return fmt.Errorf("max_pages must not be negative, got %d", r.MaxPages)
+	}
+	if r.Action == policyActionSkip && (r.Delay != "" || r.Selector != "" || r.Wait != "" || r.MaxPages > 0) {
+		return fmt.Errorf("a skip rule cannot set delay, selector, wait or max_pages")
+	}
+	return nil
+}
+
+// Match returns the first rule whose path matches u, or nil.
+func (p *crawlPolicy) Match(u *url.URL) *policyRule {
+	if p == nil {
+		return nil
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	} else if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	for _, rule := range p.Rules {
+		if rule.glob.Match(path) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// saves reports whether pages under the rule are saved.
+func (r *policyRule) saves() bool {
+	return r.Action == policyActionSave || r.Action == policyActionSaveOnly
+}
+
+// follows reports whether links on pages under the rule are followed.
+func (r *policyRule) follows() bool {
+	return r.Action == policyActionSave || r.Action == policyActionFollowOnly
+}
+
+// describe summarizes the rule's effect for logs and 'sitepanda check-policy'.
+func (r *policyRule) describe() string {
+	parts := []string{r.Action}
+	if r.delay > 0 {
+		parts = append(parts, "delay "+r.delay.String())
+	}
+	if r.Selector != "" {
+		parts = append(parts, "selector "+r.Selector)
+	}
+	if r.Wait != "" {
+		parts = append(parts, "wait "+r.Wait)
+	}
+	if r.MaxPages > 0 {
+		parts = append(parts, fmt.Sprintf("max %d pages", r.MaxPages))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// policySkipReason returns why a URL under rule must not be fetched, or "".
+func (c *Crawler) policySkipReason(rule *policyRule) string {
+	switch {
+	case rule == nil:
+		return ""
+	case rule.Action == policyActionSkip:
+		return fmt.Sprintf("policy rule %s: skip", rule.Path)
+	case rule.MaxPages > 0 && c.policySaved[rule] >= rule.MaxPages:
+		return fmt.Sprintf("policy rule %s: max_pages %d reached", rule.Path, rule.MaxPages)
+	}
+	return ""
+}
+
+// sleepContext waits for d, returning early with false if ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// writePolicyCheck prints the rule that applies to each URL and what the crawler does with it.
+func writePolicyCheck(w io.Writer, policy *crawlPolicy, urls []string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "URL\tRULE\tBEHAVIOR")
+	for _, rawURL := range urls {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+		}
+		if rule := policy.Match(u); rule != nil {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", rawURL, rule.Path, rule.describe())
+		} else {
+			fmt.Fprintf(tw, "%s\t-\tno rule (--match and --follow-match apply)\n", rawURL)
+		}
+	}
+	return tw.Flush()
+}
+
+// HandleCheckPolicy validates a --policy file and shows which rule applies to the given URLs.
+func HandleCheckPolicy(path string, urls []string) {
+	policy, err := loadPolicy(path)
+	if err != nil {
+		logger.Fatalf("Error: %s: %v", path, err)
+	}
+	fmt.Printf("%s: %d rules OK\n", path, len(policy.Rules))
+	for i, rule := range policy.Rules {
+		fmt.Printf("  %d. %s: %s\n", i+1, rule.Path, rule.describe())
+	}
+	if len(urls) == 0 {
+		return
+	}
+	fmt.Println()
+	if err := writePolicyCheck(os.Stdout, policy, urls); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+}
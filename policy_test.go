@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testPolicy = `
+rules:
+  - path: "/blog/tag/**"
+    action: skip
+  - path: "/blog/**"
+    selector: article
+    delay: 500ms
+    max_pages: 2
+  - path: "/blog"
+    action: follow-only
+    wait: networkidle
+  - path: "/docs/*/archive/**"
+    action: save-only
+`
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("parsePolicy: %v", err)
+	}
+	if len(policy.Rules) != 4 {
+		t.Fatalf("got %d rules, want 4", len(policy.Rules))
+	}
+	blog := policy.Rules[1]
+	if blog.Action != policyActionSave || blog.delay != 500*time.Millisecond || blog.Selector != "article" || blog.MaxPages != 2 {
+		t.Errorf("blog rule = %+v", blog)
+	}
+	if index := policy.Rules[2]; index.waitForNetworkIdle == nil || !*index.waitForNetworkIdle {
+		t.Errorf("wait: networkidle not parsed: %+v", index)
+	}
+
+	invalid := []struct {
+		name, yaml, wantErr string
+	}{
+		{"no rules", "rules: []", "no rules"},
+		{"unknown key", "rules:\n  - path: /a\n    actoin: skip", "invalid policy file"},
+		{"missing path", "rules:\n  - action: skip", "rule 1: path is required"},
+		{"bad glob", "rules:\n  - path: \"/a/[\"", "invalid path glob"},
+		{"bad action", "rules:\n  - path: /a\n    action: crawl", "unknown action"},
+		{"bad delay", "rules:\n  - path: /a\n    delay: soon", "invalid delay"},
+		{"bad wait", "rules:\n  - path: /a\n    wait: idle", "invalid wait value"},
+		{"negative max", "rules:\n  - path: /a\n    max_pages: -1", "must not be negative"},
+		{"skip with options", "rules:\n  - path: /a\n    action: skip\n    delay: 1s", "skip rule cannot set"},
+	}
+	for _, tt := range invalid {
+		if _, err := parsePolicy([]byte(tt.yaml)); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: parsePolicy error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPolicyMatch(t *testing.T) {
+	policy, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/blog/tag/go", "/blog/tag/**"},
+		{"https://example.com/blog/2024/post", "/blog/**"},
+		{"https://example.com/blog", "/blog"},
+		{"https://example.com/docs/v1/archive/a", "/docs/*/archive/**"},
+		{"https://example.com/about", ""},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		got := ""
+		if rule := policy.Match(u); rule != nil {
+			got = rule.Path
+		}
+		if got != tt.want {
+			t.Errorf("Match(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	var none *crawlPolicy
+	if rule := none.Match(&url.URL{Path: "/"}); rule != nil {
+		t.Errorf("nil policy matched %+v", rule)
+	}
+}
+
+func TestPolicySkipReason(t *testing.T) {
+	policy, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Crawler{opts: CrawlOptions{Policy: policy}, policySaved: make(map[*policyRule]int)}
+	skip, blog := policy.Rules[0], policy.Rules[1]
+
+	if got := c.policySkipReason(skip); got != "policy rule /blog/tag/**: skip" {
+		t.Errorf("skip rule reason = %q", got)
+	}
+	if got := c.policySkipReason(blog); got != "" {
+		t.Errorf("blog rule reason before cap = %q", got)
+	}
+	c.policySaved[blog] = 2
+	if got := c.policySkipReason(blog); got != "policy rule /blog/**: max_pages 2 reached" {
+		t.Errorf("blog rule reason at cap = %q", got)
+	}
+	if got := c.policySkipReason(nil); got != "" {
+		t.Errorf("no rule reason = %q", got)
+	}
+}
+
+func TestExtractAndFilterLinksWithPolicy(t *testing.T) {
+	policy, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageURL, _ := url.Parse("http://example.com/")
+	c := &Crawler{
+		startURL:            pageURL,
+		followMatchPatterns: compileTestGlobPatterns([]string{"/docs/**"}),
+		opts:                CrawlOptions{Policy: policy},
+	}
+	html := `<a href="/blog/tag/go">tag</a><a href="/blog/post">post</a><a href="/docs/intro">docs</a><a href="/about">about</a>`
+
+	got := c.extractAndFilterLinks(pageURL, html)
+	want := []string{"http://example.com/blog/post", "http://example.com/docs/intro"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("extractAndFilterLinks() = %v, want %v", got, want)
+	}
+}
+
+func TestWritePolicyCheck(t *testing.T) {
+	policy, err := parsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writePolicyCheck(&buf, policy, []string{"https://example.com/blog/post", "https://example.com/about"}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"https://example.com/blog/post  /blog/**  save, delay 500ms, selector article, max 2 pages",
+		"https://example.com/about      -         no rule",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/hokupod/sitepanda/cmd"
+)
+
+// previewColumnWidth is the width of each Markdown column when extractions are shown side by side.
+const previewColumnWidth = 60
+
+// previewExtraction is one extraction of the previewed page.
+type previewExtraction struct {
+	Label string
+	Page  *PageData
+	Err   error
+}
+
+// runPreviewExtractions extracts rawHTML with the readability defaults and, if contentSelector
+// is set, with the selector as well.
+func runPreviewExtractions(pageURL string, rawHTML string, contentSelector string, selectorMode string) []previewExtraction {
+	page, err := processHTML(pageURL, rawHTML, "", "")
+	extractions := []previewExtraction{{Label: "readability defaults", Page: page, Err: err}}
+	if contentSelector != "" {
+		page, err := processHTML(pageURL, rawHTML, contentSelector, selectorMode)
+		extractions = append(extractions, previewExtraction{Label: "--content-selector " + contentSelector, Page: page, Err: err})
+	}
+	return extractions
+}
+
+// writePreview prints the size and title of each extraction side by side, followed by the first
+// lines of their Markdown.
+func writePreview(w io.Writer, rawBytes int, extractions []previewExtraction, lines int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	row := func(name string, value func(e previewExtraction) string) {
+		cells := []string{name}
+		for _, e := range extractions {
+			if e.Err != nil {
+				cells = append(cells, "-")
+			} else {
+				cells = append(cells, value(e))
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	header := []string{""}
+	for _, e := range extractions {
+		header = append(header, e.Label)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	row("Title", func(e previewExtraction) string { return e.Page.Title })
+	row("Raw HTML", func(previewExtraction) string { return formatByteSize(int64(rawBytes)) })
+	row("Extracted", func(e previewExtraction) string {
+		extracted := len(e.Page.Markdown)
+		ratio := 0.0
+		if rawBytes > 0 {
+			ratio = 100 * float64(extracted) / float64(rawBytes)
+		}
+		return fmt.Sprintf("%s (%.1f%%)", formatByteSize(int64(extracted)), ratio)
+	})
+	row("Words", func(e previewExtraction) string { return fmt.Sprint(len(strings.Fields(e.Page.Markdown))) })
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, e := range extractions {
+		if e.Err != nil {
+			fmt.Fprintf(w, "Extraction with %s failed: %v\n", e.Label, e.Err)
+		}
+	}
+	if lines == 0 {
+		return nil
+	}
+
+	columns := make([][]string, len(extractions))
+	height := 0
+	for i, e := range extractions {
+		if e.Err == nil {
+			columns[i] = firstLines(e.Page.Markdown, lines)
+		}
+		height = max(height, len(columns[i]))
+	}
+	fmt.Fprintf(w, "\nFirst %d lines of Markdown:\n", lines)
+	if len(columns) == 1 {
+		_, err := fmt.Fprintln(w, strings.Join(columns[0], "\n"))
+		return err
+	}
+	for y := 0; y < height; y++ {
+		var b strings.Builder
+		for i, column := range columns {
+			cell := ""
+			if y < len(column) {
+				cell = truncateRunes(column[y], previewColumnWidth)
+			}
+			if i < len(columns)-1 {
+				cell += strings.Repeat(" ", previewColumnWidth-utf8.RuneCountInString(cell)) + " | "
+			}
+			b.WriteString(cell)
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(b.String(), " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstLines returns up to n lines of text, without tabs so columns stay aligned.
+func firstLines(text string, n int) []string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	for i, line := range lines {
+		lines[i] = strings.ReplaceAll(line, "\t", "    ")
+	}
+	return lines
+}
+
+// HandlePreview runs 'sitepanda preview'.
+func HandlePreview(pageURL string) {
+	if cmd.GetSilent() {
+		SetLoggerOutput(io.Discard)
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		logger.Fatalf("Error: preview requires an http or https URL, got: %s", pageURL)
+	}
+	if err := validateSelectorMode(cmd.GetPreviewSelectorMode()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	rawHTML, err := loadPageHTML(u.String(), cmd.GetPreviewWaitForNetworkIdle())
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	extractions := runPreviewExtractions(u.String(), rawHTML, cmd.GetPreviewContentSelector(), cmd.GetPreviewSelectorMode())
+	if err := writePreview(os.Stdout, len(rawHTML), extractions, cmd.GetPreviewLines()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWritePreview(t *testing.T) {
+	extractions := []previewExtraction{
+		{Label: "readability defaults", Page: &PageData{Title: "Intro", Markdown: "# Intro\n\nHome Docs Blog\n\nWelcome to the docs."}},
+		{Label: "--content-selector article", Page: &PageData{Title: "Intro", Markdown: "Welcome to the docs.\n\n\tIndented"}},
+	}
+	var out bytes.Buffer
+	if err := writePreview(&out, 2048, extractions, 3); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"readability defaults  --content-selector article",
+		"Raw HTML   2.0KB",
+		"Words      9",
+		"First 3 lines of Markdown:",
+		"# Intro" + strings.Repeat(" ", previewColumnWidth-len("# Intro")) + " | Welcome to the docs.",
+		"Home Docs Blog" + strings.Repeat(" ", previewColumnWidth-len("Home Docs Blog")) + " |     Indented",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("preview does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "Welcome") != 1 {
+		t.Errorf("only the first 3 lines of each extraction should be shown:\n%s", got)
+	}
+}
+
+func TestWritePreviewFailedExtraction(t *testing.T) {
+	extractions := []previewExtraction{
+		{Label: "readability defaults", Page: &PageData{Title: "Intro", Markdown: "Text"}},
+		{Label: "--content-selector .missing", Err: errors.New("no content")},
+	}
+	var out bytes.Buffer
+	if err := writePreview(&out, 100, extractions, 0); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	if !strings.Contains(got, "Extraction with --content-selector .missing failed: no content") {
+		t.Errorf("missing failure line:\n%s", got)
+	}
+	if strings.Contains(got, "Markdown:") {
+		t.Errorf("--lines 0 should not print Markdown:\n%s", got)
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
 	"github.com/JohannesKaufmann/html-to-markdown/plugin"
@@ -18,9 +21,75 @@ type PageData struct {
 	Markdown    string
 	RawHTML     string
 	ArticleHTML string
+	// Breadcrumbs is the page's breadcrumb trail, if it has one.
+	Breadcrumbs []Breadcrumb
+	// Section is the breadcrumb trail above the page, e.g. "Docs > Guide".
+	Section string
+	// Tags holds taxonomy markers such as keywords, article:tag and tag/category links.
+	Tags []string
+	// Published is the detected publication date, or the zero time if unknown.
+	Published time.Time
+	// Comments holds the page's comment thread when --include-comments is used.
+	Comments []Comment
+	// Tables holds the page's data tables for sidecar CSV files (--tables csv).
+	Tables []Table
+	// Headers holds the selected HTTP response headers of the page, keyed by lower-cased name.
+	Headers map[string]string
+	// A11yTree is the page's ARIA snapshot (YAML) when --a11y-tree is used.
+	A11yTree string
+	// OGImage and Favicon are --output-dir relative paths of the images saved by --capture-og-images.
+	OGImage string
+	Favicon string
+	// Site is the --domains-file host the page was crawled for.
+	Site string
+	// FinalURL is where URL redirected to, if it did.
+	FinalURL string
+	// Canonical is the URL the page declares with <link rel="canonical"> (--respect-canonical).
+	Canonical string
+	// Status is the HTTP status of the page's main response; 0 if there was none.
+	Status int
+	// Labels are the names of the --label rules matching the page's URL.
+	Labels []string
+	// Extracted holds the JSON values of --eval-extract expressions, keyed by expression.
+	Extracted map[string]json.RawMessage
+	// Relevance is the page's BM25 score for --relevant-to, or 0 without it.
+	Relevance float64
 }
 
-func processHTML(pageURL string, rawHTML string, contentSelector string) (*PageData, error) {
+// errProcessingTimeout is returned when content extraction exceeds the per-page processing timeout.
+var errProcessingTimeout = errors.New("content processing timed out")
+
+// processHTMLWithTimeout runs processHTML but gives up after timeout (0 disables the limit).
+// Readability and Markdown conversion cannot be interrupted, so an abandoned extraction
+// keeps running in its goroutine until it finishes and its result is discarded.
+func processHTMLWithTimeout(timeout time.Duration, pageURL string, rawHTML string, contentSelector string, selectorMode string) (*PageData, error) {
+	if timeout <= 0 {
+		return processHTML(pageURL, rawHTML, contentSelector, selectorMode)
+	}
+
+	type result struct {
+		pageData *PageData
+		err      error
+	}
+	resultChan := make(chan result, 1)
+	go func() {
+		pageData, err := processHTML(pageURL, rawHTML, contentSelector, selectorMode)
+		resultChan <- result{pageData: pageData, err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case res := <-resultChan:
+		return res.pageData, res.err
+	case <-timer.C:
+		return nil, fmt.Errorf("%w after %s for %s", errProcessingTimeout, timeout, pageURL)
+	}
+}
+
+// processHTML extracts the readable content of a page. contentSelector and selectorMode narrow the
+// page down before readability runs (see selectContent).
+func processHTML(pageURL string, rawHTML string, contentSelector string, selectorMode string) (*PageData, error) {
 	parsedURL, err := url.Parse(pageURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse page URL %s: %w", pageURL, err)
@@ -33,13 +102,13 @@ func processHTML(pageURL string, rawHTML string, contentSelector string) (*PageD
 		if err != nil {
 			logger.Printf("Warning: failed to parse HTML for content selector on %s: %v. Falling back to full page for readability.", pageURL, err)
 		} else {
-			selection := doc.Find(contentSelector).First()
+			selection, matchedSelector := selectContent(doc, contentSelector, selectorMode)
 			if selection.Length() > 0 {
-				selectedHTML, err := goquery.OuterHtml(selection)
+				selectedHTML, err := selectionOuterHTML(selection)
 				if err != nil {
-					logger.Printf("Warning: failed to get outer HTML for selector '%s' on %s: %v. Falling back to full page for readability.", contentSelector, pageURL, err)
+					logger.Printf("Warning: failed to get outer HTML for selector '%s' on %s: %v. Falling back to full page for readability.", matchedSelector, pageURL, err)
 				} else {
-					logger.Printf("Successfully applied content selector '%s' on %s. Using selected HTML for readability.", contentSelector, pageURL)
+					logger.Printf("Successfully applied content selector '%s' on %s. Using selected HTML for readability.", matchedSelector, pageURL)
 					htmlToProcess = selectedHTML
 				}
 			} else {
@@ -102,12 +171,24 @@ func processHTML(pageURL string, rawHTML string, contentSelector string) (*PageD
 		return nil, fmt.Errorf("failed to convert HTML to Markdown for %s: %w", pageURL, err)
 	}
 
+	var breadcrumbs []Breadcrumb
+	var tags []string
+	var published time.Time
+	if metaDoc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML)); err == nil {
+		breadcrumbs = extractBreadcrumbsFromDocument(parsedURL, metaDoc)
+		tags = extractTagsFromDocument(parsedURL, metaDoc)
+		published = extractPublishedDateFromDocument(parsedURL, metaDoc)
+	}
 	pageData := &PageData{
 		Title:       article.Title,
 		URL:         pageURL,
 		Markdown:    strings.TrimSpace(markdownContent),
 		RawHTML:     rawHTML,
 		ArticleHTML: article.Content,
+		Breadcrumbs: breadcrumbs,
+		Section:     strings.Join(sectionTrailFromBreadcrumbs(parsedURL, breadcrumbs), sectionSeparator),
+		Tags:        tags,
+		Published:   published,
 	}
 
 	logger.Printf("Successfully processed content for %s (Title: %s, Markdown length: %d)", pageURL, article.Title, len(pageData.Markdown))
@@ -115,6 +196,56 @@ func processHTML(pageURL string, rawHTML string, contentSelector string) (*PageD
 }
 
 func formatPageDataAsXML(page *PageData) string {
-	return fmt.Sprintf("<page>\n  <title>%s</title>\n  <url>%s</url>\n  <content>\n%s\n  </content>\n</page>",
-		page.Title, page.URL, page.Markdown)
+	var metadata string
+	if page.Site != "" {
+		metadata += fmt.Sprintf("  <site>%s</site>\n", page.Site)
+	}
+	if page.FinalURL != "" {
+		metadata += fmt.Sprintf("  <final_url>%s</final_url>\n", page.FinalURL)
+	}
+	if page.Canonical != "" {
+		metadata += fmt.Sprintf("  <canonical>%s</canonical>\n", page.Canonical)
+	}
+	if page.Status != 0 {
+		metadata += fmt.Sprintf("  <status>%d</status>\n", page.Status)
+	}
+	if page.Section != "" {
+		metadata += fmt.Sprintf("  <section>%s</section>\n", page.Section)
+	}
+	if len(page.Labels) > 0 {
+		metadata += fmt.Sprintf("  <labels>%s</labels>\n", strings.Join(page.Labels, ", "))
+	}
+	if len(page.Tags) > 0 {
+		metadata += fmt.Sprintf("  <tags>%s</tags>\n", strings.Join(page.Tags, ", "))
+	}
+	if published := formatPublishedDate(page.Published); published != "" {
+		metadata += fmt.Sprintf("  <published>%s</published>\n", published)
+	}
+	if page.Relevance > 0 {
+		metadata += fmt.Sprintf("  <relevance>%g</relevance>\n", page.Relevance)
+	}
+	if len(page.Extracted) > 0 {
+		extracted, _ := json.Marshal(page.Extracted)
+		metadata += fmt.Sprintf("  <extracted>%s</extracted>\n", extracted)
+	}
+	var comments string
+	if len(page.Comments) > 0 {
+		comments = fmt.Sprintf("  <comments>\n%s\n  </comments>\n", formatCommentsAsMarkdown(page.Comments))
+	}
+	if page.A11yTree != "" {
+		comments += fmt.Sprintf("  <a11y_tree>\n%s\n  </a11y_tree>\n", page.A11yTree)
+	}
+	return fmt.Sprintf("<page>\n  <title>%s</title>\n  <url>%s</url>\n%s  <content>\n%s\n  </content>\n%s</page>",
+		page.Title, page.URL, metadata, page.Markdown, comments)
+}
+
+// formatPublishedDate renders a publication date for output, or "" if it is unknown.
+func formatPublishedDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
 }
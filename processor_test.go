@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestProcessHTML(t *testing.T) {
@@ -149,7 +151,7 @@ func TestProcessHTML(t *testing.T) {
 			// Note: processHTML uses a global logger. For more isolated tests,
 			// the logger could be injected. For now, we accept global logger usage.
 
-			pageData, err := processHTML(tt.pageURL, tt.rawHTML, tt.contentSelector)
+			pageData, err := processHTML(tt.pageURL, tt.rawHTML, tt.contentSelector, "")
 
 			if (err != nil) != tt.expectError {
 				t.Fatalf("processHTML() error = %v, wantErr %v", err, tt.expectError)
@@ -287,6 +289,16 @@ func TestFormatPageDataAsXML(t *testing.T) {
 			// The current Sprintf doesn't escape these for the content block, which is typical for this kind of XML-like format.
 			want: "<page>\n  <title>Special Chars < > &</title>\n  <url>http://example.com/special</url>\n  <content>\nText with <, >, &, ' and \" should appear as is.\n  </content>\n</page>",
 		},
+		{
+			name: "page with section",
+			page: PageData{
+				Title:    "Install",
+				URL:      "http://example.com/docs/install",
+				Markdown: "Steps.",
+				Section:  "Docs > Guide",
+			},
+			want: "<page>\n  <title>Install</title>\n  <url>http://example.com/docs/install</url>\n  <section>Docs > Guide</section>\n  <content>\nSteps.\n  </content>\n</page>",
+		},
 	}
 
 	for _, tt := range tests {
@@ -300,3 +312,25 @@ func TestFormatPageDataAsXML(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessHTMLWithTimeout(t *testing.T) {
+	rawHTML := `<html><head><title>Timeout Test</title></head><body><article><h1>Heading</h1><p>` +
+		strings.Repeat("Some reasonably long paragraph text. ", 2000) + `</p></article></body></html>`
+
+	t.Run("No timeout behaves like processHTML", func(t *testing.T) {
+		pageData, err := processHTMLWithTimeout(0, "http://example.com/page", rawHTML, "", "")
+		if err != nil {
+			t.Fatalf("processHTMLWithTimeout() unexpected error: %v", err)
+		}
+		if pageData == nil || pageData.Markdown == "" {
+			t.Errorf("Expected extracted Markdown, got %+v", pageData)
+		}
+	})
+
+	t.Run("Exceeded timeout returns errProcessingTimeout", func(t *testing.T) {
+		_, err := processHTMLWithTimeout(time.Nanosecond, "http://example.com/page", rawHTML, "", "")
+		if !errors.Is(err, errProcessingTimeout) {
+			t.Errorf("Expected errProcessingTimeout, got %v", err)
+		}
+	})
+}
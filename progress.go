@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Progress formats accepted by --progress-format.
+const (
+	progressFormatText = "text"
+	progressFormatJSON = "json"
+)
+
+// progressEvent is one line of --progress-format json output. Event is "start", "page" or
+// "finish"; the other fields are set as they apply to it.
+type progressEvent struct {
+	Event    string    `json:"event"`
+	Time     time.Time `json:"time"`
+	URL      string    `json:"url,omitempty"`
+	Status   int       `json:"status,omitempty"`
+	Bytes    int       `json:"bytes,omitempty"`
+	Decision string    `json:"decision,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	// Processed counts the page events so far, Saved the pages saved and Queued the URLs
+	// still waiting, so Processed/(Processed+Queued) approximates the crawl's progress.
+	Processed int `json:"processed"`
+	Saved     int `json:"saved"`
+	Queued    int `json:"queued"`
+	// Set on the finish event.
+	StopReason string  `json:"stop_reason,omitempty"`
+	Failed     int     `json:"failed,omitempty"`
+	Skipped    int     `json:"skipped,omitempty"`
+	Seconds    float64 `json:"seconds,omitempty"`
+}
+
+// progressReporter writes progressEvents as JSON lines. A nil *progressReporter discards them,
+// which is the text format: progress is then only shown in the log.
+type progressReporter struct {
+	mu        sync.Mutex
+	enc       *json.Encoder
+	closer    io.Closer
+	started   time.Time
+	processed int
+}
+
+// newProgressReporter returns the reporter for --progress-format, writing to path if set (a
+// file or named pipe) and to stderr otherwise.
+func newProgressReporter(format string, path string) (*progressReporter, error) {
+	switch format {
+	case "", progressFormatText:
+		if path != "" {
+			return nil, fmt.Errorf("--progress-file requires --progress-format %s", progressFormatJSON)
+		}
+		return nil, nil
+	case progressFormatJSON:
+	default:
+		return nil, fmt.Errorf("invalid --progress-format %q (supported: %s, %s)", format, progressFormatText, progressFormatJSON)
+	}
+	if path == "" {
+		return &progressReporter{enc: json.NewEncoder(os.Stderr)}, nil
+	}
+	// A named pipe blocks here until a reader opens it.
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open progress file %s: %w", path, err)
+	}
+	return &progressReporter{enc: json.NewEncoder(f), closer: f}, nil
+}
+
+func (p *progressReporter) emit(ev progressEvent) {
+	ev.Time = time.Now().UTC()
+	if err := p.enc.Encode(&ev); err != nil {
+		logger.Printf("Warning: failed to write progress event: %v", err)
+	}
+}
+
+// Start reports the beginning of a crawl from startURL.
+func (p *progressReporter) Start(startURL string, queued int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.started = time.Now()
+	p.emit(progressEvent{Event: "start", URL: startURL, Queued: queued})
+}
+
+// Page reports the decision for one attempted URL, with the same fields as --audit-log.
+func (p *progressReporter) Page(pageURL string, status int, bytes int, decision string, reason string, saved int, queued int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processed++
+	p.emit(progressEvent{Event: "page", URL: pageURL, Status: status, Bytes: bytes, Decision: decision, Reason: reason, Processed: p.processed, Saved: saved, Queued: queued})
+}
+
+// Finish reports how the crawl ended.
+func (p *progressReporter) Finish(result CrawlResult) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.emit(progressEvent{
+		Event:      "finish",
+		Processed:  p.processed,
+		Saved:      result.PagesSaved,
+		StopReason: result.StopReason,
+		Failed:     result.PagesFailed,
+		Skipped:    len(result.SkippedPages),
+		Seconds:    time.Since(p.started).Seconds(),
+	})
+}
+
+func (p *progressReporter) Close() error {
+	if p == nil || p.closer == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closer.Close()
+}
+
+// recordDecision records the outcome of an attempted URL in the --audit-log and as a
+// --progress-format json event.
+func (c *Crawler) recordDecision(pageURL string, status int, bytes int, decision string, reason string) {
+	c.opts.AuditLog.Record(pageURL, status, bytes, decision, reason)
+	c.opts.Progress.Page(pageURL, status, bytes, decision, reason, c.savedCount(), c.queued)
+}
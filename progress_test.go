@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewProgressReporter(t *testing.T) {
+	if p, err := newProgressReporter("text", ""); p != nil || err != nil {
+		t.Errorf("text format = (%v, %v), want no reporter", p, err)
+	}
+	if _, err := newProgressReporter("xml", ""); err == nil || !strings.Contains(err.Error(), "invalid --progress-format") {
+		t.Errorf("xml format error = %v", err)
+	}
+	if _, err := newProgressReporter("text", "progress.jsonl"); err == nil || !strings.Contains(err.Error(), "requires --progress-format json") {
+		t.Errorf("--progress-file with text error = %v", err)
+	}
+
+	var none *progressReporter
+	none.Start("https://example.com/", 1)
+	none.Page("https://example.com/", 200, 10, auditDecisionSaved, "", 1, 0)
+	none.Finish(CrawlResult{})
+	if err := none.Close(); err != nil {
+		t.Errorf("nil Close() = %v", err)
+	}
+}
+
+func TestProgressEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.jsonl")
+	p, err := newProgressReporter(progressFormatJSON, path)
+	if err != nil {
+		t.Fatalf("newProgressReporter: %v", err)
+	}
+	c := &Crawler{opts: CrawlOptions{Progress: p}, results: []PageData{{URL: "https://example.com/"}}, queued: 3}
+
+	p.Start("https://example.com/", 1)
+	c.recordDecision("https://example.com/", 200, 1234, auditDecisionSaved, "")
+	c.queued = 2
+	c.recordDecision("https://example.com/private", 0, 0, auditDecisionSkipped, "policy rule /private/**: skip")
+	p.Finish(CrawlResult{StopReason: "Completed", PagesSaved: 1, SkippedPages: []SkippedPage{{}}})
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var events []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("invalid event line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, ev)
+	}
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4", len(events))
+	}
+	page := events[1]
+	if page["event"] != "page" || page["decision"] != "saved" || page["status"] != float64(200) || page["processed"] != float64(1) || page["saved"] != float64(1) || page["queued"] != float64(3) {
+		t.Errorf("page event = %v", page)
+	}
+	if skipped := events[2]; skipped["reason"] != "policy rule /private/**: skip" || skipped["processed"] != float64(2) || skipped["queued"] != float64(2) {
+		t.Errorf("skipped event = %v", skipped)
+	}
+	if finish := events[3]; finish["event"] != "finish" || finish["stop_reason"] != "Completed" || finish["processed"] != float64(2) || finish["skipped"] != float64(1) {
+		t.Errorf("finish event = %v", finish)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// browserDirPattern matches the per-revision directories Playwright installs browsers into,
+// e.g. chromium-1169 or chromium_headless_shell-1169.
+var browserDirPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*-\d+$`)
+
+// playwrightBrowsersDir returns the directory Playwright installs browsers into: the
+// PLAYWRIGHT_BROWSERS_PATH override or ms-playwright in the user cache directory.
+func playwrightBrowsersDir(driverDir string) (string, error) {
+	switch dir := os.Getenv("PLAYWRIGHT_BROWSERS_PATH"); dir {
+	case "":
+	case "0":
+		return filepath.Join(driverDir, "package", ".local-browsers"), nil
+	default:
+		return dir, nil
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %w", err)
+	}
+	return filepath.Join(cacheDir, "ms-playwright"), nil
+}
+
+// browserDirNames returns the browser directory names a Playwright package's browsers.json
+// refers to.
+func browserDirNames(browsersJSON []byte) ([]string, error) {
+	var descriptor struct {
+		Browsers []struct {
+			Name     string `json:"name"`
+			Revision string `json:"revision"`
+		} `json:"browsers"`
+	}
+	if err := json.Unmarshal(browsersJSON, &descriptor); err != nil {
+		return nil, fmt.Errorf("invalid browsers.json: %w", err)
+	}
+	var names []string
+	for _, b := range descriptor.Browsers {
+		if b.Name == "" || b.Revision == "" {
+			continue
+		}
+		names = append(names, strings.ReplaceAll(b.Name, "-", "_")+"-"+b.Revision)
+	}
+	return names, nil
+}
+
+// browsersInUse returns the browser directories used by Sitepanda's Playwright driver and by
+// any other Playwright installation that registered itself in browsersDir/.links, which other
+// tools on the machine share the directory through.
+func browsersInUse(browsersDir, driverDir string) (map[string]bool, error) {
+	data, err := os.ReadFile(filepath.Join(driverDir, "package", "browsers.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Playwright driver's browser list (run 'sitepanda init chromium' first): %w", err)
+	}
+	names, err := browserDirNames(data)
+	if err != nil {
+		return nil, err
+	}
+	inUse := make(map[string]bool)
+	for _, name := range names {
+		inUse[name] = true
+	}
+
+	links, _ := os.ReadDir(filepath.Join(browsersDir, ".links"))
+	for _, link := range links {
+		target, err := os.ReadFile(filepath.Join(browsersDir, ".links", link.Name()))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(strings.TrimSpace(string(target)), "browsers.json"))
+		if err != nil {
+			continue // the installation that registered the link is gone
+		}
+		names, err := browserDirNames(data)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			inUse[name] = true
+		}
+	}
+	return inUse, nil
+}
+
+// prunableBrowserDirs returns the browser directories among entries that are not in use.
+// Entries that are not browser directories are never pruned.
+func prunableBrowserDirs(entries []string, inUse map[string]bool) []string {
+	var prunable []string
+	for _, name := range entries {
+		if browserDirPattern.MatchString(name) && !inUse[name] {
+			prunable = append(prunable, name)
+		}
+	}
+	sort.Strings(prunable)
+	return prunable
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// pruneBrowsers removes the unused browser directories from browsersDir and returns their
+// names and the disk space reclaimed.
+func pruneBrowsers(browsersDir, driverDir string) ([]string, int64, error) {
+	inUse, err := browsersInUse(browsersDir, driverDir)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, err := os.ReadDir(browsersDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read browser directory %s: %w", browsersDir, err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+
+	var removed []string
+	var reclaimed int64
+	for _, name := range prunableBrowserDirs(names, inUse) {
+		path := filepath.Join(browsersDir, name)
+		size := dirSize(path)
+		if err := os.RemoveAll(path); err != nil {
+			return removed, reclaimed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = append(removed, name)
+		reclaimed += size
+	}
+	return removed, reclaimed, nil
+}
+
+// HandlePrune removes browser versions the Playwright driver no longer uses - exported version for cmd package
+func HandlePrune() {
+	driverDir, err := GetAppSubdirectory("playwright_driver")
+	if err != nil {
+		logger.Fatalf("Failed to get Sitepanda's Playwright driver directory: %v", err)
+	}
+	browsersDir, err := playwrightBrowsersDir(driverDir)
+	if err != nil {
+		logger.Fatalf("Failed to determine Playwright's browser directory: %v", err)
+	}
+	logger.Printf("Pruning unused browser versions in %s...", browsersDir)
+
+	removed, reclaimed, err := pruneBrowsers(browsersDir, driverDir)
+	for _, name := range removed {
+		logger.Printf("Removed %s", name)
+	}
+	if err != nil {
+		logger.Fatalf("Pruning failed: %v", err)
+	}
+	if len(removed) == 0 {
+		logger.Println("No unused browser versions found.")
+		return
+	}
+	logger.Printf("Removed %d unused browser version(s), reclaimed %s.", len(removed), formatByteSize(reclaimed))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBrowserDirNames(t *testing.T) {
+	data := []byte(`{"comment":"x","browsers":[
+		{"name":"chromium","revision":"1169","installByDefault":true},
+		{"name":"chromium-headless-shell","revision":"1169","installByDefault":true},
+		{"name":"ffmpeg","revision":"1011"},
+		{"name":"broken"}
+	]}`)
+	got, err := browserDirNames(data)
+	if err != nil {
+		t.Fatalf("browserDirNames() error = %v", err)
+	}
+	want := []string{"chromium-1169", "chromium_headless_shell-1169", "ffmpeg-1011"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("browserDirNames() = %v, want %v", got, want)
+	}
+
+	if _, err := browserDirNames([]byte("not json")); err == nil {
+		t.Error("browserDirNames() with invalid JSON: expected error")
+	}
+}
+
+func TestPrunableBrowserDirs(t *testing.T) {
+	inUse := map[string]bool{"chromium-1169": true, "chromium_headless_shell-1169": true}
+	tests := []struct {
+		name    string
+		entries []string
+		want    []string
+	}{
+		{"nothing installed", nil, nil},
+		{"only current versions", []string{"chromium-1169", "chromium_headless_shell-1169"}, nil},
+		{
+			name:    "old versions pruned",
+			entries: []string{"chromium-1169", "chromium-1155", "chromium_headless_shell-1155", "firefox-1482"},
+			want:    []string{"chromium-1155", "chromium_headless_shell-1155", "firefox-1482"},
+		},
+		{"non-browser entries kept", []string{".links", "__dirlock", "chromium", "notes-v2"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prunableBrowserDirs(tt.entries, inUse); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("prunableBrowserDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneBrowsers(t *testing.T) {
+	driverDir := t.TempDir()
+	browsersDir := t.TempDir()
+	otherPackage := t.TempDir()
+
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(driverDir, "package", "browsers.json"), `{"browsers":[{"name":"chromium","revision":"1169"}]}`)
+	// Another Playwright installation sharing the directory still uses webkit-2100.
+	writeFile(filepath.Join(otherPackage, "browsers.json"), `{"browsers":[{"name":"webkit","revision":"2100"}]}`)
+	writeFile(filepath.Join(browsersDir, ".links", "abc123"), otherPackage)
+	// A link whose installation is gone protects nothing.
+	writeFile(filepath.Join(browsersDir, ".links", "def456"), filepath.Join(otherPackage, "removed"))
+
+	writeFile(filepath.Join(browsersDir, "chromium-1169", "chrome"), "current")
+	writeFile(filepath.Join(browsersDir, "chromium-1155", "chrome"), "0123456789")
+	writeFile(filepath.Join(browsersDir, "chromium-1155", "lib", "libx.so"), "01234")
+	writeFile(filepath.Join(browsersDir, "webkit-2100", "webkit"), "shared")
+
+	removed, reclaimed, err := pruneBrowsers(browsersDir, driverDir)
+	if err != nil {
+		t.Fatalf("pruneBrowsers() error = %v", err)
+	}
+	if want := []string{"chromium-1155"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	if reclaimed != 15 {
+		t.Errorf("reclaimed = %d, want 15", reclaimed)
+	}
+	for _, dir := range []string{"chromium-1169", "webkit-2100", ".links"} {
+		if _, err := os.Stat(filepath.Join(browsersDir, dir)); err != nil {
+			t.Errorf("%s should be kept: %v", dir, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(browsersDir, "chromium-1155")); !os.IsNotExist(err) {
+		t.Errorf("chromium-1155 should be removed, stat error = %v", err)
+	}
+
+	if _, _, err := pruneBrowsers(browsersDir, t.TempDir()); err == nil {
+		t.Error("pruneBrowsers() without a driver browsers.json: expected error")
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// publishedDateLayouts are tried in order when parsing a detected publication date.
+var publishedDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006/01/02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+}
+
+// publishedMetaSelectors are meta tags that commonly carry the publication date, most specific first.
+var publishedMetaSelectors = []string{
+	`meta[property="article:published_time"]`,
+	`meta[property="og:published_time"]`,
+	`meta[itemprop="datePublished"]`,
+	`meta[name="datePublished"]`,
+	`meta[name="pubdate"]`,
+	`meta[name="publish-date"]`,
+	`meta[name="date"]`,
+	`meta[name="DC.date.issued"]`,
+	`meta[name="dc.date"]`,
+}
+
+// urlDatePattern matches /2023/05/12/ or /2023/05/ style dates in a URL path.
+var urlDatePattern = regexp.MustCompile(`/((?:19|20)\d{2})/(0?[1-9]|1[0-2])(?:/(0?[1-9]|[12]\d|3[01]))?(?:/|$)`)
+
+// parsePublishedDate parses a date in one of publishedDateLayouts.
+func parsePublishedDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range publishedDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q (expected e.g. 2023-01-01 or 2023-01-01T00:00:00Z)", s)
+}
+
+// extractPublishedDateFromDocument detects a page's publication date from meta tags, JSON-LD
+// datePublished, <time> elements marked as the publication date, and finally the URL path.
+// It returns the zero time if no date is found.
+func extractPublishedDateFromDocument(pageURL *url.URL, doc *goquery.Document) time.Time {
+	for _, selector := range publishedMetaSelectors {
+		content, _ := doc.Find(selector).First().Attr("content")
+		if t, err := parsePublishedDate(content); err == nil {
+			return t
+		}
+	}
+
+	var fromJSONLD time.Time
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(s.Text()), &data); err != nil {
+			return true
+		}
+		if published, ok := findJSONLDString(data, "datePublished"); ok {
+			if t, err := parsePublishedDate(published); err == nil {
+				fromJSONLD = t
+				return false
+			}
+		}
+		return true
+	})
+	if !fromJSONLD.IsZero() {
+		return fromJSONLD
+	}
+
+	if datetime, ok := doc.Find(`time[itemprop="datePublished"], time[pubdate], article time[datetime]`).First().Attr("datetime"); ok {
+		if t, err := parsePublishedDate(datetime); err == nil {
+			return t
+		}
+	}
+
+	if pageURL != nil {
+		if m := urlDatePattern.FindStringSubmatch(pageURL.Path); m != nil {
+			year, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			day := 1
+			if m[3] != "" {
+				day, _ = strconv.Atoi(m[3])
+			}
+			return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		}
+	}
+	return time.Time{}
+}
+
+// findJSONLDString returns the first string value for key in a decoded JSON-LD value.
+func findJSONLDString(data any, key string) (string, bool) {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			if s, ok := findJSONLDString(item, key); ok {
+				return s, true
+			}
+		}
+	case map[string]any:
+		if s, ok := v[key].(string); ok {
+			return s, true
+		}
+		if graph, ok := v["@graph"]; ok {
+			return findJSONLDString(graph, key)
+		}
+	}
+	return "", false
+}
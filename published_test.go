@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractPublishedDateFromDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		pageURL string
+		html    string
+		want    time.Time
+	}{
+		{
+			name:    "article:published_time meta",
+			pageURL: "https://example.com/post",
+			html:    `<head><meta property="article:published_time" content="2023-05-01T10:30:00Z"></head>`,
+			want:    time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "JSON-LD datePublished in @graph",
+			pageURL: "https://example.com/post",
+			html: `<script type="application/ld+json">{"@context":"https://schema.org","@graph":[
+{"@type":"BlogPosting","headline":"Hi","datePublished":"2022-11-20"}]}</script>`,
+			want: time.Date(2022, 11, 20, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "time element in article",
+			pageURL: "https://example.com/post",
+			html:    `<article><time datetime="2021-02-03">Feb 3</time><p>Body</p></article>`,
+			want:    time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "meta takes precedence over URL",
+			pageURL: "https://example.com/2019/01/02/post",
+			html:    `<head><meta name="date" content="2020-03-04"></head>`,
+			want:    time.Date(2020, 3, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "URL year, month and day",
+			pageURL: "https://example.com/blog/2019/07/15/release",
+			html:    `<p>No metadata</p>`,
+			want:    time.Date(2019, 7, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "URL year and month",
+			pageURL: "https://example.com/2018/12/",
+			html:    `<p>Archive</p>`,
+			want:    time.Date(2018, 12, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "unparseable meta falls through",
+			pageURL: "https://example.com/about",
+			html:    `<head><meta name="date" content="sometime"></head>`,
+			want:    time.Time{},
+		},
+		{
+			name:    "number in path is not a date",
+			pageURL: "https://example.com/products/2023/",
+			html:    `<p>Catalog</p>`,
+			want:    time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pageURL, _ := url.Parse(tt.pageURL)
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatalf("failed to parse HTML: %v", err)
+			}
+			got := extractPublishedDateFromDocument(pageURL, doc)
+			if !got.Equal(tt.want) {
+				t.Errorf("extractPublishedDateFromDocument() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePublishedDate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: "2023-01-01", want: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{input: " 2023-01-01T08:00:00+09:00 ", want: time.Date(2022, 12, 31, 23, 0, 0, 0, time.UTC)},
+		{input: "January 2, 2024", want: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{input: "01/02/2023", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePublishedDate(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePublishedDate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !got.Equal(tt.want) {
+			t.Errorf("parsePublishedDate(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFormatPublishedDate(t *testing.T) {
+	if got := formatPublishedDate(time.Time{}); got != "" {
+		t.Errorf("formatPublishedDate(zero) = %q, want empty", got)
+	}
+	if got := formatPublishedDate(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)); got != "2023-05-01" {
+		t.Errorf("formatPublishedDate(date) = %q, want 2023-05-01", got)
+	}
+	if got := formatPublishedDate(time.Date(2023, 5, 1, 10, 30, 0, 0, time.UTC)); got != "2023-05-01T10:30:00Z" {
+		t.Errorf("formatPublishedDate(datetime) = %q, want 2023-05-01T10:30:00Z", got)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parseQueryParamWhitelist validates a --query-param-whitelist list such as ["page", "tab"].
+func parseQueryParamWhitelist(params []string) ([]string, error) {
+	return parseQueryParamNames("--query-param-whitelist", params, false)
+}
+
+// parseStripQueryParams validates a --strip-query-params list such as ["utm_*", "fbclid"].
+func parseStripQueryParams(params []string) ([]string, error) {
+	return parseQueryParamNames("--strip-query-params", params, true)
+}
+
+// parseQueryParamNames trims and deduplicates the parameter names of flag. With prefixes, a
+// name may end in '*' to stand for every parameter starting with the rest.
+func parseQueryParamNames(flag string, params []string, prefixes bool) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	for _, p := range params {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		name := p
+		if prefixes {
+			name = strings.TrimSuffix(p, "*")
+		}
+		if name == "" || strings.ContainsAny(name, "=&?#*") {
+			return nil, fmt.Errorf("invalid %s parameter %q", flag, p)
+		}
+		seen[p] = true
+		names = append(names, p)
+	}
+	return names, nil
+}
+
+// applyQueryPolicy rewrites a normalized link URL so that only whitelisted query parameters
+// that --strip-query-params does not drop, sorted by name, make it a distinct page; with
+// --strip-query none do. Without these options every query string is kept.
+func (c *Crawler) applyQueryPolicy(normalized string) string {
+	if len(c.opts.QueryParamWhitelist) == 0 && len(c.opts.StripQueryParams) == 0 && !c.opts.StripQuery {
+		return normalized
+	}
+	u, err := url.Parse(normalized)
+	if err != nil || u.RawQuery == "" {
+		return normalized
+	}
+	if c.opts.StripQuery {
+		u.RawQuery = ""
+		return u.String()
+	}
+	query := u.Query()
+	if len(c.opts.QueryParamWhitelist) > 0 {
+		kept := url.Values{}
+		for _, name := range c.opts.QueryParamWhitelist {
+			if values, ok := query[name]; ok {
+				kept[name] = values
+			}
+		}
+		query = kept
+	}
+	for name := range query {
+		if queryParamListed(c.opts.StripQueryParams, name) {
+			delete(query, name)
+		}
+	}
+	u.RawQuery = query.Encode() // Encode sorts by key.
+	return u.String()
+}
+
+// queryParamListed reports whether name is in names, where a name ending in '*' is a prefix.
+func queryParamListed(names []string, name string) bool {
+	for _, n := range names {
+		if prefix, ok := strings.CutSuffix(n, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package main
+
+import "testing"
+
+func TestApplyQueryPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		whitelist []string
+		strip     []string
+		stripAll  bool
+		in        string
+		want      string
+	}{
+		{name: "no whitelist keeps query", in: "https://example.com/list?page=2&utm_source=x", want: "https://example.com/list?page=2&utm_source=x"},
+		{name: "drops other params", whitelist: []string{"page", "tab"}, in: "https://example.com/list?utm_source=x&page=2", want: "https://example.com/list?page=2"},
+		{name: "sorts kept params", whitelist: []string{"page", "tab"}, in: "https://example.com/list?tab=api&page=2", want: "https://example.com/list?page=2&tab=api"},
+		{name: "no whitelisted params", whitelist: []string{"page"}, in: "https://example.com/list?sort=asc", want: "https://example.com/list"},
+		{name: "no query", whitelist: []string{"page"}, in: "https://example.com/list", want: "https://example.com/list"},
+		{name: "strips listed params", strip: []string{"utm_*", "fbclid"}, in: "https://example.com/list?utm_source=x&page=2&fbclid=abc&utm_medium=y", want: "https://example.com/list?page=2"},
+		{name: "strip sorts remaining params", strip: []string{"ref"}, in: "https://example.com/list?tab=api&ref=nav&page=2", want: "https://example.com/list?page=2&tab=api"},
+		{name: "strip prefix needs the prefix", strip: []string{"utm_*"}, in: "https://example.com/list?utm=1", want: "https://example.com/list?utm=1"},
+		{name: "strip after whitelist", whitelist: []string{"page", "tab"}, strip: []string{"tab"}, in: "https://example.com/list?tab=api&page=2&sort=asc", want: "https://example.com/list?page=2"},
+		{name: "strip query", stripAll: true, in: "https://example.com/list?page=2&utm_source=x", want: "https://example.com/list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Crawler{opts: CrawlOptions{QueryParamWhitelist: tt.whitelist, StripQueryParams: tt.strip, StripQuery: tt.stripAll}}
+			if got := c.applyQueryPolicy(tt.in); got != tt.want {
+				t.Errorf("applyQueryPolicy(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQueryParamWhitelist(t *testing.T) {
+	got, err := parseQueryParamWhitelist([]string{" page", "tab", "page", ""})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "page" || got[1] != "tab" {
+		t.Errorf("parseQueryParamWhitelist() = %v, want [page tab]", got)
+	}
+	if _, err := parseQueryParamWhitelist([]string{"page=2"}); err == nil {
+		t.Error("expected an error for a parameter with a value")
+	}
+}
+
+func TestParseStripQueryParams(t *testing.T) {
+	got, err := parseStripQueryParams([]string{"utm_*", " fbclid", "utm_*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "utm_*" || got[1] != "fbclid" {
+		t.Errorf("parseStripQueryParams() = %v, want [utm_* fbclid]", got)
+	}
+	for _, bad := range []string{"*", "utm_*x", "ref=1"} {
+		if _, err := parseStripQueryParams([]string{bad}); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+	if _, err := parseQueryParamWhitelist([]string{"utm_*"}); err == nil {
+		t.Error("expected --query-param-whitelist to reject a prefix")
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// Referer modes for --referer. Any other value is used as a fixed Referer URL.
+const (
+	refererAuto = "auto"
+	refererNone = "none"
+)
+
+// validateRefererMode checks a --referer value: "", auto, none or an absolute http(s) URL.
+func validateRefererMode(mode string) error {
+	switch mode {
+	case "", refererAuto, refererNone:
+		return nil
+	}
+	u, err := url.Parse(mode)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid referer %q (expected auto, none or an absolute http(s) URL)", mode)
+	}
+	return nil
+}
+
+// refererFor returns the Referer to send when navigating to pageURL, or "" for none.
+func (c *Crawler) refererFor(pageURL string) string {
+	switch c.opts.Referer {
+	case "", refererNone:
+		return ""
+	case refererAuto:
+		return c.linkedFrom[pageURL]
+	default:
+		return c.opts.Referer
+	}
+}
+
+// recordLinkSource remembers the first page that linked to pageURL, for --referer auto.
+func (c *Crawler) recordLinkSource(pageURL, fromURL string) {
+	if c.opts.Referer != refererAuto {
+		return
+	}
+	if c.linkedFrom == nil {
+		c.linkedFrom = make(map[string]string)
+	}
+	if _, ok := c.linkedFrom[pageURL]; !ok {
+		c.linkedFrom[pageURL] = fromURL
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestRefererFor(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{mode: "", want: ""},
+		{mode: refererNone, want: ""},
+		{mode: refererAuto, want: "https://example.com/index"},
+		{mode: "https://www.google.com/", want: "https://www.google.com/"},
+	}
+	for _, tt := range tests {
+		c := &Crawler{opts: CrawlOptions{Referer: tt.mode}}
+		c.recordLinkSource("https://example.com/a", "https://example.com/index")
+		c.recordLinkSource("https://example.com/a", "https://example.com/other")
+		if got := c.refererFor("https://example.com/a"); got != tt.want {
+			t.Errorf("refererFor() with mode %q = %q, want %q", tt.mode, got, tt.want)
+		}
+		if tt.mode == refererAuto {
+			if got := c.refererFor("https://example.com/start"); got != "" {
+				t.Errorf("refererFor() for an unlinked URL = %q, want empty", got)
+			}
+		}
+	}
+}
+
+func TestValidateRefererMode(t *testing.T) {
+	for _, mode := range []string{"", "auto", "none", "https://example.com/from"} {
+		if err := validateRefererMode(mode); err != nil {
+			t.Errorf("validateRefererMode(%q) error = %v", mode, err)
+		}
+	}
+	for _, mode := range []string{"always", "example.com", "ftp://example.com/"} {
+		if err := validateRefererMode(mode); err == nil {
+			t.Errorf("validateRefererMode(%q) expected error", mode)
+		}
+	}
+}
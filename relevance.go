@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: k1 is term frequency saturation, b the document length normalization.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// relevanceTerms splits text into lower-cased terms of letters and digits.
+func relevanceTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// scoreRelevance returns the BM25 score of each result's title and Markdown for query, with
+// document frequencies taken from results themselves.
+func scoreRelevance(results []PageData, query string) []float64 {
+	seen := make(map[string]bool)
+	var queryTerms []string
+	for _, term := range relevanceTerms(query) {
+		if !seen[term] {
+			seen[term] = true
+			queryTerms = append(queryTerms, term)
+		}
+	}
+
+	freqs := make([]map[string]int, len(results))
+	lengths := make([]int, len(results))
+	docFreq := make(map[string]int)
+	var totalLength int
+	for i := range results {
+		terms := relevanceTerms(results[i].Title + " " + results[i].Markdown)
+		freqs[i] = make(map[string]int)
+		for _, term := range terms {
+			if seen[term] {
+				freqs[i][term]++
+			}
+		}
+		for term := range freqs[i] {
+			docFreq[term]++
+		}
+		lengths[i] = len(terms)
+		totalLength += len(terms)
+	}
+
+	scores := make([]float64, len(results))
+	if len(results) == 0 || totalLength == 0 {
+		return scores
+	}
+	n := float64(len(results))
+	avgLength := float64(totalLength) / n
+	for i := range results {
+		for _, term := range queryTerms {
+			tf := float64(freqs[i][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(docFreq[term])
+			idf := math.Log((n-df+0.5)/(df+0.5) + 1)
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avgLength))
+		}
+	}
+	return scores
+}
+
+// selectRelevant keeps the results that mention query, most relevant first, at most top of them
+// (0 for no cap), and sets their Relevance. It returns the kept and the dropped pages.
+func selectRelevant(results []PageData, query string, top int) ([]PageData, []SkippedPage) {
+	scores := scoreRelevance(results, query)
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	var kept []PageData
+	var dropped []SkippedPage
+	for rank, i := range order {
+		switch {
+		case scores[i] == 0:
+			dropped = append(dropped, SkippedPage{URL: results[i].URL, Reason: fmt.Sprintf("not relevant to %q", query)})
+		case top > 0 && rank >= top:
+			dropped = append(dropped, SkippedPage{URL: results[i].URL, Reason: fmt.Sprintf("not among the top %d relevant pages", top)})
+		default:
+			pd := results[i]
+			pd.Relevance = math.Round(scores[i]*1000) / 1000
+			kept = append(kept, pd)
+		}
+	}
+	return kept, dropped
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectRelevant(t *testing.T) {
+	results := []PageData{
+		{URL: "https://example.com/intro", Title: "Introduction", Markdown: "Welcome to the docs. Kubernetes is mentioned once."},
+		{URL: "https://example.com/net", Title: "Kubernetes Networking", Markdown: "Kubernetes networking: pods, services and network policies. Networking in Kubernetes."},
+		{URL: "https://example.com/storage", Title: "Storage", Markdown: "Volumes and persistent storage."},
+		{URL: "https://example.com/dns", Title: "DNS", Markdown: "Cluster DNS and networking basics."},
+	}
+
+	kept, dropped := selectRelevant(results, "Kubernetes networking", 0)
+	var urls []string
+	for _, pd := range kept {
+		urls = append(urls, pd.URL)
+		if pd.Relevance <= 0 {
+			t.Errorf("%s kept without a relevance score", pd.URL)
+		}
+	}
+	if got := strings.Join(urls, " "); !strings.HasPrefix(got, "https://example.com/net ") || len(kept) != 3 {
+		t.Errorf("kept = %s, want /net first and the three matching pages", got)
+	}
+	if len(dropped) != 1 || dropped[0].URL != "https://example.com/storage" {
+		t.Errorf("dropped = %v, want only /storage", dropped)
+	}
+	if results[1].Relevance != 0 {
+		t.Error("selectRelevant modified its input")
+	}
+
+	kept, dropped = selectRelevant(results, "kubernetes networking", 1)
+	if len(kept) != 1 || kept[0].URL != "https://example.com/net" {
+		t.Errorf("top 1 = %v", kept)
+	}
+	if len(dropped) != 3 {
+		t.Errorf("top 1 dropped %d pages, want 3", len(dropped))
+	}
+}
+
+func TestScoreRelevancePrefersRareTerms(t *testing.T) {
+	results := []PageData{
+		{Markdown: "common common rare"},
+		{Markdown: "common common common"},
+		{Markdown: "common other words"},
+	}
+	scores := scoreRelevance(results, "common rare")
+	if !(scores[0] > scores[1] && scores[1] > 0) {
+		t.Errorf("scores = %v, want the page with the rare term first", scores)
+	}
+	if got := scoreRelevance(results, "  ,, "); got[0] != 0 || got[1] != 0 {
+		t.Errorf("empty query scored %v", got)
+	}
+}
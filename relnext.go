@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// extractRelNextLinks returns the normalized rel="next" targets of a page, from <link>/<a> elements
+// and the HTTP Link header, restricted to the host of the crawl. Unlike extractAndFilterLinks it
+// ignores --follow-match, so paginated series are followed wherever their pages live on the site.
+func (c *Crawler) extractRelNextLinks(pageURL *url.URL, htmlBody string, linkHeader string) []string {
+	var hrefs []string
+	if doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlBody)); err == nil {
+		doc.Find("link[rel][href], a[rel][href]").Each(func(_ int, s *goquery.Selection) {
+			rel, _ := s.Attr("rel")
+			if hasToken(rel, "next") {
+				href, _ := s.Attr("href")
+				hrefs = append(hrefs, href)
+			}
+		})
+	}
+	hrefs = append(hrefs, relNextFromLinkHeader(linkHeader)...)
+
+	seen := make(map[string]bool)
+	var links []string
+	for _, href := range hrefs {
+		absolute, err := pageURL.Parse(strings.TrimSpace(href))
+		if err != nil {
+			continue
+		}
+		normalized, err := normalizeURLtoString(absolute.String())
+		if err != nil {
+			continue
+		}
+		normalized = c.collapseLocale(c.applyQueryPolicy(normalized))
+		parsed, _ := url.Parse(normalized)
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || !c.isCrawlHost(parsed.Hostname()) || c.excludesFollow(parsed) {
+			continue
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			links = append(links, normalized)
+		}
+	}
+	return links
+}
+
+// relNextFromLinkHeader returns the targets of rel="next" entries in an RFC 8288 Link header,
+// e.g. `<https://example.com/page/2>; rel="next", <https://example.com/>; rel="first"`.
+func relNextFromLinkHeader(header string) []string {
+	var targets []string
+	for _, entry := range splitLinkHeader(header) {
+		target, params, ok := strings.Cut(entry, ";")
+		target = strings.TrimSpace(target)
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(param, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "rel") && hasToken(strings.Trim(strings.TrimSpace(value), `"`), "next") {
+				targets = append(targets, target[1:len(target)-1])
+				break
+			}
+		}
+	}
+	return targets
+}
+
+// splitLinkHeader splits a Link header on the commas between entries, ignoring commas inside <...> and quotes.
+func splitLinkHeader(header string) []string {
+	var entries []string
+	inURL, inQuote := false, false
+	start := 0
+	for i, r := range header {
+		switch {
+		case r == '<' && !inQuote:
+			inURL = true
+		case r == '>' && !inQuote:
+			inURL = false
+		case r == '"' && !inURL:
+			inQuote = !inQuote
+		case r == ',' && !inURL && !inQuote:
+			entries = append(entries, header[start:i])
+			start = i + 1
+		}
+	}
+	if rest := strings.TrimSpace(header[start:]); rest != "" {
+		entries = append(entries, rest)
+	}
+	return entries
+}
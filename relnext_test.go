@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestExtractRelNextLinks(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/blog/series/part-1")
+	c := &Crawler{startURL: pageURL}
+
+	html := `<html><head><link rel="next" href="/archive/2019/part-2"><link rel="prev" href="/blog/"></head>
+<body><a rel="nofollow next" href="https://example.com/archive/2019/part-2#top">Next</a>
+<a rel="next" href="https://other.example.com/part-2">Elsewhere</a><a href="/blog/x">x</a></body></html>`
+	header := `<https://example.com/archive/2019/part-2?from=header>; rel="next", <https://example.com/>; rel="first"`
+
+	got := c.extractRelNextLinks(pageURL, html, header)
+	want := []string{
+		"https://example.com/archive/2019/part-2",
+		"https://example.com/archive/2019/part-2?from=header",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extractRelNextLinks() = %v, want %v", got, want)
+	}
+}
+
+func TestRelNextFromLinkHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: nil},
+		{header: `<https://example.com/page/2>; rel="next"`, want: []string{"https://example.com/page/2"}},
+		{header: `<https://example.com/a,b>; rel="prev next"; title="a, b", <https://example.com/c>; rel=last`, want: []string{"https://example.com/a,b"}},
+		{header: `https://example.com/page/2; rel="next"`, want: nil},
+	}
+	for _, tt := range tests {
+		if got := relNextFromLinkHeader(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("relNextFromLinkHeader(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// crawlReport is the --report file: the outcome of a crawl, including the pages it did not save
+// and why, for scripts and CI to check after a run.
+type crawlReport struct {
+	StartURL          string               `json:"start_url"`
+	StartedAt         time.Time            `json:"started_at"`
+	Status            string               `json:"status"`
+	DurationSeconds   float64              `json:"duration_seconds"`
+	PagesFetched      int                  `json:"pages_fetched"`
+	PagesSaved        int                  `json:"pages_saved"`
+	PagesMatchSkipped int                  `json:"pages_match_skipped"`
+	PagesSkipped      int                  `json:"pages_skipped"`
+	PagesFailed       int                  `json:"pages_failed"`
+	BytesDownloaded   int64                `json:"bytes_downloaded"`
+	Hosts             map[string]HostStats `json:"hosts,omitempty"`
+	Failures          []reportPage         `json:"failures"`
+	Skipped           []reportPage         `json:"skipped"`
+	Gated             []reportPage         `json:"gated,omitempty"`
+	Unreachable       []reportPage         `json:"unreachable,omitempty"`
+	Downloads         []DownloadedAsset    `json:"downloads,omitempty"`
+	OutputFile        string               `json:"output_file,omitempty"`
+	OutputError       string               `json:"output_error,omitempty"`
+}
+
+// reportPage is a page of the --report file with the reason it was not saved.
+type reportPage struct {
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// newCrawlReport builds the --report file of a crawl of startURL begun at started.
+func newCrawlReport(startURL string, started time.Time, result CrawlResult) crawlReport {
+	report := crawlReport{
+		StartURL:          startURL,
+		StartedAt:         started.UTC(),
+		Status:            result.StopReason,
+		DurationSeconds:   result.Duration.Seconds(),
+		PagesFetched:      result.PagesFetched,
+		PagesSaved:        result.PagesSaved,
+		PagesMatchSkipped: result.MatchSkippedPages,
+		PagesSkipped:      len(result.SkippedPages),
+		PagesFailed:       result.PagesFailed,
+		BytesDownloaded:   result.BytesDownloaded,
+		Hosts:             result.HostStats,
+		Failures:          reportPages(result.FailedPages),
+		Skipped:           reportPages(result.SkippedPages),
+		Gated:             reportPages(result.GatedPages),
+		Unreachable:       reportPages(result.ShortcutSkipped),
+		Downloads:         result.DownloadedAssets,
+		OutputFile:        result.OutputFile,
+	}
+	if result.OutputFileError != nil {
+		report.OutputError = result.OutputFileError.Error()
+	}
+	// Scripts can read failures and skipped without checking for null.
+	if report.Failures == nil {
+		report.Failures = []reportPage{}
+	}
+	if report.Skipped == nil {
+		report.Skipped = []reportPage{}
+	}
+	return report
+}
+
+func reportPages(pages []SkippedPage) []reportPage {
+	var out []reportPage
+	for _, p := range pages {
+		out = append(out, reportPage{URL: p.URL, Reason: p.Reason})
+	}
+	return out
+}
+
+// writeCrawlReport writes report as indented JSON to path, creating its directory.
+func writeCrawlReport(path string, report crawlReport) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
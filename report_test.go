@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrawlResultCounts(t *testing.T) {
+	pages := map[string]string{
+		"index.html":     `<html><head><title>Home</title></head><body><h1>Home</h1><p>Start here.</p><a href="/docs/a">A</a> <a href="/docs/missing">Missing</a> <a href="/blog/post">Post</a></body></html>`,
+		"docs/a.html":    `<html><head><title>A</title></head><body><h1>A</h1><p>A documentation page.</p></body></html>`,
+		"blog/post.html": `<html><head><title>Post</title></head><body><h1>Post</h1><p>A blog post.</p></body></html>`,
+	}
+	_, result, _ := crawlFixtures(t, pages, fixtureCrawl{match: []string{"/docs/**"}})
+	if result.PagesFetched != 3 || result.PagesSaved != 1 || result.MatchSkippedPages != 2 {
+		t.Errorf("fetched %d, saved %d, match-skipped %d; want 3, 1, 2", result.PagesFetched, result.PagesSaved, result.MatchSkippedPages)
+	}
+	if result.PagesFailed != 1 || len(result.FailedPages) != 1 || result.FailedPages[0].URL != "https://example.com/docs/missing" || !strings.Contains(result.FailedPages[0].Reason, "no fixture") {
+		t.Errorf("failed pages = %d, %+v", result.PagesFailed, result.FailedPages)
+	}
+	if result.BytesDownloaded == 0 || result.Duration <= 0 {
+		t.Errorf("bytes = %d, duration = %s; want both counted", result.BytesDownloaded, result.Duration)
+	}
+}
+
+func TestWriteCrawlReport(t *testing.T) {
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("JST", 9*3600))
+	result := CrawlResult{
+		StopReason:        "Completed",
+		OutputFile:        "out.json",
+		OutputFileError:   errors.New("disk full"),
+		PagesFetched:      4,
+		PagesSaved:        2,
+		MatchSkippedPages: 1,
+		PagesFailed:       1,
+		FailedPages:       []SkippedPage{{URL: "https://example.com/b", Reason: "net::ERR_TIMED_OUT"}},
+		BytesDownloaded:   2048,
+		Duration:          1500 * time.Millisecond,
+		HostStats:         map[string]HostStats{"example.com": {Saved: 2, Failed: 1}},
+	}
+	path := filepath.Join(t.TempDir(), "reports", "report.json")
+	if err := writeCrawlReport(path, newCrawlReport("https://example.com/", started, result)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{
+		"start_url":           "https://example.com/",
+		"started_at":          "2024-05-01T03:00:00Z",
+		"status":              "Completed",
+		"duration_seconds":    1.5,
+		"pages_fetched":       4.0,
+		"pages_saved":         2.0,
+		"pages_match_skipped": 1.0,
+		"pages_skipped":       0.0,
+		"pages_failed":        1.0,
+		"bytes_downloaded":    2048.0,
+		"output_error":        "disk full",
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+	if failures, _ := got["failures"].([]any); len(failures) != 1 || failures[0].(map[string]any)["reason"] != "net::ERR_TIMED_OUT" {
+		t.Errorf("failures = %v", got["failures"])
+	}
+	if skipped, ok := got["skipped"].([]any); !ok || len(skipped) != 0 {
+		t.Errorf("skipped = %v, want an empty list", got["skipped"])
+	}
+	if _, ok := got["gated"]; ok {
+		t.Error("empty gated list was written")
+	}
+}
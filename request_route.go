@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// requestHeaderRoute returns a route handler that strips the Referer header (--referer none) and adds
+// the OAuth bearer token for matching hosts, or nil if no request needs rewriting. With --bypass-cache
+// a handler is always returned: Playwright disables the HTTP cache while routing is enabled.
+func requestHeaderRoute(ctx context.Context, opts CrawlOptions) func(playwright.Route) {
+	stripReferer := opts.Referer == refererNone
+	if !stripReferer && opts.OAuth == nil && !opts.BypassCache {
+		return nil
+	}
+	return func(route playwright.Route) {
+		request := route.Request()
+		var authorization string
+		if opts.OAuth != nil {
+			if u, err := url.Parse(request.URL()); err == nil && opts.OAuth.MatchesHost(u.Hostname()) {
+				token, err := opts.OAuth.Token(ctx)
+				if err != nil {
+					logger.Printf("Warning: failed to obtain OAuth token for %s: %v", request.URL(), err)
+				} else {
+					authorization = "Bearer " + token
+				}
+			}
+		}
+		if !stripReferer && authorization == "" {
+			if err := route.Continue(); err != nil {
+				logger.Printf("Warning: failed to continue request %s: %v", request.URL(), err)
+			}
+			return
+		}
+
+		headers := make(map[string]string)
+		for name, value := range request.Headers() {
+			if stripReferer && strings.EqualFold(name, "referer") {
+				continue
+			}
+			headers[name] = value
+		}
+		if authorization != "" {
+			headers["authorization"] = authorization
+		}
+		if err := route.Continue(playwright.RouteContinueOptions{Headers: headers}); err != nil {
+			logger.Printf("Warning: failed to continue request %s with rewritten headers: %v", request.URL(), err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import "strings"
+
+// selectResponseHeaders returns the wanted headers present in headers, keyed by lower-cased name.
+// It returns nil if none are present.
+func selectResponseHeaders(headers map[string]string, wanted []string) map[string]string {
+	var selected map[string]string
+	for _, name := range wanted {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		value, ok := headers[name]
+		if !ok {
+			for k, v := range headers {
+				if strings.EqualFold(k, name) {
+					value, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		if selected == nil {
+			selected = make(map[string]string)
+		}
+		selected[name] = value
+	}
+	return selected
+}
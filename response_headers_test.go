@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectResponseHeaders(t *testing.T) {
+	headers := map[string]string{
+		"content-type":  "text/html; charset=utf-8",
+		"ETag":          `"abc123"`,
+		"cache-control": "max-age=600",
+		"set-cookie":    "session=secret",
+	}
+
+	tests := []struct {
+		name   string
+		wanted []string
+		want   map[string]string
+	}{
+		{
+			name:   "defaults present in response",
+			wanted: []string{"content-type", "last-modified", "etag", "x-robots-tag", "cache-control"},
+			want: map[string]string{
+				"content-type":  "text/html; charset=utf-8",
+				"etag":          `"abc123"`,
+				"cache-control": "max-age=600",
+			},
+		},
+		{name: "case-insensitive names", wanted: []string{" Content-Type "}, want: map[string]string{"content-type": "text/html; charset=utf-8"}},
+		{name: "nothing wanted", wanted: nil, want: nil},
+		{name: "nothing present", wanted: []string{"last-modified"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectResponseHeaders(headers, tt.wanted); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectResponseHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
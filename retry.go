@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// maxRetryWait caps the wait before a retry, whether from --retry-backoff doubling or a
+// server's Retry-After.
+const maxRetryWait = time.Minute
+
+// fetchErrorClass says what went wrong with a fetch and so whether it is worth repeating.
+type fetchErrorClass int
+
+const (
+	// fetchOK is a fetch without error or error status.
+	fetchOK fetchErrorClass = iota
+	// fetchErrorPermanent is specific to the page, e.g. a 404 or empty HTML; retrying won't help.
+	fetchErrorPermanent
+	fetchErrorTimeout
+	// fetchErrorConnection is a connection dropped or reset mid-request.
+	fetchErrorConnection
+	// fetchErrorServer is a 5xx or 429 response.
+	fetchErrorServer
+	// fetchErrorUnreachable is a host that cannot be resolved or reached (see deadhosts.go).
+	fetchErrorUnreachable
+	// fetchErrorRefused is a refused connection: unreachable, and fatal to a single-site crawl.
+	fetchErrorRefused
+	// fetchErrorBrowserClosed means the browser or its page is gone; the crawl cannot go on.
+	fetchErrorBrowserClosed
+)
+
+// retryable reports whether a fetch that failed this way may succeed if repeated.
+func (c fetchErrorClass) retryable() bool {
+	return c == fetchErrorTimeout || c == fetchErrorConnection || c == fetchErrorServer
+}
+
+func (c fetchErrorClass) String() string {
+	switch c {
+	case fetchOK:
+		return "ok"
+	case fetchErrorTimeout:
+		return "timeout"
+	case fetchErrorConnection:
+		return "connection error"
+	case fetchErrorServer:
+		return "server error"
+	case fetchErrorUnreachable:
+		return "host unreachable"
+	case fetchErrorRefused:
+		return "connection refused"
+	case fetchErrorBrowserClosed:
+		return "browser closed"
+	default:
+		return "permanent error"
+	}
+}
+
+// browserClosedErrors are substrings of errors from a browser, context or page that has closed.
+var browserClosedErrors = []string{
+	"browser has been closed",
+	"Target page, context or browser has been closed",
+	"Target closed",
+}
+
+// connectionErrors are browser network errors for a connection that failed mid-request.
+var connectionErrors = []string{
+	"net::ERR_CONNECTION_RESET",
+	"net::ERR_CONNECTION_CLOSED",
+	"net::ERR_CONNECTION_ABORTED",
+	"net::ERR_EMPTY_RESPONSE",
+	"net::ERR_NETWORK_CHANGED",
+	"net::ERR_INTERNET_DISCONNECTED",
+	"net::ERR_HTTP2_PROTOCOL_ERROR",
+	"(Playwright connection issue)",
+}
+
+// timeoutErrors are browser network errors for a request that took too long.
+var timeoutErrors = []string{
+	"net::ERR_TIMED_OUT",
+	"net::ERR_CONNECTION_TIMED_OUT",
+}
+
+// classifyFetch classifies the outcome of a fetch from its error and response status.
+func classifyFetch(err error, status int) fetchErrorClass {
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	switch {
+	case containsAny(msg, browserClosedErrors):
+		return fetchErrorBrowserClosed
+	case status >= 500 || status == http.StatusTooManyRequests:
+		return fetchErrorServer
+	case err == nil:
+		return fetchOK
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, playwright.ErrTimeout) || containsAny(msg, timeoutErrors):
+		return fetchErrorTimeout
+	case strings.Contains(msg, "net::ERR_CONNECTION_REFUSED"):
+		return fetchErrorRefused
+	case deadHostReason(err) != "":
+		return fetchErrorUnreachable
+	case containsAny(msg, connectionErrors):
+		return fetchErrorConnection
+	default:
+		return fetchErrorPermanent
+	}
+}
+
+func containsAny(s string, substrings []string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryPolicy is --retries and --retry-backoff. The zero value never retries.
+type retryPolicy struct {
+	// Retries is how many times a retryable fetch is repeated.
+	Retries int
+	// Backoff is the wait before the first retry, doubled for each further one.
+	Backoff time.Duration
+}
+
+// wait returns how long to wait before retry number retry (1 for the first), honoring a
+// Retry-After header of the failed response if it asks for longer. Both are capped at
+// maxRetryWait.
+func (p retryPolicy) wait(retry int, retryAfter string, now time.Time) time.Duration {
+	wait := p.Backoff
+	for i := 1; i < retry && wait < maxRetryWait; i++ {
+		wait *= 2
+	}
+	if after := parseRetryAfter(retryAfter, now); after > wait {
+		wait = after
+	}
+	return min(wait, maxRetryWait)
+}
+
+// parseRetryAfter returns the delay of a Retry-After header, in seconds or as an HTTP date, or 0.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+func TestClassifyFetch(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		want   fetchErrorClass
+	}{
+		{"ok", nil, 200, fetchOK},
+		{"no response", nil, 0, fetchOK},
+		{"server error", nil, 503, fetchErrorServer},
+		{"rate limited", nil, 429, fetchErrorServer},
+		{"not found", errors.New("no fixture for https://example.com/x"), 404, fetchErrorPermanent},
+		{"not found without error", nil, 404, fetchOK},
+		{"deadline", fmt.Errorf("timeout fetching https://example.com/: %w", context.DeadlineExceeded), 0, fetchErrorTimeout},
+		{"playwright timeout", fmt.Errorf("playwright page.Goto failed for https://example.com/: %w", playwright.ErrTimeout), 0, fetchErrorTimeout},
+		{"net timeout", errors.New("page.Goto: net::ERR_TIMED_OUT at https://example.com/"), 0, fetchErrorTimeout},
+		{"reset", errors.New("page.Goto: net::ERR_CONNECTION_RESET at https://example.com/"), 0, fetchErrorConnection},
+		{"page closed", errors.New("playwright page for https://example.com/ closed after navigation (Playwright connection issue)"), 0, fetchErrorConnection},
+		{"refused", errors.New("page.Goto: net::ERR_CONNECTION_REFUSED at https://example.com/"), 0, fetchErrorRefused},
+		{"dns", errors.New("page.Goto: net::ERR_NAME_NOT_RESOLVED at https://example.com/"), 0, fetchErrorUnreachable},
+		{"browser closed", errors.New("playwright page.Goto failed for https://example.com/ (Playwright connection issue): Target page, context or browser has been closed"), 0, fetchErrorBrowserClosed},
+		{"empty", fmt.Errorf("fixture index.html: %w", errEmptyHTML), 200, fetchErrorPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyFetch(tt.err, tt.status); got != tt.want {
+				t.Errorf("classifyFetch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	for class, want := range map[fetchErrorClass]bool{fetchErrorTimeout: true, fetchErrorConnection: true, fetchErrorServer: true, fetchErrorPermanent: false, fetchErrorRefused: false, fetchErrorUnreachable: false, fetchErrorBrowserClosed: false} {
+		if got := class.retryable(); got != want {
+			t.Errorf("%s.retryable() = %t, want %t", class, got, want)
+		}
+	}
+}
+
+func TestRetryPolicyWait(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	policy := retryPolicy{Retries: 5, Backoff: time.Second}
+	tests := []struct {
+		retry      int
+		retryAfter string
+		want       time.Duration
+	}{
+		{1, "", time.Second},
+		{2, "", 2 * time.Second},
+		{3, "", 4 * time.Second},
+		{10, "", maxRetryWait},
+		{1, "5", 5 * time.Second},
+		{3, "1", 4 * time.Second},
+		{1, "Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second},
+		{1, "Wed, 01 May 2024 11:00:00 GMT", time.Second},
+		{1, "3600", maxRetryWait},
+		{1, "soon", time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.wait(tt.retry, tt.retryAfter, now); got != tt.want {
+			t.Errorf("wait(%d, %q) = %s, want %s", tt.retry, tt.retryAfter, got, tt.want)
+		}
+	}
+	if got := (retryPolicy{}).wait(3, "", now); got != 0 {
+		t.Errorf("wait() without backoff = %s, want 0", got)
+	}
+}
+
+func TestCrawlDoesNotRetryNotFound(t *testing.T) {
+	pages := map[string]string{
+		"index.html": `<html><head><title>Home</title></head><body><h1>Home</h1><p>Welcome.</p><a href="/missing">Missing</a></body></html>`,
+	}
+	started := time.Now()
+	_, result, _ := crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{Retry: retryPolicy{Retries: 3, Backoff: time.Minute}}})
+	if elapsed := time.Since(started); elapsed > 30*time.Second {
+		t.Errorf("crawl took %s; the 404 was retried", elapsed)
+	}
+	if result.PagesFailed != 1 || result.PagesSaved != 1 {
+		t.Errorf("failed %d, saved %d; want 1, 1", result.PagesFailed, result.PagesSaved)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// robotsUserAgent is the product token matched against robots.txt User-agent lines; groups for
+// other crawlers are ignored and the "*" group applies if there is none for it.
+const robotsUserAgent = "sitepanda"
+
+// robotsFetchTimeout bounds the robots.txt request for a host.
+const robotsFetchTimeout = 30 * time.Second
+
+// maxRobotsBytes is how much of a robots.txt is read; RFC 9309 requires at least 500 KiB.
+const maxRobotsBytes = 512 << 10
+
+// robotsRule is one Allow or Disallow line of a robots.txt group.
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+// robotsRules are the robots.txt rules that apply to Sitepanda on one host. A nil *robotsRules
+// allows everything (--ignore-robots).
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+	// unavailable, if set, is why robots.txt could not be read, in which case the whole host is
+	// treated as disallowed.
+	unavailable string
+}
+
+// robotsGroup is a robots.txt group: its User-agent lines and the lines that follow them.
+type robotsGroup struct {
+	agents     []string
+	rules      []robotsRule
+	crawlDelay time.Duration
+	hasDelay   bool
+}
+
+// parseRobots parses a robots.txt file (RFC 9309) and keeps the groups for robotsUserAgent, or
+// the "*" groups if none name it. Crawl-delay, a common extension, is read in seconds.
+func parseRobots(body string) *robotsRules {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	inAgents := false
+	for _, line := range strings.Split(body, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			continue
+		case "allow", "disallow":
+			// An empty Disallow allows everything, which is the default.
+			if current != nil && value != "" {
+				current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value, re: robotsPatternRegexp(value)})
+			}
+		case "crawl-delay":
+			if seconds, err := strconv.ParseFloat(value, 64); current != nil && err == nil && seconds >= 0 {
+				current.crawlDelay, current.hasDelay = time.Duration(seconds*float64(time.Second)), true
+			}
+		}
+		inAgents = false
+	}
+
+	rules := &robotsRules{}
+	for _, agent := range []string{robotsUserAgent, "*"} {
+		matched := false
+		for _, g := range groups {
+			if !slices.Contains(g.agents, agent) {
+				continue
+			}
+			matched = true
+			rules.rules = append(rules.rules, g.rules...)
+			if g.hasDelay {
+				rules.crawlDelay = max(rules.crawlDelay, g.crawlDelay)
+			}
+		}
+		if matched {
+			break
+		}
+	}
+	return rules
+}
+
+// robotsPatternRegexp compiles a path pattern, where * matches any characters and a trailing $
+// anchors the end of the path.
+func robotsPatternRegexp(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
+
+// robotsRulesForResponse returns the rules for a robots.txt response. As RFC 9309 specifies, a
+// missing file (4xx) allows everything and a server error disallows the whole host.
+func robotsRulesForResponse(status int, body []byte) *robotsRules {
+	switch {
+	case status >= 200 && status < 300:
+		return parseRobots(string(body))
+	case status >= 500:
+		return &robotsRules{unavailable: fmt.Sprintf("robots.txt returned status %d", status)}
+	default:
+		return &robotsRules{}
+	}
+}
+
+// CrawlDelay returns the robots.txt Crawl-delay to wait before each fetch.
+func (r *robotsRules) CrawlDelay() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.crawlDelay
+}
+
+// skipReason returns why u must not be fetched under r, or "". The longest matching pattern
+// decides, and Allow wins a tie.
+func (r *robotsRules) skipReason(u *url.URL) string {
+	if r == nil {
+		return ""
+	}
+	if r.unavailable != "" {
+		return r.unavailable + "; treating the host as disallowed"
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	var match *robotsRule
+	for i, rule := range r.rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if match == nil || len(rule.pattern) > len(match.pattern) || (len(rule.pattern) == len(match.pattern) && rule.allow) {
+			match = &r.rules[i]
+		}
+	}
+	if match == nil || match.allow {
+		return ""
+	}
+	return fmt.Sprintf("disallowed by robots.txt (Disallow: %s)", match.pattern)
+}
+
+// robotsOrigin returns the key robots.txt rules are cached under for u.
+func robotsOrigin(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+// robotsFor returns the robots.txt rules for u's host, fetching them on first use. Hosts whose
+// robots.txt cannot be requested at all are crawled as if it were missing; the page fetch then
+// reports the underlying error.
+func (c *Crawler) robotsFor(u *url.URL) *robotsRules {
+	if c.opts.IgnoreRobots {
+		return nil
+	}
+	origin := robotsOrigin(u)
+	if rules, ok := c.robots[origin]; ok {
+		return rules
+	}
+	if c.robots == nil {
+		c.robots = make(map[string]*robotsRules)
+	}
+	robotsURL := origin + "/robots.txt"
+	if c.opts.Fixtures != nil {
+		c.robots[origin] = c.opts.Fixtures.robots(u)
+		return c.robots[origin]
+	}
+	rules, err := fetchRobots(c.rootCtx, robotsURL, c.opts.Proxy)
+	if err != nil {
+		logger.Printf("Warning: %v; crawling %s without it.", err, origin)
+		rules = &robotsRules{}
+	} else {
+		logger.Printf("Loaded %s: %d rules, crawl delay %s.", robotsURL, len(rules.rules), rules.crawlDelay)
+	}
+	c.robots[origin] = rules
+	return rules
+}
+
+// fetchRobots requests robotsURL, through proxy if set, accepting its certificates as the
+// browser does with --proxy.
+func fetchRobots(ctx context.Context, robotsURL string, proxy string) (*robotsRules, error) {
+	client := &http.Client{Timeout: robotsFetchTimeout}
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", proxy, err)
+		}
+		client.Transport = &http.Transport{
+			Proxy:           http.ProxyURL(proxyURL),
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", robotsURL, err)
+	}
+	req.Header.Set("User-Agent", "sitepanda/"+Version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch %s: %w", robotsURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsBytes))
+	if err != nil {
+		return nil, fmt.Errorf("could not read %s: %w", robotsURL, err)
+	}
+	return robotsRulesForResponse(resp.StatusCode, body), nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRobots(t *testing.T) {
+	body := `# Example robots.txt
+User-agent: Googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /private/
+Allow: /private/open$
+Disallow: /*.pdf$
+Disallow: /search?
+Crawl-delay: 1.5
+
+Sitemap: https://example.com/sitemap.xml
+`
+	rules := parseRobots(body)
+	if rules.CrawlDelay() != 1500*time.Millisecond {
+		t.Errorf("CrawlDelay() = %s, want 1.5s", rules.CrawlDelay())
+	}
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/", true},
+		{"/docs/intro", true},
+		{"/private/", false},
+		{"/private/notes", false},
+		{"/private/open", true},
+		{"/private/open/more", false},
+		{"/files/report.pdf", false},
+		{"/files/report.pdf?download=1", true},
+		{"/search?q=go", false},
+		{"/search", true},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse("https://example.com" + tt.path)
+		reason := rules.skipReason(u)
+		if (reason == "") != tt.allowed {
+			t.Errorf("skipReason(%s) = %q, want allowed %v", tt.path, reason, tt.allowed)
+		}
+	}
+}
+
+func TestParseRobotsSitepandaGroup(t *testing.T) {
+	body := `User-agent: *
+Disallow: /
+
+User-agent: Sitepanda
+User-agent: OtherBot
+Disallow: /admin
+`
+	rules := parseRobots(body)
+	u, _ := url.Parse("https://example.com/docs")
+	if reason := rules.skipReason(u); reason != "" {
+		t.Errorf("the sitepanda group should replace the * group, got %q", reason)
+	}
+	u, _ = url.Parse("https://example.com/admin/users")
+	if reason := rules.skipReason(u); !strings.Contains(reason, "Disallow: /admin") {
+		t.Errorf("skipReason(/admin/users) = %q", reason)
+	}
+}
+
+func TestRobotsRulesForResponse(t *testing.T) {
+	u, _ := url.Parse("https://example.com/page")
+	if reason := robotsRulesForResponse(404, nil).skipReason(u); reason != "" {
+		t.Errorf("missing robots.txt should allow everything, got %q", reason)
+	}
+	if reason := robotsRulesForResponse(503, nil).skipReason(u); !strings.Contains(reason, "status 503") {
+		t.Errorf("server error should disallow the host, got %q", reason)
+	}
+	if reason := robotsRulesForResponse(200, []byte("User-agent: *\nDisallow:\n")).skipReason(u); reason != "" {
+		t.Errorf("empty Disallow should allow everything, got %q", reason)
+	}
+
+	var ignored *robotsRules
+	if ignored.skipReason(u) != "" || ignored.CrawlDelay() != 0 {
+		t.Error("nil rules should allow everything without delay")
+	}
+}
+
+func TestRobotsFor(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/robots.txt" || !strings.HasPrefix(r.UserAgent(), "sitepanda/") {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private/\n")
+	}))
+	defer server.Close()
+
+	c := &Crawler{rootCtx: context.Background()}
+	for _, path := range []string{"/docs", "/private/a"} {
+		u, _ := url.Parse(server.URL + path)
+		reason := c.robotsFor(u).skipReason(u)
+		if (reason == "") != (path == "/docs") {
+			t.Errorf("skipReason(%s) = %q", path, reason)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("robots.txt was requested %d times, want 1", requests)
+	}
+
+	c = &Crawler{rootCtx: context.Background(), opts: CrawlOptions{IgnoreRobots: true}}
+	u, _ := url.Parse(server.URL + "/private/a")
+	if rules := c.robotsFor(u); rules != nil || requests != 1 {
+		t.Errorf("--ignore-robots returned %v after %d requests", rules, requests)
+	}
+}
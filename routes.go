@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// routeFormatsByExt maps --route file extensions to the output format written to them. Files
+// with other extensions get the --output-format.
+var routeFormatsByExt = map[string]string{
+	".json":     "json",
+	".jsonl":    "jsonl",
+	".ndjson":   "jsonl",
+	".parquet":  parquetFormat,
+	".org":      "org",
+	".adoc":     "asciidoc",
+	".asciidoc": "asciidoc",
+	".xml":      "xml-like",
+}
+
+// outputRoute is one --route destination and the labels whose pages are written to it.
+type outputRoute struct {
+	Labels []string
+	Path   string
+	// Dir is set for destinations ending in a slash, which receive one Markdown file per page
+	// as with --output-dir.
+	Dir bool
+	// Format is the output format of a file destination, or "" for the --output-format.
+	Format string
+}
+
+// parseRoutes parses --route label=destination values. Labels routed to the same destination
+// share it, so a page with several of them is written there once.
+func parseRoutes(specs []string, labels urlLabels) ([]outputRoute, error) {
+	var routes []outputRoute
+	routed := make(map[string]bool)
+	for _, spec := range specs {
+		label, dest, ok := strings.Cut(spec, "=")
+		label, dest = strings.TrimSpace(label), strings.TrimSpace(dest)
+		if !ok || label == "" || dest == "" {
+			return nil, fmt.Errorf("invalid --route %q (expected label=file or label=dir/)", spec)
+		}
+		if !slices.ContainsFunc(labels, func(l urlLabel) bool { return l.name == label }) {
+			return nil, fmt.Errorf("--route %s: no --label named %q", spec, label)
+		}
+		if routed[label] {
+			return nil, fmt.Errorf("--route %s: label %q is already routed", spec, label)
+		}
+		routed[label] = true
+
+		route := outputRoute{Path: dest, Dir: strings.HasSuffix(dest, "/") || strings.HasSuffix(dest, string(filepath.Separator))}
+		if !route.Dir {
+			route.Format = routeFormatsByExt[strings.ToLower(filepath.Ext(dest))]
+		}
+		if i := slices.IndexFunc(routes, func(r outputRoute) bool { return filepath.Clean(r.Path) == filepath.Clean(dest) }); i >= 0 {
+			if routes[i].Dir != route.Dir {
+				return nil, fmt.Errorf("--route %s: %s is used both as a file and as a directory", spec, dest)
+			}
+			routes[i].Labels = append(routes[i].Labels, label)
+			continue
+		}
+		route.Labels = []string{label}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// assignRoutes distributes results over routes, keeping crawl order. A page goes to every
+// route one of its labels is routed to; pages with no routed label are returned as unrouted.
+func assignRoutes(results []PageData, routes []outputRoute) (assigned [][]PageData, unrouted []PageData) {
+	assigned = make([][]PageData, len(routes))
+	for _, pd := range results {
+		matched := false
+		for i, route := range routes {
+			if slices.ContainsFunc(pd.Labels, func(label string) bool { return slices.Contains(route.Labels, label) }) {
+				assigned[i] = append(assigned[i], pd)
+				matched = true
+			}
+		}
+		if !matched {
+			unrouted = append(unrouted, pd)
+		}
+	}
+	return assigned, unrouted
+}
+
+// writeRoutes writes the routed pages and returns the pages left for the main output. Every
+// route is written, even with no pages, so stale output from an earlier run is replaced.
+func (c *Crawler) writeRoutes(result *CrawlResult) []PageData {
+	assigned, unrouted := assignRoutes(c.results, c.opts.Routes)
+	for i, route := range c.opts.Routes {
+		pages := assigned[i]
+		result.RouteFiles = append(result.RouteFiles, SplitFile{Name: strings.Join(route.Labels, ","), Path: route.Path, Pages: len(pages)})
+		if err := c.writeRoute(route, pages); err != nil {
+			logger.Printf("Error writing route %s: %v", route.Path, err)
+			result.RouteFileError = err
+		}
+	}
+	return unrouted
+}
+
+func (c *Crawler) writeRoute(route outputRoute, pages []PageData) error {
+	if route.Dir {
+		if err := os.MkdirAll(route.Path, 0755); err != nil {
+			return err
+		}
+		_, err := writePageFiles(route.Path, pages)
+		return err
+	}
+	var data []byte
+	var err error
+	if route.Format == "" {
+		data, err = c.formatOutput(pages)
+	} else {
+		data, err = formatResults(route.Format, pages)
+	}
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(route.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(route.Path, data, 0644)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRoutes(t *testing.T) {
+	labels, err := parseLabels([]string{"docs=/docs/**", "api=/api/**", "blog=/blog/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes, err := parseRoutes([]string{"docs=out/reference.jsonl", "blog=blog/", "api=out/./reference.jsonl"}, labels)
+	if err != nil {
+		t.Fatalf("parseRoutes: %v", err)
+	}
+	want := []outputRoute{
+		{Labels: []string{"docs", "api"}, Path: "out/reference.jsonl", Format: "jsonl"},
+		{Labels: []string{"blog"}, Path: "blog/", Dir: true},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("parseRoutes = %+v, want %+v", routes, want)
+	}
+	if routes, _ := parseRoutes([]string{"docs=docs.md"}, labels); routes[0].Format != "" {
+		t.Errorf("unknown extension format = %q, want the --output-format", routes[0].Format)
+	}
+
+	invalid := []struct {
+		specs   []string
+		wantErr string
+	}{
+		{[]string{"docs"}, "expected label=file"},
+		{[]string{"docs="}, "expected label=file"},
+		{[]string{"news=news.json"}, `no --label named "news"`},
+		{[]string{"docs=a.json", "docs=b.json"}, `label "docs" is already routed`},
+		{[]string{"docs=out/", "api=out"}, "both as a file and as a directory"},
+	}
+	for _, tt := range invalid {
+		if _, err := parseRoutes(tt.specs, labels); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseRoutes(%v) error = %v, want %q", tt.specs, err, tt.wantErr)
+		}
+	}
+}
+
+func TestWriteRoutes(t *testing.T) {
+	dir := t.TempDir()
+	routes := []outputRoute{
+		{Labels: []string{"docs"}, Path: filepath.Join(dir, "docs.json"), Format: "json"},
+		{Labels: []string{"blog"}, Path: filepath.Join(dir, "blog") + "/", Dir: true},
+		{Labels: []string{"news"}, Path: filepath.Join(dir, "news.txt")},
+	}
+	c := &Crawler{
+		outputFormat: "jsonl",
+		opts:         CrawlOptions{Routes: routes},
+		results: []PageData{
+			{Title: "Intro", URL: "https://example.com/docs/intro", Markdown: "docs", Labels: []string{"docs"}},
+			{Title: "Post", URL: "https://example.com/blog/post", Markdown: "blog", Labels: []string{"blog", "docs"}},
+			{Title: "About", URL: "https://example.com/about", Markdown: "about"},
+		},
+	}
+	var result CrawlResult
+	unrouted := c.writeRoutes(&result)
+
+	if len(unrouted) != 1 || unrouted[0].Title != "About" {
+		t.Errorf("unrouted = %+v, want only About", unrouted)
+	}
+	if result.RouteFileError != nil {
+		t.Fatalf("RouteFileError = %v", result.RouteFileError)
+	}
+	if got := []int{result.RouteFiles[0].Pages, result.RouteFiles[1].Pages, result.RouteFiles[2].Pages}; !reflect.DeepEqual(got, []int{2, 1, 0}) {
+		t.Errorf("route page counts = %v, want [2 1 0]", got)
+	}
+	docs, _ := os.ReadFile(routes[0].Path)
+	if !strings.HasPrefix(string(docs), "[") || !strings.Contains(string(docs), `"title": "Post"`) {
+		t.Errorf("docs.json = %s, want a JSON array with both docs pages", docs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blog", pagePathMappingFile)); err != nil {
+		t.Errorf("blog/ route has no page files: %v", err)
+	}
+	if news, err := os.ReadFile(routes[2].Path); err != nil || len(news) != 0 {
+		t.Errorf("news.txt = %q, %v; want an empty file", news, err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// lockHeldExitCode is the exit status of a run that gives up because another run of the same job
+// holds its --lock-file (EX_TEMPFAIL: try again later).
+const lockHeldExitCode = 75
+
+// lockPollInterval is how often a waiting run retries a held lock.
+const lockPollInterval = 500 * time.Millisecond
+
+// errRunLockHeld is returned by acquireRunLock when another process still holds the lock.
+var errRunLockHeld = errors.New("lock is held by another sitepanda run")
+
+// runLock is a held --lock-file.
+type runLock struct {
+	file *os.File
+	path string
+}
+
+// runLockKey identifies a job for the default lock file: its --job-name, or else the output
+// options and URL sources that would make two runs write the same files.
+func runLockKey(jobName, outfile, outputDir string, sources []string) string {
+	if jobName != "" {
+		return "job:" + jobName
+	}
+	parts := []string{"outfile:" + absPathOrSelf(outfile), "output-dir:" + absPathOrSelf(outputDir)}
+	for _, s := range sources {
+		parts = append(parts, "source:"+s)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// absPathOrSelf returns path made absolute, so the same file named from two working
+// directories maps to one key. Empty paths and '-' (stdout) are returned as is.
+func absPathOrSelf(path string) string {
+	if path == "" || path == "-" {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// defaultRunLockPath returns the lock file for key in Sitepanda's data directory.
+func defaultRunLockPath(key string) (string, error) {
+	dir, err := GetAppSubdirectory("locks")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".lock"), nil
+}
+
+// acquireRunLock takes the lock at path, retrying for up to wait while another run holds it. It
+// returns errRunLockHeld if the lock is still held when wait has elapsed.
+func acquireRunLock(ctx context.Context, path string, wait time.Duration) (*runLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := tryLockFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if f != nil {
+			if err := f.Truncate(0); err == nil {
+				fmt.Fprintf(f, "pid %d, started %s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+			}
+			return &runLock{file: f, path: path}, nil
+		}
+		if !time.Now().Before(deadline) {
+			return nil, errRunLockHeld
+		}
+		select {
+		case <-time.After(lockPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// runLockHolder describes the run holding the lock at path, or "" if that cannot be read (on
+// Windows the held file is not readable).
+func runLockHolder(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// release unlocks the lock file. The file is left in place: removing it would let a run that
+// already opened it and one that creates it anew both hold "the" lock.
+func (l *runLock) release() {
+	if l == nil {
+		return
+	}
+	if err := l.file.Close(); err != nil {
+		logger.Printf("Error releasing lock %s: %v", l.path, err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunLockKey(t *testing.T) {
+	base := runLockKey("", "out.jsonl", "", []string{"", "https://example.com"})
+	tests := []struct {
+		name string
+		key  string
+		same bool
+	}{
+		{"identical job", runLockKey("", "out.jsonl", "", []string{"", "https://example.com"}), true},
+		{"different outfile", runLockKey("", "other.jsonl", "", []string{"", "https://example.com"}), false},
+		{"different URL", runLockKey("", "out.jsonl", "", []string{"", "https://example.org"}), false},
+		{"job name", runLockKey("nightly", "out.jsonl", "", []string{"", "https://example.com"}), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key == base; got != tt.same {
+				t.Errorf("key equal to base = %v, want %v (key %q)", got, tt.same, tt.key)
+			}
+		})
+	}
+	if runLockKey("nightly", "a", "", nil) != runLockKey("nightly", "b", "dir", []string{"x"}) {
+		t.Error("runs with the same --job-name should share a key")
+	}
+}
+
+func TestAcquireRunLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "job.lock")
+	ctx := context.Background()
+
+	first, err := acquireRunLock(ctx, path, 0)
+	if err != nil {
+		t.Fatalf("first acquireRunLock() error = %v", err)
+	}
+	if holder := runLockHolder(path); !strings.HasPrefix(holder, "pid ") {
+		t.Errorf("runLockHolder() = %q, want pid and start time", holder)
+	}
+
+	if _, err := acquireRunLock(ctx, path, 0); !errors.Is(err, errRunLockHeld) {
+		t.Fatalf("second acquireRunLock() error = %v, want errRunLockHeld", err)
+	}
+
+	go func() {
+		time.Sleep(2 * lockPollInterval)
+		first.release()
+	}()
+	second, err := acquireRunLock(ctx, path, 10*time.Second)
+	if err != nil {
+		t.Fatalf("waiting acquireRunLock() error = %v", err)
+	}
+	second.release()
+}
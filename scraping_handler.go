@@ -2,32 +2,118 @@ package main
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/hokupod/sitepanda/cmd"
 	"github.com/playwright-community/playwright-go"
 )
 
+// maxSkippedPagesInSummary caps how many skipped pages are listed individually in the summary report.
+const maxSkippedPagesInSummary = 20
+
 // HandleScraping implements the main scraping logic - exported version for cmd package
 func HandleScraping(args []string) {
 	// Configure logger based on silent flag
 	if cmd.GetSilent() {
 		SetLoggerOutput(io.Discard)
 	}
+	runStarted := time.Now()
 
 	var startURLForCrawler string
 	var targetURLsForCrawler []string
+	var urlOverrides map[string]URLOverrides
+	var domainRoots []domainRoot
 	isURLListMode := false
 
-	// Handle URL arguments and --url-file logic
+	if cmd.GetOffset() < 0 {
+		logger.Fatalf("Error: --offset must not be negative, got %d.", cmd.GetOffset())
+	}
+
+	if cmd.GetLock() || cmd.GetLockFile() != "" {
+		lockPath := cmd.GetLockFile()
+		if lockPath == "" {
+			key := runLockKey(cmd.GetJobName(), cmd.GetOutfile(), cmd.GetOutputDir(), append([]string{cmd.GetURLFile(), cmd.GetSearch(), cmd.GetDomainsFile()}, args...))
+			var err error
+			if lockPath, err = defaultRunLockPath(key); err != nil {
+				logger.Fatalf("Error: Failed to determine lock file: %v", err)
+			}
+		}
+		if cmd.GetLockWait() > 0 {
+			logger.Printf("Waiting up to %s for lock %s...", cmd.GetLockWait(), lockPath)
+		}
+		runLock, err := acquireRunLock(context.Background(), lockPath, cmd.GetLockWait())
+		if errors.Is(err, errRunLockHeld) {
+			holder := runLockHolder(lockPath)
+			if holder != "" {
+				holder = " (" + holder + ")"
+			}
+			logger.Printf("Another sitepanda run of this job holds lock %s%s; exiting.", lockPath, holder)
+			os.Exit(lockHeldExitCode)
+		}
+		if err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+		defer runLock.release()
+	}
+
+	// Handle URL arguments, --url-file and --search logic
 	urlFile := cmd.GetURLFile()
-	if urlFile != "" {
+	searchQuery := cmd.GetSearch()
+	domainsFile := cmd.GetDomainsFile()
+	if urlFile != "" && searchQuery != "" {
+		logger.Fatal("Error: Cannot use --url-file and --search together.")
+	}
+	if domainsFile != "" && (urlFile != "" || searchQuery != "") {
+		logger.Fatal("Error: Cannot use --domains-file with --url-file or --search.")
+	}
+	var interactions []interaction
+	if interactFile := cmd.GetInteractFile(); interactFile != "" {
+		if urlFile != "" || searchQuery != "" || domainsFile != "" {
+			logger.Fatal("Error: --interact can only be used with a <url> argument, not with --url-file, --search or --domains-file.")
+		}
+		data, err := os.ReadFile(interactFile)
+		if err != nil {
+			logger.Fatalf("Error: Failed to read --interact file %s: %v", interactFile, err)
+		}
+		interactions, err = parseInteractions(data)
+		if err != nil {
+			logger.Fatalf("Error: --interact %s: %v", interactFile, err)
+		}
+	}
+	if domainsFile != "" {
+		if len(args) > 0 {
+			logger.Fatal("Error: Cannot use <url> argument when --domains-file is specified.")
+		}
+		if cmd.GetOffset() > 0 {
+			logger.Fatal("Error: --offset can only be used with --url-file.")
+		}
+		fileContent, err := os.ReadFile(domainsFile)
+		if err != nil {
+			logger.Fatalf("Error: Failed to read --domains-file %s: %v", domainsFile, err)
+		}
+		domainRoots, err = parseDomainsFile(string(fileContent))
+		if err != nil {
+			logger.Fatalf("Error: Invalid --domains-file %s: %v", domainsFile, err)
+		}
+		if len(domainRoots) == 0 {
+			logger.Fatalf("Error: --domains-file %s is empty or contains no root URLs.", domainsFile)
+		}
+		startURLForCrawler = domainRoots[0].URL
+		targetURLsForCrawler = []string{startURLForCrawler}
+	} else if urlFile != "" {
 		if len(args) > 0 {
 			logger.Fatal("Error: Cannot use <url> argument when --url-file is specified.")
 		}
@@ -35,23 +121,72 @@ func HandleScraping(args []string) {
 		if err != nil {
 			logger.Fatalf("Error: Failed to read --url-file %s: %v", urlFile, err)
 		}
-		lines := strings.Split(string(fileContent), "\n")
-		for _, line := range lines {
-			trimmedLine := strings.TrimSpace(line)
-			if trimmedLine != "" {
-				targetURLsForCrawler = append(targetURLsForCrawler, trimmedLine)
+		entries, err := parseURLFile(string(fileContent))
+		if err != nil {
+			logger.Fatalf("Error: Invalid --url-file %s: %v", urlFile, err)
+		}
+		for _, entry := range entries {
+			targetURLsForCrawler = append(targetURLsForCrawler, entry.URL)
+			if entry.Overrides == (URLOverrides{}) {
+				continue
 			}
+			normalizedURL, err := normalizeURLtoString(entry.URL)
+			if err != nil {
+				continue // Reported when the crawler builds its queue.
+			}
+			if urlOverrides == nil {
+				urlOverrides = make(map[string]URLOverrides)
+			}
+			urlOverrides[normalizedURL] = entry.Overrides
 		}
 		if len(targetURLsForCrawler) == 0 {
 			logger.Fatalf("Error: --url-file %s is empty or contains no valid URLs.", urlFile)
 		}
+		totalURLs := len(targetURLsForCrawler)
+		targetURLsForCrawler = selectURLListShard(targetURLsForCrawler, cmd.GetOffset(), cmd.GetPageLimit())
+		if len(targetURLsForCrawler) == 0 {
+			logger.Fatalf("Error: --offset %d is past the end of --url-file %s (%d URLs).", cmd.GetOffset(), urlFile, totalURLs)
+		}
+		if len(targetURLsForCrawler) < totalURLs {
+			logger.Printf("Processing URLs %d-%d of %d from --url-file.", cmd.GetOffset()+1, cmd.GetOffset()+len(targetURLsForCrawler), totalURLs)
+		}
 		startURLForCrawler = targetURLsForCrawler[0]
 		isURLListMode = true
+	} else if searchQuery != "" {
+		if len(args) > 0 {
+			logger.Fatal("Error: Cannot use <url> argument when --search is specified.")
+		}
+		if cmd.GetOffset() > 0 {
+			logger.Fatal("Error: --offset can only be used with --url-file.")
+		}
+		if cmd.GetSearchLimit() <= 0 {
+			logger.Fatalf("Error: --search-limit must be positive, got %d.", cmd.GetSearchLimit())
+		}
+		search := &webSearch{
+			Engine:   cmd.GetSearchEngine(),
+			APIKey:   cmd.GetSearchAPIKey(),
+			CX:       cmd.GetSearchCX(),
+			Endpoint: cmd.GetSearchEndpoint(),
+		}
+		urls, err := search.Search(context.Background(), searchQuery, cmd.GetSearchLimit())
+		if err != nil {
+			logger.Fatalf("Error: --search failed: %v", err)
+		}
+		if len(urls) == 0 {
+			logger.Fatalf("Error: %s search for %q returned no results.", search.Engine, searchQuery)
+		}
+		logger.Printf("Seeded %d URLs from %s search for %q.", len(urls), search.Engine, searchQuery)
+		targetURLsForCrawler = urls
+		startURLForCrawler = urls[0]
+		isURLListMode = true
 	} else {
 		if len(args) < 1 {
-			logger.Println("Error: URL argument or --url-file option is required for scraping, or specify 'init' command.")
+			logger.Println("Error: URL argument or --url-file option is required for scraping (or use --search), or specify 'init' command.")
 			os.Exit(1)
 		}
+		if cmd.GetOffset() > 0 {
+			logger.Fatal("Error: --offset can only be used with --url-file.")
+		}
 		startURLForCrawler = args[0]
 		if startURLForCrawler == "init" {
 			logger.Println("Error: 'init' is a command, not a URL. To initialize, run 'sitepanda init [browser]'.")
@@ -68,80 +203,550 @@ func HandleScraping(args []string) {
 		os.Exit(1)
 	}
 
+	fallbackBrowserName := cmd.GetFallbackBrowser()
+	if fallbackBrowserName != "" {
+		if err := validateFallbackBrowser(browserName, fallbackBrowserName); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	}
+
 	logger.Printf("Sitepanda v%s starting with browser: %s", Version, browserName)
 
-	playwrightDriverDir, err := GetAppSubdirectory("playwright_driver")
+	templateVars := newOutputTemplateVars(startURLForCrawler, time.Now(), cmd.GetJobName())
+	var outfiles []string
+	for _, value := range cmd.GetOutfiles() {
+		expanded, err := expandOutputTemplate(value, templateVars)
+		if err != nil {
+			logger.Fatalf("Error: Invalid --outfile template: %v", err)
+		}
+		if path, ok := localStorePath(expanded); ok {
+			if expanded != value {
+				// Templated paths usually point into per-run directories that do not exist yet.
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					logger.Fatalf("Error: Failed to create directory for --outfile %s: %v", path, err)
+				}
+			}
+			expanded = path
+		} else if path, ok := snapshotDBPath(expanded); ok && expanded != value {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				logger.Fatalf("Error: Failed to create directory for --outfile %s: %v", path, err)
+			}
+		}
+		outfiles = append(outfiles, expanded)
+	}
+	var outfile string
+	if len(outfiles) > 0 {
+		outfile = outfiles[0]
+	}
+	outputDir, err := expandOutputTemplate(cmd.GetOutputDir(), templateVars)
 	if err != nil {
-		logger.Fatalf("Failed to determine or create Sitepanda's Playwright driver directory: %v", err)
+		logger.Fatalf("Error: Invalid --output-dir template: %v", err)
+	}
+	notifications, err := buildNotifiers()
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	notifyFailure := func(reason string, result CrawlResult) {
+		if len(notifications) == 0 {
+			return
+		}
+		sendNotifications(notifications, notificationData{
+			StartURL:     startURLForCrawler,
+			JobName:      cmd.GetJobName(),
+			Reason:       reason,
+			StopReason:   result.StopReason,
+			PagesSaved:   result.PagesSaved,
+			PagesSkipped: len(result.SkippedPages),
+			Time:         time.Now(),
+		})
+	}
+
+	var fixtures *fixtureSite
+	if fixtureDir := cmd.GetFixtureDir(); fixtureDir != "" {
+		if fixtures, err = newFixtureSite(fixtureDir); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	}
+
+	// Configuration logging
+	matchPatterns := cmd.GetMatchPatterns()
+	followMatchPatterns := cmd.GetFollowMatchPatterns()
+	pageLimit := cmd.GetPageLimit()
+	contentSelector := cmd.GetContentSelector()
+	waitForNetworkIdle := cmd.GetWaitForNetworkIdle()
+	outputFormat := cmd.GetOutputFormat()
+	_, localOutfile := localStorePath(outfile)
+	if isDirectoryOutputFormat(outputFormat) && (outfile == "" || len(outfiles) > 1 || !localOutfile) {
+		logger.Fatalf("Error: --output-format %s writes a directory and requires a single local --outfile <dir>.", outputFormat)
+	}
+	if outputFormat == parquetFormat && (outfile == "" || slices.Contains(outfiles, "-")) {
+		logger.Fatal("Error: --output-format parquet is a binary format and requires --outfile <file> other than stdout.")
 	}
 
-	browserExecutablePath, browserPrepareCleanup, err := prepareBrowser(browserName, playwrightDriverDir)
+	maxPageBytes, err := parseByteSize(cmd.GetMaxPageBytes())
+	if err != nil {
+		logger.Fatalf("Error: Invalid --max-page-bytes value: %v", err)
+	}
+	maxMemory, err := parseByteSize(cmd.GetMaxMemory())
+	if err != nil {
+		logger.Fatalf("Error: Invalid --max-memory value: %v", err)
+	}
+	maxBytes, err := parseByteSize(cmd.GetMaxBytes())
 	if err != nil {
-		logger.Fatalf("Failed to prepare %s: %v. If not installed, please run 'sitepanda init %s'.", browserName, err, browserName)
+		logger.Fatalf("Error: Invalid --max-bytes value: %v", err)
+	}
+	crawlOpts := CrawlOptions{
+		ProcessTimeout:  cmd.GetProcessTimeout(),
+		MaxPageBytes:    maxPageBytes,
+		MaxMemory:       maxMemory,
+		URLOverrides:    urlOverrides,
+		OutputDir:       outputDir,
+		TOCFile:         cmd.GetTOCFile(),
+		SEOReport:       cmd.GetSEOReport(),
+		IncludeComments: cmd.GetIncludeComments(),
+		Tables:          cmd.GetTables(),
+		FollowRelNext:   cmd.GetFollowRelNext(),
+		Referer:         cmd.GetReferer(),
+		ResponseHeaders: cmd.GetResponseHeaders(),
+		SelectorMode:    cmd.GetSelectorMode(),
+		A11yTree:        cmd.GetA11yTree(),
+		CaptureOGImages: cmd.GetCaptureOGImages(),
+		Domains:         domainRoots,
+		Interactions:    interactions,
+		EvalExtract:     cmd.GetEvalExtract(),
+		BypassCache:     cmd.GetBypassCache(),
+		Prefetch:        cmd.GetPrefetch(),
+		Concurrency:     cmd.GetConcurrency(),
+		IgnoreRobots:    cmd.GetIgnoreRobots(),
+		AdaptiveWait:    cmd.GetAdaptiveWait(),
+		IncludeGated:    cmd.GetIncludeGated(),
+	}
+	crawlOpts.Bytes = newByteBudget(maxBytes)
+	if crawlOpts.ExcludeMatch, err = compileExcludePatterns("--exclude-match", cmd.GetExcludeMatch()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if crawlOpts.ExcludeFollow, err = compileExcludePatterns("--exclude-follow", cmd.GetExcludeFollow()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if crawlOpts.MaxDepth = cmd.GetMaxDepth(); crawlOpts.MaxDepth < 0 {
+		logger.Fatalf("Error: --depth must not be negative, got %d.", crawlOpts.MaxDepth)
+	}
+	if crawlOpts.SkipStatus, err = parseStatusSet(cmd.GetSkipStatus()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	crawlOpts.RecordStatus = cmd.GetSkipStatusSet()
+	if crawlOpts.DownloadAssets, err = parseAssetKinds(cmd.GetDownloadAssets()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if crawlOpts.AssetDir, err = expandOutputTemplate(cmd.GetAssetDir(), templateVars); err != nil {
+		logger.Fatalf("Error: Invalid --asset-dir template: %v", err)
+	}
+	if len(crawlOpts.DownloadAssets) > 0 && crawlOpts.AssetDir == "" {
+		logger.Fatal("Error: --download-assets requires --asset-dir.")
+	}
+	if len(crawlOpts.DownloadAssets) == 0 && crawlOpts.AssetDir != "" {
+		logger.Fatal("Error: --asset-dir requires --download-assets.")
+	}
+	crawlOpts.Retry = retryPolicy{Retries: cmd.GetRetries(), Backoff: cmd.GetRetryBackoff()}
+	if crawlOpts.Retry.Retries < 0 {
+		logger.Fatalf("Error: --retries must not be negative, got %d.", crawlOpts.Retry.Retries)
+	}
+	if crawlOpts.Retry.Backoff < 0 {
+		logger.Fatalf("Error: --retry-backoff must not be negative, got %s.", crawlOpts.Retry.Backoff)
+	}
+	if crawlOpts.Concurrency < 1 {
+		logger.Fatalf("Error: --concurrency must be at least 1, got %d.", crawlOpts.Concurrency)
+	}
+	crawlOpts.Throttle, err = newRequestThrottle(cmd.GetDelay(), cmd.GetDelayJitter(), cmd.GetMaxRPS())
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if crawlOpts.Throttle != nil && (crawlOpts.Concurrency > 1 || crawlOpts.Prefetch) {
+		logger.Fatal("Error: --concurrency and --prefetch fetch several pages at once and cannot be combined with --delay, --delay-jitter or --max-rps.")
+	}
+	crawlOpts.DisableServiceWorkers = cmd.GetDisableServiceWorkers()
+	if crawlOpts.Proxy = cmd.GetProxy(); crawlOpts.Proxy != "" && browserName != "chromium" {
+		logger.Fatal("Error: --proxy is only supported with --browser chromium.")
+	}
+	if crawlOpts.Fixtures = fixtures; fixtures != nil {
+		if err := validateFixtureOptions(crawlOpts, fallbackBrowserName); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	}
+	crawlOpts.RespectCanonical = cmd.GetRespectCanonical()
+	crawlOpts.DedupeContent = cmd.GetDedupeContent()
+	crawlOpts.DedupeSimilarity = cmd.GetDedupeSimilarity()
+	if crawlOpts.DedupeSimilarity < 0 || crawlOpts.DedupeSimilarity > 1 {
+		logger.Fatalf("Error: --dedupe-similarity must be between 0 and 1, got %g.", crawlOpts.DedupeSimilarity)
+	}
+	crawlOpts.RelevantTo, crawlOpts.RelevantTop = strings.TrimSpace(cmd.GetRelevantTo()), cmd.GetTop()
+	if crawlOpts.RelevantTop < 0 {
+		logger.Fatalf("Error: --top must not be negative, got %d.", crawlOpts.RelevantTop)
+	}
+	if crawlOpts.RelevantTop > 0 && crawlOpts.RelevantTo == "" {
+		logger.Fatal("Error: --top requires --relevant-to.")
+	}
+	crawlOpts.Strategy = cmd.GetStrategy()
+	crawlOpts.PrioritizeMatch = cmd.GetPrioritizeMatch()
+	if _, err := newFrontier(crawlOpts.Strategy, crawlOpts.RelevantTo); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if tmpl := cmd.GetWrapTemplate(); tmpl != "" {
+		if outputFormat != "xml-like" {
+			logger.Fatalf("Error: --wrap-template replaces the output format and cannot be combined with --output-format %s.", outputFormat)
+		}
+		crawlOpts.WrapTemplate, err = parseWrapTemplate(tmpl)
+		if err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	}
+	crawlOpts.MaxTotalTokens = cmd.GetMaxTotalTokens()
+	if crawlOpts.MaxTotalTokens < 0 {
+		logger.Fatalf("Error: --max-total-tokens must not be negative, got %d.", crawlOpts.MaxTotalTokens)
+	}
+	if crawlOpts.MaxTotalTokens > 0 && crawlOpts.WrapTemplate == "" {
+		logger.Fatal("Error: --max-total-tokens requires --wrap-template.")
+	}
+	if spec := cmd.GetSplit(); spec != "" {
+		if outfile == "" || len(outfiles) > 1 || !localOutfile {
+			logger.Fatal("Error: --split writes one output file per split and requires a single local --outfile.")
+		}
+		if outputFormat == siteBundleFormat {
+			logger.Fatal("Error: --split cannot be used with --output-format site.")
+		}
+		crawlOpts.Splits, err = parseSplitSpec(spec)
+		if err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+		crawlOpts.SplitSeed = cmd.GetSplitSeed()
+	}
+	if crawlOpts.SplitHeading, err = parseSplitHeading(cmd.GetSplitByHeading()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if crawlOpts.SplitHeading > 0 && (outputFormat == siteBundleFormat || outputFormat == "org" || outputFormat == "asciidoc") {
+		logger.Fatalf("Error: --split-by-heading splits the Markdown of pages and cannot be used with --output-format %s.", outputFormat)
+	}
+	if !isDirectoryOutputFormat(outputFormat) && len(crawlOpts.Splits) == 0 {
+		for _, location := range outfiles {
+			store, err := openStore(location)
+			if err != nil {
+				logger.Fatalf("Error: Invalid --outfile %s: %v", location, err)
+			}
+			crawlOpts.Stores = append(crawlOpts.Stores, store)
+		}
+	}
+	if policyFile := cmd.GetPolicyFile(); policyFile != "" {
+		if crawlOpts.Policy, err = loadPolicy(policyFile); err != nil {
+			logger.Fatalf("Error: Invalid --policy file %s: %v", policyFile, err)
+		}
+	}
+	if crawlOpts.Labels, err = parseLabels(cmd.GetLabels()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if specs := cmd.GetRoutes(); len(specs) > 0 {
+		if len(crawlOpts.Splits) > 0 || isDirectoryOutputFormat(outputFormat) {
+			logger.Fatalf("Error: --route cannot be combined with --split or --output-format %s or %s.", siteBundleFormat, hfDatasetFormat)
+		}
+		var expanded []string
+		for _, spec := range specs {
+			spec, err := expandOutputTemplate(spec, templateVars)
+			if err != nil {
+				logger.Fatalf("Error: Invalid --route template: %v", err)
+			}
+			expanded = append(expanded, spec)
+		}
+		if crawlOpts.Routes, err = parseRoutes(expanded, crawlOpts.Labels); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	}
+	if filterExpr := cmd.GetFilter(); filterExpr != "" {
+		if crawlOpts.Filter, err = parseFilter(filterExpr); err != nil {
+			logger.Fatalf("Error: Invalid --filter expression: %v", err)
+		}
+	}
+	if freezeTime := cmd.GetFreezeTime(); freezeTime != "" {
+		crawlOpts.FreezeTime, err = time.Parse(time.RFC3339, freezeTime)
+		if err != nil {
+			logger.Fatalf("Error: Invalid --freeze-time %q: expected RFC 3339, e.g. 2024-01-01T00:00:00Z", freezeTime)
+		}
+	}
+	crawlOpts.MaxContentLength = cmd.GetMaxContentLength()
+	if crawlOpts.MaxContentLength < 0 {
+		logger.Fatalf("Error: --max-content-length must not be negative, got %d.", crawlOpts.MaxContentLength)
+	}
+	if whitelist := cmd.GetQueryParamWhitelist(); len(whitelist) > 0 {
+		if !cmd.GetTreatQueryAsPage() {
+			logger.Fatal("Error: --query-param-whitelist requires --treat-query-as-page.")
+		}
+		crawlOpts.QueryParamWhitelist, err = parseQueryParamWhitelist(whitelist)
+		if err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	}
+	crawlOpts.StripQueryParams, err = parseStripQueryParams(cmd.GetStripQueryParams())
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	crawlOpts.StripQuery = cmd.GetStripQuery()
+	if crawlOpts.StripQuery && (cmd.GetTreatQueryAsPage() || len(crawlOpts.StripQueryParams) > 0) {
+		logger.Fatal("Error: --strip-query cannot be combined with --treat-query-as-page or --strip-query-params.")
+	}
+	if cmd.GetCollapseLocales() {
+		startURL, err := url.Parse(startURLForCrawler)
+		if err != nil {
+			logger.Fatalf("Error: Invalid start URL %s: %v", startURLForCrawler, err)
+		}
+		if crawlOpts.Locales, err = newLocaleCollapser(startURL, cmd.GetPreferredLang()); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	} else if cmd.GetPreferredLang() != "" {
+		logger.Fatal("Error: --preferred-lang requires --collapse-locales.")
+	}
+	if cmd.GetLatestVersionOnly() && len(cmd.GetVersions()) > 0 {
+		logger.Fatal("Error: --latest-version-only cannot be combined with --versions.")
+	}
+	if crawlOpts.Versions, err = newVersionFilter(cmd.GetLatestVersionOnly(), cmd.GetVersions()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	crawlOpts.DropFields, err = parseDropFields(cmd.GetDropFields())
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if err := validateTablesMode(crawlOpts.Tables); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	crawlOpts.DOMRules, err = parseDOMRules(cmd.GetDOMRules())
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	crawlOpts.RedactPII, err = parsePIIKinds(cmd.GetRedactPII())
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if err := validateSelectorMode(crawlOpts.SelectorMode); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if err := validateRefererMode(crawlOpts.Referer); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if tokenURL := cmd.GetOAuthTokenURL(); tokenURL != "" {
+		if cmd.GetOAuthClientID() == "" || cmd.GetOAuthClientSecret() == "" {
+			logger.Fatalf("Error: --oauth-token-url requires --oauth-client-id and --oauth-client-secret.")
+		}
+		oauthHosts := cmd.GetOAuthHosts()
+		if len(oauthHosts) == 0 {
+			for _, root := range domainRoots {
+				oauthHosts = append(oauthHosts, root.Host)
+			}
+		}
+		if len(oauthHosts) == 0 {
+			if startURL, err := url.Parse(startURLForCrawler); err == nil {
+				oauthHosts = []string{startURL.Hostname()}
+			}
+		}
+		crawlOpts.OAuth = &oauthClientCredentials{
+			TokenURL:     tokenURL,
+			ClientID:     cmd.GetOAuthClientID(),
+			ClientSecret: cmd.GetOAuthClientSecret(),
+			Scopes:       cmd.GetOAuthScopes(),
+			Hosts:        oauthHosts,
+		}
+		if _, err := crawlOpts.OAuth.Token(context.Background()); err != nil {
+			logger.Fatalf("Error: Failed to obtain OAuth token: %v", err)
+		}
+	} else if cmd.GetOAuthClientID() != "" || cmd.GetOAuthClientSecret() != "" {
+		logger.Fatalf("Error: --oauth-client-id and --oauth-client-secret require --oauth-token-url.")
+	}
+	if crawlOpts.Tables == tablesModeCSV && crawlOpts.OutputDir == "" {
+		logger.Fatalf("Error: --tables csv writes sidecar CSV files and requires --output-dir.")
+	}
+	if crawlOpts.CaptureOGImages && crawlOpts.OutputDir == "" {
+		logger.Fatalf("Error: --capture-og-images saves images next to the page files and requires --output-dir.")
+	}
+	if publishedAfter := cmd.GetPublishedAfter(); publishedAfter != "" {
+		crawlOpts.PublishedAfter, err = parsePublishedDate(publishedAfter)
+		if err != nil {
+			logger.Fatalf("Error: Invalid --published-after value: %v", err)
+		}
+	}
+	if crawlOpts.Progress, err = newProgressReporter(cmd.GetProgressFormat(), cmd.GetProgressFile()); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	defer crawlOpts.Progress.Close()
+	if auditLogPath := cmd.GetAuditLog(); auditLogPath != "" {
+		crawlOpts.AuditLog, err = newAuditLog(auditLogPath)
+		if err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+		defer crawlOpts.AuditLog.Close()
+	}
+
+	if path := cmd.GetWASMTransform(); path != "" {
+		if crawlOpts.WASMTransform, err = loadWASMTransform(path); err != nil {
+			logger.Fatalf("Error: Invalid --wasm-transform: %v", err)
+		}
+		defer crawlOpts.WASMTransform.Close()
 	}
-	defer browserPrepareCleanup()
 
+	// Plugins and browsers are started only once every flag is valid. logger.Fatal exits
+	// without running deferred calls, so failures from here on go through fatalf, which
+	// stops them first instead of leaving the processes behind.
+	if paths := cmd.GetPlugins(); len(paths) > 0 {
+		if crawlOpts.Plugins, err = startPlugins(paths); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+	}
+	stopBrowsers := func() {}
+	fatalf := func(format string, v ...any) {
+		stopBrowsers()
+		crawlOpts.Plugins.Close()
+		logger.Fatalf(format, v...)
+	}
+
+	var playwrightDriverDir, browserExecutablePath string
 	var lightpandaCmd *exec.Cmd
 	var wsURL string
 	var pwInstance *playwright.Playwright
 	var pwBrowser playwright.Browser
 	var lpStdout, lpStderr *bytes.Buffer
+	var lightpandaLog io.Writer
+	var fallbackPWBrowser playwright.Browser
 
-	verboseBrowser := cmd.GetVerboseBrowser()
+	// A crawl from --fixture-dir reads files and needs no browser.
+	if fixtures == nil {
+		playwrightDriverDir, err = GetAppSubdirectory("playwright_driver")
+		if err != nil {
+			fatalf("Failed to determine or create Sitepanda's Playwright driver directory: %v", err)
+		}
 
-	lightpandaCmd, wsURL, pwInstance, pwBrowser, lpStdout, lpStderr, err = launchBrowserAndGetConnection(browserName, browserExecutablePath, playwrightDriverDir, verboseBrowser)
-	if err != nil {
-		logger.Fatalf("Failed to launch %s or connect: %v.", browserName, err)
-	}
+		var browserPrepareCleanup func()
+		browserExecutablePath, browserPrepareCleanup, err = prepareBrowser(browserName, playwrightDriverDir)
+		if err != nil {
+			fatalf("Failed to prepare %s: %v. If not installed, please run 'sitepanda init %s'.", browserName, err, browserName)
+		}
+		defer browserPrepareCleanup()
 
-	defer func() {
-		if pwBrowser != nil && pwBrowser.IsConnected() {
-			logger.Printf("Closing Playwright browser connection for %s...", browserName)
-			if err := pwBrowser.Close(); err != nil {
-				logger.Printf("Warning: failed to close Playwright browser for %s: %v", browserName, err)
+		verboseBrowser := cmd.GetVerboseBrowser()
+
+		if browserLogFile := cmd.GetBrowserLogFile(); browserLogFile != "" && browserName == "lightpanda" {
+			browserLogMaxSize, err := parseByteSize(cmd.GetBrowserLogMaxSize())
+			if err != nil {
+				fatalf("Error: Invalid --browser-log-max-size value: %v", err)
+			}
+			rotatingLog, err := newRotatingFileWriter(browserLogFile, browserLogMaxSize, cmd.GetBrowserLogMaxBackups())
+			if err != nil {
+				fatalf("Error: Failed to open --browser-log-file: %v", err)
 			}
+			defer rotatingLog.Close()
+			lightpandaLog = rotatingLog
+			logger.Printf("Writing Lightpanda output to %s", browserLogFile)
+		}
+
+		var daemonState *browserDaemonState
+		if !cmd.GetNoDaemon() {
+			daemonState = findRunningBrowserDaemon(browserName)
 		}
-		if pwInstance != nil {
-			logger.Printf("Stopping Playwright instance for %s...", browserName)
-			if err := pwInstance.Stop(); err != nil {
-				logger.Printf("Warning: failed to stop Playwright instance for %s: %v", browserName, err)
+		if daemonState != nil {
+			logger.Printf("Using running %s daemon (PID %d) at %s", browserName, daemonState.PID, daemonState.Endpoint)
+			wsURL, pwInstance, pwBrowser, err = connectToBrowserDaemon(daemonState, playwrightDriverDir)
+			if err != nil {
+				notifyFailure(fmt.Sprintf("failed to connect to %s daemon: %v", browserName, err), CrawlResult{StopReason: "Failed to start"})
+				fatalf("Failed to connect to %s daemon: %v. Run 'sitepanda browser stop --browser %s' or use --no-daemon.", browserName, err, browserName)
+			}
+		} else {
+			lightpandaCmd, wsURL, pwInstance, pwBrowser, lpStdout, lpStderr, err = launchBrowserAndGetConnection(browserName, browserExecutablePath, playwrightDriverDir, verboseBrowser, lightpandaLog)
+			if err != nil {
+				notifyFailure(fmt.Sprintf("failed to launch %s: %v", browserName, err), CrawlResult{StopReason: "Failed to start"})
+				fatalf("Failed to launch %s or connect: %v.", browserName, err)
 			}
 		}
-		if lightpandaCmd != nil && lightpandaCmd.Process != nil {
-			logger.Printf("Attempting to terminate Lightpanda process (PID: %d)...", lightpandaCmd.Process.Pid)
-			if killErr := lightpandaCmd.Process.Kill(); killErr != nil {
-				logger.Printf("Warning: failed to kill Lightpanda process (PID: %d): %v", lightpandaCmd.Process.Pid, killErr)
-			} else {
-				logger.Printf("Lightpanda process (PID: %d) terminated.", lightpandaCmd.Process.Pid)
+
+		stopBrowsers = func() {
+			shutdownBrowser(browserName, pwBrowser, pwInstance, lightpandaCmd)
+		}
+
+		if fallbackBrowserName != "" {
+			var shutdownFallback func()
+			fallbackPWBrowser, shutdownFallback, err = launchFallbackBrowser(fallbackBrowserName, playwrightDriverDir, verboseBrowser)
+			if err != nil {
+				notifyFailure(fmt.Sprintf("failed to launch fallback browser %s: %v", fallbackBrowserName, err), CrawlResult{StopReason: "Failed to start"})
+				fatalf("Failed to launch fallback browser %s: %v. If not installed, please run 'sitepanda init %s'.", fallbackBrowserName, err, fallbackBrowserName)
+			}
+			stopPrimary := stopBrowsers
+			stopBrowsers = func() {
+				shutdownFallback()
+				stopPrimary()
 			}
-			_ = lightpandaCmd.Wait()
 		}
+	}
+	defer func() {
+		stopBrowsers()
 	}()
 
-	// Configuration logging
-	outfile := cmd.GetOutfile()
-	matchPatterns := cmd.GetMatchPatterns()
-	followMatchPatterns := cmd.GetFollowMatchPatterns()
-	pageLimit := cmd.GetPageLimit()
-	contentSelector := cmd.GetContentSelector()
-	waitForNetworkIdle := cmd.GetWaitForNetworkIdle()
-	outputFormat := cmd.GetOutputFormat()
-
 	logger.Printf("Configuration:")
 	logger.Printf("  Start URL (or first from list): %s", startURLForCrawler)
-	if isURLListMode {
+	if len(domainRoots) > 0 {
+		logger.Printf("  Mode: Multi-site Crawl from %s, %d sites (limit per site)", domainsFile, len(domainRoots))
+	} else if len(interactions) > 0 {
+		logger.Printf("  Mode: Interaction Crawl, %d interactions from %s", len(interactions), cmd.GetInteractFile())
+	} else if searchQuery != "" {
+		logger.Printf("  Mode: URL List from %s search (%q), %d URLs", cmd.GetSearchEngine(), searchQuery, len(targetURLsForCrawler))
+	} else if isURLListMode {
 		logger.Printf("  Mode: URL List from file (%s), %d URLs", urlFile, len(targetURLsForCrawler))
 	} else {
 		logger.Printf("  Mode: Single URL Crawl")
 	}
 	logger.Printf("  Browser: %s", browserName)
-	if browserName == "lightpanda" {
+	if fallbackBrowserName != "" {
+		logger.Printf("  Fallback Browser: %s", fallbackBrowserName)
+	}
+	if crawlOpts.Throttle != nil {
+		logger.Printf("  Throttle: %s", crawlOpts.Throttle)
+	}
+	if crawlOpts.Concurrency > 1 {
+		logger.Printf("  Concurrency: %d pages", crawlOpts.Concurrency)
+	} else if crawlOpts.Prefetch {
+		logger.Printf("  Prefetch: enabled")
+	}
+	if crawlOpts.DisableServiceWorkers {
+		logger.Printf("  Service Workers: disabled")
+	}
+	if crawlOpts.BypassCache {
+		logger.Printf("  HTTP Cache: bypassed")
+	}
+	if !crawlOpts.FreezeTime.IsZero() {
+		logger.Printf("  Frozen Time: %s", crawlOpts.FreezeTime.Format(time.RFC3339))
+	}
+	if len(crawlOpts.QueryParamWhitelist) > 0 {
+		logger.Printf("  Query Params Distinguishing Pages: %s", strings.Join(crawlOpts.QueryParamWhitelist, ", "))
+	}
+	if len(crawlOpts.StripQueryParams) > 0 {
+		logger.Printf("  Query Params Stripped From Links: %s", strings.Join(crawlOpts.StripQueryParams, ", "))
+	}
+	if crawlOpts.StripQuery {
+		logger.Println("  Query Strings Stripped From Links: true")
+	}
+	if crawlOpts.AdaptiveWait {
+		logger.Printf("  Adaptive Wait: enabled (settle %s)", adaptiveWaitSettle)
+	}
+	if fixtures != nil {
+		logger.Printf("  Fixture Directory: %s (no browser)", fixtures.dir)
+	} else if browserName == "lightpanda" {
 		logger.Printf("  Lightpanda Path: %s", browserExecutablePath)
 		logger.Printf("  Lightpanda WebSocket: %s", wsURL)
 	} else if browserName == "chromium" {
 		logger.Printf("  Chromium managed by Playwright in: %s", playwrightDriverDir)
 	}
-	logger.Printf("  Outfile: %s", outfile)
+	logger.Printf("  Outfile: %s", strings.Join(outfiles, ", "))
+	if crawlOpts.OutputDir != "" {
+		logger.Printf("  Output Directory: %s", crawlOpts.OutputDir)
+	}
+	if crawlOpts.TOCFile != "" {
+		logger.Printf("  Table of Contents: %s", crawlOpts.TOCFile)
+	}
+	if crawlOpts.SEOReport != "" {
+		logger.Printf("  SEO Report: %s", crawlOpts.SEOReport)
+	}
 	logger.Printf("  Output Format: %s", outputFormat)
 	logger.Printf("  Match Patterns (for content saving): %v", matchPatterns)
 	if isURLListMode {
@@ -149,21 +754,86 @@ func HandleScraping(args []string) {
 	} else {
 		logger.Printf("  Follow Match Patterns (for crawling): %v", followMatchPatterns)
 	}
+	if len(crawlOpts.ExcludeMatch) > 0 {
+		logger.Printf("  Exclude Match Patterns (not saved): %v", cmd.GetExcludeMatch())
+	}
+	if len(crawlOpts.ExcludeFollow) > 0 {
+		logger.Printf("  Exclude Follow Patterns (not crawled): %v", cmd.GetExcludeFollow())
+	}
 	logger.Printf("  Page Limit: %d", pageLimit)
+	if crawlOpts.MaxDepth > 0 {
+		logger.Printf("  Max Depth: %d", crawlOpts.MaxDepth)
+	}
+	if crawlOpts.Bytes != nil {
+		logger.Printf("  Max Bytes Downloaded: %s", formatByteSize(crawlOpts.Bytes.limit))
+	}
 	logger.Printf("  Content Selector: %s", contentSelector)
 	logger.Printf("  Silent: %t", cmd.GetSilent())
 	logger.Printf("  Wait For Network Idle: %t", waitForNetworkIdle)
 	logger.Printf("  Verbose Browser Logs: %t", cmd.GetVerboseBrowser())
+	if lightpandaLog != nil {
+		logger.Printf("  Browser Log File: %s (max %s, %d backups)", cmd.GetBrowserLogFile(), cmd.GetBrowserLogMaxSize(), cmd.GetBrowserLogMaxBackups())
+	}
+	if crawlOpts.MaxPageBytes > 0 {
+		logger.Printf("  Max Page Size: %s", formatByteSize(crawlOpts.MaxPageBytes))
+	} else {
+		logger.Printf("  Max Page Size: unlimited")
+	}
+	logger.Printf("  Process Timeout: %s", crawlOpts.ProcessTimeout)
+	if crawlOpts.MaxMemory > 0 {
+		logger.Printf("  Max Memory: %s", formatByteSize(crawlOpts.MaxMemory))
+	}
+	if crawlOpts.AuditLog != nil {
+		logger.Printf("  Audit Log: %s", cmd.GetAuditLog())
+	}
+	if crawlOpts.IncludeComments {
+		logger.Printf("  Include Comments: true")
+	}
+	if crawlOpts.Tables != "" {
+		logger.Printf("  Tables: %s", crawlOpts.Tables)
+	}
+	if len(crawlOpts.DOMRules) > 0 {
+		logger.Printf("  DOM Rules: %v", cmd.GetDOMRules())
+	}
+	if crawlOpts.FollowRelNext {
+		logger.Printf("  Follow rel=next: true")
+	}
+	if crawlOpts.A11yTree {
+		logger.Printf("  Accessibility Tree: true")
+	}
+	if crawlOpts.CaptureOGImages {
+		logger.Printf("  Capture og:image/favicon: true")
+	}
+	if len(crawlOpts.RedactPII) > 0 {
+		logger.Printf("  Redact PII: %v", cmd.GetRedactPII())
+	}
+	if len(crawlOpts.DropFields) > 0 {
+		logger.Printf("  Drop Fields: %v", crawlOpts.DropFields)
+	}
+	if crawlOpts.MaxContentLength > 0 {
+		logger.Printf("  Max Content Length: %d", crawlOpts.MaxContentLength)
+	}
+	if crawlOpts.Referer != "" {
+		logger.Printf("  Referer: %s", crawlOpts.Referer)
+	}
+	if crawlOpts.OAuth != nil {
+		logger.Printf("  OAuth: client %s via %s for %s", crawlOpts.OAuth.ClientID, crawlOpts.OAuth.TokenURL, strings.Join(crawlOpts.OAuth.Hosts, ", "))
+	}
+	if !crawlOpts.PublishedAfter.IsZero() {
+		logger.Printf("  Published After: %s", formatPublishedDate(crawlOpts.PublishedAfter))
+	}
 
 	var crawler *Crawler
 	var crawlerErr error
 
-	if browserName == "lightpanda" {
-		crawler, crawlerErr = NewCrawlerForLightpanda(startURLForCrawler, targetURLsForCrawler, isURLListMode, wsURL, pwInstance, pageLimit, matchPatterns, followMatchPatterns, contentSelector, outfile, cmd.GetSilent(), waitForNetworkIdle, outputFormat)
+	if fixtures != nil {
+		crawler, crawlerErr = NewCrawlerForFixtures(startURLForCrawler, targetURLsForCrawler, isURLListMode, pageLimit, matchPatterns, followMatchPatterns, contentSelector, outfile, cmd.GetSilent(), waitForNetworkIdle, outputFormat, crawlOpts)
+	} else if browserName == "lightpanda" {
+		crawler, crawlerErr = NewCrawlerForLightpanda(startURLForCrawler, targetURLsForCrawler, isURLListMode, wsURL, pwInstance, pageLimit, matchPatterns, followMatchPatterns, contentSelector, outfile, cmd.GetSilent(), waitForNetworkIdle, outputFormat, crawlOpts)
 	} else if browserName == "chromium" {
-		crawler, crawlerErr = NewCrawlerForPlaywrightBrowser(startURLForCrawler, targetURLsForCrawler, isURLListMode, pwBrowser, pageLimit, matchPatterns, followMatchPatterns, contentSelector, outfile, cmd.GetSilent(), waitForNetworkIdle, outputFormat)
+		crawler, crawlerErr = NewCrawlerForPlaywrightBrowser(startURLForCrawler, targetURLsForCrawler, isURLListMode, pwBrowser, pageLimit, matchPatterns, followMatchPatterns, contentSelector, outfile, cmd.GetSilent(), waitForNetworkIdle, outputFormat, crawlOpts)
 	} else {
-		logger.Fatalf("Unsupported browser for crawler creation: %s", browserName)
+		fatalf("Unsupported browser for crawler creation: %s", browserName)
 	}
 
 	if crawlerErr != nil {
@@ -173,7 +843,14 @@ func HandleScraping(args []string) {
 		if lpStderr != nil && lpStderr.Len() > 0 {
 			logger.Printf("--- Browser stderr (on NewCrawler failure) ---\n%s", lpStderr.String())
 		}
-		logger.Fatalf("Failed to initialize crawler: %v", crawlerErr)
+		notifyFailure(fmt.Sprintf("failed to initialize crawler: %v", crawlerErr), CrawlResult{StopReason: "Failed to start"})
+		fatalf("Failed to initialize crawler: %v", crawlerErr)
+	}
+	if fallbackPWBrowser != nil {
+		if err := crawler.attachFallbackBrowser(fallbackBrowserName, fallbackPWBrowser); err != nil {
+			notifyFailure(err.Error(), CrawlResult{StopReason: "Failed to start"})
+			fatalf("Failed to initialize fallback browser: %v", err)
+		}
 	}
 
 	// Setup signal handling for graceful shutdown with partial results
@@ -186,6 +863,9 @@ func HandleScraping(args []string) {
 	}()
 
 	crawlResult, crawlErr := crawler.Crawl()
+	crawlOpts.Plugins.OnFinish(crawlResult)
+	crawlOpts.Progress.Finish(crawlResult)
+	crawlOpts.Plugins.Close()
 
 	// This block handles fatal errors from *before* the crawl loop started.
 	if crawlErr != nil {
@@ -201,22 +881,170 @@ func HandleScraping(args []string) {
 		}
 	}
 
+	var reportErr error
+	if reportPath := cmd.GetReportFile(); reportPath != "" {
+		reportErr = writeCrawlReport(reportPath, newCrawlReport(startURLForCrawler, runStarted, crawlResult))
+	}
+
+	var manifestFiles []manifestFile
+	var manifestErr error
+	if manifestPath := cmd.GetManifestFile(); manifestPath != "" {
+		var browserVersion string
+		if crawler.pwBrowser != nil {
+			browserVersion = crawler.pwBrowser.Version()
+		}
+		outputFile, outputBundle := crawlResult.OutputFile, ""
+		if crawlResult.OutputFileError != nil || len(crawlResult.StoreResults) > 0 {
+			outputFile = ""
+		} else if isDirectoryOutputFormat(outputFormat) {
+			outputFile, outputBundle = "", crawlResult.OutputFile
+		}
+		files := []string{outputFile, crawlResult.TOCFile, crawlResult.SEOReport, cmd.GetAuditLog(), cmd.GetReportFile()}
+		for _, f := range crawlResult.SplitFiles {
+			files = append(files, f.Path)
+		}
+		dirs := []string{crawlResult.OutputDir, outputBundle}
+		for _, f := range crawlResult.RouteFiles {
+			if strings.HasSuffix(f.Path, "/") || strings.HasSuffix(f.Path, string(filepath.Separator)) {
+				dirs = append(dirs, f.Path)
+			} else {
+				files = append(files, f.Path)
+			}
+		}
+		for _, store := range crawlResult.StoreResults {
+			path, ok := localStorePath(store.Location)
+			if !ok {
+				path, ok = snapshotDBPath(store.Location)
+			}
+			if ok && store.Err == nil {
+				files = append(files, path)
+			}
+		}
+		manifestFiles, manifestErr = collectManifestFiles(manifestPath, files, dirs)
+		if manifestErr == nil {
+			manifestErr = writeManifest(manifestPath, runManifest{
+				ManifestVersion:  manifestFormatVersion,
+				SitepandaVersion: Version,
+				Browser:          browserName,
+				BrowserVersion:   browserVersion,
+				GeneratedAt:      time.Now().UTC(),
+				StartURL:         startURLForCrawler,
+				Status:           crawlResult.StopReason,
+				PagesSaved:       crawlResult.PagesSaved,
+				Config:           cmd.GetEffectiveConfig(),
+				Files:            manifestFiles,
+			})
+		}
+	}
+
 	// Always print the summary report at the end.
 	var summary strings.Builder
 	summary.WriteString("\n--------------------\n")
 	summary.WriteString("  Scraping Summary\n")
 	summary.WriteString("--------------------\n")
 	summary.WriteString(fmt.Sprintf("  Status: %s\n", crawlResult.StopReason))
+	summary.WriteString(fmt.Sprintf("  Duration: %s\n", crawlResult.Duration.Round(time.Second)))
+	summary.WriteString(fmt.Sprintf("  Pages Fetched: %d\n", crawlResult.PagesFetched))
 	summary.WriteString(fmt.Sprintf("  Pages Saved: %d\n", crawlResult.PagesSaved))
+	if crawlOpts.SplitHeading > 0 {
+		summary.WriteString(fmt.Sprintf("  Section Records (--split-by-heading h%d): %d\n", crawlOpts.SplitHeading, crawlResult.SectionRecords))
+	}
+	if crawlResult.MatchSkippedPages > 0 {
+		summary.WriteString(fmt.Sprintf("  Not Matched (links followed only): %d\n", crawlResult.MatchSkippedPages))
+	}
+	if len(crawlOpts.DownloadAssets) > 0 {
+		summary.WriteString(fmt.Sprintf("  Files Downloaded: %d (%s)\n", len(crawlResult.DownloadedAssets), crawlOpts.AssetDir))
+	}
+	writeSkippedPagesSummary(&summary, "Pages Failed", crawlResult.FailedPages)
+	writeSkippedPagesSummary(&summary, "Pages Skipped", crawlResult.SkippedPages)
+	writeSkippedPagesSummary(&summary, "Skipped (Host Unreachable)", crawlResult.ShortcutSkipped)
+	writeSkippedPagesSummary(&summary, "Gated Pages Excluded", crawlResult.GatedPages)
+	writeSkippedPagesSummary(&summary, "Not Relevant (--relevant-to)", crawlResult.IrrelevantPages)
+	writeNearDuplicatesSummary(&summary, crawlResult.NearDuplicates)
 
-	if crawlResult.OutputFile != "" {
+	if crawlResult.OutputDir != "" && crawlResult.PagesSaved > 0 {
+		if crawlResult.OutputDirError != nil {
+			summary.WriteString(fmt.Sprintf("  Output Directory: FAILED to write to %s (%v)\n", crawlResult.OutputDir, crawlResult.OutputDirError))
+		} else {
+			summary.WriteString(fmt.Sprintf("  Output Directory: %s (mapping: %s)\n", crawlResult.OutputDir, pagePathMappingFile))
+		}
+	}
+	if crawlResult.TOCFile != "" && crawlResult.PagesSaved > 0 {
+		if crawlResult.TOCFileError != nil {
+			summary.WriteString(fmt.Sprintf("  Table of Contents: FAILED to write to %s (%v)\n", crawlResult.TOCFile, crawlResult.TOCFileError))
+		} else {
+			summary.WriteString(fmt.Sprintf("  Table of Contents: %s\n", crawlResult.TOCFile))
+		}
+	}
+	if crawlOpts.AdaptiveWait {
+		summary.WriteString(fmt.Sprintf("  Adaptive Wait Retries: %d\n", crawlResult.AdaptiveWaitRetries))
+	}
+	if crawlOpts.Bytes != nil {
+		summary.WriteString(fmt.Sprintf("  Bytes Downloaded: %s of %s\n", formatByteSize(crawlResult.BytesDownloaded), formatByteSize(crawlOpts.Bytes.limit)))
+	} else {
+		summary.WriteString(fmt.Sprintf("  Bytes Downloaded: %s (HTML)\n", formatByteSize(crawlResult.BytesDownloaded)))
+	}
+	if crawlOpts.MaxDepth > 0 {
+		summary.WriteString(fmt.Sprintf("  Pages at Max Depth (links not followed): %d\n", crawlResult.DepthLimitedPages))
+	}
+	if fallbackBrowserName != "" {
+		summary.WriteString(fmt.Sprintf("  Fallback Fetches: %d (%s)\n", crawlResult.FallbackFetches, fallbackBrowserName))
+	}
+	if crawlResult.PIIRedactions != nil {
+		summary.WriteString(fmt.Sprintf("  PII Redacted: %s\n", formatPIIRedactionCounts(crawlResult.PIIRedactions)))
+	}
+	if crawlResult.SEOReport != "" {
+		if crawlResult.SEOReportError != nil {
+			summary.WriteString(fmt.Sprintf("  SEO Report: FAILED to write to %s (%v)\n", crawlResult.SEOReport, crawlResult.SEOReportError))
+		} else {
+			summary.WriteString(fmt.Sprintf("  SEO Report: %s\n", crawlResult.SEOReport))
+		}
+	}
+	if reportPath := cmd.GetReportFile(); reportPath != "" {
+		if reportErr != nil {
+			summary.WriteString(fmt.Sprintf("  Report: FAILED to write to %s (%v)\n", reportPath, reportErr))
+		} else {
+			summary.WriteString(fmt.Sprintf("  Report: %s\n", reportPath))
+		}
+	}
+	if manifestPath := cmd.GetManifestFile(); manifestPath != "" {
+		if manifestErr != nil {
+			summary.WriteString(fmt.Sprintf("  Manifest: FAILED to write to %s (%v)\n", manifestPath, manifestErr))
+		} else {
+			summary.WriteString(fmt.Sprintf("  Manifest: %s (%d files)\n", manifestPath, len(manifestFiles)))
+		}
+	}
+	for _, f := range crawlResult.RouteFiles {
+		summary.WriteString(fmt.Sprintf("  Output Route (%s): %s (%d pages)\n", f.Name, f.Path, f.Pages))
+	}
+	if crawlResult.RouteFileError != nil {
+		summary.WriteString(fmt.Sprintf("  Output Routes: FAILED to write all routes (%v)\n", crawlResult.RouteFileError))
+	}
+	if len(crawlResult.SplitFiles) > 0 {
+		for _, f := range crawlResult.SplitFiles {
+			summary.WriteString(fmt.Sprintf("  Output File (%s): %s (%d pages)\n", f.Name, f.Path, f.Pages))
+		}
+		if crawlResult.OutputFileError != nil {
+			summary.WriteString(fmt.Sprintf("  Output Files: FAILED to write all splits (%v)\n", crawlResult.OutputFileError))
+		}
+	} else if len(crawlResult.StoreResults) > 1 {
+		for _, store := range crawlResult.StoreResults {
+			if store.Err != nil {
+				summary.WriteString(fmt.Sprintf("  Output: FAILED to write to %s (%v)\n", store.Location, store.Err))
+			} else {
+				summary.WriteString(fmt.Sprintf("  Output: %s\n", store.Location))
+			}
+		}
+	} else if crawlResult.OutputFile != "" {
 		if crawlResult.OutputFileError != nil {
 			summary.WriteString(fmt.Sprintf("  Output File: FAILED to write to %s (%v)\n", crawlResult.OutputFile, crawlResult.OutputFileError))
 		} else {
 			summary.WriteString(fmt.Sprintf("  Output File: %s\n", crawlResult.OutputFile))
 		}
-	} else {
-		if crawlResult.PagesSaved > 0 {
+	} else if crawlResult.OutputDir == "" || crawlResult.PagesSaved == 0 {
+		if crawlResult.PagesSaved > 0 && len(crawlResult.RouteFiles) > 0 {
+			summary.WriteString("  Output (unrouted pages): stdout\n")
+		} else if crawlResult.PagesSaved > 0 {
 			summary.WriteString("  Output: stdout\n")
 		} else {
 			summary.WriteString("  Output: No pages saved.\n")
@@ -225,5 +1053,111 @@ func HandleScraping(args []string) {
 	summary.WriteString("--------------------")
 	logger.Print(summary.String())
 
+	if cmd.GetHistory() || cmd.GetHistoryFile() != "" {
+		recordRunHistory(runStarted, startURLForCrawler, domainRoots, crawlResult)
+	}
+
+	if reason := crawlFailureReason(crawlResult, crawlErr); reason != "" {
+		notifyFailure(reason, crawlResult)
+	}
+
 	logger.Println("Sitepanda finished.")
 }
+
+// writeSkippedPagesSummary lists skipped pages under title in the summary report, up to
+// maxSkippedPagesInSummary of them.
+func writeSkippedPagesSummary(summary *strings.Builder, title string, pages []SkippedPage) {
+	if len(pages) == 0 {
+		return
+	}
+	summary.WriteString(fmt.Sprintf("  %s: %d\n", title, len(pages)))
+	for i, skipped := range pages {
+		if i == maxSkippedPagesInSummary {
+			summary.WriteString(fmt.Sprintf("    ... and %d more\n", len(pages)-maxSkippedPagesInSummary))
+			break
+		}
+		summary.WriteString(fmt.Sprintf("    - %s (%s)\n", skipped.URL, skipped.Reason))
+	}
+}
+
+// writeNearDuplicatesSummary lists the --dedupe-similarity clusters: each saved page with the
+// pages dropped as its near-duplicates.
+func writeNearDuplicatesSummary(summary *strings.Builder, clusters []NearDuplicateCluster) {
+	if len(clusters) == 0 {
+		return
+	}
+	dropped := 0
+	for _, cluster := range clusters {
+		dropped += len(cluster.Duplicates)
+	}
+	summary.WriteString(fmt.Sprintf("  Near-Duplicates Dropped: %d in %d clusters\n", dropped, len(clusters)))
+	for i, cluster := range clusters {
+		if i == maxSkippedPagesInSummary {
+			summary.WriteString(fmt.Sprintf("    ... and %d more clusters\n", len(clusters)-maxSkippedPagesInSummary))
+			break
+		}
+		summary.WriteString(fmt.Sprintf("    - %s (kept), %d near-duplicates: %s\n", cluster.URL, len(cluster.Duplicates), strings.Join(cluster.Duplicates, ", ")))
+	}
+}
+
+// selectURLListShard returns the part of a --url-file list to process: entries from position
+// offset on, at most limit of them (limit 0 means no cap).
+func selectURLListShard(urls []string, offset, limit int) []string {
+	if offset >= len(urls) {
+		return nil
+	}
+	urls = urls[offset:]
+	if limit > 0 && limit < len(urls) {
+		urls = urls[:limit]
+	}
+	return urls
+}
+
+// buildNotifiers creates the failure notifications configured by the notify section of --config
+// and the --notify-* and --smtp-* flags. A flag overrides the setting it shares with the file.
+func buildNotifiers() ([]notification, error) {
+	var cfg notifyConfig
+	if path := cmd.GetConfigFile(); path != "" {
+		file, err := loadConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg = file.Notify
+	}
+	cfg.Template = cmp.Or(cmd.GetNotifyTemplate(), cfg.Template)
+	cfg.Slack.Webhook = cmp.Or(cmd.GetNotifySlackWebhook(), cfg.Slack.Webhook)
+	cfg.Discord.Webhook = cmp.Or(cmd.GetNotifyDiscordWebhook(), cfg.Discord.Webhook)
+	if to := cmd.GetNotifyEmail(); len(to) > 0 {
+		cfg.Email.To = to
+	}
+	server := &cfg.Email.SMTP
+	server.Host = cmp.Or(cmd.GetSMTPHost(), server.Host)
+	if cmd.GetSMTPPortSet() || server.Port == 0 {
+		server.Port = cmd.GetSMTPPort()
+	}
+	server.Username = cmp.Or(cmd.GetSMTPUsername(), server.Username)
+	server.Password = cmp.Or(cmd.GetSMTPPassword(), server.Password)
+	server.From = cmp.Or(cmd.GetSMTPFrom(), server.From)
+	return cfg.notifications()
+}
+
+// recordRunHistory appends the run's statistics to the run history. Errors are only logged.
+func recordRunHistory(started time.Time, startURL string, domainRoots []domainRoot, result CrawlResult) {
+	path, err := historyFilePath()
+	if err != nil {
+		logger.Printf("Warning: Failed to record run history: %v", err)
+		return
+	}
+	var hosts []string
+	for _, root := range domainRoots {
+		hosts = append(hosts, root.Host)
+	}
+	if len(hosts) == 0 {
+		if u, err := url.Parse(startURL); err == nil {
+			hosts = []string{u.Hostname()}
+		}
+	}
+	if err := appendHistoryRun(path, newHistoryRun(started, startURL, cmd.GetJobName(), hosts, result)); err != nil {
+		logger.Printf("Warning: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Search engines for --search-engine.
+const (
+	searchEngineBing   = "bing"
+	searchEngineGoogle = "google"
+)
+
+// Default API endpoints and page sizes of the supported search engines. Google's Custom Search
+// API returns at most 100 results per query.
+const (
+	bingSearchEndpoint   = "https://api.bing.microsoft.com/v7.0/search"
+	googleSearchEndpoint = "https://www.googleapis.com/customsearch/v1"
+	bingSearchPageSize   = 50
+	googleSearchPageSize = 10
+	googleSearchMaxStart = 91
+)
+
+// webSearch seeds a crawl from search results using a search engine's JSON API.
+type webSearch struct {
+	Engine string
+	APIKey string
+	// CX is the Google Programmable Search Engine ID; unused for Bing.
+	CX string
+	// Endpoint overrides the engine's default API URL, e.g. for a compatible proxy.
+	Endpoint string
+
+	client *http.Client
+}
+
+// validate checks the engine and that the credentials it needs are set.
+func (s *webSearch) validate() error {
+	switch s.Engine {
+	case searchEngineBing:
+	case searchEngineGoogle:
+		if s.CX == "" {
+			return fmt.Errorf("--search-engine google requires --search-cx (the Programmable Search Engine ID)")
+		}
+	default:
+		return fmt.Errorf("invalid --search-engine %q: must be bing or google", s.Engine)
+	}
+	if s.APIKey == "" {
+		return fmt.Errorf("--search requires --search-api-key (or SITEPANDA_SEARCH_API_KEY)")
+	}
+	return nil
+}
+
+// Search returns up to limit distinct result URLs for query, in ranking order.
+func (s *webSearch) Search(ctx context.Context, query string, limit int) ([]string, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var urls []string
+	for offset := 0; len(urls) < limit; {
+		var page []string
+		var err error
+		if s.Engine == searchEngineGoogle {
+			if offset+1 > googleSearchMaxStart {
+				break
+			}
+			page, err = s.searchGoogle(ctx, query, offset, min(googleSearchPageSize, limit-len(urls)))
+		} else {
+			page, err = s.searchBing(ctx, query, offset, min(bingSearchPageSize, limit-len(urls)))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		offset += len(page)
+		for _, u := range page {
+			if len(urls) == limit {
+				break
+			}
+			normalized, err := normalizeURLtoString(u)
+			if err != nil || seen[normalized] {
+				continue
+			}
+			seen[normalized] = true
+			urls = append(urls, normalized)
+		}
+	}
+	return urls, nil
+}
+
+type bingSearchResponse struct {
+	WebPages struct {
+		Value []struct {
+			URL string `json:"url"`
+		} `json:"value"`
+	} `json:"webPages"`
+}
+
+func (s *webSearch) searchBing(ctx context.Context, query string, offset, count int) ([]string, error) {
+	params := url.Values{
+		"q":      {query},
+		"count":  {strconv.Itoa(count)},
+		"offset": {strconv.Itoa(offset)},
+	}
+	var resp bingSearchResponse
+	if err := s.getJSON(ctx, s.endpoint(bingSearchEndpoint), params, map[string]string{"Ocp-Apim-Subscription-Key": s.APIKey}, &resp); err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, v := range resp.WebPages.Value {
+		urls = append(urls, v.URL)
+	}
+	return urls, nil
+}
+
+type googleSearchResponse struct {
+	Items []struct {
+		Link string `json:"link"`
+	} `json:"items"`
+}
+
+func (s *webSearch) searchGoogle(ctx context.Context, query string, offset, count int) ([]string, error) {
+	params := url.Values{
+		"key":   {s.APIKey},
+		"cx":    {s.CX},
+		"q":     {query},
+		"num":   {strconv.Itoa(count)},
+		"start": {strconv.Itoa(offset + 1)},
+	}
+	var resp googleSearchResponse
+	if err := s.getJSON(ctx, s.endpoint(googleSearchEndpoint), params, nil, &resp); err != nil {
+		return nil, err
+	}
+	var urls []string
+	for _, item := range resp.Items {
+		urls = append(urls, item.Link)
+	}
+	return urls, nil
+}
+
+func (s *webSearch) endpoint(defaultEndpoint string) string {
+	if s.Endpoint != "" {
+		return s.Endpoint
+	}
+	return defaultEndpoint
+}
+
+// getJSON performs a search API request and decodes its JSON response into v.
+func (s *webSearch) getJSON(ctx context.Context, endpoint string, params url.Values, headers map[string]string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to build %s search request: %w", s.Engine, err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+	client := s.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s search request failed: %w", s.Engine, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read %s search response: %w", s.Engine, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s search returned status %s: %s", s.Engine, resp.Status, truncateRunes(string(body), 200))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode %s search response: %w", s.Engine, err)
+	}
+	return nil
+}
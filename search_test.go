@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestWebSearchBing(t *testing.T) {
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Ocp-Apim-Subscription-Key") != "key" || r.URL.Query().Get("q") != "site:example.com go" {
+			http.Error(w, "bad request", http.StatusUnauthorized)
+			return
+		}
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		count, _ := strconv.Atoi(r.URL.Query().Get("count"))
+		var values []map[string]string
+		for i := offset; i < offset+count && i < 5; i++ {
+			values = append(values, map[string]string{"url": fmt.Sprintf("https://example.com/p%d", i%4)})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"webPages": map[string]any{"value": values}})
+	}))
+	defer server.Close()
+
+	s := &webSearch{Engine: searchEngineBing, APIKey: "key", Endpoint: server.URL}
+	got, err := s.Search(context.Background(), "site:example.com go", 10)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	want := []string{"https://example.com/p0", "https://example.com/p1", "https://example.com/p2", "https://example.com/p3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Search() = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(offsets, []string{"0", "5"}) {
+		t.Errorf("requested offsets = %v, want [0 5]", offsets)
+	}
+
+	s.APIKey = "wrong"
+	if _, err := s.Search(context.Background(), "site:example.com go", 10); err == nil {
+		t.Errorf("Search() with a rejected key expected error")
+	}
+}
+
+func TestWebSearchGoogle(t *testing.T) {
+	var starts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("key") != "key" || q.Get("cx") != "engine" {
+			http.Error(w, "bad request", http.StatusForbidden)
+			return
+		}
+		starts = append(starts, q.Get("start")+"/"+q.Get("num"))
+		start, _ := strconv.Atoi(q.Get("start"))
+		num, _ := strconv.Atoi(q.Get("num"))
+		var items []map[string]string
+		for i := start; i < start+num; i++ {
+			items = append(items, map[string]string{"link": fmt.Sprintf("https://example.com/r%d", i)})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items})
+	}))
+	defer server.Close()
+
+	s := &webSearch{Engine: searchEngineGoogle, APIKey: "key", CX: "engine", Endpoint: server.URL}
+	got, err := s.Search(context.Background(), "kubernetes", 25)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(got) != 25 || got[0] != "https://example.com/r1" || got[24] != "https://example.com/r25" {
+		t.Errorf("Search() = %v", got)
+	}
+	if want := []string{"1/10", "11/10", "21/5"}; !reflect.DeepEqual(starts, want) {
+		t.Errorf("requested pages = %v, want %v", starts, want)
+	}
+
+	starts = nil
+	got, err = s.Search(context.Background(), "kubernetes", 500)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(got) != 100 {
+		t.Errorf("Search() returned %d results, want Google's cap of 100", len(got))
+	}
+}
+
+func TestWebSearchValidate(t *testing.T) {
+	tests := []webSearch{
+		{Engine: "duckduckgo", APIKey: "key"},
+		{Engine: searchEngineBing},
+		{Engine: searchEngineGoogle, APIKey: "key"},
+	}
+	for _, s := range tests {
+		if err := s.validate(); err == nil {
+			t.Errorf("validate(%+v) expected error", s)
+		}
+	}
+	ok := webSearch{Engine: searchEngineGoogle, APIKey: "key", CX: "cx"}
+	if err := ok.validate(); err != nil {
+		t.Errorf("validate() error = %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hokupod/sitepanda/cmd"
+)
+
+const (
+	// selftestDocPages is the number of pages in the synthetic site's docs section.
+	selftestDocPages = 5
+	// selftestBlogPosts is the number of blog posts, listed selftestPostsPerPage to a page.
+	selftestBlogPosts    = 6
+	selftestPostsPerPage = 3
+	// selftestSlowDelay is how long /page2 takes to respond.
+	selftestSlowDelay = 2 * time.Second
+)
+
+// selftestRobots is the synthetic site's robots.txt.
+const selftestRobots = "User-agent: *\nDisallow: /private/\n"
+
+// selftestSPAScript renders /spa's content half a second after load, like a client-side app.
+const selftestSPAScript = `setTimeout(() => {
+  document.getElementById("app").innerHTML = "<article><h1>Rendered by JavaScript</h1>" +
+    "<p>This paragraph only exists after the page's script has run, so it is extracted with --wait-for-network-idle or --adaptive-wait.</p></article>";
+}, 500);`
+
+// newSelftestSite returns the handler of the synthetic site served by 'sitepanda
+// selftest-server' and the integration tests, delaying every response by latency.
+func newSelftestSite(latency time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		writeSelftestPage(w, http.StatusOK, "Sitepanda Test Site", `<h1>Sitepanda Test Site</h1>
+<p>A synthetic site for trying Sitepanda's flags without touching external sites.</p>
+<ul>
+<li><a href="/docs/">Documentation</a> with tables and code blocks</li>
+<li><a href="/blog/">Blog</a> with paginated listings</li>
+<li><a href="/spa">A page rendered by JavaScript</a></li>
+<li><a href="/page1">A two-page walk</a> ending on a slow page</li>
+<li><a href="/private/">A private area</a> disallowed by robots.txt</li>
+<li><a href="/missing">A missing page</a></li>
+</ul>`)
+	})
+	mux.HandleFunc("GET /robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, selftestRobots)
+	})
+	mux.HandleFunc("GET /docs/{$}", func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		b.WriteString("<h1>Documentation</h1>\n<ol>\n")
+		for n := 1; n <= selftestDocPages; n++ {
+			fmt.Fprintf(&b, "<li><a href=\"/docs/%d\">Chapter %d</a></li>\n", n, n)
+		}
+		b.WriteString("</ol>")
+		writeSelftestPage(w, http.StatusOK, "Documentation", b.String())
+	})
+	mux.HandleFunc("GET /docs/{n}", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.PathValue("n"))
+		if err != nil || n < 1 || n > selftestDocPages {
+			writeSelftestNotFound(w)
+			return
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, `<h1>Chapter %d</h1>
+<p>Chapter %d of the synthetic documentation explains one step of a crawl in enough prose for readability to treat it as the main content of the page.</p>
+<h2>Options</h2>
+<table><thead><tr><th>Flag</th><th>Effect</th></tr></thead>
+<tbody><tr><td>--limit</td><td>Stops after this many pages</td></tr><tr><td>--match</td><td>Saves only matching paths</td></tr></tbody></table>
+<h2>Example</h2>
+<pre><code>sitepanda scrape --match "/docs/**" http://127.0.0.1/docs/%d</code></pre>
+<p>`, n, n, n)
+		if n > 1 {
+			fmt.Fprintf(&b, `<a href="/docs/%d">Previous chapter</a> `, n-1)
+		}
+		if n < selftestDocPages {
+			fmt.Fprintf(&b, `<a href="/docs/%d">Next chapter</a>`, n+1)
+		}
+		b.WriteString("</p>")
+		writeSelftestPage(w, http.StatusOK, fmt.Sprintf("Chapter %d", n), b.String())
+	})
+	mux.HandleFunc("GET /blog/{$}", func(w http.ResponseWriter, r *http.Request) {
+		pages := (selftestBlogPosts + selftestPostsPerPage - 1) / selftestPostsPerPage
+		page := 1
+		if value := r.URL.Query().Get("page"); value != "" {
+			var err error
+			if page, err = strconv.Atoi(value); err != nil || page < 1 || page > pages {
+				writeSelftestNotFound(w)
+				return
+			}
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "<h1>Blog, page %d of %d</h1>\n<ul>\n", page, pages)
+		for n := (page-1)*selftestPostsPerPage + 1; n <= min(page*selftestPostsPerPage, selftestBlogPosts); n++ {
+			fmt.Fprintf(&b, "<li><a href=\"/blog/post-%d\">Post %d</a></li>\n", n, n)
+		}
+		b.WriteString("</ul>")
+		if page < pages {
+			fmt.Fprintf(&b, "\n<p><a rel=\"next\" href=\"/blog/?page=%d\">Older posts</a></p>", page+1)
+		}
+		writeSelftestPage(w, http.StatusOK, fmt.Sprintf("Blog, page %d", page), b.String())
+	})
+	mux.HandleFunc("GET /blog/{post}", func(w http.ResponseWriter, r *http.Request) {
+		number, ok := strings.CutPrefix(r.PathValue("post"), "post-")
+		n, err := strconv.Atoi(number)
+		if !ok || err != nil || n < 1 || n > selftestBlogPosts {
+			writeSelftestNotFound(w)
+			return
+		}
+		writeSelftestPage(w, http.StatusOK, fmt.Sprintf("Post %d", n), fmt.Sprintf(`<article>
+<h1>Post %d</h1>
+<p><time datetime="2024-01-%02d">January %d, 2024</time></p>
+<p>Blog post %d of the synthetic site. Posts are reached through a paginated listing, so crawling all of them needs the listing's second page.</p>
+</article>`, n, n, n, n))
+	})
+	mux.HandleFunc("GET /spa", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><title>Client-side App</title></head><body><div id=\"app\"></div><script>%s</script></body></html>\n", selftestSPAScript)
+	})
+	mux.HandleFunc("GET /private/", func(w http.ResponseWriter, r *http.Request) {
+		writeSelftestPage(w, http.StatusOK, "Private", "<h1>Private</h1>\n<p>robots.txt disallows this page, so it is only crawled with --ignore-robots.</p>")
+	})
+	mux.HandleFunc("GET /page1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintln(w, `<html><head><title>Page 1</title></head><body><h1>Hello</h1><p>This is page 1.</p><a href="/page2">Page 2</a></body></html>`)
+	})
+	mux.HandleFunc("GET /page2", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		// Slow enough for tests to interrupt a crawl while it waits.
+		time.Sleep(selftestSlowDelay)
+		fmt.Fprintln(w, `<html><head><title>Page 2</title></head><body><p>This is page 2.</p></body></html>`)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeSelftestNotFound(w)
+	})
+	if latency <= 0 {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// writeSelftestPage writes a page of the synthetic site with its shared navigation and footer.
+func writeSelftestPage(w http.ResponseWriter, status int, title string, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en"><head><meta charset="utf-8"><title>%s</title></head>
+<body>
+<header><nav><a href="/">Home</a> <a href="/docs/">Docs</a> <a href="/blog/">Blog</a></nav></header>
+<main>
+%s
+</main>
+<footer><p>Sitepanda test site</p></footer>
+</body></html>
+`, html.EscapeString(title), body)
+}
+
+func writeSelftestNotFound(w http.ResponseWriter) {
+	writeSelftestPage(w, http.StatusNotFound, "Not Found", "<h1>Not Found</h1>\n<p>This page does not exist.</p>")
+}
+
+// HandleSelftestServer runs 'sitepanda selftest-server' until it is interrupted.
+func HandleSelftestServer() {
+	address := net.JoinHostPort(cmd.GetSelftestHost(), strconv.Itoa(cmd.GetSelftestPort()))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logger.Fatalf("Error: failed to listen on %s: %v", address, err)
+	}
+	server := &http.Server{Handler: newSelftestSite(cmd.GetSelftestLatency())}
+
+	siteURL := "http://" + listener.Addr().String() + "/"
+	// The URL goes to stdout so scripts can read the chosen port.
+	fmt.Println(siteURL)
+	logger.Printf("Test site listening on %s (Ctrl+C to stop)", siteURL)
+	logger.Printf("Crawl it with: sitepanda scrape %s", siteURL)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Fatalf("Error: test site stopped: %v", err)
+	}
+	logger.Println("Test site stopped.")
+}
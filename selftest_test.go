@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelftestSite(t *testing.T) {
+	server := httptest.NewServer(newSelftestSite(0))
+	defer server.Close()
+
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/", http.StatusOK, `<a href="/docs/">Documentation</a>`},
+		{"/robots.txt", http.StatusOK, "Disallow: /private/"},
+		{"/docs/", http.StatusOK, `<a href="/docs/5">Chapter 5</a>`},
+		{"/docs/1", http.StatusOK, `<a href="/docs/2">Next chapter</a>`},
+		{"/docs/6", http.StatusNotFound, "does not exist"},
+		{"/blog/", http.StatusOK, `<a rel="next" href="/blog/?page=2">`},
+		{"/blog/?page=2", http.StatusOK, `<a href="/blog/post-6">Post 6</a>`},
+		{"/blog/?page=3", http.StatusNotFound, "does not exist"},
+		{"/blog/post-2", http.StatusOK, "Blog post 2"},
+		{"/spa", http.StatusOK, `<div id="app"></div>`},
+		{"/private/", http.StatusOK, "--ignore-robots"},
+		{"/page1", http.StatusOK, `<a href="/page2">`},
+		{"/missing", http.StatusNotFound, "does not exist"},
+	}
+	for _, tt := range tests {
+		resp, err := http.Get(server.URL + tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status || !strings.Contains(string(body), tt.want) {
+			t.Errorf("GET %s = %d, want %d with %q:\n%s", tt.path, resp.StatusCode, tt.status, tt.want, body)
+		}
+	}
+}
+
+func TestSelftestSiteLatency(t *testing.T) {
+	server := httptest.NewServer(newSelftestSite(100 * time.Millisecond))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("response took %s, want at least the 100ms latency", elapsed)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// seoPage is the --seo-report entry for one fetched page.
+type seoPage struct {
+	URL                   string          `json:"url"`
+	Status                int             `json:"status,omitempty"`
+	Title                 string          `json:"title"`
+	TitleLength           int             `json:"title_length"`
+	MetaDescription       string          `json:"meta_description"`
+	MetaDescriptionLength int             `json:"meta_description_length"`
+	Canonical             string          `json:"canonical,omitempty"`
+	Robots                []string        `json:"robots,omitempty"`
+	H1Count               int             `json:"h1_count"`
+	BrokenInternalLinks   []seoBrokenLink `json:"broken_internal_links,omitempty"`
+
+	internalLinks []string
+}
+
+// seoBrokenLink is an internal link whose target failed when the crawl fetched it.
+type seoBrokenLink struct {
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// seoReportFile is the document written to --seo-report.
+type seoReportFile struct {
+	Pages []*seoPage `json:"pages"`
+}
+
+// seoReport collects SEO data for every fetched page. Links are only checked against URLs the
+// crawl itself fetched, so links that were never followed are not reported. A nil *seoReport
+// discards everything.
+type seoReport struct {
+	pages    []*seoPage
+	failures map[string]seoBrokenLink
+}
+
+func newSEOReport() *seoReport {
+	return &seoReport{failures: make(map[string]seoBrokenLink)}
+}
+
+// AddPage records a fetched page. headers are the page's response headers with lower-cased names.
+func (r *seoReport) AddPage(pageURL *url.URL, status int, headers map[string]string, rawHTML string) {
+	if r == nil {
+		return
+	}
+	page := &seoPage{URL: pageURL.String(), Status: status}
+	if status >= 400 {
+		r.failures[page.URL] = seoBrokenLink{URL: page.URL, Status: status}
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		logger.Printf("Warning: failed to parse HTML for SEO report on %s: %v", page.URL, err)
+		r.pages = append(r.pages, page)
+		return
+	}
+	fillSEOPage(page, pageURL, doc, headers["x-robots-tag"])
+	r.pages = append(r.pages, page)
+}
+
+// RecordFailure records a URL that could not be fetched.
+func (r *seoReport) RecordFailure(pageURL string, status int, fetchErr error) {
+	if r == nil {
+		return
+	}
+	r.failures[pageURL] = seoBrokenLink{URL: pageURL, Status: status, Error: fetchErr.Error()}
+}
+
+// Build resolves broken internal links and returns the report document.
+func (r *seoReport) Build() seoReportFile {
+	for _, page := range r.pages {
+		page.BrokenInternalLinks = nil
+		for _, link := range page.internalLinks {
+			if failure, ok := r.failures[link]; ok {
+				page.BrokenInternalLinks = append(page.BrokenInternalLinks, failure)
+			}
+		}
+	}
+	return seoReportFile{Pages: r.pages}
+}
+
+// Write writes the report as indented JSON to path.
+func (r *seoReport) Write(path string) error {
+	data, err := json.MarshalIndent(r.Build(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode SEO report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write SEO report %s: %w", path, err)
+	}
+	return nil
+}
+
+// fillSEOPage extracts the on-page SEO signals of doc into page.
+func fillSEOPage(page *seoPage, pageURL *url.URL, doc *goquery.Document, xRobotsTag string) {
+	page.Title = strings.TrimSpace(doc.Find("head title").First().Text())
+	if page.Title == "" {
+		page.Title = strings.TrimSpace(doc.Find("title").First().Text())
+	}
+	page.TitleLength = utf8.RuneCountInString(page.Title)
+
+	if content, ok := doc.Find(`meta[name="description" i]`).First().Attr("content"); ok {
+		page.MetaDescription = strings.TrimSpace(content)
+	}
+	page.MetaDescriptionLength = utf8.RuneCountInString(page.MetaDescription)
+
+	if canonical := canonicalLink(doc, pageURL); canonical != nil {
+		page.Canonical = canonical.String()
+	}
+
+	var directives []string
+	doc.Find(`meta[name="robots" i], meta[name="googlebot" i]`).Each(func(_ int, s *goquery.Selection) {
+		content, _ := s.Attr("content")
+		directives = append(directives, strings.Split(content, ",")...)
+	})
+	directives = append(directives, strings.Split(xRobotsTag, ",")...)
+	page.Robots = uniqueRobotsDirectives(directives)
+
+	page.H1Count = doc.Find("h1").Length()
+
+	seen := make(map[string]bool)
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		link, err := pageURL.Parse(strings.TrimSpace(href))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Hostname() != pageURL.Hostname() {
+			return
+		}
+		normalized, err := normalizeURLtoString(link.String())
+		if err != nil || seen[normalized] {
+			return
+		}
+		seen[normalized] = true
+		page.internalLinks = append(page.internalLinks, normalized)
+	})
+}
+
+// uniqueRobotsDirectives lower-cases and trims directives, dropping empty and repeated ones.
+func uniqueRobotsDirectives(directives []string) []string {
+	var unique []string
+	seen := make(map[string]bool)
+	for _, d := range directives {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == "" || seen[d] {
+			continue
+		}
+		seen[d] = true
+		unique = append(unique, d)
+	}
+	return unique
+}
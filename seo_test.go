@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestSEOReportAddPage(t *testing.T) {
+	pageURL, _ := url.Parse("https://example.com/docs/")
+	html := `<html><head>
+		<title> Getting Started – Docs </title>
+		<meta name="Description" content=" Learn the basics. ">
+		<meta name="robots" content="noindex, FOLLOW">
+		<meta name="googlebot" content="noindex">
+		<link rel="canonical" href="/docs/start">
+	</head><body>
+		<h1>One</h1><h1>Two</h1>
+		<a href="/docs/a">A</a>
+		<a href="/docs/missing">Missing</a>
+		<a href="https://example.com/docs/a#top">A again</a>
+		<a href="/docs/down">Down</a>
+		<a href="https://other.example.org/x">External</a>
+		<a href="mailto:me@example.com">Mail</a>
+	</body></html>`
+
+	report := newSEOReport()
+	report.AddPage(pageURL, 200, map[string]string{"x-robots-tag": "noarchive"}, html)
+	missingURL, _ := url.Parse("https://example.com/docs/missing")
+	report.AddPage(missingURL, 404, nil, "<html><head><title>Not found</title></head></html>")
+	report.RecordFailure("https://example.com/docs/down", 0, errors.New("timeout"))
+
+	pages := report.Build().Pages
+	if len(pages) != 2 {
+		t.Fatalf("Build() returned %d pages, want 2", len(pages))
+	}
+	got := *pages[0]
+	got.internalLinks = nil
+	want := seoPage{
+		URL:                   "https://example.com/docs/",
+		Status:                200,
+		Title:                 "Getting Started – Docs",
+		TitleLength:           22,
+		MetaDescription:       "Learn the basics.",
+		MetaDescriptionLength: 17,
+		Canonical:             "https://example.com/docs/start",
+		Robots:                []string{"noindex", "follow", "noarchive"},
+		H1Count:               2,
+		BrokenInternalLinks: []seoBrokenLink{
+			{URL: "https://example.com/docs/missing", Status: 404},
+			{URL: "https://example.com/docs/down", Error: "timeout"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("page = %+v\nwant %+v", got, want)
+	}
+	if pages[1].Status != 404 || pages[1].H1Count != 0 || pages[1].MetaDescription != "" {
+		t.Errorf("404 page = %+v", *pages[1])
+	}
+}
+
+func TestSEOReportNil(t *testing.T) {
+	var report *seoReport
+	pageURL, _ := url.Parse("https://example.com/")
+	report.AddPage(pageURL, 200, nil, "<html></html>")
+	report.RecordFailure("https://example.com/x", 500, errors.New("boom"))
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+)
+
+// simhashShingleWords is the number of consecutive words hashed together as one feature.
+// Shingles rather than single words make pages with the same vocabulary in a different order
+// distinct, while overlapping excerpts still share most features.
+const simhashShingleWords = 3
+
+// simhash returns the 64-bit SimHash of text over word shingles, and false if text has no words.
+func simhash(text string) (uint64, bool) {
+	words := relevanceTerms(text)
+	if len(words) == 0 {
+		return 0, false
+	}
+	var weights [64]int
+	add := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	if len(words) < simhashShingleWords {
+		add(strings.Join(words, " "))
+	}
+	for i := 0; i+simhashShingleWords <= len(words); i++ {
+		add(strings.Join(words[i:i+simhashShingleWords], " "))
+	}
+	var fingerprint uint64
+	for bit, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint, true
+}
+
+// simhashSimilarity is the share of equal bits in two fingerprints, from 0 to 1.
+func simhashSimilarity(a, b uint64) float64 {
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
+
+// NearDuplicateCluster is a saved page and the pages dropped as its near-duplicates.
+type NearDuplicateCluster struct {
+	URL        string
+	Duplicates []string
+}
+
+// nearDuplicateIndex holds the fingerprints of the saved pages for --dedupe-similarity. A nil
+// index finds no duplicates.
+type nearDuplicateIndex struct {
+	threshold    float64
+	fingerprints []uint64
+	urls         []string
+	// clusters maps the index of a saved page to its cluster, for pages with duplicates.
+	clusters map[int]*NearDuplicateCluster
+	order    []int
+}
+
+func newNearDuplicateIndex(threshold float64) *nearDuplicateIndex {
+	return &nearDuplicateIndex{threshold: threshold, clusters: make(map[int]*NearDuplicateCluster)}
+}
+
+// Check returns the saved page that markdown is a near-duplicate of, with their similarity,
+// and records pageURL in that page's cluster. Otherwise it adds the page to the index and
+// returns "".
+func (x *nearDuplicateIndex) Check(pageURL string, markdown string) (string, float64) {
+	if x == nil {
+		return "", 0
+	}
+	fingerprint, ok := simhash(markdown)
+	if !ok {
+		return "", 0
+	}
+	for i, other := range x.fingerprints {
+		if similarity := simhashSimilarity(fingerprint, other); similarity >= x.threshold {
+			cluster, ok := x.clusters[i]
+			if !ok {
+				cluster = &NearDuplicateCluster{URL: x.urls[i]}
+				x.clusters[i] = cluster
+				x.order = append(x.order, i)
+			}
+			cluster.Duplicates = append(cluster.Duplicates, pageURL)
+			return x.urls[i], similarity
+		}
+	}
+	x.fingerprints = append(x.fingerprints, fingerprint)
+	x.urls = append(x.urls, pageURL)
+	return "", 0
+}
+
+// Clusters returns the clusters in the order their first duplicate was found.
+func (x *nearDuplicateIndex) Clusters() []NearDuplicateCluster {
+	if x == nil {
+		return nil
+	}
+	clusters := make([]NearDuplicateCluster, 0, len(x.order))
+	for _, i := range x.order {
+		clusters = append(clusters, *x.clusters[i])
+	}
+	return clusters
+}
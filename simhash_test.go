@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func simhashTestText(topic string, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "Sentence %d about %s explains how %s works in practice. ", i, topic, topic)
+	}
+	return b.String()
+}
+
+func TestSimhashSimilarity(t *testing.T) {
+	base := simhashTestText("routing", 40)
+	nearCopy := strings.Replace(base, "Sentence 7 about", "Sentence seven regarding", 1) + " Posted in News."
+	other := simhashTestText("billing", 40)
+
+	a, _ := simhash(base)
+	b, _ := simhash(nearCopy)
+	c, _ := simhash(other)
+	if got := simhashSimilarity(a, b); got < 0.9 {
+		t.Errorf("near copy similarity = %.2f, want >= 0.9", got)
+	}
+	if got := simhashSimilarity(a, c); got >= 0.9 {
+		t.Errorf("different page similarity = %.2f, want < 0.9", got)
+	}
+	if _, ok := simhash("  ... "); ok {
+		t.Error("simhash of text without words should report false")
+	}
+}
+
+func TestNearDuplicateIndex(t *testing.T) {
+	x := newNearDuplicateIndex(0.9)
+	base := simhashTestText("routing", 40)
+	if original, _ := x.Check("https://example.com/a", base); original != "" {
+		t.Fatalf("first page reported as duplicate of %s", original)
+	}
+	if original, _ := x.Check("https://example.com/b", simhashTestText("billing", 40)); original != "" {
+		t.Fatalf("different page reported as duplicate of %s", original)
+	}
+	if original, similarity := x.Check("https://example.com/tag/a", base+" Tagged: routing."); original != "https://example.com/a" || similarity < 0.9 {
+		t.Errorf("Check() = %q, %.2f, want a duplicate of /a", original, similarity)
+	}
+	x.Check("https://example.com/category/a", base)
+	if original, _ := x.Check("https://example.com/empty", ""); original != "" {
+		t.Error("empty pages must not be treated as duplicates")
+	}
+
+	clusters := x.Clusters()
+	if len(clusters) != 1 || clusters[0].URL != "https://example.com/a" || len(clusters[0].Duplicates) != 2 {
+		t.Errorf("Clusters() = %+v", clusters)
+	}
+
+	var disabled *nearDuplicateIndex
+	if original, _ := disabled.Check("https://example.com/a", base); original != "" || disabled.Clusters() != nil {
+		t.Error("nil index should find no duplicates")
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// siteBundleFormat is the --output-format that writes a static HTML site into --outfile.
+	siteBundleFormat = "site"
+	// siteSearchTextChars caps the text per page in the client-side search index.
+	siteSearchTextChars = 5000
+)
+
+// siteSearchEntry is one page in the bundle's search index.
+type siteSearchEntry struct {
+	Title   string `json:"t"`
+	Path    string `json:"p"`
+	Section string `json:"s,omitempty"`
+	Text    string `json:"x"`
+}
+
+// siteBundle writes crawl results as a self-contained static site: one HTML page per result,
+// a navigation sidebar, a client-side search index and the images the pages use.
+type siteBundle struct {
+	ctx   context.Context
+	dir   string
+	pages map[string]string // page URL to slash-separated path relative to dir
+	// images maps image URLs to their path relative to dir, "" if the download failed.
+	images map[string]string
+}
+
+// writeSiteBundle writes results as a static HTML site into dir.
+func writeSiteBundle(ctx context.Context, dir string, results []PageData) error {
+	b := &siteBundle{ctx: ctx, dir: dir, pages: make(map[string]string), images: make(map[string]string)}
+	allocator := newPagePathAllocator()
+	for _, pd := range results {
+		b.pages[pd.URL] = strings.TrimSuffix(allocator.Allocate(pd.URL), ".md") + ".html"
+	}
+	toc := buildTOCTree(results)
+
+	var index []siteSearchEntry
+	for i := range results {
+		pd := &results[i]
+		rel := b.pages[pd.URL]
+		content := b.rewriteContent(pd, rel)
+		page := b.renderPage(pd.Title, rel, toc, fmt.Sprintf(`<p class="source"><a href="%s">%s</a></p>`, html.EscapeString(pd.URL), html.EscapeString(pd.URL))+content)
+		if err := b.writeFile(rel, page); err != nil {
+			return err
+		}
+		text := strings.Join(strings.Fields(pd.Markdown), " ")
+		if len(text) > siteSearchTextChars {
+			cut := siteSearchTextChars
+			for cut > 0 && !isRuneStart(text[cut]) {
+				cut--
+			}
+			text = text[:cut]
+		}
+		index = append(index, siteSearchEntry{Title: pd.Title, Path: rel, Section: pd.Section, Text: text})
+	}
+
+	home := b.renderPage("Contents", "index.html", toc, b.renderTOC(toc, "index.html", "contents"))
+	if err := b.writeFile("index.html", home); err != nil {
+		return err
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("failed to encode search index: %w", err)
+	}
+	// A script rather than JSON, so search also works when the bundle is opened from file://.
+	if err := b.writeFile("_site/search-index.js", "var SITEPANDA_SEARCH_INDEX = "+string(indexJSON)+";\n"); err != nil {
+		return err
+	}
+	if err := b.writeFile("_site/style.css", siteBundleCSS); err != nil {
+		return err
+	}
+	return b.writeFile("_site/search.js", siteBundleSearchJS)
+}
+
+func (b *siteBundle) writeFile(rel string, content string) error {
+	full := filepath.Join(b.dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", rel, err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// relativeLink returns the link from the bundle file from to the bundle file to.
+func relativeLink(from string, to string) string {
+	fromDir := path.Dir(from)
+	rel, err := filepath.Rel(filepath.FromSlash(fromDir), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return (&url.URL{Path: filepath.ToSlash(rel)}).String()
+}
+
+// rewriteContent returns the page's article HTML with links to crawled pages pointing into the
+// bundle, other links made absolute, images downloaded into the bundle and scripts removed.
+func (b *siteBundle) rewriteContent(pd *PageData, rel string) string {
+	articleHTML := pd.ArticleHTML
+	if strings.TrimSpace(articleHTML) == "" {
+		return "<pre>" + html.EscapeString(pd.Markdown) + "</pre>"
+	}
+	pageURL, err := url.Parse(pd.URL)
+	if err != nil {
+		return articleHTML
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(articleHTML))
+	if err != nil {
+		return articleHTML
+	}
+	doc.Find("script, noscript").Remove()
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		target, err := pageURL.Parse(href)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+			return
+		}
+		withoutFragment := *target
+		withoutFragment.Fragment = ""
+		if normalized, err := normalizeURLtoString(withoutFragment.String()); err == nil {
+			if targetRel, ok := b.pages[normalized]; ok {
+				link := relativeLink(rel, targetRel)
+				if target.Fragment != "" {
+					link += "#" + target.EscapedFragment()
+				}
+				s.SetAttr("href", link)
+				return
+			}
+		}
+		s.SetAttr("href", target.String())
+	})
+	doc.Find("img[src]").Each(func(_ int, s *goquery.Selection) {
+		src, _ := s.Attr("src")
+		imageURL, err := pageURL.Parse(src)
+		if err != nil || (imageURL.Scheme != "http" && imageURL.Scheme != "https") {
+			return
+		}
+		s.RemoveAttr("srcset")
+		if local := b.image(imageURL.String()); local != "" {
+			s.SetAttr("src", relativeLink(rel, local))
+		} else {
+			s.SetAttr("src", imageURL.String())
+		}
+	})
+	out, err := doc.Find("body").Html()
+	if err != nil {
+		return articleHTML
+	}
+	return out
+}
+
+// image returns the bundle path of imageURL, downloading it on first use.
+func (b *siteBundle) image(imageURL string) string {
+	if rel, ok := b.images[imageURL]; ok {
+		return rel
+	}
+	rel, err := downloadAsset(b.ctx, imageURL, b.dir)
+	if err != nil {
+		logger.Printf("Warning: %v (linking to the original)", err)
+	}
+	b.images[imageURL] = rel
+	return rel
+}
+
+// renderTOC renders the table of contents as nested lists with links relative to the file from.
+func (b *siteBundle) renderTOC(root *tocNode, from string, class string) string {
+	var sb strings.Builder
+	var walk func(n *tocNode)
+	walk = func(n *tocNode) {
+		sb.WriteString("<ul>")
+		for _, c := range n.children {
+			sb.WriteString("<li>")
+			if c.page != nil {
+				current := ""
+				if b.pages[c.page.URL] == from {
+					current = ` aria-current="page"`
+				}
+				fmt.Fprintf(&sb, `<a href="%s"%s>%s</a>`, html.EscapeString(relativeLink(from, b.pages[c.page.URL])), current, html.EscapeString(c.label))
+			} else {
+				fmt.Fprintf(&sb, "<span>%s</span>", html.EscapeString(c.label))
+			}
+			if len(c.children) > 0 {
+				walk(c)
+			}
+			sb.WriteString("</li>")
+		}
+		sb.WriteString("</ul>")
+	}
+	fmt.Fprintf(&sb, `<nav class="%s">`, class)
+	walk(root)
+	sb.WriteString("</nav>")
+	return sb.String()
+}
+
+// renderPage wraps content in the bundle's page layout for the file rel.
+func (b *siteBundle) renderPage(title string, rel string, toc *tocNode, content string) string {
+	root := relativeLink(rel, "index.html")
+	root = strings.TrimSuffix(root, "index.html")
+	asset := func(name string) string { return html.EscapeString(root + "_site/" + name) }
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>%s</title>
+<link rel="stylesheet" href="%s">
+</head>
+<body data-root="%s">
+<aside>
+<p><a href="%sindex.html">Contents</a></p>
+<input type="search" id="search" placeholder="Search" aria-label="Search">
+<ul id="search-results"></ul>
+%s
+</aside>
+<main>
+<h1>%s</h1>
+%s
+</main>
+<script src="%s"></script>
+<script src="%s"></script>
+</body>
+</html>
+`, html.EscapeString(title), asset("style.css"), html.EscapeString(root), html.EscapeString(root), b.renderTOC(toc, rel, "sidebar"), html.EscapeString(title), content, asset("search-index.js"), asset("search.js"))
+}
+
+const siteBundleCSS = `body { margin: 0; display: flex; font-family: system-ui, sans-serif; line-height: 1.5; color: #222; }
+aside { width: 18rem; flex-shrink: 0; height: 100vh; overflow-y: auto; position: sticky; top: 0; padding: 1rem; box-sizing: border-box; border-right: 1px solid #ddd; background: #fafafa; font-size: 0.9rem; }
+aside ul { list-style: none; padding-left: 1rem; margin: 0.2rem 0; }
+aside nav > ul { padding-left: 0; }
+aside a { color: #0550ae; text-decoration: none; }
+aside a[aria-current="page"] { font-weight: bold; }
+#search { width: 100%; box-sizing: border-box; padding: 0.3rem; margin-bottom: 0.5rem; }
+#search-results { padding-left: 0; }
+#search-results li { margin-bottom: 0.4rem; }
+main { padding: 1rem 2rem; max-width: 50rem; overflow-wrap: anywhere; }
+main img { max-width: 100%; height: auto; }
+main pre { overflow-x: auto; background: #f4f4f4; padding: 0.5rem; }
+.source { font-size: 0.8rem; color: #666; }
+@media (max-width: 50rem) { body { display: block; } aside { width: auto; height: auto; position: static; border-right: none; border-bottom: 1px solid #ddd; } }
+`
+
+const siteBundleSearchJS = `(function () {
+  var input = document.getElementById("search");
+  var list = document.getElementById("search-results");
+  var root = document.body.getAttribute("data-root") || "";
+  var index = window.SITEPANDA_SEARCH_INDEX || [];
+  input.addEventListener("input", function () {
+    var terms = input.value.toLowerCase().split(/\s+/).filter(Boolean);
+    list.innerHTML = "";
+    if (terms.length === 0) return;
+    var hits = [];
+    index.forEach(function (page) {
+      var title = (page.t || "").toLowerCase();
+      var text = (page.x || "").toLowerCase();
+      var score = 0;
+      for (var i = 0; i < terms.length; i++) {
+        var inTitle = title.indexOf(terms[i]) >= 0;
+        if (!inTitle && text.indexOf(terms[i]) < 0) return;
+        score += inTitle ? 10 : 1;
+      }
+      hits.push({ page: page, score: score });
+    });
+    hits.sort(function (a, b) { return b.score - a.score; });
+    hits.slice(0, 20).forEach(function (hit) {
+      var li = document.createElement("li");
+      var a = document.createElement("a");
+      a.href = root + hit.page.p;
+      a.textContent = hit.page.t || hit.page.p;
+      li.appendChild(a);
+      if (hit.page.s) {
+        var small = document.createElement("div");
+        small.textContent = hit.page.s;
+        small.style.color = "#666";
+        li.appendChild(small);
+      }
+      list.appendChild(li);
+    });
+    if (hits.length === 0) list.innerHTML = "<li>No results</li>";
+  });
+})();
+`
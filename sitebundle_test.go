@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRelativeLink(t *testing.T) {
+	tests := []struct {
+		from, to, want string
+	}{
+		{"index.html", "example.com/docs/a.html", "example.com/docs/a.html"},
+		{"example.com/docs/a.html", "example.com/docs/b.html", "b.html"},
+		{"example.com/docs/a.html", "example.com/index.html", "../index.html"},
+		{"example.com/docs/a.html", "index.html", "../../index.html"},
+		{"example.com/a.html", "example.com/my page.html", "my%20page.html"},
+	}
+	for _, tt := range tests {
+		if got := relativeLink(tt.from, tt.to); got != tt.want {
+			t.Errorf("relativeLink(%q, %q) = %q, want %q", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSiteBundle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	results := []PageData{
+		{
+			Title:       "Home",
+			URL:         "https://example.com/",
+			Markdown:    "Welcome to the docs",
+			ArticleHTML: `<div><p>See <a href="/docs/install#linux">install</a> and <a href="https://other.org/x">elsewhere</a>.</p><img src="` + server.URL + `/logo.png" srcset="x 2x"><script>alert(1)</script></div>`,
+		},
+		{
+			Title:    "Install",
+			URL:      "https://example.com/docs/install",
+			Section:  "Docs",
+			Markdown: "Run the installer",
+		},
+	}
+	dir := filepath.Join(t.TempDir(), "site")
+	if err := writeSiteBundle(context.Background(), dir, results); err != nil {
+		t.Fatalf("writeSiteBundle() error = %v", err)
+	}
+
+	read := func(rel string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatalf("bundle file %s: %v", rel, err)
+		}
+		return string(data)
+	}
+
+	home := read("example.com/index.html")
+	for _, want := range []string{
+		`href="docs/install.html#linux"`,
+		`href="https://other.org/x"`,
+		`src="../_assets/`,
+		`href="../_site/style.css"`,
+		`<a href="docs/install.html">Install</a>`,
+		`aria-current="page"`,
+	} {
+		if !strings.Contains(home, want) {
+			t.Errorf("home page missing %q:\n%s", want, home)
+		}
+	}
+	for _, unwanted := range []string{"alert(1)", "srcset"} {
+		if strings.Contains(home, unwanted) {
+			t.Errorf("home page should not contain %q", unwanted)
+		}
+	}
+
+	install := read("example.com/docs/install.html")
+	if !strings.Contains(install, "<pre>Run the installer</pre>") {
+		t.Errorf("page without article HTML should fall back to its Markdown:\n%s", install)
+	}
+	if !strings.Contains(read("index.html"), `href="example.com/docs/install.html"`) {
+		t.Error("contents page should link to every page")
+	}
+	if index := read("_site/search-index.js"); !strings.Contains(index, `"p":"example.com/docs/install.html"`) || !strings.Contains(index, "Run the installer") {
+		t.Errorf("search index incomplete: %s", index)
+	}
+	read("_site/search.js")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// statusSet is a --skip-status list of HTTP status codes and classes such as 4xx. A nil
+// *statusSet contains nothing.
+type statusSet struct {
+	// classes[n] holds the nxx class.
+	classes [6]bool
+	codes   map[int]bool
+}
+
+// parseStatusSet parses a comma-separated list like "4xx,5xx" or "404,410,5xx". "" and "none"
+// return nil, so no page is skipped for its status.
+func parseStatusSet(value string) (*statusSet, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "none") {
+		return nil, nil
+	}
+	set := &statusSet{codes: make(map[int]bool)}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if len(item) == 3 && strings.HasSuffix(item, "xx") && item[0] >= '1' && item[0] <= '5' {
+			set.classes[item[0]-'0'] = true
+			continue
+		}
+		code, err := strconv.Atoi(item)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid --skip-status entry %q: want a status code such as 404 or a class such as 4xx", item)
+		}
+		set.codes[code] = true
+	}
+	return set, nil
+}
+
+// contains reports whether status is in the set. Status 0, a page without a main response, never is.
+func (s *statusSet) contains(status int) bool {
+	if s == nil || status < 100 || status > 599 {
+		return false
+	}
+	return s.classes[status/100] || s.codes[status]
+}
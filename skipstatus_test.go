@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseStatusSet(t *testing.T) {
+	tests := []struct {
+		value   string
+		skipped []int
+		kept    []int
+		wantErr bool
+	}{
+		{value: "4xx,5xx", skipped: []int{400, 404, 410, 500, 503}, kept: []int{0, 200, 301, 304}},
+		{value: "404, 410,5XX", skipped: []int{404, 410, 502}, kept: []int{200, 403, 429}},
+		{value: "none", kept: []int{404, 500}},
+		{value: "", kept: []int{404, 500}},
+		{value: "4xx,abc", wantErr: true},
+		{value: "600", wantErr: true},
+		{value: "6xx", wantErr: true},
+	}
+	for _, tt := range tests {
+		set, err := parseStatusSet(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseStatusSet(%q) error = %v, wantErr %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		for _, status := range tt.skipped {
+			if !set.contains(status) {
+				t.Errorf("parseStatusSet(%q) does not contain %d", tt.value, status)
+			}
+		}
+		for _, status := range tt.kept {
+			if set.contains(status) {
+				t.Errorf("parseStatusSet(%q) contains %d", tt.value, status)
+			}
+		}
+	}
+}
+
+func TestPageStatusOutput(t *testing.T) {
+	page := PageData{Title: "Docs", URL: "https://example.com/docs", Markdown: "Body", Status: 200}
+	if out := formatPageDataAsXML(&page); !strings.Contains(out, "<status>200</status>") {
+		t.Errorf("xml-like output lacks the status:\n%s", out)
+	}
+	if out := formatPageDataAsMarkdownFile(&page); !strings.Contains(out, "status: 200\n") {
+		t.Errorf("front matter lacks the status:\n%s", out)
+	}
+	data, err := formatResultsAsJSON([]PageData{page})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"status": 200`) {
+		t.Errorf("JSON output lacks the status:\n%s", data)
+	}
+	filter, err := parseFilter("status == 200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason := filter.rejectReason(&page); reason != "" {
+		t.Errorf("status == 200 rejected the page: %s", reason)
+	}
+}
+
+func TestCrawlRecordsStatus(t *testing.T) {
+	pages := map[string]string{
+		"index.html": `<html><head><title>Home</title></head><body><h1>Home</h1><p>Welcome.</p></body></html>`,
+	}
+	filter, err := parseFilter("status == 200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range []bool{false, true} {
+		_, _, outfile := crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{Filter: filter, RecordStatus: record}})
+		data, err := os.ReadFile(outfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A 200 is written only when asked for, but --filter sees it either way.
+		if got := strings.Contains(string(data), `"status":200`); got != record {
+			t.Errorf("RecordStatus %t: output has status %t:\n%s", record, got, data)
+		}
+	}
+}
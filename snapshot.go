@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hokupod/sitepanda/cmd"
+	_ "modernc.org/sqlite"
+)
+
+// snapshotScheme is the --outfile prefix that selects a snapshot database instead of an output file.
+const snapshotScheme = "sqlite://"
+
+// snapshotSchema creates the version table. A row is one distinct content of a page: fetched_at is
+// when that content was first fetched and last_fetched_at when it was last seen unchanged.
+const snapshotSchema = `
+CREATE TABLE IF NOT EXISTS page_versions (
+	id INTEGER PRIMARY KEY,
+	url TEXT NOT NULL,
+	fetched_at TEXT NOT NULL,
+	last_fetched_at TEXT NOT NULL,
+	content_hash TEXT NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS page_versions_url ON page_versions (url, id);
+`
+
+// pageVersion is one stored version of a page.
+type pageVersion struct {
+	ID            int64
+	URL           string
+	FetchedAt     time.Time
+	LastFetchedAt time.Time
+	ContentHash   string
+	Title         string
+	Content       string
+}
+
+// snapshotDBPath returns the database path of a "sqlite://<path>" --outfile.
+func snapshotDBPath(outfile string) (string, bool) {
+	return strings.CutPrefix(outfile, snapshotScheme)
+}
+
+// snapshotDB keeps the versions of crawled pages in a SQLite database, so repeated crawls build up
+// a history of each page instead of overwriting the previous output.
+type snapshotDB struct {
+	db *sql.DB
+}
+
+// openSnapshotDB opens or creates the snapshot database at path.
+func openSnapshotDB(path string) (*snapshotDB, error) {
+	if path == "" {
+		return nil, errors.New("snapshot database path is empty")
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot database %s: %w", path, err)
+	}
+	// SQLite allows a single writer; one connection also keeps the pragma below in effect.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open snapshot database %s: %w", path, err)
+	}
+	if _, err := db.Exec(snapshotSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize snapshot database %s: %w", path, err)
+	}
+	return &snapshotDB{db: db}, nil
+}
+
+// Close closes the database.
+func (s *snapshotDB) Close() error {
+	return s.db.Close()
+}
+
+// contentHash returns the hex SHA-256 of a page's content.
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// AddVersions stores pages fetched at fetchedAt in one transaction. A page whose content matches its
+// latest version only updates that version's last fetch time. It returns the number of new versions.
+func (s *snapshotDB) AddVersions(ctx context.Context, pages []PageData, fetchedAt time.Time) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	at := fetchedAt.UTC().Format(time.RFC3339Nano)
+	added := 0
+	for _, page := range pages {
+		hash := contentHash(page.Markdown)
+		var latestID int64
+		var latestHash string
+		err := tx.QueryRowContext(ctx, "SELECT id, content_hash FROM page_versions WHERE url = ? ORDER BY id DESC LIMIT 1", page.URL).Scan(&latestID, &latestHash)
+		switch {
+		case err == nil && latestHash == hash:
+			if _, err := tx.ExecContext(ctx, "UPDATE page_versions SET last_fetched_at = ? WHERE id = ?", at, latestID); err != nil {
+				return 0, fmt.Errorf("failed to update snapshot of %s: %w", page.URL, err)
+			}
+			continue
+		case err != nil && !errors.Is(err, sql.ErrNoRows):
+			return 0, fmt.Errorf("failed to read latest snapshot of %s: %w", page.URL, err)
+		}
+		if _, err := tx.ExecContext(ctx, "INSERT INTO page_versions (url, fetched_at, last_fetched_at, content_hash, title, content) VALUES (?, ?, ?, ?, ?, ?)",
+			page.URL, at, at, hash, page.Title, page.Markdown); err != nil {
+			return 0, fmt.Errorf("failed to store snapshot of %s: %w", page.URL, err)
+		}
+		added++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// Versions returns the stored versions of pageURL, oldest first.
+func (s *snapshotDB) Versions(ctx context.Context, pageURL string) ([]pageVersion, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT id, url, fetched_at, last_fetched_at, content_hash, title, content FROM page_versions WHERE url = ? ORDER BY id", pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshots of %s: %w", pageURL, err)
+	}
+	defer rows.Close()
+
+	var versions []pageVersion
+	for rows.Next() {
+		var v pageVersion
+		var fetchedAt, lastFetchedAt string
+		if err := rows.Scan(&v.ID, &v.URL, &fetchedAt, &lastFetchedAt, &v.ContentHash, &v.Title, &v.Content); err != nil {
+			return nil, fmt.Errorf("failed to read snapshots of %s: %w", pageURL, err)
+		}
+		v.FetchedAt, _ = time.Parse(time.RFC3339Nano, fetchedAt)
+		v.LastFetchedAt, _ = time.Parse(time.RFC3339Nano, lastFetchedAt)
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// writeSnapshots adds the crawl's pages to the snapshot database at path.
+func writeSnapshots(path string, pages []PageData, fetchedAt time.Time) error {
+	db, err := openSnapshotDB(path)
+	if err != nil {
+		return err
+	}
+	added, err := db.AddVersions(context.Background(), pages, fetchedAt)
+	if err != nil {
+		db.Close()
+		return err
+	}
+	logger.Printf("Stored %d new page versions (%d pages unchanged) in %s", added, len(pages)-added, path)
+	return db.Close()
+}
+
+// writeVersionTimeline prints the versions of a page as a table, oldest first.
+func writeVersionTimeline(w io.Writer, versions []pageVersion) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tFIRST FETCHED\tLAST FETCHED\tHASH\tSIZE\tTITLE")
+	for i, v := range versions {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n", i+1,
+			v.FetchedAt.Local().Format("2006-01-02 15:04"), v.LastFetchedAt.Local().Format("2006-01-02 15:04"),
+			v.ContentHash[:min(12, len(v.ContentHash))], len(v.Content), v.Title)
+	}
+	return tw.Flush()
+}
+
+// HandleHistoryShow handles `sitepanda history show`: it lists the stored versions of a page, or
+// prints one of them with --version.
+func HandleHistoryShow(pageURL string) {
+	path := cmd.GetSnapshotDB()
+	if _, err := os.Stat(path); err != nil {
+		logger.Fatalf("Error: Snapshot database %s: %v", path, err)
+	}
+	db, err := openSnapshotDB(path)
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	defer db.Close()
+
+	versions, err := db.Versions(context.Background(), pageURL)
+	if err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+	if len(versions) == 0 {
+		fmt.Printf("No versions of %s stored in %s.\n", pageURL, path)
+		return
+	}
+
+	if n := cmd.GetSnapshotVersion(); n != 0 {
+		if n < 0 {
+			n += len(versions) + 1
+		}
+		if n < 1 || n > len(versions) {
+			logger.Fatalf("Error: --version must be between 1 and %d (or -1 for the latest), got %d.", len(versions), cmd.GetSnapshotVersion())
+		}
+		fmt.Println(versions[n-1].Content)
+		return
+	}
+	if err := writeVersionTimeline(os.Stdout, versions); err != nil {
+		logger.Fatalf("Error: %v", err)
+	}
+}
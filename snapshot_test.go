@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotDBVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pages.db")
+	t1 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := [][]PageData{
+		{{URL: "https://example.com/a", Title: "A", Markdown: "one"}, {URL: "https://example.com/b", Title: "B", Markdown: "b"}},
+		{{URL: "https://example.com/a", Title: "A", Markdown: "one"}, {URL: "https://example.com/b", Title: "B", Markdown: "b2"}},
+		{{URL: "https://example.com/a", Title: "A v2", Markdown: "two"}},
+	}
+	wantAdded := []int{2, 1, 1}
+	for i, pages := range runs {
+		if err := writeSnapshots(path, pages, t1.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("writeSnapshots(run %d) error = %v", i, err)
+		}
+		db, err := openSnapshotDB(path)
+		if err != nil {
+			t.Fatalf("openSnapshotDB() error = %v", err)
+		}
+		var count int
+		if err := db.db.QueryRow("SELECT COUNT(*) FROM page_versions").Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		db.Close()
+		want := 0
+		for _, n := range wantAdded[:i+1] {
+			want += n
+		}
+		if count != want {
+			t.Errorf("after run %d: %d versions stored, want %d", i, count, want)
+		}
+	}
+
+	db, err := openSnapshotDB(path)
+	if err != nil {
+		t.Fatalf("openSnapshotDB() error = %v", err)
+	}
+	defer db.Close()
+	versions, err := db.Versions(context.Background(), "https://example.com/a")
+	if err != nil {
+		t.Fatalf("Versions() error = %v", err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Versions() = %d versions, want 2", len(versions))
+	}
+	first, second := versions[0], versions[1]
+	if first.Content != "one" || !first.FetchedAt.Equal(t1) || !first.LastFetchedAt.Equal(t1.Add(time.Hour)) {
+		t.Errorf("first version = %+v, want content %q fetched at %v and last fetched an hour later", first, "one", t1)
+	}
+	if second.Content != "two" || second.Title != "A v2" || second.ContentHash != contentHash("two") || !second.FetchedAt.Equal(t1.Add(2*time.Hour)) {
+		t.Errorf("second version = %+v", second)
+	}
+
+	if versions, err := db.Versions(context.Background(), "https://example.com/missing"); err != nil || len(versions) != 0 {
+		t.Errorf("Versions(missing) = %v, %v; want none", versions, err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeVersionTimeline(&buf, versions); err != nil {
+		t.Fatalf("writeVersionTimeline() error = %v", err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "FIRST FETCHED") || !strings.Contains(out, contentHash("two")[:12]) || !strings.Contains(out, "A v2") {
+		t.Errorf("writeVersionTimeline() =\n%s", out)
+	}
+}
+
+func TestSnapshotDBPath(t *testing.T) {
+	if path, ok := snapshotDBPath("sqlite://out/pages.db"); !ok || path != "out/pages.db" {
+		t.Errorf(`snapshotDBPath("sqlite://out/pages.db") = %q, %v`, path, ok)
+	}
+	if _, ok := snapshotDBPath("out/pages.json"); ok {
+		t.Error(`snapshotDBPath("out/pages.json") reported a database`)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// outputSplit is one named share of the pages in a --split specification.
+type outputSplit struct {
+	Name  string
+	Ratio float64
+}
+
+// splitNamePattern accepts the split names that Hugging Face datasets accepts.
+var splitNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// parseSplitSpec parses a --split value such as "train=0.9,val=0.1". Ratios must be positive
+// and add up to 1.
+func parseSplitSpec(spec string) ([]outputSplit, error) {
+	var splits []outputSplit
+	seen := make(map[string]bool)
+	var total float64
+	for _, part := range strings.Split(spec, ",") {
+		name, ratioText, ok := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --split entry %q: expected name=ratio", part)
+		}
+		if !splitNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid --split name %q: use letters, digits and underscores", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate --split name %q", name)
+		}
+		seen[name] = true
+		ratio, err := strconv.ParseFloat(strings.TrimSpace(ratioText), 64)
+		if err != nil || ratio <= 0 || ratio > 1 {
+			return nil, fmt.Errorf("invalid --split ratio %q for %s: expected a number in (0, 1]", ratioText, name)
+		}
+		total += ratio
+		splits = append(splits, outputSplit{Name: name, Ratio: ratio})
+	}
+	if math.Abs(total-1) > 1e-6 {
+		return nil, fmt.Errorf("--split ratios add up to %g, not 1", total)
+	}
+	return splits, nil
+}
+
+// assignSplits distributes results over splits, keeping crawl order within each split. A page's
+// split depends only on seed and its URL, so it stays in the same split when the corpus is
+// recrawled or grows, and pages do not leak between training and evaluation sets.
+func assignSplits(results []PageData, splits []outputSplit, seed int64) [][]PageData {
+	assigned := make([][]PageData, len(splits))
+	for _, pd := range results {
+		i := splitIndex(pd.URL, splits, seed)
+		assigned[i] = append(assigned[i], pd)
+	}
+	return assigned
+}
+
+// splitIndex returns the split of pageURL: a hash of seed and URL mapped to [0, 1) picks the
+// split whose cumulative ratio range contains it.
+func splitIndex(pageURL string, splits []outputSplit, seed int64) int {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(seed, 10) + "\n" + pageURL))
+	point := float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53)
+	var cumulative float64
+	for i, s := range splits {
+		cumulative += s.Ratio
+		if point < cumulative {
+			return i
+		}
+	}
+	return len(splits) - 1
+}
+
+// splitOutfilePath returns the output file of split name: its name inserted before the
+// extension of outfile, e.g. corpus.jsonl becomes corpus.train.jsonl.
+func splitOutfilePath(outfile string, name string) string {
+	ext := filepath.Ext(outfile)
+	if ext == filepath.Base(outfile) {
+		ext = ""
+	}
+	return strings.TrimSuffix(outfile, ext) + "." + name + ext
+}
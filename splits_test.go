@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseSplitSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []outputSplit
+		wantErr bool
+	}{
+		{spec: "train=0.9,val=0.1", want: []outputSplit{{"train", 0.9}, {"val", 0.1}}},
+		{spec: " train = 0.8 , validation=0.1,test=0.1", want: []outputSplit{{"train", 0.8}, {"validation", 0.1}, {"test", 0.1}}},
+		{spec: "all=1", want: []outputSplit{{"all", 1}}},
+		{spec: "train=0.9", wantErr: true},
+		{spec: "train=0.9,val=0.2", wantErr: true},
+		{spec: "train=0.5,train=0.5", wantErr: true},
+		{spec: "train", wantErr: true},
+		{spec: "train=abc,val=0.5", wantErr: true},
+		{spec: "train=-0.5,val=1.5", wantErr: true},
+		{spec: "my-split=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSplitSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSplitSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseSplitSpec(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestAssignSplits(t *testing.T) {
+	splits := []outputSplit{{"train", 0.8}, {"val", 0.2}}
+	var results []PageData
+	for i := 0; i < 2000; i++ {
+		results = append(results, PageData{URL: fmt.Sprintf("https://example.com/page/%d", i)})
+	}
+
+	assigned := assignSplits(results, splits, 42)
+	if total := len(assigned[0]) + len(assigned[1]); total != len(results) {
+		t.Fatalf("assigned %d pages, want %d", total, len(results))
+	}
+	if share := float64(len(assigned[0])) / float64(len(results)); math.Abs(share-0.8) > 0.05 {
+		t.Errorf("train share = %.3f, want about 0.8", share)
+	}
+
+	// A page keeps its split when the crawl changes around it.
+	again := assignSplits(results[:500], splits, 42)
+	inTrain := make(map[string]bool)
+	for _, pd := range assigned[0] {
+		inTrain[pd.URL] = true
+	}
+	for _, pd := range again[0] {
+		if !inTrain[pd.URL] {
+			t.Errorf("%s moved to train in a smaller crawl", pd.URL)
+		}
+	}
+
+	// Another seed gives another assignment.
+	other := assignSplits(results, splits, 7)
+	if reflect.DeepEqual(other[1], assigned[1]) {
+		t.Error("different seeds produced the same validation split")
+	}
+}
+
+func TestSplitOutfilePath(t *testing.T) {
+	tests := []struct{ outfile, want string }{
+		{"corpus.jsonl", "corpus.train.jsonl"},
+		{"out/data.tar.json", "out/data.tar.train.json"},
+		{"corpus", "corpus.train"},
+		{".corpus", ".corpus.train"},
+	}
+	for _, tt := range tests {
+		if got := splitOutfilePath(tt.outfile, "train"); got != tt.want {
+			t.Errorf("splitOutfilePath(%q) = %q, want %q", tt.outfile, got, tt.want)
+		}
+	}
+}
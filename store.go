@@ -0,0 +1,391 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Store is a destination for the encoded output of a crawl, selected by the scheme of an
+// --outfile value.
+type Store interface {
+	// Location is the --outfile value the store was opened from.
+	Location() string
+	Put(ctx context.Context, data []byte, contentType string) error
+}
+
+// pageStore is a Store that keeps the crawled pages themselves instead of the encoded output.
+type pageStore interface {
+	Store
+	PutPages(ctx context.Context, pages []PageData) error
+}
+
+// storeOpener opens the Store for an --outfile value with a registered scheme.
+type storeOpener func(location string) (Store, error)
+
+// storeSchemes maps --outfile URL schemes to their stores. Values without a scheme are local
+// files and "-" is stdout; a new backend only needs an entry here.
+var storeSchemes = map[string]storeOpener{
+	"file":   openFileStore,
+	"http":   openWebhookStore,
+	"https":  openWebhookStore,
+	"s3":     openS3Store,
+	"sqlite": openSQLiteStore,
+}
+
+// storeHTTPClient uploads output to remote stores; uploads can be much larger than fetched pages.
+var storeHTTPClient = &http.Client{Timeout: 10 * time.Minute}
+
+// StoreResult is the outcome of writing the output to one store.
+type StoreResult struct {
+	Location string
+	Err      error
+}
+
+// openStore returns the Store for an --outfile value.
+func openStore(location string) (Store, error) {
+	if location == "-" {
+		return stdoutStore{}, nil
+	}
+	scheme, _, ok := strings.Cut(location, "://")
+	if !ok {
+		return fileStore{path: location}, nil
+	}
+	open, ok := storeSchemes[strings.ToLower(scheme)]
+	if !ok {
+		schemes := make([]string, 0, len(storeSchemes))
+		for s := range storeSchemes {
+			schemes = append(schemes, s)
+		}
+		sort.Strings(schemes)
+		return nil, fmt.Errorf("unsupported scheme %q (supported: %s, a file path or -)", scheme, strings.Join(schemes, ", "))
+	}
+	return open(location)
+}
+
+// localStorePath returns the file path an --outfile value writes to, and false if it is not a
+// local file.
+func localStorePath(location string) (string, bool) {
+	if location == "-" {
+		return "", false
+	}
+	scheme, _, ok := strings.Cut(location, "://")
+	if !ok {
+		return location, true
+	}
+	if !strings.EqualFold(scheme, "file") {
+		return "", false
+	}
+	u, err := url.Parse(location)
+	if err != nil || u.Path == "" {
+		return "", false
+	}
+	return u.Path, true
+}
+
+// writeStores puts data, or pages for a pageStore, into every store. It logs and returns each
+// store's outcome, and the failures joined into one error.
+func writeStores(ctx context.Context, stores []Store, pages []PageData, data []byte, contentType string) ([]StoreResult, error) {
+	results := make([]StoreResult, 0, len(stores))
+	var errs []error
+	for _, store := range stores {
+		var err error
+		if ps, ok := store.(pageStore); ok {
+			err = ps.PutPages(ctx, pages)
+		} else {
+			err = store.Put(ctx, data, contentType)
+		}
+		if err != nil {
+			logger.Printf("Error writing output to %s: %v", store.Location(), err)
+			if len(stores) > 1 {
+				err = fmt.Errorf("%s: %w", store.Location(), err)
+			}
+			errs = append(errs, err)
+		}
+		results = append(results, StoreResult{Location: store.Location(), Err: err})
+	}
+	return results, errors.Join(errs...)
+}
+
+// outputContentType is the media type of the single-file output in format.
+func outputContentType(format string, wrapTemplate string) string {
+	if wrapTemplate != "" {
+		return "text/plain; charset=utf-8"
+	}
+	switch format {
+	case "json":
+		return "application/json"
+	case "jsonl":
+		return "application/x-ndjson"
+	case parquetFormat:
+		return "application/vnd.apache.parquet"
+	case "org":
+		return "text/org; charset=utf-8"
+	case "asciidoc":
+		return "text/asciidoc; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// fileStore writes the output to a local file.
+type fileStore struct {
+	path string
+}
+
+func openFileStore(location string) (Store, error) {
+	path, ok := localStorePath(location)
+	if !ok {
+		return nil, fmt.Errorf("invalid file URL %q", location)
+	}
+	return fileStore{path: path}, nil
+}
+
+func (s fileStore) Location() string { return s.path }
+
+func (s fileStore) Put(_ context.Context, data []byte, _ string) error {
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// stdoutStore prints the output, as Sitepanda does without --outfile.
+type stdoutStore struct{}
+
+func (stdoutStore) Location() string { return "-" }
+
+func (stdoutStore) Put(_ context.Context, data []byte, _ string) error {
+	_, err := fmt.Println(string(data))
+	return err
+}
+
+// sqliteStore keeps versions of the crawled pages in a snapshot database (see snapshotDB).
+type sqliteStore struct {
+	location string
+	path     string
+}
+
+func openSQLiteStore(location string) (Store, error) {
+	path, ok := snapshotDBPath(location)
+	if !ok || path == "" {
+		return nil, fmt.Errorf("SQLite location must be sqlite://<path>, got %q", location)
+	}
+	return sqliteStore{location: location, path: path}, nil
+}
+
+func (s sqliteStore) Location() string { return s.location }
+
+func (s sqliteStore) Put(context.Context, []byte, string) error {
+	return errors.New("a SQLite store keeps pages, not encoded output")
+}
+
+func (s sqliteStore) PutPages(_ context.Context, pages []PageData) error {
+	return writeSnapshots(s.path, pages, time.Now())
+}
+
+// webhookStore POSTs the output to an HTTP(S) endpoint.
+type webhookStore struct {
+	url string
+}
+
+func openWebhookStore(location string) (Store, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q", location)
+	}
+	return webhookStore{url: location}, nil
+}
+
+func (s webhookStore) Location() string { return s.url }
+
+func (s webhookStore) Put(ctx context.Context, data []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := storeHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+// awsCredentials are the keys S3 requests are signed with.
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// s3Store uploads the output as an S3 object with a single PUT. Credentials and region come
+// from the standard AWS environment variables; AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL selects
+// an S3-compatible service, addressed path-style.
+type s3Store struct {
+	location  string
+	objectURL *url.URL
+	region    string
+	creds     awsCredentials
+}
+
+func openS3Store(location string) (Store, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+	if bucket == "" || key == "" {
+		return nil, fmt.Errorf("S3 location must be s3://<bucket>/<key>, got %q", location)
+	}
+	creds := awsCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("S3 output requires AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	var objectURL *url.URL
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_S3")
+	if endpoint == "" {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if endpoint != "" {
+		if objectURL, err = url.Parse(strings.TrimSuffix(endpoint, "/")); err != nil || objectURL.Host == "" {
+			return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+		}
+		objectURL.RawPath = objectURL.EscapedPath() + "/" + awsURIEncode(bucket, false) + "/" + awsURIEncode(key, false)
+		objectURL.Path += "/" + bucket + "/" + key
+	} else {
+		objectURL = &url.URL{Scheme: "https", Host: bucket + ".s3." + region + ".amazonaws.com", Path: "/" + key, RawPath: "/" + awsURIEncode(key, false)}
+	}
+	return &s3Store{location: location, objectURL: objectURL, region: region, creds: creds}, nil
+}
+
+func (s *s3Store) Location() string { return s.location }
+
+func (s *s3Store) Put(ctx context.Context, data []byte, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL.String(), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	payloadHash := sha256.Sum256(data)
+	signAWSRequest(req, hex.EncodeToString(payloadHash[:]), s.creds, s.region, "s3", time.Now())
+	resp, err := storeHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("S3 request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("S3 returned status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// signAWSRequest adds AWS Signature Version 4 headers to req, signing all of its headers.
+func signAWSRequest(req *http.Request, payloadHash string, creds awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	query := req.URL.Query()
+	queryKeys := make([]string, 0, len(query))
+	for k := range query {
+		queryKeys = append(queryKeys, k)
+	}
+	sort.Strings(queryKeys)
+	var queryParts []string
+	for _, k := range queryKeys {
+		for _, v := range query[k] {
+			queryParts = append(queryParts, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		strings.Join(queryParts, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + creds.SecretAccessKey)
+	for _, part := range []string{amzDate[:8], region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode percent-encodes s as AWS signatures require: everything but unreserved
+// characters, and "/" too if encodeSlash is set.
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
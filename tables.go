@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Table modes for --tables.
+const (
+	tablesModeKeep = "keep"
+	tablesModeCSV  = "csv"
+	tablesModeHTML = "html"
+)
+
+// Table is a data table taken out of a page before readability so it survives extraction.
+type Table struct {
+	HTML string
+	Rows [][]string
+}
+
+var (
+	tablePlaceholderPattern          = regexp.MustCompile(`XSITEPANDATABLE(\d+)X`)
+	tablePlaceholderParagraphPattern = regexp.MustCompile(`<p>\s*XSITEPANDATABLE(\d+)X\s*</p>`)
+)
+
+func tablePlaceholder(i int) string { return fmt.Sprintf("XSITEPANDATABLE%dX", i) }
+
+// validateTablesMode checks a --tables value. The empty string leaves tables to readability.
+func validateTablesMode(mode string) error {
+	switch mode {
+	case "", tablesModeKeep, tablesModeCSV, tablesModeHTML:
+		return nil
+	}
+	return fmt.Errorf("invalid tables mode %q (expected %s, %s or %s)", mode, tablesModeKeep, tablesModeCSV, tablesModeHTML)
+}
+
+// protectTables replaces each data table in rawHTML with a placeholder paragraph and returns the
+// modified HTML and the tables in placeholder order. Layout tables (role="presentation" or
+// containing other tables) are left alone. If no tables are found, rawHTML is returned unchanged.
+func protectTables(rawHTML string) (string, []Table) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(rawHTML))
+	if err != nil {
+		return rawHTML, nil
+	}
+	var tables []Table
+	doc.Find("table").Each(func(_ int, s *goquery.Selection) {
+		if s.ParentsFiltered("table").Length() > 0 || s.Find("table").Length() > 0 {
+			return
+		}
+		if role, _ := s.Attr("role"); role == "presentation" || role == "none" {
+			return
+		}
+		rows := tableRows(s)
+		if len(rows) == 0 {
+			return
+		}
+		outer, err := goquery.OuterHtml(s)
+		if err != nil {
+			return
+		}
+		s.ReplaceWithHtml("<p>" + tablePlaceholder(len(tables)) + "</p>")
+		tables = append(tables, Table{HTML: outer, Rows: rows})
+	})
+	if len(tables) == 0 {
+		return rawHTML, nil
+	}
+	modified, err := goquery.OuterHtml(doc.Selection)
+	if err != nil {
+		return rawHTML, nil
+	}
+	return modified, tables
+}
+
+// tableRows returns the cell texts of a table, one slice per row.
+func tableRows(table *goquery.Selection) [][]string {
+	var rows [][]string
+	table.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		var row []string
+		tr.ChildrenFiltered("th, td").Each(func(_ int, cell *goquery.Selection) {
+			row = append(row, collapseWhitespace(cell.Text()))
+		})
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	})
+	return rows
+}
+
+// restoreTables puts the protected tables back into the processed page: as Markdown pipe tables
+// (keep and csv modes) or raw HTML blocks (html mode). ArticleHTML always gets the original HTML.
+func restoreTables(pd *PageData, tables []Table, mode string) {
+	if len(tables) == 0 {
+		return
+	}
+	tableAt := func(match string, pattern *regexp.Regexp) (Table, bool) {
+		i, err := strconv.Atoi(pattern.FindStringSubmatch(match)[1])
+		if err != nil || i >= len(tables) {
+			return Table{}, false
+		}
+		return tables[i], true
+	}
+
+	pd.Markdown = tablePlaceholderPattern.ReplaceAllStringFunc(pd.Markdown, func(match string) string {
+		t, ok := tableAt(match, tablePlaceholderPattern)
+		if !ok {
+			return ""
+		}
+		if mode == tablesModeHTML {
+			return t.HTML
+		}
+		return formatTableAsMarkdown(t.Rows)
+	})
+	restoreHTML := func(pattern *regexp.Regexp) func(string) string {
+		return func(match string) string {
+			if t, ok := tableAt(match, pattern); ok {
+				return t.HTML
+			}
+			return ""
+		}
+	}
+	pd.ArticleHTML = tablePlaceholderParagraphPattern.ReplaceAllStringFunc(pd.ArticleHTML, restoreHTML(tablePlaceholderParagraphPattern))
+	pd.ArticleHTML = tablePlaceholderPattern.ReplaceAllStringFunc(pd.ArticleHTML, restoreHTML(tablePlaceholderPattern))
+	if mode == tablesModeCSV {
+		pd.Tables = tables
+	}
+}
+
+// formatTableAsMarkdown renders rows as a GitHub Flavored Markdown pipe table with the first row
+// as the header. Short rows are padded to the widest row.
+func formatTableAsMarkdown(rows [][]string) string {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	escape := strings.NewReplacer("|", `\|`, "\n", " ")
+	writeRow := func(b *strings.Builder, row []string) {
+		b.WriteString("|")
+		for i := 0; i < width; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = escape.Replace(row[i])
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	var b strings.Builder
+	writeRow(&b, rows[0])
+	b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(&b, row)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// writeTableCSVFiles writes each table of a page as <page>.table-N.csv next to the page file.
+func writeTableCSVFiles(pageFile string, tables []Table) error {
+	base := strings.TrimSuffix(pageFile, filepath.Ext(pageFile))
+	for i, t := range tables {
+		path := fmt.Sprintf("%s.table-%d.csv", base, i+1)
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create table file %s: %w", path, err)
+		}
+		w := csv.NewWriter(f)
+		if err := w.WriteAll(t.Rows); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write table file %s: %w", path, err)
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write table file %s: %w", path, err)
+		}
+	}
+	return nil
+}
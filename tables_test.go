@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProtectTables(t *testing.T) {
+	html := `<html><body><article><p>Intro</p>
+<table><thead><tr><th>Name</th><th>Price</th></tr></thead>
+<tbody><tr><td>Apple</td><td>$1</td></tr><tr><td>Pear | Big</td><td>$2</td></tr></tbody></table>
+<table role="presentation"><tr><td>Layout</td></tr></table>
+<table><tr><td><table><tr><td>Nested</td></tr></table></td></tr></table>
+</article></body></html>`
+
+	modified, tables := protectTables(html)
+	if len(tables) != 1 {
+		t.Fatalf("protectTables() found %d tables, want 1", len(tables))
+	}
+	wantRows := [][]string{{"Name", "Price"}, {"Apple", "$1"}, {"Pear | Big", "$2"}}
+	if !reflect.DeepEqual(tables[0].Rows, wantRows) {
+		t.Errorf("protectTables() rows = %v, want %v", tables[0].Rows, wantRows)
+	}
+	if !strings.Contains(modified, "<p>"+tablePlaceholder(0)+"</p>") {
+		t.Errorf("protectTables() did not insert a placeholder: %s", modified)
+	}
+	if strings.Contains(modified, "Apple") {
+		t.Errorf("protectTables() left the data table in the HTML")
+	}
+	if !strings.Contains(modified, "Layout") || !strings.Contains(modified, "Nested") {
+		t.Errorf("protectTables() removed a layout table")
+	}
+
+	unchanged, none := protectTables("<p>No tables</p>")
+	if none != nil || unchanged != "<p>No tables</p>" {
+		t.Errorf("protectTables() changed HTML without tables")
+	}
+}
+
+func TestRestoreTables(t *testing.T) {
+	tables := []Table{{HTML: "<table><tr><td>a</td></tr></table>", Rows: [][]string{{"Name", "Price"}, {"Pear | Big"}}}}
+
+	tests := []struct {
+		mode         string
+		wantMarkdown string
+		wantTables   bool
+	}{
+		{mode: tablesModeKeep, wantMarkdown: "Intro\n\n| Name | Price |\n| --- | --- |\n| Pear \\| Big |  |\n\nEnd"},
+		{mode: tablesModeCSV, wantMarkdown: "Intro\n\n| Name | Price |\n| --- | --- |\n| Pear \\| Big |  |\n\nEnd", wantTables: true},
+		{mode: tablesModeHTML, wantMarkdown: "Intro\n\n<table><tr><td>a</td></tr></table>\n\nEnd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			pd := &PageData{
+				Markdown:    "Intro\n\n" + tablePlaceholder(0) + "\n\nEnd",
+				ArticleHTML: "<div><p>Intro</p><p>" + tablePlaceholder(0) + "</p></div>",
+			}
+			restoreTables(pd, tables, tt.mode)
+			if pd.Markdown != tt.wantMarkdown {
+				t.Errorf("Markdown = %q, want %q", pd.Markdown, tt.wantMarkdown)
+			}
+			if want := "<div><p>Intro</p>" + tables[0].HTML + "</div>"; pd.ArticleHTML != want {
+				t.Errorf("ArticleHTML = %q, want %q", pd.ArticleHTML, want)
+			}
+			if (pd.Tables != nil) != tt.wantTables {
+				t.Errorf("Tables set = %v, want %v", pd.Tables != nil, tt.wantTables)
+			}
+		})
+	}
+}
+
+func TestWriteTableCSVFiles(t *testing.T) {
+	dir := t.TempDir()
+	pageFile := filepath.Join(dir, "pricing.md")
+	tables := []Table{
+		{Rows: [][]string{{"Name", "Price"}, {"Apple, red", "$1"}}},
+		{Rows: [][]string{{"Only"}}},
+	}
+	if err := writeTableCSVFiles(pageFile, tables); err != nil {
+		t.Fatalf("writeTableCSVFiles() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "pricing.table-1.csv"))
+	if err != nil {
+		t.Fatalf("failed to read first table: %v", err)
+	}
+	if want := "Name,Price\n\"Apple, red\",$1\n"; string(got) != want {
+		t.Errorf("pricing.table-1.csv = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pricing.table-2.csv")); err != nil {
+		t.Errorf("second table file missing: %v", err)
+	}
+}
+
+func TestValidateTablesMode(t *testing.T) {
+	for _, mode := range []string{"", "keep", "csv", "html"} {
+		if err := validateTablesMode(mode); err != nil {
+			t.Errorf("validateTablesMode(%q) error = %v", mode, err)
+		}
+	}
+	if err := validateTablesMode("markdown"); err == nil {
+		t.Errorf("validateTablesMode(\"markdown\") expected error")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// maxTagLength drops "tags" that are really sentences, e.g. from stuffed keyword meta tags.
+const maxTagLength = 50
+
+// tagPathSegments mark links that point at a tag or category archive.
+var tagPathSegments = map[string]bool{"tag": true, "tags": true, "category": true, "categories": true}
+
+// extractTagsFromDocument collects taxonomy markers: meta keywords, article:tag, rel="tag" links and
+// links to tag/category archives on the same host. Links are taken from the <article> element when
+// the page has one, so site-wide tag clouds are ignored. Tags are deduplicated case-insensitively.
+func extractTagsFromDocument(pageURL *url.URL, doc *goquery.Document) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		tag = strings.TrimPrefix(collapseWhitespace(tag), "#")
+		key := strings.ToLower(tag)
+		if tag == "" || len(tag) > maxTagLength || seen[key] {
+			return
+		}
+		seen[key] = true
+		tags = append(tags, tag)
+	}
+
+	doc.Find(`meta[name="keywords" i]`).Each(func(_ int, s *goquery.Selection) {
+		content, _ := s.Attr("content")
+		for _, kw := range strings.Split(content, ",") {
+			add(kw)
+		}
+	})
+	doc.Find(`meta[property="article:tag"]`).Each(func(_ int, s *goquery.Selection) {
+		content, _ := s.Attr("content")
+		add(content)
+	})
+
+	scope := doc.Find("article").First()
+	if scope.Length() == 0 {
+		scope = doc.Selection
+	}
+	scope.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		if rel, _ := s.Attr("rel"); hasToken(rel, "tag") {
+			add(s.Text())
+			return
+		}
+		href, _ := s.Attr("href")
+		if isTagArchiveLink(pageURL, href) {
+			add(s.Text())
+		}
+	})
+	return tags
+}
+
+// isTagArchiveLink reports whether href points at a tag or category archive on the page's host.
+func isTagArchiveLink(pageURL *url.URL, href string) bool {
+	if pageURL == nil {
+		return false
+	}
+	link, err := pageURL.Parse(href)
+	if err != nil || link.Host != pageURL.Host {
+		return false
+	}
+	segments := strings.Split(strings.Trim(link.Path, "/"), "/")
+	for i, seg := range segments[:len(segments)-1] {
+		if tagPathSegments[strings.ToLower(seg)] && segments[i+1] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasToken(list, token string) bool {
+	for _, f := range strings.Fields(list) {
+		if strings.EqualFold(f, token) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestExtractTagsFromDocument(t *testing.T) {
+	pageURL, _ := url.Parse("https://blog.example.com/2024/05/go-generics")
+
+	tests := []struct {
+		name string
+		html string
+		want []string
+	}{
+		{
+			name: "meta keywords and article:tag",
+			html: `<head><meta name="Keywords" content="Go, generics , ,Type Parameters">
+<meta property="article:tag" content="golang"><meta property="article:tag" content="go"></head>`,
+			want: []string{"Go", "generics", "Type Parameters", "golang"},
+		},
+		{
+			name: "tag links inside article only",
+			html: `<body><aside><a href="/tag/everything">everything</a></aside>
+<article><p>Post</p><footer>
+<a rel="tag" href="https://other.example.com/t/1">#Release Notes</a>
+<a href="/tags/compilers/">Compilers</a>
+<a href="/category/engineering">Engineering</a>
+<a href="/tag/">All tags</a>
+<a href="https://elsewhere.example.com/tag/offsite">offsite</a>
+<a href="/2024/05/">May</a>
+</footer></article></body>`,
+			want: []string{"Release Notes", "Compilers", "Engineering"},
+		},
+		{
+			name: "whole page without article",
+			html: `<body><div class="tags"><a href="/categories/news">News</a></div></body>`,
+			want: []string{"News"},
+		},
+		{
+			name: "overlong keyword is dropped",
+			html: `<meta name="keywords" content="` + strings.Repeat("word ", 20) + `, short">`,
+			want: []string{"short"},
+		},
+		{
+			name: "no tags",
+			html: `<p>Nothing here</p>`,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(tt.html))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := extractTagsFromDocument(pageURL, doc)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractTagsFromDocument() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
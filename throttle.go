@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// requestThrottle paces the crawl's fetches (--delay, --delay-jitter, --max-rps). A nil
+// *requestThrottle adds no waiting.
+type requestThrottle struct {
+	delay  time.Duration
+	jitter time.Duration
+	// interval is the minimum time between fetch starts, 1/--max-rps.
+	interval time.Duration
+	// next is when the last reserved fetch starts; zero before the first.
+	next time.Time
+	// randN returns a random duration in [0, n); rand.N unless a test replaces it.
+	randN func(n time.Duration) time.Duration
+}
+
+// newRequestThrottle returns the throttle for the given options, or nil if they are all zero.
+func newRequestThrottle(delay time.Duration, jitter time.Duration, maxRPS float64) (*requestThrottle, error) {
+	switch {
+	case delay < 0:
+		return nil, fmt.Errorf("--delay must not be negative, got %s", delay)
+	case jitter < 0:
+		return nil, fmt.Errorf("--delay-jitter must not be negative, got %s", jitter)
+	case maxRPS < 0:
+		return nil, fmt.Errorf("--max-rps must not be negative, got %g", maxRPS)
+	case delay == 0 && jitter == 0 && maxRPS == 0:
+		return nil, nil
+	}
+	t := &requestThrottle{delay: delay, jitter: jitter, randN: rand.N[time.Duration]}
+	if maxRPS > 0 {
+		t.interval = time.Duration(float64(time.Second) / maxRPS)
+	}
+	return t, nil
+}
+
+// reserve returns how long to wait at now before the next fetch and records that fetch's start.
+// minDelay is a wait required regardless of the throttle, e.g. a robots.txt Crawl-delay. The
+// --delay and its jitter apply between fetches, so the first fetch only waits minDelay.
+func (t *requestThrottle) reserve(now time.Time, minDelay time.Duration) time.Duration {
+	if t == nil {
+		return minDelay
+	}
+	wait := minDelay
+	if !t.next.IsZero() {
+		delay := t.delay
+		if t.jitter > 0 {
+			delay += t.randN(t.jitter + 1)
+		}
+		wait = max(wait, delay, t.next.Add(t.interval).Sub(now))
+	}
+	t.next = now.Add(wait)
+	return wait
+}
+
+// String describes the throttle for the run log.
+func (t *requestThrottle) String() string {
+	var parts []string
+	if t.delay > 0 || t.jitter > 0 {
+		parts = append(parts, fmt.Sprintf("delay %s", t.delay))
+	}
+	if t.jitter > 0 {
+		parts = append(parts, fmt.Sprintf("up to %s jitter", t.jitter))
+	}
+	if t.interval > 0 {
+		parts = append(parts, fmt.Sprintf("at least %s between requests", t.interval))
+	}
+	return strings.Join(parts, ", ")
+}
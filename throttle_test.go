@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRequestThrottle(t *testing.T) {
+	if th, err := newRequestThrottle(0, 0, 0); th != nil || err != nil {
+		t.Errorf("no options = (%v, %v), want no throttle", th, err)
+	}
+	for _, tt := range []struct {
+		delay, jitter time.Duration
+		rps           float64
+		want          string
+	}{
+		{-time.Second, 0, 0, "--delay"},
+		{0, -time.Second, 0, "--delay-jitter"},
+		{0, 0, -1, "--max-rps"},
+	} {
+		if _, err := newRequestThrottle(tt.delay, tt.jitter, tt.rps); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("newRequestThrottle(%s, %s, %g) error = %v, want it to mention %s", tt.delay, tt.jitter, tt.rps, err, tt.want)
+		}
+	}
+	th, err := newRequestThrottle(2*time.Second, time.Second, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := th.String(), "delay 2s, up to 1s jitter, at least 250ms between requests"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestRequestThrottleReserve(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var none *requestThrottle
+	if got := none.reserve(start, time.Second); got != time.Second {
+		t.Errorf("nil throttle wait = %s, want the minimum delay", got)
+	}
+
+	delayed, _ := newRequestThrottle(2*time.Second, 500*time.Millisecond, 0)
+	delayed.randN = func(n time.Duration) time.Duration { return n - 1 }
+	if got := delayed.reserve(start, 0); got != 0 {
+		t.Errorf("first fetch wait = %s, want 0", got)
+	}
+	if got, want := delayed.reserve(start.Add(5*time.Second), 0), 2500*time.Millisecond; got != want {
+		t.Errorf("second fetch wait = %s, want delay plus jitter %s", got, want)
+	}
+	if got := delayed.reserve(start.Add(20*time.Second), 10*time.Second); got != 10*time.Second {
+		t.Errorf("wait with a longer crawl delay = %s, want 10s", got)
+	}
+
+	limited, _ := newRequestThrottle(0, 0, 2)
+	now := start
+	var waits []time.Duration
+	for i := 0; i < 3; i++ {
+		wait := limited.reserve(now, 0)
+		waits = append(waits, wait)
+		now = now.Add(wait + 100*time.Millisecond) // each fetch takes 100ms
+	}
+	want := []time.Duration{0, 400 * time.Millisecond, 400 * time.Millisecond}
+	for i := range want {
+		if waits[i] != want[i] {
+			t.Errorf("--max-rps 2 waits = %v, want %v", waits, want)
+			break
+		}
+	}
+	if got := limited.reserve(now.Add(time.Second), 0); got != 0 {
+		t.Errorf("wait after a slow page = %s, want 0", got)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tocNode is a section or page in the generated table of contents.
+type tocNode struct {
+	label    string
+	page     *PageData
+	children []*tocNode
+}
+
+func (n *tocNode) child(label string) *tocNode {
+	for _, c := range n.children {
+		if c.label == label {
+			return c
+		}
+	}
+	c := &tocNode{label: label}
+	n.children = append(n.children, c)
+	return c
+}
+
+// buildTOCTree arranges pages by their section trail. Pages are placed under their breadcrumb trail
+// when they have one, and otherwise under their URL path, with each parent path labeled by the
+// title of the page at that path if it was scraped. Sections keep first-seen (crawl) order.
+func buildTOCTree(results []PageData) *tocNode {
+	titleByURL := make(map[string]string, len(results))
+	for _, pd := range results {
+		if pd.Title != "" {
+			titleByURL[pd.URL] = pd.Title
+		}
+	}
+
+	root := &tocNode{}
+	for i := range results {
+		pd := &results[i]
+		pageURL, err := url.Parse(pd.URL)
+		if err != nil {
+			continue
+		}
+		trail := sectionTrailFromBreadcrumbs(pageURL, pd.Breadcrumbs)
+		if trail == nil {
+			trail = tocTrailFromURL(pageURL, titleByURL)
+		}
+
+		node := root
+		for _, label := range trail {
+			node = node.child(label)
+		}
+		label := pd.Title
+		if label == "" {
+			label = pd.URL
+		}
+		leaf := node.child(label)
+		if leaf.page != nil {
+			// Two pages with the same title in one section: keep both.
+			leaf = &tocNode{label: label}
+			node.children = append(node.children, leaf)
+		}
+		leaf.page = pd
+	}
+	return root
+}
+
+// tocTrailFromURL returns one label per parent path segment of pageURL.
+func tocTrailFromURL(pageURL *url.URL, titleByURL map[string]string) []string {
+	segments := strings.Split(strings.Trim(pageURL.Path, "/"), "/")
+	if len(segments) <= 1 {
+		return []string{}
+	}
+	trail := make([]string, 0, len(segments)-1)
+	for i := 1; i < len(segments); i++ {
+		prefix := &url.URL{Scheme: pageURL.Scheme, Host: pageURL.Host, Path: "/" + strings.Join(segments[:i], "/")}
+		if title, ok := titleByURL[prefix.String()]; ok {
+			trail = append(trail, title)
+		} else {
+			label, err := url.PathUnescape(segments[i-1])
+			if err != nil {
+				label = segments[i-1]
+			}
+			trail = append(trail, label)
+		}
+	}
+	return trail
+}
+
+// formatTOC renders the tree as a nested Markdown list. linkFor returns the link target for a page.
+func formatTOC(root *tocNode, linkFor func(*PageData) string) string {
+	var b strings.Builder
+	b.WriteString("# Table of Contents\n\n")
+	var walk func(n *tocNode, depth int)
+	walk = func(n *tocNode, depth int) {
+		for _, c := range n.children {
+			indent := strings.Repeat("  ", depth)
+			if c.page != nil {
+				fmt.Fprintf(&b, "%s- [%s](%s)\n", indent, escapeMarkdownLinkText(c.label), linkFor(c.page))
+			} else {
+				fmt.Fprintf(&b, "%s- %s\n", indent, c.label)
+			}
+			walk(c, depth+1)
+		}
+	}
+	walk(root, 0)
+	return b.String()
+}
+
+func escapeMarkdownLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// writeTOC writes the table of contents to tocPath. If pagePaths (URL to path relative to outputDir)
+// is given, entries link to the per-page Markdown files; otherwise they link to the page URLs.
+func writeTOC(tocPath string, results []PageData, outputDir string, pagePaths map[string]string) error {
+	tocDir := filepath.Dir(tocPath)
+	linkFor := func(pd *PageData) string {
+		rel, ok := pagePaths[pd.URL]
+		if !ok {
+			return pd.URL
+		}
+		target := filepath.Join(outputDir, filepath.FromSlash(rel))
+		if link, err := filepath.Rel(tocDir, target); err == nil {
+			target = link
+		}
+		return (&url.URL{Path: filepath.ToSlash(target)}).String()
+	}
+	content := formatTOC(buildTOCTree(results), linkFor)
+	if err := os.WriteFile(tocPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write table of contents %s: %w", tocPath, err)
+	}
+	return nil
+}
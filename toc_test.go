@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildAndFormatTOC(t *testing.T) {
+	results := []PageData{
+		{Title: "Home", URL: "https://example.com/"},
+		{Title: "Guide", URL: "https://example.com/guide"},
+		{Title: "Install", URL: "https://example.com/guide/install"},
+		{Title: "Config", URL: "https://example.com/reference/config"},
+		{
+			Title: "FAQ",
+			URL:   "https://example.com/faq",
+			Breadcrumbs: []Breadcrumb{
+				{Name: "Home", URL: "https://example.com/"},
+				{Name: "Guide", URL: "https://example.com/guide"},
+				{Name: "FAQ"},
+			},
+		},
+	}
+
+	got := formatTOC(buildTOCTree(results), func(pd *PageData) string { return pd.URL })
+	want := `# Table of Contents
+
+- [Home](https://example.com/)
+- [Guide](https://example.com/guide)
+  - [Install](https://example.com/guide/install)
+  - [FAQ](https://example.com/faq)
+- reference
+  - [Config](https://example.com/reference/config)
+`
+	if got != want {
+		t.Errorf("formatTOC() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTOCLinksToPageFiles(t *testing.T) {
+	dir := t.TempDir()
+	outputDir := filepath.Join(dir, "pages")
+	tocPath := filepath.Join(dir, "toc.md")
+	results := []PageData{{Title: "A [draft]", URL: "https://example.com/a b"}}
+	pagePaths := map[string]string{"https://example.com/a b": "example.com/a b.md"}
+
+	if err := writeTOC(tocPath, results, outputDir, pagePaths); err != nil {
+		t.Fatalf("writeTOC() error: %v", err)
+	}
+	content, err := os.ReadFile(tocPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `- [A \[draft\]](pages/example.com/a%20b.md)`) {
+		t.Errorf("TOC does not link to the page file:\n%s", content)
+	}
+}
@@ -0,0 +1,696 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hokupod/sitepanda/cmd"
+)
+
+// uiPreviewTimeout bounds a selector preview or a /api/fetch request, which launch a browser
+// for one page.
+const uiPreviewTimeout = 2 * time.Minute
+
+// uiLogLines is how many log lines of a scrape the UI keeps to show why it failed.
+const uiLogLines = 20
+
+// uiOutputExtensions maps the output formats offered by the UI to their file extensions.
+var uiOutputExtensions = map[string]string{
+	"json":     "json",
+	"jsonl":    "jsonl",
+	"xml-like": "txt",
+	"org":      "org",
+	"asciidoc": "adoc",
+	"parquet":  "parquet",
+}
+
+// uiResultContentTypes maps output file extensions to the Content-Type of a job's result.
+var uiResultContentTypes = map[string]string{
+	".json":  "application/json",
+	".jsonl": "application/x-ndjson",
+	".txt":   "text/plain; charset=utf-8",
+	".org":   "text/plain; charset=utf-8",
+	".adoc":  "text/plain; charset=utf-8",
+}
+
+// uiScrapeRequest is the scrape configured in the web UI.
+type uiScrapeRequest struct {
+	URL                string   `json:"url"`
+	Match              []string `json:"match"`
+	FollowMatch        []string `json:"follow_match"`
+	ContentSelector    string   `json:"content_selector"`
+	WaitForNetworkIdle bool     `json:"wait_for_network_idle"`
+	Limit              int      `json:"limit"`
+	OutputFormat       string   `json:"output_format"`
+	Browser            string   `json:"browser"`
+}
+
+// scrapeArgs validates the request and returns the 'sitepanda scrape' arguments that run it,
+// writing to outfile. Scrapes report progress as --progress-format json events on stderr.
+func (r uiScrapeRequest) scrapeArgs(outfile string) ([]string, error) {
+	u, err := url.Parse(strings.TrimSpace(r.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("enter an http or https URL")
+	}
+	if _, ok := uiOutputExtensions[r.OutputFormat]; !ok {
+		return nil, fmt.Errorf("unsupported output format %q", r.OutputFormat)
+	}
+	if r.Limit < 0 {
+		return nil, fmt.Errorf("the page limit must not be negative")
+	}
+	args := []string{"scrape", "--progress-format", "json", "--outfile", outfile, "--output-format", r.OutputFormat, "--limit", strconv.Itoa(r.Limit)}
+	switch r.Browser {
+	case "":
+	case "chromium", "lightpanda":
+		args = append(args, "--browser", r.Browser)
+	default:
+		return nil, fmt.Errorf("unsupported browser %q", r.Browser)
+	}
+	for _, pattern := range r.Match {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			args = append(args, "--match", pattern)
+		}
+	}
+	for _, pattern := range r.FollowMatch {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			args = append(args, "--follow-match", pattern)
+		}
+	}
+	if selector := strings.TrimSpace(r.ContentSelector); selector != "" {
+		args = append(args, "--content-selector", selector)
+	}
+	if r.WaitForNetworkIdle {
+		args = append(args, "--wait-for-network-idle")
+	}
+	return append(args, u.String()), nil
+}
+
+// uiScrapeResult is the response of /api/scrape: the saved pages and why the crawl stopped.
+type uiScrapeResult struct {
+	Pages      []JSONOutputPage `json:"pages"`
+	StopReason string           `json:"stop_reason,omitempty"`
+}
+
+// uiMapResult is the response of /api/map: the URLs a scrape would save, without their content.
+type uiMapResult struct {
+	URLs       []string `json:"urls"`
+	StopReason string   `json:"stop_reason,omitempty"`
+}
+
+// uiJobStatus is the state of a web UI scrape as reported to the page.
+type uiJobStatus struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	State      string   `json:"state"` // queued, running, done, failed or cancelled
+	Processed  int      `json:"processed"`
+	Saved      int      `json:"saved"`
+	Queued     int      `json:"queued"`
+	StopReason string   `json:"stop_reason,omitempty"`
+	Log        []string `json:"log,omitempty"`
+}
+
+// uiJob is a scrape started from the web UI.
+type uiJob struct {
+	mu        sync.Mutex
+	status    uiJobStatus
+	outfile   string
+	process   *os.Process
+	cancelled bool
+	// owner is the API key that started the job; only it can see the job.
+	owner *uiAPIKey
+	// args are the job's 'sitepanda scrape' arguments.
+	args []string
+	// reserved is the part of its key's page quota held for the job.
+	reserved int
+	// events are the job's latest server-sent events; eventBase is the ID of the first.
+	events    []uiEvent
+	eventBase int
+	// changed is closed and replaced when an event is added or the job's state changes.
+	changed chan struct{}
+}
+
+// snapshot returns a copy of the job's status.
+func (j *uiJob) snapshot() uiJobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	status := j.status
+	status.Log = append([]string(nil), j.status.Log...)
+	return status
+}
+
+// readOutput consumes a scrape's stderr, applying progress events and keeping the last log lines.
+func (j *uiJob) readOutput(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var ev progressEvent
+		isEvent := strings.HasPrefix(line, `{"event":`) && json.Unmarshal([]byte(line), &ev) == nil
+		j.mu.Lock()
+		if isEvent {
+			j.status.Processed, j.status.Saved, j.status.Queued = ev.Processed, ev.Saved, ev.Queued
+			if ev.Event == "finish" {
+				j.status.StopReason = ev.StopReason
+			}
+			if name := uiEventName(ev); name != "" {
+				j.publishLocked(name, []byte(line))
+			}
+		} else {
+			j.status.Log = append(j.status.Log, line)
+			if len(j.status.Log) > uiLogLines {
+				j.status.Log = j.status.Log[len(j.status.Log)-uiLogLines:]
+			}
+		}
+		j.mu.Unlock()
+	}
+}
+
+// uiServer serves the web UI and runs its scrapes.
+type uiServer struct {
+	exe    string
+	tmpDir string
+	// keys restricts the API to the keys of an --api-keys file; nil leaves it open.
+	keys *uiAPIKeys
+
+	// maxJobs caps the scrapes running at once (0 for no cap); further jobs wait queued.
+	maxJobs int
+
+	mu    sync.Mutex
+	jobs  map[string]*uiJob
+	order []*uiJob // jobs by ID, the order queued jobs start in
+	// running counts started scrapes that have not exited.
+	running  int
+	stopping bool
+	nextID   int
+}
+
+func newUIServer(exe string, tmpDir string) *uiServer {
+	return &uiServer{exe: exe, tmpDir: tmpDir, jobs: make(map[string]*uiJob)}
+}
+
+func (s *uiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		io.WriteString(w, uiPageHTML)
+	})
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, uiOpenAPISpec)
+	})
+	mux.HandleFunc("POST /api/preview", s.authorize(s.handlePreview))
+	mux.HandleFunc("POST /api/fetch", s.authorize(s.handleFetch))
+	mux.HandleFunc("POST /api/scrape", s.authorize(s.handleScrape))
+	mux.HandleFunc("POST /api/map", s.authorize(s.handleMap))
+	mux.HandleFunc("POST /api/jobs", s.authorize(s.handleStartJob))
+	mux.HandleFunc("GET /api/jobs/{id}", s.authorize(s.handleJobStatus))
+	mux.HandleFunc("POST /api/jobs/{id}/cancel", s.authorize(s.handleCancelJob))
+	mux.HandleFunc("GET /api/jobs/{id}/download", s.authorize(s.handleDownload))
+	mux.HandleFunc("GET /api/jobs/{id}/result", s.authorize(s.handleResult))
+	mux.HandleFunc("GET /api/jobs/{id}/events", s.authorize(s.handleJobEvents))
+	return mux
+}
+
+// decodeUIRequest reads a JSON request body. Requiring a JSON content type and a same-origin
+// Origin keeps other web sites from starting scrapes through the user's browser.
+func decodeUIRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		http.Error(w, "expected a JSON request", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+			return false
+		}
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(v); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeUIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// resultDir returns the directory for the results of key's scrapes, one per key under
+// tmpDir/keys so that keys never share files, nor do their directories mix with the results
+// of scrapes made without a key.
+func (s *uiServer) resultDir(key *uiAPIKey) (string, error) {
+	if key == nil {
+		return s.tmpDir, nil
+	}
+	dir := filepath.Join(s.tmpDir, "keys", key.Name)
+	return dir, os.MkdirAll(dir, 0700)
+}
+
+// runScrape runs req to completion for the endpoints that answer with its pages, with JSON
+// output and extraArgs, and returns the saved pages and why the crawl stopped. A timeout of 0
+// leaves the scrape running until it ends or the client goes away. It answers the request
+// itself and returns false if the scrape could not run.
+func (s *uiServer) runScrape(w http.ResponseWriter, r *http.Request, req uiScrapeRequest, timeout time.Duration, extraArgs ...string) ([]JSONOutputPage, string, bool) {
+	req.OutputFormat = "json"
+	key := requestAPIKey(r)
+	dir, err := s.resultDir(key)
+	if err != nil {
+		writeUIJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return nil, "", false
+	}
+	outfile := filepath.Join(dir, fmt.Sprintf("request-%d.json", time.Now().UnixNano()))
+	defer os.Remove(outfile)
+	if _, err := req.scrapeArgs(outfile); err != nil {
+		writeUIJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return nil, "", false
+	}
+	reserved, err := key.reservePages(req.Limit)
+	if err != nil {
+		writeUIJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+		return nil, "", false
+	}
+	req.Limit = reserved
+	args, _ := req.scrapeArgs(outfile)
+	// The start URL stays the last argument.
+	args = append(args[:len(args)-1], append(extraArgs, args[len(args)-1])...)
+	pages := []JSONOutputPage{}
+	defer func() { key.releasePages(reserved, len(pages)) }()
+
+	ctx := r.Context()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	// The progress events and log lines are collected as for a job.
+	job := &uiJob{}
+	scrapeCmd := exec.CommandContext(ctx, s.exe, args...)
+	stderr, err := scrapeCmd.StderrPipe()
+	if err == nil {
+		err = scrapeCmd.Start()
+	}
+	if err == nil {
+		job.readOutput(stderr)
+		err = scrapeCmd.Wait()
+	}
+	if err != nil {
+		writeUIJSON(w, http.StatusBadGateway, map[string]string{"error": fmt.Sprintf("scrape failed: %v\n%s", err, strings.Join(job.status.Log, "\n"))})
+		return nil, "", false
+	}
+	if data, err := os.ReadFile(outfile); err == nil {
+		json.Unmarshal(data, &pages)
+	}
+	return pages, job.status.StopReason, true
+}
+
+// handlePreview scrapes only the start URL with the request's selector and returns its title
+// and Markdown.
+func (s *uiServer) handlePreview(w http.ResponseWriter, r *http.Request) {
+	var req uiScrapeRequest
+	if !decodeUIRequest(w, r, &req) {
+		return
+	}
+	req.Match, req.FollowMatch, req.Limit = nil, nil, 1
+	pages, _, ok := s.runScrape(w, r, req, uiPreviewTimeout)
+	if !ok {
+		return
+	}
+	if len(pages) == 0 {
+		writeUIJSON(w, http.StatusOK, map[string]string{"error": "No content was extracted from this page. Try another selector."})
+		return
+	}
+	writeUIJSON(w, http.StatusOK, map[string]string{"title": pages[0].Title, "content": pages[0].Content})
+}
+
+// handleFetch scrapes only the start URL and returns the page as it would be saved.
+func (s *uiServer) handleFetch(w http.ResponseWriter, r *http.Request) {
+	var req uiScrapeRequest
+	if !decodeUIRequest(w, r, &req) {
+		return
+	}
+	req.Match, req.FollowMatch, req.Limit = nil, nil, 1
+	pages, _, ok := s.runScrape(w, r, req, uiPreviewTimeout)
+	if !ok {
+		return
+	}
+	if len(pages) == 0 {
+		writeUIJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "no content was extracted from this page"})
+		return
+	}
+	writeUIJSON(w, http.StatusOK, pages[0])
+}
+
+// handleScrape runs a scrape and answers with its pages once it has finished, for clients
+// that do not want to poll a job.
+func (s *uiServer) handleScrape(w http.ResponseWriter, r *http.Request) {
+	var req uiScrapeRequest
+	if !decodeUIRequest(w, r, &req) {
+		return
+	}
+	if pages, stopReason, ok := s.runScrape(w, r, req, 0); ok {
+		writeUIJSON(w, http.StatusOK, uiScrapeResult{Pages: pages, StopReason: stopReason})
+	}
+}
+
+// handleMap runs a scrape without keeping page content and answers with the URLs it saved.
+func (s *uiServer) handleMap(w http.ResponseWriter, r *http.Request) {
+	var req uiScrapeRequest
+	if !decodeUIRequest(w, r, &req) {
+		return
+	}
+	pages, stopReason, ok := s.runScrape(w, r, req, 0, "--drop-fields", "content")
+	if !ok {
+		return
+	}
+	result := uiMapResult{URLs: []string{}, StopReason: stopReason}
+	for _, page := range pages {
+		result.URLs = append(result.URLs, page.URL)
+	}
+	writeUIJSON(w, http.StatusOK, result)
+}
+
+func (s *uiServer) handleStartJob(w http.ResponseWriter, r *http.Request) {
+	var req uiScrapeRequest
+	if !decodeUIRequest(w, r, &req) {
+		return
+	}
+	if req.OutputFormat == "" {
+		req.OutputFormat = "json"
+	}
+	key := requestAPIKey(r)
+	dir, err := s.resultDir(key)
+	if err != nil {
+		writeUIJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	s.mu.Lock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.mu.Unlock()
+	job := &uiJob{status: uiJobStatus{ID: id, URL: req.URL, State: "queued"}, owner: key}
+	job.outfile = filepath.Join(dir, "job-"+id+"."+uiOutputExtensions[req.OutputFormat])
+	if _, err := req.scrapeArgs(job.outfile); err != nil {
+		writeUIJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	// With a page quota, the scrape's --limit is lowered to the pages the key has left.
+	if job.reserved, err = key.reservePages(req.Limit); err != nil {
+		writeUIJSON(w, http.StatusForbidden, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Limit = job.reserved
+	job.args, _ = req.scrapeArgs(job.outfile)
+
+	s.mu.Lock()
+	s.jobs[id] = job
+	s.order = append(s.order, job)
+	s.mu.Unlock()
+	s.saveJob(job)
+	if key != nil {
+		logger.Printf("Queued scrape %s of %s for key %s.", id, req.URL, key.Name)
+	} else {
+		logger.Printf("Queued scrape %s of %s.", id, req.URL)
+	}
+	s.startQueued()
+	writeUIJSON(w, http.StatusAccepted, job.snapshot())
+}
+
+// startQueued starts queued jobs, oldest first, while fewer than --max-jobs are running.
+func (s *uiServer) startQueued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.order {
+		if s.stopping || (s.maxJobs > 0 && s.running >= s.maxJobs) {
+			return
+		}
+		job.mu.Lock()
+		queued := job.status.State == "queued"
+		job.mu.Unlock()
+		if queued {
+			s.startJob(job)
+		}
+	}
+}
+
+// startJob runs a queued job's scrape. s.mu must be held.
+func (s *uiServer) startJob(job *uiJob) {
+	scrapeCmd := exec.Command(s.exe, job.args...)
+	stderr, err := scrapeCmd.StderrPipe()
+	if err == nil {
+		err = scrapeCmd.Start()
+	}
+	job.mu.Lock()
+	if err != nil {
+		job.status.State = "failed"
+		job.status.Log = append(job.status.Log, fmt.Sprintf("failed to start the scrape: %v", err))
+		job.owner.releasePages(job.reserved, 0)
+		job.wakeLocked()
+		job.mu.Unlock()
+		s.saveJob(job)
+		return
+	}
+	job.status.State = "running"
+	job.process = scrapeCmd.Process
+	job.mu.Unlock()
+	s.running++
+	s.saveJob(job)
+	logger.Printf("Started scrape %s of %s.", job.status.ID, job.status.URL)
+
+	go func() {
+		job.readOutput(stderr)
+		err := scrapeCmd.Wait()
+		s.mu.Lock()
+		s.running--
+		stopping := s.stopping
+		s.mu.Unlock()
+
+		job.mu.Lock()
+		_, statErr := os.Stat(job.outfile)
+		switch {
+		case stopping && !job.cancelled:
+			// The server is stopping; the saved record still says running, so the next
+			// start queues the job again.
+			job.mu.Unlock()
+			return
+		case job.cancelled && statErr == nil:
+			job.status.State = "cancelled"
+		case err != nil:
+			job.status.State = "failed"
+		case statErr != nil:
+			job.status.State = "failed"
+			job.status.Log = append(job.status.Log, "No pages were saved.")
+		default:
+			job.status.State = "done"
+		}
+		job.owner.releasePages(job.reserved, job.status.Saved)
+		logger.Printf("Scrape %s %s.", job.status.ID, job.status.State)
+		job.wakeLocked()
+		job.mu.Unlock()
+		s.saveJob(job)
+		s.startQueued()
+	}()
+}
+
+// job returns the job named in r's path, answering 404 if there is none or it belongs to
+// another API key.
+func (s *uiServer) job(w http.ResponseWriter, r *http.Request) *uiJob {
+	s.mu.Lock()
+	job := s.jobs[r.PathValue("id")]
+	s.mu.Unlock()
+	if job != nil && job.owner != requestAPIKey(r) {
+		job = nil
+	}
+	if job == nil {
+		http.NotFound(w, r)
+	}
+	return job
+}
+
+func (s *uiServer) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	if job := s.job(w, r); job != nil {
+		writeUIJSON(w, http.StatusOK, job.snapshot())
+	}
+}
+
+// handleCancelJob drops a queued scrape or interrupts a running one, which then writes the
+// pages saved so far.
+func (s *uiServer) handleCancelJob(w http.ResponseWriter, r *http.Request) {
+	var none struct{}
+	if !decodeUIRequest(w, r, &none) {
+		return
+	}
+	job := s.job(w, r)
+	if job == nil {
+		return
+	}
+	job.mu.Lock()
+	switch job.status.State {
+	case "queued":
+		job.status.State = "cancelled"
+		job.owner.releasePages(job.reserved, 0)
+		job.wakeLocked()
+		job.mu.Unlock()
+		s.saveJob(job)
+	case "running":
+		job.cancelled = true
+		interruptProcess(job.process)
+		job.mu.Unlock()
+	default:
+		job.mu.Unlock()
+	}
+	writeUIJSON(w, http.StatusOK, job.snapshot())
+}
+
+// finishedJob returns the job named in r's path if its scrape has ended, answering 409 while
+// it is queued or running.
+func (s *uiServer) finishedJob(w http.ResponseWriter, r *http.Request) *uiJob {
+	job := s.job(w, r)
+	if job == nil {
+		return nil
+	}
+	if state := job.snapshot().State; state == "queued" || state == "running" {
+		http.Error(w, "the scrape is still "+state, http.StatusConflict)
+		return nil
+	}
+	return job
+}
+
+func (s *uiServer) handleDownload(w http.ResponseWriter, r *http.Request) {
+	job := s.finishedJob(w, r)
+	if job == nil {
+		return
+	}
+	name := "sitepanda"
+	if u, err := url.Parse(job.snapshot().URL); err == nil && u.Hostname() != "" {
+		name += "-" + u.Hostname()
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+filepath.Ext(job.outfile)))
+	http.ServeFile(w, r, job.outfile)
+}
+
+// handleResult streams a finished job's output file for API clients.
+func (s *uiServer) handleResult(w http.ResponseWriter, r *http.Request) {
+	job := s.finishedJob(w, r)
+	if job == nil {
+		return
+	}
+	if contentType, ok := uiResultContentTypes[filepath.Ext(job.outfile)]; ok {
+		w.Header().Set("Content-Type", contentType)
+	}
+	http.ServeFile(w, r, job.outfile)
+}
+
+// stopJobs interrupts the scrapes that are still running. Their saved records stay as they
+// are, so a server started on the same --data-dir runs them again.
+func (s *uiServer) stopJobs() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopping = true
+	for _, job := range s.jobs {
+		job.mu.Lock()
+		if job.status.State == "running" {
+			interruptProcess(job.process)
+		}
+		job.mu.Unlock()
+	}
+}
+
+// interruptProcess asks p to stop like Ctrl+C does, killing it where interrupts are unsupported.
+func interruptProcess(p *os.Process) {
+	if err := p.Signal(os.Interrupt); err != nil {
+		p.Kill()
+	}
+}
+
+// openInBrowser opens pageURL with the system's default browser.
+func openInBrowser(pageURL string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", pageURL).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", pageURL).Start()
+	default:
+		return exec.Command("xdg-open", pageURL).Start()
+	}
+}
+
+// HandleUI runs 'sitepanda ui' until it is interrupted.
+func HandleUI() {
+	exe, err := os.Executable()
+	if err != nil {
+		logger.Fatalf("Error: could not determine sitepanda executable: %v", err)
+	}
+	dataDir := cmd.GetUIDataDir()
+	if dataDir != "" {
+		if err := os.MkdirAll(dataDir, 0700); err != nil {
+			logger.Fatalf("Error: failed to create --data-dir: %v", err)
+		}
+	} else {
+		if dataDir, err = os.MkdirTemp("", "sitepanda-ui-"); err != nil {
+			logger.Fatalf("Error: failed to create a directory for results: %v", err)
+		}
+		defer os.RemoveAll(dataDir)
+	}
+
+	listener, err := net.Listen("tcp", cmd.GetUIListen())
+	if err != nil {
+		logger.Fatalf("Error: failed to listen on %s: %v", cmd.GetUIListen(), err)
+	}
+	ui := newUIServer(exe, dataDir)
+	ui.maxJobs = cmd.GetUIMaxJobs()
+	if path := cmd.GetUIAPIKeys(); path != "" {
+		if ui.keys, err = loadAPIKeys(path); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+		logger.Printf("API requests need one of %d API keys from %s.", len(ui.keys.Keys), path)
+	}
+	if cmd.GetUIDataDir() != "" {
+		if err := ui.loadJobs(); err != nil {
+			logger.Fatalf("Error: %v", err)
+		}
+		logger.Printf("Keeping jobs and results in %s.", dataDir)
+		ui.startQueued()
+	}
+	server := &http.Server{Handler: ui.handler()}
+
+	address := "http://" + listener.Addr().String() + "/"
+	logger.Printf("Sitepanda UI listening on %s (Ctrl+C to stop)", address)
+	if !cmd.GetUINoOpen() {
+		if err := openInBrowser(address); err != nil {
+			logger.Printf("Could not open a browser (%v). Open %s yourself.", err, address)
+		}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		ui.stopJobs()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Fatalf("Error: UI server stopped: %v", err)
+	}
+	logger.Println("UI stopped.")
+}
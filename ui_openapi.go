@@ -0,0 +1,10 @@
+package main
+
+import _ "embed"
+
+// uiOpenAPISpec is the OpenAPI document of the /api endpoints of 'sitepanda ui', served at
+// /openapi.json. The client package is generated from it; keep it in step with
+// uiServer.handler and run 'go generate ./client' after changing it.
+//
+//go:embed openapi.json
+var uiOpenAPISpec string
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hokupod/sitepanda/client"
+)
+
+// jsonFieldNames returns the JSON names of a struct type's fields.
+func jsonFieldNames(v any) []string {
+	var names []string
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestUIOpenAPISpec(t *testing.T) {
+	server := httptest.NewServer(newUIServer("sitepanda", t.TempDir()).handler())
+	defer server.Close()
+	res, err := http.Get(server.URL + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var spec struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&spec); err != nil {
+		t.Fatalf("/openapi.json is not valid JSON: %v", err)
+	}
+
+	wantPaths := map[string]string{
+		"/api/preview":            "post",
+		"/api/fetch":              "post",
+		"/api/scrape":             "post",
+		"/api/map":                "post",
+		"/api/jobs":               "post",
+		"/api/jobs/{id}":          "get",
+		"/api/jobs/{id}/cancel":   "post",
+		"/api/jobs/{id}/events":   "get",
+		"/api/jobs/{id}/result":   "get",
+		"/api/jobs/{id}/download": "get",
+	}
+	for path, method := range wantPaths {
+		if _, ok := spec.Paths[path][method]; !ok {
+			t.Errorf("spec is missing %s %s", strings.ToUpper(method), path)
+		}
+	}
+	if len(spec.Paths) != len(wantPaths) {
+		t.Errorf("spec has %d paths, want %d", len(spec.Paths), len(wantPaths))
+	}
+
+	// The schemas, the server's types and the client's types must agree on field names.
+	for schema, types := range map[string][]any{
+		"ScrapeRequest": {uiScrapeRequest{}, client.ScrapeRequest{}},
+		"Job":           {uiJobStatus{}, client.Job{}},
+		"Page":          {JSONOutputPage{}, client.Page{}},
+		"Breadcrumb":    {Breadcrumb{}, client.Breadcrumb{}},
+		"Comment":       {Comment{}, client.Comment{}},
+		"ScrapeResult":  {uiScrapeResult{}, client.ScrapeResult{}},
+		"MapResult":     {uiMapResult{}, client.MapResult{}},
+	} {
+		var properties []string
+		for name := range spec.Components.Schemas[schema].Properties {
+			properties = append(properties, name)
+		}
+		sort.Strings(properties)
+		for _, typ := range types {
+			if got := jsonFieldNames(typ); !reflect.DeepEqual(got, properties) {
+				t.Errorf("%T fields %v, schema %s properties %v", typ, got, schema, properties)
+			}
+		}
+	}
+}
+
+func TestUIClient(t *testing.T) {
+	keys, err := parseAPIKeys([]byte("keys:\n  - name: a\n    key: aaaaaaaaaaaaaaaaaaaa\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui := newUIServer(filepath.Join(t.TempDir(), "missing-sitepanda"), t.TempDir())
+	ui.keys = keys
+	server := httptest.NewServer(ui.handler())
+	defer server.Close()
+	ctx := context.Background()
+
+	anonymous, err := client.NewClientWithResponses(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := anonymous.GetJobWithResponse(ctx, "1"); err != nil || res.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("GetJob() without a key = %v, %v; want 401", res.Status(), err)
+	}
+
+	c, err := client.NewClientWithResponses(server.URL+"/", client.WithAPIKey("aaaaaaaaaaaaaaaaaaaa"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, err := c.StartJobWithResponse(ctx, client.ScrapeRequest{URL: "ftp://example.com/"})
+	if err != nil || started.JSON400 == nil || !strings.Contains(*started.JSON400.Error, "http or https URL") {
+		t.Errorf("StartJob() with a bad URL = %s %s, %v", started.Status(), started.Body, err)
+	}
+	// The server cannot run scrapes here, so the job fails as soon as it starts.
+	limit := 5
+	started, err = c.StartJobWithResponse(ctx, client.ScrapeRequest{URL: "https://example.com/", Limit: &limit})
+	if err != nil || started.JSON202 == nil {
+		t.Fatalf("StartJob() = %v, %v", started.Status(), err)
+	}
+	if job := started.JSON202; job.ID == "" || job.URL != "https://example.com/" {
+		t.Errorf("StartJob() = %+v", job)
+	}
+	job, err := c.WaitForJob(ctx, started.JSON202.ID, 0)
+	if err != nil || job.State != client.JobStateFailed || !job.Finished() {
+		t.Errorf("WaitForJob() = %+v, %v; want a failed job", job, err)
+	}
+	if res, err := c.GetJobResultWithResponse(ctx, job.ID); err != nil || res.StatusCode() != http.StatusNotFound {
+		t.Errorf("GetJobResult() of a job without output = %v, %v; want 404", res.Status(), err)
+	}
+	if res, err := c.CancelJobWithResponse(ctx, "99", client.CancelJobJSONRequestBody{}); err != nil || res.StatusCode() != http.StatusNotFound {
+		t.Errorf("CancelJob() of an unknown job = %v, %v; want 404", res.Status(), err)
+	}
+
+	// The synchronous endpoints validate the request and report a scrape that cannot run.
+	if res, err := c.FetchWithResponse(ctx, client.ScrapeRequest{URL: "example.com"}); err != nil || res.JSON400 == nil {
+		t.Errorf("Fetch() with a bad URL = %v, %v; want 400", res.Status(), err)
+	}
+	if res, err := c.ScrapeWithResponse(ctx, client.ScrapeRequest{URL: "https://example.com/"}); err != nil || res.JSON502 == nil || !strings.Contains(*res.JSON502.Error, "scrape failed") {
+		t.Errorf("Scrape() = %v, %v; want 502", res.Status(), err)
+	}
+	if res, err := c.MapWithResponse(ctx, client.ScrapeRequest{URL: "https://example.com/"}); err != nil || res.JSON502 == nil {
+		t.Errorf("Map() = %v, %v; want 502", res.Status(), err)
+	}
+}
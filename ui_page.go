@@ -0,0 +1,219 @@
+package main
+
+// uiPageHTML is the single page served by 'sitepanda ui'. It talks to the /api endpoints of
+// uiServer and has no external dependencies.
+const uiPageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Sitepanda</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+h1 { font-size: 1.4rem; }
+label { display: block; margin-top: 0.8rem; font-weight: 600; }
+label.inline { display: inline; font-weight: normal; }
+input[type=text], input[type=number], textarea, select { width: 100%; box-sizing: border-box; padding: 0.3rem; font: inherit; }
+textarea { height: 4rem; font-family: monospace; }
+.hint { color: #666; font-size: 0.85rem; font-weight: normal; }
+.buttons { margin-top: 1rem; }
+button { padding: 0.4rem 1rem; margin-right: 0.5rem; }
+section { margin-top: 1.5rem; border-top: 1px solid #ddd; padding-top: 0.5rem; }
+pre { background: #f6f6f6; padding: 0.6rem; white-space: pre-wrap; max-height: 24rem; overflow: auto; }
+.error { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Sitepanda</h1>
+<form id="form">
+<label>Start URL <input type="text" name="url" placeholder="https://example.com/docs/" required></label>
+<label>Pages to save <span class="hint">(--match, one glob per line; empty saves every page)</span>
+<textarea name="match" placeholder="/docs/**"></textarea></label>
+<label>Links to follow <span class="hint">(--follow-match, one glob per line; empty follows every same-host link)</span>
+<textarea name="follow_match"></textarea></label>
+<label>Content selector <span class="hint">(--content-selector, e.g. main or .article-body)</span>
+<input type="text" name="content_selector"></label>
+<label class="inline"><input type="checkbox" name="wait_for_network_idle"> Wait for the network to be idle before extracting</label>
+<label>Page limit <span class="hint">(0 for no limit)</span> <input type="number" name="limit" value="20" min="0"></label>
+<label>Output format <select name="output_format">
+<option value="json">JSON</option>
+<option value="jsonl">JSON Lines</option>
+<option value="xml-like">XML-like text</option>
+<option value="org">Org</option>
+<option value="asciidoc">AsciiDoc</option>
+<option value="parquet">Parquet</option>
+</select></label>
+<label>Browser <select name="browser">
+<option value="">Default</option>
+<option value="chromium">Chromium</option>
+<option value="lightpanda">Lightpanda</option>
+</select></label>
+<div class="buttons">
+<button type="button" id="preview">Preview start page</button>
+<button type="submit" id="run">Run scrape</button>
+</div>
+</form>
+
+<section id="preview-section" hidden>
+<h2 id="preview-title">Preview</h2>
+<pre id="preview-content"></pre>
+</section>
+
+<section id="job-section" hidden>
+<h2>Scrape <span id="job-state"></span></h2>
+<p id="job-progress"></p>
+<div class="buttons">
+<button type="button" id="cancel">Cancel</button>
+<a id="download" href="#" hidden>Download results</a>
+</div>
+<pre id="job-log" hidden></pre>
+</section>
+
+<script>
+const form = document.getElementById("form");
+let jobID = null;
+
+function lines(text) {
+  return text.split("\n").map(s => s.trim()).filter(s => s !== "");
+}
+
+function request() {
+  const f = form.elements;
+  return {
+    url: f.url.value.trim(),
+    match: lines(f.match.value),
+    follow_match: lines(f.follow_match.value),
+    content_selector: f.content_selector.value.trim(),
+    wait_for_network_idle: f.wait_for_network_idle.checked,
+    limit: parseInt(f.limit.value || "0", 10),
+    output_format: f.output_format.value,
+    browser: f.browser.value
+  };
+}
+
+// Servers started with --api-keys need a key with every API request; it is asked for once.
+let apiKey = sessionStorage.getItem("sitepanda-api-key") || "";
+
+async function api(path, options = {}) {
+  for (;;) {
+    const headers = Object.assign({}, options.headers);
+    if (apiKey) {
+      headers["X-API-Key"] = apiKey;
+    }
+    const res = await fetch(path, Object.assign({}, options, {headers}));
+    if (res.status !== 401) {
+      return res;
+    }
+    const key = prompt("This Sitepanda server needs an API key:");
+    if (!key) {
+      return res;
+    }
+    apiKey = key.trim();
+    sessionStorage.setItem("sitepanda-api-key", apiKey);
+  }
+}
+
+async function post(path, body) {
+  const res = await api(path, {method: "POST", headers: {"Content-Type": "application/json"}, body: JSON.stringify(body)});
+  const text = await res.text();
+  try {
+    return JSON.parse(text);
+  } catch (e) {
+    return {error: text.trim() || res.statusText};
+  }
+}
+
+document.getElementById("preview").addEventListener("click", async () => {
+  const section = document.getElementById("preview-section");
+  const title = document.getElementById("preview-title");
+  const content = document.getElementById("preview-content");
+  section.hidden = false;
+  title.textContent = "Loading preview...";
+  content.textContent = "";
+  content.className = "";
+  const data = await post("/api/preview", request());
+  if (data.error) {
+    title.textContent = "Preview";
+    content.textContent = data.error;
+    content.className = "error";
+    return;
+  }
+  title.textContent = data.title || "(untitled)";
+  content.textContent = data.content;
+});
+
+form.addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const data = await post("/api/jobs", request());
+  document.getElementById("job-section").hidden = false;
+  if (data.error) {
+    showJob({state: "failed", log: [data.error]});
+    return;
+  }
+  jobID = data.id;
+  showJob(data);
+  poll();
+});
+
+document.getElementById("cancel").addEventListener("click", async () => {
+  if (jobID) {
+    showJob(await post("/api/jobs/" + jobID + "/cancel", {}));
+  }
+});
+
+document.getElementById("download").addEventListener("click", async (event) => {
+  if (!apiKey) {
+    return;
+  }
+  // A link cannot send the API key, so the file is fetched and saved from a blob.
+  event.preventDefault();
+  const res = await api(event.currentTarget.href);
+  if (!res.ok) {
+    alert(await res.text());
+    return;
+  }
+  const name = /filename="([^"]+)"/.exec(res.headers.get("Content-Disposition") || "");
+  const link = document.createElement("a");
+  link.href = URL.createObjectURL(await res.blob());
+  link.download = name ? name[1] : "sitepanda-results";
+  link.click();
+  URL.revokeObjectURL(link.href);
+});
+
+function showJob(job) {
+  const running = job.state === "running" || job.state === "queued";
+  document.getElementById("job-state").textContent = job.state || "";
+  document.getElementById("job-progress").textContent = job.id ?
+    (job.processed || 0) + " pages processed, " + (job.saved || 0) + " saved, " + (job.queued || 0) + " queued" +
+    (job.stop_reason ? " (" + job.stop_reason + ")" : "") : "";
+  document.getElementById("cancel").hidden = !running;
+  document.getElementById("run").disabled = running;
+  const download = document.getElementById("download");
+  download.hidden = !job.id || running || job.state === "failed";
+  if (job.id) {
+    download.href = "/api/jobs/" + job.id + "/download";
+  }
+  const log = document.getElementById("job-log");
+  log.hidden = !job.log || job.log.length === 0 || job.state !== "failed";
+  log.textContent = (job.log || []).join("\n");
+  log.className = "error";
+}
+
+async function poll() {
+  const id = jobID;
+  while (id === jobID) {
+    await new Promise(resolve => setTimeout(resolve, 1000));
+    const res = await api("/api/jobs/" + id);
+    if (!res.ok) {
+      return;
+    }
+    const job = await res.json();
+    showJob(job);
+    if (job.state !== "running" && job.state !== "queued") {
+      return;
+    }
+  }
+}
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUIScrapeArgs(t *testing.T) {
+	req := uiScrapeRequest{
+		URL:                " https://example.com/docs/ ",
+		Match:              []string{"/docs/**", " "},
+		FollowMatch:        []string{"/docs/**"},
+		ContentSelector:    "main",
+		WaitForNetworkIdle: true,
+		Limit:              5,
+		OutputFormat:       "jsonl",
+		Browser:            "lightpanda",
+	}
+	got, err := req.scrapeArgs("out.jsonl")
+	if err != nil {
+		t.Fatalf("scrapeArgs() error: %v", err)
+	}
+	want := []string{
+		"scrape", "--progress-format", "json", "--outfile", "out.jsonl", "--output-format", "jsonl", "--limit", "5",
+		"--browser", "lightpanda", "--match", "/docs/**", "--follow-match", "/docs/**",
+		"--content-selector", "main", "--wait-for-network-idle", "https://example.com/docs/",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scrapeArgs() = %q\nwant %q", got, want)
+	}
+
+	tests := []struct {
+		name string
+		req  uiScrapeRequest
+		want string
+	}{
+		{"missing URL", uiScrapeRequest{OutputFormat: "json"}, "http or https URL"},
+		{"file URL", uiScrapeRequest{URL: "file:///etc/passwd", OutputFormat: "json"}, "http or https URL"},
+		{"format", uiScrapeRequest{URL: "https://example.com/", OutputFormat: "pdf"}, "unsupported output format"},
+		{"limit", uiScrapeRequest{URL: "https://example.com/", OutputFormat: "json", Limit: -1}, "must not be negative"},
+		{"browser", uiScrapeRequest{URL: "https://example.com/", OutputFormat: "json", Browser: "firefox"}, "unsupported browser"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.req.scrapeArgs("out"); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("scrapeArgs() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUIJobReadOutput(t *testing.T) {
+	job := &uiJob{status: uiJobStatus{ID: "1", State: "running"}}
+	var output strings.Builder
+	output.WriteString(`{"event":"start","time":"2024-01-01T00:00:00Z","url":"https://example.com/","processed":0,"saved":0,"queued":1}` + "\n")
+	for i := 0; i < uiLogLines+5; i++ {
+		output.WriteString("log line\n")
+	}
+	output.WriteString(`{"event":"page","time":"2024-01-01T00:00:01Z","url":"https://example.com/","processed":1,"saved":1,"queued":4}` + "\n")
+	output.WriteString(`{"event":"finish","time":"2024-01-01T00:00:02Z","processed":1,"saved":1,"queued":0,"stop_reason":"Completed"}` + "\n")
+	output.WriteString("last line\n")
+	job.readOutput(strings.NewReader(output.String()))
+
+	status := job.snapshot()
+	if status.Processed != 1 || status.Saved != 1 || status.Queued != 0 || status.StopReason != "Completed" {
+		t.Errorf("status = %+v", status)
+	}
+	if len(status.Log) != uiLogLines || status.Log[len(status.Log)-1] != "last line" {
+		t.Errorf("log has %d lines ending %q, want %d ending %q", len(status.Log), status.Log[len(status.Log)-1], uiLogLines, "last line")
+	}
+}
+
+func TestUIServerHandler(t *testing.T) {
+	server := httptest.NewServer(newUIServer("sitepanda", t.TempDir()).handler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET / = %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	post := func(path string, contentType string, origin string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path, strings.NewReader(`{"url":"https://example.com/","output_format":"json"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	if got := post("/api/jobs", "text/plain", ""); got != http.StatusUnsupportedMediaType {
+		t.Errorf("form post status = %d, want %d", got, http.StatusUnsupportedMediaType)
+	}
+	if got := post("/api/jobs", "application/json", "https://evil.example"); got != http.StatusForbidden {
+		t.Errorf("cross-origin post status = %d, want %d", got, http.StatusForbidden)
+	}
+	if got := post("/api/jobs/7/cancel", "application/json", server.URL); got != http.StatusNotFound {
+		t.Errorf("cancel unknown job status = %d, want %d", got, http.StatusNotFound)
+	}
+
+	res, err = http.Get(server.URL + "/api/jobs/7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("GET unknown job = %d, want %d", res.StatusCode, http.StatusNotFound)
+	}
+}
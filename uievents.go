@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// uiEventHistory is how many events a job keeps for clients that connect late or reconnect.
+const uiEventHistory = 1000
+
+// uiEventKeepAlive is how often an idle event stream sends a comment to keep proxies from
+// closing it.
+const uiEventKeepAlive = 15 * time.Second
+
+// uiEvent is a server-sent event of a job.
+type uiEvent struct {
+	ID   int
+	Name string
+	Data []byte
+}
+
+// uiEventName maps a --progress-format json event to the name of its server-sent event.
+func uiEventName(ev progressEvent) string {
+	switch {
+	case ev.Event == "start":
+		return "started"
+	case ev.Event != "page":
+		return ""
+	case ev.Decision == auditDecisionSaved:
+		return "page_saved"
+	case ev.Decision == auditDecisionFailed:
+		return "error"
+	default:
+		return "page_skipped"
+	}
+}
+
+// publishLocked adds an event to the job's stream. j.mu must be held.
+func (j *uiJob) publishLocked(name string, data []byte) {
+	j.eventBase = max(j.eventBase, 1)
+	j.events = append(j.events, uiEvent{ID: j.eventBase + len(j.events), Name: name, Data: data})
+	if len(j.events) > uiEventHistory {
+		drop := len(j.events) - uiEventHistory
+		j.events = j.events[drop:]
+		j.eventBase += drop
+	}
+	j.wakeLocked()
+}
+
+// wakeLocked tells the job's event streams that something changed. j.mu must be held.
+func (j *uiJob) wakeLocked() {
+	if j.changed != nil {
+		close(j.changed)
+	}
+	j.changed = make(chan struct{})
+}
+
+// eventsAfter returns the job's events with IDs above lastID, a channel closed on the next
+// change, the job's status and the ID its finished event gets.
+func (j *uiJob) eventsAfter(lastID int) ([]uiEvent, <-chan struct{}, uiJobStatus, int) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.changed == nil {
+		j.changed = make(chan struct{})
+	}
+	var events []uiEvent
+	for _, ev := range j.events {
+		if ev.ID > lastID {
+			events = append(events, ev)
+		}
+	}
+	status := j.status
+	status.Log = append([]string(nil), j.status.Log...)
+	return events, j.changed, status, max(j.eventBase, 1) + len(j.events)
+}
+
+// handleJobEvents streams a job's progress as server-sent events: started, page_saved,
+// page_skipped and error as the scrape reports them, then finished with the job's final
+// status. A Last-Event-ID header resumes after that event.
+func (s *uiServer) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	job := s.job(w, r)
+	if job == nil {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	lastID, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(uiEventKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, changed, status, finishedID := job.eventsAfter(lastID)
+		for _, ev := range events {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Name, ev.Data)
+			lastID = ev.ID
+		}
+		if status.State != "queued" && status.State != "running" {
+			data, _ := json.Marshal(status)
+			fmt.Fprintf(w, "id: %d\nevent: finished\ndata: %s\n\n", finishedID, data)
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+		select {
+		case <-changed:
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hokupod/sitepanda/client"
+)
+
+func TestUIEventName(t *testing.T) {
+	tests := []struct {
+		ev   progressEvent
+		want string
+	}{
+		{progressEvent{Event: "start"}, "started"},
+		{progressEvent{Event: "page", Decision: auditDecisionSaved}, "page_saved"},
+		{progressEvent{Event: "page", Decision: auditDecisionFailed}, "error"},
+		{progressEvent{Event: "page", Decision: auditDecisionSkipped}, "page_skipped"},
+		{progressEvent{Event: "finish"}, ""},
+	}
+	for _, tt := range tests {
+		if got := uiEventName(tt.ev); got != tt.want {
+			t.Errorf("uiEventName(%+v) = %q, want %q", tt.ev, got, tt.want)
+		}
+	}
+}
+
+func TestUIJobEvents(t *testing.T) {
+	ui := newUIServer("sitepanda", t.TempDir())
+	job := &uiJob{status: uiJobStatus{ID: "1", URL: "https://example.com/", State: "running"}}
+	ui.jobs["1"] = job
+	server := httptest.NewServer(ui.handler())
+	defer server.Close()
+
+	job.readOutput(strings.NewReader(strings.Join([]string{
+		`{"event":"start","url":"https://example.com/","processed":0,"saved":0,"queued":1}`,
+		`{"event":"page","url":"https://example.com/","decision":"saved","processed":1,"saved":1,"queued":1}`,
+		"a log line",
+	}, "\n")))
+
+	// The stream replays past events, then waits for new ones until the job finishes.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := client.NewClientWithResponses(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	var finished client.Job
+	err = c.Events(ctx, "1", 0, func(ev client.Event) bool {
+		got = append(got, ev.Name)
+		switch ev.Name {
+		case client.EventPageSaved:
+			go func() {
+				job.readOutput(strings.NewReader(`{"event":"page","url":"https://example.com/a","decision":"failed","processed":2,"saved":1,"queued":0}`))
+				job.mu.Lock()
+				job.status.State = "done"
+				job.wakeLocked()
+				job.mu.Unlock()
+			}()
+		case client.EventFinished:
+			if ev.ID != 4 {
+				t.Errorf("finished event ID = %d, want 4", ev.ID)
+			}
+			if err := json.Unmarshal(ev.Data, &finished); err != nil {
+				t.Errorf("finished event data %s: %v", ev.Data, err)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Events() error: %v", err)
+	}
+	if want := []string{"started", "page_saved", "error", "finished"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+	if finished.State != client.JobStateDone || finished.Processed != 2 || finished.Log == nil || (*finished.Log)[0] != "a log line" {
+		t.Errorf("finished event = %+v", finished)
+	}
+
+	// A reconnecting client gets the events after its Last-Event-ID.
+	got = nil
+	if err := c.Events(ctx, "1", 2, func(ev client.Event) bool {
+		got = append(got, ev.Name)
+		return true
+	}); err != nil {
+		t.Fatalf("Events() after 2 error: %v", err)
+	}
+	if want := []string{"error", "finished"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events after 2 = %v, want %v", got, want)
+	}
+}
+
+func TestUIJobEventsHistory(t *testing.T) {
+	job := &uiJob{}
+	for range uiEventHistory + 5 {
+		job.publishLocked("page_saved", []byte("{}"))
+	}
+	events, _, _, finishedID := job.eventsAfter(0)
+	if len(events) != uiEventHistory || events[0].ID != 6 || finishedID != uiEventHistory+6 {
+		t.Errorf("eventsAfter(0) = %d events from %d, finished ID %d", len(events), events[0].ID, finishedID)
+	}
+}
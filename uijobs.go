@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// uiJobRecord is a job as saved in the jobs directory, so that it outlives the server.
+type uiJobRecord struct {
+	Status  uiJobStatus `json:"status"`
+	Outfile string      `json:"outfile"`
+	Args    []string    `json:"args"`
+	// Owner is the name of the API key that started the job.
+	Owner    string `json:"owner,omitempty"`
+	Reserved int    `json:"reserved,omitempty"`
+}
+
+// jobsDir is where job records are saved.
+func (s *uiServer) jobsDir() string {
+	return filepath.Join(s.tmpDir, "jobs")
+}
+
+// saveJob writes job's record. A record that cannot be written is logged; the job goes on.
+func (s *uiServer) saveJob(job *uiJob) {
+	job.mu.Lock()
+	record := uiJobRecord{Status: job.status, Outfile: job.outfile, Args: job.args, Reserved: job.reserved}
+	if job.owner != nil {
+		record.Owner = job.owner.Name
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	job.mu.Unlock()
+	if err == nil {
+		err = os.MkdirAll(s.jobsDir(), 0700)
+	}
+	if err == nil {
+		file := filepath.Join(s.jobsDir(), record.Status.ID+".json")
+		// Writing a temporary file first keeps a crash from leaving half a record.
+		if err = os.WriteFile(file+".tmp", data, 0600); err == nil {
+			err = os.Rename(file+".tmp", file)
+		}
+	}
+	if err != nil {
+		logger.Printf("Warning: could not save job %s: %v", record.Status.ID, err)
+	}
+}
+
+// loadJobs reads the records an earlier server saved in the same directory. Jobs that were
+// queued or running when it stopped are queued again and restart from the beginning. Jobs
+// of API keys no longer in --api-keys are skipped.
+func (s *uiServer) loadJobs() error {
+	entries, err := os.ReadDir(s.jobsDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read saved jobs: %w", err)
+	}
+	var loaded []*uiJob
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		var record uiJobRecord
+		data, err := os.ReadFile(filepath.Join(s.jobsDir(), entry.Name()))
+		if err == nil {
+			err = json.Unmarshal(data, &record)
+		}
+		id, idErr := strconv.Atoi(record.Status.ID)
+		if err != nil || idErr != nil || record.Status.ID+".json" != entry.Name() {
+			logger.Printf("Warning: skipping unreadable job record %s", entry.Name())
+			continue
+		}
+		var owner *uiAPIKey
+		if s.keys != nil {
+			if owner = s.keys.byName(record.Owner); owner == nil {
+				continue
+			}
+		} else if record.Owner != "" {
+			continue
+		}
+		job := &uiJob{status: record.Status, outfile: record.Outfile, args: record.Args, owner: owner, reserved: record.Reserved}
+		switch job.status.State {
+		case "queued", "running":
+			job.status = uiJobStatus{ID: record.Status.ID, URL: record.Status.URL, State: "queued", Log: []string{"Restarted after the server stopped."}}
+			owner.countPages(job.reserved)
+		default:
+			owner.countPages(job.status.Saved)
+		}
+		s.nextID = max(s.nextID, id)
+		loaded = append(loaded, job)
+	}
+	sort.Slice(loaded, func(i, j int) bool {
+		a, _ := strconv.Atoi(loaded[i].status.ID)
+		b, _ := strconv.Atoi(loaded[j].status.ID)
+		return a < b
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range loaded {
+		s.jobs[job.status.ID] = job
+		s.order = append(s.order, job)
+	}
+	if len(loaded) > 0 {
+		queued := 0
+		for _, job := range loaded {
+			if job.status.State == "queued" {
+				queued++
+			}
+		}
+		logger.Printf("Loaded %d saved jobs; %d will run again.", len(loaded), queued)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUIJobStore(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := parseAPIKeys([]byte("keys:\n  - name: team-a\n    key: aaaaaaaaaaaaaaaaaaaa\n    max_pages: 100\n  - name: gone\n    key: bbbbbbbbbbbbbbbbbbbb\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := newUIServer("sitepanda", dir)
+	first.saveJob(&uiJob{status: uiJobStatus{ID: "1", URL: "https://example.com/", State: "done", Saved: 3}, outfile: "job-1.json", args: []string{"scrape"}, owner: keys.Keys[0], reserved: 10})
+	first.saveJob(&uiJob{status: uiJobStatus{ID: "2", URL: "https://example.com/docs/", State: "running", Processed: 4, Saved: 2}, outfile: "job-2.json", args: []string{"scrape", "--limit", "20"}, owner: keys.Keys[0], reserved: 20})
+	first.saveJob(&uiJob{status: uiJobStatus{ID: "3", State: "done"}, owner: keys.Keys[1]})
+	os.WriteFile(filepath.Join(first.jobsDir(), "broken.json"), []byte("{"), 0600)
+
+	// The restarted server no longer has the "gone" key.
+	keys.Keys = keys.Keys[:1]
+	second := newUIServer("sitepanda", dir)
+	second.keys = keys
+	if err := second.loadJobs(); err != nil {
+		t.Fatal(err)
+	}
+	if len(second.order) != 2 || second.order[0].status.ID != "1" || second.order[1].status.ID != "2" {
+		t.Fatalf("loaded jobs = %v", second.order)
+	}
+	if second.nextID != 2 {
+		t.Errorf("nextID = %d, want 2", second.nextID)
+	}
+	done, restarted := second.jobs["1"].snapshot(), second.jobs["2"].snapshot()
+	if done.State != "done" || done.Saved != 3 {
+		t.Errorf("finished job = %+v", done)
+	}
+	if restarted.State != "queued" || restarted.Saved != 0 || len(restarted.Log) != 1 {
+		t.Errorf("interrupted job = %+v, want it queued again", restarted)
+	}
+	if second.jobs["2"].owner != keys.Keys[0] || len(second.jobs["2"].args) != 3 {
+		t.Errorf("interrupted job owner %v, args %q", second.jobs["2"].owner, second.jobs["2"].args)
+	}
+	// The finished job's saved pages and the queued job's reservation count against the quota.
+	if got, err := keys.Keys[0].reservePages(0); err != nil || got != 100-3-20 {
+		t.Errorf("remaining quota = %d, %v; want %d", got, err, 100-3-20)
+	}
+}
+
+func TestUIJobQueue(t *testing.T) {
+	s := newUIServer(filepath.Join(t.TempDir(), "missing-sitepanda"), t.TempDir())
+	s.maxJobs = 1
+	key := &uiAPIKey{Name: "a", MaxPages: 10}
+	reserved, _ := key.reservePages(5)
+	job := &uiJob{status: uiJobStatus{ID: "1", State: "queued"}, args: []string{"scrape"}, owner: key, reserved: reserved}
+	s.jobs["1"], s.order = job, []*uiJob{job}
+
+	s.running = 1
+	s.startQueued()
+	if state := job.snapshot().State; state != "queued" {
+		t.Errorf("state with --max-jobs reached = %q, want queued", state)
+	}
+	s.running = 0
+	s.startQueued()
+	if state := job.snapshot().State; state != "failed" {
+		t.Errorf("state after failing to start = %q, want failed", state)
+	}
+	if got, _ := key.reservePages(0); got != 10 {
+		t.Errorf("quota after a failed start = %d, want the reservation released", got)
+	}
+}
+
+func TestUIJobResult(t *testing.T) {
+	dir := t.TempDir()
+	outfile := filepath.Join(dir, "job-1.jsonl")
+	if err := os.WriteFile(outfile, []byte(`{"title":"Home"}`+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s := newUIServer("sitepanda", dir)
+	s.jobs["1"] = &uiJob{status: uiJobStatus{ID: "1", State: "done"}, outfile: outfile}
+	s.jobs["2"] = &uiJob{status: uiJobStatus{ID: "2", State: "queued"}, outfile: filepath.Join(dir, "job-2.json")}
+	server := httptest.NewServer(s.handler())
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/api/jobs/1/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "application/x-ndjson" || string(body) != `{"title":"Home"}`+"\n" {
+		t.Errorf("GET result = %d %s %q", res.StatusCode, res.Header.Get("Content-Type"), body)
+	}
+	res, err = http.Get(server.URL + "/api/jobs/2/result")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("GET result of a queued job = %d, want %d", res.StatusCode, http.StatusConflict)
+	}
+}
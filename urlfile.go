@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// urlFileEntry is one URL from a --url-file, with its optional per-URL overrides.
+type urlFileEntry struct {
+	URL       string
+	Overrides URLOverrides
+}
+
+// URLOverrides replaces crawl settings for a single URL. Zero values keep the global setting.
+type URLOverrides struct {
+	// ContentSelector overrides --content-selector.
+	ContentSelector string
+	// WaitForNetworkIdle overrides --wait-for-network-idle when non-nil.
+	WaitForNetworkIdle *bool
+}
+
+// parseURLFile parses --url-file content. Each non-blank line holds a URL optionally followed by
+// key=value overrides, e.g. "https://example.com/post selector=.post wait=networkidle". A value
+// with spaces is quoted: selector="article .content". Lines starting with '#' and anything
+// after a whitespace-preceded '#' outside quotes are comments.
+func parseURLFile(content string) ([]urlFileEntry, error) {
+	var entries []urlFileEntry
+	for i, line := range strings.Split(content, "\n") {
+		lineNum := i + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		rawURL, rest := trimmed, ""
+		if i := strings.IndexAny(trimmed, " \t"); i >= 0 {
+			rawURL, rest = trimmed[:i], trimmed[i:]
+		}
+		fields, err := splitURLFileOverrides(rest)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		entry := urlFileEntry{URL: rawURL}
+		for _, field := range fields {
+			key, value, found := strings.Cut(field, "=")
+			if !found || value == "" {
+				return nil, fmt.Errorf("line %d: invalid override %q (expected key=value)", lineNum, field)
+			}
+			switch key {
+			case "selector":
+				entry.Overrides.ContentSelector = value
+			case "wait":
+				var networkIdle bool
+				switch value {
+				case "load":
+					networkIdle = false
+				case "networkidle":
+					networkIdle = true
+				default:
+					return nil, fmt.Errorf("line %d: invalid wait value %q (supported: load, networkidle)", lineNum, value)
+				}
+				entry.Overrides.WaitForNetworkIdle = &networkIdle
+			default:
+				return nil, fmt.Errorf("line %d: unknown override %q (supported: selector, wait)", lineNum, key)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// splitURLFileOverrides splits the overrides after a URL into key=value fields at whitespace.
+// Text in double or single quotes, e.g. selector="h1, h2", is taken as is, without its quotes.
+// A '#' starting a field outside quotes begins a comment that runs to the end of the line.
+func splitURLFileOverrides(s string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inField := false
+	var quote rune
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				field.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		case r == '#' && !inField:
+			return fields, nil
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in %q", quote, strings.TrimSpace(s))
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// stripURLFileComment removes a full-line '#' comment or a trailing comment introduced by " #".
+// A '#' directly attached to a URL is kept, as it is a fragment.
+func stripURLFileComment(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") {
+		return ""
+	}
+	for i := 1; i < len(trimmed); i++ {
+		if trimmed[i] == '#' && (trimmed[i-1] == ' ' || trimmed[i-1] == '\t') {
+			return trimmed[:i]
+		}
+	}
+	return trimmed
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseURLFile(t *testing.T) {
+	networkIdle := true
+	load := false
+
+	tests := []struct {
+		name      string
+		content   string
+		want      []urlFileEntry
+		wantError string
+	}{
+		{
+			name:    "plain URLs and blank lines",
+			content: "https://a.com/1\n\n  https://a.com/2  \n",
+			want:    []urlFileEntry{{URL: "https://a.com/1"}, {URL: "https://a.com/2"}},
+		},
+		{
+			name:    "comments",
+			content: "# batch 1\nhttps://a.com/1 # the landing page\n  # indented comment\nhttps://a.com/2#section\n",
+			want:    []urlFileEntry{{URL: "https://a.com/1"}, {URL: "https://a.com/2#section"}},
+		},
+		{
+			name:    "overrides",
+			content: "https://a.com/b selector=.post wait=networkidle\nhttps://a.com/c wait=load\n",
+			want: []urlFileEntry{
+				{URL: "https://a.com/b", Overrides: URLOverrides{ContentSelector: ".post", WaitForNetworkIdle: &networkIdle}},
+				{URL: "https://a.com/c", Overrides: URLOverrides{WaitForNetworkIdle: &load}},
+			},
+		},
+		{
+			name:    "quoted overrides with spaces",
+			content: "https://a.com/b selector=\"article .content\" wait=load # docs\nhttps://a.com/c selector='h1, h2'\nhttps://a.com/d selector=\"div #main\"\n",
+			want: []urlFileEntry{
+				{URL: "https://a.com/b", Overrides: URLOverrides{ContentSelector: "article .content", WaitForNetworkIdle: &load}},
+				{URL: "https://a.com/c", Overrides: URLOverrides{ContentSelector: "h1, h2"}},
+				{URL: "https://a.com/d", Overrides: URLOverrides{ContentSelector: "div #main"}},
+			},
+		},
+		{
+			name:      "unquoted selector with spaces",
+			content:   "https://a.com/b selector=h1, h2\n",
+			wantError: `line 1: invalid override "h2"`,
+		},
+		{
+			name:      "unterminated quote",
+			content:   "https://a.com/b selector=\"article .content\n",
+			wantError: `line 1: unterminated " quote`,
+		},
+		{
+			name:      "unknown override",
+			content:   "https://a.com/1\nhttps://a.com/b depth=2\n",
+			wantError: `line 2: unknown override "depth"`,
+		},
+		{
+			name:      "override without value",
+			content:   "https://a.com/b selector\n",
+			wantError: `line 1: invalid override "selector"`,
+		},
+		{
+			name:      "invalid wait value",
+			content:   "https://a.com/b wait=forever\n",
+			wantError: `line 1: invalid wait value "forever"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseURLFile(tt.content)
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("parseURLFile() error = %v, want containing %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseURLFile() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseURLFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
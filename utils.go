@@ -1,9 +1,12 @@
 package main
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -33,3 +36,51 @@ func truncateString(s string, maxLen int) string {
 	}
 	return string(runes[:maxLen])
 }
+
+// byteSizeUnits maps accepted size suffixes to their multipliers (binary units).
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TIB", 1 << 40}, {"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+	{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses human-readable sizes such as "10MB", "512k" or "2GiB".
+// Units are binary (1KB = 1024 bytes). A plain number is taken as bytes, and
+// an empty string or "0" yields 0, which callers treat as "no limit".
+func parseByteSize(s string) (int64, error) {
+	trimmed := strings.ToUpper(strings.TrimSpace(s))
+	if trimmed == "" {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(trimmed, unit.suffix) {
+			multiplier = unit.multiplier
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, unit.suffix))
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q (expected e.g. 512KB, 10MB, 2GB)", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// formatByteSize renders a byte count using the largest fitting binary unit.
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1fGB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
@@ -93,3 +93,56 @@ func TestLoggerExists(t *testing.T) {
 		t.Error("Expected logger to write some output")
 	}
 }
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"1024", 1024, false},
+		{"512B", 512, false},
+		{"10KB", 10 * 1024, false},
+		{"10kb", 10 * 1024, false},
+		{"10MB", 10 * 1024 * 1024, false},
+		{"1.5M", 1536 * 1024, false},
+		{"2GB", 2 << 30, false},
+		{"2GiB", 2 << 30, false},
+		{" 3 MB ", 3 << 20, false},
+		{"abc", 0, true},
+		{"-1MB", 0, true},
+		{"10XB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseByteSize(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseByteSize(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseByteSize(%q) = %d, want %d", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatByteSize(t *testing.T) {
+	tests := []struct {
+		input int64
+		want  string
+	}{
+		{0, "0B"},
+		{999, "999B"},
+		{2048, "2.0KB"},
+		{10 << 20, "10.0MB"},
+		{3 << 30, "3.0GB"},
+	}
+	for _, tt := range tests {
+		if got := formatByteSize(tt.input); got != tt.want {
+			t.Errorf("formatByteSize(%d) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
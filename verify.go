@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// verifyTitle and verifyMarker identify the bundled test page in the extracted output.
+const (
+	verifyTitle  = "Sitepanda Verification Page"
+	verifyMarker = "The quick brown panda verifies the installation"
+)
+
+// verifyPageHTML is the page 'sitepanda init --verify' crawls. The script-rendered paragraph
+// checks that the browser executes JavaScript.
+var verifyPageHTML = `<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>` + verifyTitle + `</title></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<article>
+<h1>` + verifyTitle + `</h1>
+<p>This page is served locally by sitepanda init --verify to check that the browser starts,
+loads a page and that the fetch and extraction pipeline produces Markdown.</p>
+<p>Readability needs a little text to recognise an article, so this paragraph is here to provide
+it. A broken installation usually fails long before this point, at browser startup.</p>
+<div id="rendered"></div>
+</article>
+<script>
+document.getElementById("rendered").innerHTML = "<p>` + verifyMarker + `.</p>";
+</script>
+</body>
+</html>`
+
+// serveVerifyPage serves verifyPageHTML on a loopback port and returns its URL and a function
+// stopping the server.
+func serveVerifyPage() (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start local test server: %w", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, verifyPageHTML)
+	})}
+	go server.Serve(listener)
+	return "http://" + listener.Addr().String() + "/", func() { server.Close() }, nil
+}
+
+// checkVerifyResult reports what is wrong with the page extracted from the test page, if anything.
+func checkVerifyResult(results []PageData) error {
+	if len(results) == 0 {
+		return errors.New("the test page was not saved")
+	}
+	page := results[0]
+	if page.Title != verifyTitle {
+		return fmt.Errorf("extracted title %q, want %q", page.Title, verifyTitle)
+	}
+	if !strings.Contains(page.Markdown, verifyMarker) {
+		return errors.New("the script-rendered paragraph is missing from the extracted Markdown; the browser did not run JavaScript")
+	}
+	return nil
+}
+
+// HandleInitVerify launches the installed browser, crawls a bundled local test page through the
+// full fetch and extraction pipeline and reports the result - exported version for cmd package
+func HandleInitVerify(browserName string) {
+	logger.Printf("Verifying the %s installation...", browserName)
+
+	pageURL, stopServer, err := serveVerifyPage()
+	if err != nil {
+		logger.Fatalf("Verification failed: %v", err)
+	}
+	defer stopServer()
+
+	playwrightDriverDir, err := GetAppSubdirectory("playwright_driver")
+	if err != nil {
+		logger.Fatalf("Verification failed: could not determine Playwright driver directory: %v", err)
+	}
+
+	startupBegin := time.Now()
+	browserExecutablePath, browserPrepareCleanup, err := prepareBrowser(browserName, playwrightDriverDir)
+	if err != nil {
+		logger.Fatalf("Verification failed: %v\nRun 'sitepanda init %s' to (re)install it.", err, browserName)
+	}
+	defer browserPrepareCleanup()
+
+	lightpandaCmd, wsURL, pwInstance, pwBrowser, _, _, err := launchBrowserAndGetConnection(browserName, browserExecutablePath, playwrightDriverDir, false, nil)
+	defer shutdownBrowser(browserName, pwBrowser, pwInstance, lightpandaCmd)
+	if err != nil {
+		logger.Fatalf("Verification failed: could not launch %s: %v\nRun 'sitepanda init %s' to (re)install it.", browserName, err, browserName)
+	}
+	startup := time.Since(startupBegin)
+	logger.Printf("Browser started in %s.", startup.Round(time.Millisecond))
+
+	var crawler *Crawler
+	if browserName == "lightpanda" {
+		crawler, err = NewCrawlerForLightpanda(pageURL, []string{pageURL}, true, wsURL, pwInstance, 0, nil, nil, "", os.DevNull, true, false, "jsonl", CrawlOptions{})
+	} else {
+		crawler, err = NewCrawlerForPlaywrightBrowser(pageURL, []string{pageURL}, true, pwBrowser, 0, nil, nil, "", os.DevNull, true, false, "jsonl", CrawlOptions{})
+	}
+	if err != nil {
+		logger.Fatalf("Verification failed: could not initialize crawler: %v", err)
+	}
+
+	crawlBegin := time.Now()
+	if _, err := crawler.Crawl(); err != nil {
+		logger.Fatalf("Verification failed: crawling the test page: %v", err)
+	}
+	if err := checkVerifyResult(crawler.results); err != nil {
+		logger.Fatalf("Verification failed: %v", err)
+	}
+	logger.Printf("Verification passed: %s started in %s and fetched and extracted the test page in %s.",
+		browserName, startup.Round(time.Millisecond), time.Since(crawlBegin).Round(time.Millisecond))
+}
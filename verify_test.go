@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCheckVerifyResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []PageData
+		wantErr string
+	}{
+		{"nothing saved", nil, "not saved"},
+		{"wrong title", []PageData{{Title: "Other", Markdown: verifyMarker}}, "extracted title"},
+		{"no JavaScript", []PageData{{Title: verifyTitle, Markdown: "static text"}}, "did not run JavaScript"},
+		{"ok", []PageData{{Title: verifyTitle, Markdown: "# Title\n\n" + verifyMarker + "."}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkVerifyResult(tt.results)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkVerifyResult() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkVerifyResult() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServeVerifyPage(t *testing.T) {
+	pageURL, stop, err := serveVerifyPage()
+	if err != nil {
+		t.Fatalf("serveVerifyPage() error = %v", err)
+	}
+	defer stop()
+
+	htmlContent, err := fetchPageHTMLOverHTTP(context.Background(), pageURL)
+	if err != nil {
+		t.Fatalf("fetching test page: %v", err)
+	}
+	pageData, err := processHTML(pageURL, htmlContent, "", "")
+	if err != nil {
+		t.Fatalf("processHTML() error = %v", err)
+	}
+	// Without a browser the script-rendered marker is missing, which the check must catch.
+	results := []PageData{*pageData}
+	if err := checkVerifyResult(results); err == nil || !strings.Contains(err.Error(), "JavaScript") {
+		t.Errorf("checkVerifyResult() on unrendered page error = %v, want JavaScript error", err)
+	}
+	results[0].Markdown += "\n\n" + verifyMarker + "."
+	if err := checkVerifyResult(results); err != nil {
+		t.Errorf("checkVerifyResult() on rendered page error = %v", err)
+	}
+}
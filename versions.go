@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// versionSegmentPattern matches a docs version path segment: v2, v1.4, 2.1, 3.0.x. A bare number
+// is not taken as a version, since /page/2 style paths are common.
+var versionSegmentPattern = regexp.MustCompile(`^(?i)(?:v(\d{1,3}(?:\.(?:\d+|x)){0,3})|(\d{1,3}(?:\.(?:\d+|x)){1,3}))$`)
+
+// versionAliases are path segments that name the newest docs version.
+var versionAliases = map[string]bool{"latest": true, "stable": true, "current": true}
+
+// versionSwitcherSelector finds the options of version switcher dropdowns, whose targets are
+// not <a> links.
+const versionSwitcherSelector = `select[class*="version" i] option[value], select[id*="version" i] option[value], select[name*="version" i] option[value]`
+
+// docVersion is a parsed docs version.
+type docVersion struct {
+	// alias is "latest", "stable" or "current" for an alias segment, "" for a numbered one.
+	alias string
+	// parts are the version numbers without trailing zeros and .x, e.g. [2 1] for v2.1.0.
+	parts []int
+}
+
+// parseVersionSegment parses a path segment as a docs version.
+func parseVersionSegment(segment string) (docVersion, bool) {
+	if lower := strings.ToLower(segment); versionAliases[lower] {
+		return docVersion{alias: lower}, true
+	}
+	m := versionSegmentPattern.FindStringSubmatch(segment)
+	if m == nil {
+		return docVersion{}, false
+	}
+	var v docVersion
+	for _, part := range strings.Split(m[1]+m[2], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			break // .x
+		}
+		v.parts = append(v.parts, n)
+	}
+	for len(v.parts) > 1 && v.parts[len(v.parts)-1] == 0 {
+		v.parts = v.parts[:len(v.parts)-1]
+	}
+	return v, true
+}
+
+func (v docVersion) String() string {
+	if v.alias != "" {
+		return v.alias
+	}
+	parts := make([]string, len(v.parts))
+	for i, n := range v.parts {
+		parts[i] = strconv.Itoa(n)
+	}
+	return "v" + strings.Join(parts, ".")
+}
+
+// compare orders versions from oldest to newest. Aliases are newer than any number.
+func (v docVersion) compare(other docVersion) int {
+	switch {
+	case v.alias != "" && other.alias != "":
+		return 0
+	case v.alias != "":
+		return 1
+	case other.alias != "":
+		return -1
+	}
+	return slices.Compare(v.parts, other.parts)
+}
+
+// equal reports whether v and other name the same version, e.g. v2 and 2.0.
+func (v docVersion) equal(other docVersion) bool {
+	return v.alias == other.alias && slices.Equal(v.parts, other.parts)
+}
+
+// urlVersion returns the first version segment of u's path, and the host and path before it,
+// which identify the docs tree it versions.
+func urlVersion(u *url.URL) (tree string, v docVersion, ok bool) {
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, segment := range segments {
+		if v, ok := parseVersionSegment(segment); ok {
+			return u.Host + "/" + strings.Join(segments[:i], "/"), v, true
+		}
+	}
+	return "", docVersion{}, false
+}
+
+// versionFilter keeps a crawl of versioned docs to one version (--latest-version-only) or to
+// chosen ones (--versions). URLs without a version segment are always crawled. A nil
+// *versionFilter crawls every version.
+type versionFilter struct {
+	// selected are the --versions; empty keeps only the newest version seen of each docs tree.
+	selected []docVersion
+	// newest maps each docs tree to the newest version linked from the pages crawled so far.
+	newest map[string]docVersion
+}
+
+// newVersionFilter returns the filter for --latest-version-only (latestOnly) or --versions, or
+// nil if neither is set.
+func newVersionFilter(latestOnly bool, versions []string) (*versionFilter, error) {
+	if len(versions) == 0 {
+		if !latestOnly {
+			return nil, nil
+		}
+		return &versionFilter{newest: make(map[string]docVersion)}, nil
+	}
+	f := &versionFilter{}
+	for _, value := range versions {
+		value = strings.TrimSpace(value)
+		v, ok := parseVersionSegment(value)
+		if !ok {
+			// --versions 2 is read as v2.
+			v, ok = parseVersionSegment("v" + strings.TrimPrefix(strings.ToLower(value), "v"))
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid --versions entry %q: want a version such as v2, 3.1 or latest", value)
+		}
+		f.selected = append(f.selected, v)
+	}
+	return f, nil
+}
+
+// observe records the versions that pageURL and the links and version switcher of its
+// document belong to, so older versions can be told apart before they are queued.
+func (f *versionFilter) observe(pageURL *url.URL, doc *goquery.Document) {
+	if f == nil || f.newest == nil {
+		return
+	}
+	f.observeURL(pageURL)
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		if u, err := pageURL.Parse(href); err == nil {
+			f.observeURL(u)
+		}
+	})
+	doc.Find(versionSwitcherSelector).Each(func(_ int, s *goquery.Selection) {
+		value, _ := s.Attr("value")
+		if u, err := pageURL.Parse(value); err == nil {
+			f.observeURL(u)
+		}
+	})
+}
+
+func (f *versionFilter) observeURL(u *url.URL) {
+	tree, v, ok := urlVersion(u)
+	if !ok {
+		return
+	}
+	if newest, seen := f.newest[tree]; !seen || v.compare(newest) > 0 {
+		f.newest[tree] = v
+	}
+}
+
+// skipReason returns why u is not crawled, or "" if it is.
+func (f *versionFilter) skipReason(u *url.URL) string {
+	if f == nil {
+		return ""
+	}
+	tree, v, ok := urlVersion(u)
+	if !ok {
+		return ""
+	}
+	if len(f.selected) > 0 {
+		if slices.ContainsFunc(f.selected, v.equal) {
+			return ""
+		}
+		return fmt.Sprintf("docs version %s is not in --versions", v)
+	}
+	if newest, seen := f.newest[tree]; seen && v.compare(newest) < 0 {
+		return fmt.Sprintf("docs version %s is older than %s", v, newest)
+	}
+	return ""
+}
+
+// versionSkipReason is versionFilter.skipReason for a dequeued URL. The start URL is always
+// crawled, as are explicitly listed URLs.
+func (c *Crawler) versionSkipReason(pageURL *url.URL, pageURLStr string) string {
+	if c.opts.Versions == nil || c.isURLListMode {
+		return ""
+	}
+	if start, err := normalizeURLtoString(c.startURL.String()); err == nil && start == pageURLStr {
+		return ""
+	}
+	return c.opts.Versions.skipReason(pageURL)
+}
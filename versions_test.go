@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseVersionSegment(t *testing.T) {
+	tests := []struct {
+		segment   string
+		want      string
+		isVersion bool
+	}{
+		{"v2", "v2", true},
+		{"V1.4", "v1.4", true},
+		{"2.1", "v2.1", true},
+		{"3.0.x", "v3", true},
+		{"v2.0.0", "v2", true},
+		{"latest", "latest", true},
+		{"Stable", "stable", true},
+		{"2", "", false},
+		{"2024", "", false},
+		{"video", "", false},
+		{"guide", "", false},
+	}
+	for _, tt := range tests {
+		v, ok := parseVersionSegment(tt.segment)
+		if ok != tt.isVersion || (ok && v.String() != tt.want) {
+			t.Errorf("parseVersionSegment(%q) = %s, %t; want %s, %t", tt.segment, v, ok, tt.want, tt.isVersion)
+		}
+	}
+}
+
+func TestVersionFilter(t *testing.T) {
+	parse := func(s string) *url.URL {
+		u, err := url.Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	latest, err := newVersionFilter(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seen := range []string{"https://example.com/docs/v1/intro", "https://example.com/docs/v3/intro", "https://example.com/docs/v2.5/intro", "https://example.com/api/1.0/ref"} {
+		latest.observeURL(parse(seen))
+	}
+	selected, err := newVersionFilter(false, []string{"v2", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		url          string
+		latestSkips  bool
+		selectsSkips bool
+	}{
+		{"https://example.com/docs/v3/guide", false, false},
+		{"https://example.com/docs/v1/guide", true, true},
+		{"https://example.com/docs/v2.5/guide", true, true},
+		{"https://example.com/docs/2.0/guide", true, false},
+		{"https://example.com/api/1.0/ref", false, true},
+		{"https://example.com/blog/post", false, false},
+	}
+	for _, tt := range tests {
+		u := parse(tt.url)
+		if got := latest.skipReason(u) != ""; got != tt.latestSkips {
+			t.Errorf("--latest-version-only skips %s = %t, want %t", tt.url, got, tt.latestSkips)
+		}
+		if got := selected.skipReason(u) != ""; got != tt.selectsSkips {
+			t.Errorf("--versions v2,3 skips %s = %t, want %t", tt.url, got, tt.selectsSkips)
+		}
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<select id="version-select"><option value="/docs/v4/">v4</option></select>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest.observe(parse("https://example.com/docs/v3/guide"), doc)
+	if latest.skipReason(parse("https://example.com/docs/v3/guide")) == "" {
+		t.Error("a version older than the one in the version switcher was kept")
+	}
+	latest.observeURL(parse("https://example.com/docs/latest/"))
+	if latest.skipReason(parse("https://example.com/docs/v4/guide")) == "" {
+		t.Error("a numbered version was kept next to latest")
+	}
+	if f, err := newVersionFilter(false, nil); f != nil || err != nil {
+		t.Errorf("newVersionFilter without flags = %v, %v; want nil", f, err)
+	}
+	if _, err := newVersionFilter(false, []string{"newest"}); err == nil {
+		t.Error("newVersionFilter accepted --versions newest")
+	}
+}
+
+func TestCrawlLatestVersionOnly(t *testing.T) {
+	pages := map[string]string{
+		"docs/index.html":    `<html><head><title>Docs</title></head><body><h1>Docs</h1><p>Pick a version.</p><a href="/docs/v1/">v1</a> <a href="/docs/v2/">v2</a></body></html>`,
+		"docs/v1/index.html": `<html><head><title>Docs v1</title></head><body><h1>Docs v1</h1><p>Old docs.</p></body></html>`,
+		"docs/v2/index.html": `<html><head><title>Docs v2</title></head><body><h1>Docs v2</h1><p>Current docs.</p><a href="/docs/v2/guide">Guide</a></body></html>`,
+		"docs/v2/guide.html": `<html><head><title>Guide v2</title></head><body><h1>Guide</h1><p>Start with the basics.</p></body></html>`,
+	}
+	versions, err := newVersionFilter(true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler, _, _ := crawlFixtures(t, pages, fixtureCrawl{start: "https://example.com/docs", opts: CrawlOptions{Versions: versions}})
+	var got []string
+	for _, page := range crawler.results {
+		got = append(got, page.URL)
+	}
+	slices.Sort(got)
+	want := []string{"https://example.com/docs", "https://example.com/docs/v2", "https://example.com/docs/v2/guide"}
+	if !slices.Equal(got, want) {
+		t.Errorf("saved %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// A --wasm-transform module runs in a fresh instance for every page, so pages cannot see each
+// other, with these limits. It gets no files, environment, network or real clock.
+const (
+	wasmTransformTimeout = 10 * time.Second
+	// wasmMemoryLimitPages caps the module's linear memory at 256MiB (64KiB pages).
+	wasmMemoryLimitPages = 4096
+	maxWASMOutputBytes   = 64 << 20
+)
+
+// wasmTransformInput is written to a --wasm-transform module's stdin: the page as it would be
+// saved, and the fetched HTML it was extracted from.
+type wasmTransformInput struct {
+	Page JSONOutputPage `json:"page"`
+	HTML string         `json:"html"`
+}
+
+// wasmTransform is a --wasm-transform module: a WASI command that reads a page as JSON on stdin
+// and writes a result in the format of the plugins' OnPage hook to stdout.
+type wasmTransform struct {
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	timeout  time.Duration
+}
+
+// loadWASMTransform compiles the module at path. A nil *wasmTransform transforms nothing.
+func loadWASMTransform(path string) (*wasmTransform, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read WASM module: %w", err)
+	}
+	return newWASMTransform(filepath.Base(path), code)
+}
+
+func newWASMTransform(name string, code []byte) (*wasmTransform, error) {
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(wasmMemoryLimitPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	compiled, err := runtime.CompileModule(ctx, code)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("invalid WASM module %s: %w", name, err)
+	}
+	if _, ok := compiled.ExportedFunctions()["_start"]; !ok {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("WASM module %s does not export _start; it must be built as a WASI command", name)
+	}
+	return &wasmTransform{name: name, runtime: runtime, compiled: compiled, timeout: wasmTransformTimeout}, nil
+}
+
+// run instantiates the module with input on its stdin and returns what it wrote to stdout.
+func (t *wasmTransform) run(input []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{max: maxWASMOutputBytes}
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(t.name).
+		WithStdin(bytes.NewReader(input)).
+		WithStdout(stdout).
+		WithStderr(newLinePrefixWriter(logger.Writer(), "[wasm-transform "+t.name+"] "))
+	mod, err := t.runtime.InstantiateModule(ctx, t.compiled, config)
+	if mod != nil {
+		mod.Close(ctx)
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("timed out after %s", t.timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stdout.overflow {
+		return nil, fmt.Errorf("wrote more than %s to stdout", formatByteSize(maxWASMOutputBytes))
+	}
+	return stdout.buf.Bytes(), nil
+}
+
+// Apply runs the module on a page before it is saved and applies its result like a plugin's
+// OnPage. It returns why the module dropped the page, or "". A failing module is logged and
+// leaves the page unchanged.
+func (t *wasmTransform) Apply(pd *PageData, html string) string {
+	if t == nil {
+		return ""
+	}
+	input, err := json.Marshal(wasmTransformInput{Page: newJSONOutputPage(pd), HTML: html})
+	if err != nil {
+		logger.Printf("Warning: failed to encode %s for WASM module %s: %v", pd.URL, t.name, err)
+		return ""
+	}
+	output, err := t.run(input)
+	if err != nil {
+		logger.Printf("Warning: WASM module %s failed on %s: %v", t.name, pd.URL, err)
+		return ""
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return ""
+	}
+	var res pluginPageResult
+	if err := json.Unmarshal(output, &res); err != nil {
+		logger.Printf("Warning: WASM module %s returned an invalid result for %s: %v", t.name, pd.URL, err)
+		return ""
+	}
+	if res.Drop {
+		if res.Reason == "" {
+			return fmt.Sprintf("dropped by WASM module %s", t.name)
+		}
+		return fmt.Sprintf("dropped by WASM module %s: %s", t.name, res.Reason)
+	}
+	res.apply(pd)
+	return ""
+}
+
+// Close releases the compiled module.
+func (t *wasmTransform) Close() {
+	if t != nil {
+		t.runtime.Close(context.Background())
+	}
+}
+
+// cappedBuffer collects up to max bytes and fails writes beyond that.
+type cappedBuffer struct {
+	buf      bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if b.buf.Len()+len(p) > b.max {
+		b.overflow = true
+		return 0, errors.New("output limit exceeded")
+	}
+	return b.buf.Write(p)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wasmSection encodes a WebAssembly section with its LEB128 size prefix.
+func wasmSection(id byte, content []byte) []byte {
+	return append(append([]byte{id}, wasmULEB(len(content))...), content...)
+}
+
+func wasmULEB(n int) []byte {
+	var out []byte
+	for {
+		b := byte(n & 0x7f)
+		n >>= 7
+		if n != 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			return out
+		}
+	}
+}
+
+func wasmName(s string) []byte {
+	return append(wasmULEB(len(s)), s...)
+}
+
+// wasmCommand assembles a WASI command whose _start runs body. imports are WASI functions with
+// fd_write's signature, numbered from 0, so _start is function len(imports). The module has
+// memoryPages of memory holding data at address 64 and an iovec pointing at it at address 16.
+func wasmCommand(imports []string, body []byte, data string, memoryPages int) []byte {
+	module := []byte{0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00}
+	// Type 0 is (i32, i32, i32, i32) -> i32, type 1 is () -> ().
+	module = append(module, wasmSection(1, []byte{0x02, 0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x01, 0x7f, 0x60, 0x00, 0x00})...)
+	imp := wasmULEB(len(imports))
+	for _, name := range imports {
+		imp = append(append(imp, wasmName("wasi_snapshot_preview1")...), wasmName(name)...)
+		imp = append(imp, 0x00, 0x00)
+	}
+	module = append(module, wasmSection(2, imp)...)
+	module = append(module, wasmSection(3, []byte{0x01, 0x01})...)
+	module = append(module, wasmSection(5, append([]byte{0x01, 0x00}, wasmULEB(memoryPages)...))...)
+	exp := append([]byte{0x02}, wasmName("memory")...)
+	exp = append(append(exp, 0x02, 0x00), wasmName("_start")...)
+	exp = append(append(exp, 0x00), wasmULEB(len(imports))...)
+	module = append(module, wasmSection(7, exp)...)
+	fn := append(append([]byte{0x00}, body...), 0x0b)
+	module = append(module, wasmSection(10, append(append([]byte{0x01}, wasmULEB(len(fn))...), fn...))...)
+	iovec := []byte{64, 0, 0, 0, byte(len(data)), byte(len(data) >> 8), 0, 0}
+	seg := append(append([]byte{0x02, 0x00, 0x41, 0x10, 0x0b}, wasmULEB(len(iovec))...), iovec...)
+	seg = append(append(seg, 0x00, 0x41, 0xc0, 0x00, 0x0b), wasmName(data)...)
+	return append(module, wasmSection(11, seg)...)
+}
+
+// wasmCall calls fn(fd, iovec at 16, 1, result at 8) and drops its errno.
+func wasmCall(fn, fd byte) []byte {
+	return []byte{0x41, fd, 0x41, 0x10, 0x41, 0x01, 0x41, 0x08, 0x10, fn, 0x1a}
+}
+
+// wasmWriteData is a module that writes data to stdout.
+func wasmWriteData(data string) []byte {
+	return wasmCommand([]string{"fd_write"}, wasmCall(0, 1), data, 1)
+}
+
+func TestWASMTransformApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		module      []byte
+		wantReason  string
+		wantTitle   string
+		wantContent string
+	}{
+		{
+			name:        "edits the page",
+			module:      wasmWriteData(`{"title":"Edited","content":"edited","extracted":{"n":1}}`),
+			wantTitle:   "Edited",
+			wantContent: "edited",
+		},
+		{
+			name:       "drops the page",
+			module:     wasmWriteData(`{"drop":true,"reason":"spam"}`),
+			wantReason: "dropped by WASM module test.wasm: spam",
+		},
+		{
+			name:        "no output leaves the page unchanged",
+			module:      wasmCommand(nil, nil, "", 1),
+			wantTitle:   "Example",
+			wantContent: "# Example",
+		},
+		{
+			name:        "a trap leaves the page unchanged",
+			module:      wasmCommand(nil, []byte{0x00}, "", 1),
+			wantTitle:   "Example",
+			wantContent: "# Example",
+		},
+		{
+			name:        "invalid output leaves the page unchanged",
+			module:      wasmWriteData("not json"),
+			wantTitle:   "Example",
+			wantContent: "# Example",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transform, err := newWASMTransform("test.wasm", tt.module)
+			if err != nil {
+				t.Fatalf("newWASMTransform() error = %v", err)
+			}
+			defer transform.Close()
+			pd := &PageData{URL: "https://example.com/", Title: "Example", Markdown: "# Example"}
+			if reason := transform.Apply(pd, "<html></html>"); reason != tt.wantReason {
+				t.Errorf("Apply() = %q, want %q", reason, tt.wantReason)
+			}
+			if tt.wantReason != "" {
+				return
+			}
+			if pd.Title != tt.wantTitle || pd.Markdown != tt.wantContent {
+				t.Errorf("Apply() page = %q %q, want %q %q", pd.Title, pd.Markdown, tt.wantTitle, tt.wantContent)
+			}
+		})
+	}
+
+	var transform *wasmTransform
+	if reason := transform.Apply(&PageData{}, ""); reason != "" {
+		t.Errorf("nil Apply() = %q", reason)
+	}
+}
+
+func TestWASMTransformRun(t *testing.T) {
+	// The echo module reads stdin into its buffer and writes it back, showing what it was given.
+	echo := []byte{0x41, 0x14, 0x41, 0x80, 0x80, 0x01, 0x36, 0x02, 0x00} // iovec length = 16384
+	echo = append(echo, wasmCall(0, 0)...)
+	echo = append(echo, 0x41, 0x14, 0x41, 0x08, 0x28, 0x02, 0x00, 0x36, 0x02, 0x00) // iovec length = bytes read
+	echo = append(echo, wasmCall(1, 1)...)
+	transform, err := newWASMTransform("echo.wasm", wasmCommand([]string{"fd_read", "fd_write"}, echo, "", 1))
+	if err != nil {
+		t.Fatalf("newWASMTransform() error = %v", err)
+	}
+	defer transform.Close()
+	pd := &PageData{URL: "https://example.com/", Title: "Example", Markdown: "# Example"}
+	input, _ := json.Marshal(wasmTransformInput{Page: newJSONOutputPage(pd), HTML: "<p>hi</p>"})
+	out, err := transform.run(input)
+	if err != nil {
+		t.Fatalf("run(echo) error = %v", err)
+	}
+	var got wasmTransformInput
+	if err := json.Unmarshal(out, &got); err != nil || got.Page.URL != pd.URL || got.Page.Content != pd.Markdown || got.HTML != "<p>hi</p>" {
+		t.Errorf("echo module read %q (%v), want the page and its HTML", out, err)
+	}
+
+	loop, err := newWASMTransform("loop.wasm", wasmCommand(nil, []byte{0x03, 0x40, 0x0c, 0x00, 0x0b}, "", 1))
+	if err != nil {
+		t.Fatalf("newWASMTransform() error = %v", err)
+	}
+	defer loop.Close()
+	loop.timeout = 100 * time.Millisecond
+	if _, err := loop.run(nil); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("run(loop) error = %v, want a timeout", err)
+	}
+}
+
+func TestNewWASMTransformRejects(t *testing.T) {
+	tests := map[string][]byte{
+		"not a module":    []byte("not wasm"),
+		"no _start":       {0x00, 'a', 's', 'm', 0x01, 0x00, 0x00, 0x00},
+		"too much memory": wasmCommand(nil, nil, "", wasmMemoryLimitPages+1),
+	}
+	for name, module := range tests {
+		if transform, err := newWASMTransform("test.wasm", module); err == nil {
+			transform.Close()
+			t.Errorf("newWASMTransform(%s) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// crawlWorker takes URLs from the queue and processes them one at a time in its own browser
+// page (--concurrency, --prefetch). Workers share the crawler's state under c.mu and release it
+// while they wait, fetch or extract, so that much of N pages' work runs in parallel.
+type crawlWorker struct {
+	// page is the worker's browser page; nil when pages are read from --fixture-dir.
+	page playwright.Page
+}
+
+// workerCount returns how many pages the crawl processes at once: --concurrency, and two with
+// --prefetch.
+func (c *Crawler) workerCount() int {
+	n := max(c.opts.Concurrency, 1)
+	if c.opts.Prefetch {
+		n = max(n, 2)
+	}
+	return n
+}
+
+// startWorkers sets up the crawl's workers. The first uses c.page; the others get a page of
+// their own. Browsers that cannot open another page (e.g. Lightpanda, which serves one page per
+// connection) run with fewer workers.
+func (c *Crawler) startWorkers() {
+	n := c.workerCount()
+	c.workers = []*crawlWorker{{page: c.page}}
+	for len(c.workers) < n {
+		if c.opts.Fixtures != nil {
+			c.workers = append(c.workers, &crawlWorker{})
+			continue
+		}
+		if c.pwContext == nil {
+			break
+		}
+		page, err := c.pwContext.NewPage()
+		if err != nil {
+			logger.Printf("Warning: could only open %d of %d pages for concurrent crawling: %v", len(c.workers), n, err)
+			break
+		}
+		c.opts.Bytes.watch(page)
+		c.workers = append(c.workers, &crawlWorker{page: page})
+	}
+	c.idle = sync.NewCond(&c.mu)
+}
+
+// runWorkers crawls queue with every worker on its own goroutine and returns once the queue is
+// empty or the crawl stopped.
+func (c *Crawler) runWorkers(queue Frontier, result *CrawlResult) {
+	var wg sync.WaitGroup
+	for _, w := range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.runWorker(w, queue, result)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Crawler) runWorker(w *crawlWorker, queue Frontier, result *CrawlResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		pageURL, ok := c.nextURL(queue, result)
+		if !ok {
+			return
+		}
+		stop := c.crawlURL(w, queue, pageURL, result)
+		c.inFlight--
+		if stop {
+			c.stopped = true
+		}
+		c.idle.Broadcast()
+	}
+}
+
+// nextURL takes the next URL from queue, waiting while the queue is empty or the --limit is
+// taken up by pages other workers are processing, as those may add links or not be saved. It
+// returns false once the crawl is over, setting result.StopReason if it ended early. c.mu must
+// be held.
+func (c *Crawler) nextURL(queue Frontier, result *CrawlResult) (string, bool) {
+	for {
+		if c.stopped || (queue.Len() == 0 && c.inFlight == 0) {
+			return "", false
+		}
+		if queue.Len() == 0 {
+			c.idle.Wait()
+			continue
+		}
+		if c.rootCtx.Err() != nil {
+			logger.Printf("Root context canceled. Stopping crawl. Error: %v", c.rootCtx.Err())
+			result.StopReason = "Cancelled by user"
+			return "", c.stopCrawl()
+		}
+		if c.pageLimit > 0 && len(c.opts.Domains) == 0 && c.savedCount() >= c.pageLimit {
+			logger.Printf("Page limit (%d) for saved content reached. Stopping crawl.", c.pageLimit)
+			result.StopReason = fmt.Sprintf("Page limit reached (%d)", c.pageLimit)
+			return "", c.stopCrawl()
+		}
+		if c.pageLimit > 0 && len(c.opts.Domains) == 0 && c.savedCount()+c.inFlight >= c.pageLimit {
+			c.idle.Wait()
+			continue
+		}
+		if c.opts.Bytes.exceeded() {
+			logger.Printf("Byte budget (%s) reached after downloading %s. Stopping crawl.", formatByteSize(c.opts.Bytes.limit), formatByteSize(c.opts.Bytes.Used()))
+			result.StopReason = fmt.Sprintf("Byte budget reached (%s)", formatByteSize(c.opts.Bytes.limit))
+			return "", c.stopCrawl()
+		}
+		pageURL, _ := queue.Pop()
+		c.queued = queue.Len()
+		c.inFlight++
+		return pageURL, true
+	}
+}
+
+// stopCrawl ends the crawl for every worker; those processing a page finish it first. It
+// returns false for nextURL.
+func (c *Crawler) stopCrawl() bool {
+	c.stopped = true
+	c.idle.Broadcast()
+	return false
+}
+
+// unlocked runs f without holding c.mu, so other workers go on while this one waits on the
+// network or extracts a page. f must not touch the crawler's shared state.
+func (c *Crawler) unlocked(f func()) {
+	c.mu.Unlock()
+	defer c.mu.Lock()
+	f()
+}
+
+// sleep waits for d without holding c.mu, returning early with false if the crawl is canceled.
+func (c *Crawler) sleep(d time.Duration) bool {
+	ok := false
+	c.unlocked(func() { ok = sleepContext(c.rootCtx, d) })
+	return ok
+}
+
+// saveLimitReason returns why a page is no longer saved after its fetch: other workers saved
+// pages of its host up to the --domains-file limit, or of its --policy rule up to max_pages,
+// while it was fetched. A crawl with one worker checks both limits before the fetch only.
+func (c *Crawler) saveLimitReason(u *url.URL, rule *policyRule) string {
+	if c.domainLimitReached(u.Hostname()) {
+		return fmt.Sprintf("page limit for %s reached", u.Hostname())
+	}
+	return c.policySkipReason(rule)
+}
+
+// closeWorkers closes the workers' pages, including c.page or the page that replaced it.
+func (c *Crawler) closeWorkers() {
+	for _, w := range c.workers {
+		if w.page != nil && !w.page.IsClosed() {
+			if err := w.page.Close(); err != nil {
+				logger.Printf("Error closing Playwright page: %v", err)
+			}
+		}
+	}
+	c.workers = nil
+}
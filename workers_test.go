@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWorkerCount(t *testing.T) {
+	tests := []struct {
+		concurrency int
+		prefetch    bool
+		want        int
+	}{
+		{0, false, 1},
+		{1, false, 1},
+		{1, true, 2},
+		{4, false, 4},
+		{4, true, 4},
+	}
+	for _, tt := range tests {
+		c := &Crawler{opts: CrawlOptions{Concurrency: tt.concurrency, Prefetch: tt.prefetch}}
+		if got := c.workerCount(); got != tt.want {
+			t.Errorf("workerCount(concurrency %d, prefetch %v) = %d, want %d", tt.concurrency, tt.prefetch, got, tt.want)
+		}
+	}
+}
+
+func TestFetchSkipReason(t *testing.T) {
+	policy, err := parsePolicy([]byte("rules:\n  - path: /private/**\n    action: skip\n  - path: /slow/**\n    delay: 1s\n  - path: /news/**\n    max_pages: 1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := newVersionFilter(false, []string{"v2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	excludeFollow, err := compileExcludePatterns("--exclude-follow", []string{"/drafts/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start, _ := url.Parse("https://example.com/")
+	c := &Crawler{
+		startURL: start,
+		opts: CrawlOptions{
+			Policy:        policy,
+			Versions:      versions,
+			ExcludeFollow: excludeFollow,
+			Domains:       []domainRoot{{URL: "https://full.example.com/", Host: "full.example.com", Limit: 2}},
+		},
+		deadHosts:   map[string]string{"down.example.com": "connection refused"},
+		fetched:     map[string]string{"https://example.com/moved": "https://example.com/old"},
+		policySaved: map[*policyRule]int{},
+		domainSaved: map[string]int{"full.example.com": 2},
+		robots: map[string]*robotsRules{
+			"https://example.com": parseRobots("User-agent: *\nDisallow: /admin/\n"),
+		},
+	}
+	news, _ := url.Parse("https://example.com/news/a")
+	c.policySaved[policy.Match(news)] = 1
+	for pageURL, want := range map[string]fetchSkipKind{
+		"https://example.com/docs/":         fetchSkipNone,
+		"https://example.com/slow/a":        fetchSkipNone,
+		"https://example.com/private/a":     fetchSkipPage,
+		"https://example.com/news/b":        fetchSkipPage,
+		"https://example.com/drafts/a":      fetchSkipPage,
+		"https://example.com/admin/users":   fetchSkipPage,
+		"https://example.com/docs/v1/intro": fetchSkipPage,
+		"https://example.com/docs/v2/intro": fetchSkipNone,
+		"https://example.com/moved":         fetchSkipQuiet,
+		"https://full.example.com/docs/":    fetchSkipQuiet,
+		"https://down.example.com/docs/":    fetchSkipShortcut,
+	} {
+		u, _ := url.Parse(pageURL)
+		if reason, got := c.fetchSkipReason(u, pageURL, policy.Match(u)); got != want {
+			t.Errorf("fetchSkipReason(%s) = %q, %d; want kind %d", pageURL, reason, got, want)
+		}
+	}
+}
+
+func TestCrawlConcurrency(t *testing.T) {
+	pages := map[string]string{}
+	var links []string
+	for i := range 12 {
+		section := "guide"
+		if i%3 == 0 {
+			section = "news"
+		}
+		links = append(links, fmt.Sprintf(`<a href="/%s/%d">Page %d</a>`, section, i, i))
+		pages[fmt.Sprintf("%s/%d.html", section, i)] = fmt.Sprintf(`<html><head><title>Page %d</title></head><body><h1>Page %d</h1><p>Body of page %d.</p><a href="/">Home</a></body></html>`, i, i, i)
+	}
+	pages["index.html"] = `<html><head><title>Home</title></head><body><h1>Home</h1><p>All pages.</p>` + strings.Join(links, " ") + `</body></html>`
+
+	saved := func(crawler *Crawler) []string {
+		var got []string
+		for _, page := range crawler.results {
+			got = append(got, page.URL)
+		}
+		slices.Sort(got)
+		if len(slices.Compact(slices.Clone(got))) != len(got) {
+			t.Errorf("a page was saved twice: %v", got)
+		}
+		return got
+	}
+
+	crawler, result, _ := crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{Concurrency: 4}})
+	if len(crawler.workers) != 0 || result.PagesSaved != 13 || len(saved(crawler)) != 13 {
+		t.Errorf("saved %v (%d pages), want all 13", saved(crawler), result.PagesSaved)
+	}
+
+	crawler, result, _ = crawlFixtures(t, pages, fixtureCrawl{limit: 5, opts: CrawlOptions{Concurrency: 4}})
+	if got := saved(crawler); len(got) != 5 || result.StopReason != "Page limit reached (5)" {
+		t.Errorf("with --limit 5 saved %v, stop reason %q", got, result.StopReason)
+	}
+
+	policy, err := parsePolicy([]byte("rules:\n  - path: /news/**\n    max_pages: 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawler, _, _ = crawlFixtures(t, pages, fixtureCrawl{opts: CrawlOptions{Concurrency: 4, Policy: policy}})
+	var news int
+	for _, pageURL := range saved(crawler) {
+		if strings.Contains(pageURL, "/news/") {
+			news++
+		}
+	}
+	if news != 2 {
+		t.Errorf("saved %d pages under a max_pages 2 rule, want 2", news)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// wrapTemplatePlaceholder matches "{name}" placeholders in --wrap-template.
+var wrapTemplatePlaceholder = regexp.MustCompile(`\{([a-z]+)\}`)
+
+// wrapTruncationMarker ends the content of the page cut to fit --max-total-tokens.
+const wrapTruncationMarker = "\n\n[truncated]"
+
+// parseWrapTemplate turns the \n, \t and \\ escapes of a --wrap-template value into the
+// characters they stand for, so templates can be written on one shell line, and checks its
+// placeholders.
+func parseWrapTemplate(tmpl string) (string, error) {
+	unescaped := strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t").Replace(tmpl)
+	for _, m := range wrapTemplatePlaceholder.FindAllStringSubmatch(unescaped, -1) {
+		switch m[1] {
+		case "title", "url", "content", "section", "published":
+		default:
+			return "", fmt.Errorf("unknown --wrap-template placeholder %s (supported: {title}, {url}, {content}, {section}, {published})", m[0])
+		}
+	}
+	return unescaped, nil
+}
+
+// renderWrapTemplate fills tmpl for pd, with content in place of {content}.
+func renderWrapTemplate(tmpl string, pd *PageData, content string) string {
+	return wrapTemplatePlaceholder.ReplaceAllStringFunc(tmpl, func(m string) string {
+		switch m {
+		case "{title}":
+			return pd.Title
+		case "{url}":
+			return pd.URL
+		case "{content}":
+			return content
+		case "{section}":
+			return pd.Section
+		case "{published}":
+			return formatPublishedDate(pd.Published)
+		}
+		return m
+	})
+}
+
+// formatResultsWithTemplate concatenates results rendered with tmpl. With maxTokens > 0 pages
+// are added in crawl order while their estimated tokens fit the budget; the first page that
+// does not fit is cut to the remaining budget and the rest are left out. It returns the text
+// and how many pages it includes, counting a cut page.
+func formatResultsWithTemplate(tmpl string, results []PageData, maxTokens int64) ([]byte, int) {
+	var b strings.Builder
+	var used int64
+	for i := range results {
+		pd := &results[i]
+		entry := renderWrapTemplate(tmpl, pd, pd.Markdown)
+		tokens := estimateTokens(entry)
+		if maxTokens <= 0 || used+tokens <= maxTokens {
+			b.WriteString(entry)
+			used += tokens
+			continue
+		}
+
+		// Rendered pieces are estimated separately, which never undercounts their concatenation.
+		remaining := maxTokens - used
+		overhead := estimateTokens(renderWrapTemplate(tmpl, pd, ""))
+		contentRunes := int((remaining-overhead)*4) - utf8.RuneCountInString(wrapTruncationMarker)
+		if overhead >= remaining || contentRunes <= 0 {
+			return []byte(b.String()), i
+		}
+		content := pd.Markdown
+		for n, cut := 0, 0; cut < len(content); n++ {
+			if n == contentRunes {
+				content = content[:cut]
+				break
+			}
+			_, size := utf8.DecodeRuneInString(content[cut:])
+			cut += size
+		}
+		b.WriteString(renderWrapTemplate(tmpl, pd, content+wrapTruncationMarker))
+		return []byte(b.String()), i + 1
+	}
+	return []byte(b.String()), len(results)
+}
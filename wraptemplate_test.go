@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWrapTemplate(t *testing.T) {
+	tests := []struct {
+		tmpl    string
+		want    string
+		wantErr bool
+	}{
+		{tmpl: `### {title}\nURL: {url}\n\n{content}\n\n---\n`, want: "### {title}\nURL: {url}\n\n{content}\n\n---\n"},
+		{tmpl: `{section}\t{published}`, want: "{section}\t{published}"},
+		{tmpl: `C:\\new {content}`, want: `C:\new {content}`},
+		{tmpl: "{body}", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWrapTemplate(tt.tmpl)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWrapTemplate(%q) error = %v, wantErr %v", tt.tmpl, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseWrapTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+		}
+	}
+}
+
+func TestFormatResultsWithTemplate(t *testing.T) {
+	tmpl := "### {title}\nURL: {url}\n{published}\n{content}\n---\n"
+	results := []PageData{
+		{Title: "One", URL: "https://example.com/1", Markdown: strings.Repeat("a", 40), Published: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{Title: "Two", URL: "https://example.com/2", Markdown: "Literal {url} in {content}"},
+		{Title: "Three", URL: "https://example.com/3", Markdown: strings.Repeat("c", 400)},
+	}
+
+	out, included := formatResultsWithTemplate(tmpl, results, 0)
+	if included != 3 {
+		t.Errorf("included = %d, want 3", included)
+	}
+	if !strings.HasPrefix(string(out), "### One\nURL: https://example.com/1\n2024-03-01\naaaa") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	if !strings.Contains(string(out), "Literal {url} in {content}\n---\n### Three") {
+		t.Errorf("page content must not be expanded as a template:\n%s", out)
+	}
+
+	for _, budget := range []int64{30, 40, 60, 100} {
+		out, included := formatResultsWithTemplate(tmpl, results, budget)
+		if got := estimateTokens(string(out)); got > budget {
+			t.Errorf("budget %d: output has %d tokens", budget, got)
+		}
+		if included < 1 || included > 3 {
+			t.Errorf("budget %d: included = %d", budget, included)
+		}
+	}
+
+	out, included = formatResultsWithTemplate(tmpl, results, 60)
+	if included != 3 || !strings.HasSuffix(string(out), "c"+wrapTruncationMarker+"\n---\n") {
+		t.Errorf("budget 60: want the third page cut to fit, got %d pages:\n%s", included, out)
+	}
+
+	if out, included := formatResultsWithTemplate(tmpl, results, 5); included != 0 || len(out) != 0 {
+		t.Errorf("budget 5: want no pages, got %d: %q", included, out)
+	}
+}